@@ -18,6 +18,7 @@
 package affinity
 
 import (
+	"errors"
 	"math"
 	"strings"
 	"sync"
@@ -68,10 +69,14 @@ func (s *ServiceAffinityRoute) Notify(invokers []protocol.Invoker) {
 	key := strings.Join([]string{url.ColonSeparatedKey(), constant.AffinityRuleSuffix}, "")
 	dynamicConfiguration.AddListener(key, s)
 	value, err := dynamicConfiguration.GetRule(key)
-	if err != nil {
+	if err != nil && !errors.Is(err, config_center.ErrKeyNotFound) {
 		logger.Errorf("Failed to query affinity rule, key=%s, err=%v", key, err)
 		return
 	}
+	if value == "" {
+		logger.Infof("Affinity rule is empty, key=%s", key)
+		return
+	}
 
 	s.Process(&config_center.ConfigChangeEvent{Key: key, Value: value, ConfigType: remoting.EventTypeAdd})
 }
@@ -126,10 +131,14 @@ func (s *ApplicationAffinityRoute) Notify(invokers []protocol.Invoker) {
 		key := strings.Join([]string{providerApplication, constant.AffinityRuleSuffix}, "")
 		dynamicConfiguration.AddListener(key, s)
 		value, err := dynamicConfiguration.GetRule(key)
-		if err != nil {
+		if err != nil && !errors.Is(err, config_center.ErrKeyNotFound) {
 			logger.Errorf("Failed to query affinity rule, key=%s, err=%v", key, err)
 			return
 		}
+		if value == "" {
+			logger.Infof("Affinity rule is empty, key=%s", key)
+			return
+		}
 
 		s.Process(&config_center.ConfigChangeEvent{Key: key, Value: value, ConfigType: remoting.EventTypeUpdate})
 	}
@@ -18,6 +18,7 @@
 package condition
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -304,7 +305,7 @@ func (s *ServiceRouter) Notify(invokers []protocol.Invoker) {
 	key := strings.Join([]string{url.ColonSeparatedKey(), constant.ConditionRouterRuleSuffix}, "")
 	dynamicConfiguration.AddListener(key, s)
 	value, err := dynamicConfiguration.GetRule(key)
-	if err != nil {
+	if err != nil && !errors.Is(err, config_center.ErrKeyNotFound) {
 		logger.Errorf("Failed to query condition rule, key=%s, err=%v", key, err)
 		return
 	}
@@ -370,7 +371,7 @@ func (a *ApplicationRouter) Notify(invokers []protocol.Invoker) {
 		dynamicConfiguration.AddListener(key, a)
 		a.application = providerApplication
 		value, err := dynamicConfiguration.GetRule(key)
-		if err != nil {
+		if err != nil && !errors.Is(err, config_center.ErrKeyNotFound) {
 			logger.Errorf("Failed to query condition rule, key=%s, err=%v", key, err)
 			return
 		}
@@ -18,6 +18,7 @@
 package script
 
 import (
+	"errors"
 	"strings"
 	"sync"
 )
@@ -212,8 +213,13 @@ func (s *ScriptRouter) Notify(invokers []protocol.Invoker) {
 		dynamicConfiguration.AddListener(listenTarget, s)
 		s.applicationName = providerApplication
 		value, err = dynamicConfiguration.GetRule(listenTarget)
-		if err != nil {
+		if err != nil && !errors.Is(err, config_center.ErrKeyNotFound) {
 			logger.Errorf("Failed to query Script rule, applicationName=%s, listening=%s, err=%v", s.applicationName, listenTarget, err)
+			return
+		}
+		if value == "" {
+			logger.Infof("Script rule is empty, applicationName=%s, listening=%s", s.applicationName, listenTarget)
+			return
 		}
 		s.Process(&config_center.ConfigChangeEvent{Key: listenTarget, Value: value, ConfigType: remoting.EventTypeUpdate})
 	}
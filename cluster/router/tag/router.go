@@ -18,6 +18,7 @@
 package tag
 
 import (
+	"errors"
 	"strings"
 	"sync"
 )
@@ -90,7 +91,7 @@ func (p *PriorityRouter) Notify(invokers []protocol.Invoker) {
 	key := strings.Join([]string{application, constant.TagRouterRuleSuffix}, "")
 	dynamicConfiguration.AddListener(key, p)
 	value, err := dynamicConfiguration.GetRule(key)
-	if err != nil {
+	if err != nil && !errors.Is(err, config_center.ErrKeyNotFound) {
 		logger.Errorf("query router rule fail,key=%s,err=%v", key, err)
 		return
 	}
@@ -227,6 +227,17 @@ const (
 	ConfigSecretKey           = "config-center.secret"
 	ConfigBackupConfigKey     = "config-center.isBackupConfig"
 	ConfigBackupConfigPathKey = "config-center.backupConfigPath"
+	ConfigObserverKey         = "config-center.observer"
+	ConfigParseCacheSizeKey   = "config-center.parse-cache-size"
+	ConfigTLSConfigKey        = "config-center.tls-config"
+	ConfigTLSCACertFileKey    = "config-center.tls.ca-cert-file"
+	ConfigTLSCertFileKey      = "config-center.tls.cert-file"
+	ConfigTLSKeyFileKey       = "config-center.tls.key-file"
+	ConfigTLSServerNameKey    = "config-center.tls.server-name"
+	ConfigKeyPrefixKey        = "config-center.key-prefix"
+	ConfigConnectTimeoutKey   = "config-center.connect-timeout"
+	ConfigReadTimeoutKey      = "config-center.read-timeout"
+	ConfigMaxPendingReadsKey  = "config-center.max-pending-reads"
 )
 
 const (
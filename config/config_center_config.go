@@ -145,7 +145,7 @@ func startConfigCenter(rc *RootConfig) error {
 	}
 
 	strConf, err := dynamicConfig.GetProperties(cc.DataId, config_center.WithGroup(cc.Group))
-	if err != nil {
+	if err != nil && !errors.Is(err, config_center.ErrKeyNotFound) {
 		logger.Warnf("[Config Center] Dynamic config center has started, but config may not be initialized, because: %s", err)
 		return nil
 	}
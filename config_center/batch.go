@@ -0,0 +1,86 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// DefaultMaxConcurrency is the number of parallel reads used by GetPropertiesBatch when
+// Options.MaxConcurrency is not set.
+const DefaultMaxConcurrency = 8
+
+// ConfigurationBatchReader is implemented by the config centers able to read several keys at once, e.g.
+// with a native multi-get. The others are read key by key by GetPropertiesBatch.
+type ConfigurationBatchReader interface {
+	// GetPropertiesBatch get properties of several keys at once, the values of the keys read
+	// successfully are returned along with the aggregated error of the failed ones
+	GetPropertiesBatch(keys []string, opts ...Option) (map[string]string, error)
+}
+
+// GetPropertiesBatch reads keys from dc, using its ConfigurationBatchReader implementation if any, or else
+// its GetProperties in parallel, running at most Options.MaxConcurrency reads at the same time.
+//
+// The values of the keys read successfully are always returned, and the failures of the others are
+// joined into the returned error.
+func GetPropertiesBatch(dc DynamicConfiguration, keys []string, opts ...Option) (map[string]string, error) {
+	if r, ok := dc.(ConfigurationBatchReader); ok {
+		return r.GetPropertiesBatch(keys, opts...)
+	}
+	return fanOutGetProperties(dc.GetProperties, keys, opts...)
+}
+
+func fanOutGetProperties(get func(string, ...Option) (string, error), keys []string, opts ...Option) (map[string]string, error) {
+	concurrency := NewOptions(opts...).MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = DefaultMaxConcurrency
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		result = make(map[string]string, len(keys))
+		errs   []error
+		sem    = make(chan struct{}, concurrency)
+	)
+	for _, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			value, err := get(key, opts...)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, perrors.WithMessagef(err, "get properties of key %s", key))
+				return
+			}
+			result[key] = value
+		}(key)
+	}
+	wg.Wait()
+	return result, errors.Join(errs...)
+}
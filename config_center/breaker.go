@@ -0,0 +1,359 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+import (
+	gxset "github.com/dubbogo/gost/container/set"
+	"github.com/dubbogo/gost/log/logger"
+
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+)
+
+const (
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerWindow           = 10 * time.Second
+	DefaultBreakerOpenTimeout      = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned by CircuitBreakerConfiguration for the reads short-circuited while the
+// circuit is open, unless a value of the key was cached
+var ErrCircuitOpen = perrors.New("config center circuit breaker is open")
+
+// BreakerState is the state of the circuit of a CircuitBreakerConfiguration
+type BreakerState int
+
+const (
+	// BreakerClosed means the reads go to the config center
+	BreakerClosed BreakerState = iota
+	// BreakerOpen means the reads are short-circuited
+	BreakerOpen
+	// BreakerHalfOpen means a read is probing the config center, the others being short-circuited
+	BreakerHalfOpen
+)
+
+var breakerStateStrings = [...]string{
+	"closed",
+	"open",
+	"half-open",
+}
+
+func (s BreakerState) String() string {
+	if s < 0 || int(s) >= len(breakerStateStrings) {
+		return "unknown"
+	}
+	return breakerStateStrings[s]
+}
+
+// BreakerOption configures a CircuitBreakerConfiguration
+type BreakerOption func(*CircuitBreakerConfiguration)
+
+// WithBreakerFailureThreshold sets the number of consecutive failures within the window opening the circuit
+func WithBreakerFailureThreshold(n int) BreakerOption {
+	return func(b *CircuitBreakerConfiguration) {
+		b.failureThreshold = n
+	}
+}
+
+// WithBreakerWindow sets the window the consecutive failures must happen within to open the circuit
+func WithBreakerWindow(d time.Duration) BreakerOption {
+	return func(b *CircuitBreakerConfiguration) {
+		b.window = d
+	}
+}
+
+// WithBreakerOpenTimeout sets how long the circuit stays open before a read probes the config center
+func WithBreakerOpenTimeout(d time.Duration) BreakerOption {
+	return func(b *CircuitBreakerConfiguration) {
+		b.openTimeout = d
+	}
+}
+
+// WithBreakerPredicate sets the predicate telling the failures, which count towards opening the circuit,
+// from the errors which don't, like a missing key. IsTransientError is used by default.
+func WithBreakerPredicate(isFailure func(error) bool) BreakerOption {
+	return func(b *CircuitBreakerConfiguration) {
+		b.isFailure = isFailure
+	}
+}
+
+type breakerCacheKey struct {
+	method string
+	key    string
+	group  string
+}
+
+// CircuitBreakerConfiguration stops calling the wrapped DynamicConfiguration once its reads keep failing,
+// so that an unreachable config center doesn't make every read wait for the timeout. After the failure
+// threshold is reached within the window, the circuit opens and the reads return the last value read of
+// the key, or ErrCircuitOpen if none. Once the open timeout elapses, the circuit is half-open: a single
+// read probes the config center and closes the circuit if it succeeds, or opens it again if it fails.
+// The listeners and the writes are forwarded as is.
+type CircuitBreakerConfiguration struct {
+	dc               DynamicConfiguration
+	failureThreshold int
+	window           time.Duration
+	openTimeout      time.Duration
+	isFailure        func(error) bool
+	// now is replaced by the tests to move the clock
+	now func() time.Time
+
+	mu           sync.Mutex
+	state        BreakerState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	probing      bool
+	values       map[breakerCacheKey]string
+}
+
+// NewCircuitBreakerConfiguration returns dc behind a circuit breaker
+func NewCircuitBreakerConfiguration(dc DynamicConfiguration, opts ...BreakerOption) *CircuitBreakerConfiguration {
+	b := &CircuitBreakerConfiguration{
+		dc:               dc,
+		failureThreshold: DefaultBreakerFailureThreshold,
+		window:           DefaultBreakerWindow,
+		openTimeout:      DefaultBreakerOpenTimeout,
+		isFailure:        IsTransientError,
+		now:              time.Now,
+		values:           make(map[breakerCacheKey]string),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if b.failureThreshold < 1 {
+		b.failureThreshold = 1
+	}
+	return b
+}
+
+// Unwrap returns the wrapped DynamicConfiguration
+func (b *CircuitBreakerConfiguration) Unwrap() DynamicConfiguration {
+	return b.dc
+}
+
+// State returns the current state of the circuit
+func (b *CircuitBreakerConfiguration) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && !b.probing && b.now().Sub(b.openedAt) >= b.openTimeout {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow tells whether a read may go to the config center, turning the circuit half-open and the read into
+// the probe once the open timeout elapsed
+func (b *CircuitBreakerConfiguration) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	default:
+		return false
+	}
+}
+
+// record updates the circuit with the result of a read allowed by allow
+func (b *CircuitBreakerConfiguration) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	failed := err != nil && b.isFailure(err)
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+		if failed {
+			b.open()
+			return
+		}
+		logger.Infof("[Config Center] circuit breaker closed, the config center is reachable again")
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	if !failed {
+		b.failures = 0
+		return
+	}
+	now := b.now()
+	if b.failures == 0 || now.Sub(b.firstFailure) > b.window {
+		b.failures = 0
+		b.firstFailure = now
+	}
+	b.failures++
+	if b.failures >= b.failureThreshold {
+		b.open()
+	}
+}
+
+// open opens the circuit, mu must be held
+func (b *CircuitBreakerConfiguration) open() {
+	if b.state != BreakerOpen {
+		logger.Warnf("[Config Center] circuit breaker opened after %d failures, short-circuiting the reads for %v",
+			b.failures, b.openTimeout)
+	}
+	b.state = BreakerOpen
+	b.openedAt = b.now()
+	b.failures = 0
+}
+
+// read calls read if the circuit allows it, caching its value, or else returns the cached value
+func (b *CircuitBreakerConfiguration) read(method, key string, opts []Option, read func() (string, error)) (string, error) {
+	ck := breakerCacheKey{method: method, key: key, group: NewOptions(opts...).Center.Group}
+	if !b.allow() {
+		b.mu.Lock()
+		value, ok := b.values[ck]
+		b.mu.Unlock()
+		if ok {
+			return value, nil
+		}
+		return "", ErrCircuitOpen
+	}
+	value, err := read()
+	b.record(err)
+	if err == nil {
+		b.mu.Lock()
+		b.values[ck] = value
+		b.mu.Unlock()
+	}
+	return value, err
+}
+
+func (b *CircuitBreakerConfiguration) Parser() parser.ConfigurationParser {
+	return b.dc.Parser()
+}
+
+func (b *CircuitBreakerConfiguration) SetParser(p parser.ConfigurationParser) {
+	b.dc.SetParser(p)
+}
+
+func (b *CircuitBreakerConfiguration) RegisterParserForPattern(glob string, p parser.ConfigurationParser) error {
+	return RegisterParserForPattern(b.dc, glob, p)
+}
+
+func (b *CircuitBreakerConfiguration) ParserFor(key string) parser.ConfigurationParser {
+	return ParserFor(b.dc, key)
+}
+
+func (b *CircuitBreakerConfiguration) AddListener(key string, listener ConfigurationListener, opts ...Option) {
+	b.dc.AddListener(key, listener, opts...)
+}
+
+func (b *CircuitBreakerConfiguration) RemoveListener(key string, listener ConfigurationListener, opts ...Option) {
+	b.dc.RemoveListener(key, listener, opts...)
+}
+
+func (b *CircuitBreakerConfiguration) AddGroupListener(group string, listener ConfigurationListener, opts ...Option) {
+	if err := AddGroupListener(b.dc, group, listener, opts...); err != nil {
+		logger.Warnf("[Config Center] can not listen group %s: %v", group, err)
+	}
+}
+
+func (b *CircuitBreakerConfiguration) RemoveGroupListener(group string, listener ConfigurationListener, opts ...Option) {
+	_ = RemoveGroupListener(b.dc, group, listener, opts...)
+}
+
+func (b *CircuitBreakerConfiguration) RemoveAllListeners(key string, opts ...Option) {
+	_ = RemoveAllListeners(b.dc, key, opts...)
+}
+
+func (b *CircuitBreakerConfiguration) GetProperties(key string, opts ...Option) (string, error) {
+	return b.read("properties", key, opts, func() (string, error) {
+		return b.dc.GetProperties(key, opts...)
+	})
+}
+
+// GetPropertiesWithMeta is never served from the cache while the circuit is open, since the cached value
+// may not match the metadata of the backend
+func (b *CircuitBreakerConfiguration) GetPropertiesWithMeta(key string, opts ...Option) (string, ValueMeta, error) {
+	if !b.allow() {
+		return "", ValueMeta{}, ErrCircuitOpen
+	}
+	value, meta, err := GetPropertiesWithMeta(b.dc, key, opts...)
+	b.record(err)
+	return value, meta, err
+}
+
+func (b *CircuitBreakerConfiguration) GetRule(key string, opts ...Option) (string, error) {
+	return b.read("rule", key, opts, func() (string, error) {
+		return b.dc.GetRule(key, opts...)
+	})
+}
+
+func (b *CircuitBreakerConfiguration) GetInternalProperty(key string, opts ...Option) (string, error) {
+	return b.read("internal", key, opts, func() (string, error) {
+		return b.dc.GetInternalProperty(key, opts...)
+	})
+}
+
+// GetConfigKeysByGroup is short-circuited with ErrCircuitOpen while the circuit is open, the keys not
+// being cached
+func (b *CircuitBreakerConfiguration) GetConfigKeysByGroup(group string) (*gxset.HashSet, error) {
+	if !b.allow() {
+		return nil, ErrCircuitOpen
+	}
+	keys, err := b.dc.GetConfigKeysByGroup(group)
+	b.record(err)
+	return keys, err
+}
+
+func (b *CircuitBreakerConfiguration) GetPropertiesWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return b.read("properties", key, opts, func() (string, error) {
+		return GetPropertiesWithContext(ctx, b.dc, key, opts...)
+	})
+}
+
+func (b *CircuitBreakerConfiguration) GetRuleWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return b.read("rule", key, opts, func() (string, error) {
+		return GetRuleWithContext(ctx, b.dc, key, opts...)
+	})
+}
+
+func (b *CircuitBreakerConfiguration) GetInternalPropertyWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return b.read("internal", key, opts, func() (string, error) {
+		return GetInternalPropertyWithContext(ctx, b.dc, key, opts...)
+	})
+}
+
+func (b *CircuitBreakerConfiguration) PublishConfig(key, group, value string) error {
+	return b.dc.PublishConfig(key, group, value)
+}
+
+func (b *CircuitBreakerConfiguration) RemoveConfig(key, group string) error {
+	return b.dc.RemoveConfig(key, group)
+}
+
+func (b *CircuitBreakerConfiguration) PublishConfigCAS(key, group, expectedOld, newValue string) (bool, error) {
+	return PublishConfigCAS(b.dc, key, group, expectedOld, newValue)
+}
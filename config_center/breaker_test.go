@@ -0,0 +1,139 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+// switchConfiguration fails its reads with err while err is set
+type switchConfiguration struct {
+	*MockDynamicConfiguration
+	err   error
+	calls int
+}
+
+func (c *switchConfiguration) GetProperties(key string, _ ...Option) (string, error) {
+	c.calls++
+	if c.err != nil {
+		return "", c.err
+	}
+	return "value of " + key, nil
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	dc := &switchConfiguration{MockDynamicConfiguration: &MockDynamicConfiguration{}}
+	b := NewCircuitBreakerConfiguration(dc, WithBreakerFailureThreshold(3), WithBreakerOpenTimeout(time.Hour))
+	assert.Equal(t, BreakerClosed, b.State())
+
+	value, err := b.GetProperties("cached")
+	assert.NoError(t, err)
+	assert.Equal(t, "value of cached", value)
+
+	dc.err = syscall.ECONNREFUSED
+	for i := 0; i < 3; i++ {
+		_, err = b.GetProperties("key")
+		assert.ErrorIs(t, err, syscall.ECONNREFUSED)
+	}
+	assert.Equal(t, BreakerOpen, b.State())
+	assert.Equal(t, 3+1, dc.calls)
+
+	// the reads are short-circuited, returning the cached value if any
+	value, err = b.GetProperties("cached")
+	assert.NoError(t, err)
+	assert.Equal(t, "value of cached", value)
+	_, err = b.GetProperties("key")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 3+1, dc.calls)
+}
+
+func TestCircuitBreakerIgnoresPermanentErrors(t *testing.T) {
+	dc := &switchConfiguration{MockDynamicConfiguration: &MockDynamicConfiguration{}, err: ErrKeyNotFound}
+	b := NewCircuitBreakerConfiguration(dc, WithBreakerFailureThreshold(1))
+	for i := 0; i < 3; i++ {
+		_, err := b.GetProperties("key")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	}
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+func TestCircuitBreakerWindow(t *testing.T) {
+	dc := &switchConfiguration{MockDynamicConfiguration: &MockDynamicConfiguration{}, err: syscall.ECONNREFUSED}
+	b := NewCircuitBreakerConfiguration(dc, WithBreakerFailureThreshold(2), WithBreakerWindow(10*time.Second))
+	clock := newFakeClock(b)
+	_, _ = b.GetProperties("key")
+	clock.advance(20 * time.Second)
+	_, _ = b.GetProperties("key")
+	assert.Equal(t, BreakerClosed, b.State())
+	_, _ = b.GetProperties("key")
+	assert.Equal(t, BreakerOpen, b.State())
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	dc := &switchConfiguration{MockDynamicConfiguration: &MockDynamicConfiguration{}, err: syscall.ECONNREFUSED}
+	b := NewCircuitBreakerConfiguration(dc, WithBreakerFailureThreshold(1), WithBreakerOpenTimeout(10*time.Second))
+	clock := newFakeClock(b)
+	_, _ = b.GetProperties("key")
+	assert.Equal(t, BreakerOpen, b.State())
+
+	// a failing probe opens the circuit again
+	clock.advance(20 * time.Second)
+	assert.Equal(t, BreakerHalfOpen, b.State())
+	_, err := b.GetProperties("key")
+	assert.ErrorIs(t, err, syscall.ECONNREFUSED)
+	assert.Equal(t, BreakerOpen, b.State())
+
+	// a successful probe closes it
+	clock.advance(20 * time.Second)
+	dc.err = nil
+	value, err := b.GetProperties("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value of key", value)
+	assert.Equal(t, BreakerClosed, b.State())
+}
+
+// fakeClock is the clock of a CircuitBreakerConfiguration, only moved by advance
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock(b *CircuitBreakerConfiguration) *fakeClock {
+	c := &fakeClock{now: time.Now()}
+	b.now = c.Now
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestBreakerStateString(t *testing.T) {
+	assert.Equal(t, "closed", BreakerClosed.String())
+	assert.Equal(t, "half-open", BreakerHalfOpen.String())
+	assert.Equal(t, "unknown", BreakerState(-1).String())
+}
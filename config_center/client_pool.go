@@ -0,0 +1,119 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+import (
+	"github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+)
+
+// ClientKey returns the key under which the backend client of url is shared by a ClientPool, made of the
+// protocol and the address of url, and a digest of its credentials and of the values of its paramKeys,
+// which are the other params the client is configured with, e.g. the namespace. The credentials are
+// digested so that the key can be logged.
+func ClientKey(url *common.URL, paramKeys ...string) string {
+	h := sha256.New()
+	for _, v := range []string{url.Username, url.Password} {
+		_, _ = fmt.Fprintf(h, "%d:%s", len(v), v)
+	}
+	for _, k := range paramKeys {
+		v := url.GetParam(k, "")
+		_, _ = fmt.Fprintf(h, "%d:%s=%d:%s", len(k), k, len(v), v)
+	}
+	return url.Protocol + "://" + url.Location + "#" + hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// ClientPool shares the backend clients between the config centers connecting to the same server with the
+// same credentials, so that a process creating many config centers against a server opens a single
+// connection. The clients are ref-counted, the last Release closing the client.
+type ClientPool[C any] struct {
+	closeClient func(C)
+
+	mu      sync.Mutex
+	clients map[string]*pooledClient[C]
+}
+
+type pooledClient[C any] struct {
+	client   C
+	refCount int32
+}
+
+// NewClientPool returns a pool closing the clients with closeClient once they are no longer referenced
+func NewClientPool[C any](closeClient func(C)) *ClientPool[C] {
+	return &ClientPool[C]{
+		closeClient: closeClient,
+		clients:     make(map[string]*pooledClient[C]),
+	}
+}
+
+// Acquire returns the client of key, creating it with create if there's none, and increments its ref
+// count. Each successful Acquire must be matched by a Release.
+func (p *ClientPool[C]) Acquire(key string, create func() (C, error)) (C, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pc, ok := p.clients[key]; ok {
+		pc.refCount++
+		logger.Debugf("[Config Center] client %s ref count incremented to %d", key, pc.refCount)
+		return pc.client, nil
+	}
+	client, err := create()
+	if err != nil {
+		return client, err
+	}
+	p.clients[key] = &pooledClient[C]{client: client, refCount: 1}
+	logger.Debugf("[Config Center] client %s created", key)
+	return client, nil
+}
+
+// Release decrements the ref count of the client of key, and closes it when the count reaches 0. Releasing
+// the client more times than it's acquired is logged and ignored.
+func (p *ClientPool[C]) Release(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc, ok := p.clients[key]
+	if !ok {
+		logger.Errorf("[Config Center] client %s released more times than acquired", key)
+		return
+	}
+	pc.refCount--
+	logger.Debugf("[Config Center] client %s ref count decremented to %d", key, pc.refCount)
+	if pc.refCount == 0 {
+		delete(p.clients, key)
+		p.closeClient(pc.client)
+	}
+}
+
+// RefCount returns the number of references to the client of key, 0 if there's none
+func (p *ClientPool[C]) RefCount(key string) int32 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pc, ok := p.clients[key]; ok {
+		return pc.refCount
+	}
+	return 0
+}
@@ -0,0 +1,183 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"sync"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+// WithCoalesce makes AddListener coalesce the change events of the key, so that a slow listener only
+// gets the latest event when several ones arrive while it processes the previous one, the intermediate
+// ones being dropped. The events are then delivered on a goroutine of the listener rather than on the
+// one of the watch, and the memory held by the pending events is bounded to one event.
+func WithCoalesce() Option {
+	return func(opts *Options) {
+		opts.Coalesce = true
+	}
+}
+
+type coalescerKey struct {
+	key      string
+	listener ConfigurationListener
+}
+
+// CoalescingListeners keeps the coalescers of the listeners added with WithCoalesce. The zero value is
+// ready to use.
+type CoalescingListeners struct {
+	mu         sync.Mutex
+	coalescers map[coalescerKey]*coalescingListener
+}
+
+// Add returns the listener to register on the watch of key, which is listener itself unless opts has
+// WithCoalesce
+func (c *CoalescingListeners) Add(key string, listener ConfigurationListener, opts *Options) ConfigurationListener {
+	if !opts.Coalesce {
+		return listener
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ck := coalescerKey{key: key, listener: listener}
+	if cl, ok := c.coalescers[ck]; ok {
+		return cl
+	}
+	if c.coalescers == nil {
+		c.coalescers = make(map[coalescerKey]*coalescingListener)
+	}
+	cl := &coalescingListener{
+		ConfigurationListener: listener,
+		pending:               make(chan *ConfigChangeEvent, 1),
+		done:                  make(chan struct{}),
+		exited:                make(chan struct{}),
+	}
+	c.coalescers[ck] = cl
+	go cl.run()
+	return cl
+}
+
+// Remove stops the coalescer of listener, waiting for the event it's delivering, and returns the listener
+// registered on the watch of key
+func (c *CoalescingListeners) Remove(key string, listener ConfigurationListener) ConfigurationListener {
+	c.mu.Lock()
+	ck := coalescerKey{key: key, listener: listener}
+	cl, ok := c.coalescers[ck]
+	if !ok {
+		c.mu.Unlock()
+		return listener
+	}
+	delete(c.coalescers, ck)
+	close(cl.done)
+	c.mu.Unlock()
+	<-cl.exited
+	return cl
+}
+
+// RemoveKey stops the coalescers of all the listeners of key, and waits for the events they are delivering
+func (c *CoalescingListeners) RemoveKey(key string) {
+	var removed []*coalescingListener
+	c.mu.Lock()
+	for ck, cl := range c.coalescers {
+		if ck.key == key {
+			delete(c.coalescers, ck)
+			close(cl.done)
+			removed = append(removed, cl)
+		}
+	}
+	c.mu.Unlock()
+	for _, cl := range removed {
+		<-cl.exited
+	}
+}
+
+// StopAll stops all the coalescers, and waits for the events they are delivering
+func (c *CoalescingListeners) StopAll() {
+	var removed []*coalescingListener
+	c.mu.Lock()
+	for ck, cl := range c.coalescers {
+		delete(c.coalescers, ck)
+		close(cl.done)
+		removed = append(removed, cl)
+	}
+	c.mu.Unlock()
+	for _, cl := range removed {
+		<-cl.exited
+	}
+}
+
+// coalescingListener delivers the events to the wrapped listener on its own goroutine, through a single
+// slot where a new event replaces the one not delivered yet
+type coalescingListener struct {
+	ConfigurationListener
+	pending chan *ConfigChangeEvent
+	done    chan struct{}
+	exited  chan struct{}
+
+	// mu serializes Process, so that the replacement of the pending event is atomic
+	mu sync.Mutex
+}
+
+// Process replaces the pending event with its merge with event, so that the listener sees the whole change
+func (cl *coalescingListener) Process(event *ConfigChangeEvent) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	select {
+	case <-cl.done:
+		return
+	default:
+	}
+	select {
+	case old := <-cl.pending:
+		if event = coalesce(old, event); event == nil {
+			return
+		}
+	default:
+	}
+	cl.pending <- event
+}
+
+// coalesce merges the pending event old with the next one event into the change from the state before old
+// to the state after event, e.g. a key added then modified is added with the last value. It returns nil
+// when the changes cancel out, i.e. a key added then deleted.
+func coalesce(old, event *ConfigChangeEvent) *ConfigChangeEvent {
+	e := *event
+	e.OldValue = old.OldValue
+	switch {
+	case old.ChangeType == ChangeTypeAdded && event.ChangeType == ChangeTypeDeleted:
+		return nil
+	case old.ChangeType == ChangeTypeAdded:
+		e.ChangeType, e.ConfigType = ChangeTypeAdded, remoting.EventTypeAdd
+	case old.ChangeType == ChangeTypeDeleted && event.ChangeType == ChangeTypeAdded:
+		e.ChangeType, e.ConfigType = ChangeTypeModified, remoting.EventTypeUpdate
+	}
+	return &e
+}
+
+func (cl *coalescingListener) run() {
+	defer close(cl.exited)
+	for {
+		select {
+		case <-cl.done:
+			return
+		case event := <-cl.pending:
+			cl.ConfigurationListener.Process(event)
+		}
+	}
+}
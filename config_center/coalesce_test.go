@@ -0,0 +1,145 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+// slowListener blocks on its first event until released
+type slowListener struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+
+	mu     sync.Mutex
+	values []string
+}
+
+func (l *slowListener) Process(event *ConfigChangeEvent) {
+	l.once.Do(func() {
+		close(l.started)
+		<-l.release
+	})
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.values = append(l.values, event.NewValue)
+}
+
+func (l *slowListener) received() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.values...)
+}
+
+func TestCoalesceFlood(t *testing.T) {
+	listener := &slowListener{started: make(chan struct{}), release: make(chan struct{})}
+	var coalescers CoalescingListeners
+	cl := coalescers.Add("key", listener, NewOptions(WithCoalesce()))
+	defer coalescers.StopAll()
+
+	event := func(i int) *ConfigChangeEvent {
+		value := strconv.Itoa(i)
+		return &ConfigChangeEvent{Key: "key", Value: value, NewValue: value, ConfigType: remoting.EventTypeUpdate,
+			ChangeType: ChangeTypeModified}
+	}
+	cl.Process(event(0))
+	<-listener.started
+	for i := 1; i <= 10000; i++ {
+		cl.Process(event(i))
+		assert.LessOrEqual(t, len(cl.(*coalescingListener).pending), 1)
+	}
+	close(listener.release)
+
+	assert.Eventually(t, func() bool {
+		return len(listener.received()) == 2
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"0", "10000"}, listener.received())
+}
+
+func TestCoalesceChangeTypes(t *testing.T) {
+	event := func(changeType ChangeType, oldValue, newValue string) *ConfigChangeEvent {
+		configType := map[ChangeType]remoting.EventType{
+			ChangeTypeAdded:    remoting.EventTypeAdd,
+			ChangeTypeModified: remoting.EventTypeUpdate,
+			ChangeTypeDeleted:  remoting.EventTypeDel,
+		}[changeType]
+		return &ConfigChangeEvent{Key: "key", Value: newValue, ConfigType: configType, OldValue: oldValue,
+			NewValue: newValue, ChangeType: changeType}
+	}
+	tests := []struct {
+		name  string
+		old   *ConfigChangeEvent
+		event *ConfigChangeEvent
+		want  *ConfigChangeEvent
+	}{
+		{"modified twice", event(ChangeTypeModified, "A", "B"), event(ChangeTypeModified, "B", "C"), event(ChangeTypeModified, "A", "C")},
+		{"added then modified", event(ChangeTypeAdded, "", "A"), event(ChangeTypeModified, "A", "B"), event(ChangeTypeAdded, "", "B")},
+		{"added then deleted", event(ChangeTypeAdded, "", "A"), event(ChangeTypeDeleted, "A", ""), nil},
+		{"modified then deleted", event(ChangeTypeModified, "A", "B"), event(ChangeTypeDeleted, "B", ""), event(ChangeTypeDeleted, "A", "")},
+		{"deleted then added", event(ChangeTypeDeleted, "A", ""), event(ChangeTypeAdded, "", "B"), event(ChangeTypeModified, "A", "B")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, coalesce(tt.old, tt.event))
+		})
+	}
+
+	// the pending event is dropped when the changes cancel out
+	listener := &slowListener{started: make(chan struct{}), release: make(chan struct{})}
+	var coalescers CoalescingListeners
+	cl := coalescers.Add("key", listener, NewOptions(WithCoalesce()))
+	defer coalescers.StopAll()
+	cl.Process(event(ChangeTypeModified, "A", "B"))
+	<-listener.started
+	cl.Process(event(ChangeTypeAdded, "", "C"))
+	cl.Process(event(ChangeTypeDeleted, "C", ""))
+	assert.Empty(t, cl.(*coalescingListener).pending)
+	close(listener.release)
+}
+
+func TestCoalesceRemove(t *testing.T) {
+	listener := &slowListener{started: make(chan struct{}), release: make(chan struct{})}
+	close(listener.release)
+	var coalescers CoalescingListeners
+	assert.Equal(t, ConfigurationListener(listener), coalescers.Add("key", listener, NewOptions()))
+
+	cl := coalescers.Add("key", listener, NewOptions(WithCoalesce()))
+	assert.Equal(t, cl, coalescers.Add("key", listener, NewOptions(WithCoalesce())))
+	cl.Process(&ConfigChangeEvent{Key: "key", NewValue: "A"})
+	assert.Eventually(t, func() bool {
+		return len(listener.received()) == 1
+	}, time.Second, time.Millisecond)
+
+	// the coalescer is stopped once RemoveKey returns, so the next events are dropped
+	coalescers.RemoveKey("key")
+	cl.Process(&ConfigChangeEvent{Key: "key", NewValue: "B"})
+	assert.Equal(t, ConfigurationListener(listener), coalescers.Remove("key", listener))
+	assert.Equal(t, []string{"A"}, listener.received())
+}
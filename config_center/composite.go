@@ -0,0 +1,333 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+import (
+	gxset "github.com/dubbogo/gost/container/set"
+	"github.com/dubbogo/gost/log/logger"
+
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+type compositeListenerKey struct {
+	key      string
+	listener ConfigurationListener
+}
+
+// CompositeConfiguration layers an ordered list of DynamicConfiguration, e.g. a local file, then a remote
+// config center, then the hardcoded defaults, the first layers overriding the next ones.
+//
+// A read tries the layers in order and returns the first value found. A layer missing the key, i.e.
+// failing with ErrKeyNotFound, is skipped silently. A layer failing with any other error is skipped as
+// well so that an unavailable layer doesn't hide the next ones, but if no layer has the key the error of
+// the first failed layer is returned rather than ErrKeyNotFound, since that layer may have had the key.
+// The layers not supporting the read, i.e. failing with ErrUnsupported, are skipped as missing the key.
+// WithDefault applies only when every layer misses the key. The WithContext reads give up on the next
+// layers once ctx is done.
+//
+// The listeners are added to every layer, and the events are merged so that the listener only sees the
+// changes of the layer the key is resolved from: the event of a layer is dropped when a previous layer has
+// the key, and the deletion of the key from a layer is delivered as a modification to the value of the
+// next layer having the key, if any.
+//
+// The parsers are set on every layer, and read from the first one. The composite is read-only, the
+// writes must be made on the layers themselves.
+type CompositeConfiguration struct {
+	layers []DynamicConfiguration
+
+	mu        sync.Mutex
+	listeners map[compositeListenerKey]*compositeListener
+}
+
+// NewCompositeConfiguration returns the composite of layers, which must not be empty, the first layer
+// having the highest priority
+func NewCompositeConfiguration(layers ...DynamicConfiguration) *CompositeConfiguration {
+	return &CompositeConfiguration{
+		layers:    layers,
+		listeners: make(map[compositeListenerKey]*compositeListener),
+	}
+}
+
+// Layers returns the layers in order
+func (c *CompositeConfiguration) Layers() []DynamicConfiguration {
+	return c.layers
+}
+
+// resolve reads key from the layers in order with get, see CompositeConfiguration
+func (c *CompositeConfiguration) resolve(ctx context.Context, key string, get func(dc DynamicConfiguration, opts ...Option) (string, error), opts ...Option) (string, error) {
+	layerOpts := append(opts[:len(opts):len(opts)], withoutDefault())
+	var firstErr error
+	unsupported := 0
+	for i, dc := range c.layers {
+		value, err := get(dc, layerOpts...)
+		if err == nil {
+			return value, nil
+		}
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if errors.Is(err, ErrUnsupported) {
+			unsupported++
+			continue
+		}
+		logger.Debugf("[Config Center] layer %d failed to read key %s, trying the next one: %v", i, key, err)
+		if firstErr == nil {
+			firstErr = perrors.WithMessagef(err, "layer %d", i)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if firstErr != nil {
+		return "", firstErr
+	}
+	if unsupported == len(c.layers) {
+		return "", ErrUnsupported
+	}
+	return NewOptions(opts...).KeyNotFound(key)
+}
+
+func (c *CompositeConfiguration) Parser() parser.ConfigurationParser {
+	return c.layers[0].Parser()
+}
+
+func (c *CompositeConfiguration) SetParser(p parser.ConfigurationParser) {
+	for _, dc := range c.layers {
+		dc.SetParser(p)
+	}
+}
+
+// RegisterParserForPattern registers p on every layer, and returns ErrUnsupported if none supports it
+func (c *CompositeConfiguration) RegisterParserForPattern(glob string, p parser.ConfigurationParser) error {
+	err := ErrUnsupported
+	for _, dc := range c.layers {
+		if RegisterParserForPattern(dc, glob, p) == nil {
+			err = nil
+		}
+	}
+	return err
+}
+
+func (c *CompositeConfiguration) ParserFor(key string) parser.ConfigurationParser {
+	return ParserFor(c.layers[0], key)
+}
+
+func (c *CompositeConfiguration) AddListener(key string, listener ConfigurationListener, opts ...Option) {
+	c.mu.Lock()
+	lk := compositeListenerKey{key: key, listener: listener}
+	cl, ok := c.listeners[lk]
+	if !ok {
+		cl = &compositeListener{
+			ConfigurationListener: listener,
+			c:                     c,
+			key:                   key,
+			opts:                  append(opts[:len(opts):len(opts)], withoutDefault()),
+		}
+		for i := range c.layers {
+			cl.layers = append(cl.layers, &compositeLayerListener{parent: cl, layer: i})
+		}
+		c.listeners[lk] = cl
+	}
+	c.mu.Unlock()
+	for i, dc := range c.layers {
+		dc.AddListener(key, cl.layers[i], opts...)
+	}
+}
+
+func (c *CompositeConfiguration) RemoveListener(key string, listener ConfigurationListener, opts ...Option) {
+	c.mu.Lock()
+	lk := compositeListenerKey{key: key, listener: listener}
+	cl, ok := c.listeners[lk]
+	delete(c.listeners, lk)
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	for i, dc := range c.layers {
+		dc.RemoveListener(key, cl.layers[i], opts...)
+	}
+}
+
+// RemoveAllListeners removes all the listeners of key from every layer
+func (c *CompositeConfiguration) RemoveAllListeners(key string, opts ...Option) {
+	c.mu.Lock()
+	for lk := range c.listeners {
+		if lk.key == key {
+			delete(c.listeners, lk)
+		}
+	}
+	c.mu.Unlock()
+	for _, dc := range c.layers {
+		_ = RemoveAllListeners(dc, key, opts...)
+	}
+}
+
+func (c *CompositeConfiguration) GetProperties(key string, opts ...Option) (string, error) {
+	return c.resolve(context.Background(), key, func(dc DynamicConfiguration, opts ...Option) (string, error) {
+		return dc.GetProperties(key, opts...)
+	}, opts...)
+}
+
+// GetPropertiesWithMeta returns the metadata of the layer the key is resolved from, the layers not
+// implementing ConfigurationMetaReader being skipped
+func (c *CompositeConfiguration) GetPropertiesWithMeta(key string, opts ...Option) (string, ValueMeta, error) {
+	var meta ValueMeta
+	value, err := c.resolve(context.Background(), key, func(dc DynamicConfiguration, opts ...Option) (string, error) {
+		var (
+			value string
+			err   error
+		)
+		value, meta, err = GetPropertiesWithMeta(dc, key, opts...)
+		return value, err
+	}, opts...)
+	if err != nil {
+		return value, ValueMeta{}, err
+	}
+	return value, meta, nil
+}
+
+func (c *CompositeConfiguration) GetRule(key string, opts ...Option) (string, error) {
+	return c.resolve(context.Background(), key, func(dc DynamicConfiguration, opts ...Option) (string, error) {
+		return dc.GetRule(key, opts...)
+	}, opts...)
+}
+
+func (c *CompositeConfiguration) GetInternalProperty(key string, opts ...Option) (string, error) {
+	return c.resolve(context.Background(), key, func(dc DynamicConfiguration, opts ...Option) (string, error) {
+		return dc.GetInternalProperty(key, opts...)
+	}, opts...)
+}
+
+// PublishConfig returns ErrUnsupported, the composite being read-only
+func (c *CompositeConfiguration) PublishConfig(string, string, string) error {
+	return ErrUnsupported
+}
+
+// RemoveConfig returns ErrUnsupported, the composite being read-only
+func (c *CompositeConfiguration) RemoveConfig(string, string) error {
+	return ErrUnsupported
+}
+
+// GetConfigKeysByGroup returns the union of the keys of group in every layer. The layers failing are
+// skipped, and the error of the first one is returned only if they all fail.
+func (c *CompositeConfiguration) GetConfigKeysByGroup(group string) (*gxset.HashSet, error) {
+	set := gxset.NewSet()
+	var firstErr error
+	failed := 0
+	for i, dc := range c.layers {
+		keys, err := dc.GetConfigKeysByGroup(group)
+		if err != nil {
+			logger.Warnf("[Config Center] layer %d failed to get the keys of group %s: %v", i, group, err)
+			if firstErr == nil {
+				firstErr = perrors.WithMessagef(err, "layer %d", i)
+			}
+			failed++
+			continue
+		}
+		for _, key := range keys.Values() {
+			set.Add(key)
+		}
+	}
+	if failed == len(c.layers) && firstErr != nil {
+		return nil, firstErr
+	}
+	return set, nil
+}
+
+func (c *CompositeConfiguration) GetPropertiesWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return c.resolve(ctx, key, func(dc DynamicConfiguration, opts ...Option) (string, error) {
+		return GetPropertiesWithContext(ctx, dc, key, opts...)
+	}, opts...)
+}
+
+func (c *CompositeConfiguration) GetRuleWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return c.resolve(ctx, key, func(dc DynamicConfiguration, opts ...Option) (string, error) {
+		return GetRuleWithContext(ctx, dc, key, opts...)
+	}, opts...)
+}
+
+func (c *CompositeConfiguration) GetInternalPropertyWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return c.resolve(ctx, key, func(dc DynamicConfiguration, opts ...Option) (string, error) {
+		return GetInternalPropertyWithContext(ctx, dc, key, opts...)
+	}, opts...)
+}
+
+// compositeListener merges the events of the layers for a listener, see CompositeConfiguration. The
+// events are processed one at a time so that the layers checked stay consistent with the event delivered.
+type compositeListener struct {
+	ConfigurationListener
+	c      *CompositeConfiguration
+	key    string
+	opts   []Option
+	layers []*compositeLayerListener
+
+	mu sync.Mutex
+}
+
+// has reports whether the layer has the key
+func (cl *compositeListener) has(layer int) (string, bool) {
+	value, err := cl.c.layers[layer].GetProperties(cl.key, cl.opts...)
+	return value, err == nil
+}
+
+func (cl *compositeListener) process(layer int, event *ConfigChangeEvent) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	for i := 0; i < layer; i++ {
+		if _, ok := cl.has(i); ok {
+			return
+		}
+	}
+	if event.ChangeType != ChangeTypeDeleted {
+		cl.ConfigurationListener.Process(event)
+		return
+	}
+	for i := layer + 1; i < len(cl.c.layers); i++ {
+		if value, ok := cl.has(i); ok {
+			e := *event
+			e.Value = value
+			e.NewValue = value
+			e.ConfigType = remoting.EventTypeUpdate
+			e.ChangeType = ChangeTypeModified
+			cl.ConfigurationListener.Process(&e)
+			return
+		}
+	}
+	cl.ConfigurationListener.Process(event)
+}
+
+// compositeLayerListener is the listener added to a layer
+type compositeLayerListener struct {
+	parent *compositeListener
+	layer  int
+}
+
+func (ll *compositeLayerListener) Process(event *ConfigChangeEvent) {
+	ll.parent.process(ll.layer, event)
+}
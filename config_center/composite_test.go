@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+// mapConfiguration serves its values, or fails every read with err if set
+type mapConfiguration struct {
+	*MockDynamicConfiguration
+	values    map[string]string
+	err       error
+	listeners []ConfigurationListener
+}
+
+func newMapConfiguration(values map[string]string) *mapConfiguration {
+	return &mapConfiguration{MockDynamicConfiguration: &MockDynamicConfiguration{}, values: values}
+}
+
+func (c *mapConfiguration) GetProperties(key string, opts ...Option) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	if value, ok := c.values[key]; ok {
+		return value, nil
+	}
+	return NewOptions(opts...).KeyNotFound(key)
+}
+
+func (c *mapConfiguration) AddListener(_ string, listener ConfigurationListener, _ ...Option) {
+	c.listeners = append(c.listeners, listener)
+}
+
+// set changes the value of key, an empty value deleting it, and notifies the listeners
+func (c *mapConfiguration) set(key, value string) {
+	eventType := remoting.EventTypeUpdate
+	if len(value) == 0 {
+		eventType = remoting.EventTypeDel
+		delete(c.values, key)
+	} else {
+		c.values[key] = value
+	}
+	event := &ConfigChangeEvent{Key: key, Value: value, NewValue: value, ConfigType: eventType, ChangeType: ChangeTypeOf(eventType)}
+	for _, listener := range c.listeners {
+		listener.Process(event)
+	}
+}
+
+func TestCompositeGetProperties(t *testing.T) {
+	local := newMapConfiguration(map[string]string{"a": "local"})
+	remote := newMapConfiguration(map[string]string{"a": "remote", "b": "remote"})
+	defaults := newMapConfiguration(map[string]string{"a": "default", "b": "default", "c": "default"})
+	c := NewCompositeConfiguration(local, remote, defaults)
+
+	for key, expected := range map[string]string{"a": "local", "b": "remote", "c": "default"} {
+		value, err := c.GetProperties(key)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, value)
+	}
+
+	_, err := c.GetProperties("d")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	value, err := c.GetProperties("d", WithDefault("fallback"))
+	assert.NoError(t, err)
+	assert.Equal(t, "fallback", value)
+	value, err = c.GetProperties("b", WithDefault("fallback"))
+	assert.NoError(t, err)
+	assert.Equal(t, "remote", value)
+}
+
+func TestCompositeLayerError(t *testing.T) {
+	remote := newMapConfiguration(map[string]string{"a": "remote"})
+	remote.err = syscall.ECONNREFUSED
+	defaults := newMapConfiguration(map[string]string{"a": "default"})
+	c := NewCompositeConfiguration(newMapConfiguration(map[string]string{}), remote, defaults)
+
+	value, err := c.GetProperties("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", value)
+
+	_, err = c.GetProperties("b", WithDefault("fallback"))
+	assert.True(t, errors.Is(err, syscall.ECONNREFUSED))
+}
+
+func TestCompositeListener(t *testing.T) {
+	local := newMapConfiguration(map[string]string{})
+	remote := newMapConfiguration(map[string]string{"a": "remote"})
+	c := NewCompositeConfiguration(local, remote)
+	listener := &recordingListener{}
+	c.AddListener("a", listener)
+
+	remote.set("a", "remote2")
+	local.set("a", "local")
+	remote.set("a", "remote3")
+	local.set("a", "")
+	remote.set("a", "")
+
+	if events := listener.Events(); assert.Len(t, events, 4) {
+		assert.Equal(t, "remote2", events[0].NewValue)
+		assert.Equal(t, "local", events[1].NewValue)
+		assert.Equal(t, ChangeTypeModified, events[2].ChangeType)
+		assert.Equal(t, "remote3", events[2].NewValue)
+		assert.Equal(t, ChangeTypeDeleted, events[3].ChangeType)
+	}
+
+	c.RemoveListener("a", listener)
+	assert.Empty(t, c.listeners)
+}
@@ -0,0 +1,134 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// CompressionCodec compresses the config payloads stored in the config center, see WithCompression
+type CompressionCodec interface {
+	// Detect reports whether data was compressed by the codec, e.g. from its magic header
+	Detect(data []byte) bool
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// StreamingCompressionCodec is a CompressionCodec decompressing as a stream, which lets WithMaxValueSize
+// abort the decompression of a value once the limit is crossed
+type StreamingCompressionCodec interface {
+	CompressionCodec
+	NewReader(data []byte) (io.ReadCloser, error)
+}
+
+// GzipCodec is the gzip CompressionCodec, detecting the gzip magic header
+var GzipCodec CompressionCodec = gzipCodec{}
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Detect(data []byte) bool {
+	return bytes.HasPrefix(data, gzipMagic)
+}
+
+func (gzipCodec) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c gzipCodec) Decompress(data []byte) ([]byte, error) {
+	r, err := c.NewReader(data)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (gzipCodec) NewReader(data []byte) (io.ReadCloser, error) {
+	return gzip.NewReader(bytes.NewReader(data))
+}
+
+// Decompress returns value decompressed by the codec set by WithCompression if the codec detects it or
+// WithCompressed is set, and value as is otherwise. The decompressed value is bounded by WithMaxValueSize.
+func (o *Options) Decompress(key, value string) (string, error) {
+	if o.Compression == nil || (!o.Compressed && !o.Compression.Detect([]byte(value))) {
+		return value, nil
+	}
+	decompressed, err := o.decompress(key, []byte(value))
+	if err != nil {
+		if perrors.Is(err, ErrValueTooLarge) {
+			return "", err
+		}
+		return "", perrors.WithMessagef(err, "decompress the value of key %s", key)
+	}
+	return string(decompressed), nil
+}
+
+func (o *Options) decompress(key string, data []byte) ([]byte, error) {
+	sc, ok := o.Compression.(StreamingCompressionCodec)
+	if !ok {
+		decompressed, err := o.Compression.Decompress(data)
+		if err != nil {
+			return nil, err
+		}
+		return decompressed, o.CheckValueSize(key, int64(len(decompressed)))
+	}
+	r, err := sc.NewReader(data)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return o.ReadValue(key, r)
+}
+
+// Compress returns value compressed by the codec set by WithCompression, or value as is without codec
+func (o *Options) Compress(value string) (string, error) {
+	if o.Compression == nil {
+		return value, nil
+	}
+	compressed, err := o.Compression.Compress([]byte(value))
+	if err != nil {
+		return "", perrors.WithMessage(err, "compress the value")
+	}
+	return string(compressed), nil
+}
+
+// PublishCompressedConfig publishes value compressed by the codec set by WithCompression, to be read back
+// with the same option.
+func PublishCompressedConfig(dc DynamicConfiguration, key, group, value string, opts ...Option) error {
+	compressed, err := NewOptions(opts...).Compress(value)
+	if err != nil {
+		return err
+	}
+	return dc.PublishConfig(key, group, compressed)
+}
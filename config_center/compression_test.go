@@ -0,0 +1,60 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzipRoundTrip(t *testing.T) {
+	opts := NewOptions(WithCompression(GzipCodec))
+	compressed, err := opts.Compress("key=value")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "key=value", compressed)
+	assert.True(t, GzipCodec.Detect([]byte(compressed)))
+
+	value, err := opts.Decompress("key", compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, "key=value", value)
+}
+
+func TestDecompressPlainValue(t *testing.T) {
+	value, err := NewOptions(WithCompression(GzipCodec)).Decompress("key", "key=value")
+	assert.NoError(t, err)
+	assert.Equal(t, "key=value", value)
+
+	_, err = NewOptions(WithCompression(GzipCodec), WithCompressed()).Decompress("key", "key=value")
+	assert.Error(t, err)
+}
+
+func TestWithoutCompression(t *testing.T) {
+	compressed, err := NewOptions(WithCompression(GzipCodec)).Compress("key=value")
+	assert.NoError(t, err)
+
+	value, err := NewOptions(WithCompression(GzipCodec), WithCompression(nil)).Decompress("key", compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, compressed, value)
+
+	value, err = NewOptions().Compress("key=value")
+	assert.NoError(t, err)
+	assert.Equal(t, "key=value", value)
+}
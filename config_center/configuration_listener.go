@@ -19,6 +19,7 @@ package config_center
 
 import (
 	"fmt"
+	"sync"
 )
 
 import (
@@ -31,13 +32,84 @@ type ConfigurationListener interface {
 	Process(*ConfigChangeEvent)
 }
 
+// ChangeType is the kind of change happened on a config key
+type ChangeType int
+
+const (
+	// ChangeTypeAdded means the key is added
+	ChangeTypeAdded ChangeType = iota
+	// ChangeTypeModified means the value of the key is modified
+	ChangeTypeModified
+	// ChangeTypeDeleted means the key is deleted
+	ChangeTypeDeleted
+)
+
+var changeTypeStrings = [...]string{
+	"added",
+	"modified",
+	"deleted",
+}
+
+func (t ChangeType) String() string {
+	if t < 0 || int(t) >= len(changeTypeStrings) {
+		return "unknown"
+	}
+	return changeTypeStrings[t]
+}
+
+// ChangeTypeOf returns the ChangeType of the remoting event type
+func ChangeTypeOf(eventType remoting.EventType) ChangeType {
+	switch eventType {
+	case remoting.EventTypeAdd:
+		return ChangeTypeAdded
+	case remoting.EventTypeDel:
+		return ChangeTypeDeleted
+	default:
+		return ChangeTypeModified
+	}
+}
+
 // ConfigChangeEvent for changing listener's event
 type ConfigChangeEvent struct {
 	Key        string
 	Value      any
 	ConfigType remoting.EventType
+	// OldValue is the value before the change, it's empty when the key is added or the previous value is unknown
+	OldValue string
+	// NewValue is the value after the change, it's empty when the key is deleted
+	NewValue string
+	// ChangeType tells the deletion of a key from its modification to an empty value, the backends
+	// mapping their native watch events onto it
+	ChangeType ChangeType
 }
 
 func (c ConfigChangeEvent) String() string {
 	return fmt.Sprintf("ConfigChangeEvent{key = %v , value = %v , changeType = %v}", c.Key, c.Value, c.ConfigType)
 }
+
+// ValueCache remembers the last value delivered for each key, so that the change events of backends which
+// don't deliver the previous value natively can still carry OldValue. The zero value is ready to use.
+type ValueCache struct {
+	values sync.Map
+}
+
+// NewChangeEvent returns the event of key changing to value, filling OldValue with the last value delivered
+// for key and recording value as the new one.
+func (vc *ValueCache) NewChangeEvent(key, value string, eventType remoting.EventType) *ConfigChangeEvent {
+	var old any
+	if eventType == remoting.EventTypeDel {
+		old, _ = vc.values.LoadAndDelete(key)
+		value = ""
+	} else {
+		old, _ = vc.values.Swap(key, value)
+	}
+	oldValue, _ := old.(string)
+	return &ConfigChangeEvent{
+		Key:        key,
+		Value:      value,
+		ConfigType: eventType,
+		OldValue:   oldValue,
+		NewValue:   value,
+		ChangeType: ChangeTypeOf(eventType),
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+func TestValueCacheNewChangeEvent(t *testing.T) {
+	var values ValueCache
+	event := values.NewChangeEvent("key", "v1", remoting.EventTypeAdd)
+	assert.Equal(t, "", event.OldValue)
+	assert.Equal(t, "v1", event.NewValue)
+	assert.Equal(t, ChangeTypeAdded, event.ChangeType)
+
+	event = values.NewChangeEvent("key", "v2", remoting.EventTypeUpdate)
+	assert.Equal(t, "v1", event.OldValue)
+	assert.Equal(t, "v2", event.NewValue)
+	assert.Equal(t, "v2", event.Value)
+	assert.Equal(t, ChangeTypeModified, event.ChangeType)
+
+	event = values.NewChangeEvent("key", "", remoting.EventTypeDel)
+	assert.Equal(t, "v2", event.OldValue)
+	assert.Equal(t, "", event.NewValue)
+	assert.Equal(t, ChangeTypeDeleted, event.ChangeType)
+}
+
+func TestValueCacheEmptyValue(t *testing.T) {
+	var values ValueCache
+	values.NewChangeEvent("key", "v1", remoting.EventTypeAdd)
+
+	event := values.NewChangeEvent("key", "", remoting.EventTypeUpdate)
+	assert.Equal(t, ChangeTypeModified, event.ChangeType)
+	assert.Equal(t, "v1", event.OldValue)
+	assert.Equal(t, "", event.NewValue)
+
+	event = values.NewChangeEvent("key", "", remoting.EventTypeDel)
+	assert.Equal(t, ChangeTypeDeleted, event.ChangeType)
+	assert.Equal(t, "", event.OldValue)
+
+	event = values.NewChangeEvent("key", "", remoting.EventTypeAdd)
+	assert.Equal(t, ChangeTypeAdded, event.ChangeType)
+	assert.Equal(t, "", event.NewValue)
+}
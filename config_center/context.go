@@ -0,0 +1,234 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+import (
+	"github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
+)
+
+// ContextualConfiguration is an optional interface for DynamicConfiguration implementations
+// whose reads can be bounded by the caller's context. The methods return ctx.Err() as soon as ctx is done,
+// but a backend whose client is not context aware abandons the read rather than canceling it: the request
+// keeps running on the server and in a goroutine until it returns, see PendingReads.
+type ContextualConfiguration interface {
+	// GetPropertiesWithContext get properties file, returning when ctx is done
+	GetPropertiesWithContext(ctx context.Context, key string, opts ...Option) (string, error)
+
+	// GetRuleWithContext get Router rule properties file, returning when ctx is done
+	GetRuleWithContext(ctx context.Context, key string, opts ...Option) (string, error)
+
+	// GetInternalPropertyWithContext get value by key in Default properties file, returning when ctx is done
+	GetInternalPropertyWithContext(ctx context.Context, key string, opts ...Option) (string, error)
+}
+
+// NewReadContext derives the context of a single read from parent and the timeout set by WithReadTimeout,
+// or else by WithTimeout. Both limits apply, so the earlier of the parent deadline and the option timeout wins.
+func NewReadContext(parent context.Context, opts *Options) (context.Context, context.CancelFunc) {
+	return newReadContext(parent, opts.ReadTimeout())
+}
+
+// NewURLReadContext is NewReadContext falling back to the read timeout the config center of url is created
+// with, see ReadTimeout, when opts set none
+func NewURLReadContext(parent context.Context, url *common.URL, opts *Options) (context.Context, context.CancelFunc) {
+	timeout := opts.ReadTimeout()
+	if timeout <= 0 {
+		timeout = ReadTimeout(url, 0)
+	}
+	return newReadContext(parent, timeout)
+}
+
+func newReadContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if parent == nil {
+		parent = context.Background()
+	}
+	if timeout > 0 {
+		return context.WithTimeout(parent, timeout)
+	}
+	return context.WithCancel(parent)
+}
+
+// ConnectTimeout returns the connect timeout of the config center of url set by WithConnectTimeout, or else
+// by WithTimeout, or else def. It's used by the backends to dial their servers.
+func ConnectTimeout(url *common.URL, def time.Duration) time.Duration {
+	return urlTimeout(url, constant.ConfigConnectTimeoutKey, def)
+}
+
+// ReadTimeout returns the read timeout of the config center of url set by WithReadTimeout, or else by
+// WithTimeout, or else def
+func ReadTimeout(url *common.URL, def time.Duration) time.Duration {
+	return urlTimeout(url, constant.ConfigReadTimeoutKey, def)
+}
+
+func urlTimeout(url *common.URL, key string, def time.Duration) time.Duration {
+	if url == nil {
+		return def
+	}
+	if d := parseTimeout(url.GetParam(key, "")); d > 0 {
+		return d
+	}
+	if d := parseTimeout(url.GetParam(constant.ConfigTimeoutKey, "")); d > 0 {
+		return d
+	}
+	return def
+}
+
+// RunWithContext runs read in a new goroutine and returns its result, or ctx.Err() if ctx is done first.
+// It is meant for backend clients which don't accept a context themselves. The goroutine of a read abandoned
+// when ctx is done only exits when the read returns, use PendingReads to bound them.
+func RunWithContext(ctx context.Context, read func() (string, error)) (string, error) {
+	return runWithContext(ctx, read, func() {})
+}
+
+// DefaultMaxPendingReads is the number of reads PendingReads runs at once when its Max is not set
+const DefaultMaxPendingReads = 16
+
+// MaxPendingReads returns the bound of the pending reads of the config center of url, set by its
+// config-center.max-pending-reads param, or else DefaultMaxPendingReads
+func MaxPendingReads(url *common.URL) int {
+	if url == nil {
+		return DefaultMaxPendingReads
+	}
+	return int(url.GetParamInt(constant.ConfigMaxPendingReadsKey, DefaultMaxPendingReads))
+}
+
+// PendingReads bounds the reads of a backend client run by RunWithContext which have not returned yet,
+// including the ones abandoned when their context is done, so that a hung server doesn't leak a goroutine
+// per read. A read waits for one of them to return, or fails with ctx.Err(). The zero value is ready to use.
+type PendingReads struct {
+	// Max is the number of pending reads, DefaultMaxPendingReads if not positive. It must be set before
+	// the first Run.
+	Max int
+
+	once      sync.Once
+	slots     chan struct{}
+	exhausted atomic.Bool
+}
+
+// Run is RunWithContext, once the number of pending reads is below Max. Running out of slots is logged
+// once until a pending read returns, as it usually means the server hangs.
+func (p *PendingReads) Run(ctx context.Context, read func() (string, error)) (string, error) {
+	slots := p.getSlots()
+	select {
+	case slots <- struct{}{}:
+	default:
+		if p.exhausted.CompareAndSwap(false, true) {
+			logger.Warnf("[Config Center] %d reads are pending, the next reads wait for one of them to return", cap(slots))
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	return runWithContext(ctx, read, func() {
+		<-slots
+		p.exhausted.Store(false)
+	})
+}
+
+// Pending returns the number of reads which have not returned yet
+func (p *PendingReads) Pending() int {
+	return len(p.getSlots())
+}
+
+func (p *PendingReads) getSlots() chan struct{} {
+	p.once.Do(func() {
+		max := p.Max
+		if max <= 0 {
+			max = DefaultMaxPendingReads
+		}
+		p.slots = make(chan struct{}, max)
+	})
+	return p.slots
+}
+
+// runWithContext is RunWithContext calling done once read returns, whether or not ctx is done first
+func runWithContext(ctx context.Context, read func() (string, error), done func()) (string, error) {
+	if err := ctx.Err(); err != nil {
+		done()
+		return "", err
+	}
+	type result struct {
+		value string
+		err   error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		defer done()
+		value, err := read()
+		ch <- result{value: value, err: err}
+	}()
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case r := <-ch:
+		return r.value, r.err
+	}
+}
+
+// GetPropertiesWithContext reads key from dc, using its ContextualConfiguration implementation if any.
+// Otherwise the read is run by RunWithContext, and so abandoned rather than canceled when ctx is done.
+func GetPropertiesWithContext(ctx context.Context, dc DynamicConfiguration, key string, opts ...Option) (string, error) {
+	if cc, ok := dc.(ContextualConfiguration); ok {
+		return cc.GetPropertiesWithContext(ctx, key, opts...)
+	}
+	ctx, cancel := NewReadContext(ctx, NewOptions(opts...))
+	defer cancel()
+	return RunWithContext(ctx, func() (string, error) {
+		return dc.GetProperties(key, opts...)
+	})
+}
+
+// GetRuleWithContext reads the router rule of key from dc, using its ContextualConfiguration implementation if any.
+// Otherwise the read is run by RunWithContext, and so abandoned rather than canceled when ctx is done.
+func GetRuleWithContext(ctx context.Context, dc DynamicConfiguration, key string, opts ...Option) (string, error) {
+	if cc, ok := dc.(ContextualConfiguration); ok {
+		return cc.GetRuleWithContext(ctx, key, opts...)
+	}
+	ctx, cancel := NewReadContext(ctx, NewOptions(opts...))
+	defer cancel()
+	return RunWithContext(ctx, func() (string, error) {
+		return dc.GetRule(key, opts...)
+	})
+}
+
+// GetInternalPropertyWithContext reads the internal property of key from dc, using its ContextualConfiguration
+// implementation if any. Otherwise the read is run by RunWithContext, and so abandoned rather than canceled
+// when ctx is done.
+func GetInternalPropertyWithContext(ctx context.Context, dc DynamicConfiguration, key string, opts ...Option) (string, error) {
+	if cc, ok := dc.(ContextualConfiguration); ok {
+		return cc.GetInternalPropertyWithContext(ctx, key, opts...)
+	}
+	ctx, cancel := NewReadContext(ctx, NewOptions(opts...))
+	defer cancel()
+	return RunWithContext(ctx, func() (string, error) {
+		return dc.GetInternalProperty(key, opts...)
+	})
+}
@@ -0,0 +1,183 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
+)
+
+func TestOptionsTimeout(t *testing.T) {
+	assert.Equal(t, time.Duration(0), NewOptions().Timeout())
+	assert.Equal(t, 12*time.Second, NewOptions(WithTimeout(12*time.Second)).Timeout())
+
+	opts := NewOptions()
+	opts.Center.Timeout = "3s"
+	assert.Equal(t, 3*time.Second, opts.Timeout())
+}
+
+func TestNewReadContext(t *testing.T) {
+	ctx, cancel := NewReadContext(context.Background(), NewOptions(WithTimeout(time.Second)))
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.True(t, time.Until(deadline) <= time.Second)
+
+	// the parent deadline takes precedence when it's earlier
+	parent, parentCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer parentCancel()
+	ctx, cancel = NewReadContext(parent, NewOptions(WithTimeout(time.Minute)))
+	defer cancel()
+	deadline, _ = ctx.Deadline()
+	parentDeadline, _ := parent.Deadline()
+	assert.Equal(t, parentDeadline, deadline)
+}
+
+func TestPhaseTimeouts(t *testing.T) {
+	opts := NewOptions(WithTimeout(5 * time.Second))
+	assert.Equal(t, 5*time.Second, opts.ConnectTimeout())
+	assert.Equal(t, 5*time.Second, opts.ReadTimeout())
+
+	opts = NewOptions(WithAddress("zookeeper://127.0.0.1:2181"), WithTimeout(5*time.Second),
+		WithConnectTimeout(100*time.Millisecond), WithReadTimeout(time.Minute))
+	assert.Equal(t, 100*time.Millisecond, opts.ConnectTimeout())
+	assert.Equal(t, time.Minute, opts.ReadTimeout())
+	assert.Equal(t, 5*time.Second, opts.Timeout())
+
+	url, err := opts.URL()
+	assert.NoError(t, err)
+	assert.Equal(t, 100*time.Millisecond, ConnectTimeout(url, 0))
+	assert.Equal(t, time.Minute, ReadTimeout(url, 0))
+
+	// the combined timeout is the default of both phases, then def
+	url, _ = common.NewURL("zookeeper://127.0.0.1:2181", common.WithParamsValue(constant.ConfigTimeoutKey, "3s"))
+	assert.Equal(t, 3*time.Second, ConnectTimeout(url, time.Second))
+	assert.Equal(t, 3*time.Second, ReadTimeout(url, time.Second))
+	url, _ = common.NewURL("zookeeper://127.0.0.1:2181")
+	assert.Equal(t, time.Second, ConnectTimeout(url, time.Second))
+	assert.Equal(t, time.Duration(0), ReadTimeout(nil, 0))
+}
+
+func TestNewURLReadContext(t *testing.T) {
+	url, err := NewOptions(WithAddress("nacos://127.0.0.1:8848"), WithConnectTimeout(time.Millisecond),
+		WithReadTimeout(time.Minute)).URL()
+	assert.NoError(t, err)
+
+	// the reads honor the read timeout of the config center, not its connect timeout
+	ctx, cancel := NewURLReadContext(context.Background(), url, NewOptions())
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.True(t, time.Until(deadline) > time.Second)
+	assert.True(t, time.Until(deadline) <= time.Minute)
+
+	// the read timeout of a call overrides it
+	ctx, cancel = NewURLReadContext(context.Background(), url, NewOptions(WithReadTimeout(10*time.Millisecond)))
+	defer cancel()
+	_, err = RunWithContext(ctx, func() (string, error) {
+		time.Sleep(time.Second)
+		return "late", nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	ctx, cancel = NewURLReadContext(context.Background(), nil, NewOptions())
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
+
+func TestRunWithContext(t *testing.T) {
+	value, err := RunWithContext(context.Background(), func() (string, error) {
+		return "v", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "v", value)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = RunWithContext(ctx, func() (string, error) {
+		time.Sleep(time.Second)
+		return "v", nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPendingReads(t *testing.T) {
+	reads := PendingReads{Max: 1}
+	release := make(chan struct{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := reads.Run(ctx, func() (string, error) {
+		<-release
+		return "late", nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	// the abandoned read is still pending, so the next one waits for it rather than starting
+	assert.Equal(t, 1, reads.Pending())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	started := false
+	_, err = reads.Run(ctx, func() (string, error) {
+		started = true
+		return "v", nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, started)
+	// running out of slots is logged once until a pending read returns
+	assert.True(t, reads.exhausted.Load())
+
+	close(release)
+	assert.Eventually(t, func() bool { return reads.Pending() == 0 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return !reads.exhausted.Load() }, time.Second, time.Millisecond)
+	value, err := reads.Run(context.Background(), func() (string, error) {
+		return "v", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "v", value)
+	assert.Eventually(t, func() bool { return reads.Pending() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestMaxPendingReads(t *testing.T) {
+	assert.Equal(t, DefaultMaxPendingReads, MaxPendingReads(nil))
+
+	url, err := NewOptions(WithAddress("nacos://127.0.0.1:8848")).URL()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultMaxPendingReads, MaxPendingReads(url))
+
+	url.SetParam(constant.ConfigMaxPendingReadsKey, "2")
+	reads := PendingReads{Max: MaxPendingReads(url)}
+	assert.Equal(t, 2, cap(reads.getSlots()))
+}
+
+func TestGetPropertiesWithContext(t *testing.T) {
+	dc := &MockDynamicConfiguration{content: "content"}
+	value, err := GetPropertiesWithContext(context.Background(), dc, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "content", value)
+}
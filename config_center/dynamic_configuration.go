@@ -17,12 +17,19 @@
 
 package config_center
 
+import (
+	"strings"
+)
+
 import (
 	gxset "github.com/dubbogo/gost/container/set"
+
+	perrors "github.com/pkg/errors"
 )
 
 import (
 	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
 	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
 )
 
@@ -31,13 +38,28 @@ const (
 	DefaultConfigTimeout = "10s"
 )
 
+// ErrKeyNotFound is returned by the getters when the key does not exist in the config center
+// and no default value is given by WithDefault.
+var ErrKeyNotFound = perrors.New("config center key not found")
+
+// ErrUnsupported is returned for the operations the config center backend does not support
+var ErrUnsupported = perrors.New("operation not supported by the config center")
+
+// ErrNoParserConfigured is returned by the operations parsing the rules when the config center has no
+// parser, e.g. when it's created without its factory and SetParser is never called. The unmarshal helpers
+// fall back to the default parser instead.
+var ErrNoParserConfigured = perrors.New("config center has no parser configured")
+
 // DynamicConfiguration is the interface which modifys listener and gets properties file.
 type DynamicConfiguration interface {
+	ConfigurationPublisher
+
 	Parser() parser.ConfigurationParser
 	SetParser(parser.ConfigurationParser)
 	AddListener(string, ConfigurationListener, ...Option)
 	RemoveListener(string, ConfigurationListener, ...Option)
-	// GetProperties get properties file
+	// GetProperties get properties file, a missing key results in the value of WithDefault
+	// if any, or else ErrKeyNotFound
 	GetProperties(string, ...Option) (string, error)
 
 	// GetRule get Router rule properties file
@@ -46,19 +68,74 @@ type DynamicConfiguration interface {
 	// GetInternalProperty get value by key in Default properties file(dubbo.properties)
 	GetInternalProperty(string, ...Option) (string, error)
 
+	// GetConfigKeysByGroup will return all keys with the group
+	GetConfigKeysByGroup(group string) (*gxset.HashSet, error)
+}
+
+// ConfigurationPublisher is the write side of DynamicConfiguration, for the callers which only publish
+// configs. The read-only backends return ErrUnsupported from its methods.
+type ConfigurationPublisher interface {
 	// PublishConfig will publish the config with the (key, group, value) pair
 	// for zk: path is /$(group)/config/$(key) -> value
 	// for nacos: group, key -> value
-	PublishConfig(string, string, string) error
+	PublishConfig(key, group, value string) error
 
 	// RemoveConfig will remove the config white the (key, group) pair
-	RemoveConfig(string, string) error
+	RemoveConfig(key, group string) error
+}
 
-	// GetConfigKeysByGroup will return all keys with the group
-	GetConfigKeysByGroup(group string) (*gxset.HashSet, error)
+// ConfigurationCASPublisher is implemented by the config centers able to write a value conditionally,
+// e.g. for leader election. Among the builtin backends only zookeeper supports it, with a versioned
+// set. Use PublishConfigCAS to get ErrUnsupported from the others.
+type ConfigurationCASPublisher interface {
+	ConfigurationPublisher
+
+	// PublishConfigCAS atomically sets the value of the (key, group) pair to newValue if its current
+	// value is expectedOld, an empty expectedOld meaning that the key must not exist. It returns false
+	// without error when the current value doesn't match.
+	PublishConfigCAS(key, group, expectedOld, newValue string) (bool, error)
+}
+
+// PublishConfigCAS calls the PublishConfigCAS of dc, or returns ErrUnsupported if dc doesn't
+// implement ConfigurationCASPublisher
+func PublishConfigCAS(dc DynamicConfiguration, key, group, expectedOld, newValue string) (bool, error) {
+	if p, ok := dc.(ConfigurationCASPublisher); ok {
+		return p.PublishConfigCAS(key, group, expectedOld, newValue)
+	}
+	return false, ErrUnsupported
+}
+
+// CacheStatsReporter is implemented by the config centers caching the parsed contents, which are the
+// nacos, zookeeper and file builtin backends. Use CacheStats to get false from the others.
+type CacheStatsReporter interface {
+	// CacheStats returns the hits, misses, evictions and size of the parse cache
+	CacheStats() parser.CacheStats
+
+	// ResetCacheStats zeroes the hits, misses and evictions of the parse cache
+	ResetCacheStats()
+}
+
+// CacheStats calls the CacheStats of dc, or returns false if dc doesn't implement CacheStatsReporter
+func CacheStats(dc DynamicConfiguration) (parser.CacheStats, bool) {
+	if r, ok := dc.(CacheStatsReporter); ok {
+		return r.CacheStats(), true
+	}
+	return parser.CacheStats{}, false
 }
 
-// GetRuleKey The format is '{interfaceName}:[version]:[group]'
+// GetRuleKey The format is '{interfaceName}:[version]:[group]', where a missing version or group is omitted
+// along with its colon, and the version 0.0.0 counts as missing
 func GetRuleKey(url *common.URL) string {
-	return url.ColonSeparatedKey()
+	intf := url.GetParam(constant.InterfaceKey, strings.TrimPrefix(url.Path, "/"))
+	if intf == "" {
+		return ""
+	}
+	segments := []string{intf}
+	if version := url.GetParam(constant.VersionKey, ""); version != "" && version != "0.0.0" {
+		segments = append(segments, version)
+	}
+	if group := url.GetParam(constant.GroupKey, ""); group != "" {
+		segments = append(segments, group)
+	}
+	return strings.Join(segments, ":")
 }
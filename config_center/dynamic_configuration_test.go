@@ -18,6 +18,8 @@
 package config_center
 
 import (
+	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -28,6 +30,8 @@ import (
 
 import (
 	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant/file"
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
 	"dubbo.apache.org/dubbo-go/v3/global"
 )
 
@@ -44,4 +48,153 @@ func TestGetRuleKey(t *testing.T) {
 	url, err := common.NewURL("dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider?interface=test&group=groupA&version=0")
 	assert.NoError(t, err)
 	assert.Equal(t, "test:0:groupA", GetRuleKey(url))
+
+	tests := []struct {
+		name   string
+		params string
+		want   string
+	}{
+		{name: "version and group", params: "?version=1.0.0&group=groupA", want: "com.ikurento.user.UserProvider:1.0.0:groupA"},
+		{name: "version only", params: "?version=1.0.0", want: "com.ikurento.user.UserProvider:1.0.0"},
+		{name: "group only", params: "?group=groupA", want: "com.ikurento.user.UserProvider:groupA"},
+		{name: "neither", params: "", want: "com.ikurento.user.UserProvider"},
+		{name: "default version", params: "?version=0.0.0&group=groupA", want: "com.ikurento.user.UserProvider:groupA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, err := common.NewURL("dubbo://192.168.1.1:20000/com.ikurento.user.UserProvider" + tt.params)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, GetRuleKey(url))
+		})
+	}
+}
+
+func TestGetPropertiesBatch(t *testing.T) {
+	var running, maxRunning int32
+	get := func(key string, _ ...Option) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		if key == "bad" {
+			return "", errors.New("not found")
+		}
+		return key + "-value", nil
+	}
+
+	result, err := fanOutGetProperties(get, []string{"a", "b", "bad", "c"}, WithMaxConcurrency(2))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bad")
+	assert.Equal(t, map[string]string{"a": "a-value", "b": "b-value", "c": "c-value"}, result)
+	assert.LessOrEqual(t, maxRunning, int32(2))
+
+	result, err = GetPropertiesBatch(&MockDynamicConfiguration{content: "v"}, []string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "v", "b": "v"}, result)
+}
+
+func TestGetAndUnmarshal(t *testing.T) {
+	type application struct {
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+		Port    int    `yaml:"port"`
+	}
+	type root struct {
+		Application application `yaml:"application"`
+	}
+
+	dc := &MockDynamicConfiguration{}
+	dc.SetParser(&parser.DefaultConfigurationParser{})
+
+	dc.content = "application.name=demo\napplication.version=1.0.0\napplication.port=20000"
+	var fromProperties root
+	assert.NoError(t, GetAndUnmarshal(dc, "dubbo.properties", &fromProperties))
+	assert.Equal(t, application{Name: "demo", Version: "1.0.0", Port: 20000}, fromProperties.Application)
+
+	dc.content = "application:\n  name: demo\n  port: 20000\n"
+	var fromYaml root
+	assert.NoError(t, GetAndUnmarshal(dc, "dubbo.yaml", &fromYaml))
+	assert.Equal(t, application{Name: "demo", Port: 20000}, fromYaml.Application)
+
+	dc.content = `{"Application": {"Name": "demo"}}`
+	var fromJson root
+	assert.NoError(t, GetAndUnmarshal(dc, "dubbo", &fromJson, WithFormat(file.JSON)))
+	assert.Equal(t, "demo", fromJson.Application.Name)
+}
+
+func TestOptionsKeyNotFound(t *testing.T) {
+	_, err := NewOptions().KeyNotFound("key")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	value, err := NewOptions(WithDefault("x")).KeyNotFound("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "x", value)
+
+	value, err = NewOptions(WithDefault("")).KeyNotFound("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "", value)
+}
+
+func TestGetPropertiesFromGroupChain(t *testing.T) {
+	groups := map[string]string{
+		"dubbo":      "a=1\nb=2",
+		"dubbo.prod": "b=3\nc=4",
+	}
+	get := func(key string, opts ...Option) (string, error) {
+		tmpOpts := NewOptions(opts...)
+		assert.Empty(t, tmpOpts.GroupChain)
+		if content, ok := groups[tmpOpts.Center.Group]; ok {
+			return content, nil
+		}
+		return tmpOpts.KeyNotFound(key)
+	}
+
+	content, err := GetPropertiesFromGroupChain(get, nil, "key", WithGroupChain("dubbo.dev", "dubbo.prod", "dubbo"))
+	assert.NoError(t, err)
+	assert.Equal(t, groups["dubbo.prod"], content)
+
+	content, err = GetPropertiesFromGroupChain(get, nil, "key", WithGroupChain("dubbo", "dubbo.dev", "dubbo.prod"), WithMerge())
+	assert.NoError(t, err)
+	merged, err := (&parser.DefaultConfigurationParser{}).Parse(content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "1", "b": "3", "c": "4"}, merged)
+
+	_, err = GetPropertiesFromGroupChain(get, nil, "key", WithGroupChain("dubbo.dev"))
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	content, err = GetPropertiesFromGroupChain(get, nil, "key", WithGroupChain("dubbo.dev"), WithDefault("x"))
+	assert.NoError(t, err)
+	assert.Equal(t, "x", content)
+}
+
+type casConfiguration struct {
+	*MockDynamicConfiguration
+	value string
+}
+
+func (c *casConfiguration) PublishConfigCAS(_, _, expectedOld, newValue string) (bool, error) {
+	if c.value != expectedOld {
+		return false, nil
+	}
+	c.value = newValue
+	return true, nil
+}
+
+func TestPublishConfigCAS(t *testing.T) {
+	_, err := PublishConfigCAS(&MockDynamicConfiguration{}, "key", "group", "", "v1")
+	assert.True(t, errors.Is(err, ErrUnsupported))
+
+	dc := &casConfiguration{MockDynamicConfiguration: &MockDynamicConfiguration{}}
+	ok, err := PublishConfigCAS(dc, "key", "group", "", "v1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = PublishConfigCAS(dc, "key", "group", "v0", "v2")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "v1", dc.value)
 }
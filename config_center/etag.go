@@ -0,0 +1,48 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// ETag returns the etag of a config content, the hex sha256 of value. It's derived from the content only,
+// so that it's the same across processes and backends.
+func ETag(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetPropertiesIfChanged reads key from dc and returns the value with its etag, unless the etag is lastETag,
+// in which case changed is false and the value is left empty
+func GetPropertiesIfChanged(dc DynamicConfiguration, key, lastETag string, opts ...Option) (value string, etag string, changed bool, err error) {
+	return readIfChanged(dc.GetProperties, key, lastETag, opts...)
+}
+
+func readIfChanged(get func(string, ...Option) (string, error), key, lastETag string, opts ...Option) (value string, etag string, changed bool, err error) {
+	value, err = get(key, opts...)
+	if err != nil {
+		return "", "", false, err
+	}
+	etag = ETag(value)
+	if etag == lastETag {
+		return "", etag, false, nil
+	}
+	return value, etag, true, nil
+}
@@ -0,0 +1,59 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestETag(t *testing.T) {
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", ETag(""))
+	assert.Equal(t, ETag("a=b"), ETag("a=b"))
+	assert.NotEqual(t, ETag("a=b"), ETag("a=c"))
+}
+
+func TestGetPropertiesIfChanged(t *testing.T) {
+	dc := &MockDynamicConfiguration{content: "a=b"}
+
+	value, etag, changed, err := GetPropertiesIfChanged(dc, "key", "")
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "a=b", value)
+	assert.Equal(t, ETag("a=b"), etag)
+
+	value, etag2, changed, err := GetPropertiesIfChanged(dc, "key", etag)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Empty(t, value)
+	assert.Equal(t, etag, etag2)
+
+	dc.content = "a=c"
+	value, etag2, changed, err = GetPropertiesIfChanged(dc, "key", etag)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "a=c", value)
+	assert.NotEqual(t, etag, etag2)
+
+	_, _, _, err = readIfChanged(func(string, ...Option) (string, error) { return "", ErrKeyNotFound }, "key", etag)
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+}
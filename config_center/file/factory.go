@@ -45,6 +45,7 @@ func (f *fileDynamicConfigurationFactory) GetDynamicConfiguration(url *common.UR
 		return nil, perrors.WithStack(err)
 	}
 
-	dynamicConfiguration.SetParser(&parser.DefaultConfigurationParser{})
-	return dynamicConfiguration, err
+	dynamicConfiguration.SetParser(parser.NewCachingConfigurationParser(&parser.DefaultConfigurationParser{},
+		int(url.GetParamInt(constant.ConfigParseCacheSizeKey, parser.DefaultParseCacheSize))))
+	return config_center.ApplyKeyPrefix(dynamicConfiguration, url), err
 }
@@ -26,6 +26,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
 import (
@@ -59,7 +60,12 @@ type FileSystemDynamicConfiguration struct {
 	rootPath      string
 	encoding      string
 	cacheListener *CacheListener
+	pollers       config_center.PollingListeners
+	coalescers    config_center.CoalescingListeners
+	parsers       config_center.PatternParsers
+	lastGood      config_center.LastGoodRules
 	parser        parser.ConfigurationParser
+	observer      config_center.Observer
 }
 
 func newFileSystemDynamicConfiguration(url *common.URL) (*FileSystemDynamicConfiguration, error) {
@@ -77,9 +83,14 @@ func newFileSystemDynamicConfiguration(url *common.URL) (*FileSystemDynamicConfi
 		url:      url,
 		rootPath: root,
 		encoding: encode,
+		observer: config_center.GetObserver(url),
 	}
 
 	c.cacheListener = NewCacheListener(c.rootPath)
+	c.cacheListener.observer = c.observer
+	c.cacheListener.invalidate = func(content string) {
+		parser.InvalidateParsed(c.Parser(), content)
+	}
 
 	return c, nil
 }
@@ -94,18 +105,44 @@ func (fsdc *FileSystemDynamicConfiguration) Parser() parser.ConfigurationParser
 	return fsdc.parser
 }
 
+// CacheStats returns the statistics of the parse cache, which are zero when the cache is disabled
+func (fsdc *FileSystemDynamicConfiguration) CacheStats() parser.CacheStats {
+	stats, _ := parser.ParsedCacheStats(fsdc.Parser())
+	return stats
+}
+
+// ResetCacheStats zeroes the hits, misses and evictions of the parse cache
+func (fsdc *FileSystemDynamicConfiguration) ResetCacheStats() {
+	parser.ResetParsedCacheStats(fsdc.Parser())
+}
+
 // SetParser Set Parser
 func (fsdc *FileSystemDynamicConfiguration) SetParser(p parser.ConfigurationParser) {
 	fsdc.parser = p
 }
 
+// RegisterParserForPattern makes p the parser of the keys matching glob
+func (fsdc *FileSystemDynamicConfiguration) RegisterParserForPattern(glob string, p parser.ConfigurationParser) error {
+	return fsdc.parsers.Register(glob, p)
+}
+
+// ParserFor returns the parser of key, falling back to Parser
+func (fsdc *FileSystemDynamicConfiguration) ParserFor(key string) parser.ConfigurationParser {
+	return fsdc.parsers.Parser(key, fsdc.Parser())
+}
+
 // AddListener Add listener
 func (fsdc *FileSystemDynamicConfiguration) AddListener(key string, listener config_center.ConfigurationListener,
 	opts ...config_center.Option) {
 	tmpOpts := config_center.NewOptions(opts...)
 
 	tmpPath := fsdc.GetPath(key, tmpOpts.Center.Group)
+	listener = fsdc.coalescers.Add(tmpPath, listener, tmpOpts)
+	listener = fsdc.pollers.Add(tmpPath, listener, func() (string, error) {
+		return fsdc.GetProperties(key, opts...)
+	}, tmpOpts)
 	fsdc.cacheListener.AddListener(tmpPath, listener)
+	config_center.LoadInitial(listener)
 }
 
 // RemoveListener Remove listener
@@ -114,30 +151,111 @@ func (fsdc *FileSystemDynamicConfiguration) RemoveListener(key string, listener
 	tmpOpts := config_center.NewOptions(opts...)
 
 	tmpPath := fsdc.GetPath(key, tmpOpts.Center.Group)
-	fsdc.cacheListener.RemoveListener(tmpPath, listener)
+	fsdc.cacheListener.RemoveListener(tmpPath, fsdc.pollers.Remove(tmpPath, fsdc.coalescers.Remove(tmpPath, listener)))
+}
+
+// RemoveAllListeners removes all the listeners added by AddListener on key
+func (fsdc *FileSystemDynamicConfiguration) RemoveAllListeners(key string, opts ...config_center.Option) {
+	tmpOpts := config_center.NewOptions(opts...)
+
+	tmpPath := fsdc.GetPath(key, tmpOpts.Center.Group)
+	fsdc.pollers.RemoveKey(tmpPath)
+	fsdc.cacheListener.RemoveAllListeners(tmpPath)
+	fsdc.coalescers.RemoveKey(tmpPath)
+}
+
+// AddGroupListener adds a listener notified of the changes of any file of the directory of group
+func (fsdc *FileSystemDynamicConfiguration) AddGroupListener(group string, listener config_center.ConfigurationListener,
+	_ ...config_center.Option) {
+	fsdc.cacheListener.AddGroupListener(fsdc.groupPath(group), listener)
+}
+
+// RemoveGroupListener removes a listener added by AddGroupListener
+func (fsdc *FileSystemDynamicConfiguration) RemoveGroupListener(group string, listener config_center.ConfigurationListener,
+	_ ...config_center.Option) {
+	fsdc.cacheListener.RemoveGroupListener(fsdc.groupPath(group), listener)
+}
+
+// groupPath returns the directory of group, which is the default group if empty as for the keys
+func (fsdc *FileSystemDynamicConfiguration) groupPath(group string) string {
+	if len(group) == 0 {
+		group = config_center.DefaultGroup
+	}
+	return fsdc.GetPath("", group)
 }
 
 // GetProperties get properties file
 func (fsdc *FileSystemDynamicConfiguration) GetProperties(key string, opts ...config_center.Option) (string, error) {
 	tmpOpts := config_center.NewOptions(opts...)
+	if tmpOpts.Interpolation {
+		return config_center.GetPropertiesInterpolated(fsdc.GetProperties, key, opts...)
+	}
+	if len(tmpOpts.GroupChain) != 0 {
+		return config_center.GetPropertiesFromGroupChain(fsdc.GetProperties, fsdc.ParserFor(key), key, opts...)
+	}
+	start := time.Now()
+	value, err := fsdc.getProperties(key, tmpOpts)
+	config_center.ObserveRead(fsdc.observer, key, start, err)
+	config_center.LogRead(tmpOpts, key, value, err)
+	return value, err
+}
 
-	tmpPath := fsdc.GetPath(key, tmpOpts.Center.Group)
-	file, err := os.ReadFile(tmpPath)
+func (fsdc *FileSystemDynamicConfiguration) getProperties(key string, tmpOpts *config_center.Options) (string, error) {
+	value, _, err := fsdc.getPropertiesWithMeta(key, tmpOpts)
+	return value, err
+}
+
+// GetPropertiesWithMeta get properties file along with its modification time, the other fields of
+// config_center.ValueMeta being zero
+func (fsdc *FileSystemDynamicConfiguration) GetPropertiesWithMeta(key string, opts ...config_center.Option) (string, config_center.ValueMeta, error) {
+	tmpOpts := config_center.NewOptions(opts...)
+	start := time.Now()
+	value, meta, err := fsdc.getPropertiesWithMeta(key, tmpOpts)
+	config_center.ObserveRead(fsdc.observer, key, start, err)
+	config_center.LogRead(tmpOpts, key, value, err)
+	return value, meta, err
+}
+
+func (fsdc *FileSystemDynamicConfiguration) getPropertiesWithMeta(key string, tmpOpts *config_center.Options) (string, config_center.ValueMeta, error) {
+	f, err := os.Open(fsdc.GetPath(key, tmpOpts.Center.Group))
 	if err != nil {
-		return "", perrors.WithStack(err)
+		if os.IsNotExist(err) {
+			value, err := tmpOpts.KeyNotFound(key)
+			return value, config_center.ValueMeta{}, err
+		}
+		return "", config_center.ValueMeta{}, perrors.WithStack(err)
+	}
+	defer f.Close()
+	// the stat of the opened file matches the content read even if the file is replaced meanwhile
+	info, err := f.Stat()
+	if err != nil {
+		return "", config_center.ValueMeta{}, perrors.WithStack(err)
+	}
+	if err := tmpOpts.CheckValueSize(key, info.Size()); err != nil {
+		return "", config_center.ValueMeta{}, err
+	}
+	// the file may grow after the stat, so the read is bounded as well
+	content, err := tmpOpts.ReadValue(key, f)
+	if err != nil {
+		return "", config_center.ValueMeta{}, perrors.WithStack(err)
+	}
+	value, err := tmpOpts.Decompress(key, string(content))
+	if err != nil {
+		return "", config_center.ValueMeta{}, err
 	}
-	return string(file), nil
+	return value, config_center.ValueMeta{ModifiedAt: info.ModTime()}, nil
 }
 
 // GetRule get Router rule properties file
 func (fsdc *FileSystemDynamicConfiguration) GetRule(key string, opts ...config_center.Option) (string, error) {
-	return fsdc.GetProperties(key, opts...)
+	rule, err := fsdc.GetProperties(key, opts...)
+	return fsdc.lastGood.Check(key, config_center.NewOptions(opts...), fsdc.ParserFor(key), rule, err)
 }
 
-// GetInternalProperty get value by key in Default properties file(dubbo.properties)
+// GetInternalProperty get value by key in Default properties file(dubbo.properties), which is never compressed
 func (fsdc *FileSystemDynamicConfiguration) GetInternalProperty(key string, opts ...config_center.Option) (string,
 	error) {
-	return fsdc.GetProperties(key, opts...)
+	return fsdc.GetProperties(key, append(append([]config_center.Option{}, opts...), config_center.WithCompression(nil))...)
 }
 
 // PublishConfig will publish the config with the (key, group, value) pair
@@ -165,6 +283,12 @@ func (fsdc *FileSystemDynamicConfiguration) GetConfigKeysByGroup(group string) (
 	return r, nil
 }
 
+// Snapshot returns the values of all the keys of groups, which are required since the groups can't be
+// listed, see config_center.SnapshotGroups
+func (fsdc *FileSystemDynamicConfiguration) Snapshot(groups ...string) (map[string]string, error) {
+	return config_center.SnapshotGroups(fsdc, groups...)
+}
+
 // RemoveConfig will remove tconfig_center/nacos/impl_testhe config whit hte (key, group)
 func (fsdc *FileSystemDynamicConfiguration) RemoveConfig(key string, group string) error {
 	tmpPath := fsdc.GetPath(key, group)
@@ -174,6 +298,8 @@ func (fsdc *FileSystemDynamicConfiguration) RemoveConfig(key string, group strin
 
 // Close close file watcher
 func (fsdc *FileSystemDynamicConfiguration) Close() error {
+	fsdc.pollers.StopAll()
+	fsdc.coalescers.StopAll()
 	return fsdc.cacheListener.Close()
 }
 
@@ -18,20 +18,34 @@
 package file
 
 import (
-	"fmt"
+	"errors"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
 
 import (
+	"github.com/dubbogo/gost/log/logger"
+
 	"github.com/stretchr/testify/assert"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 import (
 	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
 	"dubbo.apache.org/dubbo-go/v3/common/extension"
 	"dubbo.apache.org/dubbo-go/v3/config_center"
+	"dubbo.apache.org/dubbo-go/v3/remoting"
 )
 
 const (
@@ -71,14 +85,13 @@ func TestAddListener(t *testing.T) {
 	err = file.PublishConfig(key, group, value)
 	assert.NoError(t, err)
 
-	listener := &mockDataListener{}
+	listener := &eventsListener{events: make(chan *config_center.ConfigChangeEvent, 16)}
 	file.AddListener(key, listener, config_center.WithGroup(group))
 
 	value = "Test Value 2"
 	err = file.PublishConfig(key, group, value)
 	assert.NoError(t, err)
-	// remove need wait a moment
-	time.Sleep(time.Second)
+	listener.waitEvent(t, remoting.EventTypeUpdate, value)
 	defer destroy(file.rootPath, file)
 }
 
@@ -90,21 +103,26 @@ func TestRemoveListener(t *testing.T) {
 	err = file.PublishConfig(key, group, value)
 	assert.NoError(t, err)
 
-	listener := &mockDataListener{}
+	listener := &eventsListener{events: make(chan *config_center.ConfigChangeEvent, 16)}
 	file.AddListener(key, listener, config_center.WithGroup(group))
+	// the sentinel sees the same events, once it sees a write the listener has been notified of it too
+	sentinel := &eventsListener{events: make(chan *config_center.ConfigChangeEvent, 16)}
+	file.AddListener(key, sentinel, config_center.WithGroup(group))
 
 	value = "Test Value 2"
 	err = file.PublishConfig(key, group, value)
 	assert.NoError(t, err)
+	listener.waitEvent(t, remoting.EventTypeUpdate, value)
 
-	// make sure callback before RemoveListener
-	time.Sleep(time.Second)
 	file.RemoveListener(key, listener, config_center.WithGroup(group))
 	value = "Test Value 3"
 	err = file.PublishConfig(key, group, value)
 	assert.NoError(t, err)
-	// remove need wait a moment
-	time.Sleep(time.Second)
+	sentinel.waitEvent(t, remoting.EventTypeUpdate, value)
+	for len(listener.events) > 0 {
+		e := <-listener.events
+		assert.NotEqual(t, value, e.Value)
+	}
 	defer destroy(file.rootPath, file)
 }
 
@@ -119,8 +137,6 @@ func TestGetConfigKeysByGroup(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 1, gs.Size())
 	assert.Equal(t, key, gs.Values()[0])
-	// remove need wait a moment
-	time.Sleep(time.Second)
 	defer destroy(file.rootPath, file)
 }
 
@@ -137,6 +153,19 @@ func TestGetConfig(t *testing.T) {
 	defer destroy(file.rootPath, file)
 }
 
+func TestGetMissingConfig(t *testing.T) {
+	file, err := initFileData(t)
+	assert.NoError(t, err)
+	defer destroy(file.rootPath, file)
+
+	_, err = file.GetProperties("missing.key", config_center.WithGroup("dubbogo"))
+	assert.ErrorIs(t, err, config_center.ErrKeyNotFound)
+
+	prop, err := file.GetProperties("missing.key", config_center.WithGroup("dubbogo"), config_center.WithDefault("x"))
+	assert.NoError(t, err)
+	assert.Equal(t, "x", prop)
+}
+
 func TestPublishConfig(t *testing.T) {
 	file, err := initFileData(t)
 	assert.NoError(t, err)
@@ -150,13 +179,426 @@ func TestPublishConfig(t *testing.T) {
 	defer destroy(file.rootPath, file)
 }
 
+type valueListener struct {
+	value atomic.Value
+}
+
+func (l *valueListener) Process(event *config_center.ConfigChangeEvent) {
+	if value, ok := event.Value.(string); ok {
+		l.value.Store(value)
+	}
+}
+
+func TestPublisherRoundTrip(t *testing.T) {
+	file, err := initFileData(t)
+	assert.NoError(t, err)
+	defer destroy(file.rootPath, file)
+	group := "dubbogo"
+
+	var publisher config_center.ConfigurationPublisher = file
+	assert.NoError(t, publisher.PublishConfig(key, group, "A"))
+	listener := &valueListener{}
+	file.AddListener(key, listener, config_center.WithGroup(group))
+
+	assert.NoError(t, file.PublishConfig(key, group, "B"))
+	assert.Eventually(t, func() bool { return listener.value.Load() == "B" }, 5*time.Second, 10*time.Millisecond)
+	prop, err := file.GetProperties(key, config_center.WithGroup(group))
+	assert.NoError(t, err)
+	assert.Equal(t, "B", prop)
+
+	assert.NoError(t, file.RemoveConfig(key, group))
+	_, err = file.GetProperties(key, config_center.WithGroup(group))
+	assert.ErrorIs(t, err, config_center.ErrKeyNotFound)
+}
+
+func newPrefixedFileData(t *testing.T, prefix string) (config_center.DynamicConfiguration, *FileSystemDynamicConfiguration) {
+	regurl, err := common.NewURL("registry://127.0.0.1:2181",
+		common.WithParamsValue(constant.ConfigKeyPrefixKey, prefix))
+	assert.NoError(t, err)
+	factory, err := extension.GetConfigCenterFactory("file")
+	assert.NoError(t, err)
+	dc, err := factory.GetDynamicConfiguration(regurl)
+	assert.NoError(t, err)
+	return dc, dc.(*config_center.PrefixedConfiguration).Unwrap().(*FileSystemDynamicConfiguration)
+}
+
+func TestKeyPrefixIsolation(t *testing.T) {
+	tenantA, fileA := newPrefixedFileData(t, "tenant-a.")
+	defer destroy(fileA.rootPath, fileA)
+	tenantB, fileB := newPrefixedFileData(t, "tenant-b.")
+	defer fileB.Close()
+	group := "dubbogo"
+
+	listenerA, listenerB := &valueListener{}, &valueListener{}
+	tenantA.AddListener(key, listenerA, config_center.WithGroup(group))
+	tenantB.AddListener(key, listenerB, config_center.WithGroup(group))
+
+	assert.NoError(t, tenantA.PublishConfig(key, group, "A"))
+	assert.Eventually(t, func() bool { return listenerA.value.Load() == "A" }, 5*time.Second, 10*time.Millisecond)
+	prop, err := tenantA.GetProperties(key, config_center.WithGroup(group))
+	assert.NoError(t, err)
+	assert.Equal(t, "A", prop)
+	prop, err = fileA.GetProperties("tenant-a."+key, config_center.WithGroup(group))
+	assert.NoError(t, err)
+	assert.Equal(t, "A", prop)
+
+	_, err = tenantB.GetProperties(key, config_center.WithGroup(group))
+	assert.ErrorIs(t, err, config_center.ErrKeyNotFound)
+	// tenant B's watch delivers in order, so the write of tenant A is handled once a later write is seen
+	sentinel := &eventsListener{events: make(chan *config_center.ConfigChangeEvent, 16)}
+	tenantB.AddListener("sentinel", sentinel, config_center.WithGroup(group))
+	assert.NoError(t, tenantB.PublishConfig("sentinel", group, "B"))
+	sentinel.waitEvent(t, remoting.EventTypeUpdate, "B")
+	assert.Nil(t, listenerB.value.Load())
+
+	keys, err := tenantA.GetConfigKeysByGroup(group)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{key}, keys.Values())
+}
+
+type eventsListener struct {
+	events chan *config_center.ConfigChangeEvent
+}
+
+func (l *eventsListener) Process(event *config_center.ConfigChangeEvent) {
+	l.events <- event
+}
+
+// waitEvent waits for an event of type typ with value and returns it
+func (l *eventsListener) waitEvent(t *testing.T, typ remoting.EventType, value string) *config_center.ConfigChangeEvent {
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-l.events:
+			if e.ConfigType == typ && e.Value == value {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("no %v event with value %q", typ, value)
+			return nil
+		}
+	}
+}
+
+func TestTempDirWatch(t *testing.T) {
+	dir := t.TempDir()
+	regurl, err := common.NewURL("registry://127.0.0.1:2181", common.WithParamsValue(ConfigCenterDirParamName, dir))
+	assert.NoError(t, err)
+	factory, err := extension.GetConfigCenterFactory("file")
+	assert.NoError(t, err)
+	dc, err := factory.GetDynamicConfiguration(regurl)
+	assert.NoError(t, err)
+	file := dc.(*FileSystemDynamicConfiguration)
+	defer file.Close()
+	assert.Equal(t, dir, file.RootPath())
+
+	group := "dubbogo"
+	// the key doesn't exist yet, its creation is notified
+	listener := &eventsListener{events: make(chan *config_center.ConfigChangeEvent, 16)}
+	file.AddListener(key, listener, config_center.WithGroup(group))
+	path := filepath.Join(dir, group, key)
+
+	assert.NoError(t, os.WriteFile(path, []byte("A"), 0o600))
+	listener.waitEvent(t, remoting.EventTypeUpdate, "A")
+	prop, err := file.GetRule(key, config_center.WithGroup(group))
+	assert.NoError(t, err)
+	assert.Equal(t, "A", prop)
+
+	assert.NoError(t, os.WriteFile(path, []byte("B"), 0o600))
+	listener.waitEvent(t, remoting.EventTypeUpdate, "B")
+	prop, err = file.GetProperties(key, config_center.WithGroup(group))
+	assert.NoError(t, err)
+	assert.Equal(t, "B", prop)
+
+	assert.NoError(t, os.Remove(path))
+	listener.waitEvent(t, remoting.EventTypeDel, "")
+	_, err = file.GetProperties(key, config_center.WithGroup(group))
+	assert.ErrorIs(t, err, config_center.ErrKeyNotFound)
+}
+
+func TestGroupListener(t *testing.T) {
+	dir := t.TempDir()
+	regurl, err := common.NewURL("registry://127.0.0.1:2181", common.WithParamsValue(ConfigCenterDirParamName, dir))
+	assert.NoError(t, err)
+	factory, err := extension.GetConfigCenterFactory("file")
+	assert.NoError(t, err)
+	dc, err := factory.GetDynamicConfiguration(regurl)
+	assert.NoError(t, err)
+	file := dc.(*FileSystemDynamicConfiguration)
+	defer file.Close()
+
+	group := "flags"
+	listener := &eventsListener{events: make(chan *config_center.ConfigChangeEvent, 16)}
+	assert.NoError(t, config_center.AddGroupListener(file, group, listener))
+
+	assert.NoError(t, file.PublishConfig("flag-a", group, "on"))
+	assert.Equal(t, "flag-a", listener.waitEvent(t, remoting.EventTypeUpdate, "on").Key)
+	assert.NoError(t, file.PublishConfig("flag-b", group, "off"))
+	assert.Equal(t, "flag-b", listener.waitEvent(t, remoting.EventTypeUpdate, "off").Key)
+	assert.NoError(t, file.RemoveConfig("flag-a", group))
+	assert.Equal(t, "flag-a", listener.waitEvent(t, remoting.EventTypeDel, "").Key)
+
+	// the watch delivers in order, so the write of flag-c is handled once the later write of the
+	// sentinel is seen
+	sentinel := &eventsListener{events: make(chan *config_center.ConfigChangeEvent, 16)}
+	file.AddListener("sentinel", sentinel, config_center.WithGroup(group))
+	assert.NoError(t, config_center.RemoveGroupListener(file, group, listener))
+	assert.NoError(t, file.PublishConfig("flag-c", group, "on"))
+	assert.NoError(t, file.PublishConfig("sentinel", group, "on"))
+	sentinel.waitEvent(t, remoting.EventTypeUpdate, "on")
+	for len(listener.events) > 0 {
+		e := <-listener.events
+		assert.NotEqual(t, "flag-c", e.Key)
+	}
+}
+
+// lateListener counts the events delivered after removed is set
+type lateListener struct {
+	removed *atomic.Bool
+	events  atomic.Int32
+	late    atomic.Int32
+}
+
+func (l *lateListener) Process(*config_center.ConfigChangeEvent) {
+	l.events.Add(1)
+	// let the removal run while the event is in flight
+	runtime.Gosched()
+	if l.removed.Load() {
+		l.late.Add(1)
+	}
+}
+
+func TestRemoveAllListenersWhileWriting(t *testing.T) {
+	dir := t.TempDir()
+	regurl, err := common.NewURL("registry://127.0.0.1:2181", common.WithParamsValue(ConfigCenterDirParamName, dir))
+	assert.NoError(t, err)
+	factory, err := extension.GetConfigCenterFactory("file")
+	assert.NoError(t, err)
+	dc, err := factory.GetDynamicConfiguration(regurl)
+	assert.NoError(t, err)
+	file := dc.(*FileSystemDynamicConfiguration)
+	defer file.Close()
+
+	group := "dubbogo"
+	var removed atomic.Bool
+	listeners := make([]*lateListener, 3)
+	for i := range listeners {
+		listeners[i] = &lateListener{removed: &removed}
+		file.AddListener(key, listeners[i], config_center.WithGroup(group), config_center.WithPollInterval(time.Millisecond))
+	}
+
+	done := make(chan struct{})
+	var (
+		wg     sync.WaitGroup
+		writes atomic.Int32
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			assert.NoError(t, file.PublishConfig(key, group, strconv.Itoa(i)))
+			writes.Add(1)
+		}
+	}()
+
+	assert.Eventually(t, func() bool {
+		for _, l := range listeners {
+			if l.events.Load() == 0 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, config_center.RemoveAllListeners(file, key, config_center.WithGroup(group)))
+	removed.Store(true)
+	// keep writing, neither the watch nor the pollers may deliver an event now
+	removedAt := writes.Load()
+	assert.Eventually(t, func() bool { return writes.Load() >= removedAt+20 }, 5*time.Second, time.Millisecond)
+	close(done)
+	wg.Wait()
+	// the watch delivers in order, so the writes are handled once the later write of the sentinel is seen
+	sentinel := &eventsListener{events: make(chan *config_center.ConfigChangeEvent, 16)}
+	file.AddListener("sentinel", sentinel, config_center.WithGroup(group))
+	assert.NoError(t, file.PublishConfig("sentinel", group, "on"))
+	sentinel.waitEvent(t, remoting.EventTypeUpdate, "on")
+	for _, l := range listeners {
+		assert.Zero(t, l.late.Load())
+	}
+}
+
+type countingObserver struct {
+	reads  int32
+	errors int32
+}
+
+func (o *countingObserver) OnRead(_ string, _ time.Duration, err error) {
+	atomic.AddInt32(&o.reads, 1)
+	if err != nil {
+		atomic.AddInt32(&o.errors, 1)
+	}
+}
+
+func (o *countingObserver) OnEvent(string) {}
+
+func TestObserver(t *testing.T) {
+	observer := &countingObserver{}
+	regurl, err := common.NewURL("registry://127.0.0.1:2181", config_center.WithObserver(observer))
+	assert.NoError(t, err)
+	factory, err := extension.GetConfigCenterFactory("file")
+	assert.NoError(t, err)
+	dc, err := factory.GetDynamicConfiguration(regurl)
+	assert.NoError(t, err)
+	file := dc.(*FileSystemDynamicConfiguration)
+	defer destroy(file.rootPath, file)
+
+	assert.NoError(t, file.PublishConfig(key, "dubbogo", "A"))
+	_, err = file.GetProperties(key, config_center.WithGroup("dubbogo"))
+	assert.NoError(t, err)
+	_, err = file.GetProperties("missing.key", config_center.WithGroup("dubbogo"))
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&observer.reads))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&observer.errors))
+}
+
+func TestMaxValueSize(t *testing.T) {
+	file, err := initFileData(t)
+	assert.NoError(t, err)
+	defer destroy(file.rootPath, file)
+	assert.NoError(t, file.PublishConfig(key, "", strings.Repeat("x", 1<<10)))
+
+	_, err = file.GetProperties(key, config_center.WithMaxValueSize(1<<10-1))
+	assert.True(t, errors.Is(err, config_center.ErrValueTooLarge))
+	_, err = file.GetRule(key, config_center.WithMaxValueSize(1<<10-1))
+	assert.True(t, errors.Is(err, config_center.ErrValueTooLarge))
+
+	value, err := file.GetProperties(key, config_center.WithMaxValueSize(1<<10))
+	assert.NoError(t, err)
+	assert.Len(t, value, 1<<10)
+}
+
+func TestNoParserConfigured(t *testing.T) {
+	file, err := initFileData(t)
+	assert.NoError(t, err)
+	defer destroy(file.rootPath, file)
+	file.SetParser(nil)
+	assert.NoError(t, file.PublishConfig(key, "", "dubbo.application.name=foo"))
+
+	rule, err := file.GetRule(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "dubbo.application.name=foo", rule)
+	_, err = file.GetRule(key, config_center.WithLastGoodFallback())
+	assert.True(t, errors.Is(err, config_center.ErrNoParserConfigured))
+
+	// the unmarshal helpers fall back to the default parser
+	var out struct {
+		Dubbo struct {
+			Application struct {
+				Name string `yaml:"name"`
+			} `yaml:"application"`
+		} `yaml:"dubbo"`
+	}
+	assert.NoError(t, config_center.GetAndUnmarshal(file, key, &out))
+	assert.Equal(t, "foo", out.Dubbo.Application.Name)
+}
+
 func destroy(path string, fdc *FileSystemDynamicConfiguration) {
 	fdc.Close()
 	os.RemoveAll(path)
 }
 
-type mockDataListener struct{}
+func TestGetCompressedConfig(t *testing.T) {
+	file, err := initFileData(t)
+	assert.NoError(t, err)
+	defer destroy(file.rootPath, file)
+
+	err = config_center.PublishCompressedConfig(file, key, "", "A", config_center.WithCompression(config_center.GzipCodec))
+	assert.NoError(t, err)
+
+	prop, err := file.GetProperties(key, config_center.WithCompression(config_center.GzipCodec))
+	assert.NoError(t, err)
+	assert.Equal(t, "A", prop)
+
+	raw, err := file.GetInternalProperty(key, config_center.WithCompression(config_center.GzipCodec))
+	assert.NoError(t, err)
+	assert.NotEqual(t, "A", raw)
+	assert.True(t, config_center.GzipCodec.Detect([]byte(raw)))
+}
+
+func TestGetPropertiesWithMeta(t *testing.T) {
+	file, err := initFileData(t)
+	assert.NoError(t, err)
+	defer destroy(file.rootPath, file)
+
+	before := time.Now().Add(-time.Second)
+	assert.NoError(t, file.PublishConfig(key, "", "A"))
+
+	value, meta, err := file.GetPropertiesWithMeta(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "A", value)
+	assert.True(t, meta.ModifiedAt.After(before))
+	assert.Zero(t, meta.Revision)
+
+	_, meta, err = file.GetPropertiesWithMeta("missing", config_center.WithDefault("B"))
+	assert.NoError(t, err)
+	assert.Zero(t, meta)
+}
+
+func TestGetRuleLastGoodFallback(t *testing.T) {
+	file, err := initFileData(t)
+	assert.NoError(t, err)
+	defer destroy(file.rootPath, file)
+
+	good := "configVersion: v2.7\nscope: application\nkey: demo\nenabled: true\n"
+	assert.NoError(t, file.PublishConfig(key, "", good))
+	rule, err := file.GetRule(key, config_center.WithLastGoodFallback())
+	assert.NoError(t, err)
+	assert.Equal(t, good, rule)
+
+	assert.NoError(t, file.PublishConfig(key, "", "scope: [application"))
+	rule, err = file.GetRule(key, config_center.WithLastGoodFallback())
+	assert.True(t, errors.Is(err, config_center.ErrLastGoodRule))
+	assert.Equal(t, good, rule)
+
+	rule, err = file.GetRule(key)
+	assert.NoError(t, err)
+	assert.Equal(t, "scope: [application", rule)
+
+	_, err = file.GetRule("other", config_center.WithLastGoodFallback())
+	assert.True(t, errors.Is(err, config_center.ErrKeyNotFound))
+}
+
+func TestReadLogsRedactSecrets(t *testing.T) {
+	file, err := initFileData(t)
+	assert.NoError(t, err)
+	defer destroy(file.rootPath, file)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	old := logger.GetLogger()
+	logger.SetLogger(zap.New(core).Sugar())
+	defer logger.SetLogger(old)
+
+	assert.NoError(t, file.PublishConfig("db.password", "", "s3cr3t"))
+	assert.NoError(t, file.PublishConfig("db.url", "", "mysql://db"))
+	value, err := file.GetProperties("db.password")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", value)
+	_, err = file.GetProperties("db.url")
+	assert.NoError(t, err)
+	_, err = file.GetProperties("db.url", config_center.WithSecretKeyMatcher(func(key string) bool { return key == "db.url" }))
+	assert.NoError(t, err)
 
-func (l *mockDataListener) Process(configType *config_center.ConfigChangeEvent) {
-	fmt.Printf("process!!!!! %v", configType)
+	var messages []string
+	for _, entry := range logs.All() {
+		assert.NotContains(t, entry.Message, "s3cr3t")
+		messages = append(messages, entry.Message)
+	}
+	assert.Contains(t, messages, "[Config Center] read key db.password: "+config_center.RedactedValue)
+	assert.Contains(t, messages, "[Config Center] read key db.url: mysql://db")
+	assert.Contains(t, messages, "[Config Center] read key db.url: "+config_center.RedactedValue)
 }
@@ -19,6 +19,7 @@ package file
 
 import (
 	"os"
+	"path/filepath"
 	"sync"
 )
 
@@ -36,14 +37,26 @@ import (
 
 // CacheListener is file watcher
 type CacheListener struct {
-	watch        *fsnotify.Watcher
+	watch *fsnotify.Watcher
+	// keyListeners is copied on write, listenerLock serializes the writers
 	keyListeners sync.Map
-	rootPath     string
+	// groupListeners maps the group directories to their listeners, copied on write as well
+	groupListeners sync.Map
+	listenerLock   sync.Mutex
+	barrier        config_center.ListenerBarrier
+	// dirRefs counts the watched keys and groups of each group directory, the directories rather than the
+	// files are watched so that the keys created after AddListener are notified too
+	dirRefs  map[string]int
+	values   config_center.ValueCache
+	observer config_center.Observer
+	rootPath string
+	// invalidate drops the parsed result of a replaced content
+	invalidate func(content string)
 }
 
 // NewCacheListener creates a new CacheListener
 func NewCacheListener(rootPath string) *CacheListener {
-	cl := &CacheListener{rootPath: rootPath}
+	cl := &CacheListener{rootPath: rootPath, dirRefs: make(map[string]int)}
 	// start watcher
 	watch, err := fsnotify.NewWatcher()
 	if err != nil {
@@ -53,24 +66,16 @@ func NewCacheListener(rootPath string) *CacheListener {
 		for {
 			select {
 			case event := <-watch.Events:
-				key := event.Name
 				logger.Debugf("watcher %s, event %v", cl.rootPath, event)
 				if event.Op&fsnotify.Write == fsnotify.Write {
-					if l, ok := cl.keyListeners.Load(key); ok {
-						dataChangeCallback(l.(map[config_center.ConfigurationListener]struct{}), key,
-							remoting.EventTypeUpdate)
-					}
+					cl.notify(event.Name, remoting.EventTypeUpdate)
 				}
 				if event.Op&fsnotify.Create == fsnotify.Create {
-					if l, ok := cl.keyListeners.Load(key); ok {
-						dataChangeCallback(l.(map[config_center.ConfigurationListener]struct{}), key,
-							remoting.EventTypeAdd)
-					}
+					cl.notify(event.Name, remoting.EventTypeAdd)
 				}
-				if event.Op&fsnotify.Remove == fsnotify.Remove {
-					if l, ok := cl.keyListeners.Load(key); ok {
-						removeCallback(l.(map[config_center.ConfigurationListener]struct{}), key, remoting.EventTypeDel)
-					}
+				// a file renamed away is gone from its path, as a removed one
+				if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+					cl.notify(event.Name, remoting.EventTypeDel)
 				}
 			case err := <-watch.Errors:
 				// err may be nil, ignore
@@ -89,29 +94,46 @@ func NewCacheListener(rootPath string) *CacheListener {
 	return cl
 }
 
-func removeCallback(lmap map[config_center.ConfigurationListener]struct{}, key string, event remoting.EventType) {
-	if len(lmap) == 0 {
-		logger.Warnf("file watch callback but configuration listener is empty, key:%s, event:%v", key, event)
-		return
-	}
-	for l := range lmap {
-		callback(l, key, "", event)
-	}
+// notify delivers the change of the file at key to its listeners and to the group listeners of its directory
+func (cl *CacheListener) notify(key string, event remoting.EventType) {
+	cl.barrier.Deliver(func() {
+		cl.deliver(key, event)
+	})
 }
 
-func dataChangeCallback(lmap map[config_center.ConfigurationListener]struct{}, key string, event remoting.EventType) {
-	if len(lmap) == 0 {
-		logger.Warnf("file watch callback but configuration listener is empty, key:%s, event:%v", key, event)
+func (cl *CacheListener) deliver(key string, event remoting.EventType) {
+	var keyListeners, groupListeners map[config_center.ConfigurationListener]struct{}
+	if l, ok := cl.keyListeners.Load(key); ok {
+		keyListeners = l.(map[config_center.ConfigurationListener]struct{})
+	}
+	if l, ok := cl.groupListeners.Load(filepath.Dir(key)); ok {
+		groupListeners = l.(map[config_center.ConfigurationListener]struct{})
+	}
+	if len(keyListeners) == 0 && len(groupListeners) == 0 {
 		return
 	}
-	c := getFileContent(key)
-	for l := range lmap {
-		callback(l, key, c, event)
+	config_center.ObserveEvent(cl.observer, key)
+	var content string
+	if event != remoting.EventTypeDel {
+		content = getFileContent(key)
+	}
+	changeEvent := cl.values.NewChangeEvent(key, content, event)
+	if cl.invalidate != nil {
+		cl.invalidate(changeEvent.OldValue)
+	}
+	for l := range keyListeners {
+		callback(l, changeEvent)
+	}
+	// the events of the group listeners carry the key within the group rather than the path
+	changeEvent.Key = filepath.Base(key)
+	for l := range groupListeners {
+		callback(l, changeEvent)
 	}
 }
 
-func callback(listener config_center.ConfigurationListener, path, data string, event remoting.EventType) {
-	listener.Process(&config_center.ConfigChangeEvent{Key: path, Value: data, ConfigType: event})
+func callback(listener config_center.ConfigurationListener, event *config_center.ConfigChangeEvent) {
+	e := *event
+	listener.Process(&e)
 }
 
 // Close will remove key listener and close watcher
@@ -120,36 +142,138 @@ func (cl *CacheListener) Close() error {
 		cl.keyListeners.Delete(key)
 		return true
 	})
+	cl.groupListeners.Range(func(dir, value any) bool {
+		cl.groupListeners.Delete(dir)
+		return true
+	})
 	return cl.watch.Close()
 }
 
-// AddListener will add a listener if loaded
-// if you watcher a file or directory not exist, will error with no such file or directory
+// AddListener will add a listener if loaded, adding the same listener for the same key again is a no-op.
+// The key doesn't need to exist, its creation is notified as an add event.
 func (cl *CacheListener) AddListener(key string, listener config_center.ConfigurationListener) {
+	cl.listenerLock.Lock()
+	defer cl.listenerLock.Unlock()
 	// reference from https://stackoverflow.com/questions/34018908/golang-why-dont-we-have-a-set-datastructure
 	// make a map[your type]struct{} like set in java
-	listeners, loaded := cl.keyListeners.LoadOrStore(key, map[config_center.ConfigurationListener]struct{}{
-		listener: {},
-	})
+	listeners := map[config_center.ConfigurationListener]struct{}{}
+	old, loaded := cl.keyListeners.Load(key)
 	if loaded {
-		listeners.(map[config_center.ConfigurationListener]struct{})[listener] = struct{}{}
+		if _, ok := old.(map[config_center.ConfigurationListener]struct{})[listener]; ok {
+			return
+		}
+		for l := range old.(map[config_center.ConfigurationListener]struct{}) {
+			listeners[l] = struct{}{}
+		}
+	}
+	listeners[listener] = struct{}{}
+	cl.keyListeners.Store(key, listeners)
+	if !loaded {
+		cl.refDir(filepath.Dir(key))
+	}
+}
+
+// RemoveListener will delete a listener if loaded, the directory of the file is no longer watched once the
+// last listener of its files is removed
+func (cl *CacheListener) RemoveListener(key string, listener config_center.ConfigurationListener) {
+	cl.listenerLock.Lock()
+	defer cl.listenerLock.Unlock()
+	old, loaded := cl.keyListeners.Load(key)
+	if !loaded {
+		return
+	}
+	listeners := map[config_center.ConfigurationListener]struct{}{}
+	for l := range old.(map[config_center.ConfigurationListener]struct{}) {
+		if l != listener {
+			listeners[l] = struct{}{}
+		}
+	}
+	if len(listeners) != 0 {
 		cl.keyListeners.Store(key, listeners)
 		return
 	}
-	if err := cl.watch.Add(key); err != nil {
-		logger.Errorf("watcher add path:%s err:%v", key, err)
+	cl.keyListeners.Delete(key)
+	cl.unrefDir(filepath.Dir(key))
+}
+
+// RemoveAllListeners will delete all the listeners of the key, the directory of the file is no longer watched
+// once the last listener of its files is removed. No event is delivered to them once it returns.
+func (cl *CacheListener) RemoveAllListeners(key string) {
+	cl.listenerLock.Lock()
+	if _, loaded := cl.keyListeners.LoadAndDelete(key); loaded {
+		cl.unrefDir(filepath.Dir(key))
 	}
+	cl.listenerLock.Unlock()
+	cl.barrier.Wait()
 }
 
-// RemoveListener will delete a listener if loaded
-func (cl *CacheListener) RemoveListener(key string, listener config_center.ConfigurationListener) {
-	listeners, loaded := cl.keyListeners.Load(key)
+// AddGroupListener will add a listener notified of the changes of the files of the directory dir, adding the
+// same listener for the same directory again is a no-op
+func (cl *CacheListener) AddGroupListener(dir string, listener config_center.ConfigurationListener) {
+	cl.listenerLock.Lock()
+	defer cl.listenerLock.Unlock()
+	listeners := map[config_center.ConfigurationListener]struct{}{}
+	old, loaded := cl.groupListeners.Load(dir)
+	if loaded {
+		if _, ok := old.(map[config_center.ConfigurationListener]struct{})[listener]; ok {
+			return
+		}
+		for l := range old.(map[config_center.ConfigurationListener]struct{}) {
+			listeners[l] = struct{}{}
+		}
+	}
+	listeners[listener] = struct{}{}
+	cl.groupListeners.Store(dir, listeners)
 	if !loaded {
+		cl.refDir(dir)
+	}
+}
+
+// RemoveGroupListener will delete a listener added by AddGroupListener if loaded
+func (cl *CacheListener) RemoveGroupListener(dir string, listener config_center.ConfigurationListener) {
+	cl.listenerLock.Lock()
+	defer cl.listenerLock.Unlock()
+	old, loaded := cl.groupListeners.Load(dir)
+	if !loaded {
+		return
+	}
+	listeners := map[config_center.ConfigurationListener]struct{}{}
+	for l := range old.(map[config_center.ConfigurationListener]struct{}) {
+		if l != listener {
+			listeners[l] = struct{}{}
+		}
+	}
+	if len(listeners) != 0 {
+		cl.groupListeners.Store(dir, listeners)
+		return
+	}
+	cl.groupListeners.Delete(dir)
+	cl.unrefDir(dir)
+}
+
+// refDir watches dir unless it's watched already, listenerLock must be held
+func (cl *CacheListener) refDir(dir string) {
+	cl.dirRefs[dir]++
+	if cl.dirRefs[dir] > 1 {
+		return
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		logger.Errorf("watcher create path:%s err:%v", dir, err)
+	}
+	if err := cl.watch.Add(dir); err != nil {
+		logger.Errorf("watcher add path:%s err:%v", dir, err)
+	}
+}
+
+// unrefDir stops watching dir once it's no longer referenced, listenerLock must be held
+func (cl *CacheListener) unrefDir(dir string) {
+	cl.dirRefs[dir]--
+	if cl.dirRefs[dir] > 0 {
 		return
 	}
-	delete(listeners.(map[config_center.ConfigurationListener]struct{}), listener)
-	if err := cl.watch.Remove(key); err != nil {
-		logger.Errorf("watcher remove path:%s err:%v", key, err)
+	delete(cl.dirRefs, dir)
+	if err := cl.watch.Remove(dir); err != nil {
+		logger.Errorf("watcher remove path:%s err:%v", dir, err)
 	}
 }
 
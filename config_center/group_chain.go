@@ -0,0 +1,81 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"strings"
+)
+
+import (
+	"github.com/magiconair/properties"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+)
+
+// GetPropertiesFromGroupChain reads key from the groups set by WithGroupChain through get, which reads a
+// single group. It is the GetProperties implementation shared by the backends when a group chain is set.
+//
+// Without Options.Merge, the value of the first group having key is returned. With Options.Merge, the
+// value of every group having key is parsed by p and the maps are merged, the later groups overriding the
+// earlier ones, and the merged map is returned in properties format. A key missing from every group
+// results in the value of WithDefault if any, or else ErrKeyNotFound.
+func GetPropertiesFromGroupChain(get func(string, ...Option) (string, error), p parser.ConfigurationParser,
+	key string, opts ...Option) (string, error) {
+	tmpOpts := NewOptions(opts...)
+	if p == nil {
+		p = &parser.DefaultConfigurationParser{}
+	}
+
+	merged := properties.NewProperties()
+	merged.DisableExpansion = true
+	found := false
+	for _, group := range tmpOpts.GroupChain {
+		groupOpts := append(opts[:len(opts):len(opts)], WithGroup(group), WithGroupChain(), withoutDefault())
+		content, err := get(key, groupOpts...)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if !tmpOpts.Merge {
+			return content, nil
+		}
+		m, err := p.Parse(content)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range m {
+			merged.Set(k, v)
+		}
+		found = true
+	}
+	if !found {
+		return tmpOpts.KeyNotFound(key)
+	}
+
+	merged.Sort()
+	var sb strings.Builder
+	if _, err := merged.Write(&sb, properties.UTF8); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,274 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	gxset "github.com/dubbogo/gost/container/set"
+	"github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+// DefaultGroupRelistInterval is the interval GroupListeners lists the group at to find the added and
+// deleted keys, unless set by WithPollInterval
+const DefaultGroupRelistInterval = 30 * time.Second
+
+// ConfigurationGroupWatcher is implemented by the config centers able to notify a listener of the changes
+// of any key of a group, which are the nacos, zookeeper and file builtin backends
+type ConfigurationGroupWatcher interface {
+	// AddGroupListener adds a listener notified when any key of group is added, modified or deleted, the
+	// events carrying the key that changed
+	AddGroupListener(group string, listener ConfigurationListener, opts ...Option)
+
+	// RemoveGroupListener removes a listener added by AddGroupListener along with the underlying watches
+	RemoveGroupListener(group string, listener ConfigurationListener, opts ...Option)
+}
+
+// AddGroupListener adds listener on the keys of group if dc implements ConfigurationGroupWatcher, or else
+// returns ErrUnsupported
+func AddGroupListener(dc DynamicConfiguration, group string, listener ConfigurationListener, opts ...Option) error {
+	gw, ok := dc.(ConfigurationGroupWatcher)
+	if !ok {
+		return ErrUnsupported
+	}
+	gw.AddGroupListener(group, listener, opts...)
+	return nil
+}
+
+// RemoveGroupListener removes listener from the keys of group if dc implements ConfigurationGroupWatcher,
+// or else returns ErrUnsupported
+func RemoveGroupListener(dc DynamicConfiguration, group string, listener ConfigurationListener, opts ...Option) error {
+	gw, ok := dc.(ConfigurationGroupWatcher)
+	if !ok {
+		return ErrUnsupported
+	}
+	gw.RemoveGroupListener(group, listener, opts...)
+	return nil
+}
+
+// GroupKeyWatch is what a backend without a native group watch provides to GroupListeners to emulate it
+type GroupKeyWatch struct {
+	// List returns the keys of the group
+	List func(group string) (*gxset.HashSet, error)
+	// Get reads the value of a key of the group
+	Get func(group, key string) (string, error)
+	// Add watches a key of the group with the listener
+	Add func(group, key string, listener ConfigurationListener)
+	// Remove stops watching a key of the group with the listener
+	Remove func(group, key string, listener ConfigurationListener)
+}
+
+type groupListenerKey struct {
+	group    string
+	listener ConfigurationListener
+}
+
+// GroupListeners emulates the group listeners with a watch on each key of the group. The keys are found
+// by listing the group when the listener is added and then periodically, the keys found added or deleted
+// since the previous listing being notified and their watches added or removed accordingly. The zero
+// value is ready to use.
+type GroupListeners struct {
+	mu      sync.Mutex
+	watches map[groupListenerKey]*groupWatch
+}
+
+// Add starts watching the keys of group with listener. The group is listed every opts.PollInterval if
+// set, or else every DefaultGroupRelistInterval. Adding the same listener for the same group again is a
+// no-op.
+func (g *GroupListeners) Add(group string, listener ConfigurationListener, watch GroupKeyWatch, opts *Options) {
+	g.mu.Lock()
+	gk := groupListenerKey{group: group, listener: listener}
+	if _, ok := g.watches[gk]; ok {
+		g.mu.Unlock()
+		return
+	}
+	if g.watches == nil {
+		g.watches = make(map[groupListenerKey]*groupWatch)
+	}
+	gw := &groupWatch{
+		group:    group,
+		listener: listener,
+		watch:    watch,
+		keys:     make(map[string]*groupKeyListener),
+		done:     make(chan struct{}),
+	}
+	g.watches[gk] = gw
+	g.mu.Unlock()
+
+	gw.relist(false)
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultGroupRelistInterval
+	}
+	go gw.run(interval)
+}
+
+// Remove stops watching the keys of group with listener
+func (g *GroupListeners) Remove(group string, listener ConfigurationListener) {
+	g.mu.Lock()
+	gk := groupListenerKey{group: group, listener: listener}
+	gw, ok := g.watches[gk]
+	delete(g.watches, gk)
+	g.mu.Unlock()
+	if ok {
+		gw.stop()
+	}
+}
+
+// StopAll stops watching the keys of all the groups
+func (g *GroupListeners) StopAll() {
+	g.mu.Lock()
+	watches := g.watches
+	g.watches = nil
+	g.mu.Unlock()
+	for _, gw := range watches {
+		gw.stop()
+	}
+}
+
+// groupWatch keeps the watches of the keys of a group for a listener
+type groupWatch struct {
+	group    string
+	listener ConfigurationListener
+	watch    GroupKeyWatch
+	done     chan struct{}
+
+	mu      sync.Mutex
+	stopped bool
+	keys    map[string]*groupKeyListener
+}
+
+func (gw *groupWatch) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-gw.done:
+			return
+		case <-ticker.C:
+			gw.relist(true)
+		}
+	}
+}
+
+// relist lists the group and updates the watched keys, notifying the listener of the keys added and
+// deleted if notify is set
+func (gw *groupWatch) relist(notify bool) {
+	keys, err := gw.watch.List(gw.group)
+	if err != nil {
+		logger.Debugf("[Config Center] listing the keys of group %s failed: %v", gw.group, err)
+		return
+	}
+	current := make(map[string]struct{}, keys.Size())
+	for _, v := range keys.Values() {
+		if key, ok := v.(string); ok {
+			current[key] = struct{}{}
+		}
+	}
+
+	var events []*ConfigChangeEvent
+	gw.mu.Lock()
+	if gw.stopped {
+		gw.mu.Unlock()
+		return
+	}
+	for key := range current {
+		if _, ok := gw.keys[key]; ok {
+			continue
+		}
+		kl := &groupKeyListener{ConfigurationListener: gw.listener, key: key}
+		gw.keys[key] = kl
+		gw.watch.Add(gw.group, key, kl)
+		if !notify {
+			continue
+		}
+		value, err := gw.watch.Get(gw.group, key)
+		if err != nil {
+			logger.Debugf("[Config Center] reading the added key %s of group %s failed: %v", key, gw.group, err)
+			continue
+		}
+		events = append(events, groupEvent(key, value, remoting.EventTypeAdd))
+	}
+	for key, kl := range gw.keys {
+		if _, ok := current[key]; ok {
+			continue
+		}
+		delete(gw.keys, key)
+		gw.watch.Remove(gw.group, key, kl)
+		if notify {
+			events = append(events, groupEvent(key, "", remoting.EventTypeDel))
+		}
+	}
+	gw.mu.Unlock()
+
+	for _, event := range events {
+		gw.listener.Process(event)
+	}
+}
+
+// stop ends the listing and removes the watches of the keys
+func (gw *groupWatch) stop() {
+	gw.mu.Lock()
+	defer gw.mu.Unlock()
+	if gw.stopped {
+		return
+	}
+	gw.stopped = true
+	close(gw.done)
+	for key, kl := range gw.keys {
+		delete(gw.keys, key)
+		gw.watch.Remove(gw.group, key, kl)
+	}
+}
+
+func groupEvent(key, value string, eventType remoting.EventType) *ConfigChangeEvent {
+	return &ConfigChangeEvent{
+		Key:        key,
+		Value:      value,
+		ConfigType: eventType,
+		NewValue:   value,
+		ChangeType: ChangeTypeOf(eventType),
+	}
+}
+
+// groupKeyListener tags the events of the watch of a key with the key, the backends reporting it in
+// their own way
+type groupKeyListener struct {
+	ConfigurationListener
+	key string
+}
+
+func (kl *groupKeyListener) Process(event *ConfigChangeEvent) {
+	e := *event
+	e.Key = kl.key
+	kl.ConfigurationListener.Process(&e)
+}
+
+// IsGroupKeyListener reports whether listener was added by GroupListeners on the watch of a key, for the
+// backends to tell it apart from the listeners added by AddListener on the same key
+func IsGroupKeyListener(listener ConfigurationListener) bool {
+	_, ok := listener.(*groupKeyListener)
+	return ok
+}
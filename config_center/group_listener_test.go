@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	gxset "github.com/dubbogo/gost/container/set"
+
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+// memoryGroup is a group of keys whose watches are kept in memory
+type memoryGroup struct {
+	mu       sync.Mutex
+	values   map[string]string
+	watchers map[string]map[ConfigurationListener]struct{}
+}
+
+func newMemoryGroup() *memoryGroup {
+	return &memoryGroup{values: make(map[string]string), watchers: make(map[string]map[ConfigurationListener]struct{})}
+}
+
+func (g *memoryGroup) keyWatch() GroupKeyWatch {
+	return GroupKeyWatch{
+		List: func(string) (*gxset.HashSet, error) {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			set := gxset.NewSet()
+			for key := range g.values {
+				set.Add(key)
+			}
+			return set, nil
+		},
+		Get: func(_, key string) (string, error) {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			return g.values[key], nil
+		},
+		Add: func(_, key string, listener ConfigurationListener) {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			if g.watchers[key] == nil {
+				g.watchers[key] = make(map[ConfigurationListener]struct{})
+			}
+			g.watchers[key][listener] = struct{}{}
+		},
+		Remove: func(_, key string, listener ConfigurationListener) {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			delete(g.watchers[key], listener)
+			if len(g.watchers[key]) == 0 {
+				delete(g.watchers, key)
+			}
+		},
+	}
+}
+
+func (g *memoryGroup) set(key, value string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.values[key] = value
+	for l := range g.watchers[key] {
+		l.Process(&ConfigChangeEvent{Key: "/watched/" + key, Value: value, ConfigType: remoting.EventTypeUpdate})
+	}
+}
+
+func (g *memoryGroup) delete(key string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.values, key)
+}
+
+func (g *memoryGroup) watched() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return len(g.watchers)
+}
+
+type groupEventsListener struct {
+	events chan *ConfigChangeEvent
+}
+
+func (l *groupEventsListener) Process(event *ConfigChangeEvent) {
+	l.events <- event
+}
+
+func (l *groupEventsListener) next(t *testing.T) *ConfigChangeEvent {
+	select {
+	case e := <-l.events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+		return nil
+	}
+}
+
+func TestGroupListeners(t *testing.T) {
+	group := newMemoryGroup()
+	group.set("a", "1")
+	listener := &groupEventsListener{events: make(chan *ConfigChangeEvent, 16)}
+	var groups GroupListeners
+	groups.Add("flags", listener, group.keyWatch(), NewOptions(WithPollInterval(10*time.Millisecond)))
+	assert.Equal(t, 1, group.watched())
+
+	// the events of the watched keys are tagged with the key
+	group.set("a", "2")
+	e := listener.next(t)
+	assert.Equal(t, "a", e.Key)
+	assert.Equal(t, "2", e.Value)
+
+	// the added keys are found by the next listing
+	group.set("b", "3")
+	e = listener.next(t)
+	assert.Equal(t, "b", e.Key)
+	assert.Equal(t, ChangeTypeAdded, e.ChangeType)
+	assert.Equal(t, "3", e.Value)
+
+	group.delete("a")
+	e = listener.next(t)
+	assert.Equal(t, "a", e.Key)
+	assert.Equal(t, ChangeTypeDeleted, e.ChangeType)
+
+	groups.Remove("flags", listener)
+	assert.Equal(t, 0, group.watched())
+}
+
+func TestAddGroupListenerUnsupported(t *testing.T) {
+	listener := &groupEventsListener{}
+	assert.ErrorIs(t, AddGroupListener(&MockDynamicConfiguration{}, "group", listener), ErrUnsupported)
+	assert.ErrorIs(t, RemoveGroupListener(&MockDynamicConfiguration{}, "group", listener), ErrUnsupported)
+}
@@ -0,0 +1,151 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// MaxInterpolationDepth is the max depth of the references resolved by WithInterpolation, a value
+// referencing a key whose value references another key being at depth 2
+const MaxInterpolationDepth = 8
+
+// ErrInterpolationCycle is returned by GetProperties with WithInterpolation when the references of a
+// value lead back to a key being resolved
+var ErrInterpolationCycle = perrors.New("config center references form a cycle")
+
+// WithInterpolation makes GetProperties replace each ${other.key} of the value by the value of other.key
+// read from the same config center and group, its own references being resolved too, e.g. a value
+// ${base.url}/app. $${ stands for a literal ${. A reference to a missing key is kept as is, see
+// WithStrictInterpolation.
+func WithInterpolation() Option {
+	return func(opts *Options) {
+		opts.Interpolation = true
+	}
+}
+
+// WithStrictInterpolation is WithInterpolation failing with ErrKeyNotFound on a reference to a missing key
+func WithStrictInterpolation() Option {
+	return func(opts *Options) {
+		opts.Interpolation = true
+		opts.StrictInterpolation = true
+	}
+}
+
+func withoutInterpolation() Option {
+	return func(opts *Options) {
+		opts.Interpolation = false
+		opts.StrictInterpolation = false
+	}
+}
+
+// GetPropertiesInterpolated reads key through get, which reads a single key, and resolves the references
+// of the value, see WithInterpolation. It is the GetProperties implementation shared by the backends when
+// interpolation is enabled. The references are resolved at most once per call, and fail with
+// ErrInterpolationCycle on a cycle or when nesting deeper than MaxInterpolationDepth.
+func GetPropertiesInterpolated(get func(string, ...Option) (string, error), key string, opts ...Option) (string, error) {
+	tmpOpts := NewOptions(opts...)
+	getOpts := append(opts[:len(opts):len(opts)], withoutInterpolation())
+	value, err := get(key, getOpts...)
+	if err != nil {
+		return "", err
+	}
+	i := &interpolator{
+		get:      get,
+		opts:     append(getOpts, withoutDefault()),
+		strict:   tmpOpts.StrictInterpolation,
+		resolved: make(map[string]string),
+	}
+	return i.expand(value, []string{key})
+}
+
+type interpolator struct {
+	get      func(string, ...Option) (string, error)
+	opts     []Option
+	strict   bool
+	resolved map[string]string
+}
+
+// expand resolves the references of value, path being the keys from the one read to the one of value
+func (i *interpolator) expand(value string, path []string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+	var sb strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			break
+		}
+		if start > 0 && value[start-1] == '$' {
+			sb.WriteString(value[:start-1])
+			sb.WriteString("${")
+			value = value[start+2:]
+			continue
+		}
+		end := strings.IndexByte(value[start+2:], '}')
+		if end < 0 {
+			// an unterminated reference is kept as is
+			break
+		}
+		sb.WriteString(value[:start])
+		ref := value[start+2 : start+2+end]
+		resolved, err := i.resolve(ref, path)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(resolved)
+		value = value[start+3+end:]
+	}
+	sb.WriteString(value)
+	return sb.String(), nil
+}
+
+func (i *interpolator) resolve(ref string, path []string) (string, error) {
+	for _, key := range path {
+		if key == ref {
+			return "", perrors.WithMessagef(ErrInterpolationCycle, "%s -> %s", strings.Join(path, " -> "), ref)
+		}
+	}
+	if len(path) >= MaxInterpolationDepth {
+		return "", perrors.WithMessagef(ErrInterpolationCycle, "references of key %s nest deeper than %d",
+			path[0], MaxInterpolationDepth)
+	}
+	if value, ok := i.resolved[ref]; ok {
+		return value, nil
+	}
+
+	value, err := i.get(ref, i.opts...)
+	if errors.Is(err, ErrKeyNotFound) && !i.strict {
+		return "${" + ref + "}", nil
+	}
+	if err != nil {
+		return "", perrors.WithMessagef(err, "resolve reference ${%s} of key %s", ref, path[len(path)-1])
+	}
+	value, err = i.expand(value, append(path[:len(path):len(path)], ref))
+	if err != nil {
+		return "", err
+	}
+	i.resolved[ref] = value
+	return value, nil
+}
@@ -0,0 +1,108 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func mapGetter(values map[string]string, reads map[string]int) func(string, ...Option) (string, error) {
+	return func(key string, opts ...Option) (string, error) {
+		if reads != nil {
+			reads[key]++
+		}
+		if v, ok := values[key]; ok {
+			return v, nil
+		}
+		return NewOptions(opts...).KeyNotFound(key)
+	}
+}
+
+func TestGetPropertiesInterpolated(t *testing.T) {
+	reads := make(map[string]int)
+	get := mapGetter(map[string]string{
+		"base.host": "db.example.com",
+		"base.url":  "jdbc://${base.host}:5432",
+		"db.url":    "${base.url}/app?replica=${base.url}/replica",
+		"literal":   "price $${amount} $5",
+		"missing":   "${base.url}/${not.there}",
+	}, reads)
+
+	value, err := GetPropertiesInterpolated(get, "db.url")
+	assert.NoError(t, err)
+	assert.Equal(t, "jdbc://db.example.com:5432/app?replica=jdbc://db.example.com:5432/replica", value)
+	// the references are resolved once per call
+	assert.Equal(t, 1, reads["base.url"])
+	assert.Equal(t, 1, reads["base.host"])
+
+	value, err = GetPropertiesInterpolated(get, "literal")
+	assert.NoError(t, err)
+	assert.Equal(t, "price ${amount} $5", value)
+
+	value, err = GetPropertiesInterpolated(get, "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, "jdbc://db.example.com:5432/${not.there}", value)
+
+	_, err = GetPropertiesInterpolated(get, "missing", WithStrictInterpolation())
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	// the default only applies to the key read, not to its references
+	value, err = GetPropertiesInterpolated(get, "absent", WithDefault("${base.host}"))
+	assert.NoError(t, err)
+	assert.Equal(t, "db.example.com", value)
+	_, err = GetPropertiesInterpolated(get, "missing", WithStrictInterpolation(), WithDefault("x"))
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+}
+
+func TestGetPropertiesInterpolatedCycle(t *testing.T) {
+	get := mapGetter(map[string]string{
+		"a":    "${b}",
+		"b":    "x${c}",
+		"c":    "${a}",
+		"self": "${self}",
+	}, nil)
+
+	for _, key := range []string{"a", "self"} {
+		_, err := GetPropertiesInterpolated(get, key)
+		assert.True(t, errors.Is(err, ErrInterpolationCycle), "key %s: %v", key, err)
+	}
+}
+
+func TestGetPropertiesInterpolatedMaxDepth(t *testing.T) {
+	values := make(map[string]string)
+	for i := 1; i <= MaxInterpolationDepth+1; i++ {
+		values[fmt.Sprintf("k%d", i)] = fmt.Sprintf("${k%d}", i+1)
+	}
+	values[fmt.Sprintf("k%d", MaxInterpolationDepth)] = "end"
+	get := mapGetter(values, nil)
+
+	value, err := GetPropertiesInterpolated(get, "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, "end", value)
+
+	values[fmt.Sprintf("k%d", MaxInterpolationDepth)] = fmt.Sprintf("${k%d}", MaxInterpolationDepth+1)
+	values[fmt.Sprintf("k%d", MaxInterpolationDepth+1)] = "end"
+	_, err = GetPropertiesInterpolated(get, "k1")
+	assert.True(t, errors.Is(err, ErrInterpolationCycle))
+}
@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+)
+
+// ErrLastGoodRule is returned by GetRule along with the last good rule of the key when its current rule
+// fails to parse, see WithLastGoodFallback
+var ErrLastGoodRule = perrors.New("config center rule fails to parse, serving the last good one")
+
+// WithLastGoodFallback makes GetRule parse the rule with the parser of the key, see ParserFor, and return
+// the last rule of the key parsed successfully along with ErrLastGoodRule when the current one fails, e.g.
+// after a malformed rule is pushed. The error of the parser is returned as is when there's no last good
+// rule. This trades freshness for availability, the caller being expected to use the rule and log the
+// error when it's ErrLastGoodRule. The read errors, e.g. a missing key, are returned as is.
+func WithLastGoodFallback() Option {
+	return func(opts *Options) {
+		opts.LastGoodFallback = true
+	}
+}
+
+type lastGoodRuleKey struct {
+	key   string
+	group string
+}
+
+// LastGoodRules remembers the last rule of each key parsed successfully by GetRule with
+// WithLastGoodFallback. The zero value is ready to use.
+type LastGoodRules struct {
+	rules sync.Map // sync.Map[lastGoodRuleKey]string
+}
+
+// Check returns the result of GetRule reading rule of key with err, falling back to the last good rule of
+// key when opts has WithLastGoodFallback and rule fails to parse with p. A nil p results in
+// ErrNoParserConfigured.
+func (r *LastGoodRules) Check(key string, opts *Options, p parser.ConfigurationParser, rule string, err error) (string, error) {
+	if err != nil || !opts.LastGoodFallback {
+		return rule, err
+	}
+	if p == nil {
+		return "", perrors.WithMessagef(ErrNoParserConfigured, "parse rule of key %s", key)
+	}
+	rk := lastGoodRuleKey{key: key, group: opts.Center.Group}
+	if _, perr := p.ParseToUrls(rule); perr != nil {
+		if last, ok := r.rules.Load(rk); ok {
+			return last.(string), perrors.WithMessagef(ErrLastGoodRule, "key %s: %v", key, perr)
+		}
+		return "", perrors.WithMessagef(perr, "parse rule of key %s", key)
+	}
+	r.rules.Store(rk, rule)
+	return rule, nil
+}
@@ -0,0 +1,64 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"sync"
+)
+
+// ConfigurationListenersRemover is implemented by the config centers able to remove all the listeners of a
+// key at once, for the components owning listeners they can't enumerate, which are the nacos, zookeeper
+// and file builtin backends
+type ConfigurationListenersRemover interface {
+	// RemoveAllListeners removes all the listeners added by AddListener on key, along with their pollers,
+	// and stops watching the key once no group listener needs it. No event is delivered to the removed
+	// listeners once it returns, so it must not be called from the Process of a listener of the key.
+	RemoveAllListeners(key string, opts ...Option)
+}
+
+// RemoveAllListeners removes all the listeners of key if dc implements ConfigurationListenersRemover, or
+// else returns ErrUnsupported
+func RemoveAllListeners(dc DynamicConfiguration, key string, opts ...Option) error {
+	r, ok := dc.(ConfigurationListenersRemover)
+	if !ok {
+		return ErrUnsupported
+	}
+	r.RemoveAllListeners(key, opts...)
+	return nil
+}
+
+// ListenerBarrier lets a backend wait for the events being delivered, so that the listeners it removed
+// get no event afterwards. The events are delivered by Deliver, which reads the listeners to notify, and
+// Wait returns once the deliveries started before it are done. The zero value is ready to use.
+type ListenerBarrier struct {
+	mu sync.RWMutex
+}
+
+// Deliver runs deliver, which reads the listeners of the event and notifies them
+func (b *ListenerBarrier) Deliver(deliver func()) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	deliver()
+}
+
+// Wait waits for the deliveries in progress, the following ones seeing the listeners removed before
+func (b *ListenerBarrier) Wait() {
+	// acquiring the lock is enough for the deliveries in progress to be done
+	b.mu.Lock()
+	b.mu.Unlock() // nolint
+}
@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+// removalListener counts the events it gets, and those delivered after removed is set
+type removalListener struct {
+	removed *atomic.Bool
+	events  atomic.Int32
+	late    atomic.Int32
+}
+
+func (l *removalListener) Process(*ConfigChangeEvent) {
+	l.events.Add(1)
+	// keep the event in flight for a while, for the removal to race with it
+	time.Sleep(time.Millisecond)
+	if l.removed.Load() {
+		l.late.Add(1)
+	}
+}
+
+func TestRemoveKeyWhilePolling(t *testing.T) {
+	var counter atomic.Int64
+	// every read returns a new value, so that every poll delivers an event
+	read := func() (string, error) {
+		return strconv.FormatInt(counter.Add(1), 10), nil
+	}
+
+	var (
+		pollers PollingListeners
+		removed atomic.Bool
+		kept    atomic.Bool
+	)
+	listeners := make([]*removalListener, 3)
+	for i := range listeners {
+		listeners[i] = &removalListener{removed: &removed}
+		pollers.Add("key", listeners[i], read, NewOptions(WithPollInterval(time.Millisecond)))
+	}
+	other := &removalListener{removed: &kept}
+	pollers.Add("other", other, read, NewOptions(WithPollInterval(time.Millisecond)))
+	defer pollers.StopAll()
+
+	assert.Eventually(t, func() bool {
+		for _, l := range listeners {
+			if l.events.Load() == 0 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, time.Millisecond)
+
+	pollers.RemoveKey("key")
+	removed.Store(true)
+	time.Sleep(20 * time.Millisecond)
+	for _, l := range listeners {
+		assert.Zero(t, l.late.Load())
+	}
+
+	// the pollers of the other keys keep running
+	count := other.events.Load()
+	assert.Eventually(t, func() bool { return other.events.Load() > count }, time.Second, time.Millisecond)
+}
+
+func TestRemoveAllListenersUnsupported(t *testing.T) {
+	assert.ErrorIs(t, RemoveAllListeners(&MockDynamicConfiguration{}, "key"), ErrUnsupported)
+}
@@ -0,0 +1,368 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+import (
+	gxset "github.com/dubbogo/gost/container/set"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/config_center"
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+type memoryKey struct {
+	key   string
+	group string
+}
+
+type memoryValue struct {
+	value string
+	meta  config_center.ValueMeta
+}
+
+// DynamicConfiguration is an in-memory config center. The values are written by Set, Delete or the
+// ConfigurationPublisher methods, and the change events are delivered synchronously to the listeners of
+// the key before they return, except for the listeners added with WithCoalesce. An empty group is the default group, as for the builtin backends, and
+// WithTimeout is ignored since the reads never block. It's safe for concurrent use.
+type DynamicConfiguration struct {
+	mu         sync.RWMutex
+	values     map[memoryKey]*memoryValue
+	listeners  map[memoryKey][]config_center.ConfigurationListener
+	revision   int64
+	parser     parser.ConfigurationParser
+	parsers    config_center.PatternParsers
+	lastGood   config_center.LastGoodRules
+	coalescers config_center.CoalescingListeners
+}
+
+// NewDynamicConfiguration returns an empty in-memory config center using the default parser
+func NewDynamicConfiguration() *DynamicConfiguration {
+	return &DynamicConfiguration{
+		values:    make(map[memoryKey]*memoryValue),
+		listeners: make(map[memoryKey][]config_center.ConfigurationListener),
+		parser:    &parser.DefaultConfigurationParser{},
+	}
+}
+
+func (mk memoryKey) String() string {
+	return mk.group + "/" + mk.key
+}
+
+func newMemoryKey(key, group string) memoryKey {
+	if len(group) == 0 {
+		group = config_center.DefaultGroup
+	}
+	return memoryKey{key: key, group: group}
+}
+
+// Set sets the value of the (key, group) pair and notifies its listeners
+func (m *DynamicConfiguration) Set(key, group, value string) {
+	mk := newMemoryKey(key, group)
+	m.mu.Lock()
+	old, ok := m.values[mk]
+	m.revision++
+	v := &memoryValue{value: value, meta: config_center.ValueMeta{Revision: m.revision, Version: 1, ModifiedAt: time.Now()}}
+	event := &config_center.ConfigChangeEvent{Key: key, Value: value, ConfigType: remoting.EventTypeAdd, NewValue: value}
+	if ok {
+		v.meta.Version = old.meta.Version + 1
+		event.ConfigType = remoting.EventTypeUpdate
+		event.OldValue = old.value
+	}
+	event.ChangeType = config_center.ChangeTypeOf(event.ConfigType)
+	m.values[mk] = v
+	listeners := m.listeners[mk]
+	m.mu.Unlock()
+	notify(listeners, event)
+}
+
+// Delete deletes the (key, group) pair and notifies its listeners, it reports whether the key existed
+func (m *DynamicConfiguration) Delete(key, group string) bool {
+	mk := newMemoryKey(key, group)
+	m.mu.Lock()
+	old, ok := m.values[mk]
+	delete(m.values, mk)
+	listeners := m.listeners[mk]
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+	notify(listeners, &config_center.ConfigChangeEvent{
+		Key:        key,
+		Value:      "",
+		ConfigType: remoting.EventTypeDel,
+		OldValue:   old.value,
+		ChangeType: config_center.ChangeTypeDeleted,
+	})
+	return true
+}
+
+// notify delivers event to listeners, each one getting its own copy
+func notify(listeners []config_center.ConfigurationListener, event *config_center.ConfigChangeEvent) {
+	for _, listener := range listeners {
+		e := *event
+		listener.Process(&e)
+	}
+}
+
+// ListenerCount returns the number of listeners of the (key, group) pair
+func (m *DynamicConfiguration) ListenerCount(key, group string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.listeners[newMemoryKey(key, group)])
+}
+
+func (m *DynamicConfiguration) Parser() parser.ConfigurationParser {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.parser
+}
+
+func (m *DynamicConfiguration) SetParser(p parser.ConfigurationParser) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.parser = p
+}
+
+// RegisterParserForPattern makes p the parser of the keys matching glob
+func (m *DynamicConfiguration) RegisterParserForPattern(glob string, p parser.ConfigurationParser) error {
+	return m.parsers.Register(glob, p)
+}
+
+// ParserFor returns the parser of key, falling back to Parser
+func (m *DynamicConfiguration) ParserFor(key string) parser.ConfigurationParser {
+	return m.parsers.Parser(key, m.Parser())
+}
+
+// AddListener adds listener on the key of the group set by WithGroup, a listener being added once
+func (m *DynamicConfiguration) AddListener(key string, listener config_center.ConfigurationListener, opts ...config_center.Option) {
+	tmpOpts := config_center.NewOptions(opts...)
+	mk := newMemoryKey(key, tmpOpts.Center.Group)
+	listener = m.coalescers.Add(mk.String(), listener, tmpOpts)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, l := range m.listeners[mk] {
+		if l == listener {
+			return
+		}
+	}
+	m.listeners[mk] = append(m.listeners[mk], listener)
+}
+
+func (m *DynamicConfiguration) RemoveListener(key string, listener config_center.ConfigurationListener, opts ...config_center.Option) {
+	mk := newMemoryKey(key, config_center.NewOptions(opts...).Center.Group)
+	listener = m.coalescers.Remove(mk.String(), listener)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	listeners := m.listeners[mk]
+	for i, l := range listeners {
+		if l == listener {
+			m.listeners[mk] = append(listeners[:i:i], listeners[i+1:]...)
+			break
+		}
+	}
+	if len(m.listeners[mk]) == 0 {
+		delete(m.listeners, mk)
+	}
+}
+
+// RemoveAllListeners removes all the listeners of the key of the group set by WithGroup, and stops the
+// coalescers of those added with WithCoalesce
+func (m *DynamicConfiguration) RemoveAllListeners(key string, opts ...config_center.Option) {
+	mk := newMemoryKey(key, config_center.NewOptions(opts...).Center.Group)
+	m.mu.Lock()
+	delete(m.listeners, mk)
+	m.mu.Unlock()
+	m.coalescers.RemoveKey(mk.String())
+}
+
+func (m *DynamicConfiguration) GetProperties(key string, opts ...config_center.Option) (string, error) {
+	if config_center.NewOptions(opts...).Interpolation {
+		return config_center.GetPropertiesInterpolated(m.GetProperties, key, opts...)
+	}
+	value, _, err := m.GetPropertiesWithMeta(key, opts...)
+	return value, err
+}
+
+// GetPropertiesWithMeta get properties file along with its metadata, the revision being global to the
+// config center and the version counting the Set of the key since it was created
+func (m *DynamicConfiguration) GetPropertiesWithMeta(key string, opts ...config_center.Option) (string, config_center.ValueMeta, error) {
+	tmpOpts := config_center.NewOptions(opts...)
+	if len(tmpOpts.GroupChain) != 0 {
+		value, err := config_center.GetPropertiesFromGroupChain(m.GetProperties, m.ParserFor(key), key, opts...)
+		return value, config_center.ValueMeta{}, err
+	}
+	m.mu.RLock()
+	v, ok := m.values[newMemoryKey(key, tmpOpts.Center.Group)]
+	m.mu.RUnlock()
+	if !ok {
+		value, err := tmpOpts.KeyNotFound(key)
+		return value, config_center.ValueMeta{}, err
+	}
+	if err := tmpOpts.CheckValueSize(key, int64(len(v.value))); err != nil {
+		return "", config_center.ValueMeta{}, err
+	}
+	value, err := tmpOpts.Decompress(key, v.value)
+	if err != nil {
+		return "", config_center.ValueMeta{}, err
+	}
+	return value, v.meta, nil
+}
+
+func (m *DynamicConfiguration) GetRule(key string, opts ...config_center.Option) (string, error) {
+	rule, err := m.GetProperties(key, opts...)
+	return m.lastGood.Check(key, config_center.NewOptions(opts...), m.ParserFor(key), rule, err)
+}
+
+func (m *DynamicConfiguration) GetInternalProperty(key string, opts ...config_center.Option) (string, error) {
+	return m.GetProperties(key, opts...)
+}
+
+// GetPropertiesWithContext returns ctx.Err() if ctx is done, the reads never block otherwise
+func (m *DynamicConfiguration) GetPropertiesWithContext(ctx context.Context, key string, opts ...config_center.Option) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return m.GetProperties(key, opts...)
+}
+
+func (m *DynamicConfiguration) GetRuleWithContext(ctx context.Context, key string, opts ...config_center.Option) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return m.GetRule(key, opts...)
+}
+
+func (m *DynamicConfiguration) GetInternalPropertyWithContext(ctx context.Context, key string, opts ...config_center.Option) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return m.GetInternalProperty(key, opts...)
+}
+
+func (m *DynamicConfiguration) GetConfigKeysByGroup(group string) (*gxset.HashSet, error) {
+	if len(group) == 0 {
+		group = config_center.DefaultGroup
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := gxset.NewSet()
+	for mk := range m.values {
+		if mk.group == group {
+			keys.Add(mk.key)
+		}
+	}
+	return keys, nil
+}
+
+// Groups returns the sorted groups having at least one key
+func (m *DynamicConfiguration) Groups() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	seen := make(map[string]bool)
+	var groups []string
+	for mk := range m.values {
+		if !seen[mk.group] {
+			seen[mk.group] = true
+			groups = append(groups, mk.group)
+		}
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
+// Snapshot returns the values of all the keys of groups, or of all the groups without groups, indexed
+// by config_center.SnapshotKey. Unlike the other backends, it's an exact point-in-time copy.
+func (m *DynamicConfiguration) Snapshot(groups ...string) (map[string]string, error) {
+	wanted := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		wanted[newMemoryKey("", group).group] = true
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	snapshot := make(map[string]string)
+	for mk, v := range m.values {
+		if len(wanted) == 0 || wanted[mk.group] {
+			snapshot[config_center.SnapshotKey(mk.key, mk.group)] = v.value
+		}
+	}
+	return snapshot, nil
+}
+
+// LoadSnapshot replaces the values with the ones of snapshot, as returned by the Snapshot of any config
+// center, so that the keys missing from snapshot are deleted. The changes are applied in the order of
+// the snapshot keys, notifying the listeners of the keys whose value changes, so a replay is
+// deterministic. Nothing is loaded if a snapshot key is invalid.
+func (m *DynamicConfiguration) LoadSnapshot(snapshot map[string]string) error {
+	keys := config_center.SortedSnapshotKeys(snapshot)
+	loaded := make(map[memoryKey]bool, len(keys))
+	for _, sk := range keys {
+		key, group, err := config_center.ParseSnapshotKey(sk)
+		if err != nil {
+			return err
+		}
+		loaded[newMemoryKey(key, group)] = true
+	}
+
+	m.mu.RLock()
+	var deleted []memoryKey
+	for mk := range m.values {
+		if !loaded[mk] {
+			deleted = append(deleted, mk)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(deleted, func(i, j int) bool {
+		return deleted[i].String() < deleted[j].String()
+	})
+	for _, mk := range deleted {
+		m.Delete(mk.key, mk.group)
+	}
+
+	for _, sk := range keys {
+		key, group, _ := config_center.ParseSnapshotKey(sk)
+		m.mu.RLock()
+		old, ok := m.values[newMemoryKey(key, group)]
+		m.mu.RUnlock()
+		if ok && old.value == snapshot[sk] {
+			continue
+		}
+		m.Set(key, group, snapshot[sk])
+	}
+	return nil
+}
+
+// PublishConfig is Set
+func (m *DynamicConfiguration) PublishConfig(key, group, value string) error {
+	m.Set(key, group, value)
+	return nil
+}
+
+// RemoveConfig is Delete, removing a missing key is not an error
+func (m *DynamicConfiguration) RemoveConfig(key, group string) error {
+	m.Delete(key, group)
+	return nil
+}
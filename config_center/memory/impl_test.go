@@ -0,0 +1,186 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/config_center"
+)
+
+func TestSetAndGet(t *testing.T) {
+	m := NewDynamicConfiguration()
+	m.Set("key", "", "A")
+	m.Set("key", "other", "B")
+
+	value, err := m.GetProperties("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "A", value)
+	value, err = m.GetProperties("key", config_center.WithGroup(config_center.DefaultGroup))
+	assert.NoError(t, err)
+	assert.Equal(t, "A", value)
+	value, err = m.GetProperties("key", config_center.WithGroup("other"), config_center.WithTimeout(0))
+	assert.NoError(t, err)
+	assert.Equal(t, "B", value)
+
+	_, err = m.GetProperties("missing")
+	assert.True(t, errors.Is(err, config_center.ErrKeyNotFound))
+
+	assert.NoError(t, m.RemoveConfig("key", "other"))
+	_, err = m.GetProperties("key", config_center.WithGroup("other"))
+	assert.True(t, errors.Is(err, config_center.ErrKeyNotFound))
+}
+
+func TestListener(t *testing.T) {
+	m := NewDynamicConfiguration()
+	listener := &Listener{}
+	m.AddListener("key", listener)
+	m.AddListener("key", listener)
+	assert.Equal(t, 1, m.ListenerCount("key", ""))
+
+	m.Set("key", "", "A")
+	m.Set("key", "", "B")
+	m.Set("key", "other", "C")
+	assert.True(t, m.Delete("key", ""))
+	assert.Equal(t, 3, listener.Calls())
+
+	events := listener.Events()
+	assert.Equal(t, config_center.ChangeTypeAdded, events[0].ChangeType)
+	assert.Equal(t, "A", events[0].NewValue)
+	assert.Equal(t, config_center.ChangeTypeModified, events[1].ChangeType)
+	assert.Equal(t, "A", events[1].OldValue)
+	assert.Equal(t, "B", events[1].NewValue)
+	assert.Equal(t, config_center.ChangeTypeDeleted, events[2].ChangeType)
+	assert.Equal(t, "B", events[2].OldValue)
+
+	m.RemoveListener("key", listener)
+	assert.Zero(t, m.ListenerCount("key", ""))
+	m.Set("key", "", "D")
+	assert.Equal(t, 3, listener.Calls())
+}
+
+func TestEmptyValueTransitions(t *testing.T) {
+	m := NewDynamicConfiguration()
+	listener := &Listener{}
+	m.AddListener("key", listener)
+
+	m.Set("key", "", "")
+	m.Set("key", "", "A")
+	m.Set("key", "", "")
+	assert.True(t, m.Delete("key", ""))
+
+	events := listener.Events()
+	assert.Len(t, events, 4)
+	want := []config_center.ChangeType{
+		config_center.ChangeTypeAdded,
+		config_center.ChangeTypeModified,
+		config_center.ChangeTypeModified,
+		config_center.ChangeTypeDeleted,
+	}
+	for i, changeType := range want {
+		assert.Equal(t, changeType, events[i].ChangeType, "event %d", i)
+	}
+	assert.Equal(t, "A", events[2].OldValue)
+	assert.Equal(t, "", events[2].NewValue)
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	source := NewDynamicConfiguration()
+	source.Set("app.properties", "", "dubbo.application.name=demo")
+	source.Set("com.foo.Service.condition-router", "", "conditions: []")
+	source.Set("app.properties", "prod", "dubbo.application.name=prod")
+
+	snapshot, err := config_center.Snapshot(source)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"dubbo/app.properties":                   "dubbo.application.name=demo",
+		"dubbo/com.foo.Service.condition-router": "conditions: []",
+		"prod/app.properties":                    "dubbo.application.name=prod",
+	}, snapshot)
+
+	// the shared implementation of the other backends gives the same snapshot
+	shared, err := config_center.SnapshotGroups(source, config_center.DefaultGroup, "prod")
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot, shared)
+	prod, err := source.Snapshot("prod")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"prod/app.properties": "dubbo.application.name=prod"}, prod)
+
+	target := NewDynamicConfiguration()
+	target.Set("stale", "", "gone")
+	target.Set("app.properties", "prod", "dubbo.application.name=prod")
+	stale, unchanged := &Listener{}, &Listener{}
+	target.AddListener("stale", stale)
+	target.AddListener("app.properties", unchanged, config_center.WithGroup("prod"))
+
+	assert.NoError(t, target.LoadSnapshot(snapshot))
+	replayed, err := target.Snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot, replayed)
+	last, ok := stale.Last()
+	assert.True(t, ok)
+	assert.Equal(t, config_center.ChangeTypeDeleted, last.ChangeType)
+	assert.Equal(t, 0, unchanged.Calls())
+
+	assert.Error(t, target.LoadSnapshot(map[string]string{"no-group": "value"}))
+	replayed, err = target.Snapshot()
+	assert.NoError(t, err)
+	assert.Equal(t, snapshot, replayed)
+}
+
+func TestMaxValueSize(t *testing.T) {
+	m := NewDynamicConfiguration()
+	m.Set("small", "", "0123456789")
+	m.Set("large", "", strings.Repeat("x", 1<<10))
+
+	value, err := m.GetRule("small", config_center.WithMaxValueSize(10))
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", value)
+
+	value, err = m.GetProperties("large", config_center.WithMaxValueSize(10))
+	assert.True(t, errors.Is(err, config_center.ErrValueTooLarge))
+	assert.Empty(t, value)
+	_, err = m.GetRule("large", config_center.WithMaxValueSize(10))
+	assert.True(t, errors.Is(err, config_center.ErrValueTooLarge))
+}
+
+func TestInterpolation(t *testing.T) {
+	m := NewDynamicConfiguration()
+	m.Set("base.url", "", "http://example.com")
+	m.Set("db.url", "", "${base.url}/app")
+	m.Set("base.url", "other", "http://other.example.com")
+	m.Set("db.url", "other", "${base.url}/app")
+
+	value, err := m.GetProperties("db.url")
+	assert.NoError(t, err)
+	assert.Equal(t, "${base.url}/app", value)
+	value, err = m.GetProperties("db.url", config_center.WithInterpolation())
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com/app", value)
+	value, err = m.GetProperties("db.url", config_center.WithInterpolation(), config_center.WithGroup("other"))
+	assert.NoError(t, err)
+	assert.Equal(t, "http://other.example.com/app", value)
+}
@@ -0,0 +1,70 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"sync"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/config_center"
+)
+
+// Listener is a ConfigurationListener recording the events it gets, for the tests to assert on them. The
+// zero value is ready to use, and it's safe for concurrent use.
+type Listener struct {
+	mu     sync.Mutex
+	events []config_center.ConfigChangeEvent
+}
+
+func (l *Listener) Process(event *config_center.ConfigChangeEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, *event)
+}
+
+// Calls returns the number of events received
+func (l *Listener) Calls() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.events)
+}
+
+// Events returns a copy of the events received, in order
+func (l *Listener) Events() []config_center.ConfigChangeEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]config_center.ConfigChangeEvent(nil), l.events...)
+}
+
+// Last returns the last event received, or false if none
+func (l *Listener) Last() (config_center.ConfigChangeEvent, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.events) == 0 {
+		return config_center.ConfigChangeEvent{}, false
+	}
+	return l.events[len(l.events)-1], true
+}
+
+// Reset forgets the events received
+func (l *Listener) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = nil
+}
@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"time"
+)
+
+// ValueMeta is the metadata of a config value as recorded by the backend, e.g. for auditing which
+// revision of a key was loaded. It's distinct from ETag, which is a hash of the content. The fields a
+// backend doesn't record are zero:
+//   - zookeeper: Revision is the mzxid of the znode, Version its data version and ModifiedAt its mtime,
+//     there's no Creator
+//   - file: only ModifiedAt is set, from the modification time of the file
+//   - nacos doesn't expose any revision, and doesn't implement ConfigurationMetaReader
+type ValueMeta struct {
+	// Revision is the revision of the last modification of the value, global to the backend
+	Revision int64
+	// Version is the number of modifications of the key since it was created
+	Version int64
+	// ModifiedAt is the time of the last modification of the value
+	ModifiedAt time.Time
+	// Creator is the identity which created the key
+	Creator string
+}
+
+// ConfigurationMetaReader is implemented by the config centers able to return the metadata of the values
+// they read, which are the zookeeper and file builtin backends
+type ConfigurationMetaReader interface {
+	// GetPropertiesWithMeta get properties file along with its metadata. A missing key results in the value
+	// of WithDefault with zero metadata, or else ErrKeyNotFound. WithGroupChain is not supported.
+	GetPropertiesWithMeta(key string, opts ...Option) (value string, meta ValueMeta, err error)
+}
+
+// GetPropertiesWithMeta calls the GetPropertiesWithMeta of dc, or returns ErrUnsupported if dc doesn't
+// implement ConfigurationMetaReader
+func GetPropertiesWithMeta(dc DynamicConfiguration, key string, opts ...Option) (string, ValueMeta, error) {
+	if r, ok := dc.(ConfigurationMetaReader); ok {
+		return r.GetPropertiesWithMeta(key, opts...)
+	}
+	return "", ValueMeta{}, ErrUnsupported
+}
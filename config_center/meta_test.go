@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+// metaConfiguration is a mapConfiguration reporting the same metadata for every value
+type metaConfiguration struct {
+	*mapConfiguration
+	meta ValueMeta
+}
+
+func (c *metaConfiguration) GetPropertiesWithMeta(key string, opts ...Option) (string, ValueMeta, error) {
+	value, err := c.GetProperties(key, opts...)
+	if err != nil {
+		return "", ValueMeta{}, err
+	}
+	return value, c.meta, nil
+}
+
+func TestGetPropertiesWithMetaUnsupported(t *testing.T) {
+	_, _, err := GetPropertiesWithMeta(&MockDynamicConfiguration{}, "key")
+	assert.True(t, errors.Is(err, ErrUnsupported))
+}
+
+func TestCompositeGetPropertiesWithMeta(t *testing.T) {
+	meta := ValueMeta{Revision: 42, Version: 3, ModifiedAt: time.Unix(1700000000, 0)}
+	c := NewCompositeConfiguration(
+		newMapConfiguration(map[string]string{"a": "local"}),
+		&metaConfiguration{mapConfiguration: newMapConfiguration(map[string]string{"a": "remote", "b": "remote"}), meta: meta},
+	)
+
+	value, got, err := NewPrefixedConfiguration(c, "").GetPropertiesWithMeta("b")
+	assert.NoError(t, err)
+	assert.Equal(t, "remote", value)
+	assert.Equal(t, meta, got)
+
+	// the layer having a is skipped since it has no metadata
+	value, got, err = c.GetPropertiesWithMeta("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "remote", value)
+	assert.Equal(t, meta, got)
+
+	_, _, err = c.GetPropertiesWithMeta("c")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+}
@@ -26,13 +26,30 @@ import (
 	nacosClient "github.com/dubbogo/gost/database/kv/nacos"
 	"github.com/dubbogo/gost/log/logger"
 
+	nacosConstant "github.com/nacos-group/nacos-sdk-go/v2/common/constant"
+
 	perrors "github.com/pkg/errors"
 )
 
 import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
+	"dubbo.apache.org/dubbo-go/v3/config_center"
 	"dubbo.apache.org/dubbo-go/v3/remoting/nacos"
 )
 
+// nacosClients shares the nacos clients between the config centers connecting to the same server
+var nacosClients = config_center.NewClientPool(func(client *nacosClient.NacosConfigClient) {
+	client.Close()
+})
+
+// nacosClientKey returns the key of the client of url in nacosClients
+func nacosClientKey(url *common.URL) string {
+	return config_center.ClientKey(url, constant.NacosNamespaceID, constant.NacosAccessKey, constant.NacosSecretKey,
+		constant.NacosTimeout, constant.ConfigConnectTimeoutKey, constant.ClientNameKey, constant.ConfigTLSCACertFileKey, constant.ConfigTLSCertFileKey,
+		constant.ConfigTLSKeyFileKey, constant.ConfigTLSServerNameKey)
+}
+
 // NacosClient Nacos configClient
 type NacosClient struct {
 	name         string
@@ -65,7 +82,9 @@ func ValidateNacosClient(container nacosClientFacade) error {
 	url := container.GetURL()
 	if container.NacosClient() == nil || container.NacosClient().Client() == nil {
 		// in dubbo ,every registry only connect one node ,so this is []string{r.Address}
-		newClient, err := nacos.NewNacosConfigClientByUrl(url)
+		newClient, err := nacosClients.Acquire(nacosClientKey(url), func() (*nacosClient.NacosConfigClient, error) {
+			return dialNacosConfigClient(url, newNacosConfigClient)
+		})
 		if err != nil {
 			logger.Errorf("ValidateNacosClient(nacos address{%v} = error{%v}", url.Location, err)
 			return perrors.WithMessagef(err, "newNacosClient(address:%+v)", url.Location)
@@ -75,6 +94,68 @@ func ValidateNacosClient(container nacosClientFacade) error {
 	return perrors.WithMessagef(nil, "newNacosClient(address:%+v)", url.PrimitiveURL)
 }
 
+// dialNacosConfigClient creates the nacos configClient of url with newClient, bounded by the connect timeout
+// of url, since the sdk retries connecting for as long as its request timeout. A client connecting too late
+// is closed.
+func dialNacosConfigClient(url *common.URL, newClient func(*common.URL) (*nacosClient.NacosConfigClient, error)) (*nacosClient.NacosConfigClient, error) {
+	timeout := config_center.ConnectTimeout(url, 0)
+	if timeout <= 0 {
+		return newClient(url)
+	}
+	type result struct {
+		client *nacosClient.NacosConfigClient
+		err    error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		client, err := newClient(url)
+		ch <- result{client: client, err: err}
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-ch:
+		return r.client, r.err
+	case <-timer.C:
+		go func() {
+			if r := <-ch; r.client != nil {
+				r.client.Close()
+			}
+		}()
+		return nil, perrors.Errorf("connecting to nacos %s timed out after %v", url.Location, timeout)
+	}
+}
+
+// newNacosConfigClient creates the nacos configClient of url, connecting with TLS if url asks for it
+func newNacosConfigClient(url *common.URL) (*nacosClient.NacosConfigClient, error) {
+	if !config_center.TLSRequested(url) {
+		return nacos.NewNacosConfigClientByUrl(url)
+	}
+	// load the certificates first, so that a broken one fails with a clear error instead of at dial time
+	if _, err := config_center.GetTLSConfig(url); err != nil {
+		return nil, err
+	}
+	if _, ok := url.GetAttribute(constant.ConfigTLSConfigKey); ok {
+		return nil, perrors.New("nacos config center only supports tls by certificate files, use WithTLSFiles")
+	}
+	sc, cc, err := nacos.GetNacosConfig(url)
+	if err != nil {
+		return nil, err
+	}
+	cc.TLSCfg = nacosConstant.TLSConfig{
+		Enable:             true,
+		CaFile:             url.GetParam(constant.ConfigTLSCACertFileKey, ""),
+		CertFile:           url.GetParam(constant.ConfigTLSCertFileKey, ""),
+		KeyFile:            url.GetParam(constant.ConfigTLSKeyFileKey, ""),
+		ServerNameOverride: url.GetParam(constant.ConfigTLSServerNameKey, ""),
+	}
+	clientName := url.GetParam(constant.ClientNameKey, "")
+	if len(clientName) <= 0 {
+		return nil, perrors.New("nacos client name must set")
+	}
+	return nacosClient.NewNacosConfigClient(clientName, true, sc, cc)
+}
+
 // Done Get nacos configClient exit signal
 func (n *NacosClient) Done() <-chan struct{} {
 	return n.exit
@@ -24,12 +24,15 @@ import (
 )
 
 import (
+	nacosClient "github.com/dubbogo/gost/database/kv/nacos"
+
 	"github.com/stretchr/testify/assert"
 )
 
 import (
 	"dubbo.apache.org/dubbo-go/v3/common"
 	"dubbo.apache.org/dubbo-go/v3/common/constant"
+	"dubbo.apache.org/dubbo-go/v3/config_center"
 )
 
 func TestNewNacosClient(t *testing.T) {
@@ -51,15 +54,8 @@ func TestNewNacosClient(t *testing.T) {
 	assert.NoError(t, err)
 	c.wg.Add(1)
 	go HandleClientRestart(c)
-	go func() {
-		// c.configClient.Close() and <-c.configClient.Done() have order requirements.
-		// If c.configClient.Close() is called first.It is possible that "go HandleClientRestart(c)"
-		// sets c.configClient to nil before calling c.configClient.Done().
-		time.Sleep(time.Second)
-		c.client.Close()
-	}()
-	//<-c.client.Done()
 	c.Destroy()
+	assert.False(t, c.IsAvailable())
 }
 
 func TestSetNacosClient(t *testing.T) {
@@ -82,13 +78,6 @@ func TestSetNacosClient(t *testing.T) {
 	assert.NoError(t, err)
 	c.wg.Add(1)
 	go HandleClientRestart(c)
-	go func() {
-		// c.configClient.Close() and <-c.configClient.Done() have order requirements.
-		// If c.configClient.Close() is called first.It is possible that "go HandleClientRestart(c)"
-		// sets c.configClient to nil before calling c.configClient.Done().
-		time.Sleep(time.Second)
-		c.client.Close()
-	}()
 	c.Destroy()
 }
 
@@ -111,15 +100,38 @@ func TestNewNacosClient_connectError(t *testing.T) {
 	assert.NoError(t, err)
 	c.wg.Add(1)
 	go HandleClientRestart(c)
+	c.Destroy()
+}
+
+func TestDialNacosConfigClientTimeout(t *testing.T) {
+	// slow returns a client connecting until release is closed, and signals started when called
+	slow := func(started, release chan struct{}) func(*common.URL) (*nacosClient.NacosConfigClient, error) {
+		return func(*common.URL) (*nacosClient.NacosConfigClient, error) {
+			close(started)
+			<-release
+			return nil, nil
+		}
+	}
+
+	// the connect timeout fails the dial fast even though the timeout is longer
+	params := url.Values{}
+	params.Set(constant.ConfigTimeoutKey, "10s")
+	params.Set(constant.ConfigConnectTimeoutKey, "20ms")
+	configUrl, _ := common.NewURL("nacos://127.0.0.1:8848", common.WithParams(params))
+	started, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	_, err := dialNacosConfigClient(configUrl, slow(started, release))
+	assert.ErrorContains(t, err, "timed out after 20ms")
+
+	// without connect timeout the dial falls back to the timeout, and waits for the client
+	params.Del(constant.ConfigConnectTimeoutKey)
+	configUrl, _ = common.NewURL("nacos://127.0.0.1:8848", common.WithParams(params))
+	assert.Equal(t, 10*time.Second, config_center.ConnectTimeout(configUrl, 0))
+	started, connected := make(chan struct{}), make(chan struct{})
 	go func() {
-		// c.configClient.Close() and <-c.configClient.Done() have order requirements.
-		// If c.configClient.Close() is called first.It is possible that "go HandleClientRestart(c)"
-		// sets c.configClient to nil before calling c.configClient.Done().
-		time.Sleep(time.Second)
-		c.client.Close()
+		<-started
+		close(connected)
 	}()
-	// <-c.client.Done()
-	// let configClient do retry
-	time.Sleep(5 * time.Second)
-	c.Destroy()
+	_, err = dialNacosConfigClient(configUrl, slow(started, connected))
+	assert.NoError(t, err)
 }
@@ -18,8 +18,10 @@
 package nacos
 
 import (
+	"context"
 	"strings"
 	"sync"
+	"time"
 )
 
 import (
@@ -56,9 +58,20 @@ type nacosDynamicConfiguration struct {
 	rootPath     string
 	wg           sync.WaitGroup
 	cltLock      sync.Mutex
+	reads        config_center.PendingReads
 	done         chan struct{}
+	destroyOnce  sync.Once
 	client       *nacosClient.NacosConfigClient
 	keyListeners sync.Map // sync.Map[listenKey]*sync.Map[config_center.ConfigurationListener]context.CancelFunc
+	listenerLock sync.Mutex
+	barrier      config_center.ListenerBarrier
+	pollers      config_center.PollingListeners
+	coalescers   config_center.CoalescingListeners
+	parsers      config_center.PatternParsers
+	groups       config_center.GroupListeners
+	values       config_center.ValueCache
+	lastGood     config_center.LastGoodRules
+	observer     config_center.Observer
 	parser       parser.ConfigurationParser
 }
 
@@ -68,12 +81,19 @@ func newNacosDynamicConfiguration(url *common.URL) (*nacosDynamicConfiguration,
 	url.SetParam(constant.NacosPassword, url.Password)
 	url.SetParam(constant.NacosAccessKey, url.GetParam(constant.ConfigAccessKey, ""))
 	url.SetParam(constant.NacosSecretKey, url.GetParam(constant.ConfigSecretKey, ""))
-	url.SetParam(constant.NacosTimeout, url.GetParam(constant.ConfigTimeoutKey, ""))
+	// the timeout of the sdk bounds its requests, the connection is bounded by dialNacosConfigClient
+	if timeout := config_center.ReadTimeout(url, 0); timeout > 0 {
+		url.SetParam(constant.NacosTimeout, timeout.String())
+	} else {
+		url.SetParam(constant.NacosTimeout, url.GetParam(constant.ConfigTimeoutKey, ""))
+	}
 	url.SetParam(constant.NacosGroupKey, url.GetParam(constant.ConfigGroupKey, constant2.DEFAULT_GROUP))
 	c := &nacosDynamicConfiguration{
-		url:  url,
-		done: make(chan struct{}),
+		url:      url,
+		done:     make(chan struct{}),
+		observer: config_center.GetObserver(url),
 	}
+	c.reads.Max = config_center.MaxPendingReads(url)
 	c.GetURL()
 	logger.Infof("[Nacos ConfigCenter] New Nacos ConfigCenter with Configuration: %+v, url = %+v", c, c.GetURL())
 	err := ValidateNacosClient(c)
@@ -88,22 +108,58 @@ func newNacosDynamicConfiguration(url *common.URL) (*nacosDynamicConfiguration,
 
 // AddListener Add listener
 func (n *nacosDynamicConfiguration) AddListener(key string, listener config_center.ConfigurationListener, opions ...config_center.Option) {
-	n.addListener(key, listener)
+	tmpOpts := config_center.NewOptions(opions...)
+	listener = n.coalescers.Add(key, listener, tmpOpts)
+	listener = n.pollers.Add(key, listener, func() (string, error) {
+		return n.GetProperties(key, opions...)
+	}, tmpOpts)
+	n.addListener("", key, listener)
+	config_center.LoadInitial(listener)
 }
 
 // RemoveListener Remove listener
 func (n *nacosDynamicConfiguration) RemoveListener(key string, listener config_center.ConfigurationListener, opions ...config_center.Option) {
-	n.removeListener(key, listener)
+	n.removeListener("", key, n.pollers.Remove(key, n.coalescers.Remove(key, listener)))
+}
+
+// RemoveAllListeners removes all the listeners added by AddListener on key, the key is no longer listened
+// on nacos unless a group listener watches it
+func (n *nacosDynamicConfiguration) RemoveAllListeners(key string, _ ...config_center.Option) {
+	n.pollers.RemoveKey(key)
+	n.removeAllListeners("", key)
+	n.barrier.Wait()
+	n.coalescers.RemoveKey(key)
+}
+
+// AddGroupListener adds a listener notified of the changes of any key of group. Nacos can't watch a
+// group, so each key of the group is watched, the keys being found by listing the group periodically.
+func (n *nacosDynamicConfiguration) AddGroupListener(group string, listener config_center.ConfigurationListener, opts ...config_center.Option) {
+	n.groups.Add(group, listener, config_center.GroupKeyWatch{
+		List: n.GetConfigKeysByGroup,
+		Get: func(group, key string) (string, error) {
+			return n.GetProperties(key, append(append([]config_center.Option{}, opts...), config_center.WithGroup(group))...)
+		},
+		Add:    n.addListener,
+		Remove: n.removeListener,
+	}, config_center.NewOptions(opts...))
+}
+
+// RemoveGroupListener removes a listener added by AddGroupListener and the watches of the keys of group
+func (n *nacosDynamicConfiguration) RemoveGroupListener(group string, listener config_center.ConfigurationListener, _ ...config_center.Option) {
+	n.groups.Remove(group, listener)
 }
 
 // GetProperties nacos distinguishes configuration files based on group and dataId. defalut group = "dubbo" and dataId = key
 func (n *nacosDynamicConfiguration) GetProperties(key string, opts ...config_center.Option) (string, error) {
+	if config_center.NewOptions(opts...).Interpolation {
+		return config_center.GetPropertiesInterpolated(n.GetRule, key, opts...)
+	}
 	return n.GetRule(key, opts...)
 }
 
-// GetInternalProperty Get properties value by key
+// GetInternalProperty Get properties value by key, dubbo.properties is never compressed
 func (n *nacosDynamicConfiguration) GetInternalProperty(key string, opts ...config_center.Option) (string, error) {
-	return n.GetProperties(key, opts...)
+	return n.GetProperties(key, append(append([]config_center.Option{}, opts...), config_center.WithCompression(nil))...)
 }
 
 // PublishConfig will publish the config with the (key, group, value) pair
@@ -160,9 +216,26 @@ func (n *nacosDynamicConfiguration) GetConfigKeysByGroup(group string) (*gxset.H
 	return result, nil
 }
 
+// Snapshot returns the values of all the keys of groups, which are required since the groups can't be
+// listed, see config_center.SnapshotGroups
+func (n *nacosDynamicConfiguration) Snapshot(groups ...string) (map[string]string, error) {
+	return config_center.SnapshotGroups(n, groups...)
+}
+
 // GetRule Get router rule
 func (n *nacosDynamicConfiguration) GetRule(key string, opts ...config_center.Option) (string, error) {
 	tmpOpts := config_center.NewOptions(opts...)
+	if len(tmpOpts.GroupChain) != 0 {
+		return config_center.GetPropertiesFromGroupChain(n.GetRule, n.ParserFor(key), key, opts...)
+	}
+	start := time.Now()
+	content, err := n.getRule(key, tmpOpts)
+	config_center.ObserveRead(n.observer, key, start, err)
+	config_center.LogRead(tmpOpts, key, content, err)
+	return n.lastGood.Check(key, tmpOpts, n.ParserFor(key), content, err)
+}
+
+func (n *nacosDynamicConfiguration) getRule(key string, tmpOpts *config_center.Options) (string, error) {
 	resolvedGroup := n.resolvedGroup(tmpOpts.Center.Group)
 	content, err := n.client.Client().GetConfig(vo.ConfigParam{
 		DataId: key,
@@ -170,9 +243,40 @@ func (n *nacosDynamicConfiguration) GetRule(key string, opts ...config_center.Op
 	})
 	if err != nil {
 		return "", perrors.WithStack(err)
-	} else {
-		return content, nil
 	}
+	// nacos rejects blank contents on publishing, so an empty content means the key does not exist
+	if len(content) == 0 {
+		return tmpOpts.KeyNotFound(key)
+	}
+	// the sdk returns the whole content, so the value is only checked once loaded
+	if err := tmpOpts.CheckValueSize(key, int64(len(content))); err != nil {
+		return "", err
+	}
+	return tmpOpts.Decompress(key, content)
+}
+
+// GetPropertiesWithContext is GetProperties bounded by ctx and the read timeout, the read being abandoned
+// rather than canceled when ctx is done, see GetRuleWithContext
+func (n *nacosDynamicConfiguration) GetPropertiesWithContext(ctx context.Context, key string, opts ...config_center.Option) (string, error) {
+	return n.GetRuleWithContext(ctx, key, opts...)
+}
+
+// GetInternalPropertyWithContext is GetInternalProperty bounded by ctx and the read timeout, the read being
+// abandoned rather than canceled when ctx is done, see GetRuleWithContext
+func (n *nacosDynamicConfiguration) GetInternalPropertyWithContext(ctx context.Context, key string, opts ...config_center.Option) (string, error) {
+	return n.GetPropertiesWithContext(ctx, key, append(append([]config_center.Option{}, opts...), config_center.WithCompression(nil))...)
+}
+
+// GetRuleWithContext is GetRule bounded by ctx and the read timeout, see NewURLReadContext.
+// The nacos sdk is not context aware, so the read is abandoned rather than canceled when ctx is done: it
+// keeps running until the sdk returns, and at most config-center.max-pending-reads reads are pending at
+// once, see PendingReads.
+func (n *nacosDynamicConfiguration) GetRuleWithContext(ctx context.Context, key string, opts ...config_center.Option) (string, error) {
+	ctx, cancel := config_center.NewURLReadContext(ctx, n.url, config_center.NewOptions(opts...))
+	defer cancel()
+	return n.reads.Run(ctx, func() (string, error) {
+		return n.GetRule(key, opts...)
+	})
 }
 
 // Parser Get Parser
@@ -180,11 +284,32 @@ func (n *nacosDynamicConfiguration) Parser() parser.ConfigurationParser {
 	return n.parser
 }
 
+// CacheStats returns the statistics of the parse cache, which are zero when the cache is disabled
+func (n *nacosDynamicConfiguration) CacheStats() parser.CacheStats {
+	stats, _ := parser.ParsedCacheStats(n.Parser())
+	return stats
+}
+
+// ResetCacheStats zeroes the hits, misses and evictions of the parse cache
+func (n *nacosDynamicConfiguration) ResetCacheStats() {
+	parser.ResetParsedCacheStats(n.Parser())
+}
+
 // SetParser Set Parser
 func (n *nacosDynamicConfiguration) SetParser(p parser.ConfigurationParser) {
 	n.parser = p
 }
 
+// RegisterParserForPattern makes p the parser of the keys matching glob
+func (n *nacosDynamicConfiguration) RegisterParserForPattern(glob string, p parser.ConfigurationParser) error {
+	return n.parsers.Register(glob, p)
+}
+
+// ParserFor returns the parser of key, falling back to Parser
+func (n *nacosDynamicConfiguration) ParserFor(key string) parser.ConfigurationParser {
+	return n.parsers.Parser(key, n.Parser())
+}
+
 // NacosClient Get Nacos Client
 func (n *nacosDynamicConfiguration) NacosClient() *nacosClient.NacosConfigClient {
 	return n.client
@@ -212,11 +337,16 @@ func (n *nacosDynamicConfiguration) GetURL() *common.URL {
 	return n.url
 }
 
-// Destroy Destroy configuration instance
+// Destroy Destroy configuration instance, destroying again is a no-op
 func (n *nacosDynamicConfiguration) Destroy() {
-	close(n.done)
-	n.wg.Wait()
-	n.closeConfigs()
+	n.destroyOnce.Do(func() {
+		n.pollers.StopAll()
+		n.coalescers.StopAll()
+		n.groups.StopAll()
+		close(n.done)
+		n.wg.Wait()
+		n.closeConfigs()
+	})
 }
 
 // resolvedGroup will regular the group. Now, it will replace the '/' with '-'.
@@ -240,7 +370,8 @@ func (n *nacosDynamicConfiguration) IsAvailable() bool {
 }
 
 func (n *nacosDynamicConfiguration) closeConfigs() {
-	// Close the old configClient first to close the tmp node
-	n.client.Close()
+	// Stop listening before releasing the configClient, which is closed once no other config center shares it
+	clientListens.cancelAll(n)
+	nacosClients.Release(nacosClientKey(n.url))
 	logger.Infof("begin to close provider n configClient")
 }
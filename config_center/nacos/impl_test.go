@@ -20,7 +20,9 @@ package nacos
 
 import (
 	"reflect"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 import (
@@ -31,6 +33,8 @@ import (
 
 	"github.com/nacos-group/nacos-sdk-go/v2/model"
 	"github.com/nacos-group/nacos-sdk-go/v2/vo"
+
+	"github.com/stretchr/testify/assert"
 )
 
 import (
@@ -266,3 +270,146 @@ func Test_nacosDynamicConfiguration_GetConfigKeysByGroup(t *testing.T) {
 		})
 	}
 }
+
+type countingListener struct {
+	count int32
+}
+
+func (l *countingListener) Process(*config_center.ConfigChangeEvent) {
+	atomic.AddInt32(&l.count, 1)
+}
+
+type eventsListener struct {
+	events chan *config_center.ConfigChangeEvent
+}
+
+func (l *eventsListener) Process(event *config_center.ConfigChangeEvent) {
+	l.events <- event
+}
+
+func Test_nacosDynamicConfiguration_ChangeTypes(t *testing.T) {
+	var onChange func(namespace, group, dataId, data string)
+	ctrl := gomock.NewController(t)
+	mnc := NewMockIConfigClient(ctrl)
+	mnc.EXPECT().ListenConfig(gomock.Any()).DoAndReturn(func(params vo.ConfigParam) error {
+		onChange = params.OnChange
+		return nil
+	}).Times(1)
+	nc := &nacosClient.NacosConfigClient{}
+	nc.SetClient(mnc)
+	url, err := common.NewURL("nacos://127.0.0.1:8848")
+	assert.NoError(t, err)
+	n := newnNacosDynamicConfiguration(&fields{url: url, client: nc})
+
+	listener := &eventsListener{events: make(chan *config_center.ConfigChangeEvent, 1)}
+	n.AddListener("dubbo.properties", listener)
+	// the callbacks run in their own goroutines, so each event is awaited before the next change
+	next := func(data string) *config_center.ConfigChangeEvent {
+		onChange("", "dubbo", "dubbo.properties", data)
+		select {
+		case event := <-listener.events:
+			return event
+		case <-time.After(time.Second):
+			t.Fatalf("no event for the change to %q", data)
+			return nil
+		}
+	}
+
+	event := next("a=1")
+	assert.Equal(t, config_center.ChangeTypeModified, event.ChangeType)
+	assert.Equal(t, "a=1", event.NewValue)
+
+	event = next("a=2")
+	assert.Equal(t, config_center.ChangeTypeModified, event.ChangeType)
+	assert.Equal(t, "a=1", event.OldValue)
+	assert.Equal(t, "a=2", event.NewValue)
+
+	// nacos can't store an empty content, so it means the key is deleted
+	event = next("")
+	assert.Equal(t, config_center.ChangeTypeDeleted, event.ChangeType)
+	assert.Equal(t, "a=2", event.OldValue)
+	assert.Equal(t, "", event.NewValue)
+}
+
+func Test_nacosDynamicConfiguration_AddListenerDeduplication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mnc := NewMockIConfigClient(ctrl)
+	mnc.EXPECT().ListenConfig(gomock.Any()).Return(nil).Times(1)
+	// the nacos listen is canceled once the last listener of the key is removed
+	mnc.EXPECT().CancelListenConfig(vo.ConfigParam{DataId: "dubbo.properties", Group: "DEFAULT_GROUP"}).Return(nil).Times(1)
+	nc := &nacosClient.NacosConfigClient{}
+	nc.SetClient(mnc)
+	url, err := common.NewURL("nacos://127.0.0.1:8848")
+	assert.NoError(t, err)
+	n := newnNacosDynamicConfiguration(&fields{url: url, client: nc})
+
+	listener := &countingListener{}
+	for i := 0; i < 3; i++ {
+		n.AddListener("dubbo.properties", listener)
+	}
+	// the change is notified as the nacos OnChange does, but synchronously
+	ck := clientListenKey{client: nc, listenKey: listenKey{group: "DEFAULT_GROUP", key: "dubbo.properties"}}
+	clientListens.notify(ck, "", "dubbo", "dubbo.properties", "dubbo.protocol.name=dubbo")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&listener.count))
+
+	n.RemoveListener("dubbo.properties", listener)
+	clientListens.notify(ck, "", "dubbo", "dubbo.properties", "dubbo.protocol.name=tri")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&listener.count))
+}
+
+func Test_nacosDynamicConfiguration_SharedClient(t *testing.T) {
+	var onChange func(namespace, group, dataId, data string)
+	ctrl := gomock.NewController(t)
+	mnc := NewMockIConfigClient(ctrl)
+	// the key is listened once for all the config centers sharing the client, and canceled after the last
+	// of them stops listening
+	mnc.EXPECT().ListenConfig(gomock.Any()).DoAndReturn(func(params vo.ConfigParam) error {
+		onChange = params.OnChange
+		return nil
+	}).Times(1)
+	mnc.EXPECT().CancelListenConfig(vo.ConfigParam{DataId: "dubbo.properties", Group: "DEFAULT_GROUP"}).Return(nil).Times(1)
+	nc := &nacosClient.NacosConfigClient{}
+	nc.SetClient(mnc)
+	url, err := common.NewURL("nacos://127.0.0.1:18848")
+	assert.NoError(t, err)
+	key := nacosClientKey(url)
+
+	const count = 3
+	centers := make([]*nacosDynamicConfiguration, count)
+	listeners := make([]*countingListener, count)
+	for i := range centers {
+		client, err := nacosClients.Acquire(key, func() (*nacosClient.NacosConfigClient, error) { return nc, nil })
+		assert.NoError(t, err)
+		centers[i] = newnNacosDynamicConfiguration(&fields{url: url, client: client, done: make(chan struct{})})
+		listeners[i] = &countingListener{}
+		centers[i].AddListener("dubbo.properties", listeners[i])
+	}
+	assert.Equal(t, int32(count), nacosClients.RefCount(key))
+
+	onChange("", "DEFAULT_GROUP", "dubbo.properties", "a=1")
+	assert.Eventually(t, func() bool {
+		for _, l := range listeners {
+			if atomic.LoadInt32(&l.count) != 1 {
+				return false
+			}
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	// the other config centers keep being notified once one of them stops listening
+	centers[0].RemoveListener("dubbo.properties", listeners[0])
+	onChange("", "DEFAULT_GROUP", "dubbo.properties", "a=2")
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&listeners[1].count) == 2 && atomic.LoadInt32(&listeners[2].count) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&listeners[0].count))
+
+	// the connection is closed by the last Destroy, destroying again is a no-op
+	for _, n := range centers {
+		assert.NotNil(t, nc.Client())
+		n.Destroy()
+		n.Destroy()
+	}
+	assert.Nil(t, nc.Client())
+	assert.Zero(t, nacosClients.RefCount(key))
+}
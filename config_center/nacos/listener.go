@@ -23,64 +23,205 @@ import (
 )
 
 import (
+	nacosClient "github.com/dubbogo/gost/database/kv/nacos"
 	"github.com/dubbogo/gost/log/logger"
 
-	constant2 "github.com/nacos-group/nacos-sdk-go/v2/common/constant"
 	"github.com/nacos-group/nacos-sdk-go/v2/vo"
 )
 
 import (
-	"dubbo.apache.org/dubbo-go/v3/common/constant"
 	"dubbo.apache.org/dubbo-go/v3/config_center"
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
 	"dubbo.apache.org/dubbo-go/v3/metrics"
 	metricsConfigCenter "dubbo.apache.org/dubbo-go/v3/metrics/config_center"
 	"dubbo.apache.org/dubbo-go/v3/remoting"
 )
 
-func callback(listenersMap *sync.Map, _, group, dataId, data string) {
-	listenersMap.Range(func(key, value any) bool {
-		key.(config_center.ConfigurationListener).Process(&config_center.ConfigChangeEvent{Key: dataId, Value: data, ConfigType: remoting.EventTypeUpdate})
-		metrics.Publish(metricsConfigCenter.NewIncMetricEvent(dataId, group, remoting.EventTypeUpdate, metricsConfigCenter.Nacos))
-		return true
+func (n *nacosDynamicConfiguration) callback(listenersMap *sync.Map, _, group, dataId, data string) {
+	config_center.ObserveEvent(n.observer, dataId)
+	// nacos rejects blank contents on publishing, so an empty content means the key is deleted
+	eventType := remoting.EventTypeUpdate
+	if len(data) == 0 {
+		eventType = remoting.EventTypeDel
+	}
+	event := n.values.NewChangeEvent(dataId, data, eventType)
+	parser.InvalidateParsed(n.Parser(), event.OldValue)
+	n.barrier.Deliver(func() {
+		listenersMap.Range(func(key, value any) bool {
+			e := *event
+			key.(config_center.ConfigurationListener).Process(&e)
+			metrics.Publish(metricsConfigCenter.NewIncMetricEvent(dataId, group, eventType, metricsConfigCenter.Nacos))
+			return true
+		})
+	})
+}
+
+// listenKey identifies a watched key, the group being resolved
+type listenKey struct {
+	group string
+	key   string
+}
+
+// clientListenKey identifies a key watched on a nacos client
+type clientListenKey struct {
+	client *nacosClient.NacosConfigClient
+	listenKey
+}
+
+// clientListens shares the nacos listens between the config centers sharing a client, since canceling
+// the listen of a key on a client cancels it for all of them. Each key is listened on nacos once, its
+// changes being fanned out to the config centers listening to it, and the listen is only canceled once
+// the last of them stops listening.
+var clientListens = &sharedListens{listens: make(map[clientListenKey]map[*nacosDynamicConfiguration]*sync.Map)}
+
+type sharedListens struct {
+	mu      sync.Mutex
+	listens map[clientListenKey]map[*nacosDynamicConfiguration]*sync.Map
+}
+
+// listen notifies the listeners of listenersMap of the changes of lk on the client of n, listening to lk on
+// nacos if no other config center sharing the client does
+func (s *sharedListens) listen(n *nacosDynamicConfiguration, lk listenKey, listenersMap *sync.Map) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ck := clientListenKey{client: n.client, listenKey: lk}
+	if subscribers, ok := s.listens[ck]; ok {
+		subscribers[n] = listenersMap
+		return nil
+	}
+	err := n.client.Client().ListenConfig(vo.ConfigParam{
+		DataId: lk.key,
+		Group:  lk.group,
+		OnChange: func(namespace, group, dataId, data string) {
+			go s.notify(ck, namespace, group, dataId, data)
+		},
 	})
+	if err != nil {
+		return err
+	}
+	s.listens[ck] = map[*nacosDynamicConfiguration]*sync.Map{n: listenersMap}
+	return nil
+}
+
+// notify fans a change of ck out to the config centers listening to it
+func (s *sharedListens) notify(ck clientListenKey, namespace, group, dataId, data string) {
+	s.mu.Lock()
+	subscribers := make(map[*nacosDynamicConfiguration]*sync.Map, len(s.listens[ck]))
+	for n, listenersMap := range s.listens[ck] {
+		subscribers[n] = listenersMap
+	}
+	s.mu.Unlock()
+	for n, listenersMap := range subscribers {
+		n.callback(listenersMap, namespace, group, dataId, data)
+	}
+}
+
+// cancel stops notifying n of the changes of lk, canceling the nacos listen of lk once no config center
+// sharing the client of n listens to it
+func (s *sharedListens) cancel(n *nacosDynamicConfiguration, lk listenKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancelLocked(n, clientListenKey{client: n.client, listenKey: lk})
+}
+
+// cancelAll stops notifying n of the changes of all the keys it listens to
+func (s *sharedListens) cancelAll(n *nacosDynamicConfiguration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ck, subscribers := range s.listens {
+		if _, ok := subscribers[n]; ok && ck.client == n.client {
+			s.cancelLocked(n, ck)
+		}
+	}
 }
 
-func (n *nacosDynamicConfiguration) addListener(key string, listener config_center.ConfigurationListener) {
-	rawListenersMap, loaded := n.keyListeners.Load(key)
+func (s *sharedListens) cancelLocked(n *nacosDynamicConfiguration, ck clientListenKey) {
+	subscribers, ok := s.listens[ck]
+	if !ok {
+		return
+	}
+	delete(subscribers, n)
+	if len(subscribers) != 0 {
+		return
+	}
+	delete(s.listens, ck)
+	if err := ck.client.Client().CancelListenConfig(vo.ConfigParam{DataId: ck.key, Group: ck.group}); err != nil {
+		logger.Warnf("nacos : cancel listening config fail, error:%v ", err)
+	}
+}
+
+// addListener watches key of group, the group set on the url if empty, with listener
+func (n *nacosDynamicConfiguration) addListener(group, key string, listener config_center.ConfigurationListener) {
+	n.listenerLock.Lock()
+	defer n.listenerLock.Unlock()
+	lk := listenKey{group: n.resolvedGroup(group), key: key}
+	rawListenersMap, loaded := n.keyListeners.Load(lk)
 	if !loaded {
 		_, cancel := context.WithCancel(context.Background())
 		listenersMap := &sync.Map{}
 		listenersMap.Store(listener, cancel)
-
-		// double load for invalid race
-		rawListenersMap, loaded = n.keyListeners.LoadOrStore(key, listenersMap)
-		if !loaded {
-			err := n.client.Client().ListenConfig(vo.ConfigParam{
-				DataId: key,
-				Group:  n.resolvedGroup(n.url.GetParam(constant.NacosGroupKey, constant2.DEFAULT_GROUP)),
-				OnChange: func(namespace, group, dataId, data string) {
-					go callback(listenersMap, namespace, group, dataId, data)
-				},
-			})
-			if err != nil {
-				n.keyListeners.Delete(key)
-				logger.Errorf("nacos : listen config fail, error:%v ", err)
-				return
-			}
-			return
+		n.keyListeners.Store(lk, listenersMap)
+		if err := clientListens.listen(n, lk, listenersMap); err != nil {
+			n.keyListeners.Delete(lk)
+			logger.Errorf("nacos : listen config fail, error:%v ", err)
 		}
+		return
 	}
 	_, cancel := context.WithCancel(context.Background())
 	listenersMap := rawListenersMap.(*sync.Map)
-	listenersMap.Store(listener, cancel)
+	if _, registered := listenersMap.LoadOrStore(listener, cancel); registered {
+		// the listener has been added for the key, adding it again is a no-op
+		cancel()
+	}
 }
 
-func (n *nacosDynamicConfiguration) removeListener(key string, listener config_center.ConfigurationListener) {
-	rawListenersMap, loaded := n.keyListeners.Load(key)
+// removeListener stops watching key of group with listener, the key is no longer listened on nacos once
+// its last listener is removed from all the config centers sharing the client
+func (n *nacosDynamicConfiguration) removeListener(group, key string, listener config_center.ConfigurationListener) {
+	n.listenerLock.Lock()
+	defer n.listenerLock.Unlock()
+	lk := listenKey{group: n.resolvedGroup(group), key: key}
+	rawListenersMap, loaded := n.keyListeners.Load(lk)
 	if !loaded {
 		logger.Errorf("nacos : key:%s is not be listened", key)
-	} else {
-		listenersMap := rawListenersMap.(*sync.Map)
-		listenersMap.Delete(listener)
+		return
+	}
+	listenersMap := rawListenersMap.(*sync.Map)
+	listenersMap.Delete(listener)
+	n.cancelListenIfUnused(lk, listenersMap)
+}
+
+// removeAllListeners stops watching key of group with the listeners added by AddListener, the group
+// listeners watching the key being kept
+func (n *nacosDynamicConfiguration) removeAllListeners(group, key string) {
+	n.listenerLock.Lock()
+	defer n.listenerLock.Unlock()
+	lk := listenKey{group: n.resolvedGroup(group), key: key}
+	rawListenersMap, loaded := n.keyListeners.Load(lk)
+	if !loaded {
+		return
+	}
+	listenersMap := rawListenersMap.(*sync.Map)
+	listenersMap.Range(func(listener, _ any) bool {
+		if !config_center.IsGroupKeyListener(listener.(config_center.ConfigurationListener)) {
+			listenersMap.Delete(listener)
+		}
+		return true
+	})
+	n.cancelListenIfUnused(lk, listenersMap)
+}
+
+// cancelListenIfUnused stops listening to lk once it has no listener left, listenerLock must be
+// held
+func (n *nacosDynamicConfiguration) cancelListenIfUnused(lk listenKey, listenersMap *sync.Map) {
+	empty := true
+	listenersMap.Range(func(_, _ any) bool {
+		empty = false
+		return false
+	})
+	if !empty {
+		return
 	}
+	n.keyListeners.Delete(lk)
+	clientListens.cancel(n, lk)
 }
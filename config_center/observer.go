@@ -0,0 +1,78 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"time"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
+)
+
+// Observer is notified of the operations of a config center, e.g. to count them with metrics.
+// It must not block, since it's called on the read and the watch paths.
+type Observer interface {
+	// OnRead is called after each read of key, including the reads served by a local cache
+	OnRead(key string, dur time.Duration, err error)
+	// OnEvent is called once for each change event of key delivered by the watch
+	OnEvent(key string)
+}
+
+// NoopObserver is the Observer used when none is registered
+type NoopObserver struct{}
+
+func (NoopObserver) OnRead(string, time.Duration, error) {}
+
+func (NoopObserver) OnEvent(string) {}
+
+// WithObserver registers observer on the config center constructed from the URL, e.g.
+//
+//	url, _ := common.NewURL("zookeeper://127.0.0.1:2181", config_center.WithObserver(observer))
+//	dc, _ := factory.GetDynamicConfiguration(url)
+func WithObserver(observer Observer) common.Option {
+	return common.WithAttribute(constant.ConfigObserverKey, observer)
+}
+
+// GetObserver returns the Observer registered on url by WithObserver, or NoopObserver if none.
+func GetObserver(url *common.URL) Observer {
+	if url == nil {
+		return NoopObserver{}
+	}
+	if v, ok := url.GetAttribute(constant.ConfigObserverKey); ok {
+		if observer, ok := v.(Observer); ok && observer != nil {
+			return observer
+		}
+	}
+	return NoopObserver{}
+}
+
+// ObserveRead reports the read of key started at start to observer, which may be nil.
+func ObserveRead(observer Observer, key string, start time.Time, err error) {
+	if observer != nil {
+		observer.OnRead(key, time.Since(start), err)
+	}
+}
+
+// ObserveEvent reports the change event of key to observer, which may be nil.
+func ObserveEvent(observer Observer, key string) {
+	if observer != nil {
+		observer.OnEvent(key)
+	}
+}
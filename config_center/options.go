@@ -23,6 +23,10 @@ import (
 	"time"
 )
 
+import (
+	perrors "github.com/pkg/errors"
+)
+
 import (
 	"dubbo.apache.org/dubbo-go/v3/common/constant"
 	"dubbo.apache.org/dubbo-go/v3/common/constant/file"
@@ -31,10 +35,45 @@ import (
 
 type Options struct {
 	Center *global.CenterConfig
+	// MaxConcurrency limits the parallel reads of GetPropertiesBatch
+	MaxConcurrency int
+	// Format is the content format used by GetAndUnmarshal
+	Format file.Suffix
+	// DefaultValue is returned instead of ErrKeyNotFound when HasDefault is set, see WithDefault
+	DefaultValue string
+	HasDefault   bool
+	// PollInterval enables polling the key of AddListener besides the native watch, see WithPollInterval
+	PollInterval time.Duration
+	// InitialLoad makes AddListener deliver the current value first, see WithInitialLoad
+	InitialLoad bool
+	// GroupChain is the groups looked up in order by GetProperties, see WithGroupChain
+	GroupChain []string
+	// Merge makes GetProperties merge the values of all the groups in GroupChain rather than returning the first hit
+	Merge bool
+	// Compression decompresses the values read, see WithCompression
+	Compression CompressionCodec
+	// Compressed makes the values read always decompressed, see WithCompressed
+	Compressed bool
+	// LastGoodFallback makes GetRule fall back to the last good rule, see WithLastGoodFallback
+	LastGoodFallback bool
+	// SecretKeyMatcher tells the keys whose values are redacted from the logs, see WithSecretKeyMatcher
+	SecretKeyMatcher func(key string) bool
+	// Coalesce makes AddListener coalesce the events, see WithCoalesce
+	Coalesce bool
+	// MaxValueSize limits the size of the values read, see WithMaxValueSize
+	MaxValueSize int
+	// Interpolation makes GetProperties resolve the references of the values, see WithInterpolation
+	Interpolation bool
+	// StrictInterpolation makes the references to missing keys fail, see WithStrictInterpolation
+	StrictInterpolation bool
 }
 
 func defaultOptions() *Options {
-	return &Options{Center: global.DefaultCenterConfig()}
+	return &Options{
+		Center:           global.DefaultCenterConfig(),
+		SecretKeyMatcher: DefaultSecretKeyMatcher,
+		MaxValueSize:     DefaultMaxValueSize,
+	}
 }
 
 func NewOptions(opts ...Option) *Options {
@@ -47,6 +86,57 @@ func NewOptions(opts ...Option) *Options {
 
 type Option func(*Options)
 
+// KeyNotFound returns the result of reading a missing key, which is the value set by WithDefault,
+// or else ErrKeyNotFound.
+func (o *Options) KeyNotFound(key string) (string, error) {
+	if o.HasDefault {
+		return o.DefaultValue, nil
+	}
+	return "", perrors.WithMessagef(ErrKeyNotFound, "key %s", key)
+}
+
+// Timeout returns the timeout of the options. Center.Timeout is either a duration string such as "10s"
+// or the milliseconds set by WithTimeout. It returns zero when no valid timeout is set.
+func (o *Options) Timeout() time.Duration {
+	if o == nil || o.Center == nil {
+		return 0
+	}
+	return parseTimeout(o.Center.Timeout)
+}
+
+// ConnectTimeout returns the timeout set by WithConnectTimeout, or else by WithTimeout
+func (o *Options) ConnectTimeout() time.Duration {
+	return o.phaseTimeout(constant.ConfigConnectTimeoutKey)
+}
+
+// ReadTimeout returns the timeout set by WithReadTimeout, or else by WithTimeout
+func (o *Options) ReadTimeout() time.Duration {
+	return o.phaseTimeout(constant.ConfigReadTimeoutKey)
+}
+
+func (o *Options) phaseTimeout(key string) time.Duration {
+	if o != nil && o.Center != nil {
+		if d := parseTimeout(o.Center.Params[key]); d > 0 {
+			return d
+		}
+	}
+	return o.Timeout()
+}
+
+// parseTimeout parses a duration string or milliseconds, zero when s is neither
+func parseTimeout(s string) time.Duration {
+	if len(s) == 0 {
+		return 0
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return d
+	}
+	if ms, err := strconv.Atoi(s); err == nil {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return 0
+}
+
 func WithZookeeper() Option {
 	return func(opts *Options) {
 		opts.Center.Protocol = constant.ZookeeperKey
@@ -122,6 +212,109 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithConnectTimeout sets the timeout of connecting to the config center, taking precedence over WithTimeout,
+// so that an unreachable server fails fast while the reads may take longer
+func WithConnectTimeout(timeout time.Duration) Option {
+	return withPhaseTimeout(constant.ConfigConnectTimeoutKey, timeout)
+}
+
+// WithReadTimeout sets the timeout of each read, taking precedence over WithTimeout. Given to a single read
+// it overrides the read timeout the config center is created with.
+func WithReadTimeout(timeout time.Duration) Option {
+	return withPhaseTimeout(constant.ConfigReadTimeoutKey, timeout)
+}
+
+func withPhaseTimeout(key string, timeout time.Duration) Option {
+	return func(opts *Options) {
+		if opts.Center.Params == nil {
+			opts.Center.Params = make(map[string]string)
+		}
+		opts.Center.Params[key] = timeout.String()
+	}
+}
+
+func WithMaxConcurrency(n int) Option {
+	return func(opts *Options) {
+		opts.MaxConcurrency = n
+	}
+}
+
+// WithDefault sets the value returned by the getters when the key does not exist. The default only
+// replaces ErrKeyNotFound, any other error of the backend is still returned as is.
+func WithDefault(value string) Option {
+	return func(opts *Options) {
+		opts.DefaultValue = value
+		opts.HasDefault = true
+	}
+}
+
+// WithGroupChain makes GetProperties look the key up in groups in order, e.g. "dubbo", "dubbo.prod".
+// The first group having the key wins unless WithMerge is set. It overrides WithGroup, and clears the
+// chain when no group is given.
+func WithGroupChain(groups ...string) Option {
+	return func(opts *Options) {
+		opts.GroupChain = groups
+	}
+}
+
+// WithMerge makes GetProperties with a group chain parse the value of every group and merge them,
+// the later groups overriding the keys of the earlier ones
+func WithMerge() Option {
+	return func(opts *Options) {
+		opts.Merge = true
+	}
+}
+
+// WithCompression makes the getters decompress the values detected as compressed by codec, e.g. GzipCodec
+// detecting the gzip magic header, the other values being returned as is. The listeners get the values as
+// stored, see Options.Decompress. The backend must keep the binary values intact, which nacos doesn't
+// guarantee. A nil codec disables the decompression.
+func WithCompression(codec CompressionCodec) Option {
+	return func(opts *Options) {
+		opts.Compression = codec
+	}
+}
+
+// WithCompressed makes the getters decompress every value with the codec of WithCompression without
+// detecting it, failing on the values not compressed
+func WithCompressed() Option {
+	return func(opts *Options) {
+		opts.Compressed = true
+	}
+}
+
+func withoutDefault() Option {
+	return func(opts *Options) {
+		opts.DefaultValue = ""
+		opts.HasDefault = false
+	}
+}
+
+// WithPollInterval makes AddListener also re-read the key every d and notify the listener when the value
+// differs from the last seen one, in case the native watch is dropped silently
+func WithPollInterval(d time.Duration) Option {
+	return func(opts *Options) {
+		opts.PollInterval = d
+	}
+}
+
+// WithInitialLoad makes AddListener read the current value of the key and deliver it to the listener as
+// an added event before returning. The initial event always precedes any change event of the watch, the
+// changes happening while the value is read are either reflected by it or delivered after it. No initial
+// event is delivered if the key doesn't exist.
+func WithInitialLoad() Option {
+	return func(opts *Options) {
+		opts.InitialLoad = true
+	}
+}
+
+// WithFormat sets the content format used by GetAndUnmarshal, which is detected from the key suffix by default
+func WithFormat(format file.Suffix) Option {
+	return func(opts *Options) {
+		opts.Format = format
+	}
+}
+
 func WithParams(params map[string]string) Option {
 	return func(opts *Options) {
 		opts.Center.Params = params
@@ -0,0 +1,136 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"crypto/sha256"
+	"sync/atomic"
+)
+
+import (
+	"github.com/hashicorp/golang-lru"
+)
+
+// DefaultParseCacheSize is the number of parsed contents kept by the config center parsers by default
+const DefaultParseCacheSize = 128
+
+// CachingConfigurationParser caches the results of Parse by the hash of the content, so that an
+// identical rule is parsed only once. ParseToUrls is not cached since the returned URLs are mutable.
+type CachingConfigurationParser struct {
+	ConfigurationParser
+	cache *lru.Cache
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// CacheStats are the statistics of a CachingConfigurationParser. Hits, Misses and Evictions count from
+// the creation of the parser or the last ResetStats, Size is the current number of cached contents.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// NewCachingConfigurationParser returns p caching at most size parsed contents, or p itself when size
+// is not positive.
+func NewCachingConfigurationParser(p ConfigurationParser, size int) ConfigurationParser {
+	if size <= 0 {
+		return p
+	}
+	cache, err := lru.New(size)
+	if err != nil {
+		return p
+	}
+	return &CachingConfigurationParser{ConfigurationParser: p, cache: cache}
+}
+
+// Parse returns a copy of the cached result of content, parsing it on a miss
+func (parser *CachingConfigurationParser) Parse(content string) (map[string]string, error) {
+	key := sha256.Sum256([]byte(content))
+	if v, ok := parser.cache.Get(key); ok {
+		parser.hits.Add(1)
+		return copyMap(v.(map[string]string)), nil
+	}
+	parser.misses.Add(1)
+	m, err := parser.ConfigurationParser.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	if parser.cache.Add(key, copyMap(m)) {
+		parser.evictions.Add(1)
+	}
+	return m, nil
+}
+
+// Stats returns the statistics of the cache, the invalidated contents are not counted as evictions
+func (parser *CachingConfigurationParser) Stats() CacheStats {
+	return CacheStats{
+		Hits:      parser.hits.Load(),
+		Misses:    parser.misses.Load(),
+		Evictions: parser.evictions.Load(),
+		Size:      parser.cache.Len(),
+	}
+}
+
+// ResetStats zeroes the hits, misses and evictions counters
+func (parser *CachingConfigurationParser) ResetStats() {
+	parser.hits.Store(0)
+	parser.misses.Store(0)
+	parser.evictions.Store(0)
+}
+
+// Invalidate drops the cached result of content, it's called once content is replaced by a change
+func (parser *CachingConfigurationParser) Invalidate(content string) {
+	parser.cache.Remove(sha256.Sum256([]byte(content)))
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// InvalidateParsed drops the cached result of content if p caches the parsed contents
+func InvalidateParsed(p ConfigurationParser, content string) {
+	if c, ok := p.(*CachingConfigurationParser); ok && len(content) != 0 {
+		c.Invalidate(content)
+	}
+}
+
+// ParsedCacheStats returns the statistics of p, or false if p doesn't cache the parsed contents
+func ParsedCacheStats(p ConfigurationParser) (CacheStats, bool) {
+	if c, ok := p.(*CachingConfigurationParser); ok {
+		return c.Stats(), true
+	}
+	return CacheStats{}, false
+}
+
+// ResetParsedCacheStats resets the statistics of p if p caches the parsed contents
+func ResetParsedCacheStats(p ConfigurationParser) {
+	if c, ok := p.(*CachingConfigurationParser); ok {
+		c.ResetStats()
+	}
+}
@@ -108,7 +108,8 @@ func (c *ConditionMatch) IsMatch(host string, url *common.URL) bool {
 func (parser *DefaultConfigurationParser) Parse(content string) (map[string]string, error) {
 	pps, err := properties.LoadString(content)
 	if err != nil {
-		logger.Errorf("Parse the content {%v} in DefaultConfigurationParser error ,error message is {%v}", content, err)
+		// the content isn't logged since it may hold secrets
+		logger.Errorf("Parse the content in DefaultConfigurationParser error ,error message is {%v}", err)
 		return nil, err
 	}
 	return pps.Map(), nil
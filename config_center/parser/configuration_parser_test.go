@@ -87,3 +87,54 @@ configs:
 	assert.Equal(t, "override", urls[0].Protocol)
 	assert.Equal(t, "0.0.0.0", urls[0].Location)
 }
+
+type countingParser struct {
+	DefaultConfigurationParser
+	parsed int
+}
+
+func (p *countingParser) Parse(content string) (map[string]string, error) {
+	p.parsed++
+	return p.DefaultConfigurationParser.Parse(content)
+}
+
+func TestCachingConfigurationParser(t *testing.T) {
+	counting := &countingParser{}
+	assert.Equal(t, counting, NewCachingConfigurationParser(counting, 0))
+
+	parser := NewCachingConfigurationParser(counting, 2)
+	content := "dubbo.registry.address=172.0.0.1"
+	m, err := parser.Parse(content)
+	assert.NoError(t, err)
+	m["dubbo.registry.address"] = "modified"
+
+	m, err = parser.Parse(content)
+	assert.NoError(t, err)
+	assert.Equal(t, "172.0.0.1", m["dubbo.registry.address"])
+	assert.Equal(t, 1, counting.parsed)
+
+	InvalidateParsed(parser, content)
+	_, err = parser.Parse(content)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, counting.parsed)
+}
+
+func TestCachingConfigurationParserStats(t *testing.T) {
+	_, ok := ParsedCacheStats(&countingParser{})
+	assert.False(t, ok)
+
+	parser := NewCachingConfigurationParser(&countingParser{}, 2)
+	for _, content := range []string{"a=1", "a=1", "b=2", "a=1", "c=3", "b=2"} {
+		_, err := parser.Parse(content)
+		assert.NoError(t, err)
+	}
+	// c=3 evicts the least recently used b=2, which is then parsed again and evicts a=1
+	stats, ok := ParsedCacheStats(parser)
+	assert.True(t, ok)
+	assert.Equal(t, CacheStats{Hits: 2, Misses: 4, Evictions: 2, Size: 2}, stats)
+
+	InvalidateParsed(parser, "c=3")
+	ResetParsedCacheStats(parser)
+	stats, _ = ParsedCacheStats(parser)
+	assert.Equal(t, CacheStats{Size: 1}, stats)
+}
@@ -0,0 +1,164 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"strings"
+)
+
+import (
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/rawbytes"
+
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common/constant/file"
+)
+
+// DefaultFallbackOrder is the order the formats are tried in when the format of a content can't be detected
+var DefaultFallbackOrder = []file.Suffix{file.YAML, file.PROPERTIES, file.JSON}
+
+// DetectingOption configures a DetectingParser
+type DetectingOption func(*DetectingParser)
+
+// WithFormat sets the format of the contents whose format can't be detected, instead of trying the fallback order
+func WithFormat(format file.Suffix) DetectingOption {
+	return func(p *DetectingParser) {
+		p.format = format
+	}
+}
+
+// WithFallbackOrder sets the order the formats are tried in when the format of a content can't be detected
+func WithFallbackOrder(formats ...file.Suffix) DetectingOption {
+	return func(p *DetectingParser) {
+		p.order = formats
+	}
+}
+
+// DetectingParser parses each content as yaml, json or properties, sniffing its format, so that a config center can
+// host mixed formats. The override rules of ParseToUrls are always yaml.
+type DetectingParser struct {
+	DefaultConfigurationParser
+	format  file.Suffix
+	order   []file.Suffix
+	parsers map[file.Suffix]ConfigurationParser
+}
+
+// NewDetectingParser returns a DetectingParser, to be set by SetParser
+func NewDetectingParser(opts ...DetectingOption) *DetectingParser {
+	p := &DetectingParser{
+		order: DefaultFallbackOrder,
+		parsers: map[file.Suffix]ConfigurationParser{
+			file.YAML:       &koanfConfigurationParser{parser: yaml.Parser()},
+			file.JSON:       &koanfConfigurationParser{parser: json.Parser()},
+			file.PROPERTIES: &DefaultConfigurationParser{},
+		},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Parse parses content with the parser of its detected format. If the format can't be detected, content is parsed
+// as the format set by WithFormat, or else by the first format of the fallback order parsing it successfully.
+func (parser *DetectingParser) Parse(content string) (map[string]string, error) {
+	if format, ok := DetectFormat(content); ok {
+		return parser.parsers[format].Parse(content)
+	}
+	if parser.format != "" {
+		p, ok := parser.parsers[parser.format]
+		if !ok {
+			return nil, perrors.Errorf("unsupported config format %s", parser.format)
+		}
+		return p.Parse(content)
+	}
+	var errs []string
+	for _, format := range parser.order {
+		p, ok := parser.parsers[format]
+		if !ok {
+			continue
+		}
+		m, err := p.Parse(content)
+		if err == nil {
+			return m, nil
+		}
+		errs = append(errs, string(format)+": "+err.Error())
+	}
+	return nil, perrors.Errorf("failed to parse the content in any of the formats %v: %s", parser.order, strings.Join(errs, "; "))
+}
+
+// DetectFormat sniffs the format of content. It returns false if the format is ambiguous, e.g. "key: value" lines
+// only, which are valid yaml and properties.
+func DetectFormat(content string) (file.Suffix, bool) {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		return file.JSON, true
+	}
+	var yamlLines, propertiesLines int
+	for _, line := range strings.Split(content, "\n") {
+		t := strings.TrimSpace(line)
+		if t == "" || strings.HasPrefix(t, "#") || strings.HasPrefix(t, "!") {
+			continue
+		}
+		colon, equal := strings.Index(t, ":"), strings.Index(t, "=")
+		switch {
+		case t == "---" || t == "...":
+			// Document markers
+			yamlLines++
+		case strings.HasPrefix(t, "- ") || t == "-" || strings.HasSuffix(t, ":"):
+			// Sequence items and mappings of nested blocks
+			yamlLines++
+		case line[0] == ' ' || line[0] == '\t':
+			// Nested blocks
+			yamlLines++
+		case equal > 0 && (colon < 0 || equal < colon):
+			propertiesLines++
+		}
+	}
+	switch {
+	case yamlLines > 0 && propertiesLines == 0:
+		return file.YAML, true
+	case propertiesLines > 0 && yamlLines == 0:
+		return file.PROPERTIES, true
+	}
+	return "", false
+}
+
+// koanfConfigurationParser flattens the nested keys of a yaml or json content with "."
+type koanfConfigurationParser struct {
+	DefaultConfigurationParser
+	parser koanf.Parser
+}
+
+// Parse load content
+func (parser *koanfConfigurationParser) Parse(content string) (map[string]string, error) {
+	k := koanf.New(".")
+	if err := k.Load(rawbytes.Provider([]byte(content)), parser.parser); err != nil {
+		return nil, perrors.WithStack(err)
+	}
+	m := make(map[string]string, len(k.Keys()))
+	for _, key := range k.Keys() {
+		m[key] = k.String(key)
+	}
+	return m, nil
+}
@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common/constant/file"
+)
+
+func TestDetectingParserYAML(t *testing.T) {
+	content := `---
+dubbo:
+  registry:
+    address: 172.0.0.1
+  name: test`
+	format, ok := DetectFormat(content)
+	assert.True(t, ok)
+	assert.Equal(t, file.YAML, format)
+
+	m, err := NewDetectingParser().Parse(content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"dubbo.registry.address": "172.0.0.1", "dubbo.name": "test"}, m)
+}
+
+func TestDetectingParserProperties(t *testing.T) {
+	content := "# registry\ndubbo.registry.address=172.0.0.1\ndubbo.registry.name=test"
+	format, ok := DetectFormat(content)
+	assert.True(t, ok)
+	assert.Equal(t, file.PROPERTIES, format)
+
+	m, err := NewDetectingParser().Parse(content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"dubbo.registry.address": "172.0.0.1", "dubbo.registry.name": "test"}, m)
+}
+
+func TestDetectingParserJSON(t *testing.T) {
+	content := ` {"dubbo": {"registry": {"address": "172.0.0.1"}, "timeout": 3}}`
+	format, ok := DetectFormat(content)
+	assert.True(t, ok)
+	assert.Equal(t, file.JSON, format)
+
+	m, err := NewDetectingParser().Parse(content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"dubbo.registry.address": "172.0.0.1", "dubbo.timeout": "3"}, m)
+}
+
+func TestDetectingParserAmbiguous(t *testing.T) {
+	content := "dubbo.registry.address: 172.0.0.1"
+	_, ok := DetectFormat(content)
+	assert.False(t, ok)
+
+	// yaml comes first in the default fallback order
+	m, err := NewDetectingParser().Parse(content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"dubbo.registry.address": "172.0.0.1"}, m)
+
+	m, err = NewDetectingParser(WithFallbackOrder(file.PROPERTIES, file.YAML)).Parse(content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"dubbo.registry.address": "172.0.0.1"}, m)
+
+	m, err = NewDetectingParser(WithFormat(file.PROPERTIES)).Parse("key: a=b")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"key": "a=b"}, m)
+
+	_, err = NewDetectingParser(WithFormat(file.TOML)).Parse(content)
+	assert.Error(t, err)
+}
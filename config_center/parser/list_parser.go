@@ -0,0 +1,82 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ListParser is implemented by the parsers able to parse a content as an ordered list, which the map of Parse
+// can't represent, e.g. an ordered filter chain
+type ListParser interface {
+	ParseList(content string) ([]string, error)
+}
+
+// ParseList parses content as a list with p if it's a ListParser, or else with the default parser
+func ParseList(p ConfigurationParser, content string) ([]string, error) {
+	if lp, ok := p.(ListParser); ok {
+		return lp.ParseList(content)
+	}
+	return (&DefaultConfigurationParser{}).ParseList(content)
+}
+
+// ParseList parses content as a yaml or json sequence, e.g. "- a\n- b" or [a, b], or else as comma separated
+// values, keeping the order of the elements. The comma separated values are trimmed and the empty ones dropped.
+// The elements of a sequence must be scalars.
+func (parser *DefaultConfigurationParser) ParseList(content string) ([]string, error) {
+	// the scalars are decoded as written, e.g. 1.0 is not turned into 1
+	var list []string
+	if err := yaml.Unmarshal([]byte(content), &list); err == nil {
+		if list == nil {
+			list = make([]string, 0)
+		}
+		return list, nil
+	}
+	var seq []any
+	if err := yaml.Unmarshal([]byte(content), &seq); err != nil {
+		return splitList(content), nil
+	}
+	for i, v := range seq {
+		switch v.(type) {
+		case map[any]any, []any:
+			return nil, perrors.Errorf("element %d of the list is not a scalar", i)
+		}
+	}
+	return nil, perrors.New("the list is not a sequence of scalars")
+}
+
+// ParseList parses content with the underlying parser, the lists are not cached since they're mutable
+func (parser *CachingConfigurationParser) ParseList(content string) ([]string, error) {
+	return ParseList(parser.ConfigurationParser, content)
+}
+
+func splitList(content string) []string {
+	list := make([]string, 0)
+	for _, s := range strings.Split(content, ",") {
+		if s = strings.TrimSpace(s); len(s) != 0 {
+			list = append(list, s)
+		}
+	}
+	return list
+}
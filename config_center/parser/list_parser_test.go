@@ -0,0 +1,68 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseList(t *testing.T) {
+	parser := &DefaultConfigurationParser{}
+	for content, want := range map[string][]string{
+		"- echo\n- token\n- auth\n":      {"echo", "token", "auth"},
+		"  - echo\n  - token\n":          {"echo", "token"},
+		"[token, echo, 1.0]":             {"token", "echo", "1.0"},
+		`["b", "a"]`:                     {"b", "a"},
+		"token, echo ,auth":              {"token", "echo", "auth"},
+		"auth,,echo,":                    {"auth", "echo"},
+		"single":                         {"single"},
+		"":                               {},
+		"- 'a,b'\n- c\n":                 {"a,b", "c"},
+		"key: value":                     {"key: value"},
+		"- first\n- ~\n- last\n":         {"first", "", "last"},
+		"[z, y, x, w, v, u, t, s, r, q]": {"z", "y", "x", "w", "v", "u", "t", "s", "r", "q"},
+	} {
+		list, err := parser.ParseList(content)
+		assert.NoError(t, err, content)
+		assert.Equal(t, want, list, content)
+	}
+
+	_, err := parser.ParseList("- a\n- b: c\n")
+	assert.ErrorContains(t, err, "element 1 of the list is not a scalar")
+}
+
+func TestParseListRoundTrip(t *testing.T) {
+	want := []string{"tps", "echo", "token", "auth", "metrics", "a,b"}
+	out, err := yaml.Marshal(want)
+	assert.NoError(t, err)
+	for _, p := range []ConfigurationParser{
+		&DefaultConfigurationParser{},
+		NewDetectingParser(),
+		NewCachingConfigurationParser(&DefaultConfigurationParser{}, DefaultParseCacheSize),
+	} {
+		list, err := ParseList(p, string(out))
+		assert.NoError(t, err)
+		assert.Equal(t, want, list)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"path"
+	"strings"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+)
+
+// ConfigurationPatternParsers is implemented by the config centers able to choose the parser of a key by
+// the pattern it matches, which are the nacos, zookeeper and file builtin backends
+type ConfigurationPatternParsers interface {
+	// RegisterParserForPattern makes p the parser of the keys matching glob, see PatternParsers
+	RegisterParserForPattern(glob string, p parser.ConfigurationParser) error
+
+	// ParserFor returns the parser of key, which is the one set by SetParser if no pattern matches it
+	ParserFor(key string) parser.ConfigurationParser
+}
+
+// RegisterParserForPattern registers p for the keys matching glob if dc implements
+// ConfigurationPatternParsers, or else returns ErrUnsupported
+func RegisterParserForPattern(dc DynamicConfiguration, glob string, p parser.ConfigurationParser) error {
+	pp, ok := dc.(ConfigurationPatternParsers)
+	if !ok {
+		return ErrUnsupported
+	}
+	return pp.RegisterParserForPattern(glob, p)
+}
+
+// ParserFor returns the parser of key if dc implements ConfigurationPatternParsers, or else dc.Parser()
+func ParserFor(dc DynamicConfiguration, key string) parser.ConfigurationParser {
+	if pp, ok := dc.(ConfigurationPatternParsers); ok {
+		return pp.ParserFor(key)
+	}
+	return dc.Parser()
+}
+
+type patternParser struct {
+	glob   string
+	parser parser.ConfigurationParser
+	// literals and stars rank the specificity of the pattern
+	literals int
+	stars    int
+}
+
+// PatternParsers keeps the parsers registered by key pattern. The patterns have the syntax of path.Match,
+// so a * doesn't match a /. When several patterns match a key, the most specific one wins, which is the
+// one with the most literal characters, then the fewest stars, and then the last registered. The zero
+// value is ready to use.
+type PatternParsers struct {
+	mu       sync.RWMutex
+	patterns []*patternParser
+}
+
+// Register makes p the parser of the keys matching glob, replacing the parser registered for the same glob
+// if any. A nil p unregisters the glob.
+func (pp *PatternParsers) Register(glob string, p parser.ConfigurationParser) error {
+	if _, err := path.Match(glob, ""); err != nil {
+		return perrors.WithMessagef(err, "invalid key pattern %q", glob)
+	}
+	literals, stars := globSpecificity(glob)
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	for i, registered := range pp.patterns {
+		if registered.glob == glob {
+			pp.patterns = append(pp.patterns[:i], pp.patterns[i+1:]...)
+			break
+		}
+	}
+	if p != nil {
+		pp.patterns = append(pp.patterns, &patternParser{glob: glob, parser: p, literals: literals, stars: stars})
+	}
+	return nil
+}
+
+// Parser returns the parser of the most specific pattern matching key, or fallback if none
+func (pp *PatternParsers) Parser(key string, fallback parser.ConfigurationParser) parser.ConfigurationParser {
+	pp.mu.RLock()
+	defer pp.mu.RUnlock()
+	var best *patternParser
+	for _, candidate := range pp.patterns {
+		if ok, _ := path.Match(candidate.glob, key); !ok {
+			continue
+		}
+		if best == nil || candidate.literals > best.literals ||
+			candidate.literals == best.literals && candidate.stars <= best.stars {
+			best = candidate
+		}
+	}
+	if best == nil {
+		return fallback
+	}
+	return best.parser
+}
+
+// globSpecificity counts the literal characters and the stars of glob, a character class or a ? matching
+// any character rather than a literal one
+func globSpecificity(glob string) (literals, stars int) {
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*':
+			stars++
+		case '?':
+		case '[':
+			if end := strings.IndexByte(glob[i:], ']'); end > 0 {
+				i += end
+			}
+		case '\\':
+			i++
+			literals++
+		default:
+			literals++
+		}
+	}
+	return literals, stars
+}
+
+// escapeGlob escapes the characters of s having a meaning in a pattern
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+)
+
+// namedParser parses any content into its name
+type namedParser struct {
+	name string
+}
+
+func (p *namedParser) Parse(string) (map[string]string, error) {
+	return map[string]string{"parser": p.name}, nil
+}
+
+func (p *namedParser) ParseToUrls(string) ([]*common.URL, error) {
+	return nil, nil
+}
+
+func TestPatternParsersOverlapping(t *testing.T) {
+	fallback := &namedParser{name: "fallback"}
+	yml := &namedParser{name: "yml"}
+	rules := &namedParser{name: "rules"}
+	routerRules := &namedParser{name: "router-rules"}
+	exact := &namedParser{name: "exact"}
+
+	var pp PatternParsers
+	assert.NoError(t, pp.Register("*.yml", yml))
+	assert.NoError(t, pp.Register("rules-*", rules))
+	assert.NoError(t, pp.Register("rules-router-*.yml", routerRules))
+	assert.NoError(t, pp.Register("rules-router-app.yml", exact))
+
+	assert.Equal(t, fallback, pp.Parser("dubbo.properties", fallback))
+	assert.Equal(t, yml, pp.Parser("app.yml", fallback))
+	// rules-* has more literal characters than *.yml
+	assert.Equal(t, rules, pp.Parser("rules-a.yml", fallback))
+	assert.Equal(t, routerRules, pp.Parser("rules-router-b.yml", fallback))
+	assert.Equal(t, exact, pp.Parser("rules-router-app.yml", fallback))
+	// a * doesn't match a /
+	assert.Equal(t, fallback, pp.Parser("dir/app.yml", fallback))
+}
+
+func TestPatternParsersTie(t *testing.T) {
+	fallback := &namedParser{name: "fallback"}
+	first := &namedParser{name: "first"}
+	second := &namedParser{name: "second"}
+	fewerStars := &namedParser{name: "fewer-stars"}
+
+	var pp PatternParsers
+	assert.NoError(t, pp.Register("a*", first))
+	assert.NoError(t, pp.Register("*a", second))
+	// same literals and stars, the last registered wins
+	assert.Equal(t, second, pp.Parser("aa", fallback))
+
+	assert.NoError(t, pp.Register("a?", fewerStars))
+	assert.NoError(t, pp.Register("*a*", first))
+	assert.Equal(t, fewerStars, pp.Parser("aa", fallback))
+
+	// registering a glob again replaces its parser, nil unregisters it
+	assert.NoError(t, pp.Register("a?", second))
+	assert.Equal(t, second, pp.Parser("ab", fallback))
+	assert.NoError(t, pp.Register("a?", nil))
+	assert.NoError(t, pp.Register("a*", nil))
+	assert.Equal(t, first, pp.Parser("ab", fallback))
+}
+
+func TestPatternParsersInvalidPattern(t *testing.T) {
+	var pp PatternParsers
+	assert.Error(t, pp.Register("[", &namedParser{}))
+}
+
+func TestGlobSpecificity(t *testing.T) {
+	literals, stars := globSpecificity(`rules-[ab]?\*.yml*`)
+	assert.Equal(t, 11, literals)
+	assert.Equal(t, 1, stars)
+}
+
+func TestParserFor(t *testing.T) {
+	fallback := &namedParser{name: "fallback"}
+	dc := &MockDynamicConfiguration{}
+	dc.SetParser(fallback)
+	assert.Equal(t, parser.ConfigurationParser(fallback), ParserFor(dc, "app.yml"))
+	assert.ErrorIs(t, RegisterParserForPattern(dc, "*.yml", &namedParser{}), ErrUnsupported)
+
+	prefixed := NewPrefixedConfiguration(&patternConfiguration{MockDynamicConfiguration: dc}, "[tenant]/")
+	yml := &namedParser{name: "yml"}
+	assert.NoError(t, RegisterParserForPattern(prefixed, "*.yml", yml))
+	assert.Equal(t, parser.ConfigurationParser(yml), ParserFor(prefixed, "app.yml"))
+	assert.Equal(t, parser.ConfigurationParser(fallback), ParserFor(prefixed, "app.properties"))
+}
+
+type patternConfiguration struct {
+	*MockDynamicConfiguration
+	parsers PatternParsers
+}
+
+func (c *patternConfiguration) RegisterParserForPattern(glob string, p parser.ConfigurationParser) error {
+	return c.parsers.Register(glob, p)
+}
+
+func (c *patternConfiguration) ParserFor(key string) parser.ConfigurationParser {
+	return c.parsers.Parser(key, c.Parser())
+}
@@ -0,0 +1,286 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+type pollerKey struct {
+	key      string
+	listener ConfigurationListener
+}
+
+// PollingListeners keeps the pollers of the listeners added with WithPollInterval or WithInitialLoad.
+// A poller re-reads its key periodically and notifies the listener when the value differs from the
+// last seen one, as a safety net for native watches which are dropped silently. The zero value is
+// ready to use.
+type PollingListeners struct {
+	mu      sync.Mutex
+	pollers map[pollerKey]*pollingListener
+	// newTicker returns the ticks of a poller and the func stopping them, it's replaced by the tests
+	newTicker func(time.Duration) (<-chan time.Time, func())
+}
+
+// Add returns the listener to register on the native watch of key. If opts has neither a poll interval
+// nor the initial load, it's listener itself. Otherwise the returned wrapper keeps the value read by
+// read up to date with the watch events, and a poller reading the key is started if opts has a poll
+// interval. With the initial load, the wrapper holds the watch events back until LoadInitial is called.
+func (p *PollingListeners) Add(key string, listener ConfigurationListener, read func() (string, error), opts *Options) ConfigurationListener {
+	if opts.PollInterval <= 0 && !opts.InitialLoad {
+		return listener
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pk := pollerKey{key: key, listener: listener}
+	if pl, ok := p.pollers[pk]; ok {
+		return pl
+	}
+	if p.pollers == nil {
+		p.pollers = make(map[pollerKey]*pollingListener)
+	}
+	pl := &pollingListener{
+		ConfigurationListener: listener,
+		key:                   key,
+		read:                  read,
+		done:                  make(chan struct{}),
+		loading:               opts.InitialLoad,
+	}
+	if !pl.loading {
+		pl.value, pl.err = read()
+	}
+	p.pollers[pk] = pl
+	if opts.PollInterval > 0 {
+		ticks, stopTicks := p.ticker(opts.PollInterval)
+		go pl.poll(ticks, stopTicks)
+	}
+	return pl
+}
+
+func (p *PollingListeners) ticker(interval time.Duration) (<-chan time.Time, func()) {
+	if p.newTicker != nil {
+		return p.newTicker(interval)
+	}
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// LoadInitial delivers the current value of the key to the listener returned by Add with the initial
+// load, once it's registered on the native watch. The value is delivered as an added event, unless the
+// key doesn't exist, and always precedes the watch events, which are held back until then. The events
+// received before the value is read and already reflected by it are dropped.
+func LoadInitial(listener ConfigurationListener) {
+	if pl, ok := listener.(*pollingListener); ok {
+		pl.loadInitial()
+	}
+}
+
+// Remove stops the poller of listener, waiting for the event it's delivering, and returns the listener
+// registered on the native watch of key.
+func (p *PollingListeners) Remove(key string, listener ConfigurationListener) ConfigurationListener {
+	p.mu.Lock()
+	pk := pollerKey{key: key, listener: listener}
+	pl, ok := p.pollers[pk]
+	if !ok {
+		p.mu.Unlock()
+		return listener
+	}
+	delete(p.pollers, pk)
+	close(pl.done)
+	p.mu.Unlock()
+	// the poller may pick a tick even though done is closed, it must not deliver once removed
+	pl.stop()
+	return pl
+}
+
+// RemoveKey stops the pollers of all the listeners of key, and waits for the events they are delivering
+func (p *PollingListeners) RemoveKey(key string) {
+	var removed []*pollingListener
+	p.mu.Lock()
+	for pk, pl := range p.pollers {
+		if pk.key == key {
+			delete(p.pollers, pk)
+			close(pl.done)
+			removed = append(removed, pl)
+		}
+	}
+	p.mu.Unlock()
+	for _, pl := range removed {
+		pl.stop()
+	}
+}
+
+// StopAll stops all the pollers, and waits for the events they are delivering
+func (p *PollingListeners) StopAll() {
+	var removed []*pollingListener
+	p.mu.Lock()
+	for pk, pl := range p.pollers {
+		delete(p.pollers, pk)
+		close(pl.done)
+		removed = append(removed, pl)
+	}
+	p.mu.Unlock()
+	for _, pl := range removed {
+		pl.stop()
+	}
+}
+
+// pollingListener forwards the watch events to the wrapped listener and records the delivered value
+type pollingListener struct {
+	ConfigurationListener
+	key  string
+	read func() (string, error)
+	done chan struct{}
+	// barrier lets stop wait for the event being delivered by poll
+	barrier ListenerBarrier
+
+	mu      sync.Mutex
+	stopped bool
+	value   string
+	// err is the error of the last read, ErrKeyNotFound means the key doesn't exist
+	err error
+	// loading holds the watch events back in pending until the initial value is delivered
+	loading bool
+	pending []*ConfigChangeEvent
+}
+
+// Process records the value of the event before forwarding it
+func (pl *pollingListener) Process(event *ConfigChangeEvent) {
+	pl.mu.Lock()
+	if pl.loading {
+		pl.pending = append(pl.pending, event)
+		pl.mu.Unlock()
+		return
+	}
+	pl.record(event)
+	pl.mu.Unlock()
+	pl.ConfigurationListener.Process(event)
+}
+
+// record keeps the value of event. Caller must hold pl.mu.
+func (pl *pollingListener) record(event *ConfigChangeEvent) {
+	if event.ConfigType == remoting.EventTypeDel {
+		pl.value, pl.err = "", ErrKeyNotFound
+	} else if value, ok := event.Value.(string); ok {
+		pl.value, pl.err = value, nil
+	}
+}
+
+// loadInitial reads the key and delivers the value followed by the pending events. pl.mu is held while
+// delivering, so that the events received meanwhile wait for the initial value.
+func (pl *pollingListener) loadInitial() {
+	value, err := pl.read()
+
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	if !pl.loading {
+		return
+	}
+	pl.loading = false
+	pending := pl.pending
+	pl.pending = nil
+	switch {
+	case err == nil:
+		pl.value, pl.err = value, nil
+		pl.ConfigurationListener.Process(&ConfigChangeEvent{Key: pl.key, Value: value, ConfigType: remoting.EventTypeAdd,
+			NewValue: value, ChangeType: ChangeTypeAdded})
+	case errors.Is(err, ErrKeyNotFound):
+		pl.value, pl.err = "", err
+	default:
+		logger.Warnf("[Config Center] initial load of key %s failed: %v", pl.key, err)
+		pl.value, pl.err = "", err
+	}
+	for _, event := range pending {
+		if v, ok := event.Value.(string); ok && event.ConfigType != remoting.EventTypeDel && err == nil && v == value {
+			continue
+		}
+		pl.record(event)
+		pl.ConfigurationListener.Process(event)
+	}
+}
+
+func (pl *pollingListener) poll(ticks <-chan time.Time, stopTicks func()) {
+	defer stopTicks()
+	for {
+		select {
+		case <-pl.done:
+			return
+		case <-ticks:
+			pl.barrier.Deliver(func() {
+				if event := pl.check(); event != nil {
+					logger.Warnf("[Config Center] polling found the value of key %s changed, the watch likely missed an event", pl.key)
+					pl.ConfigurationListener.Process(event)
+				}
+			})
+		}
+	}
+}
+
+// check reads the key and returns the change event to synthesize, nil if nothing changed
+func (pl *pollingListener) check() *ConfigChangeEvent {
+	pl.mu.Lock()
+	loading, stopped := pl.loading, pl.stopped
+	pl.mu.Unlock()
+	if loading || stopped {
+		return nil
+	}
+	value, err := pl.read()
+	missing := errors.Is(err, ErrKeyNotFound)
+	if err != nil && !missing {
+		logger.Debugf("[Config Center] polling key %s failed: %v", pl.key, err)
+		return nil
+	}
+
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	existed, old := pl.err == nil, pl.value
+	if missing {
+		pl.value, pl.err = "", err
+		if !existed {
+			return nil
+		}
+		return &ConfigChangeEvent{Key: pl.key, Value: "", ConfigType: remoting.EventTypeDel, OldValue: old, ChangeType: ChangeTypeDeleted}
+	}
+	if existed && value == old {
+		return nil
+	}
+	pl.value, pl.err = value, nil
+	if !existed {
+		return &ConfigChangeEvent{Key: pl.key, Value: value, ConfigType: remoting.EventTypeAdd, NewValue: value, ChangeType: ChangeTypeAdded}
+	}
+	return &ConfigChangeEvent{Key: pl.key, Value: value, ConfigType: remoting.EventTypeUpdate, OldValue: old, NewValue: value,
+		ChangeType: ChangeTypeModified}
+}
+
+// stop prevents the poller from delivering events, and waits for the event it's delivering
+func (pl *pollingListener) stop() {
+	pl.mu.Lock()
+	pl.stopped = true
+	pl.mu.Unlock()
+	pl.barrier.Wait()
+}
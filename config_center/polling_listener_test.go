@@ -0,0 +1,138 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+type recordingListener struct {
+	mu     sync.Mutex
+	events []*ConfigChangeEvent
+}
+
+func (l *recordingListener) Process(event *ConfigChangeEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *recordingListener) Events() []*ConfigChangeEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]*ConfigChangeEvent(nil), l.events...)
+}
+
+func TestPollingListeners(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		value = "v1"
+	)
+	read := func() (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		return value, nil
+	}
+	setValue := func(v string) {
+		mu.Lock()
+		defer mu.Unlock()
+		value = v
+	}
+
+	ticks := make(chan time.Time)
+	pollers := PollingListeners{newTicker: func(time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}}
+	// poll waits for a poll to be done, the poller taking the second tick only once the first one is handled
+	poll := func() {
+		ticks <- time.Now()
+		ticks <- time.Now()
+	}
+	listener := &recordingListener{}
+	assert.Equal(t, listener, pollers.Add("key", listener, read, NewOptions()))
+
+	wrapped := pollers.Add("key", listener, read, NewOptions(WithPollInterval(time.Minute)))
+	assert.NotEqual(t, listener, wrapped)
+	assert.Equal(t, wrapped, pollers.Add("key", listener, read, NewOptions(WithPollInterval(time.Minute))))
+
+	// an event delivered by the watch is not reported again by the poller
+	setValue("v2")
+	wrapped.Process(&ConfigChangeEvent{Key: "key", Value: "v2", ConfigType: remoting.EventTypeUpdate})
+	poll()
+	assert.Len(t, listener.Events(), 1)
+
+	// a change missed by the watch is synthesized by the poller
+	setValue("v3")
+	poll()
+	assert.Len(t, listener.Events(), 2)
+	assert.Equal(t, "v3", listener.Events()[1].Value)
+	assert.Equal(t, remoting.EventTypeUpdate, listener.Events()[1].ConfigType)
+
+	// once removed, the poller delivers nothing even if it picks a tick along with its stop
+	assert.Equal(t, wrapped, pollers.Remove("key", listener))
+	setValue("v4")
+	assert.Nil(t, wrapped.(*pollingListener).check())
+	assert.Len(t, listener.Events(), 2)
+}
+
+func TestPollingListenersInitialLoad(t *testing.T) {
+	var pollers PollingListeners
+	listener := &recordingListener{}
+	wrapped := pollers.Add("key", listener, func() (string, error) { return "v1", nil }, NewOptions(WithInitialLoad()))
+	assert.NotEqual(t, listener, wrapped)
+
+	// the watch events are held back until the initial value is delivered, a change already
+	// reflected by the initial value is dropped
+	wrapped.Process(&ConfigChangeEvent{Key: "key", Value: "v1", ConfigType: remoting.EventTypeUpdate})
+	assert.Empty(t, listener.Events())
+
+	LoadInitial(wrapped)
+	events := listener.Events()
+	assert.Len(t, events, 1)
+	assert.Equal(t, "v1", events[0].Value)
+	assert.Equal(t, remoting.EventTypeAdd, events[0].ConfigType)
+	assert.Equal(t, ChangeTypeAdded, events[0].ChangeType)
+
+	wrapped.Process(&ConfigChangeEvent{Key: "key", Value: "v2", ConfigType: remoting.EventTypeUpdate})
+	events = listener.Events()
+	assert.Len(t, events, 2)
+	assert.Equal(t, "v2", events[1].Value)
+	pollers.Remove("key", listener)
+}
+
+func TestPollingListenersInitialLoadMissingKey(t *testing.T) {
+	var pollers PollingListeners
+	listener := &recordingListener{}
+	wrapped := pollers.Add("key", listener, func() (string, error) { return "", ErrKeyNotFound }, NewOptions(WithInitialLoad()))
+	LoadInitial(wrapped)
+	assert.Empty(t, listener.Events())
+
+	wrapped.Process(&ConfigChangeEvent{Key: "key", Value: "v1", ConfigType: remoting.EventTypeAdd})
+	assert.Len(t, listener.Events(), 1)
+	pollers.Remove("key", listener)
+}
@@ -0,0 +1,272 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+import (
+	gxset "github.com/dubbogo/gost/container/set"
+	"github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+)
+
+// WithKeyPrefix makes the config center prepend prefix to every key it reads, watches and writes, e.g. to
+// isolate the tenants sharing a config center. The event keys delivered to the listeners are stripped of
+// the prefix.
+func WithKeyPrefix(prefix string) Option {
+	return func(opts *Options) {
+		if opts.Center.Params == nil {
+			opts.Center.Params = make(map[string]string)
+		}
+		opts.Center.Params[constant.ConfigKeyPrefixKey] = prefix
+	}
+}
+
+// ApplyKeyPrefix returns dc prefixing its keys with the prefix set on url by WithKeyPrefix, or dc itself
+// if none. It's called by the config center factories.
+func ApplyKeyPrefix(dc DynamicConfiguration, url *common.URL) DynamicConfiguration {
+	if url == nil {
+		return dc
+	}
+	if prefix := url.GetParam(constant.ConfigKeyPrefixKey, ""); len(prefix) != 0 {
+		return NewPrefixedConfiguration(dc, prefix)
+	}
+	return dc
+}
+
+type prefixedListenerKey struct {
+	key      string
+	listener ConfigurationListener
+}
+
+// PrefixedConfiguration prepends a prefix to the keys of the wrapped DynamicConfiguration, so that it
+// only sees the keys having the prefix. The optional interfaces are forwarded to the wrapped one, and
+// result in ErrUnsupported if it doesn't implement them.
+type PrefixedConfiguration struct {
+	dc     DynamicConfiguration
+	prefix string
+
+	mu             sync.Mutex
+	listeners      map[prefixedListenerKey]*prefixedListener
+	groupListeners map[prefixedListenerKey]*prefixedGroupListener
+}
+
+// NewPrefixedConfiguration returns dc prefixing its keys with prefix
+func NewPrefixedConfiguration(dc DynamicConfiguration, prefix string) *PrefixedConfiguration {
+	return &PrefixedConfiguration{
+		dc:             dc,
+		prefix:         prefix,
+		listeners:      make(map[prefixedListenerKey]*prefixedListener),
+		groupListeners: make(map[prefixedListenerKey]*prefixedGroupListener),
+	}
+}
+
+// Unwrap returns the wrapped DynamicConfiguration
+func (p *PrefixedConfiguration) Unwrap() DynamicConfiguration {
+	return p.dc
+}
+
+func (p *PrefixedConfiguration) Parser() parser.ConfigurationParser {
+	return p.dc.Parser()
+}
+
+func (p *PrefixedConfiguration) SetParser(cp parser.ConfigurationParser) {
+	p.dc.SetParser(cp)
+}
+
+// RegisterParserForPattern registers cp for the keys matching glob once prefixed
+func (p *PrefixedConfiguration) RegisterParserForPattern(glob string, cp parser.ConfigurationParser) error {
+	return RegisterParserForPattern(p.dc, escapeGlob(p.prefix)+glob, cp)
+}
+
+func (p *PrefixedConfiguration) ParserFor(key string) parser.ConfigurationParser {
+	return ParserFor(p.dc, p.prefix+key)
+}
+
+func (p *PrefixedConfiguration) AddListener(key string, listener ConfigurationListener, opts ...Option) {
+	p.mu.Lock()
+	lk := prefixedListenerKey{key: key, listener: listener}
+	pl, ok := p.listeners[lk]
+	if !ok {
+		pl = &prefixedListener{ConfigurationListener: listener, key: key, prefixedKey: p.prefix + key}
+		p.listeners[lk] = pl
+	}
+	p.mu.Unlock()
+	p.dc.AddListener(p.prefix+key, pl, opts...)
+}
+
+func (p *PrefixedConfiguration) RemoveListener(key string, listener ConfigurationListener, opts ...Option) {
+	p.mu.Lock()
+	lk := prefixedListenerKey{key: key, listener: listener}
+	pl, ok := p.listeners[lk]
+	delete(p.listeners, lk)
+	p.mu.Unlock()
+	if ok {
+		p.dc.RemoveListener(p.prefix+key, pl, opts...)
+	}
+}
+
+// RemoveAllListeners removes all the listeners of key once prefixed
+func (p *PrefixedConfiguration) RemoveAllListeners(key string, opts ...Option) {
+	p.mu.Lock()
+	for lk := range p.listeners {
+		if lk.key == key {
+			delete(p.listeners, lk)
+		}
+	}
+	p.mu.Unlock()
+	_ = RemoveAllListeners(p.dc, p.prefix+key, opts...)
+}
+
+// AddGroupListener adds listener on the keys of group having the prefix
+func (p *PrefixedConfiguration) AddGroupListener(group string, listener ConfigurationListener, opts ...Option) {
+	p.mu.Lock()
+	lk := prefixedListenerKey{key: group, listener: listener}
+	pl, ok := p.groupListeners[lk]
+	if !ok {
+		pl = &prefixedGroupListener{ConfigurationListener: listener, prefix: p.prefix}
+		p.groupListeners[lk] = pl
+	}
+	p.mu.Unlock()
+	if err := AddGroupListener(p.dc, group, pl, opts...); err != nil {
+		logger.Warnf("[Config Center] can not listen group %s: %v", group, err)
+	}
+}
+
+func (p *PrefixedConfiguration) RemoveGroupListener(group string, listener ConfigurationListener, opts ...Option) {
+	p.mu.Lock()
+	lk := prefixedListenerKey{key: group, listener: listener}
+	pl, ok := p.groupListeners[lk]
+	delete(p.groupListeners, lk)
+	p.mu.Unlock()
+	if ok {
+		_ = RemoveGroupListener(p.dc, group, pl, opts...)
+	}
+}
+
+func (p *PrefixedConfiguration) GetProperties(key string, opts ...Option) (string, error) {
+	return p.dc.GetProperties(p.prefix+key, opts...)
+}
+
+func (p *PrefixedConfiguration) GetPropertiesBatch(keys []string, opts ...Option) (map[string]string, error) {
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, p.prefix+key)
+	}
+	values, err := GetPropertiesBatch(p.dc, prefixed, opts...)
+	if values == nil {
+		return nil, err
+	}
+	stripped := make(map[string]string, len(values))
+	for key, value := range values {
+		stripped[strings.TrimPrefix(key, p.prefix)] = value
+	}
+	return stripped, err
+}
+
+func (p *PrefixedConfiguration) GetPropertiesWithMeta(key string, opts ...Option) (string, ValueMeta, error) {
+	return GetPropertiesWithMeta(p.dc, p.prefix+key, opts...)
+}
+
+func (p *PrefixedConfiguration) GetRule(key string, opts ...Option) (string, error) {
+	return p.dc.GetRule(p.prefix+key, opts...)
+}
+
+func (p *PrefixedConfiguration) GetInternalProperty(key string, opts ...Option) (string, error) {
+	return p.dc.GetInternalProperty(p.prefix+key, opts...)
+}
+
+// GetConfigKeysByGroup returns the keys of group having the prefix, stripped of it
+func (p *PrefixedConfiguration) GetConfigKeysByGroup(group string) (*gxset.HashSet, error) {
+	keys, err := p.dc.GetConfigKeysByGroup(group)
+	if err != nil {
+		return nil, err
+	}
+	set := gxset.NewSet()
+	for _, v := range keys.Values() {
+		if key, ok := v.(string); ok && strings.HasPrefix(key, p.prefix) {
+			set.Add(strings.TrimPrefix(key, p.prefix))
+		}
+	}
+	return set, nil
+}
+
+func (p *PrefixedConfiguration) GetPropertiesWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return GetPropertiesWithContext(ctx, p.dc, p.prefix+key, opts...)
+}
+
+func (p *PrefixedConfiguration) GetRuleWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return GetRuleWithContext(ctx, p.dc, p.prefix+key, opts...)
+}
+
+func (p *PrefixedConfiguration) GetInternalPropertyWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return GetInternalPropertyWithContext(ctx, p.dc, p.prefix+key, opts...)
+}
+
+func (p *PrefixedConfiguration) PublishConfig(key, group, value string) error {
+	return p.dc.PublishConfig(p.prefix+key, group, value)
+}
+
+func (p *PrefixedConfiguration) RemoveConfig(key, group string) error {
+	return p.dc.RemoveConfig(p.prefix+key, group)
+}
+
+func (p *PrefixedConfiguration) PublishConfigCAS(key, group, expectedOld, newValue string) (bool, error) {
+	return PublishConfigCAS(p.dc, p.prefix+key, group, expectedOld, newValue)
+}
+
+// prefixedListener strips the prefix from the keys of the events before forwarding them. The backends
+// report the key either as is or as part of a path, so the prefixed key is replaced wherever it is.
+type prefixedListener struct {
+	ConfigurationListener
+	key         string
+	prefixedKey string
+}
+
+func (pl *prefixedListener) Process(event *ConfigChangeEvent) {
+	e := *event
+	if i := strings.LastIndex(e.Key, pl.prefixedKey); i >= 0 {
+		e.Key = e.Key[:i] + pl.key + e.Key[i+len(pl.prefixedKey):]
+	}
+	pl.ConfigurationListener.Process(&e)
+}
+
+// prefixedGroupListener drops the events of the keys of the group not having the prefix, and strips it
+// from the others
+type prefixedGroupListener struct {
+	ConfigurationListener
+	prefix string
+}
+
+func (pl *prefixedGroupListener) Process(event *ConfigChangeEvent) {
+	if !strings.HasPrefix(event.Key, pl.prefix) {
+		return
+	}
+	e := *event
+	e.Key = strings.TrimPrefix(e.Key, pl.prefix)
+	pl.ConfigurationListener.Process(&e)
+}
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"strings"
+)
+
+import (
+	"github.com/dubbogo/gost/log/logger"
+)
+
+// RedactedValue replaces the values of the secret keys in the logs
+const RedactedValue = "***"
+
+var secretKeySubstrings = []string{"password", "secret", "token"}
+
+// DefaultSecretKeyMatcher is the SecretKeyMatcher used unless WithSecretKeyMatcher is set, matching the
+// keys containing password, secret or token, whatever the case
+func DefaultSecretKeyMatcher(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range secretKeySubstrings {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithSecretKeyMatcher sets the matcher of the keys whose values are replaced with RedactedValue in the
+// logs of the config center, the getters still returning the real values. A nil matcher restores
+// DefaultSecretKeyMatcher, and a matcher always returning false disables the redaction.
+func WithSecretKeyMatcher(matcher func(key string) bool) Option {
+	return func(opts *Options) {
+		if matcher == nil {
+			matcher = DefaultSecretKeyMatcher
+		}
+		opts.SecretKeyMatcher = matcher
+	}
+}
+
+// Redact returns value as it may be logged, which is RedactedValue if key is a secret one
+func (o *Options) Redact(key, value string) string {
+	if o.SecretKeyMatcher != nil && o.SecretKeyMatcher(key) {
+		return RedactedValue
+	}
+	return value
+}
+
+// LogRead logs the read of key at debug level, the value being redacted by opts
+func LogRead(opts *Options, key, value string, err error) {
+	if err != nil {
+		logger.Debugf("[Config Center] read of key %s failed: %v", key, err)
+		return
+	}
+	logger.Debugf("[Config Center] read key %s: %s", key, opts.Redact(key, value))
+}
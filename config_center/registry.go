@@ -0,0 +1,187 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
+)
+
+// ErrUnknownScheme is returned by New when no config center is registered under the scheme of the URL
+var ErrUnknownScheme = perrors.New("no config center registered for the scheme")
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]func() DynamicConfigurationFactory)
+)
+
+// RegisterFactory registers the factory of the config centers of scheme, replacing the previous one if any.
+// The backends register themselves when their package is imported.
+func RegisterFactory(scheme string, f func() DynamicConfigurationFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[scheme] = f
+}
+
+// LookupFactory returns a new factory of the config centers of scheme, false if none is registered
+func LookupFactory(scheme string) (DynamicConfigurationFactory, bool) {
+	factoriesMu.RLock()
+	f := factories[scheme]
+	factoriesMu.RUnlock()
+	if f == nil {
+		return nil, false
+	}
+	return f(), true
+}
+
+// Schemes returns the sorted schemes of the registered config centers
+func Schemes() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	schemes := make([]string, 0, len(factories))
+	for scheme := range factories {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// New creates the config center of the scheme of rawURL, e.g. nacos://127.0.0.1:8848?group=dev&namespace=prod,
+// with the factory registered under it. The URL is mapped onto Options by ParseURL, opts are applied after.
+func New(rawURL string, opts ...Option) (DynamicConfiguration, error) {
+	o, err := ParseURL(rawURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	factory, ok := LookupFactory(o.Center.Protocol)
+	if !ok {
+		return nil, perrors.WithMessagef(ErrUnknownScheme, "scheme %s, make sure its package is imported", o.Center.Protocol)
+	}
+	u, err := o.URL()
+	if err != nil {
+		return nil, err
+	}
+	return factory.GetDynamicConfiguration(u)
+}
+
+// ParseURL maps a config center URL onto Options: the scheme is the protocol, the host the address, the user
+// info the credentials. The query params group, namespace, data-id, cluster, app-id, timeout and
+// file-extension set the fields of the same name, connect-timeout and read-timeout are the options of the
+// same name, the others are kept as is in the params of the center, e.g. config-center.key-prefix. opts
+// are applied after.
+func ParseURL(rawURL string, opts ...Option) (*Options, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, perrors.Wrapf(err, "parse config center url %s", rawURL)
+	}
+	if len(u.Scheme) == 0 {
+		return nil, perrors.Errorf("config center url %s has no scheme", rawURL)
+	}
+	if len(u.Host) == 0 {
+		return nil, perrors.Errorf("config center url %s has no address", rawURL)
+	}
+
+	o := defaultOptions()
+	cc := o.Center
+	cc.Protocol = u.Scheme
+	cc.Address = u.Host + u.Path
+	if u.User != nil {
+		cc.Username = u.User.Username()
+		cc.Password, _ = u.User.Password()
+	}
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+		switch key {
+		case "group":
+			cc.Group = value
+		case "namespace":
+			cc.Namespace = value
+		case "data-id", "dataId":
+			cc.DataId = value
+		case "cluster":
+			cc.Cluster = value
+		case "app-id", "appId":
+			cc.AppID = value
+		case "timeout":
+			cc.Timeout = value
+			if o.Timeout() <= 0 {
+				return nil, perrors.Errorf("config center url %s has an invalid timeout %q", rawURL, value)
+			}
+		case "connect-timeout", "read-timeout":
+			d := parseTimeout(value)
+			if d <= 0 {
+				return nil, perrors.Errorf("config center url %s has an invalid %s %q", rawURL, key, value)
+			}
+			if key == "connect-timeout" {
+				WithConnectTimeout(d)(o)
+			} else {
+				WithReadTimeout(d)(o)
+			}
+		case "file-extension":
+			cc.FileExtension = value
+		case "username":
+			cc.Username = value
+		case "password":
+			cc.Password = value
+		default:
+			cc.Params[key] = value
+		}
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o, nil
+}
+
+// URL returns the URL passed to the factory of the config center of the options
+func (o *Options) URL() (*common.URL, error) {
+	cc := o.Center
+	params := url.Values{}
+	for key, value := range map[string]string{
+		constant.ConfigNamespaceKey: cc.Namespace,
+		constant.ConfigGroupKey:     cc.Group,
+		constant.ConfigClusterKey:   cc.Cluster,
+		constant.ConfigAppIDKey:     cc.AppID,
+		constant.ConfigTimeoutKey:   cc.Timeout,
+		constant.ClientNameKey:      strings.Join([]string{constant.ConfigCenterPrefix, cc.Protocol, cc.Address}, "-"),
+	} {
+		if len(value) != 0 {
+			params.Set(key, value)
+		}
+	}
+	for key, value := range cc.Params {
+		params.Set(key, value)
+	}
+	return common.NewURL(cc.Address,
+		common.WithProtocol(cc.Protocol),
+		common.WithParams(params),
+		common.WithUsername(cc.Username),
+		common.WithPassword(cc.Password),
+	)
+}
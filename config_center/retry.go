@@ -0,0 +1,242 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+import (
+	gxset "github.com/dubbogo/gost/container/set"
+	"github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+)
+
+const (
+	DefaultRetryMaxAttempts = 3
+	DefaultRetryBaseDelay   = 100 * time.Millisecond
+	DefaultRetryMaxDelay    = 2 * time.Second
+)
+
+// RetryOption configures a RetryingConfiguration
+type RetryOption func(*RetryingConfiguration)
+
+// WithRetryMaxAttempts sets the number of attempts of a read, including the first one
+func WithRetryMaxAttempts(n int) RetryOption {
+	return func(r *RetryingConfiguration) {
+		r.maxAttempts = n
+	}
+}
+
+// WithRetryBackoff sets the delay before the first retry, doubled for each further retry up to maxDelay
+func WithRetryBackoff(baseDelay, maxDelay time.Duration) RetryOption {
+	return func(r *RetryingConfiguration) {
+		r.baseDelay = baseDelay
+		r.maxDelay = maxDelay
+	}
+}
+
+// WithRetryPredicate sets the predicate telling the transient errors, which are retried, from the
+// permanent ones. IsTransientError is used by default.
+func WithRetryPredicate(isTransient func(error) bool) RetryOption {
+	return func(r *RetryingConfiguration) {
+		r.isTransient = isTransient
+	}
+}
+
+// IsTransientError is the default predicate of RetryingConfiguration. A missing key, a value too large, an
+// unsupported operation and a canceled context are permanent. Everything else, timeouts and refused connections
+// included, is deemed transient since the clients of the backends don't tell their network errors apart.
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return !errors.Is(err, ErrKeyNotFound) && !errors.Is(err, ErrValueTooLarge) && !errors.Is(err, ErrUnsupported) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// RetryingConfiguration retries the reads of the wrapped DynamicConfiguration failing with a transient
+// error, with an exponential backoff between the attempts. The listeners and the writes are forwarded as
+// is, the optional interfaces resulting in ErrUnsupported if the wrapped one doesn't implement them.
+type RetryingConfiguration struct {
+	dc          DynamicConfiguration
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	isTransient func(error) bool
+}
+
+// NewRetryingConfiguration returns dc retrying its reads
+func NewRetryingConfiguration(dc DynamicConfiguration, opts ...RetryOption) *RetryingConfiguration {
+	r := &RetryingConfiguration{
+		dc:          dc,
+		maxAttempts: DefaultRetryMaxAttempts,
+		baseDelay:   DefaultRetryBaseDelay,
+		maxDelay:    DefaultRetryMaxDelay,
+		isTransient: IsTransientError,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.maxAttempts < 1 {
+		r.maxAttempts = 1
+	}
+	return r
+}
+
+// Unwrap returns the wrapped DynamicConfiguration
+func (r *RetryingConfiguration) Unwrap() DynamicConfiguration {
+	return r.dc
+}
+
+// retry calls read until it succeeds, fails with a permanent error, the attempts are exhausted or ctx is
+// done, and returns the last result
+func (r *RetryingConfiguration) retry(ctx context.Context, key string, read func() (string, error)) (string, error) {
+	delay := r.baseDelay
+	for attempt := 1; ; attempt++ {
+		value, err := read()
+		if err == nil || attempt >= r.maxAttempts || !r.isTransient(err) {
+			return value, err
+		}
+		logger.Debugf("[Config Center] read of key %s failed with transient error, retrying in %v: %v", key, delay, err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", ctx.Err()
+		case <-timer.C:
+		}
+		if delay *= 2; delay > r.maxDelay {
+			delay = r.maxDelay
+		}
+	}
+}
+
+func (r *RetryingConfiguration) Parser() parser.ConfigurationParser {
+	return r.dc.Parser()
+}
+
+func (r *RetryingConfiguration) SetParser(p parser.ConfigurationParser) {
+	r.dc.SetParser(p)
+}
+
+func (r *RetryingConfiguration) RegisterParserForPattern(glob string, p parser.ConfigurationParser) error {
+	return RegisterParserForPattern(r.dc, glob, p)
+}
+
+func (r *RetryingConfiguration) ParserFor(key string) parser.ConfigurationParser {
+	return ParserFor(r.dc, key)
+}
+
+func (r *RetryingConfiguration) AddListener(key string, listener ConfigurationListener, opts ...Option) {
+	r.dc.AddListener(key, listener, opts...)
+}
+
+func (r *RetryingConfiguration) RemoveListener(key string, listener ConfigurationListener, opts ...Option) {
+	r.dc.RemoveListener(key, listener, opts...)
+}
+
+func (r *RetryingConfiguration) AddGroupListener(group string, listener ConfigurationListener, opts ...Option) {
+	if err := AddGroupListener(r.dc, group, listener, opts...); err != nil {
+		logger.Warnf("[Config Center] can not listen group %s: %v", group, err)
+	}
+}
+
+func (r *RetryingConfiguration) RemoveGroupListener(group string, listener ConfigurationListener, opts ...Option) {
+	_ = RemoveGroupListener(r.dc, group, listener, opts...)
+}
+
+func (r *RetryingConfiguration) RemoveAllListeners(key string, opts ...Option) {
+	_ = RemoveAllListeners(r.dc, key, opts...)
+}
+
+func (r *RetryingConfiguration) GetProperties(key string, opts ...Option) (string, error) {
+	return r.retry(context.Background(), key, func() (string, error) {
+		return r.dc.GetProperties(key, opts...)
+	})
+}
+
+func (r *RetryingConfiguration) GetPropertiesWithMeta(key string, opts ...Option) (string, ValueMeta, error) {
+	var meta ValueMeta
+	value, err := r.retry(context.Background(), key, func() (string, error) {
+		var (
+			value string
+			err   error
+		)
+		value, meta, err = GetPropertiesWithMeta(r.dc, key, opts...)
+		return value, err
+	})
+	return value, meta, err
+}
+
+func (r *RetryingConfiguration) GetRule(key string, opts ...Option) (string, error) {
+	return r.retry(context.Background(), key, func() (string, error) {
+		return r.dc.GetRule(key, opts...)
+	})
+}
+
+func (r *RetryingConfiguration) GetInternalProperty(key string, opts ...Option) (string, error) {
+	return r.retry(context.Background(), key, func() (string, error) {
+		return r.dc.GetInternalProperty(key, opts...)
+	})
+}
+
+func (r *RetryingConfiguration) GetConfigKeysByGroup(group string) (*gxset.HashSet, error) {
+	var keys *gxset.HashSet
+	_, err := r.retry(context.Background(), group, func() (string, error) {
+		var err error
+		keys, err = r.dc.GetConfigKeysByGroup(group)
+		return "", err
+	})
+	return keys, err
+}
+
+func (r *RetryingConfiguration) GetPropertiesWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return r.retry(ctx, key, func() (string, error) {
+		return GetPropertiesWithContext(ctx, r.dc, key, opts...)
+	})
+}
+
+func (r *RetryingConfiguration) GetRuleWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return r.retry(ctx, key, func() (string, error) {
+		return GetRuleWithContext(ctx, r.dc, key, opts...)
+	})
+}
+
+func (r *RetryingConfiguration) GetInternalPropertyWithContext(ctx context.Context, key string, opts ...Option) (string, error) {
+	return r.retry(ctx, key, func() (string, error) {
+		return GetInternalPropertyWithContext(ctx, r.dc, key, opts...)
+	})
+}
+
+func (r *RetryingConfiguration) PublishConfig(key, group, value string) error {
+	return r.dc.PublishConfig(key, group, value)
+}
+
+func (r *RetryingConfiguration) RemoveConfig(key, group string) error {
+	return r.dc.RemoveConfig(key, group)
+}
+
+func (r *RetryingConfiguration) PublishConfigCAS(key, group, expectedOld, newValue string) (bool, error) {
+	return PublishConfigCAS(r.dc, key, group, expectedOld, newValue)
+}
@@ -0,0 +1,109 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyConfiguration fails its first failures reads with err
+type flakyConfiguration struct {
+	*MockDynamicConfiguration
+	failures int
+	err      error
+	calls    int
+}
+
+func (c *flakyConfiguration) GetProperties(_ string, _ ...Option) (string, error) {
+	c.calls++
+	if c.calls <= c.failures {
+		return "", c.err
+	}
+	return "value", nil
+}
+
+func newFlakyConfiguration(failures int, err error) *flakyConfiguration {
+	return &flakyConfiguration{MockDynamicConfiguration: &MockDynamicConfiguration{}, failures: failures, err: err}
+}
+
+func TestIsTransientError(t *testing.T) {
+	assert.False(t, IsTransientError(nil))
+	assert.False(t, IsTransientError(ErrKeyNotFound))
+	assert.False(t, IsTransientError(ErrUnsupported))
+	assert.False(t, IsTransientError(context.Canceled))
+	assert.True(t, IsTransientError(context.DeadlineExceeded))
+	assert.True(t, IsTransientError(syscall.ECONNREFUSED))
+}
+
+func TestRetryTransientError(t *testing.T) {
+	dc := newFlakyConfiguration(2, syscall.ECONNREFUSED)
+	r := NewRetryingConfiguration(dc, WithRetryBackoff(time.Millisecond, time.Millisecond))
+	value, err := r.GetProperties("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.Equal(t, 3, dc.calls)
+
+	dc = newFlakyConfiguration(3, syscall.ECONNREFUSED)
+	r = NewRetryingConfiguration(dc, WithRetryBackoff(time.Millisecond, time.Millisecond))
+	_, err = r.GetProperties("key")
+	assert.True(t, errors.Is(err, syscall.ECONNREFUSED))
+	assert.Equal(t, DefaultRetryMaxAttempts, dc.calls)
+}
+
+func TestRetryPermanentError(t *testing.T) {
+	dc := newFlakyConfiguration(1, ErrKeyNotFound)
+	r := NewRetryingConfiguration(dc, WithRetryBackoff(time.Millisecond, time.Millisecond))
+	_, err := r.GetProperties("key")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+	assert.Equal(t, 1, dc.calls)
+
+	dc = newFlakyConfiguration(1, ErrKeyNotFound)
+	r = NewRetryingConfiguration(dc, WithRetryBackoff(time.Millisecond, time.Millisecond),
+		WithRetryPredicate(func(error) bool { return true }))
+	value, err := r.GetProperties("key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.Equal(t, 2, dc.calls)
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+	dc := newFlakyConfiguration(5, syscall.ECONNREFUSED)
+	r := NewRetryingConfiguration(dc, WithRetryMaxAttempts(5), WithRetryBackoff(time.Millisecond, time.Millisecond))
+	_, err := r.GetProperties("key")
+	assert.Error(t, err)
+	assert.Equal(t, 5, dc.calls)
+	_, err = r.GetProperties("key")
+	assert.NoError(t, err)
+}
+
+func TestRetryContextDone(t *testing.T) {
+	dc := newFlakyConfiguration(1, syscall.ECONNREFUSED)
+	r := NewRetryingConfiguration(dc, WithRetryBackoff(time.Hour, time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := r.GetPropertiesWithContext(ctx, "key")
+	assert.True(t, errors.Is(err, context.Canceled))
+}
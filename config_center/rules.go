@@ -0,0 +1,102 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// RegexpRulePattern prefixes the patterns of GetRulesMatching which are regular expressions rather than globs
+const RegexpRulePattern = "regexp:"
+
+// GetRulesMatching returns the raw rules of the keys of the group in opts matching pattern, indexed by key.
+// The keys are listed with GetConfigKeysByGroup and the rules are read with GetRule.
+//
+// The pattern is a glob with the syntax of path.Match, applied to the segments of the rule keys built by
+// GetRuleKey, {interfaceName}:[version]:[group], so a * doesn't match a colon: com.foo.Service:*:* matches
+// the rules of every version and group of com.foo.Service, but not its rule without version nor group.
+// A pattern starting with RegexpRulePattern is instead a regular expression matched against the whole key.
+//
+// A key deleted between the listing and its read is skipped. The rules read successfully are always
+// returned, and the failures of the others are joined into the returned error.
+func GetRulesMatching(dc DynamicConfiguration, pattern string, opts ...Option) (map[string]string, error) {
+	match, err := ruleMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := dc.GetConfigKeysByGroup(NewOptions(opts...).Center.Group)
+	if err != nil {
+		return nil, perrors.WithMessagef(err, "list the rule keys matching %s", pattern)
+	}
+
+	var matched []string
+	for _, v := range keys.Values() {
+		if key, ok := v.(string); ok && match(key) {
+			matched = append(matched, key)
+		}
+	}
+	sort.Strings(matched)
+
+	rules := make(map[string]string, len(matched))
+	var errs []error
+	for _, key := range matched {
+		rule, err := dc.GetRule(key, opts...)
+		switch {
+		case errors.Is(err, ErrKeyNotFound):
+		case err != nil:
+			errs = append(errs, perrors.WithMessagef(err, "get rule of key %s", key))
+		default:
+			rules[key] = rule
+		}
+	}
+	return rules, errors.Join(errs...)
+}
+
+// ruleMatcher compiles the pattern of GetRulesMatching
+func ruleMatcher(pattern string) (func(string) bool, error) {
+	if expr, ok := strings.CutPrefix(pattern, RegexpRulePattern); ok {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "invalid rule pattern %s", pattern)
+		}
+		return re.MatchString, nil
+	}
+
+	glob := ruleSegmentsToPath(pattern)
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, perrors.WithMessagef(err, "invalid rule pattern %s", pattern)
+	}
+	return func(key string) bool {
+		ok, _ := path.Match(glob, ruleSegmentsToPath(key))
+		return ok
+	}, nil
+}
+
+// ruleSegmentsToPath turns the colons separating the segments of a rule key into slashes, which path.Match
+// doesn't let a * cross
+func ruleSegmentsToPath(s string) string {
+	return strings.ReplaceAll(s, ":", "/")
+}
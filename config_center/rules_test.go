@@ -0,0 +1,129 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"testing"
+)
+
+import (
+	gxset "github.com/dubbogo/gost/container/set"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rulesConfiguration serves the rules of a mapConfiguration and lists its keys, plus the extra ones
+type rulesConfiguration struct {
+	*mapConfiguration
+	extra []string
+}
+
+func (c *rulesConfiguration) GetRule(key string, opts ...Option) (string, error) {
+	return c.GetProperties(key, opts...)
+}
+
+func (c *rulesConfiguration) GetConfigKeysByGroup(string) (*gxset.HashSet, error) {
+	keys := gxset.NewSet()
+	for key := range c.values {
+		keys.Add(key)
+	}
+	for _, key := range c.extra {
+		keys.Add(key)
+	}
+	return keys, nil
+}
+
+func newRulesConfiguration() *rulesConfiguration {
+	return &rulesConfiguration{mapConfiguration: newMapConfiguration(map[string]string{
+		"com.foo.Service.condition-router":          "foo",
+		"com.foo.Service:1.0.0.condition-router":    "foo-1.0.0",
+		"com.foo.Service:1.0.0:g1.condition-router": "foo-1.0.0-g1",
+		"com.foo.Service:2.0.0:g2.condition-router": "foo-2.0.0-g2",
+		"com.foo.Service:2.0.0:g2.tag-router":       "foo-2.0.0-g2-tag",
+		"com.bar.Service:1.0.0:g1.condition-router": "bar-1.0.0-g1",
+	})}
+}
+
+func TestGetRulesMatchingGlob(t *testing.T) {
+	dc := newRulesConfiguration()
+
+	rules, err := GetRulesMatching(dc, "com.foo.Service:*:*.condition-router")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"com.foo.Service:1.0.0:g1.condition-router": "foo-1.0.0-g1",
+		"com.foo.Service:2.0.0:g2.condition-router": "foo-2.0.0-g2",
+	}, rules)
+
+	rules, err = GetRulesMatching(dc, "*:1.0.0:g1.condition-router")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"com.foo.Service:1.0.0:g1.condition-router": "foo-1.0.0-g1",
+		"com.bar.Service:1.0.0:g1.condition-router": "bar-1.0.0-g1",
+	}, rules)
+
+	rules, err = GetRulesMatching(dc, "com.foo.Service*")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"com.foo.Service.condition-router": "foo"}, rules)
+
+	rules, err = GetRulesMatching(dc, "com.baz.*")
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestGetRulesMatchingRegexp(t *testing.T) {
+	dc := newRulesConfiguration()
+
+	rules, err := GetRulesMatching(dc, RegexpRulePattern+`com\.foo\.Service(:[^:]+){0,2}\.condition-router`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"com.foo.Service.condition-router":          "foo",
+		"com.foo.Service:1.0.0.condition-router":    "foo-1.0.0",
+		"com.foo.Service:1.0.0:g1.condition-router": "foo-1.0.0-g1",
+		"com.foo.Service:2.0.0:g2.condition-router": "foo-2.0.0-g2",
+	}, rules)
+
+	// the expression matches the whole key
+	rules, err = GetRulesMatching(dc, RegexpRulePattern+`com\.foo\.Service`)
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+}
+
+func TestGetRulesMatchingInvalidPattern(t *testing.T) {
+	dc := newRulesConfiguration()
+
+	_, err := GetRulesMatching(dc, "com.foo.[")
+	assert.Error(t, err)
+	_, err = GetRulesMatching(dc, RegexpRulePattern+"com.foo.(")
+	assert.Error(t, err)
+}
+
+func TestGetRulesMatchingFailures(t *testing.T) {
+	dc := newRulesConfiguration()
+	// a listed key deleted before its read is skipped
+	dc.extra = []string{"com.foo.Service:3.0.0:g3.condition-router"}
+
+	rules, err := GetRulesMatching(dc, "com.foo.Service:*:*.condition-router")
+	assert.NoError(t, err)
+	assert.Len(t, rules, 2)
+
+	dc.err = errors.New("unavailable")
+	rules, err = GetRulesMatching(dc, "com.foo.Service:*:*.condition-router")
+	assert.ErrorIs(t, err, dc.err)
+	assert.Empty(t, rules)
+}
@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"sort"
+	"strings"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// ConfigurationSnapshotter is implemented by the config centers able to dump their values, which are the
+// nacos, zookeeper, file and memory backends. Use Snapshot to get ErrUnsupported from the others.
+type ConfigurationSnapshotter interface {
+	// Snapshot returns the values of all the keys of groups, or of all the groups if the backend is able to
+	// list them, indexed by SnapshotKey. It's a best-effort point-in-time copy, see SnapshotGroups.
+	Snapshot(groups ...string) (map[string]string, error)
+}
+
+// GroupLister is implemented by the config centers able to list their groups, which is only the memory
+// backend among the builtin ones.
+type GroupLister interface {
+	// Groups returns the groups having at least one key
+	Groups() ([]string, error)
+}
+
+// Snapshot calls the Snapshot of dc, or returns ErrUnsupported if dc doesn't implement
+// ConfigurationSnapshotter
+func Snapshot(dc DynamicConfiguration, groups ...string) (map[string]string, error) {
+	if s, ok := dc.(ConfigurationSnapshotter); ok {
+		return s.Snapshot(groups...)
+	}
+	return nil, ErrUnsupported
+}
+
+// SnapshotGroups returns the values of all the keys of groups indexed by SnapshotKey. It is the Snapshot
+// implementation shared by the config center backends. Without groups, it snapshots all the groups of dc
+// if it implements GroupLister, or else returns ErrUnsupported.
+//
+// The keys of a group are listed first, and then read one by one, so the snapshot is a best-effort
+// point-in-time copy: a key changed during the snapshot may have its old or its new value, and a key
+// added or deleted during the snapshot may be missing. The values are read by GetProperties with the
+// default options.
+func SnapshotGroups(dc DynamicConfiguration, groups ...string) (map[string]string, error) {
+	if len(groups) == 0 {
+		lister, ok := dc.(GroupLister)
+		if !ok {
+			return nil, perrors.WithMessage(ErrUnsupported, "list the groups of the config center")
+		}
+		var err error
+		if groups, err = lister.Groups(); err != nil {
+			return nil, perrors.WithMessage(err, "list the groups of the config center")
+		}
+	}
+
+	snapshot := make(map[string]string)
+	for _, group := range groups {
+		keys, err := dc.GetConfigKeysByGroup(group)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "list the keys of group %s", group)
+		}
+		for _, v := range keys.Values() {
+			key := v.(string)
+			value, err := dc.GetProperties(key, WithGroup(group))
+			if perrors.Is(err, ErrKeyNotFound) {
+				// deleted since the keys were listed
+				continue
+			}
+			if err != nil {
+				return nil, perrors.WithMessagef(err, "read key %s of group %s", key, group)
+			}
+			snapshot[SnapshotKey(key, group)] = value
+		}
+	}
+	return snapshot, nil
+}
+
+// SnapshotKey returns the key of the (key, group) pair in a snapshot, which is group/key. An empty group
+// is the default group.
+func SnapshotKey(key, group string) string {
+	if len(group) == 0 {
+		group = DefaultGroup
+	}
+	return group + "/" + key
+}
+
+// ParseSnapshotKey splits a key of a snapshot into the key and the group, see SnapshotKey
+func ParseSnapshotKey(snapshotKey string) (key, group string, err error) {
+	group, key, ok := strings.Cut(snapshotKey, "/")
+	if !ok || len(group) == 0 || len(key) == 0 {
+		return "", "", perrors.Errorf("invalid snapshot key %q, want group/key", snapshotKey)
+	}
+	return key, group, nil
+}
+
+// SortedSnapshotKeys returns the keys of snapshot in order, so that it's replayed deterministically
+func SortedSnapshotKeys(snapshot map[string]string) []string {
+	keys := make([]string, 0, len(snapshot))
+	for k := range snapshot {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
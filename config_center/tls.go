@@ -0,0 +1,115 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
+)
+
+// WithTLSConfig makes the config center constructed from the URL connect with cfg, e.g.
+//
+//	url, _ := common.NewURL("nacos://127.0.0.1:8848", config_center.WithTLSConfig(cfg))
+//
+// The backends configured by files only support WithTLSFiles, and fail with such a URL.
+func WithTLSConfig(cfg *tls.Config) common.Option {
+	return common.WithAttribute(constant.ConfigTLSConfigKey, cfg)
+}
+
+// WithTLSFiles makes the config center connect with TLS, verifying the server with the CA certificate in
+// caCertFile, and presenting the client certificate in certFile and keyFile if they are not empty.
+func WithTLSFiles(caCertFile, certFile, keyFile string) Option {
+	return func(opts *Options) {
+		if opts.Center.Params == nil {
+			opts.Center.Params = make(map[string]string)
+		}
+		opts.Center.Params[constant.ConfigTLSCACertFileKey] = caCertFile
+		opts.Center.Params[constant.ConfigTLSCertFileKey] = certFile
+		opts.Center.Params[constant.ConfigTLSKeyFileKey] = keyFile
+	}
+}
+
+// WithTLSServerName sets the server name verified in the certificate of the config center, by default the
+// host of its address
+func WithTLSServerName(name string) Option {
+	return func(opts *Options) {
+		if opts.Center.Params == nil {
+			opts.Center.Params = make(map[string]string)
+		}
+		opts.Center.Params[constant.ConfigTLSServerNameKey] = name
+	}
+}
+
+// TLSRequested reports whether url asks for TLS, either by WithTLSConfig or WithTLSFiles
+func TLSRequested(url *common.URL) bool {
+	if url == nil {
+		return false
+	}
+	if _, ok := url.GetAttribute(constant.ConfigTLSConfigKey); ok {
+		return true
+	}
+	return url.GetParam(constant.ConfigTLSCACertFileKey, "") != "" || url.GetParam(constant.ConfigTLSCertFileKey, "") != ""
+}
+
+// GetTLSConfig returns the TLS config of the config center constructed from url, nil if it doesn't ask
+// for TLS. The certificate files are loaded, so that a config center asking for TLS never falls back to
+// plaintext because of an unreadable certificate.
+func GetTLSConfig(url *common.URL) (*tls.Config, error) {
+	if !TLSRequested(url) {
+		return nil, nil
+	}
+	if v, ok := url.GetAttribute(constant.ConfigTLSConfigKey); ok {
+		cfg, ok := v.(*tls.Config)
+		if !ok || cfg == nil {
+			return nil, perrors.Errorf("config center tls: invalid tls config %T", v)
+		}
+		return cfg, nil
+	}
+
+	cfg := &tls.Config{ServerName: url.GetParam(constant.ConfigTLSServerNameKey, "")}
+	if caCertFile := url.GetParam(constant.ConfigTLSCACertFileKey, ""); caCertFile != "" {
+		caBytes, err := os.ReadFile(caCertFile)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "config center tls: read ca cert file %s", caCertFile)
+		}
+		ca := x509.NewCertPool()
+		if !ca.AppendCertsFromPEM(caBytes) {
+			return nil, perrors.Errorf("config center tls: no certificate found in ca cert file %s", caCertFile)
+		}
+		cfg.RootCAs = ca
+	}
+	if certFile := url.GetParam(constant.ConfigTLSCertFileKey, ""); certFile != "" {
+		keyFile := url.GetParam(constant.ConfigTLSKeyFileKey, "")
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, perrors.WithMessagef(err, "config center tls: load key pair %s, %s", certFile, keyFile)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
+)
+
+func TestGetTLSConfig(t *testing.T) {
+	url, _ := common.NewURL("nacos://127.0.0.1:8848")
+	assert.False(t, TLSRequested(url))
+	cfg, err := GetTLSConfig(url)
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	want := &tls.Config{ServerName: "config"}
+	url, _ = common.NewURL("nacos://127.0.0.1:8848", WithTLSConfig(want))
+	assert.True(t, TLSRequested(url))
+	cfg, err = GetTLSConfig(url)
+	assert.NoError(t, err)
+	assert.Equal(t, want, cfg)
+}
+
+func TestGetTLSConfigInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	caCertFile := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, os.WriteFile(caCertFile, []byte("not a certificate"), 0o600))
+
+	opts := NewOptions(WithTLSFiles(filepath.Join(dir, "missing.pem"), "", ""))
+	url, _ := common.NewURL("nacos://127.0.0.1:8848",
+		common.WithParamsValue(constant.ConfigTLSCACertFileKey, opts.Center.Params[constant.ConfigTLSCACertFileKey]))
+	assert.True(t, TLSRequested(url))
+	_, err := GetTLSConfig(url)
+	assert.ErrorContains(t, err, "missing.pem")
+
+	url, _ = common.NewURL("nacos://127.0.0.1:8848", common.WithParamsValue(constant.ConfigTLSCACertFileKey, caCertFile))
+	_, err = GetTLSConfig(url)
+	assert.ErrorContains(t, err, "no certificate found")
+
+	url, _ = common.NewURL("nacos://127.0.0.1:8848", common.WithParamsValue(constant.ConfigTLSCertFileKey, caCertFile),
+		common.WithParamsValue(constant.ConfigTLSKeyFileKey, caCertFile))
+	_, err = GetTLSConfig(url)
+	assert.ErrorContains(t, err, "load key pair")
+}
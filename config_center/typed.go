@@ -0,0 +1,128 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+
+	"gopkg.in/yaml.v2"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+)
+
+// GetInt reads key from dc and parses it as an int, a malformed value resulting in an error giving the
+// key and the value. See WithDefaultInt for a missing key.
+func GetInt(dc DynamicConfiguration, key string, opts ...Option) (int, error) {
+	return getTyped(dc, key, "int", strconv.Atoi, opts...)
+}
+
+// GetBool reads key from dc and parses it as a bool, accepting the values of strconv.ParseBool. See
+// WithDefaultBool for a missing key.
+func GetBool(dc DynamicConfiguration, key string, opts ...Option) (bool, error) {
+	return getTyped(dc, key, "bool", strconv.ParseBool, opts...)
+}
+
+// GetDuration reads key from dc and parses it as a duration, e.g. 1m30s. See WithDefaultDuration for a
+// missing key.
+func GetDuration(dc DynamicConfiguration, key string, opts ...Option) (time.Duration, error) {
+	return getTyped(dc, key, "duration", time.ParseDuration, opts...)
+}
+
+// GetFloat reads key from dc and parses it as a float64. See WithDefaultFloat for a missing key.
+func GetFloat(dc DynamicConfiguration, key string, opts ...Option) (float64, error) {
+	return getTyped(dc, key, "float", func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	}, opts...)
+}
+
+// GetList reads key from dc and parses it as an ordered list with the parser of dc, e.g. an ordered filter
+// chain written as a yaml sequence or comma separated values, see parser.ListParser. See WithDefaultList
+// for a missing key.
+func GetList(dc DynamicConfiguration, key string, opts ...Option) ([]string, error) {
+	// the value isn't trimmed, which would shift the first line of an indented yaml sequence
+	return getParsed(dc, key, "list", func(s string) ([]string, error) {
+		return parser.ParseList(dc.Parser(), s)
+	}, opts...)
+}
+
+// getTyped reads key from dc and parses its value trimmed of the surrounding spaces, see getParsed
+func getTyped[T any](dc DynamicConfiguration, key, typeName string, parse func(string) (T, error), opts ...Option) (T, error) {
+	return getParsed(dc, key, typeName, func(s string) (T, error) {
+		return parse(strings.TrimSpace(s))
+	}, opts...)
+}
+
+// getParsed reads key from dc and parses its value. A malformed value results in an error giving the key
+// and the value, which is redacted for the secret keys.
+func getParsed[T any](dc DynamicConfiguration, key, typeName string, parse func(string) (T, error), opts ...Option) (T, error) {
+	var zero T
+	value, err := dc.GetProperties(key, opts...)
+	if err != nil {
+		return zero, err
+	}
+	v, err := parse(value)
+	if err != nil {
+		if redacted := NewOptions(opts...).Redact(key, value); redacted != value {
+			// the parse error quotes the value as well
+			return zero, perrors.Errorf("invalid %s value %s of key %s", typeName, redacted, key)
+		}
+		return zero, perrors.Wrapf(err, "invalid %s value %q of key %s", typeName, value, key)
+	}
+	return v, nil
+}
+
+// WithDefaultInt is the WithDefault of GetInt
+func WithDefaultInt(value int) Option {
+	return WithDefault(strconv.Itoa(value))
+}
+
+// WithDefaultBool is the WithDefault of GetBool
+func WithDefaultBool(value bool) Option {
+	return WithDefault(strconv.FormatBool(value))
+}
+
+// WithDefaultDuration is the WithDefault of GetDuration
+func WithDefaultDuration(value time.Duration) Option {
+	return WithDefault(value.String())
+}
+
+// WithDefaultFloat is the WithDefault of GetFloat
+func WithDefaultFloat(value float64) Option {
+	return WithDefault(strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// WithDefaultList is the WithDefault of GetList, the values are written as a yaml sequence so that they
+// may hold commas
+func WithDefaultList(values []string) Option {
+	if len(values) == 0 {
+		return WithDefault("[]")
+	}
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return WithDefault(strings.Join(values, ","))
+	}
+	return WithDefault(string(out))
+}
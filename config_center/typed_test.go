@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedGetters(t *testing.T) {
+	dc := newMapConfiguration(map[string]string{
+		"int":      " 42 ",
+		"bool":     "true",
+		"duration": "1m30s",
+		"float":    "0.75",
+	})
+
+	i, err := GetInt(dc, "int")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, i)
+	b, err := GetBool(dc, "bool")
+	assert.NoError(t, err)
+	assert.True(t, b)
+	d, err := GetDuration(dc, "duration")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d)
+	f, err := GetFloat(dc, "float")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.75, f)
+}
+
+func TestTypedGettersMalformed(t *testing.T) {
+	dc := newMapConfiguration(map[string]string{
+		"timeout":  "soon",
+		"retries":  "3.5",
+		"password": "hunter2",
+	})
+
+	_, err := GetDuration(dc, "timeout")
+	assert.ErrorContains(t, err, `invalid duration value "soon" of key timeout`)
+	_, err = GetInt(dc, "retries")
+	assert.ErrorContains(t, err, `invalid int value "3.5" of key retries`)
+	assert.True(t, errors.Is(err, strconv.ErrSyntax))
+	_, err = GetBool(dc, "retries")
+	assert.Error(t, err)
+
+	// the values of the secret keys are redacted from the errors
+	_, err = GetInt(dc, "password")
+	assert.ErrorContains(t, err, "invalid int value *** of key password")
+	assert.NotContains(t, err.Error(), "hunter2")
+}
+
+func TestTypedGettersDefaults(t *testing.T) {
+	dc := newMapConfiguration(map[string]string{})
+
+	_, err := GetInt(dc, "missing")
+	assert.True(t, errors.Is(err, ErrKeyNotFound))
+
+	i, err := GetInt(dc, "missing", WithDefaultInt(-1))
+	assert.NoError(t, err)
+	assert.Equal(t, -1, i)
+	b, err := GetBool(dc, "missing", WithDefaultBool(true))
+	assert.NoError(t, err)
+	assert.True(t, b)
+	d, err := GetDuration(dc, "missing", WithDefaultDuration(1500*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, d)
+	f, err := GetFloat(dc, "missing", WithDefaultFloat(0.1))
+	assert.NoError(t, err)
+	assert.Equal(t, 0.1, f)
+
+	// a string default is parsed like a value
+	_, err = GetInt(dc, "missing", WithDefault("many"))
+	assert.ErrorContains(t, err, `invalid int value "many" of key missing`)
+}
+
+func TestGetList(t *testing.T) {
+	dc := newMapConfiguration(map[string]string{
+		"filters":  "- tps\n- echo\n- token\n",
+		"csv":      "token, echo, tps",
+		"nested":   "- a\n- [b]\n",
+		"password": "- hunter2\n- b: c\n",
+	})
+
+	list, err := GetList(dc, "filters")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tps", "echo", "token"}, list)
+	list, err = GetList(dc, "csv")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"token", "echo", "tps"}, list)
+
+	_, err = GetList(dc, "nested")
+	assert.ErrorContains(t, err, "invalid list value")
+	_, err = GetList(dc, "password")
+	assert.NotContains(t, err.Error(), "hunter2")
+
+	// the default round-trips, even with commas in the values
+	want := []string{"z", "a,b", "m"}
+	list, err = GetList(dc, "missing", WithDefaultList(want))
+	assert.NoError(t, err)
+	assert.Equal(t, want, list)
+	list, err = GetList(dc, "missing", WithDefaultList(nil))
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+}
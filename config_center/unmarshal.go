@@ -0,0 +1,120 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+import (
+	"github.com/mitchellh/mapstructure"
+
+	perrors "github.com/pkg/errors"
+
+	"gopkg.in/yaml.v2"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common/constant/file"
+	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
+)
+
+// GetAndUnmarshal reads key from dc and decodes the content into out.
+//
+// The format is the one set by WithFormat, or else the suffix of key, and falls back to properties.
+// yaml and json contents are unmarshalled directly, while properties contents are parsed by the parser of
+// key, see ParserFor, and the resulting map is decoded into out with its yaml tags.
+func GetAndUnmarshal(dc DynamicConfiguration, key string, out any, opts ...Option) error {
+	content, err := dc.GetProperties(key, opts...)
+	if err != nil {
+		return err
+	}
+
+	switch format := resolveFormat(key, NewOptions(opts...)); format {
+	case file.YAML, file.YML:
+		err = yaml.Unmarshal([]byte(content), out)
+	case file.JSON:
+		err = json.Unmarshal([]byte(content), out)
+	case file.PROPERTIES:
+		err = decodeProperties(ParserFor(dc, key), content, out)
+	default:
+		return perrors.Errorf("unsupported format %s of key %s", format, key)
+	}
+	if err != nil {
+		return perrors.WithMessagef(err, "unmarshal properties of key %s", key)
+	}
+	return nil
+}
+
+func resolveFormat(key string, opts *Options) file.Suffix {
+	if len(opts.Format) != 0 {
+		return opts.Format
+	}
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		switch suffix := file.Suffix(strings.ToLower(key[i+1:])); suffix {
+		case file.YAML, file.YML, file.JSON, file.PROPERTIES:
+			return suffix
+		}
+	}
+	return file.PROPERTIES
+}
+
+func decodeProperties(p parser.ConfigurationParser, content string, out any) error {
+	if p == nil {
+		p = &parser.DefaultConfigurationParser{}
+	}
+	properties, err := p.Parse(content)
+	if err != nil {
+		return err
+	}
+
+	// properties keys are dot separated paths, e.g. dubbo.application.name
+	nested := make(map[string]any)
+	for k, v := range properties {
+		setNestedValue(nested, strings.Split(k, "."), v)
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		TagName:          "yaml",
+		Result:           out,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(nested)
+}
+
+func setNestedValue(m map[string]any, path []string, value string) {
+	for _, p := range path[:len(path)-1] {
+		sub, ok := m[p].(map[string]any)
+		if !ok {
+			if _, isLeaf := m[p]; isLeaf {
+				// a leaf value already occupies the path
+				return
+			}
+			sub = make(map[string]any)
+			m[p] = sub
+		}
+		m = sub
+	}
+	if _, ok := m[path[len(path)-1]]; !ok {
+		m[path[len(path)-1]] = value
+	}
+}
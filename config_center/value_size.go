@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"io"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+// DefaultMaxValueSize is the default limit of the size of the values read, see WithMaxValueSize
+const DefaultMaxValueSize = 8 << 20
+
+// ErrValueTooLarge is returned by the getters when the value of the key is larger than the limit set by
+// WithMaxValueSize
+var ErrValueTooLarge = perrors.New("config center value too large")
+
+// WithMaxValueSize limits the values read by the getters, including GetRule, to n bytes, both as stored
+// and once decompressed, a larger value resulting in ErrValueTooLarge rather than in loading it in memory.
+// The backends reading the value as a stream, and the streaming codecs, stop reading once the limit is
+// crossed. n <= 0 removes the limit, which defaults to DefaultMaxValueSize.
+func WithMaxValueSize(n int) Option {
+	return func(opts *Options) {
+		opts.MaxValueSize = n
+	}
+}
+
+// CheckValueSize returns ErrValueTooLarge if a value of key of size bytes exceeds the limit set by
+// WithMaxValueSize
+func (o *Options) CheckValueSize(key string, size int64) error {
+	if o.MaxValueSize <= 0 || size <= int64(o.MaxValueSize) {
+		return nil
+	}
+	return o.valueTooLarge(key)
+}
+
+// ReadValue reads the value of key from r, aborting with ErrValueTooLarge as soon as more bytes than the
+// limit set by WithMaxValueSize are read
+func (o *Options) ReadValue(key string, r io.Reader) ([]byte, error) {
+	if o.MaxValueSize <= 0 {
+		return io.ReadAll(r)
+	}
+	content, err := io.ReadAll(io.LimitReader(r, int64(o.MaxValueSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > o.MaxValueSize {
+		return nil, o.valueTooLarge(key)
+	}
+	return content, nil
+}
+
+func (o *Options) valueTooLarge(key string) error {
+	return perrors.WithMessagef(ErrValueTooLarge, "key %s exceeds the limit of %d bytes", key, o.MaxValueSize)
+}
@@ -0,0 +1,65 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadValue(t *testing.T) {
+	opts := NewOptions(WithMaxValueSize(4))
+	content, err := opts.ReadValue("key", strings.NewReader("abcd"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abcd", string(content))
+
+	_, err = opts.ReadValue("key", strings.NewReader("abcde"))
+	assert.True(t, errors.Is(err, ErrValueTooLarge))
+
+	content, err = NewOptions(WithMaxValueSize(0)).ReadValue("key", strings.NewReader("abcde"))
+	assert.NoError(t, err)
+	assert.Equal(t, "abcde", string(content))
+}
+
+func TestDefaultMaxValueSize(t *testing.T) {
+	opts := NewOptions()
+	assert.Equal(t, DefaultMaxValueSize, opts.MaxValueSize)
+	assert.NoError(t, opts.CheckValueSize("key", DefaultMaxValueSize))
+	err := opts.CheckValueSize("key", DefaultMaxValueSize+1)
+	assert.True(t, errors.Is(err, ErrValueTooLarge))
+	assert.Contains(t, err.Error(), "key key")
+}
+
+func TestDecompressBounded(t *testing.T) {
+	compressed, err := NewOptions(WithCompression(GzipCodec)).Compress(strings.Repeat("a", 1024))
+	assert.NoError(t, err)
+	assert.Less(t, len(compressed), 100)
+
+	// the compressed value is small, but not once decompressed
+	_, err = NewOptions(WithCompression(GzipCodec), WithMaxValueSize(100)).Decompress("key", compressed)
+	assert.True(t, errors.Is(err, ErrValueTooLarge))
+
+	value, err := NewOptions(WithCompression(GzipCodec), WithMaxValueSize(1024)).Decompress("key", compressed)
+	assert.NoError(t, err)
+	assert.Len(t, value, 1024)
+}
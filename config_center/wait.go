@@ -0,0 +1,88 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+import (
+	perrors "github.com/pkg/errors"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+// WaitForValue blocks until the value of key in dc is expected, returning at once if it already is, or
+// until ctx is done, in which case ctx.Err() is returned. A missing or deleted key has the empty value.
+//
+// The listener watching key is registered before the current value is read, so no change is missed in
+// between, and it is removed on every return.
+func WaitForValue(ctx context.Context, dc DynamicConfiguration, key, expected string, opts ...Option) error {
+	listener := &valueWaiter{expected: expected, matched: make(chan struct{})}
+	dc.AddListener(key, listener, opts...)
+	defer dc.RemoveListener(key, listener, opts...)
+
+	value, err := GetPropertiesWithContext(ctx, dc, key, opts...)
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		listener.observe("")
+	case err != nil:
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return perrors.WithMessagef(err, "wait for the value of key %s", key)
+	default:
+		listener.observe(value)
+	}
+
+	select {
+	case <-listener.matched:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// valueWaiter closes matched once it sees the expected value
+type valueWaiter struct {
+	expected string
+	matched  chan struct{}
+	once     sync.Once
+}
+
+func (w *valueWaiter) Process(event *ConfigChangeEvent) {
+	if event.ConfigType == remoting.EventTypeDel {
+		w.observe("")
+		return
+	}
+	if value, ok := event.Value.(string); ok {
+		w.observe(value)
+	}
+}
+
+func (w *valueWaiter) observe(value string) {
+	if value == w.expected {
+		w.once.Do(func() {
+			close(w.matched)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package config_center
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
+// watchedConfiguration is a concurrency safe single key configuration notifying its listeners
+type watchedConfiguration struct {
+	*MockDynamicConfiguration
+	mu        sync.Mutex
+	value     string
+	listeners map[ConfigurationListener]struct{}
+}
+
+func newWatchedConfiguration(value string) *watchedConfiguration {
+	return &watchedConfiguration{
+		MockDynamicConfiguration: &MockDynamicConfiguration{},
+		value:                    value,
+		listeners:                make(map[ConfigurationListener]struct{}),
+	}
+}
+
+func (c *watchedConfiguration) GetProperties(key string, opts ...Option) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.value) == 0 {
+		return NewOptions(opts...).KeyNotFound(key)
+	}
+	return c.value, nil
+}
+
+func (c *watchedConfiguration) AddListener(_ string, listener ConfigurationListener, _ ...Option) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners[listener] = struct{}{}
+}
+
+func (c *watchedConfiguration) RemoveListener(_ string, listener ConfigurationListener, _ ...Option) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.listeners, listener)
+}
+
+func (c *watchedConfiguration) listenerCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.listeners)
+}
+
+func (c *watchedConfiguration) set(value string) {
+	c.mu.Lock()
+	c.value = value
+	listeners := make([]ConfigurationListener, 0, len(c.listeners))
+	for listener := range c.listeners {
+		listeners = append(listeners, listener)
+	}
+	c.mu.Unlock()
+
+	eventType := remoting.EventTypeUpdate
+	if len(value) == 0 {
+		eventType = remoting.EventTypeDel
+	}
+	for _, listener := range listeners {
+		listener.Process(&ConfigChangeEvent{Key: "flag", Value: value, ConfigType: eventType})
+	}
+}
+
+func TestWaitForValueAlreadyMatching(t *testing.T) {
+	dc := newWatchedConfiguration("on")
+
+	assert.NoError(t, WaitForValue(context.Background(), dc, "flag", "on"))
+	assert.Equal(t, 0, dc.listenerCount())
+}
+
+func TestWaitForValueFlippedAsynchronously(t *testing.T) {
+	dc := newWatchedConfiguration("off")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		for dc.listenerCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		dc.set("starting")
+		dc.set("on")
+	}()
+	assert.NoError(t, WaitForValue(ctx, dc, "flag", "on"))
+	assert.Equal(t, 0, dc.listenerCount())
+}
+
+func TestWaitForValueDeleted(t *testing.T) {
+	dc := newWatchedConfiguration("on")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		for dc.listenerCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		dc.set("")
+	}()
+	assert.NoError(t, WaitForValue(ctx, dc, "flag", ""))
+	assert.Equal(t, 0, dc.listenerCount())
+}
+
+func TestWaitForValueContextDone(t *testing.T) {
+	dc := newWatchedConfiguration("off")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, WaitForValue(ctx, dc, "flag", "on"), context.DeadlineExceeded)
+	assert.Equal(t, 0, dc.listenerCount())
+}
@@ -19,6 +19,7 @@ package zookeeper
 
 import (
 	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
 	"dubbo.apache.org/dubbo-go/v3/common/extension"
 	"dubbo.apache.org/dubbo-go/v3/config_center"
 	"dubbo.apache.org/dubbo-go/v3/config_center/parser"
@@ -35,6 +36,7 @@ func (f *zookeeperDynamicConfigurationFactory) GetDynamicConfiguration(url *comm
 	if err != nil {
 		return nil, err
 	}
-	dynamicConfiguration.SetParser(&parser.DefaultConfigurationParser{})
-	return dynamicConfiguration, err
+	dynamicConfiguration.SetParser(parser.NewCachingConfigurationParser(&parser.DefaultConfigurationParser{},
+		int(url.GetParamInt(constant.ConfigParseCacheSizeKey, parser.DefaultParseCacheSize))))
+	return config_center.ApplyKeyPrefix(dynamicConfiguration, url), err
 }
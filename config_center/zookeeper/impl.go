@@ -18,10 +18,12 @@
 package zookeeper
 
 import (
+	"context"
 	"encoding/base64"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 import (
@@ -53,13 +55,22 @@ type zookeeperDynamicConfiguration struct {
 	rootPath string
 	wg       sync.WaitGroup
 	cltLock  sync.Mutex
+	reads    config_center.PendingReads
 	done     chan struct{}
 	client   *gxzookeeper.ZookeeperClient
+	// destroyOnce makes Destroy idempotent
+	destroyOnce sync.Once
 
 	// listenerLock  sync.Mutex
 	listener      *zookeeper.ZkEventListener
 	cacheListener *CacheListener
+	pollers       config_center.PollingListeners
+	coalescers    config_center.CoalescingListeners
+	parsers       config_center.PatternParsers
+	groups        config_center.GroupListeners
+	lastGood      config_center.LastGoodRules
 	parser        parser.ConfigurationParser
+	observer      config_center.Observer
 
 	base64Enabled bool
 }
@@ -69,7 +80,10 @@ func newZookeeperDynamicConfiguration(url *common.URL) (*zookeeperDynamicConfigu
 		url: url,
 		// TODO adapt config center config
 		rootPath: "/dubbo/config",
+		done:     make(chan struct{}),
+		observer: config_center.GetObserver(url),
 	}
+	c.reads.Max = config_center.MaxPendingReads(url)
 	logger.Infof("[Zookeeper ConfigCenter] New Zookeeper ConfigCenter with Configuration: %+v, url = %+v", c, c.GetURL())
 	if v, ok := config.GetRootConfig().ConfigCenter.Params["base64"]; ok {
 		base64Enabled, err := strconv.ParseBool(v)
@@ -79,6 +93,12 @@ func newZookeeperDynamicConfiguration(url *common.URL) (*zookeeperDynamicConfigu
 		c.base64Enabled = base64Enabled
 	}
 
+	// The zookeeper client has no TLS support, refuse to connect in plaintext when TLS is asked for
+	if config_center.TLSRequested(url) {
+		return nil, perrors.Errorf("zookeeper config center does not support tls, refusing to connect to %s in plaintext", url.Location)
+	}
+
+	// the zookeeper clients are shared by address, a client being closed once no config center uses it
 	err := zookeeper.ValidateZookeeperClient(c, url.Location)
 	if err != nil {
 		logger.Errorf("zookeeper client start error ,error message is %v", err)
@@ -86,6 +106,7 @@ func newZookeeperDynamicConfiguration(url *common.URL) (*zookeeperDynamicConfigu
 	}
 	err = c.client.Create(c.rootPath)
 	if err != nil && err != zk.ErrNodeExists {
+		c.client.Close()
 		return nil, err
 	}
 
@@ -96,6 +117,10 @@ func newZookeeperDynamicConfiguration(url *common.URL) (*zookeeperDynamicConfigu
 	// Start listener
 	c.listener = zookeeper.NewZkEventListener(c.client)
 	c.cacheListener = NewCacheListener(c.rootPath, c.listener)
+	c.cacheListener.observer = c.observer
+	c.cacheListener.invalidate = func(content string) {
+		parser.InvalidateParsed(c.Parser(), content)
+	}
 	c.listener.ListenConfigurationEvent(c.rootPath, c.cacheListener)
 	return c, nil
 }
@@ -103,9 +128,19 @@ func newZookeeperDynamicConfiguration(url *common.URL) (*zookeeperDynamicConfigu
 // AddListener add listener for key
 // TODO this method should has a parameter 'group', and it does not now, so we should concat group and key with '/' manually
 func (c *zookeeperDynamicConfiguration) AddListener(key string, listener config_center.ConfigurationListener, options ...config_center.Option) {
+	tmpOpts := config_center.NewOptions(options...)
+	listener = c.coalescers.Add(key, listener, tmpOpts)
+	listener = c.pollers.Add(key, listener, func() (string, error) {
+		return c.GetProperties(key, options...)
+	}, tmpOpts)
+	c.cacheListener.AddListener(c.listenerPath(key), listener)
+	config_center.LoadInitial(listener)
+}
+
+// listenerPath returns the zk path watched for key
+func (c *zookeeperDynamicConfiguration) listenerPath(key string) string {
 	key = strings.Join([]string{c.GetURL().GetParam(constant.ConfigNamespaceKey, config_center.DefaultGroup), key}, "/")
-	qualifiedKey := buildPath(c.rootPath, key)
-	c.cacheListener.AddListener(qualifiedKey, listener)
+	return buildPath(c.rootPath, key)
 }
 
 // buildPath build path and format
@@ -119,38 +154,138 @@ func buildPath(rootPath, subPath string) string {
 }
 
 func (c *zookeeperDynamicConfiguration) RemoveListener(key string, listener config_center.ConfigurationListener, opions ...config_center.Option) {
-	c.cacheListener.RemoveListener(key, listener)
+	c.cacheListener.RemoveListener(c.listenerPath(key), c.pollers.Remove(key, c.coalescers.Remove(key, listener)))
+}
+
+// RemoveAllListeners removes all the listeners added by AddListener on key
+func (c *zookeeperDynamicConfiguration) RemoveAllListeners(key string, _ ...config_center.Option) {
+	c.pollers.RemoveKey(key)
+	c.cacheListener.RemoveAllListeners(c.listenerPath(key))
+	c.coalescers.RemoveKey(key)
+}
+
+// AddGroupListener adds a listener notified of the changes of any key of group. The configuration event
+// listener doesn't report the created children, so each key of the group is watched, the keys being found
+// by listing the group periodically.
+func (c *zookeeperDynamicConfiguration) AddGroupListener(group string, listener config_center.ConfigurationListener, opts ...config_center.Option) {
+	c.groups.Add(group, listener, config_center.GroupKeyWatch{
+		List: c.GetConfigKeysByGroup,
+		Get: func(group, key string) (string, error) {
+			return c.GetProperties(key, append(append([]config_center.Option{}, opts...), config_center.WithGroup(group))...)
+		},
+		Add: func(group, key string, listener config_center.ConfigurationListener) {
+			c.cacheListener.AddListener(c.getPath(key, group), listener)
+		},
+		Remove: func(group, key string, listener config_center.ConfigurationListener) {
+			c.cacheListener.RemoveListener(c.getPath(key, group), listener)
+		},
+	}, config_center.NewOptions(opts...))
+}
+
+// RemoveGroupListener removes a listener added by AddGroupListener and the watches of the keys of group
+func (c *zookeeperDynamicConfiguration) RemoveGroupListener(group string, listener config_center.ConfigurationListener, _ ...config_center.Option) {
+	c.groups.Remove(group, listener)
 }
 
 func (c *zookeeperDynamicConfiguration) GetProperties(key string, opts ...config_center.Option) (string, error) {
 	tmpOpts := config_center.NewOptions(opts...)
+	if tmpOpts.Interpolation {
+		return config_center.GetPropertiesInterpolated(c.GetProperties, key, opts...)
+	}
+	if len(tmpOpts.GroupChain) != 0 {
+		return config_center.GetPropertiesFromGroupChain(c.GetProperties, c.ParserFor(key), key, opts...)
+	}
+	start := time.Now()
+	value, err := c.getProperties(key, tmpOpts)
+	config_center.ObserveRead(c.observer, key, start, err)
+	config_center.LogRead(tmpOpts, key, value, err)
+	return value, err
+}
+
+func (c *zookeeperDynamicConfiguration) getProperties(key string, tmpOpts *config_center.Options) (string, error) {
+	value, _, err := c.getPropertiesWithMeta(key, tmpOpts)
+	return value, err
+}
+
+// GetPropertiesWithMeta get properties file along with the stat of its znode, see config_center.ValueMeta
+func (c *zookeeperDynamicConfiguration) GetPropertiesWithMeta(key string, opts ...config_center.Option) (string, config_center.ValueMeta, error) {
+	tmpOpts := config_center.NewOptions(opts...)
+	start := time.Now()
+	value, meta, err := c.getPropertiesWithMeta(key, tmpOpts)
+	config_center.ObserveRead(c.observer, key, start, err)
+	config_center.LogRead(tmpOpts, key, value, err)
+	return value, meta, err
+}
+
+func (c *zookeeperDynamicConfiguration) getPropertiesWithMeta(key string, tmpOpts *config_center.Options) (string, config_center.ValueMeta, error) {
 	/**
 	 * when group is not null, we are getting startup configs from Config Center, for example:
 	 * group=dubbo, key=dubbo.properties
 	 */
-	if len(tmpOpts.Center.Group) != 0 {
-		key = tmpOpts.Center.Group + "/" + key
-	} else {
-		key = c.GetURL().GetParam(constant.ConfigNamespaceKey, config_center.DefaultGroup) + "/" + key
+	group := tmpOpts.Center.Group
+	if len(group) == 0 {
+		group = c.GetURL().GetParam(constant.ConfigNamespaceKey, config_center.DefaultGroup)
 	}
-	content, _, err := c.client.GetContent(c.rootPath + "/" + key)
+	content, stat, err := c.client.GetContent(c.rootPath + "/" + group + "/" + key)
 	if err != nil {
-		return "", perrors.WithStack(err)
+		if perrors.Is(err, zk.ErrNoNode) {
+			value, err := tmpOpts.KeyNotFound(key)
+			return value, config_center.ValueMeta{}, err
+		}
+		return "", config_center.ValueMeta{}, perrors.WithStack(err)
 	}
-	if !c.base64Enabled {
-		return string(content), nil
+	if c.base64Enabled {
+		if content, err = base64.StdEncoding.DecodeString(string(content)); err != nil {
+			return "", config_center.ValueMeta{}, perrors.WithStack(err)
+		}
 	}
-
-	decoded, err := base64.StdEncoding.DecodeString(string(content))
+	// the znode is read at once, so the value is only checked once loaded
+	if err := tmpOpts.CheckValueSize(key, int64(len(content))); err != nil {
+		return "", config_center.ValueMeta{}, err
+	}
+	value, err := tmpOpts.Decompress(key, string(content))
 	if err != nil {
-		return "", perrors.WithStack(err)
+		return "", config_center.ValueMeta{}, err
 	}
-	return string(decoded), nil
+	return value, config_center.ValueMeta{
+		Revision:   stat.Mzxid,
+		Version:    int64(stat.Version),
+		ModifiedAt: time.UnixMilli(stat.Mtime),
+	}, nil
 }
 
-// GetInternalProperty For zookeeper, getConfig and getConfigs have the same meaning.
+// GetInternalProperty For zookeeper, getConfig and getConfigs have the same meaning. dubbo.properties is
+// never compressed.
 func (c *zookeeperDynamicConfiguration) GetInternalProperty(key string, opts ...config_center.Option) (string, error) {
-	return c.GetProperties(key, opts...)
+	return c.GetProperties(key, append(append([]config_center.Option{}, opts...), config_center.WithCompression(nil))...)
+}
+
+// GetPropertiesWithContext is GetProperties bounded by ctx and the read timeout, see NewURLReadContext.
+// The zk client is not context aware, so the read is abandoned rather than canceled when ctx is done: it
+// keeps running until the client returns, and at most config-center.max-pending-reads reads are pending at
+// once, see PendingReads.
+func (c *zookeeperDynamicConfiguration) GetPropertiesWithContext(ctx context.Context, key string, opts ...config_center.Option) (string, error) {
+	ctx, cancel := config_center.NewURLReadContext(ctx, c.url, config_center.NewOptions(opts...))
+	defer cancel()
+	return c.reads.Run(ctx, func() (string, error) {
+		return c.GetProperties(key, opts...)
+	})
+}
+
+// GetRuleWithContext is GetRule bounded by ctx and the read timeout, the read being abandoned rather than
+// canceled when ctx is done, see GetPropertiesWithContext.
+func (c *zookeeperDynamicConfiguration) GetRuleWithContext(ctx context.Context, key string, opts ...config_center.Option) (string, error) {
+	ctx, cancel := config_center.NewURLReadContext(ctx, c.url, config_center.NewOptions(opts...))
+	defer cancel()
+	return c.reads.Run(ctx, func() (string, error) {
+		return c.GetRule(key, opts...)
+	})
+}
+
+// GetInternalPropertyWithContext is GetInternalProperty bounded by ctx and the read timeout, the read being
+// abandoned rather than canceled when ctx is done, see GetPropertiesWithContext.
+func (c *zookeeperDynamicConfiguration) GetInternalPropertyWithContext(ctx context.Context, key string, opts ...config_center.Option) (string, error) {
+	return c.GetPropertiesWithContext(ctx, key, append(append([]config_center.Option{}, opts...), config_center.WithCompression(nil))...)
 }
 
 // PublishConfig will put the value into Zk with specific path
@@ -178,6 +313,50 @@ func (c *zookeeperDynamicConfiguration) PublishConfig(key string, group string,
 	return nil
 }
 
+// PublishConfigCAS sets the value of the (key, group) pair only if it's expectedOld, by a set on the
+// version of the node read, or a create if expectedOld is empty
+func (c *zookeeperDynamicConfiguration) PublishConfigCAS(key, group, expectedOld, newValue string) (bool, error) {
+	path := c.getPath(key, group)
+	valueBytes := []byte(newValue)
+	if c.base64Enabled {
+		valueBytes = []byte(base64.StdEncoding.EncodeToString(valueBytes))
+	}
+	content, stat, err := c.client.GetContent(path)
+	if perrors.Is(err, zk.ErrNoNode) {
+		if len(expectedOld) != 0 {
+			return false, nil
+		}
+		err = c.client.CreateWithValue(path, valueBytes)
+		if perrors.Is(err, zk.ErrNodeExists) {
+			return false, nil
+		}
+		if err != nil {
+			return false, perrors.WithStack(err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, perrors.WithStack(err)
+	}
+	if c.base64Enabled {
+		if content, err = base64.StdEncoding.DecodeString(string(content)); err != nil {
+			return false, perrors.WithStack(err)
+		}
+	}
+	// an empty expectedOld only matches a missing node
+	if len(expectedOld) == 0 || string(content) != expectedOld {
+		return false, nil
+	}
+	_, err = c.client.SetContent(path, valueBytes, stat.Version)
+	if perrors.Is(err, zk.ErrBadVersion) {
+		return false, nil
+	}
+	if err != nil {
+		return false, perrors.WithStack(err)
+	}
+	return true, nil
+}
+
 // RemoveConfig will remove the config with the (key, group) pair
 func (c *zookeeperDynamicConfiguration) RemoveConfig(key string, group string) error {
 	path := c.getPath(key, group)
@@ -206,14 +385,42 @@ func (c *zookeeperDynamicConfiguration) GetConfigKeysByGroup(group string) (*gxs
 	return set, nil
 }
 
+// Snapshot returns the values of all the keys of groups, which are required since the groups can't be
+// listed, see config_center.SnapshotGroups
+func (c *zookeeperDynamicConfiguration) Snapshot(groups ...string) (map[string]string, error) {
+	return config_center.SnapshotGroups(c, groups...)
+}
+
 func (c *zookeeperDynamicConfiguration) GetRule(key string, opts ...config_center.Option) (string, error) {
-	return c.GetProperties(key, opts...)
+	rule, err := c.GetProperties(key, opts...)
+	return c.lastGood.Check(key, config_center.NewOptions(opts...), c.ParserFor(key), rule, err)
 }
 
 func (c *zookeeperDynamicConfiguration) Parser() parser.ConfigurationParser {
 	return c.parser
 }
 
+// CacheStats returns the statistics of the parse cache, which are zero when the cache is disabled
+func (c *zookeeperDynamicConfiguration) CacheStats() parser.CacheStats {
+	stats, _ := parser.ParsedCacheStats(c.Parser())
+	return stats
+}
+
+// ResetCacheStats zeroes the hits, misses and evictions of the parse cache
+func (c *zookeeperDynamicConfiguration) ResetCacheStats() {
+	parser.ResetParsedCacheStats(c.Parser())
+}
+
+// RegisterParserForPattern makes p the parser of the keys matching glob
+func (c *zookeeperDynamicConfiguration) RegisterParserForPattern(glob string, p parser.ConfigurationParser) error {
+	return c.parsers.Register(glob, p)
+}
+
+// ParserFor returns the parser of key, falling back to Parser
+func (c *zookeeperDynamicConfiguration) ParserFor(key string) parser.ConfigurationParser {
+	return c.parsers.Parser(key, c.Parser())
+}
+
 func (c *zookeeperDynamicConfiguration) SetParser(p parser.ConfigurationParser) {
 	c.parser = p
 }
@@ -242,13 +449,19 @@ func (c *zookeeperDynamicConfiguration) GetURL() *common.URL {
 	return c.url
 }
 
+// Destroy stops the listeners and releases the client, destroying again is a no-op
 func (c *zookeeperDynamicConfiguration) Destroy() {
-	if c.listener != nil {
-		c.listener.Close()
-	}
-	close(c.done)
-	c.wg.Wait()
-	c.closeConfigs()
+	c.destroyOnce.Do(func() {
+		c.pollers.StopAll()
+		c.coalescers.StopAll()
+		c.groups.StopAll()
+		if c.listener != nil {
+			c.listener.Close()
+		}
+		close(c.done)
+		c.wg.Wait()
+		c.closeConfigs()
+	})
 }
 
 func (c *zookeeperDynamicConfiguration) IsAvailable() bool {
@@ -264,6 +477,7 @@ func (c *zookeeperDynamicConfiguration) closeConfigs() {
 	logger.Infof("begin to close provider zk client")
 	c.cltLock.Lock()
 	defer c.cltLock.Unlock()
+	// the client is closed once no other config center shares it
 	c.client.Close()
 	c.client = nil
 }
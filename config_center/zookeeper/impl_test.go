@@ -0,0 +1,69 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package zookeeper
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/remoting/zookeeper"
+)
+
+// newUnconnectedConfiguration creates a config center of url with the client of the constructor, which
+// connects in the background so that no zookeeper server is needed
+func newUnconnectedConfiguration(t *testing.T, url *common.URL) *zookeeperDynamicConfiguration {
+	c := &zookeeperDynamicConfiguration{url: url, done: make(chan struct{})}
+	assert.NoError(t, zookeeper.ValidateZookeeperClient(c, url.Location))
+	return c
+}
+
+func TestSharedClient(t *testing.T) {
+	url, err := common.NewURL("registry://127.0.0.1:12181")
+	assert.NoError(t, err)
+
+	const count = 3
+	centers := make([]*zookeeperDynamicConfiguration, count)
+	for i := range centers {
+		centers[i] = newUnconnectedConfiguration(t, url)
+	}
+	client := centers[0].client
+	for _, c := range centers {
+		assert.Same(t, client, c.client)
+	}
+
+	// the client is kept until the last Destroy, destroying again is a no-op
+	for _, c := range centers[:count-1] {
+		c.Destroy()
+		c.Destroy()
+		assert.False(t, c.IsAvailable())
+	}
+	c := newUnconnectedConfiguration(t, url)
+	assert.Same(t, client, c.client)
+	c.Destroy()
+	centers[count-1].Destroy()
+
+	c = newUnconnectedConfiguration(t, url)
+	defer c.Destroy()
+	assert.NotSame(t, client, c.client)
+}
@@ -33,10 +33,17 @@ import (
 
 // CacheListener defines keyListeners and rootPath
 type CacheListener struct {
-	// key is zkNode Path and value is set of listeners
+	// key is zkNode Path and value is set of listeners, the set is copied on write
+	// so that DataChange can range over it without holding a lock
 	keyListeners    sync.Map
+	listenerLock    sync.Mutex
+	barrier         config_center.ListenerBarrier
+	values          config_center.ValueCache
+	observer        config_center.Observer
 	zkEventListener *zookeeper.ZkEventListener
 	rootPath        string
+	// invalidate drops the parsed result of a replaced content
+	invalidate func(content string)
 }
 
 // NewCacheListener creates a new CacheListener
@@ -44,7 +51,7 @@ func NewCacheListener(rootPath string, listener *zookeeper.ZkEventListener) *Cac
 	return &CacheListener{zkEventListener: listener, rootPath: rootPath}
 }
 
-// AddListener will add a listener if loaded
+// AddListener will add a listener if loaded, adding the same listener for the same key again is a no-op
 func (l *CacheListener) AddListener(key string, listener config_center.ConfigurationListener) {
 	// FIXME do not use Client.ExistW, cause it has a bug(can not watch zk node that do not exist)
 	_, _, _, err := l.zkEventListener.Client.Conn.ExistsW(key)
@@ -53,41 +60,88 @@ func (l *CacheListener) AddListener(key string, listener config_center.Configura
 	if err != nil {
 		return
 	}
-	listeners, loaded := l.keyListeners.LoadOrStore(key, map[config_center.ConfigurationListener]struct{}{listener: {}})
-	if loaded {
-		listeners.(map[config_center.ConfigurationListener]struct{})[listener] = struct{}{}
-		l.keyListeners.Store(key, listeners)
+	l.listenerLock.Lock()
+	defer l.listenerLock.Unlock()
+	listeners := map[config_center.ConfigurationListener]struct{}{}
+	if old, loaded := l.keyListeners.Load(key); loaded {
+		if _, ok := old.(map[config_center.ConfigurationListener]struct{})[listener]; ok {
+			return
+		}
+		for k := range old.(map[config_center.ConfigurationListener]struct{}) {
+			listeners[k] = struct{}{}
+		}
 	}
+	listeners[listener] = struct{}{}
+	l.keyListeners.Store(key, listeners)
 }
 
-// RemoveListener will delete a listener if loaded
+// RemoveListener will delete a listener if loaded, and the key along with its last listener
 func (l *CacheListener) RemoveListener(key string, listener config_center.ConfigurationListener) {
-	listeners, loaded := l.keyListeners.Load(key)
-	if loaded {
-		delete(listeners.(map[config_center.ConfigurationListener]struct{}), listener)
+	l.listenerLock.Lock()
+	defer l.listenerLock.Unlock()
+	old, loaded := l.keyListeners.Load(key)
+	if !loaded {
+		return
+	}
+	listeners := map[config_center.ConfigurationListener]struct{}{}
+	for k := range old.(map[config_center.ConfigurationListener]struct{}) {
+		if k != listener {
+			listeners[k] = struct{}{}
+		}
+	}
+	if len(listeners) == 0 {
+		l.keyListeners.Delete(key)
+		return
+	}
+	l.keyListeners.Store(key, listeners)
+}
+
+// RemoveAllListeners will delete the listeners of the key but the ones of the group listeners, and the key
+// along with them if none is left. No event is delivered to the deleted listeners once it returns.
+func (l *CacheListener) RemoveAllListeners(key string) {
+	l.listenerLock.Lock()
+	if old, loaded := l.keyListeners.Load(key); loaded {
+		listeners := map[config_center.ConfigurationListener]struct{}{}
+		for k := range old.(map[config_center.ConfigurationListener]struct{}) {
+			if config_center.IsGroupKeyListener(k) {
+				listeners[k] = struct{}{}
+			}
+		}
+		if len(listeners) == 0 {
+			l.keyListeners.Delete(key)
+		} else {
+			l.keyListeners.Store(key, listeners)
+		}
 	}
+	l.listenerLock.Unlock()
+	l.barrier.Wait()
 }
 
 // DataChange changes all listeners' event
 func (l *CacheListener) DataChange(event remoting.Event) bool {
+	// the action tells a deleted node from an empty one
 	changeType := event.Action
-	if event.Content == "" {
-		changeType = remoting.EventTypeDel
-	}
 
 	key, group := l.pathToKeyGroup(event.Path)
 	defer metrics.Publish(metricsConfigCenter.NewIncMetricEvent(key, group, changeType, metricsConfigCenter.Zookeeper))
-	if listeners, ok := l.keyListeners.Load(event.Path); ok {
+	var delivered bool
+	l.barrier.Deliver(func() {
+		listeners, ok := l.keyListeners.Load(event.Path)
+		if !ok {
+			return
+		}
+		config_center.ObserveEvent(l.observer, key)
+		changeEvent := l.values.NewChangeEvent(key, event.Content, changeType)
+		if l.invalidate != nil {
+			l.invalidate(changeEvent.OldValue)
+		}
 		for listener := range listeners.(map[config_center.ConfigurationListener]struct{}) {
-			listener.Process(&config_center.ConfigChangeEvent{
-				Key:        key,
-				Value:      event.Content,
-				ConfigType: changeType,
-			})
+			e := *changeEvent
+			listener.Process(&e)
 		}
-		return true
-	}
-	return false
+		delivered = true
+	})
+	return delivered
 }
 
 func (l *CacheListener) pathToKeyGroup(path string) (string, string) {
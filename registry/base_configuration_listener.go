@@ -80,7 +80,11 @@ func (bcl *BaseConfigurationListener) Process(event *config_center.ConfigChangeE
 }
 
 func (bcl *BaseConfigurationListener) genConfiguratorFromRawRule(rawConfig string) error {
-	urls, err := bcl.dynamicConfiguration.Parser().ParseToUrls(rawConfig)
+	p := bcl.dynamicConfiguration.Parser()
+	if p == nil {
+		return config_center.ErrNoParserConfigured
+	}
+	urls, err := p.ParseToUrls(rawConfig)
 	if err != nil {
 		return perrors.WithMessage(err, "Failed to parse raw dynamic config and it will not take effect, the raw config is: "+
 			rawConfig)
@@ -47,7 +47,8 @@ func ValidateZookeeperClient(container ZkClientFacade, zkName string) error {
 
 	if container.ZkClient() == nil {
 		// in dubbo, every registry only connect one node, so this is []string{r.Address}
-		timeout := url.GetParamDuration(constant.ConfigTimeoutKey, constant.DefaultRegTimeout)
+		// the connect timeout of a config center takes precedence over its timeout
+		timeout := url.GetParamDuration(constant.ConfigConnectTimeoutKey, url.GetParam(constant.ConfigTimeoutKey, constant.DefaultRegTimeout))
 
 		zkAddresses := strings.Split(url.Location, ",")
 		logger.Infof("[Zookeeper Client] New zookeeper client with name = %s, zkAddress = %s, timeout = %s", zkName, url.Location, timeout.String())
@@ -97,11 +97,9 @@ func (l *ZkEventListener) ListenConfigurationEvent(zkPath string, listener remot
 					continue
 				}
 
-				action := remoting.EventTypeAdd
+				action := configEventAction(event.Type)
 				var content string
-				if event.Type == zk.EventNodeDeleted {
-					action = remoting.EventTypeDel
-				} else {
+				if action != remoting.EventTypeDel {
 					// 2. Try to get new configuration value of the zk node
 					// Notice: The order of step 1 and step 2 cannot be swapped, if you get value(with timestamp t1)
 					// before re-set the watcher(with timestamp t2), and some one change the data of the zk node after
@@ -117,7 +115,7 @@ func (l *ZkEventListener) ListenConfigurationEvent(zkPath string, listener remot
 
 				listener.DataChange(remoting.Event{
 					Path:    event.Path,
-					Action:  remoting.EventType(action),
+					Action:  action,
 					Content: content,
 				})
 			case <-l.exit:
@@ -128,6 +126,19 @@ func (l *ZkEventListener) ListenConfigurationEvent(zkPath string, listener remot
 	}(zkPath, listener)
 }
 
+// configEventAction maps the type of a zk event on a configuration node to the action of its event, an
+// empty node being a legitimate value rather than a deleted one
+func configEventAction(eventType zk.EventType) remoting.EventType {
+	switch eventType {
+	case zk.EventNodeCreated:
+		return remoting.EventTypeAdd
+	case zk.EventNodeDeleted:
+		return remoting.EventTypeDel
+	default:
+		return remoting.EventTypeUpdate
+	}
+}
+
 // nolint
 func (l *ZkEventListener) listenServiceNodeEvent(zkPath string, listener ...remoting.DataListener) bool {
 	l.pathMapLock.Lock()
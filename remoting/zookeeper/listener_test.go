@@ -23,11 +23,23 @@ import (
 )
 
 import (
+	"github.com/dubbogo/go-zookeeper/zk"
+
 	"github.com/stretchr/testify/assert"
 )
 
+import (
+	"dubbo.apache.org/dubbo-go/v3/remoting"
+)
+
 func TestZkPath(t *testing.T) {
 	zkPath := "io.grpc.examples.helloworld.GreeterGrpc$IGreeter"
 	zkPath = url.QueryEscape(zkPath)
 	assert.Equal(t, zkPath, "io.grpc.examples.helloworld.GreeterGrpc%24IGreeter")
 }
+
+func TestConfigEventAction(t *testing.T) {
+	assert.Equal(t, remoting.EventTypeAdd, configEventAction(zk.EventNodeCreated))
+	assert.Equal(t, remoting.EventTypeUpdate, configEventAction(zk.EventNodeDataChanged))
+	assert.Equal(t, remoting.EventTypeDel, configEventAction(zk.EventNodeDeleted))
+}
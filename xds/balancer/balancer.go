@@ -29,9 +29,11 @@ import (
 )
 
 import (
-	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/cdsbalancer"     // Register the CDS balancer
-	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/clusterimpl"     // Register the xds_cluster_impl balancer
-	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/clustermanager"  // Register the xds_cluster_manager balancer
-	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/clusterresolver" // Register the xds_cluster_resolver balancer
-	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/priority"        // Register the priority balancer
+	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/cdsbalancer"        // Register the CDS balancer
+	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/clusterimpl"        // Register the xds_cluster_impl balancer
+	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/clustermanager"     // Register the xds_cluster_manager balancer
+	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/clusterresolver"    // Register the xds_cluster_resolver balancer
+	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/priority"           // Register the priority balancer
+	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/weightedlocality"   // Register the weighted_locality balancer
+	_ "dubbo.apache.org/dubbo-go/v3/xds/balancer/weightedroundrobin" // Register the weighted_round_robin balancer
 )
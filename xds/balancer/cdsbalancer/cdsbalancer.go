@@ -25,6 +25,8 @@
 package cdsbalancer
 
 import (
+	"context"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -77,7 +79,8 @@ var (
 		// subConns.
 		return builder.Build(cc, opts), nil
 	}
-	buildProvider = buildProviderFunc
+	buildProvider            = buildProviderFunc
+	buildSystemRootsProvider = buildSystemRootsProviderFunc
 )
 
 func init() {
@@ -225,7 +228,8 @@ func (b *cdsBalancer) handleSecurityConfig(config *resource.SecurityConfig) erro
 	}
 
 	bc := b.xdsClient.BootstrapConfig()
-	if bc == nil || bc.CertProviderConfigs == nil {
+	needProviders := config.RootInstanceName != "" || config.IdentityInstanceName != ""
+	if bc == nil || (bc.CertProviderConfigs == nil && needProviders) {
 		// Bootstrap did not find any certificate provider configs, but the user
 		// has specified xdsCredentials and the management server has sent down
 		// security configuration.
@@ -233,8 +237,21 @@ func (b *cdsBalancer) handleSecurityConfig(config *resource.SecurityConfig) erro
 	}
 	cpc := bc.CertProviderConfigs
 
-	// A root provider is required whether we are using TLS or mTLS.
-	rootProvider, err := buildProvider(cpc, config.RootInstanceName, config.RootCertName, false, true)
+	// A root provider is required whether we are using TLS or mTLS. Without a
+	// root provider instance, the system root certificates are used, if the
+	// bootstrap file allows it.
+	var (
+		rootProvider certprovider.Provider
+		err          error
+	)
+	if config.RootInstanceName == "" {
+		if !bc.UseSystemRootCerts {
+			return errors.New("xds: root certificate provider instance name missing in security config, and use_system_root_certs not set in bootstrap file")
+		}
+		rootProvider, err = buildSystemRootsProvider()
+	} else {
+		rootProvider, err = buildProvider(cpc, config.RootInstanceName, config.RootCertName, false, true)
+	}
 	if err != nil {
 		return err
 	}
@@ -287,6 +304,27 @@ func buildProviderFunc(configs map[string]*certprovider.BuildableConfig, instanc
 	return provider, nil
 }
 
+// systemRootsProvider is a certprovider.Provider serving the system root
+// certificates, for the clusters whose security config doesn't name a root
+// certificate provider instance.
+type systemRootsProvider struct {
+	km *certprovider.KeyMaterial
+}
+
+func (p *systemRootsProvider) KeyMaterial(context.Context) (*certprovider.KeyMaterial, error) {
+	return p.km, nil
+}
+
+func (p *systemRootsProvider) Close() {}
+
+func buildSystemRootsProviderFunc() (certprovider.Provider, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		return nil, fmt.Errorf("xds: failed to load system root certificates: %v", err)
+	}
+	return &systemRootsProvider{km: &certprovider.KeyMaterial{Roots: roots}}, nil
+}
+
 // handleWatchUpdate handles a watch update from the xDS Client. Good updates
 // lead to clientConn updates being invoked on the underlying cluster_resolver balancer.
 func (b *cdsBalancer) handleWatchUpdate(update clusterHandlerUpdate) {
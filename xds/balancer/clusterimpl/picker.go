@@ -24,8 +24,6 @@
 package clusterimpl
 
 import (
-	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
-
 	dubbogoLogger "github.com/dubbogo/gost/log/logger"
 
 	"google.golang.org/grpc/balancer"
@@ -38,6 +36,7 @@ import (
 )
 
 import (
+	"dubbo.apache.org/dubbo-go/v3/xds/balancer/orca"
 	"dubbo.apache.org/dubbo-go/v3/xds/client"
 	"dubbo.apache.org/dubbo-go/v3/xds/client/load"
 	"dubbo.apache.org/dubbo-go/v3/xds/utils/wrr"
@@ -172,8 +171,10 @@ func (d *picker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
 			}
 			d.loadStore.CallFinished(lIDStr, info.Err)
 
-			load, ok := info.ServerLoad.(*orcapb.OrcaLoadReport)
-			if !ok {
+			// The load report is read from the trailer when the transport
+			// didn't parse it, as by the ORCA aware balancers.
+			load := orca.FromDoneInfo(info)
+			if load == nil {
 				return
 			}
 			d.loadStore.CallServerLoad(lIDStr, serverLoadCPUName, load.CpuUtilization)
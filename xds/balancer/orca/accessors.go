@@ -0,0 +1,43 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+)
+
+// CPUUtilization returns the CPU utilization of r, 0 if r is nil.
+func CPUUtilization(r *orcapb.OrcaLoadReport) float64 {
+	return r.GetCpuUtilization()
+}
+
+// MemUtilization returns the memory utilization of r, 0 if r is nil.
+func MemUtilization(r *orcapb.OrcaLoadReport) float64 {
+	return r.GetMemUtilization()
+}
+
+// NamedMetric returns the named metric key of r, looked up in the utilization
+// metrics, then in the request costs. It returns false if r is nil or has no
+// such metric.
+func NamedMetric(r *orcapb.OrcaLoadReport, key string) (float64, bool) {
+	if v, ok := r.GetUtilization()[key]; ok {
+		return v, true
+	}
+	v, ok := r.GetRequestCost()[key]
+	return v, ok
+}
@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"context"
+	"sync"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"github.com/dubbogo/gost/log/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// CostRecorder accumulates the costs of a request, e.g. the bytes read from a
+// database, to be reported in the request_cost of the per-request load
+// report. It's safe for concurrent use, so that the workers spawned by a
+// handler can record their costs. The methods of a nil CostRecorder are
+// no-ops.
+type CostRecorder struct {
+	mu    sync.Mutex
+	costs map[string]float64
+}
+
+type costRecorderKey struct{}
+
+// NewContextWithCostRecorder returns a new CostRecorder and a copy of ctx
+// carrying it, see CostRecorderFromContext.
+func NewContextWithCostRecorder(ctx context.Context) (context.Context, *CostRecorder) {
+	r := &CostRecorder{}
+	return context.WithValue(ctx, costRecorderKey{}, r), r
+}
+
+// CostRecorderFromContext returns the CostRecorder of the request of ctx, nil
+// if there's none.
+func CostRecorderFromContext(ctx context.Context) *CostRecorder {
+	r, _ := ctx.Value(costRecorderKey{}).(*CostRecorder)
+	return r
+}
+
+// AddCost adds value to the cost name of the request.
+func (r *CostRecorder) AddCost(name string, value float64) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.costs == nil {
+		r.costs = make(map[string]float64)
+	}
+	r.costs[name] += value
+}
+
+// Report returns the load report of the costs accumulated so far, nil if
+// there's none.
+func (r *CostRecorder) Report() *orcapb.OrcaLoadReport {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.costs) == 0 {
+		return nil
+	}
+	costs := make(map[string]float64, len(r.costs))
+	for name, value := range r.costs {
+		costs[name] = value
+	}
+	return &orcapb.OrcaLoadReport{RequestCost: costs}
+}
+
+// ToMetadata converts the load report of the costs accumulated so far into
+// grpc metadata, see ToMetadata. It returns nil if there's no cost.
+func (r *CostRecorder) ToMetadata() metadata.MD {
+	report := r.Report()
+	if report == nil {
+		return nil
+	}
+	return ToMetadata(report)
+}
+
+// UnaryServerInterceptor returns a server interceptor binding a CostRecorder
+// to the context of each request, and sending the costs recorded by the
+// handler in the response trailer once it returns. Handlers record their
+// costs with
+//
+//	orca.CostRecorderFromContext(ctx).AddCost("db_bytes", n)
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, r := NewContextWithCostRecorder(ctx)
+		resp, err := handler(ctx, req)
+		if md := r.ToMetadata(); md != nil {
+			if terr := grpc.SetTrailer(ctx, md); terr != nil {
+				logger.Warnf("orca: failed to set the load report trailer: %v", terr)
+			}
+		}
+		return resp, err
+	}
+}
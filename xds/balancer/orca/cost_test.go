@@ -0,0 +1,98 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/grpc"
+)
+
+func TestCostRecorderConcurrent(t *testing.T) {
+	ctx, _ := NewContextWithCostRecorder(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				CostRecorderFromContext(ctx).AddCost("db", 1)
+			}
+		}()
+	}
+	wg.Wait()
+	CostRecorderFromContext(ctx).AddCost("cache", 0.5)
+
+	got := FromMetadata(CostRecorderFromContext(ctx).ToMetadata())
+	want := &orcapb.OrcaLoadReport{RequestCost: map[string]float64{"db": 1000, "cache": 0.5}}
+	if !proto.Equal(got, want) {
+		t.Fatalf("FromMetadata(ToMetadata()) = %v, want %v", got, want)
+	}
+}
+
+func TestCostRecorderNone(t *testing.T) {
+	r := CostRecorderFromContext(context.Background())
+	if r != nil {
+		t.Fatalf("CostRecorderFromContext() = %v, want nil", r)
+	}
+	r.AddCost("db", 1)
+	if md := r.ToMetadata(); md != nil {
+		t.Fatalf("ToMetadata() of a nil recorder = %v, want nil", md)
+	}
+	if _, r = NewContextWithCostRecorder(context.Background()); r.ToMetadata() != nil {
+		t.Fatalf("ToMetadata() without cost = %v, want nil", r.ToMetadata())
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var recorded *CostRecorder
+	handler := func(ctx context.Context, req any) (any, error) {
+		recorded = CostRecorderFromContext(ctx)
+		recorded.AddCost("db", 2)
+		return req, nil
+	}
+	// Without a server transport stream the trailer can't be set, which is
+	// only logged.
+	resp, err := UnaryServerInterceptor()(context.Background(), "req", &grpc.UnaryServerInfo{}, handler)
+	if err != nil || resp != "req" {
+		t.Fatalf("interceptor returned %v, %v, want req, nil", resp, err)
+	}
+	if got := recorded.Report().GetRequestCost()["db"]; got != 2 {
+		t.Fatalf("recorded cost = %v, want 2", got)
+	}
+}
+
+// The interceptor is installed on the server, and the handlers record the
+// costs of their requests, which are sent in the response trailers.
+func ExampleUnaryServerInterceptor() {
+	s := grpc.NewServer(grpc.UnaryInterceptor(UnaryServerInterceptor()))
+	defer s.Stop()
+
+	_ = func(ctx context.Context, req any) (any, error) {
+		CostRecorderFromContext(ctx).AddCost("db_bytes", 4096)
+		return req, nil
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// AddressMDKey is the metadata key of the backend address, which
+// MergingParser.Parse uses to find the out-of-band report of the backend,
+// since balancerload.Parser isn't given the address.
+const AddressMDKey = "x-endpoint-address"
+
+// DefaultStaleness is the default duration after which a cached out-of-band
+// report is discarded.
+const DefaultStaleness = 30 * time.Second
+
+type oobReport struct {
+	report   *orcapb.OrcaLoadReport
+	received time.Time
+}
+
+// MergingParser combines the in-band load reports, from the response
+// metadata, and the out-of-band ones, streamed by the backends, into a single
+// view of the backend load.
+//
+// It caches the latest out-of-band report per address. The in-band report is
+// overlaid on top of it, as it's the freshest source. Out-of-band reports
+// older than the staleness window are discarded.
+type MergingParser struct {
+	staleness time.Duration
+
+	mu      sync.Mutex
+	reports map[string]oobReport
+}
+
+// NewMergingParser creates a MergingParser discarding the out-of-band reports
+// older than staleness. staleness <= 0 means DefaultStaleness.
+func NewMergingParser(staleness time.Duration) *MergingParser {
+	if staleness <= 0 {
+		staleness = DefaultStaleness
+	}
+	return &MergingParser{
+		staleness: staleness,
+		reports:   make(map[string]oobReport),
+	}
+}
+
+// UpdateOutOfBand caches r as the latest out-of-band report of addr.
+func (p *MergingParser) UpdateOutOfBand(addr string, r *orcapb.OrcaLoadReport) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if r == nil {
+		delete(p.reports, addr)
+		return
+	}
+	p.reports[addr] = oobReport{report: r, received: time.Now()}
+}
+
+// Listener returns a listener caching the reports of addr, to be passed to
+// RegisterListener.
+func (p *MergingParser) Listener(addr string) func(*orcapb.OrcaLoadReport) {
+	return func(r *orcapb.OrcaLoadReport) {
+		p.UpdateOutOfBand(addr, r)
+	}
+}
+
+// Parse implements balancerload.Parser. It merges the in-band report in md
+// with the out-of-band report of the address in md under AddressMDKey.
+func (p *MergingParser) Parse(md metadata.MD) any {
+	var addr string
+	if vs := md.Get(AddressMDKey); len(vs) != 0 {
+		addr = vs[0]
+	}
+	r := p.Merge(addr, FromMetadata(md))
+	if r == nil {
+		// Don't return a typed nil in the interface.
+		return nil
+	}
+	return r
+}
+
+// Merge returns the out-of-band report of addr, if not stale, overlaid with
+// inBand. Utilization and named metrics set in inBand take precedence. It
+// returns nil if neither report is available.
+func (p *MergingParser) Merge(addr string, inBand *orcapb.OrcaLoadReport) *orcapb.OrcaLoadReport {
+	oob := p.outOfBand(addr)
+	if oob == nil {
+		return inBand
+	}
+	ret := proto.Clone(oob).(*orcapb.OrcaLoadReport)
+	if inBand == nil {
+		return ret
+	}
+	if inBand.CpuUtilization != 0 {
+		ret.CpuUtilization = inBand.CpuUtilization
+	}
+	if inBand.MemUtilization != 0 {
+		ret.MemUtilization = inBand.MemUtilization
+	}
+	if inBand.Rps != 0 {
+		ret.Rps = inBand.Rps
+	}
+	ret.RequestCost = mergeNamedMetrics(ret.RequestCost, inBand.RequestCost)
+	ret.Utilization = mergeNamedMetrics(ret.Utilization, inBand.Utilization)
+	return ret
+}
+
+// outOfBand returns the cached out-of-band report of addr, discarding it if
+// stale.
+func (p *MergingParser) outOfBand(addr string) *orcapb.OrcaLoadReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cached, ok := p.reports[addr]
+	if !ok {
+		return nil
+	}
+	if time.Since(cached.received) > p.staleness {
+		delete(p.reports, addr)
+		return nil
+	}
+	return cached.report
+}
+
+func mergeNamedMetrics(dst, src map[string]float64) map[string]float64 {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]float64, len(src))
+	}
+	for name, v := range src {
+		dst[name] = v
+	}
+	return dst
+}
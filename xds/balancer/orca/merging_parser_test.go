@@ -0,0 +1,76 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestMergingParser(t *testing.T) {
+	p := NewMergingParser(time.Minute)
+	p.UpdateOutOfBand("10.0.0.1:80", &orcapb.OrcaLoadReport{
+		CpuUtilization: 0.9,
+		MemUtilization: 0.4,
+		Utilization:    map[string]float64{"queue": 0.5, "disk": 0.1},
+	})
+
+	md := ToMetadata(&orcapb.OrcaLoadReport{
+		CpuUtilization: 0.3,
+		Utilization:    map[string]float64{"queue": 0.7},
+	})
+	md = metadata.Join(md, metadata.Pairs(AddressMDKey, "10.0.0.1:80"))
+
+	got, _ := p.Parse(md).(*orcapb.OrcaLoadReport)
+	want := &orcapb.OrcaLoadReport{
+		CpuUtilization: 0.3,
+		MemUtilization: 0.4,
+		Utilization:    map[string]float64{"queue": 0.7, "disk": 0.1},
+	}
+	if !proto.Equal(got, want) {
+		t.Fatalf("Parse() = %v, want %v", got, want)
+	}
+
+	// Unknown address, only the in-band report.
+	if got := p.Merge("10.0.0.2:80", testReport); !proto.Equal(got, testReport) {
+		t.Fatalf("Merge() = %v, want %v", got, testReport)
+	}
+	if got := p.Parse(metadata.MD{}); got != nil {
+		t.Fatalf("Parse() = %v, want nil", got)
+	}
+}
+
+func TestMergingParserStaleness(t *testing.T) {
+	p := NewMergingParser(10 * time.Millisecond)
+	p.UpdateOutOfBand("10.0.0.1:80", testReport)
+	if got := p.Merge("10.0.0.1:80", nil); !proto.Equal(got, testReport) {
+		t.Fatalf("Merge() = %v, want %v", got, testReport)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got := p.Merge("10.0.0.1:80", nil); got != nil {
+		t.Fatalf("Merge() = %v, want the stale report discarded", got)
+	}
+}
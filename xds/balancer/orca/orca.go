@@ -24,6 +24,11 @@
 // Package orca implements Open Request Cost Aggregation.
 package orca
 
+import (
+	"sort"
+	"sync/atomic"
+)
+
 import (
 	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
 
@@ -31,6 +36,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 
+	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/metadata"
 )
 
@@ -40,7 +46,26 @@ import (
 
 const mdKey = "X-Endpoint-Load-Metrics-Bin"
 
+// DefaultMaxReportBytes is the default size limit of a marshaled load report
+// attached to the response metadata.
+const DefaultMaxReportBytes = 8 * 1024
+
+var maxReportBytes int64 = DefaultMaxReportBytes
+
+// SetMaxReportBytes sets the size limit of a marshaled load report attached to
+// the response metadata. n <= 0 restores DefaultMaxReportBytes.
+func SetMaxReportBytes(n int) {
+	if n <= 0 {
+		n = DefaultMaxReportBytes
+	}
+	atomic.StoreInt64(&maxReportBytes, int64(n))
+}
+
 // toBytes converts a orca load report into bytes.
+//
+// If the report is larger than the limit set by SetMaxReportBytes, named
+// metrics are dropped, largest first, until it fits. It returns nil if the
+// report doesn't fit even without named metrics.
 func toBytes(r *orcapb.OrcaLoadReport) []byte {
 	if r == nil {
 		return nil
@@ -51,7 +76,75 @@ func toBytes(r *orcapb.OrcaLoadReport) []byte {
 		logger.Warnf("orca: failed to marshal load report: %v", err)
 		return nil
 	}
-	return b
+	limit := int(atomic.LoadInt64(&maxReportBytes))
+	if len(b) <= limit {
+		return b
+	}
+	return shrinkToBytes(r, len(b), limit)
+}
+
+// shrinkToBytes drops the largest named metrics of a copy of r until its
+// marshaled size, initially size, is at most limit.
+func shrinkToBytes(r *orcapb.OrcaLoadReport, size, limit int) []byte {
+	type namedMetric struct {
+		metrics map[string]float64
+		name    string
+	}
+	r = proto.Clone(r).(*orcapb.OrcaLoadReport)
+	var named []namedMetric
+	for _, metrics := range []map[string]float64{r.RequestCost, r.Utilization} {
+		for name := range metrics {
+			named = append(named, namedMetric{metrics: metrics, name: name})
+		}
+	}
+	sort.Slice(named, func(i, j int) bool {
+		if len(named[i].name) != len(named[j].name) {
+			return len(named[i].name) > len(named[j].name)
+		}
+		return named[i].name < named[j].name
+	})
+
+	dropped := 0
+	for size > limit {
+		if dropped == len(named) {
+			logger.Warnf("orca: load report of %d bytes without named metrics exceeds the limit of %d bytes, dropping it", size, limit)
+			return nil
+		}
+		// Drop at least the estimated overflow before marshaling again.
+		for overflow := size - limit; overflow > 0 && dropped < len(named); dropped++ {
+			m := named[dropped]
+			delete(m.metrics, m.name)
+			overflow -= namedMetricSize(m.name)
+		}
+		b, err := proto.Marshal(r)
+		if err != nil {
+			logger.Warnf("orca: failed to marshal load report: %v", err)
+			return nil
+		}
+		if len(b) <= limit {
+			logger.Warnf("orca: load report exceeds the limit of %d bytes, dropped %d named metrics", limit, dropped)
+			return b
+		}
+		size = len(b)
+	}
+	return nil
+}
+
+// namedMetricSize returns the marshaled size of a named metric map entry.
+func namedMetricSize(name string) int {
+	// key: tag + length + name, value: tag + fixed64.
+	entry := 1 + varintSize(len(name)) + len(name) + 1 + 8
+	// field tag + length + entry.
+	return 1 + varintSize(entry) + entry
+}
+
+func varintSize(n int) int {
+	size := 1
+	for n >= 0x80 {
+		n >>= 7
+		size++
+	}
+	return size
 }
 
 // ToMetadata converts a orca load report into grpc metadata.
@@ -63,6 +156,39 @@ func ToMetadata(r *orcapb.OrcaLoadReport) metadata.MD {
 	return metadata.Pairs(mdKey, string(b))
 }
 
+// ToMetadataFiltered converts a orca load report into grpc metadata, keeping
+// only the named metrics whose names are in include. The named metrics are the
+// RequestCost and Utilization maps, the other fields are copied as is. It
+// reduces the header size when the backend reports more metrics than the
+// client uses.
+//
+// The result can be read by FromMetadata.
+func ToMetadataFiltered(r *orcapb.OrcaLoadReport, include []string) metadata.MD {
+	if r == nil {
+		return nil
+	}
+	keep := make(map[string]bool, len(include))
+	for _, name := range include {
+		keep[name] = true
+	}
+	filtered := proto.Clone(r).(*orcapb.OrcaLoadReport)
+	filtered.RequestCost = filterNamedMetrics(filtered.RequestCost, keep)
+	filtered.Utilization = filterNamedMetrics(filtered.Utilization, keep)
+	return ToMetadata(filtered)
+}
+
+func filterNamedMetrics(metrics map[string]float64, keep map[string]bool) map[string]float64 {
+	for name := range metrics {
+		if !keep[name] {
+			delete(metrics, name)
+		}
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+	return metrics
+}
+
 // fromBytes reads load report bytes and converts it to orca.
 func fromBytes(b []byte) *orcapb.OrcaLoadReport {
 	ret := new(orcapb.OrcaLoadReport)
@@ -73,7 +199,9 @@ func fromBytes(b []byte) *orcapb.OrcaLoadReport {
 	return ret
 }
 
-// FromMetadata reads load report from metadata and converts it to orca.
+// FromMetadata reads load report from metadata and converts it to orca. The
+// out of range utilizations are clamped and the NaN or infinite named metrics
+// dropped, see SetStrictValidation to drop the whole report instead.
 //
 // It returns nil if report is not found in metadata.
 func FromMetadata(md metadata.MD) *orcapb.OrcaLoadReport {
@@ -81,7 +209,21 @@ func FromMetadata(md metadata.MD) *orcapb.OrcaLoadReport {
 	if len(vs) == 0 {
 		return nil
 	}
-	return fromBytes([]byte(vs[0]))
+	return validate(fromBytes([]byte(vs[0])))
+}
+
+// FromDoneInfo returns the load report of a finished RPC: its ServerLoad if
+// it's a load report, or else the one in its trailer, read by FromMetadata.
+//
+// It returns nil if the RPC carries no load report.
+func FromDoneInfo(info balancer.DoneInfo) *orcapb.OrcaLoadReport {
+	if r, ok := info.ServerLoad.(*orcapb.OrcaLoadReport); ok && r != nil {
+		return r
+	}
+	if info.Trailer == nil {
+		return nil
+	}
+	return FromMetadata(info.Trailer)
 }
 
 type loadParser struct{}
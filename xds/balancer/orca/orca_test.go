@@ -0,0 +1,153 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/grpc/balancer"
+)
+
+var testReport = &orcapb.OrcaLoadReport{
+	CpuUtilization: 0.5,
+	MemUtilization: 0.25,
+	RequestCost:    map[string]float64{"db": 10, "cache": 1},
+	Utilization:    map[string]float64{"queue": 0.75},
+}
+
+func TestToMetadataFiltered(t *testing.T) {
+	got := FromMetadata(ToMetadataFiltered(testReport, []string{"db", "queue"}))
+	want := &orcapb.OrcaLoadReport{
+		CpuUtilization: 0.5,
+		MemUtilization: 0.25,
+		RequestCost:    map[string]float64{"db": 10},
+		Utilization:    map[string]float64{"queue": 0.75},
+	}
+	if !proto.Equal(got, want) {
+		t.Fatalf("FromMetadata(ToMetadataFiltered()) = %v, want %v", got, want)
+	}
+	if len(testReport.RequestCost) != 2 {
+		t.Fatalf("ToMetadataFiltered() modified the input report: %v", testReport)
+	}
+}
+
+func TestToMetadataFilteredAll(t *testing.T) {
+	got := FromMetadata(ToMetadataFiltered(testReport, nil))
+	want := &orcapb.OrcaLoadReport{
+		CpuUtilization: 0.5,
+		MemUtilization: 0.25,
+	}
+	if !proto.Equal(got, want) {
+		t.Fatalf("FromMetadata(ToMetadataFiltered()) = %v, want %v", got, want)
+	}
+}
+
+func TestToMetadataFilteredNil(t *testing.T) {
+	if md := ToMetadataFiltered(nil, []string{"db"}); md != nil {
+		t.Fatalf("ToMetadataFiltered(nil) = %v, want nil", md)
+	}
+}
+
+func TestToMetadataOversized(t *testing.T) {
+	const limit = 1024
+	SetMaxReportBytes(limit)
+	defer SetMaxReportBytes(0)
+
+	r := &orcapb.OrcaLoadReport{
+		CpuUtilization: 0.5,
+		MemUtilization: 0.25,
+		RequestCost:    make(map[string]float64),
+	}
+	for i := 0; i < 1000; i++ {
+		r.RequestCost[fmt.Sprintf("metric-%d-%s", i, strings.Repeat("x", i%50))] = float64(i)
+	}
+
+	md := ToMetadata(r)
+	vs := md.Get(mdKey)
+	if len(vs) != 1 {
+		t.Fatalf("ToMetadata() = %v, want a load report header", md)
+	}
+	if len(vs[0]) > limit {
+		t.Fatalf("load report header is %d bytes, want at most %d", len(vs[0]), limit)
+	}
+	got := FromMetadata(md)
+	if got.GetCpuUtilization() != 0.5 || got.GetMemUtilization() != 0.25 {
+		t.Fatalf("FromMetadata() = %v, want the utilization kept", got)
+	}
+	if len(got.GetRequestCost()) == 0 || len(got.GetRequestCost()) == len(r.RequestCost) {
+		t.Fatalf("FromMetadata() kept %d of %d named metrics, want some dropped", len(got.GetRequestCost()), len(r.RequestCost))
+	}
+	if len(r.RequestCost) != 1000 {
+		t.Fatalf("ToMetadata() modified the input report")
+	}
+}
+
+func TestToMetadataBaseReportOversized(t *testing.T) {
+	SetMaxReportBytes(4)
+	defer SetMaxReportBytes(0)
+
+	if md := ToMetadata(testReport); md != nil {
+		t.Fatalf("ToMetadata() = %v, want nil", md)
+	}
+}
+
+func TestFromDoneInfo(t *testing.T) {
+	if got := FromDoneInfo(balancer.DoneInfo{ServerLoad: testReport}); got != testReport {
+		t.Errorf("FromDoneInfo() = %v, want the server load %v", got, testReport)
+	}
+	got := FromDoneInfo(balancer.DoneInfo{Trailer: ToMetadata(testReport)})
+	if !proto.Equal(got, testReport) {
+		t.Errorf("FromDoneInfo() = %v, want the trailer report %v", got, testReport)
+	}
+	if got := FromDoneInfo(balancer.DoneInfo{ServerLoad: "other"}); got != nil {
+		t.Errorf("FromDoneInfo() = %v, want nil without a load report", got)
+	}
+}
+
+func TestAccessors(t *testing.T) {
+	if got := CPUUtilization(testReport); got != 0.5 {
+		t.Errorf("CPUUtilization() = %v, want 0.5", got)
+	}
+	if got := MemUtilization(testReport); got != 0.25 {
+		t.Errorf("MemUtilization() = %v, want 0.25", got)
+	}
+	if got, ok := NamedMetric(testReport, "queue"); !ok || got != 0.75 {
+		t.Errorf("NamedMetric(queue) = %v, %v, want 0.75, true", got, ok)
+	}
+	if got, ok := NamedMetric(testReport, "db"); !ok || got != 10 {
+		t.Errorf("NamedMetric(db) = %v, %v, want 10, true", got, ok)
+	}
+	if _, ok := NamedMetric(testReport, "missing"); ok {
+		t.Errorf("NamedMetric(missing) found a metric")
+	}
+
+	if CPUUtilization(nil) != 0 || MemUtilization(nil) != 0 {
+		t.Errorf("utilization of a nil report is not 0")
+	}
+	if _, ok := NamedMetric(nil, "db"); ok {
+		t.Errorf("NamedMetric() found a metric in a nil report")
+	}
+}
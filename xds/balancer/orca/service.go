@@ -0,0 +1,198 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+	orcaservicepb "github.com/cncf/xds/go/xds/service/orca/v3"
+
+	"github.com/dubbogo/gost/log/logger"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+
+	"google.golang.org/grpc"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/backoff"
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/grpcsync"
+)
+
+// Producer implements the ORCA out-of-band reporting service. Once started, it
+// periodically gets a load report from the user callback and streams it to
+// every connected client, independent of the request traffic.
+type Producer struct {
+	orcaservicepb.UnimplementedOpenRcaServiceServer
+
+	report func() *orcapb.OrcaLoadReport
+	done   *grpcsync.Event
+
+	mu      sync.Mutex
+	started bool
+	streams map[chan *orcapb.OrcaLoadReport]bool
+}
+
+// NewProducer creates a Producer streaming the load reports returned by
+// report. Register it on a server, then Start it.
+func NewProducer(report func() *orcapb.OrcaLoadReport) *Producer {
+	return &Producer{
+		report:  report,
+		done:    grpcsync.NewEvent(),
+		streams: make(map[chan *orcapb.OrcaLoadReport]bool),
+	}
+}
+
+// Register registers the ORCA service of p on s.
+func (p *Producer) Register(s *grpc.Server) {
+	orcaservicepb.RegisterOpenRcaServiceServer(s, p)
+}
+
+// Start starts reporting the load every interval. It's a no-op if p was
+// already started.
+func (p *Producer) Start(interval time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.started || p.done.HasFired() {
+		return
+	}
+	p.started = true
+	go p.run(interval)
+}
+
+// Stop stops reporting and ends the open streams.
+func (p *Producer) Stop() {
+	p.done.Fire()
+}
+
+func (p *Producer) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done.Done():
+			return
+		case <-ticker.C:
+		}
+		r := p.report()
+		if r == nil {
+			continue
+		}
+		p.mu.Lock()
+		for ch := range p.streams {
+			// Drop the pending report of a slow stream, only the latest
+			// one matters.
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- r
+		}
+		p.mu.Unlock()
+	}
+}
+
+// StreamCoreMetrics implements the OpenRcaService server. The reports are sent
+// at the interval of the producer, keeping only the request costs named in the
+// request if any.
+func (p *Producer) StreamCoreMetrics(req *orcaservicepb.OrcaLoadReportRequest, stream orcaservicepb.OpenRcaService_StreamCoreMetricsServer) error {
+	ch := make(chan *orcapb.OrcaLoadReport, 1)
+	p.mu.Lock()
+	p.streams[ch] = true
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		delete(p.streams, ch)
+		p.mu.Unlock()
+	}()
+
+	var keep map[string]bool
+	if names := req.GetRequestCostNames(); len(names) != 0 {
+		keep = make(map[string]bool, len(names))
+		for _, name := range names {
+			keep[name] = true
+		}
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-p.done.Done():
+			return nil
+		case r := <-ch:
+			if keep != nil {
+				r = proto.Clone(r).(*orcapb.OrcaLoadReport)
+				r.RequestCost = filterNamedMetrics(r.RequestCost, keep)
+			}
+			if err := stream.Send(r); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// RegisterListener opens an ORCA out-of-band reporting stream on cc, asking
+// for a report every interval, and calls listener with every report
+// received. Broken streams are recreated with backoff. The returned function
+// stops listening.
+func RegisterListener(cc grpc.ClientConnInterface, interval time.Duration, listener func(*orcapb.OrcaLoadReport)) (cancel func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go listen(ctx, orcaservicepb.NewOpenRcaServiceClient(cc), interval, listener)
+	return cancel
+}
+
+func listen(ctx context.Context, client orcaservicepb.OpenRcaServiceClient, interval time.Duration, listener func(*orcapb.OrcaLoadReport)) {
+	req := &orcaservicepb.OrcaLoadReportRequest{ReportInterval: ptypes.DurationProto(interval)}
+	retries := 0
+	for {
+		if retries != 0 {
+			timer := time.NewTimer(backoff.DefaultExponential.Backoff(retries))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+		retries++
+
+		stream, err := client.StreamCoreMetrics(ctx, req)
+		if err != nil {
+			logger.Warnf("orca: failed to create load report stream: %v", err)
+			continue
+		}
+		for {
+			r, err := stream.Recv()
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				logger.Warnf("orca: load report stream is closed with error: %v", err)
+				break
+			}
+			retries = 0
+			listener(r)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"github.com/golang/protobuf/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestProducerStreamsReports(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	s := grpc.NewServer()
+	p := NewProducer(func() *orcapb.OrcaLoadReport { return testReport })
+	p.Register(s)
+	p.Start(10 * time.Millisecond)
+	defer p.Stop()
+	go s.Serve(lis)
+	defer s.Stop()
+
+	cc, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.Dial() failed: %v", err)
+	}
+	defer cc.Close()
+
+	reports := make(chan *orcapb.OrcaLoadReport, 10)
+	cancel := RegisterListener(cc, 10*time.Millisecond, func(r *orcapb.OrcaLoadReport) {
+		select {
+		case reports <- r:
+		default:
+		}
+	})
+	defer cancel()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-reports:
+			if !proto.Equal(r, testReport) {
+				t.Fatalf("listener received %v, want %v", r, testReport)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for load report %d", i)
+		}
+	}
+}
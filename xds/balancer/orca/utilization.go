@@ -0,0 +1,100 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"sync"
+)
+
+import (
+	"google.golang.org/grpc/balancer"
+)
+
+// UtilizationStore keeps the exponentially weighted moving average of the CPU
+// utilization reported by the backends, by address. It is safe for concurrent
+// use, so that the pickers of a balancer can share one.
+type UtilizationStore struct {
+	mu              sync.Mutex
+	smoothingFactor float64
+	utilization     map[string]float64
+}
+
+// NewUtilizationStore returns an empty store weighing each new sample by
+// smoothingFactor.
+func NewUtilizationStore(smoothingFactor float64) *UtilizationStore {
+	return &UtilizationStore{
+		smoothingFactor: smoothingFactor,
+		utilization:     make(map[string]float64),
+	}
+}
+
+// SetSmoothingFactor sets the weight of the new samples in the moving
+// averages, in (0, 1].
+func (s *UtilizationStore) SetSmoothingFactor(smoothingFactor float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.smoothingFactor = smoothingFactor
+}
+
+// Record records the CPU utilization reported by the RPC to addr, if any.
+func (s *UtilizationStore) Record(addr string, info balancer.DoneInfo) {
+	r := FromDoneInfo(info)
+	if r == nil {
+		return
+	}
+	s.Update(addr, CPUUtilization(r))
+}
+
+// Update adds a CPU utilization sample of addr to its moving average. The
+// first sample of an address is taken as is.
+func (s *UtilizationStore) Update(addr string, cpu float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.utilization[addr]
+	if !ok {
+		s.utilization[addr] = cpu
+		return
+	}
+	a := s.smoothingFactor
+	s.utilization[addr] = a*cpu + (1-a)*old
+}
+
+// Utilizations returns the moving averages of the addresses of addrs with load
+// data, read at once.
+func (s *UtilizationStore) Utilizations(addrs []string) map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make(map[string]float64, len(addrs))
+	for _, addr := range addrs {
+		if u, ok := s.utilization[addr]; ok {
+			ret[addr] = u
+		}
+	}
+	return ret
+}
+
+// Retain forgets the load of the addresses for which keep returns false.
+func (s *UtilizationStore) Retain(keep func(addr string) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for addr := range s.utilization {
+		if !keep(addr) {
+			delete(s.utilization, addr)
+		}
+	}
+}
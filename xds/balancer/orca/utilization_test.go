@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"math"
+	"testing"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"google.golang.org/grpc/balancer"
+)
+
+func TestUtilizationStoreSmoothing(t *testing.T) {
+	s := NewUtilizationStore(0.5)
+	s.Update("a", 0.8)
+	s.Update("a", 0.4)
+	if got, want := s.Utilizations([]string{"a"})["a"], 0.6; math.Abs(got-want) > 1e-9 {
+		t.Errorf("utilization = %v, want %v", got, want)
+	}
+
+	s.SetSmoothingFactor(1)
+	s.Update("a", 0.2)
+	if got, want := s.Utilizations([]string{"a"})["a"], 0.2; math.Abs(got-want) > 1e-9 {
+		t.Errorf("utilization = %v, want %v", got, want)
+	}
+}
+
+func TestUtilizationStoreRecord(t *testing.T) {
+	s := NewUtilizationStore(1)
+	s.Record("a", balancer.DoneInfo{})
+	if got := s.Utilizations([]string{"a"}); len(got) != 0 {
+		t.Errorf("Utilizations() = %v after an RPC without load report, want empty", got)
+	}
+
+	s.Record("a", balancer.DoneInfo{ServerLoad: &orcapb.OrcaLoadReport{CpuUtilization: 0.3}})
+	if got, want := s.Utilizations([]string{"a", "b"}), (map[string]float64{"a": 0.3}); len(got) != 1 || got["a"] != want["a"] {
+		t.Errorf("Utilizations() = %v, want %v", got, want)
+	}
+}
+
+func TestUtilizationStoreRetain(t *testing.T) {
+	s := NewUtilizationStore(1)
+	s.Update("a", 0.5)
+	s.Update("b", 0.5)
+	s.Retain(func(addr string) bool { return addr == "b" })
+	got := s.Utilizations([]string{"a", "b"})
+	if _, ok := got["a"]; ok {
+		t.Errorf("load of the removed address is kept")
+	}
+	if _, ok := got["b"]; !ok {
+		t.Errorf("load of the remaining address is forgotten")
+	}
+}
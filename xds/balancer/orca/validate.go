@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"github.com/dubbogo/gost/log/logger"
+)
+
+var strictValidation int32
+
+// SetStrictValidation sets whether FromMetadata drops the invalid load
+// reports, returning nil, rather than fixing them. See validate.
+func SetStrictValidation(strict bool) {
+	var v int32
+	if strict {
+		v = 1
+	}
+	atomic.StoreInt32(&strictValidation, v)
+}
+
+// validate fixes the invalid fields of r in place: the utilizations, including
+// the named ones, are clamped to [0, 1], NaN becoming 0, and the named metrics
+// which are NaN or infinite are dropped. A warning is logged for each fixed
+// field. In strict mode, see SetStrictValidation, it returns nil instead if r
+// is invalid.
+func validate(r *orcapb.OrcaLoadReport) *orcapb.OrcaLoadReport {
+	if r == nil {
+		return nil
+	}
+	strict := atomic.LoadInt32(&strictValidation) != 0
+	valid := true
+	clamp := func(field string, v float64) float64 {
+		c := v
+		switch {
+		case math.IsNaN(v) || v < 0:
+			c = 0
+		case v > 1:
+			c = 1
+		default:
+			return v
+		}
+		valid = false
+		if !strict {
+			logger.Warnf("orca: load report has %s %v out of [0, 1], clamped to %v", field, v, c)
+		}
+		return c
+	}
+	dropInvalid := func(kind string, metrics map[string]float64) {
+		for name, v := range metrics {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				valid = false
+				if !strict {
+					logger.Warnf("orca: load report has %s %q of %v, dropped", kind, name, v)
+				}
+				delete(metrics, name)
+			}
+		}
+	}
+
+	r.CpuUtilization = clamp("cpu_utilization", r.CpuUtilization)
+	r.MemUtilization = clamp("mem_utilization", r.MemUtilization)
+	dropInvalid("utilization", r.Utilization)
+	for name, v := range r.Utilization {
+		r.Utilization[name] = clamp("utilization "+name, v)
+	}
+	dropInvalid("request_cost", r.RequestCost)
+	if !valid && strict {
+		logger.Warnf("orca: dropping invalid load report %v", r)
+		return nil
+	}
+	return r
+}
@@ -0,0 +1,84 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package orca
+
+import (
+	"math"
+	"testing"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"github.com/golang/protobuf/proto"
+)
+
+func TestFromMetadataClampsUtilization(t *testing.T) {
+	r := &orcapb.OrcaLoadReport{
+		CpuUtilization: 2.5,
+		MemUtilization: -0.5,
+		Utilization:    map[string]float64{"queue": 1.5, "disk": 0.5},
+		RequestCost:    map[string]float64{"db": 10},
+	}
+	got := FromMetadata(ToMetadata(r))
+	want := &orcapb.OrcaLoadReport{
+		CpuUtilization: 1,
+		MemUtilization: 0,
+		Utilization:    map[string]float64{"queue": 1, "disk": 0.5},
+		RequestCost:    map[string]float64{"db": 10},
+	}
+	if !proto.Equal(got, want) {
+		t.Fatalf("FromMetadata() = %v, want %v", got, want)
+	}
+}
+
+func TestFromMetadataDropsNaN(t *testing.T) {
+	r := &orcapb.OrcaLoadReport{
+		CpuUtilization: math.NaN(),
+		MemUtilization: 0.25,
+		Utilization:    map[string]float64{"queue": math.NaN(), "disk": 0.5},
+		RequestCost:    map[string]float64{"db": math.Inf(1), "cache": 1},
+	}
+	got := FromMetadata(ToMetadata(r))
+	want := &orcapb.OrcaLoadReport{
+		MemUtilization: 0.25,
+		Utilization:    map[string]float64{"disk": 0.5},
+		RequestCost:    map[string]float64{"cache": 1},
+	}
+	if !proto.Equal(got, want) {
+		t.Fatalf("FromMetadata() = %v, want %v", got, want)
+	}
+}
+
+func TestFromMetadataStrict(t *testing.T) {
+	SetStrictValidation(true)
+	defer SetStrictValidation(false)
+
+	for _, r := range []*orcapb.OrcaLoadReport{
+		{CpuUtilization: 2.5},
+		{Utilization: map[string]float64{"queue": -1}},
+		{RequestCost: map[string]float64{"db": math.NaN()}},
+	} {
+		if got := FromMetadata(ToMetadata(r)); got != nil {
+			t.Errorf("FromMetadata(%v) = %v, want nil", r, got)
+		}
+	}
+	if got := FromMetadata(ToMetadata(testReport)); !proto.Equal(got, testReport) {
+		t.Fatalf("FromMetadata() = %v, want %v", got, testReport)
+	}
+}
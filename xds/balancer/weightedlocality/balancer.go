@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package weightedlocality implements a two level balancer: it picks a
+// locality proportionally to its EDS weight, then the least loaded ready
+// address of that locality, as reported by ORCA.
+package weightedlocality
+
+import (
+	"encoding/json"
+)
+
+import (
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// Name is the name of the weighted locality balancer.
+const Name = "weighted_locality"
+
+func init() {
+	balancer.Register(bb{})
+}
+
+type localityWeightKeyType string
+
+const localityWeightKey = localityWeightKeyType("dubbo.xds.internal.address.locality_weight")
+
+// GetLocalityWeight returns the EDS weight of the locality of addr, 0 if it
+// is not set.
+func GetLocalityWeight(addr resolver.Address) uint32 {
+	w, _ := addr.BalancerAttributes.Value(localityWeightKey).(uint32)
+	return w
+}
+
+// SetLocalityWeight sets the EDS weight of the locality of addr to w. The
+// locality itself is set with resource.SetLocalityID.
+func SetLocalityWeight(addr resolver.Address, w uint32) resolver.Address {
+	addr.BalancerAttributes = addr.BalancerAttributes.WithValue(localityWeightKey, w)
+	return addr
+}
+
+type bb struct{}
+
+func (bb) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	store := newLoadStore()
+	pb := &pickerBuilder{store: store}
+	return &localityBalancer{
+		Balancer: base.NewBalancerBuilder(Name, pb, base.Config{}).Build(cc, opts),
+		store:    store,
+	}
+}
+
+func (bb) Name() string {
+	return Name
+}
+
+func (bb) ParseConfig(c json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	return parseConfig(c)
+}
+
+// localityBalancer is a base balancer, managing the SubConns, whose pickers
+// group the ready SubConns by the localities recorded in store.
+type localityBalancer struct {
+	balancer.Balancer
+	store *loadStore
+}
+
+func (b *localityBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	if cfg, ok := s.BalancerConfig.(*LBConfig); ok {
+		b.store.setConfig(cfg)
+	}
+	// The base balancer keeps the attributes of an address from its first
+	// update, so the localities are recorded here, before the new picker is
+	// built.
+	b.store.setLocalities(s.ResolverState.Addresses)
+	return b.Balancer.UpdateClientConnState(s)
+}
@@ -0,0 +1,56 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package weightedlocality
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+import (
+	"google.golang.org/grpc/serviceconfig"
+)
+
+const defaultSmoothingFactor = 0.5
+
+// LBConfig is the balancer config for weighted_locality balancer.
+type LBConfig struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+
+	// SmoothingFactor, in (0, 1], is the weight of a new CPU utilization
+	// sample in the exponential moving average of an address' utilization.
+	// 1 means only the latest sample is used.
+	SmoothingFactor float64
+}
+
+func parseConfig(c json.RawMessage) (*LBConfig, error) {
+	var raw struct {
+		SmoothingFactor float64 `json:"smoothingFactor,omitempty"`
+	}
+	if err := json.Unmarshal(c, &raw); err != nil {
+		return nil, err
+	}
+	cfg := &LBConfig{SmoothingFactor: defaultSmoothingFactor}
+	if raw.SmoothingFactor != 0 {
+		if raw.SmoothingFactor < 0 || raw.SmoothingFactor > 1 {
+			return nil, fmt.Errorf("smoothingFactor %v is not in (0, 1]", raw.SmoothingFactor)
+		}
+		cfg.SmoothingFactor = raw.SmoothingFactor
+	}
+	return cfg, nil
+}
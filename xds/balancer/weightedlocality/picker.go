@@ -0,0 +1,206 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package weightedlocality
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/balancer/orca"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/wrr"
+)
+
+// localityInfo is the locality of an address and its EDS weight.
+type localityInfo struct {
+	id     resource.LocalityID
+	weight uint32
+}
+
+// loadStore keeps the localities of the addresses and their smoothed CPU
+// utilization, shared by the pickers of a balancer.
+type loadStore struct {
+	mu          sync.Mutex
+	localities  map[string]localityInfo
+	utilization *orca.UtilizationStore
+}
+
+func newLoadStore() *loadStore {
+	return &loadStore{
+		localities:  make(map[string]localityInfo),
+		utilization: orca.NewUtilizationStore(defaultSmoothingFactor),
+	}
+}
+
+func (s *loadStore) setConfig(cfg *LBConfig) {
+	s.utilization.SetSmoothingFactor(cfg.SmoothingFactor)
+}
+
+// setLocalities records the localities of addrs, and forgets the load of the
+// addresses no longer there. A locality without weight gets weight 1, as in
+// the cluster resolver.
+func (s *loadStore) setLocalities(addrs []resolver.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	localities := make(map[string]localityInfo, len(addrs))
+	for _, a := range addrs {
+		w := GetLocalityWeight(a)
+		if w == 0 {
+			w = 1
+		}
+		localities[a.Addr] = localityInfo{id: resource.GetLocalityID(a), weight: w}
+	}
+	s.utilization.Retain(func(addr string) bool {
+		_, ok := localities[addr]
+		return ok
+	})
+	s.localities = localities
+}
+
+// locality returns the locality of addr. Unknown addresses share the zero
+// locality, with weight 1.
+func (s *loadStore) locality(addr string) localityInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l, ok := s.localities[addr]; ok {
+		return l
+	}
+	return localityInfo{weight: 1}
+}
+
+// leastLoaded returns the index of the address of addrs with the lowest CPU
+// utilization. Addresses without load data count as the mean of the others.
+// The scan begins at start and keeps the first minimum, so equally loaded
+// addresses, e.g. all of them without any load data, are picked round robin
+// by passing an increasing start.
+func (s *loadStore) leastLoaded(addrs []string, start int) int {
+	utilization := s.utilization.Utilizations(addrs)
+	var sum float64
+	for _, u := range utilization {
+		sum += u
+	}
+	var mean float64
+	if len(utilization) != 0 {
+		mean = sum / float64(len(utilization))
+	}
+	best, lowest := -1, 0.0
+	for k := range addrs {
+		i := (start + k) % len(addrs)
+		u, ok := utilization[addrs[i]]
+		if !ok {
+			u = mean
+		}
+		if best == -1 || u < lowest {
+			best, lowest = i, u
+		}
+	}
+	return best
+}
+
+type pickerBuilder struct {
+	store *loadStore
+}
+
+func (pb *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	byID := make(map[resource.LocalityID]*localityPicker)
+	for sc, scInfo := range info.ReadySCs {
+		addr := scInfo.Address.Addr
+		l := pb.store.locality(addr)
+		lp, ok := byID[l.id]
+		if !ok {
+			lp = &localityPicker{id: l.id, weight: l.weight}
+			byID[l.id] = lp
+		}
+		lp.subConns = append(lp.subConns, sc)
+		lp.addrs = append(lp.addrs, addr)
+	}
+	// Sort for a stable order of the localities and of their addresses.
+	localities := make([]*localityPicker, 0, len(byID))
+	for _, lp := range byID {
+		sort.Sort(lp)
+		localities = append(localities, lp)
+	}
+	sort.Slice(localities, func(i, j int) bool {
+		return lessLocalityID(localities[i].id, localities[j].id)
+	})
+	sched := wrr.NewEDF()
+	for _, lp := range localities {
+		sched.Add(lp, int64(lp.weight))
+	}
+	return &picker{sched: sched, store: pb.store}
+}
+
+func lessLocalityID(a, b resource.LocalityID) bool {
+	if a.Region != b.Region {
+		return a.Region < b.Region
+	}
+	if a.Zone != b.Zone {
+		return a.Zone < b.Zone
+	}
+	return a.SubZone < b.SubZone
+}
+
+// localityPicker is the ready SubConns of a locality.
+type localityPicker struct {
+	id       resource.LocalityID
+	weight   uint32
+	subConns []balancer.SubConn
+	addrs    []string
+	// next is the start of the next least loaded scan.
+	next uint32
+}
+
+func (lp *localityPicker) Len() int           { return len(lp.addrs) }
+func (lp *localityPicker) Less(i, j int) bool { return lp.addrs[i] < lp.addrs[j] }
+func (lp *localityPicker) Swap(i, j int) {
+	lp.addrs[i], lp.addrs[j] = lp.addrs[j], lp.addrs[i]
+	lp.subConns[i], lp.subConns[j] = lp.subConns[j], lp.subConns[i]
+}
+
+// picker picks a locality with its EDS weight, then its least loaded ready
+// SubConn.
+type picker struct {
+	sched wrr.WRR
+	store *loadStore
+}
+
+func (p *picker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	lp := p.sched.Next().(*localityPicker)
+	start := int((atomic.AddUint32(&lp.next, 1) - 1) % uint32(len(lp.addrs)))
+	i := p.store.leastLoaded(lp.addrs, start)
+
+	addr := lp.addrs[i]
+	return balancer.PickResult{
+		SubConn: lp.subConns[i],
+		Done: func(info balancer.DoneInfo) {
+			p.store.utilization.Record(addr, info)
+		},
+	}, nil
+}
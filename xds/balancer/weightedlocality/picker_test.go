@@ -0,0 +1,186 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package weightedlocality
+
+import (
+	"testing"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+type testSubConn struct {
+	balancer.SubConn
+	addr string
+}
+
+// testEndpoint is an address in the zone of that name, with the locality
+// weight of that zone.
+type testEndpoint struct {
+	addr   string
+	zone   string
+	weight uint32
+}
+
+func newTestPicker(t *testing.T, endpoints ...testEndpoint) (balancer.Picker, *loadStore) {
+	t.Helper()
+	store := newLoadStore()
+	// Only the latest load report of an address counts in the tests.
+	store.setConfig(&LBConfig{SmoothingFactor: 1})
+	var addrs []resolver.Address
+	info := base.PickerBuildInfo{ReadySCs: make(map[balancer.SubConn]base.SubConnInfo)}
+	for _, e := range endpoints {
+		addr := resolver.Address{Addr: e.addr}
+		addr = resource.SetLocalityID(addr, resource.LocalityID{Zone: e.zone})
+		if e.weight != 0 {
+			addr = SetLocalityWeight(addr, e.weight)
+		}
+		addrs = append(addrs, addr)
+		info.ReadySCs[&testSubConn{addr: e.addr}] = base.SubConnInfo{Address: addr}
+	}
+	store.setLocalities(addrs)
+	return (&pickerBuilder{store: store}).Build(info), store
+}
+
+// pickCounts picks n times, reporting cpu[addr] as the load of each RPC, and
+// returns the number of picks of each address.
+func pickCounts(t *testing.T, p balancer.Picker, n int, cpu map[string]float64) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		res, err := p.Pick(balancer.PickInfo{})
+		if err != nil {
+			t.Fatalf("Pick() failed: %v", err)
+		}
+		addr := res.SubConn.(*testSubConn).addr
+		counts[addr]++
+		if u, ok := cpu[addr]; ok {
+			res.Done(balancer.DoneInfo{ServerLoad: &orcapb.OrcaLoadReport{CpuUtilization: u}})
+		}
+	}
+	return counts
+}
+
+func TestPickerLocalityWeightsWithoutLoad(t *testing.T) {
+	p, _ := newTestPicker(t,
+		testEndpoint{addr: "a1", zone: "a", weight: 3},
+		testEndpoint{addr: "a2", zone: "a", weight: 3},
+		testEndpoint{addr: "b1", zone: "b", weight: 1},
+		testEndpoint{addr: "b2", zone: "b", weight: 1},
+	)
+	// Localities get picks proportional to their weights, and the addresses
+	// of a locality the same share without any load data.
+	got := pickCounts(t, p, 400, nil)
+	want := map[string]int{"a1": 150, "a2": 150, "b1": 50, "b2": 50}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("got unexpected picks, diff (-got +want): %v", diff)
+	}
+}
+
+func TestPickerLeastLoadedInLocality(t *testing.T) {
+	p, _ := newTestPicker(t,
+		testEndpoint{addr: "busy", zone: "a", weight: 1},
+		testEndpoint{addr: "idle", zone: "a", weight: 1},
+		testEndpoint{addr: "b1", zone: "b", weight: 1},
+		testEndpoint{addr: "b2", zone: "b", weight: 1},
+	)
+	cpu := map[string]float64{"busy": 0.9, "idle": 0.1}
+	// The first pick of zone a goes to busy, the second to idle, equally
+	// loaded since it has no load data yet, then all of them go to idle.
+	// Zone b, without load data, keeps its picks even.
+	got := pickCounts(t, p, 200, cpu)
+	want := map[string]int{"busy": 1, "idle": 99, "b1": 50, "b2": 50}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("got unexpected picks, diff (-got +want): %v", diff)
+	}
+
+	// Once idle is the most loaded, the picks of zone a move back to busy.
+	cpu["idle"] = 0.95
+	got = pickCounts(t, p, 20, cpu)
+	want = map[string]int{"busy": 9, "idle": 1, "b1": 5, "b2": 5}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("got unexpected picks after the load change, diff (-got +want): %v", diff)
+	}
+}
+
+func TestPickerMissingLoadCountsAsMean(t *testing.T) {
+	p, store := newTestPicker(t,
+		testEndpoint{addr: "a1", zone: "a"},
+		testEndpoint{addr: "a2", zone: "a"},
+		testEndpoint{addr: "a3", zone: "a"},
+	)
+	store.utilization.Update("a1", 0.5)
+	store.utilization.Update("a2", 0.1)
+	// a3 counts as 0.3, more than a2.
+	got := pickCounts(t, p, 10, nil)
+	want := map[string]int{"a2": 10}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("got unexpected picks, diff (-got +want): %v", diff)
+	}
+}
+
+func TestPickerDefaultLocalityWeight(t *testing.T) {
+	p, _ := newTestPicker(t,
+		testEndpoint{addr: "a1", zone: "a"},
+		testEndpoint{addr: "b1", zone: "b", weight: 1},
+	)
+	got := pickCounts(t, p, 10, nil)
+	want := map[string]int{"a1": 5, "b1": 5}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("got unexpected picks, diff (-got +want): %v", diff)
+	}
+}
+
+func TestSetLocalitiesForgetsRemovedAddresses(t *testing.T) {
+	store := newLoadStore()
+	a := resource.SetLocalityID(resolver.Address{Addr: "a"}, resource.LocalityID{Zone: "z"})
+	b := SetLocalityWeight(resolver.Address{Addr: "b"}, 2)
+	store.setLocalities([]resolver.Address{a, b})
+	store.utilization.Update("a", 0.5)
+	store.utilization.Update("b", 0.5)
+
+	store.setLocalities([]resolver.Address{b})
+	utilization := store.utilization.Utilizations([]string{"a", "b"})
+	if _, ok := utilization["a"]; ok {
+		t.Errorf("utilization of removed address a is still recorded")
+	}
+	if _, ok := utilization["b"]; !ok {
+		t.Errorf("utilization of address b is not recorded")
+	}
+	if got, want := store.locality("b"), (localityInfo{weight: 2}); got != want {
+		t.Errorf("locality(b) = %+v, want %+v", got, want)
+	}
+}
+
+func TestPickerNoReadySubConns(t *testing.T) {
+	p, _ := newTestPicker(t)
+	if _, err := p.Pick(balancer.PickInfo{}); err != balancer.ErrNoSubConnAvailable {
+		t.Errorf("Pick() error = %v, want %v", err, balancer.ErrNoSubConnAvailable)
+	}
+}
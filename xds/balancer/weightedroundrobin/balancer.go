@@ -0,0 +1,71 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package weightedroundrobin implements a balancer spreading the RPCs over the
+// ready addresses with weights inversely proportional to their CPU
+// utilization, as reported by ORCA.
+package weightedroundrobin
+
+import (
+	"encoding/json"
+)
+
+import (
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// Name is the name of the weighted round robin balancer.
+const Name = "weighted_round_robin"
+
+func init() {
+	balancer.Register(bb{})
+}
+
+type bb struct{}
+
+func (bb) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	store := newLoadStore()
+	pb := &pickerBuilder{store: store}
+	return &wrrBalancer{
+		Balancer: base.NewBalancerBuilder(Name, pb, base.Config{}).Build(cc, opts),
+		store:    store,
+	}
+}
+
+func (bb) Name() string {
+	return Name
+}
+
+func (bb) ParseConfig(c json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	return parseConfig(c)
+}
+
+// wrrBalancer is a base balancer, managing the SubConns, whose pickers weight
+// the ready SubConns with the loads in store.
+type wrrBalancer struct {
+	balancer.Balancer
+	store *loadStore
+}
+
+func (b *wrrBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	if cfg, ok := s.BalancerConfig.(*LBConfig); ok {
+		b.store.setConfig(cfg)
+	}
+	return b.Balancer.UpdateClientConnState(s)
+}
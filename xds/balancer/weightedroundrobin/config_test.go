@@ -0,0 +1,74 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package weightedroundrobin
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		js      string
+		want    *LBConfig
+		wantErr bool
+	}{
+		{
+			name: "OK",
+			js:   `{"weightUpdateInterval": "100ms", "smoothingFactor": 0.2}`,
+			want: &LBConfig{WeightUpdateInterval: 100 * time.Millisecond, SmoothingFactor: 0.2},
+		},
+		{
+			name: "OK with defaults",
+			js:   `{}`,
+			want: &LBConfig{WeightUpdateInterval: defaultWeightUpdateInterval, SmoothingFactor: defaultSmoothingFactor},
+		},
+		{
+			name:    "invalid interval",
+			js:      `{"weightUpdateInterval": "soon"}`,
+			wantErr: true,
+		},
+		{
+			name:    "negative interval",
+			js:      `{"weightUpdateInterval": "-1s"}`,
+			wantErr: true,
+		},
+		{
+			name:    "smoothing factor out of range",
+			js:      `{"smoothingFactor": 1.5}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseConfig([]byte(tt.js))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseConfig() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Errorf("parseConfig() got unexpected output, diff (-got +want): %v", diff)
+			}
+		})
+	}
+}
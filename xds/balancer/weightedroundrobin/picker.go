@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package weightedroundrobin
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+import (
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/balancer/orca"
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/wrr"
+)
+
+const (
+	// minUtilization bounds the weight of idle addresses.
+	minUtilization = 0.01
+	// weightScale converts the float weights to the integer ones of wrr.
+	weightScale = 1000
+)
+
+// loadStore keeps the smoothed CPU utilization of the addresses, shared by
+// the pickers of a balancer.
+type loadStore struct {
+	mu          sync.Mutex
+	cfg         *LBConfig
+	utilization *orca.UtilizationStore
+}
+
+func newLoadStore() *loadStore {
+	return &loadStore{
+		cfg: &LBConfig{
+			WeightUpdateInterval: defaultWeightUpdateInterval,
+			SmoothingFactor:      defaultSmoothingFactor,
+		},
+		utilization: orca.NewUtilizationStore(defaultSmoothingFactor),
+	}
+}
+
+func (s *loadStore) setConfig(cfg *LBConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+	s.utilization.SetSmoothingFactor(cfg.SmoothingFactor)
+}
+
+func (s *loadStore) weightUpdateInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg.WeightUpdateInterval
+}
+
+// weights returns the weights of addrs, inversely proportional to their CPU
+// utilization. Addresses without load data get the mean weight of the others,
+// so all weights are equal, i.e. plain round robin, without any load data.
+func (s *loadStore) weights(addrs []string) []int64 {
+	utilization := s.utilization.Utilizations(addrs)
+	ret := make([]int64, len(addrs))
+	var sum int64
+	for i, addr := range addrs {
+		u, ok := utilization[addr]
+		if !ok {
+			continue
+		}
+		if u < minUtilization {
+			u = minUtilization
+		}
+		ret[i] = int64(weightScale / u)
+		sum += ret[i]
+	}
+	mean := int64(weightScale)
+	if len(utilization) != 0 {
+		mean = sum / int64(len(utilization))
+	}
+	for i, w := range ret {
+		if w == 0 {
+			ret[i] = mean
+		}
+	}
+	return ret
+}
+
+type pickerBuilder struct {
+	store *loadStore
+}
+
+func (pb *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+	p := &picker{store: pb.store}
+	for sc, scInfo := range info.ReadySCs {
+		p.subConns = append(p.subConns, sc)
+		p.addrs = append(p.addrs, scInfo.Address.Addr)
+	}
+	// Sort for a stable order of the equally weighted addresses.
+	sort.Sort(p)
+	p.rebuild()
+	return p
+}
+
+// picker picks the ready SubConns with weights recomputed from the loads in
+// store every weight update interval.
+type picker struct {
+	subConns []balancer.SubConn
+	addrs    []string
+	store    *loadStore
+
+	mu      sync.Mutex
+	sched   wrr.WRR
+	updated time.Time
+}
+
+func (p *picker) Len() int           { return len(p.addrs) }
+func (p *picker) Less(i, j int) bool { return p.addrs[i] < p.addrs[j] }
+func (p *picker) Swap(i, j int) {
+	p.addrs[i], p.addrs[j] = p.addrs[j], p.addrs[i]
+	p.subConns[i], p.subConns[j] = p.subConns[j], p.subConns[i]
+}
+
+// rebuild recomputes the weights. Caller must hold p.mu, or own p.
+func (p *picker) rebuild() {
+	sched := wrr.NewEDF()
+	for i, w := range p.store.weights(p.addrs) {
+		sched.Add(i, w)
+	}
+	p.sched = sched
+	p.updated = time.Now()
+}
+
+func (p *picker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	p.mu.Lock()
+	if time.Since(p.updated) >= p.store.weightUpdateInterval() {
+		p.rebuild()
+	}
+	i := p.sched.Next().(int)
+	p.mu.Unlock()
+
+	addr := p.addrs[i]
+	return balancer.PickResult{
+		SubConn: p.subConns[i],
+		Done: func(info balancer.DoneInfo) {
+			p.store.utilization.Record(addr, info)
+		},
+	}, nil
+}
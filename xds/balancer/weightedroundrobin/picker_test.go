@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package weightedroundrobin
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	orcapb "github.com/cncf/xds/go/xds/data/orca/v3"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+type testSubConn struct {
+	balancer.SubConn
+	addr string
+}
+
+func newTestPicker(t *testing.T, addrs ...string) (balancer.Picker, *loadStore) {
+	t.Helper()
+	store := newLoadStore()
+	// The tests recompute the weights explicitly, with updateWeights.
+	store.setConfig(&LBConfig{WeightUpdateInterval: time.Hour, SmoothingFactor: 1})
+	info := base.PickerBuildInfo{ReadySCs: make(map[balancer.SubConn]base.SubConnInfo)}
+	for _, addr := range addrs {
+		info.ReadySCs[&testSubConn{addr: addr}] = base.SubConnInfo{Address: resolver.Address{Addr: addr}}
+	}
+	return (&pickerBuilder{store: store}).Build(info), store
+}
+
+func updateWeights(p balancer.Picker) {
+	wp := p.(*picker)
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	wp.rebuild()
+}
+
+// pickCounts picks n times, reporting cpu[addr] as the load of each RPC, and
+// returns the number of picks of each address.
+func pickCounts(t *testing.T, p balancer.Picker, n int, cpu map[string]float64) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	for i := 0; i < n; i++ {
+		res, err := p.Pick(balancer.PickInfo{})
+		if err != nil {
+			t.Fatalf("Pick() failed: %v", err)
+		}
+		addr := res.SubConn.(*testSubConn).addr
+		counts[addr]++
+		if u, ok := cpu[addr]; ok {
+			res.Done(balancer.DoneInfo{ServerLoad: &orcapb.OrcaLoadReport{CpuUtilization: u}})
+		}
+	}
+	return counts
+}
+
+func TestPickerNoLoadIsRoundRobin(t *testing.T) {
+	p, _ := newTestPicker(t, "a", "b", "c")
+	counts := pickCounts(t, p, 300, nil)
+	for _, addr := range []string{"a", "b", "c"} {
+		if counts[addr] != 100 {
+			t.Errorf("got %d picks of %q, want 100; all: %v", counts[addr], addr, counts)
+		}
+	}
+}
+
+func TestPickerShiftsToUnderloaded(t *testing.T) {
+	p, _ := newTestPicker(t, "busy", "idle")
+	cpu := map[string]float64{"busy": 0.8, "idle": 0.2}
+	// Without load data yet, both addresses get the same share.
+	if counts := pickCounts(t, p, 10, cpu); counts["busy"] != counts["idle"] {
+		t.Fatalf("got picks %v before any load report, want round robin", counts)
+	}
+	updateWeights(p)
+	counts := pickCounts(t, p, 1000, cpu)
+	// Weights are 1/0.8 and 1/0.2, so idle should get about 4 times the picks.
+	if ratio := float64(counts["idle"]) / float64(counts["busy"]); ratio < 3 || ratio > 5 {
+		t.Errorf("got picks %v, ratio idle/busy %v, want about 4", counts, ratio)
+	}
+}
+
+func TestPickerMissingLoadGetsMeanWeight(t *testing.T) {
+	p, store := newTestPicker(t, "a", "b", "c")
+	store.utilization.Update("a", 0.5)
+	store.utilization.Update("b", 0.25)
+	updateWeights(p)
+	// a has weight 2000, b 4000 and c, without load data, the mean 3000.
+	counts := pickCounts(t, p, 900, nil)
+	want := map[string]int{"a": 200, "b": 400, "c": 300}
+	for addr, w := range want {
+		if d := counts[addr] - w; d < -5 || d > 5 {
+			t.Errorf("got %d picks of %q, want about %d; all: %v", counts[addr], addr, w, counts)
+		}
+	}
+}
+
+func TestPickerNoReadySubConns(t *testing.T) {
+	p, _ := newTestPicker(t)
+	if _, err := p.Pick(balancer.PickInfo{}); err != balancer.ErrNoSubConnAvailable {
+		t.Errorf("Pick() error = %v, want %v", err, balancer.ErrNoSubConnAvailable)
+	}
+}
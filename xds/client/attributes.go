@@ -23,6 +23,10 @@
 
 package client
 
+import (
+	"context"
+)
+
 import (
 	_struct "github.com/golang/protobuf/ptypes/struct"
 
@@ -61,6 +65,17 @@ type XDSClient interface {
 		SetMetadata would reconnect tcp link with new metadata
 	*/
 	SetMetadata(*_struct.Struct) error
+	// UpdateMetadata changes the metadata last set with update, flushing it
+	// once to the management server.
+	UpdateMetadata(update func(*_struct.Struct)) error
+
+	// Warm blocks until the resources are received, watching them until the
+	// client is closed.
+	Warm(ctx context.Context, resources []resource.Name) error
+
+	// WaitForReady blocks until the first response is received from the
+	// management server, or ctx is done.
+	WaitForReady(ctx context.Context) error
 }
 
 // FromResolverState returns the Client from state, or nil if not present.
@@ -62,16 +62,16 @@ func (c *clientImpl) findAuthority(n *resource.Name) (_ *authority, unref func()
 		return nil, nil, errors.New("the xds-client is closed")
 	}
 
-	config := c.config.XDSServer
+	config, fallbacks := c.config.XDSServer, c.config.FallbackServers
 	if scheme == federationScheme {
 		cfg, ok := c.config.Authorities[authority]
 		if !ok {
-			return nil, nil, fmt.Errorf("xds: failed to find authority %q", authority)
+			return nil, nil, fmt.Errorf("%w: %q (name %q), known authorities are %q", ErrAuthorityNotFound, authority, n, c.knownAuthorities())
 		}
-		config = cfg.XDSServer
+		config, fallbacks = cfg.XDSServer, nil
 	}
 
-	a, err := c.newAuthority(config)
+	a, err := c.newAuthority(config, fallbacks)
 	if err != nil {
 		dubbogoLogger.Errorf(`[XDS Authority] new authority failed with error = %s, please makesure you have imported 
 	_ "dubbo.apache.org/dubbo-go/v3/xds/client/controller/version/v2"
@@ -92,11 +92,12 @@ func (c *clientImpl) findAuthority(n *resource.Name) (_ *authority, unref func()
 	return a, func() { c.unrefAuthority(a) }, nil
 }
 
-// newAuthority creates a new authority for the config. But before that, it
-// checks the cache to see if an authority for this config already exists.
+// newAuthority creates a new authority for the config, which fails over to the
+// fallbacks. But before that, it checks the cache to see if an authority for
+// this config already exists.
 //
 // caller must hold c.authorityMu
-func (c *clientImpl) newAuthority(config *bootstrap.ServerConfig) (_ *authority, retErr error) {
+func (c *clientImpl) newAuthority(config *bootstrap.ServerConfig, fallbacks []*bootstrap.ServerConfig) (_ *authority, retErr error) {
 	// First check if there's already an authority for this config. If found, it
 	// means this authority is used by other watches (could be the same
 	// authority name, or a different authority name but the same server
@@ -112,23 +113,51 @@ func (c *clientImpl) newAuthority(config *bootstrap.ServerConfig) (_ *authority,
 	if old, ok := c.idleAuthorities.Remove(configStr); ok {
 		oldA, _ := old.(*authority)
 		if oldA != nil {
+			// The timeout may have been updated while the authority was idle.
+			oldA.pubsub.SetWatchExpiryTimeout(c.pubsubWatchExpiryTimeout())
 			c.authorities[configStr] = oldA
 			return oldA, nil
 		}
 	}
 
 	// Make a new authority since there's no existing authority for this config.
-	ret := &authority{config: config, pubsub: pubsub.New(c.watchExpiryTimeout, c.logger)}
+	ret := &authority{config: config, pubsub: pubsub.New(c.pubsubWatchExpiryTimeout(), c.logger)}
+	for rType, d := range c.maxStaleness {
+		ret.pubsub.SetMaxStaleness(rType, d)
+	}
+	// The watch expiry is disabled in validate only mode, see
+	// pubsubWatchExpiryTimeout.
+	if c.validation == nil {
+		for rType, d := range c.watchExpiryTimeouts {
+			ret.pubsub.SetTypeWatchExpiryTimeout(rType, d)
+		}
+	}
+	// The fallback servers share the pubsub, so the primary server decides
+	// whether deleted resources are ignored, and whether the updates are
+	// incremental.
+	ret.pubsub.SetIgnoreResourceDeletion(config.IgnoreResourceDeletion)
+	ret.pubsub.SetIncremental(config.DeltaADS)
+	if f := c.config.ClusterNameFilter; f != nil {
+		ret.pubsub.SetClusterNameFilter(f.Match)
+	}
 	defer func() {
 		if retErr != nil {
 			ret.close()
 		}
 	}()
-	ctr, err := newController(config, ret.pubsub, c.updateValidator, c.logger)
+	var updateHandler pubsub.UpdateHandler = ret.pubsub
+	if c.validation != nil {
+		updateHandler = &validateOnlyHandler{server: config.ServerURI, report: c.validation, logger: c.logger}
+	}
+	ctr, err := newController(config, fallbacks, updateHandler, c.updateValidator, c.logger)
 	if err != nil {
 		return nil, err
 	}
 	ret.controller = ctr
+	if c.ackCallback != nil {
+		ctr.SetAckCallback(c.ackCallback)
+	}
+	ctr.SetAckPolicy(c.ackPolicy)
 	// Add it to the cache, so it will be reused.
 	c.authorities[configStr] = ret
 	return ret, nil
@@ -149,6 +178,10 @@ func (c *clientImpl) unrefAuthority(a *authority) {
 	}
 	configStr := a.config.String()
 	delete(c.authorities, configStr)
+	if c.done.HasFired() {
+		// The authority is already closed by Close, don't keep it around.
+		return
+	}
 	c.idleAuthorities.Add(configStr, a, func() {
 		a.close()
 	})
@@ -172,6 +205,18 @@ func (a *authority) SetMetadata(m *_struct.Struct) error {
 	return a.controller.SetMetadata(m)
 }
 
+// flushMetadata sends the node metadata to the management server. Without a
+// watch on this authority yet, it's sent with the first one.
+func (a *authority) flushMetadata() {
+	a.controller.FlushMetadata()
+}
+
+// ready returns a channel that is closed once the ADS stream of this authority
+// has received its first response.
+func (a *authority) ready() <-chan struct{} {
+	return a.controller.Ready()
+}
+
 // caller must hold parent's authorityMu.
 func (a *authority) ref() {
 	a.refCount++
@@ -192,6 +237,17 @@ func (a *authority) close() {
 	}
 }
 
+// closeWithError closes the authority, and invokes the callbacks of the active
+// watchers with err.
+func (a *authority) closeWithError(err error) {
+	if a.controller != nil {
+		a.controller.Close()
+	}
+	if a.pubsub != nil {
+		a.pubsub.CloseWithError(err)
+	}
+}
+
 func (a *authority) watchListener(serviceName string, cb func(resource.ListenerUpdate, error)) (cancel func()) {
 	first, cancelF := a.pubsub.WatchListener(serviceName, cb)
 	if first {
@@ -244,6 +300,10 @@ func (a *authority) reportLoad(server string) (*load.Store, func()) {
 	return a.controller.ReportLoad(server)
 }
 
+func (a *authority) watchCounts() map[resource.ResourceType]int {
+	return a.pubsub.WatchCounts()
+}
+
 func (a *authority) dump(t resource.ResourceType) map[string]resource.UpdateWithMD {
 	return a.pubsub.Dump(t)
 }
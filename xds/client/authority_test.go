@@ -0,0 +1,118 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/bootstrap"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+func TestFindAuthorityNotFound(t *testing.T) {
+	c, _ := newFakeControllerClient(t)
+	c.config.Authorities = map[string]*bootstrap.Authority{
+		"mesh-a": {XDSServer: &bootstrap.ServerConfig{ServerURI: "mesh-a.example.com:443"}},
+		"mesh-b": {XDSServer: &bootstrap.ServerConfig{ServerURI: "mesh-b.example.com:443"}},
+	}
+
+	_, _, err := c.findAuthority(&resource.Name{Scheme: federationScheme, Authority: "mesh-c", ID: "foo"})
+	if !errors.Is(err, ErrAuthorityNotFound) {
+		t.Fatalf("findAuthority() returned %v, want ErrAuthorityNotFound", err)
+	}
+	for _, want := range []string{`"mesh-c"`, `["mesh-a" "mesh-b"]`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("findAuthority() returned %q, want it to contain %s", err, want)
+		}
+	}
+
+	_, unref, err := c.findAuthority(&resource.Name{Scheme: federationScheme, Authority: "mesh-a", ID: "foo"})
+	if err != nil {
+		t.Fatalf("findAuthority() failed: %v", err)
+	}
+	unref()
+}
+
+func TestSetWatchExpiryTimeout(t *testing.T) {
+	c, _ := newFakeControllerClient(t)
+	active, unref, err := c.findAuthority(resource.ParseName(""))
+	if err != nil {
+		t.Fatalf("findAuthority() failed: %v", err)
+	}
+
+	c.SetWatchExpiryTimeout(2 * time.Second)
+	if got := c.WatchExpiryTimeout(); got != 2*time.Second {
+		t.Fatalf("WatchExpiryTimeout() = %v, want %v", got, 2*time.Second)
+	}
+	if got := active.pubsub.WatchExpiryTimeout(); got != 2*time.Second {
+		t.Fatalf("active authority has watch expiry timeout %v, want %v", got, 2*time.Second)
+	}
+
+	// The authority is idle once its last user is done. It's only updated
+	// when it's revived.
+	unref()
+	c.SetWatchExpiryTimeout(3 * time.Second)
+	if got := active.pubsub.WatchExpiryTimeout(); got != 2*time.Second {
+		t.Fatalf("idle authority has watch expiry timeout %v, want %v", got, 2*time.Second)
+	}
+	revived, unref, err := c.findAuthority(resource.ParseName(""))
+	if err != nil {
+		t.Fatalf("findAuthority() failed: %v", err)
+	}
+	defer unref()
+	if revived != active {
+		t.Fatal("findAuthority() didn't revive the idle authority")
+	}
+	if got := revived.pubsub.WatchExpiryTimeout(); got != 3*time.Second {
+		t.Fatalf("revived authority has watch expiry timeout %v, want %v", got, 3*time.Second)
+	}
+}
+
+// TestSetWatchExpiryTimeoutWatches verifies that a new timeout applies to the
+// watches started after it's set, and that the existing ones keep theirs.
+func TestSetWatchExpiryTimeoutWatches(t *testing.T) {
+	c, _ := newFakeControllerClient(t)
+	c.SetWatchExpiryTimeout(time.Hour)
+	before := make(chan error, 1)
+	cancel := c.WatchCluster("before", func(_ resource.ClusterUpdate, err error) { before <- err })
+	defer cancel()
+
+	c.SetWatchExpiryTimeout(10 * time.Millisecond)
+	after := make(chan error, 1)
+	cancel = c.WatchCluster("after", func(_ resource.ClusterUpdate, err error) { after <- err })
+	defer cancel()
+
+	select {
+	case err := <-after:
+		if err == nil {
+			t.Fatal("watch started after the update got no error, want the watch expiry")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for the watch started after the update to expire")
+	}
+	select {
+	case err := <-before:
+		t.Fatalf("watch started before the update got %v, want no callback", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
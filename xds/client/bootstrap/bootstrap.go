@@ -30,7 +30,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 )
 
 import (
@@ -60,6 +62,12 @@ const (
 	// features supported by the server. A value of "xds_v3" indicates that the
 	// server supports the v3 version of the xDS transport protocol.
 	serverFeaturesV3 = "xds_v3"
+	// A value of "ignore_resource_deletion" indicates that the client must
+	// not treat a resource missing from a response as deleted.
+	serverFeatureIgnoreResourceDeletion = "ignore_resource_deletion"
+	// A value of "delta_ads" indicates that the server supports the delta
+	// (incremental) variant of the ADS protocol, which requires "xds_v3".
+	serverFeatureDeltaADS = "delta_ads"
 
 	// Type name for Google default credentials.
 	credsGoogleDefault              = "google_default"
@@ -90,6 +98,24 @@ type ServerConfig struct {
 	// This describes the xDS gRPC endpoint and version of
 	// DiscoveryRequest/Response used on the wire.
 	TransportAPI version.TransportAPI
+	// ServerFeatures are the features supported by the server, as listed in
+	// the server_features field of the bootstrap file. The ones known to the
+	// client are also reflected in TransportAPI, IgnoreResourceDeletion and
+	// DeltaADS.
+	ServerFeatures []string
+	// IgnoreResourceDeletion is set by the "ignore_resource_deletion" server
+	// feature. When set, a previously received LDS or CDS resource missing
+	// from a response is kept in the cache, and its watchers are not told
+	// that it doesn't exist anymore, like Envoy does. It makes the client
+	// resilient to a control plane wrongly dropping resources, e.g. while it
+	// restarts.
+	IgnoreResourceDeletion bool
+	// DeltaADS is set by the "delta_ads" server feature. When set, the ADS
+	// streams use the delta variant of the protocol: the client subscribes to
+	// and unsubscribes from resource names, and the server only sends the
+	// changed resources and the names of the removed ones, instead of all the
+	// resources of a type on every change.
+	DeltaADS bool
 	// NodeProto contains the Node proto to be used in xDS requests. The actual
 	// type depends on the transport protocol version used.
 	//
@@ -97,6 +123,66 @@ type ServerConfig struct {
 	// but we keep it in each server config so that its type (e.g. *v2pb.Node or
 	// *v3pb.Node) is consistent with the transport API version.
 	NodeProto proto.Message
+	// Backoff configures the backoff of ADS stream reconnects. nil means the
+	// default exponential backoff.
+	//
+	// Like NodeProto, it's specified in the bootstrap globally for all the
+	// servers.
+	Backoff *BackoffConfig
+	// DialOptions are appended to Creds when dialing the server, e.g. a
+	// custom dialer for an in-process server. They can't be set in the
+	// bootstrap file.
+	DialOptions []grpc.DialOption
+}
+
+// BackoffConfig configures the exponential backoff, with jitter, of ADS stream
+// reconnects. Zero values mean the defaults.
+type BackoffConfig struct {
+	// BaseDelay is the delay before the first reconnect.
+	BaseDelay time.Duration
+	// MaxDelay is the upper bound of the delay between reconnects.
+	MaxDelay time.Duration
+	// HealthyDuration is how long a stream must stay up, receiving responses,
+	// for the backoff to be reset. Zero resets it on the first response.
+	HealthyDuration time.Duration
+}
+
+// UnmarshalJSON parses the durations of the backoff config, in the format
+// accepted by time.ParseDuration (e.g. "1s").
+func (bc *BackoffConfig) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		BaseDelay       string `json:"base_delay"`
+		MaxDelay        string `json:"max_delay"`
+		HealthyDuration string `json:"healthy_duration"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"base_delay", raw.BaseDelay, &bc.BaseDelay},
+		{"max_delay", raw.MaxDelay, &bc.MaxDelay},
+		{"healthy_duration", raw.HealthyDuration, &bc.HealthyDuration},
+	} {
+		if f.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(f.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %q: %v", f.name, f.value, err)
+		}
+		if d < 0 {
+			return fmt.Errorf("invalid %s %q: must not be negative", f.name, f.value)
+		}
+		*f.dst = d
+	}
+	if bc.BaseDelay > 0 && bc.MaxDelay > 0 && bc.MaxDelay < bc.BaseDelay {
+		return fmt.Errorf("max_delay %v is less than base_delay %v", bc.MaxDelay, bc.BaseDelay)
+	}
+	return nil
 }
 
 // String returns the string representation of the ServerConfig.
@@ -116,37 +202,26 @@ func (sc *ServerConfig) String() string {
 	case version.TransportV2:
 		ver = "xDSv2"
 	}
-	return strings.Join([]string{sc.ServerURI, sc.CredsType, ver}, "-")
+	parts := []string{sc.ServerURI, sc.CredsType, ver}
+	if sc.IgnoreResourceDeletion {
+		parts = append(parts, serverFeatureIgnoreResourceDeletion)
+	}
+	if sc.DeltaADS {
+		parts = append(parts, serverFeatureDeltaADS)
+	}
+	return strings.Join(parts, "-")
 }
 
 // UnmarshalJSON takes the json data (a list of servers) and unmarshals the
-// first one in the list.
+// first one in the list. It fails with a *ValidationError if the list or its
+// first server is invalid.
 func (sc *ServerConfig) UnmarshalJSON(data []byte) error {
-	var servers []*xdsServer
-	if err := json.Unmarshal(data, &servers); err != nil {
-		return fmt.Errorf("xds: json.Unmarshal(data) for field xds_servers failed during bootstrap: %v", err)
-	}
-	if len(servers) < 1 {
-		return fmt.Errorf("xds: bootstrap file parsing failed during bootstrap: file doesn't contain any management server to connect to")
-	}
-	xs := servers[0]
-	sc.ServerURI = xs.ServerURI
-	for _, cc := range xs.ChannelCreds {
-		// We stop at the first credential type that we support.
-		sc.CredsType = cc.Type
-		if cc.Type == credsGoogleDefault {
-			sc.Creds = grpc.WithCredentialsBundle(google.NewDefaultCredentials())
-			break
-		} else if cc.Type == credsInsecure {
-			sc.Creds = grpc.WithTransportCredentials(insecure.NewCredentials())
-			break
-		}
-	}
-	for _, f := range xs.ServerFeatures {
-		if f == serverFeaturesV3 {
-			sc.TransportAPI = version.TransportV3
-		}
+	var errs fieldErrors
+	first, _ := parseServers(data, "xds_servers", &errs)
+	if err := errs.err(); err != nil {
+		return err
 	}
+	*sc = *first
 	return nil
 }
 
@@ -172,25 +247,15 @@ type Authority struct {
 	XDSServer *ServerConfig
 }
 
-// UnmarshalJSON implement json unmarshaller.
+// UnmarshalJSON implement json unmarshaller. It fails with a
+// *ValidationError if the authority is invalid.
 func (a *Authority) UnmarshalJSON(data []byte) error {
-	var jsonData map[string]json.RawMessage
-	if err := json.Unmarshal(data, &jsonData); err != nil {
-		return fmt.Errorf("xds: failed to parse authority: %v", err)
-	}
-
-	for k, v := range jsonData {
-		switch k {
-		case "xds_servers":
-			if err := json.Unmarshal(v, &a.XDSServer); err != nil {
-				return fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %v", string(v), k, err)
-			}
-		case "client_listener_resource_name_template":
-			if err := json.Unmarshal(v, &a.ClientListenerResourceNameTemplate); err != nil {
-				return fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %v", string(v), k, err)
-			}
-		}
+	var errs fieldErrors
+	authority := parseAuthority(data, "", &errs)
+	if err := errs.err(); err != nil {
+		return err
 	}
+	*a = *authority
 	return nil
 }
 
@@ -203,9 +268,25 @@ type Config struct {
 	// The bootstrap file contains a list of servers (with name+creds), but we
 	// pick the first one.
 	XDSServer *ServerConfig
+	// FallbackServers are the management servers listed after the first one
+	// in the bootstrap file, in priority order. The client fails over to them
+	// when the ADS stream to XDSServer keeps failing, and fails back to
+	// XDSServer once it recovers.
+	FallbackServers []*ServerConfig
 	// CertProviderConfigs contains a mapping from certificate provider plugin
 	// instance names to parsed buildable configs.
 	CertProviderConfigs map[string]*certprovider.BuildableConfig
+	// UseSystemRootCerts makes the client verify the server certificates
+	// against the system root CAs when the security configuration received
+	// for a cluster does not name a root certificate provider instance.
+	// Without it, such a configuration is rejected.
+	UseSystemRootCerts bool
+	// ClusterNameFilter selects the CDS resources the client caches and
+	// notifies the wildcard watches of, so that the clusters pushed by the
+	// control plane of a shared mesh and never used don't take up memory. It's
+	// nil when the bootstrap has no "cluster_name_filter", keeping all the
+	// clusters.
+	ClusterNameFilter *NameFilter
 	// ServerListenerResourceNameTemplate is a template for the name of the
 	// Listener resource to subscribe to for a gRPC server.
 	//
@@ -253,17 +334,6 @@ type Config struct {
 	Authorities map[string]*Authority
 }
 
-type channelCreds struct {
-	Type   string          `json:"type"`
-	Config json.RawMessage `json:"config"`
-}
-
-type xdsServer struct {
-	ServerURI      string         `json:"server_uri"`
-	ChannelCreds   []channelCreds `json:"channel_creds"`
-	ServerFeatures []string       `json:"server_features"`
-}
-
 func bootstrapConfigFromEnvVariable() ([]byte, error) {
 	fName := envconfig.XDSBootstrapFileName
 	fContent := envconfig.XDSBootstrapFileContent
@@ -310,9 +380,30 @@ func NewConfig() (*Config, error) {
 	return NewConfigFromContents(data)
 }
 
+// NewConfigFromFile returns a new Config initialized by reading the bootstrap
+// file at path. The contents go through NewConfigFromContents.
+func NewConfigFromFile(path string) (*Config, error) {
+	data, err := bootstrapFileReadFunc(path)
+	if err != nil {
+		return nil, fmt.Errorf("xds: Failed to read bootstrap config: %v", err)
+	}
+	dubbogoLogger.Debugf("Bootstrap content: %s", data)
+	return NewConfigFromContents(data)
+}
+
 // NewConfigFromContents returns a new Config using the specified bootstrap
-// file contents instead of reading the environment variable.  This is only
-// suitable for testing purposes.
+// contents instead of reading the environment variable, e.g. inline JSON
+// injected in an environment variable of a container. It runs the same
+// parsing and validation as the file loaders.
+//
+// The ${NAME} references in the node id and cluster are replaced with the
+// value of the environment variable NAME, and $$ with a literal $, so that one
+// bootstrap can serve many pods. A reference to an unset variable is invalid,
+// unless GRPC_XDS_BOOTSTRAP_NODE_ENV_STRICT is set to "false", in which case
+// it is replaced with an empty string.
+//
+// All the invalid fields are reported at once, in a *ValidationError giving
+// the JSON path of each of them.
 func NewConfigFromContents(data []byte) (*Config, error) {
 	config := &Config{}
 
@@ -321,7 +412,11 @@ func NewConfigFromContents(data []byte) (*Config, error) {
 		return nil, fmt.Errorf("xds: Failed to parse bootstrap config: %v", err)
 	}
 
-	var node *v3corepb.Node
+	var (
+		errs       fieldErrors
+		node       *v3corepb.Node
+		adsBackoff *BackoffConfig
+	)
 	m := jsonpb.Unmarshaler{AllowUnknownFields: true}
 	for k, v := range jsonData {
 		switch k {
@@ -334,60 +429,50 @@ func NewConfigFromContents(data []byte) (*Config, error) {
 			// unmarshaler.
 			node = &v3corepb.Node{}
 			if err := m.Unmarshal(bytes.NewReader(v), node); err != nil {
-				return nil, fmt.Errorf("xds: jsonpb.Unmarshal(%v) for field %q failed during bootstrap: %v", string(v), k, err)
+				errs.add(k, "invalid node: %v", err)
+				continue
 			}
+			expandNodeEnv(node, k, &errs)
 		case "xds_servers":
-			if err := json.Unmarshal(v, &config.XDSServer); err != nil {
-				return nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %v", string(v), k, err)
-			}
+			config.XDSServer, config.FallbackServers = parseServers(v, k, &errs)
 		case "certificate_providers":
-			var providerInstances map[string]json.RawMessage
-			if err := json.Unmarshal(v, &providerInstances); err != nil {
-				return nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %v", string(v), k, err)
+			config.CertProviderConfigs = parseCertProviderConfigs(v, k, &errs)
+		case "use_system_root_certs":
+			if err := json.Unmarshal(v, &config.UseSystemRootCerts); err != nil {
+				errs.add(k, "must be a boolean")
 			}
-			configs := make(map[string]*certprovider.BuildableConfig)
-			getBuilder := internal.GetCertificateProviderBuilder.(func(string) certprovider.Builder)
-			for instance, data := range providerInstances {
-				var nameAndConfig struct {
-					PluginName string          `json:"plugin_name"`
-					Config     json.RawMessage `json:"config"`
-				}
-				if err := json.Unmarshal(data, &nameAndConfig); err != nil {
-					return nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %v", string(v), instance, err)
-				}
-
-				name := nameAndConfig.PluginName
-				parser := getBuilder(nameAndConfig.PluginName)
-				if parser == nil {
-					// We ignore plugins that we do not know about.
-					continue
-				}
-				bc, err := parser.ParseConfig(nameAndConfig.Config)
-				if err != nil {
-					return nil, fmt.Errorf("xds: Config parsing for plugin %q failed: %v", name, err)
-				}
-				configs[instance] = bc
+		case "ads_backoff":
+			backoff := &BackoffConfig{}
+			if err := json.Unmarshal(v, backoff); err != nil {
+				errs.add(k, "%v", err)
+				continue
 			}
-			config.CertProviderConfigs = configs
+			adsBackoff = backoff
+		case "cluster_name_filter":
+			config.ClusterNameFilter = parseNameFilter(v, k, &errs)
 		case "server_listener_resource_name_template":
-			if err := json.Unmarshal(v, &config.ServerListenerResourceNameTemplate); err != nil {
-				return nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %v", string(v), k, err)
-			}
+			parseString(v, k, &errs, &config.ServerListenerResourceNameTemplate)
 		case "client_default_listener_resource_name_template":
 			if !envconfig.XDSFederation {
 				dubbogoLogger.Warnf("xds: bootstrap field %v is not support when Federation is disabled", k)
 				continue
 			}
-			if err := json.Unmarshal(v, &config.ClientDefaultListenerResourceNameTemplate); err != nil {
-				return nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %v", string(v), k, err)
-			}
+			parseString(v, k, &errs, &config.ClientDefaultListenerResourceNameTemplate)
 		case "authorities":
 			if !envconfig.XDSFederation {
 				dubbogoLogger.Warnf("xds: bootstrap field %v is not support when Federation is disabled", k)
 				continue
 			}
-			if err := json.Unmarshal(v, &config.Authorities); err != nil {
-				return nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %v", string(v), k, err)
+			var authorities map[string]json.RawMessage
+			if err := json.Unmarshal(v, &authorities); err != nil {
+				errs.add(k, "must be an object: %v", err)
+				continue
+			}
+			config.Authorities = make(map[string]*Authority, len(authorities))
+			for name, raw := range authorities {
+				if authority := parseAuthority(raw, keyPath(k, name), &errs); authority != nil {
+					config.Authorities[name] = authority
+				}
 			}
 		default:
 			dubbogoLogger.Warnf("Bootstrap content has unknown field: %s", k)
@@ -401,14 +486,8 @@ func NewConfigFromContents(data []byte) (*Config, error) {
 		// Default value of the default client listener name template is "%s".
 		config.ClientDefaultListenerResourceNameTemplate = "%s"
 	}
-	if config.XDSServer == nil {
-		return nil, fmt.Errorf("xds: Required field %q not found in bootstrap %s", "xds_servers", jsonData["xds_servers"])
-	}
-	if config.XDSServer.ServerURI == "" {
-		return nil, fmt.Errorf("xds: Required field %q not found in bootstrap %s", "xds_servers.server_uri", jsonData["xds_servers"])
-	}
-	if config.XDSServer.Creds == nil {
-		return nil, fmt.Errorf("xds: Required field %q doesn't contain valid value in bootstrap %s", "xds_servers.channel_creds", jsonData["xds_servers"])
+	if _, ok := jsonData["xds_servers"]; !ok {
+		errs.add("xds_servers", "required field is missing")
 	}
 	// Post-process the authorities' client listener resource template field:
 	// - if set, it must start with "xdstp://<authority_name>/"
@@ -420,17 +499,257 @@ func NewConfigFromContents(data []byte) (*Config, error) {
 			continue
 		}
 		if !strings.HasPrefix(authority.ClientListenerResourceNameTemplate, prefix) {
-			return nil, fmt.Errorf("xds: field ClientListenerResourceNameTemplate %q of authority %q doesn't start with prefix %q", authority.ClientListenerResourceNameTemplate, name, prefix)
+			errs.add(fieldPath(keyPath("authorities", name), "client_listener_resource_name_template"),
+				"%q doesn't start with prefix %q", authority.ClientListenerResourceNameTemplate, prefix)
 		}
 	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
 
 	if err := config.updateNodeProto(node); err != nil {
 		return nil, err
 	}
+	if adsBackoff != nil {
+		config.updateBackoff(adsBackoff)
+	}
 	dubbogoLogger.Infof("Bootstrap config for creating xds-client: %v", pretty.ToJSON(config))
 	return config, nil
 }
 
+// parseString unmarshals the JSON string at path into dst.
+func parseString(data json.RawMessage, path string, errs *fieldErrors, dst *string) bool {
+	if err := json.Unmarshal(data, dst); err != nil {
+		errs.add(path, "must be a string")
+		return false
+	}
+	return true
+}
+
+// parseServers parses the list of management servers at path, returning the
+// first one and the following ones, which are the fallbacks. Unlike the first
+// server, a fallback without any supported channel creds is skipped with a
+// warning, since the client can still fail over to the others.
+func parseServers(data json.RawMessage, path string, errs *fieldErrors) (*ServerConfig, []*ServerConfig) {
+	var servers []json.RawMessage
+	if err := json.Unmarshal(data, &servers); err != nil {
+		errs.add(path, "must be an array of servers")
+		return nil, nil
+	}
+	if len(servers) == 0 {
+		errs.add(path, "must contain at least one management server")
+		return nil, nil
+	}
+	first := parseServer(servers[0], indexPath(path, 0), errs, true)
+	var fallbacks []*ServerConfig
+	for i, server := range servers[1:] {
+		if sc := parseServer(server, indexPath(path, i+1), errs, false); sc != nil {
+			fallbacks = append(fallbacks, sc)
+		}
+	}
+	return first, fallbacks
+}
+
+// parseServer parses the management server at path. It returns nil if the
+// server is invalid, or if it has no supported channel creds, which is an
+// error only when required is set.
+func parseServer(data json.RawMessage, path string, errs *fieldErrors, required bool) *ServerConfig {
+	var fields struct {
+		ServerURI      json.RawMessage `json:"server_uri"`
+		ChannelCreds   json.RawMessage `json:"channel_creds"`
+		ServerFeatures json.RawMessage `json:"server_features"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		errs.add(path, "must be an object")
+		return nil
+	}
+
+	before := errs.count()
+	sc := &ServerConfig{}
+	uriPath := fieldPath(path, "server_uri")
+	if fields.ServerURI == nil {
+		errs.add(uriPath, "required field is missing")
+	} else if parseString(fields.ServerURI, uriPath, errs, &sc.ServerURI) && sc.ServerURI == "" {
+		errs.add(uriPath, "must not be empty")
+	}
+
+	credsPath := fieldPath(path, "channel_creds")
+	var creds []json.RawMessage
+	if fields.ChannelCreds == nil {
+		errs.add(credsPath, "required field is missing")
+	} else if err := json.Unmarshal(fields.ChannelCreds, &creds); err != nil {
+		errs.add(credsPath, "must be an array of channel creds")
+	}
+	var types []string
+	for i, raw := range creds {
+		var cc struct {
+			Type   json.RawMessage `json:"type"`
+			Config json.RawMessage `json:"config"`
+		}
+		ccPath := indexPath(credsPath, i)
+		if err := json.Unmarshal(raw, &cc); err != nil {
+			errs.add(ccPath, "must be an object")
+			continue
+		}
+		typePath := fieldPath(ccPath, "type")
+		if cc.Type == nil {
+			errs.add(typePath, "required field is missing")
+			continue
+		}
+		var credsType string
+		if !parseString(cc.Type, typePath, errs, &credsType) {
+			continue
+		}
+		types = append(types, credsType)
+		if sc.Creds != nil {
+			continue
+		}
+		// We stop at the first credential type that we support.
+		switch credsType {
+		case credsGoogleDefault:
+			sc.Creds = grpc.WithCredentialsBundle(google.NewDefaultCredentials())
+			sc.CredsType = credsType
+		case credsInsecure:
+			sc.Creds = grpc.WithTransportCredentials(insecure.NewCredentials())
+			sc.CredsType = credsType
+		default:
+			builder, ok := channelCredsBuilders[credsType]
+			if !ok {
+				continue
+			}
+			tc, err := builder(cc.Config)
+			if err != nil {
+				errs.add(fieldPath(ccPath, "config"), "invalid config of channel creds type %q: %v", credsType, err)
+				continue
+			}
+			sc.Creds = grpc.WithTransportCredentials(tc)
+			sc.CredsType = credsType
+		}
+	}
+
+	if fields.ServerFeatures != nil {
+		featuresPath := fieldPath(path, "server_features")
+		var features []json.RawMessage
+		if err := json.Unmarshal(fields.ServerFeatures, &features); err != nil {
+			errs.add(featuresPath, "must be an array of strings")
+		}
+		for i, raw := range features {
+			var f string
+			if !parseString(raw, indexPath(featuresPath, i), errs, &f) {
+				continue
+			}
+			sc.ServerFeatures = append(sc.ServerFeatures, f)
+			switch f {
+			case serverFeaturesV3:
+				sc.TransportAPI = version.TransportV3
+			case serverFeatureIgnoreResourceDeletion:
+				sc.IgnoreResourceDeletion = true
+			case serverFeatureDeltaADS:
+				sc.DeltaADS = true
+			}
+		}
+		if sc.DeltaADS && sc.TransportAPI != version.TransportV3 {
+			errs.add(featuresPath, "%q requires %q", serverFeatureDeltaADS, serverFeaturesV3)
+		}
+	}
+
+	if errs.count() > before {
+		return nil
+	}
+	if sc.Creds == nil {
+		if required {
+			errs.add(credsPath, "no supported type in %q, want one of %q", types, supportedChannelCreds())
+		} else {
+			dubbogoLogger.Warnf("xds: skipping management server %s at %s without supported channel creds in %q", sc.ServerURI, path, types)
+		}
+		return nil
+	}
+	return sc
+}
+
+// parseAuthority parses the authority at path.
+func parseAuthority(data json.RawMessage, path string, errs *fieldErrors) *Authority {
+	var jsonData map[string]json.RawMessage
+	if err := json.Unmarshal(data, &jsonData); err != nil {
+		errs.add(path, "must be an object")
+		return nil
+	}
+
+	before := errs.count()
+	a := &Authority{}
+	for k, v := range jsonData {
+		switch k {
+		case "xds_servers":
+			a.XDSServer, _ = parseServers(v, fieldPath(path, k), errs)
+		case "client_listener_resource_name_template":
+			parseString(v, fieldPath(path, k), errs, &a.ClientListenerResourceNameTemplate)
+		}
+	}
+	if errs.count() > before {
+		return nil
+	}
+	return a
+}
+
+// parseCertProviderConfigs parses the certificate provider instances found in
+// the bootstrap file at path. Every instance must use a registered plugin and
+// carry a config accepted by that plugin, so a typo fails at startup instead
+// of at the first mTLS connection. All the invalid instances are reported.
+func parseCertProviderConfigs(data json.RawMessage, path string, errs *fieldErrors) map[string]*certprovider.BuildableConfig {
+	var providerInstances map[string]json.RawMessage
+	if err := json.Unmarshal(data, &providerInstances); err != nil {
+		errs.add(path, "must be an object")
+		return nil
+	}
+	instances := make([]string, 0, len(providerInstances))
+	for instance := range providerInstances {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+
+	configs := make(map[string]*certprovider.BuildableConfig)
+	getBuilder := internal.GetCertificateProviderBuilder.(func(string) certprovider.Builder)
+	for _, instance := range instances {
+		instancePath := keyPath(path, instance)
+		var nameAndConfig struct {
+			PluginName string          `json:"plugin_name"`
+			Config     json.RawMessage `json:"config"`
+		}
+		if err := json.Unmarshal(providerInstances[instance], &nameAndConfig); err != nil {
+			errs.add(instancePath, "invalid JSON %s: %v", providerInstances[instance], err)
+			continue
+		}
+
+		name := nameAndConfig.PluginName
+		parser := getBuilder(name)
+		if parser == nil {
+			errs.add(fieldPath(instancePath, "plugin_name"), "certificate provider plugin %q is not registered", name)
+			continue
+		}
+		bc, err := parser.ParseConfig(nameAndConfig.Config)
+		if err != nil {
+			errs.add(fieldPath(instancePath, "config"), "config parsing for plugin %q failed: %v", name, err)
+			continue
+		}
+		configs[instance] = bc
+	}
+	return configs
+}
+
+// updateBackoff sets the ADS backoff config of every server config, both top
+// level and in each authority.
+func (c *Config) updateBackoff(bc *BackoffConfig) {
+	c.XDSServer.Backoff = bc
+	for _, sc := range c.FallbackServers {
+		sc.Backoff = bc
+	}
+	for _, a := range c.Authorities {
+		if a.XDSServer != nil {
+			a.XDSServer.Backoff = bc
+		}
+	}
+}
+
 // updateNodeProto updates the node proto read from the bootstrap file.
 //
 // The input node is a v3.Node protobuf message corresponding to the JSON
@@ -440,7 +759,7 @@ func NewConfigFromContents(data []byte) (*Config, error) {
 // function can always expect that the NodeProto field is non-nil.
 // 2. Some additional fields which are not expected to be set in the bootstrap
 // file are populated here.
-// 3. For each server config (both top level and in each authority), we set its
+// 3. For each server config (top level, fallbacks and in each authority), we set its
 // node field to the v3.Node, or a v2.Node with the same content, depending on
 // the server's transprot API version.
 func (c *Config) updateNodeProto(node *v3corepb.Node) error {
@@ -466,11 +785,13 @@ func (c *Config) updateNodeProto(node *v3corepb.Node) error {
 	v2.BuildVersion = gRPCVersion
 	v2.UserAgentVersionType = &v2corepb.Node_UserAgentVersion{UserAgentVersion: grpc.Version}
 
-	switch c.XDSServer.TransportAPI {
-	case version.TransportV2:
-		c.XDSServer.NodeProto = v2
-	case version.TransportV3:
-		c.XDSServer.NodeProto = v3
+	for _, sc := range append([]*ServerConfig{c.XDSServer}, c.FallbackServers...) {
+		switch sc.TransportAPI {
+		case version.TransportV2:
+			sc.NodeProto = v2
+		case version.TransportV3:
+			sc.NodeProto = v3
+		}
 	}
 
 	for _, a := range c.Authorities {
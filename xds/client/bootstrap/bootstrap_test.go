@@ -24,11 +24,17 @@
 package bootstrap
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 import (
@@ -42,6 +48,8 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/google"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/credentials/tls/certprovider"
@@ -49,6 +57,8 @@ import (
 
 import (
 	"dubbo.apache.org/dubbo-go/v3/xds/client/resource/version"
+	xdscertprovider "dubbo.apache.org/dubbo-go/v3/xds/credentials/certprovider"
+	"dubbo.apache.org/dubbo-go/v3/xds/internal"
 	"dubbo.apache.org/dubbo-go/v3/xds/utils/envconfig"
 )
 
@@ -238,11 +248,22 @@ var (
 	}
 	nonNilCredsConfigV3 = &Config{
 		XDSServer: &ServerConfig{
-			ServerURI:    "trafficdirector.googleapis.com:443",
-			Creds:        grpc.WithCredentialsBundle(google.NewComputeEngineCredentials()),
-			CredsType:    "google_default",
-			TransportAPI: version.TransportV3,
-			NodeProto:    v3NodeProto,
+			ServerURI:      "trafficdirector.googleapis.com:443",
+			Creds:          grpc.WithCredentialsBundle(google.NewComputeEngineCredentials()),
+			CredsType:      "google_default",
+			TransportAPI:   version.TransportV3,
+			ServerFeatures: []string{"foo", "bar", "xds_v3"},
+			NodeProto:      v3NodeProto,
+		},
+		ClientDefaultListenerResourceNameTemplate: "%s",
+	}
+	nonV3FeaturesConfigV2 = &Config{
+		XDSServer: &ServerConfig{
+			ServerURI:      "trafficdirector.googleapis.com:443",
+			Creds:          grpc.WithCredentialsBundle(google.NewComputeEngineCredentials()),
+			CredsType:      "google_default",
+			ServerFeatures: []string{"foo", "bar"},
+			NodeProto:      v2NodeProto,
 		},
 		ClientDefaultListenerResourceNameTemplate: "%s",
 	}
@@ -389,6 +410,58 @@ func TestNewConfigV2ProtoFailure(t *testing.T) {
 	}
 }
 
+// TestNewConfigFromContents verifies that inline bootstrap contents are parsed
+// and validated like the same contents read from a file.
+func TestNewConfigFromContents(t *testing.T) {
+	tests := []struct {
+		name       string
+		contents   string
+		wantError  bool
+		wantConfig *Config
+	}{
+		{"goodBootstrap", v2BootstrapFileMap["goodBootstrap"], false, nonNilCredsConfigV2},
+		{"multipleXDSServers", v2BootstrapFileMap["multipleXDSServers"], false, nonNilCredsConfigV2},
+		{"empty", "", true, nil},
+		{"badJSON", `["test": 123]`, true, nil},
+		{"noBalancerName", `{"node": {"id": "ENVOY_NODE_ID"}}`, true, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "bootstrap.json")
+			if err := os.WriteFile(path, []byte(test.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			fileConfig, fileErr := NewConfigFromFile(path)
+			c, err := NewConfigFromContents([]byte(test.contents))
+			if (err != nil) != test.wantError {
+				t.Fatalf("NewConfigFromContents() returned error %v, wantError: %v", err, test.wantError)
+			}
+			if (fileErr != nil) != test.wantError {
+				t.Fatalf("NewConfigFromFile() returned error %v, wantError: %v", fileErr, test.wantError)
+			}
+			if test.wantError {
+				if err.Error() != fileErr.Error() {
+					t.Fatalf("NewConfigFromContents() returned error %q, NewConfigFromFile() returned %q", err, fileErr)
+				}
+				return
+			}
+			if err := c.compare(test.wantConfig); err != nil {
+				t.Fatal(err)
+			}
+			if err := fileConfig.compare(test.wantConfig); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestNewConfigFromFileNotFound(t *testing.T) {
+	if _, err := NewConfigFromFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("NewConfigFromFile() succeeded for a missing file")
+	}
+}
+
 // TestNewConfigV2ProtoSuccess exercises the functionality in NewConfig with
 // different bootstrap file contents. It overrides the fileReadFunc by returning
 // bootstrap file contents defined in this test, instead of reading from a file.
@@ -443,7 +516,7 @@ func TestNewConfigV3Support(t *testing.T) {
 		name       string
 		wantConfig *Config
 	}{
-		{"serverDoesNotSupportsV3", nonNilCredsConfigV2},
+		{"serverDoesNotSupportsV3", nonV3FeaturesConfigV2},
 		{"serverSupportsV3", nonNilCredsConfigV3},
 	}
 
@@ -701,8 +774,8 @@ func TestNewConfigWithCertificateProviders(t *testing.T) {
 		},
 		{
 
-			name:       "allUnknownCertProviders",
-			wantConfig: nonNilCredsConfigV3,
+			name:    "allUnknownCertProviders",
+			wantErr: true,
 		},
 		{
 			name:       "goodCertProviderConfig",
@@ -718,6 +791,111 @@ func TestNewConfigWithCertificateProviders(t *testing.T) {
 	}*/
 }
 
+func TestNewConfigWithUnregisteredCertProviders(t *testing.T) {
+	oldGetBuilder := internal.GetCertificateProviderBuilder
+	internal.GetCertificateProviderBuilder = xdscertprovider.GetBuilder
+	defer func() { internal.GetCertificateProviderBuilder = oldGetBuilder }()
+
+	_, err := NewConfigFromContents([]byte(`
+	{
+		"xds_servers" : [{
+			"server_uri": "trafficdirector.googleapis.com:443",
+			"channel_creds": [
+				{ "type": "insecure" }
+			]
+		}],
+		"certificate_providers": {
+			"unknownProviderInstance1": {
+				"plugin_name": "foo",
+				"config": {"foo": "bar"}
+			},
+			"unknownProviderInstance2": {
+				"plugin_name": "bar",
+				"config": {"foo": "bar"}
+			}
+		}
+	}`))
+	if err == nil {
+		t.Fatal("NewConfigFromContents() succeeded with unregistered certificate provider plugins")
+	}
+	for _, instance := range []string{"unknownProviderInstance1", "unknownProviderInstance2"} {
+		if !strings.Contains(err.Error(), instance) {
+			t.Errorf("NewConfigFromContents() error %q doesn't mention instance %q", err, instance)
+		}
+	}
+}
+
+func TestNewConfigWithADSBackoff(t *testing.T) {
+	c, err := NewConfigFromContents([]byte(`
+	{
+		"xds_servers" : [{
+			"server_uri": "trafficdirector.googleapis.com:443",
+			"channel_creds": [
+				{ "type": "insecure" }
+			]
+		}],
+		"ads_backoff": {
+			"base_delay": "500ms",
+			"max_delay": "1m",
+			"healthy_duration": "30s"
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("NewConfigFromContents() failed: %v", err)
+	}
+	want := &BackoffConfig{BaseDelay: 500 * time.Millisecond, MaxDelay: time.Minute, HealthyDuration: 30 * time.Second}
+	if diff := cmp.Diff(want, c.XDSServer.Backoff); diff != "" {
+		t.Fatalf("unexpected backoff config (-want +got):\n%s", diff)
+	}
+
+	for _, bad := range []string{
+		`{"base_delay": "soon"}`,
+		`{"max_delay": "-1s"}`,
+		`{"base_delay": "2s", "max_delay": "1s"}`,
+	} {
+		if err := json.Unmarshal([]byte(bad), &BackoffConfig{}); err == nil {
+			t.Errorf("json.Unmarshal(%s) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestNewConfigWithUseSystemRootCerts(t *testing.T) {
+	const format = `
+	{
+		"xds_servers" : [{
+			"server_uri": "trafficdirector.googleapis.com:443",
+			"channel_creds": [
+				{ "type": "insecure" }
+			]
+		}]%s
+	}`
+	tests := []struct {
+		name    string
+		extra   string
+		want    bool
+		wantErr bool
+	}{
+		{name: "unset"},
+		{name: "true", extra: `, "use_system_root_certs": true`, want: true},
+		{name: "false", extra: `, "use_system_root_certs": false`},
+		{name: "not a boolean", extra: `, "use_system_root_certs": "yes"`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := NewConfigFromContents([]byte(fmt.Sprintf(format, test.extra)))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("NewConfigFromContents() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if c.UseSystemRootCerts != test.want {
+				t.Fatalf("UseSystemRootCerts = %v, want %v", c.UseSystemRootCerts, test.want)
+			}
+		})
+	}
+}
+
 func TestNewConfigWithServerListenerResourceNameTemplate(t *testing.T) {
 	cancel := setupBootstrapOverride(map[string]string{
 		"badServerListenerResourceNameTemplate:": `
@@ -929,11 +1107,12 @@ func TestNewConfigWithFederation(t *testing.T) {
 					"xds.td.com": {
 						ClientListenerResourceNameTemplate: "xdstp://xds.td.com/envoy.config.listener.v3.Listener/%s",
 						XDSServer: &ServerConfig{
-							ServerURI:    "td.com",
-							Creds:        grpc.WithCredentialsBundle(google.NewComputeEngineCredentials()),
-							CredsType:    "google_default",
-							TransportAPI: version.TransportV3,
-							NodeProto:    v3NodeProto,
+							ServerURI:      "td.com",
+							Creds:          grpc.WithCredentialsBundle(google.NewComputeEngineCredentials()),
+							CredsType:      "google_default",
+							TransportAPI:   version.TransportV3,
+							ServerFeatures: []string{"foo", "bar", "xds_v3"},
+							NodeProto:      v3NodeProto,
 						},
 					},
 				},
@@ -1001,3 +1180,325 @@ func TestNewConfigWithFederation(t *testing.T) {
 		})
 	}
 }
+
+// TestNewConfigValidationErrors verifies that all the invalid fields of a
+// bootstrap are reported together, each with its JSON path.
+func TestNewConfigValidationErrors(t *testing.T) {
+	oldFederationSupport := envconfig.XDSFederation
+	envconfig.XDSFederation = true
+	defer func() { envconfig.XDSFederation = oldFederationSupport }()
+
+	tests := []struct {
+		name      string
+		contents  string
+		wantPaths []string
+	}{
+		{
+			name:      "missingServers",
+			contents:  `{"node": {"id": "ENVOY_NODE_ID"}}`,
+			wantPaths: []string{"xds_servers"},
+		},
+		{
+			name:      "emptyServers",
+			contents:  `{"xds_servers": []}`,
+			wantPaths: []string{"xds_servers"},
+		},
+		{
+			name: "badCredsType",
+			contents: `
+			{
+				"xds_servers" : [{
+					"server_uri": "trafficdirector.googleapis.com:443",
+					"channel_creds": [{ "type": 42 }]
+				}]
+			}`,
+			wantPaths: []string{"xds_servers[0].channel_creds[0].type"},
+		},
+		{
+			name: "unsupportedCreds",
+			contents: `
+			{
+				"xds_servers" : [{
+					"server_uri": "trafficdirector.googleapis.com:443",
+					"channel_creds": [{ "type": "not-google-default" }]
+				}]
+			}`,
+			wantPaths: []string{"xds_servers[0].channel_creds"},
+		},
+		{
+			name: "deltaADSWithoutV3",
+			contents: `
+			{
+				"xds_servers" : [{
+					"server_uri": "trafficdirector.googleapis.com:443",
+					"channel_creds": [{ "type": "insecure" }],
+					"server_features": ["delta_ads"]
+				}]
+			}`,
+			wantPaths: []string{"xds_servers[0].server_features"},
+		},
+		{
+			name: "multipleErrors",
+			contents: `
+			{
+				"xds_servers" : [
+					{
+						"server_uri": "",
+						"channel_creds": [{ "config": {} }],
+						"server_features": ["xds_v3", 3]
+					},
+					{
+						"channel_creds": "insecure"
+					}
+				],
+				"server_listener_resource_name_template": 123,
+				"ads_backoff": {"base_delay": "soon"}
+			}`,
+			wantPaths: []string{
+				"ads_backoff",
+				"server_listener_resource_name_template",
+				"xds_servers[0].channel_creds[0].type",
+				"xds_servers[0].server_features[1]",
+				"xds_servers[0].server_uri",
+				"xds_servers[1].channel_creds",
+				"xds_servers[1].server_uri",
+			},
+		},
+		{
+			name: "badAuthority",
+			contents: `
+			{
+				"xds_servers" : [{
+					"server_uri": "trafficdirector.googleapis.com:443",
+					"channel_creds": [{ "type": "insecure" }]
+				}],
+				"authorities": {
+					"xds.td.com": {
+						"xds_servers": [{ "server_uri": "td.com" }]
+					},
+					"xds.other.com": {
+						"client_listener_resource_name_template": "some/template/%s"
+					}
+				}
+			}`,
+			wantPaths: []string{
+				`authorities["xds.other.com"].client_listener_resource_name_template`,
+				`authorities["xds.td.com"].xds_servers[0].channel_creds`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewConfigFromContents([]byte(test.contents))
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("NewConfigFromContents() returned error %v, want a *ValidationError", err)
+			}
+			var gotPaths []string
+			for _, fe := range verr.Errors {
+				gotPaths = append(gotPaths, fe.Path)
+				if !strings.Contains(err.Error(), fe.Path) {
+					t.Errorf("error %q doesn't mention the path %q", err, fe.Path)
+				}
+			}
+			if diff := cmp.Diff(test.wantPaths, gotPaths); diff != "" {
+				t.Fatalf("unexpected error paths (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestNewConfigSkipsUnsupportedFallbackServer verifies that a fallback server
+// without supported channel creds doesn't fail the bootstrap.
+func TestNewConfigSkipsUnsupportedFallbackServer(t *testing.T) {
+	c, err := NewConfigFromContents([]byte(`
+	{
+		"xds_servers" : [
+			{
+				"server_uri": "trafficdirector.googleapis.com:443",
+				"channel_creds": [{ "type": "insecure" }]
+			},
+			{
+				"server_uri": "backup.never.use.com:1234",
+				"channel_creds": [{ "type": "not-google-default" }]
+			},
+			{
+				"server_uri": "backup.com:1234",
+				"channel_creds": [{ "type": "insecure" }]
+			}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("NewConfigFromContents() failed: %v", err)
+	}
+	if len(c.FallbackServers) != 1 || c.FallbackServers[0].ServerURI != "backup.com:1234" {
+		t.Fatalf("NewConfigFromContents() returned fallback servers %v, want only backup.com:1234", c.FallbackServers)
+	}
+}
+
+// TestNewConfigWithIgnoreResourceDeletion verifies that the
+// ignore_resource_deletion server feature is parsed, and that it tells apart
+// the configs of otherwise identical servers.
+func TestNewConfigWithIgnoreResourceDeletion(t *testing.T) {
+	const bootstrapFormat = `
+	{
+		"xds_servers" : [{
+			"server_uri": "trafficdirector.googleapis.com:443",
+			"channel_creds": [{ "type": "insecure" }],
+			"server_features": [%s]
+		}]
+	}`
+
+	tests := []struct {
+		name         string
+		features     string
+		wantFeatures []string
+		wantIgnore   bool
+	}{
+		{name: "noFeatures", features: ``},
+		{name: "otherFeatures", features: `"xds_v3"`, wantFeatures: []string{"xds_v3"}},
+		{
+			name:         "ignoreResourceDeletion",
+			features:     `"xds_v3", "ignore_resource_deletion"`,
+			wantFeatures: []string{"xds_v3", "ignore_resource_deletion"},
+			wantIgnore:   true,
+		},
+	}
+
+	configStrings := make(map[string]bool)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := NewConfigFromContents([]byte(fmt.Sprintf(bootstrapFormat, test.features)))
+			if err != nil {
+				t.Fatalf("NewConfigFromContents() failed: %v", err)
+			}
+			if diff := cmp.Diff(test.wantFeatures, c.XDSServer.ServerFeatures, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("unexpected server features (-want +got):\n%s", diff)
+			}
+			if c.XDSServer.IgnoreResourceDeletion != test.wantIgnore {
+				t.Errorf("IgnoreResourceDeletion = %v, want %v", c.XDSServer.IgnoreResourceDeletion, test.wantIgnore)
+			}
+			configStrings[c.XDSServer.String()] = true
+		})
+	}
+	// noFeatures and otherFeatures differ by their transport API.
+	if len(configStrings) != len(tests) {
+		t.Errorf("got server config strings %v, want %d different ones", configStrings, len(tests))
+	}
+}
+
+// TestNewConfigWithDeltaADS verifies that the delta_ads server feature is
+// parsed, and that it tells apart the configs of otherwise identical servers.
+func TestNewConfigWithDeltaADS(t *testing.T) {
+	const bootstrapFormat = `
+	{
+		"xds_servers" : [{
+			"server_uri": "trafficdirector.googleapis.com:443",
+			"channel_creds": [{ "type": "insecure" }],
+			"server_features": [%s]
+		}]
+	}`
+
+	sotw, err := NewConfigFromContents([]byte(fmt.Sprintf(bootstrapFormat, `"xds_v3"`)))
+	if err != nil {
+		t.Fatalf("NewConfigFromContents() failed: %v", err)
+	}
+	if sotw.XDSServer.DeltaADS {
+		t.Errorf("DeltaADS = true without the delta_ads feature, want false")
+	}
+
+	delta, err := NewConfigFromContents([]byte(fmt.Sprintf(bootstrapFormat, `"xds_v3", "delta_ads"`)))
+	if err != nil {
+		t.Fatalf("NewConfigFromContents() failed: %v", err)
+	}
+	if !delta.XDSServer.DeltaADS {
+		t.Errorf("DeltaADS = false with the delta_ads feature, want true")
+	}
+	if sotw.XDSServer.String() == delta.XDSServer.String() {
+		t.Errorf("the configs with and without delta_ads have the same string %q", sotw.XDSServer.String())
+	}
+}
+
+// countingCreds are insecure transport credentials counting the client
+// handshakes.
+type countingCreds struct {
+	credentials.TransportCredentials
+	handshakes *int32
+}
+
+func (c countingCreds) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	atomic.AddInt32(c.handshakes, 1)
+	return c.TransportCredentials.ClientHandshake(ctx, authority, conn)
+}
+
+// TestRegisterChannelCredentials verifies that a registered channel_creds type
+// is built from its config, and that the connections to the server use the
+// credentials it builds.
+func TestRegisterChannelCredentials(t *testing.T) {
+	const credsType = "fake-spiffe"
+	var handshakes int32
+	RegisterChannelCredentials(credsType, func(config json.RawMessage) (credentials.TransportCredentials, error) {
+		var cfg struct {
+			TrustDomain string `json:"trust_domain"`
+		}
+		if err := json.Unmarshal(config, &cfg); err != nil {
+			return nil, err
+		}
+		if cfg.TrustDomain == "" {
+			return nil, errors.New("trust_domain is required")
+		}
+		return countingCreds{TransportCredentials: insecure.NewCredentials(), handshakes: &handshakes}, nil
+	})
+	defer UnregisterChannelCredentialsForTesting(credsType)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("net.Listen() failed: %v", err)
+	}
+	s := grpc.NewServer()
+	defer s.Stop()
+	go s.Serve(lis)
+
+	const bootstrapFormat = `
+	{
+		"xds_servers" : [{
+			"server_uri": %q,
+			"channel_creds": [
+				{ "type": "not-registered" },
+				{ "type": "fake-spiffe", "config": %s }
+			]
+		}]
+	}`
+
+	_, err = NewConfigFromContents([]byte(fmt.Sprintf(bootstrapFormat, lis.Addr().String(), `{}`)))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Path != "xds_servers[0].channel_creds[1].config" {
+		t.Fatalf("NewConfigFromContents() with an invalid creds config returned error %v, want one for xds_servers[0].channel_creds[1].config", err)
+	}
+
+	c, err := NewConfigFromContents([]byte(fmt.Sprintf(bootstrapFormat, lis.Addr().String(), `{"trust_domain": "example.org"}`)))
+	if err != nil {
+		t.Fatalf("NewConfigFromContents() failed: %v", err)
+	}
+	if c.XDSServer.CredsType != credsType {
+		t.Fatalf("CredsType = %q, want %q", c.XDSServer.CredsType, credsType)
+	}
+
+	cc, err := grpc.Dial(c.XDSServer.ServerURI, c.XDSServer.Creds)
+	if err != nil {
+		t.Fatalf("grpc.Dial() failed: %v", err)
+	}
+	defer cc.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cc.Connect()
+	for state := cc.GetState(); state != connectivity.Ready; state = cc.GetState() {
+		if !cc.WaitForStateChange(ctx, state) {
+			t.Fatalf("timeout waiting for the connection to be ready, last state %v", state)
+		}
+	}
+	if atomic.LoadInt32(&handshakes) == 0 {
+		t.Fatal("the connection didn't use the registered channel creds")
+	}
+}
@@ -0,0 +1,65 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+import (
+	"google.golang.org/grpc/credentials"
+)
+
+// channelCredsBuilders are the builders of the channel_creds types registered
+// with RegisterChannelCredentials.
+var channelCredsBuilders = make(map[string]func(config json.RawMessage) (credentials.TransportCredentials, error))
+
+// RegisterChannelCredentials registers the builder of a custom channel_creds
+// type, e.g. for SPIFFE based mTLS to the management server. A server whose
+// channel_creds list typeName connects with the transport credentials built
+// from the config of the entry, nil if it has none. An error returned by the
+// builder fails the bootstrap.
+//
+// The built-in types, "google_default" and "insecure", can't be replaced.
+//
+// NOTE: this function must only be called during initialization time (i.e. in
+// an init() function), and is not thread-safe. If multiple builders are
+// registered for the same type, the one registered last will take effect.
+func RegisterChannelCredentials(typeName string, builder func(config json.RawMessage) (credentials.TransportCredentials, error)) {
+	channelCredsBuilders[typeName] = builder
+}
+
+// UnregisterChannelCredentialsForTesting unregisters the channel_creds type
+// typeName for testing purposes.
+func UnregisterChannelCredentialsForTesting(typeName string) {
+	delete(channelCredsBuilders, typeName)
+}
+
+// supportedChannelCreds returns the supported channel_creds types, built-in and
+// registered, sorted.
+func supportedChannelCreds() []string {
+	types := []string{credsGoogleDefault, credsInsecure}
+	for t := range channelCredsBuilders {
+		if t != credsGoogleDefault && t != credsInsecure {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bootstrap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldError is the failure of a bootstrap field to validate.
+type FieldError struct {
+	// Path is the JSON path of the offending field, e.g.
+	// "xds_servers[0].channel_creds[0].type". The keys of JSON objects with
+	// arbitrary names, like authorities, are quoted in brackets, e.g.
+	// `authorities["xds.td.com"]`.
+	Path string
+	// Reason is why the field is invalid.
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+}
+
+// ValidationError aggregates all the field errors found in a bootstrap
+// config, so that a hand-edited bootstrap can be fixed in one go. The
+// individual FieldErrors can be retrieved with errors.As, or by ranging over
+// Errors.
+type ValidationError struct {
+	// Errors are sorted by path.
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		msgs = append(msgs, fe.Error())
+	}
+	return fmt.Sprintf("xds: invalid bootstrap config: %s", strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the field errors.
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		errs = append(errs, fe)
+	}
+	return errs
+}
+
+// fieldErrors collects the field errors found while parsing a bootstrap
+// config.
+type fieldErrors struct {
+	errs []*FieldError
+}
+
+func (fe *fieldErrors) add(path, format string, args ...any) {
+	fe.errs = append(fe.errs, &FieldError{Path: path, Reason: fmt.Sprintf(format, args...)})
+}
+
+// count returns the number of errors collected so far, for the callers to
+// tell whether a part of the config added any.
+func (fe *fieldErrors) count() int {
+	return len(fe.errs)
+}
+
+// err returns a *ValidationError with the collected errors, or nil if there
+// are none.
+func (fe *fieldErrors) err() error {
+	if len(fe.errs) == 0 {
+		return nil
+	}
+	errs := append([]*FieldError(nil), fe.errs...)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	return &ValidationError{Errors: errs}
+}
+
+// fieldPath returns the path of the field named name in the object at
+// parent.
+func fieldPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// indexPath returns the path of the i-th element of the array at parent.
+func indexPath(parent string, i int) string {
+	return fmt.Sprintf("%s[%d]", parent, i)
+}
+
+// keyPath returns the path of the value of key in the object with arbitrary
+// keys at parent.
+func keyPath(parent, key string) string {
+	return fmt.Sprintf("%s[%q]", parent, key)
+}
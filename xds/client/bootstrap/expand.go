@@ -0,0 +1,92 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bootstrap
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+import (
+	v3corepb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/envconfig"
+)
+
+// expandNodeEnv substitutes the environment variables referenced in the id
+// and the cluster of node, the node at path, so that a single bootstrap can
+// embed e.g. the pod name and namespace, as expanded by expandEnv.
+func expandNodeEnv(node *v3corepb.Node, path string, errs *fieldErrors) {
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{
+		{"id", &node.Id},
+		{"cluster", &node.Cluster},
+	} {
+		expanded, err := expandEnv(*field.value, envconfig.XDSBootstrapNodeEnvStrict)
+		if err != nil {
+			errs.add(fieldPath(path, field.name), "%v", err)
+			continue
+		}
+		*field.value = expanded
+	}
+}
+
+// expandEnv replaces each ${NAME} in s with the value of the environment
+// variable NAME, and each $$ with a literal $. A $ followed by anything else
+// is kept as is. If strict is set, referencing an unset variable is an error,
+// otherwise it expands to an empty string.
+func expandEnv(s string, strict bool) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", s)
+			}
+			name := s[i+2 : i+2+end]
+			if name == "" {
+				return "", fmt.Errorf("empty variable name in %q", s)
+			}
+			value, ok := os.LookupEnv(name)
+			if !ok && strict {
+				return "", fmt.Errorf("environment variable %s referenced in %q is not set", name, s)
+			}
+			b.WriteString(value)
+			i += 2 + end
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bootstrap
+
+import (
+	"errors"
+	"testing"
+)
+
+import (
+	v3corepb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/envconfig"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("POD_NAME", "foo-7d9f")
+	t.Setenv("POD_NAMESPACE", "prod")
+	t.Setenv("EMPTY", "")
+
+	tests := []struct {
+		name    string
+		s       string
+		strict  bool
+		want    string
+		wantErr bool
+	}{
+		{name: "no reference", s: "sidecar~10.0.0.1", strict: true, want: "sidecar~10.0.0.1"},
+		{name: "resolved", s: "sidecar~${POD_NAME}.${POD_NAMESPACE}", strict: true, want: "sidecar~foo-7d9f.prod"},
+		{name: "set but empty", s: "a${EMPTY}b", strict: true, want: "ab"},
+		{name: "missing strict", s: "sidecar~${MISSING_VAR}", strict: true, wantErr: true},
+		{name: "missing lenient", s: "sidecar~${MISSING_VAR}", want: "sidecar~"},
+		{name: "escaped dollar", s: "$${POD_NAME}", strict: true, want: "${POD_NAME}"},
+		{name: "literal dollar", s: "cost$5$", strict: true, want: "cost$5$"},
+		{name: "unterminated", s: "${POD_NAME", strict: true, wantErr: true},
+		{name: "empty name", s: "${}", strict: true, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := expandEnv(test.s, test.strict)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("expandEnv(%q, %v) returned error %v, wantErr: %v", test.s, test.strict, err, test.wantErr)
+			}
+			if got != test.want {
+				t.Fatalf("expandEnv(%q, %v) = %q, want %q", test.s, test.strict, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewConfigExpandsNodeEnv(t *testing.T) {
+	const bootstrap = `
+	{
+		"node": {
+			"id": "sidecar~${POD_NAME}.${POD_NAMESPACE}",
+			"cluster": "${CLUSTER}"
+		},
+		"xds_servers" : [{
+			"server_uri": "trafficdirector.googleapis.com:443",
+			"channel_creds": [{ "type": "insecure" }],
+			"server_features" : ["xds_v3"]
+		}]
+	}`
+	t.Setenv("POD_NAME", "foo-7d9f")
+	t.Setenv("POD_NAMESPACE", "prod")
+	t.Setenv("CLUSTER", "foo")
+
+	c, err := NewConfigFromContents([]byte(bootstrap))
+	if err != nil {
+		t.Fatalf("NewConfigFromContents() failed: %v", err)
+	}
+	node := c.XDSServer.NodeProto.(*v3corepb.Node)
+	if node.GetId() != "sidecar~foo-7d9f.prod" || node.GetCluster() != "foo" {
+		t.Fatalf("NewConfigFromContents() returned node id %q and cluster %q, want sidecar~foo-7d9f.prod and foo", node.GetId(), node.GetCluster())
+	}
+}
+
+func TestNewConfigMissingNodeEnv(t *testing.T) {
+	const bootstrap = `
+	{
+		"node": {
+			"id": "sidecar~${MISSING_POD_NAME}",
+			"cluster": "foo"
+		},
+		"xds_servers" : [{
+			"server_uri": "trafficdirector.googleapis.com:443",
+			"channel_creds": [{ "type": "insecure" }],
+			"server_features" : ["xds_v3"]
+		}]
+	}`
+
+	_, err := NewConfigFromContents([]byte(bootstrap))
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Errors) != 1 || verr.Errors[0].Path != "node.id" {
+		t.Fatalf("NewConfigFromContents() returned error %v, want a single error at node.id", err)
+	}
+
+	oldStrict := envconfig.XDSBootstrapNodeEnvStrict
+	envconfig.XDSBootstrapNodeEnvStrict = false
+	defer func() { envconfig.XDSBootstrapNodeEnvStrict = oldStrict }()
+	c, err := NewConfigFromContents([]byte(bootstrap))
+	if err != nil {
+		t.Fatalf("NewConfigFromContents() failed without strictness: %v", err)
+	}
+	if id := c.XDSServer.NodeProto.(*v3corepb.Node).GetId(); id != "sidecar~" {
+		t.Fatalf("NewConfigFromContents() returned node id %q, want sidecar~", id)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"regexp"
+)
+
+// NameFilter selects resources by name with allow and deny lists of regular
+// expressions, with the RE2 syntax. A name is selected if it matches none of
+// the Deny expressions and, unless Allow is empty, one of the Allow ones. The
+// expressions must match the whole name, e.g. "outbound\|.*\|foo" doesn't
+// match "outbound|80||foo.bar".
+type NameFilter struct {
+	Allow []*regexp.Regexp
+	Deny  []*regexp.Regexp
+}
+
+// Match reports whether name is selected by f. A nil filter selects all the
+// names.
+func (f *NameFilter) Match(name string) bool {
+	if f == nil {
+		return true
+	}
+	for _, re := range f.Deny {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	if len(f.Allow) == 0 {
+		return true
+	}
+	for _, re := range f.Allow {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseNameFilter parses the name filter at path, an object with optional
+// "allow" and "deny" arrays of regular expressions.
+func parseNameFilter(data json.RawMessage, path string, errs *fieldErrors) *NameFilter {
+	var fields struct {
+		Allow []json.RawMessage `json:"allow"`
+		Deny  []json.RawMessage `json:"deny"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		errs.add(path, "must be an object with arrays of regular expressions")
+		return nil
+	}
+	return &NameFilter{
+		Allow: parseRegexps(fields.Allow, fieldPath(path, "allow"), errs),
+		Deny:  parseRegexps(fields.Deny, fieldPath(path, "deny"), errs),
+	}
+}
+
+// parseRegexps compiles the regular expressions of the array at path, each
+// anchored to match whole names.
+func parseRegexps(exprs []json.RawMessage, path string, errs *fieldErrors) []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for i, raw := range exprs {
+		var expr string
+		if !parseString(raw, indexPath(path, i), errs, &expr) {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			errs.add(indexPath(path, i), "invalid regular expression %q: %v", expr, err)
+			continue
+		}
+		res = append(res, re)
+	}
+	return res
+}
@@ -0,0 +1,101 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bootstrap
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewConfigWithClusterNameFilter(t *testing.T) {
+	c, err := NewConfigFromContents([]byte(`
+	{
+		"xds_servers" : [{
+			"server_uri": "trafficdirector.googleapis.com:443",
+			"channel_creds": [{ "type": "insecure" }]
+		}],
+		"cluster_name_filter": {
+			"allow": ["outbound\\|.*\\|foo\\..*", "inbound\\|.*"],
+			"deny": ["outbound\\|.*\\|foo\\.canary"]
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("NewConfigFromContents() failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"outbound|80||foo.default", true},
+		{"inbound|20000||", true},
+		{"outbound|80||bar.default", false},
+		{"outbound|80||foo.canary", false},
+		// The expressions match whole names.
+		{"xoutbound|80||foo.default", false},
+	}
+	for _, test := range tests {
+		if got := c.ClusterNameFilter.Match(test.name); got != test.want {
+			t.Errorf("ClusterNameFilter.Match(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNewConfigWithoutClusterNameFilter(t *testing.T) {
+	c, err := NewConfigFromContents([]byte(`
+	{
+		"xds_servers" : [{
+			"server_uri": "trafficdirector.googleapis.com:443",
+			"channel_creds": [{ "type": "insecure" }]
+		}]
+	}`))
+	if err != nil {
+		t.Fatalf("NewConfigFromContents() failed: %v", err)
+	}
+	if c.ClusterNameFilter != nil {
+		t.Fatalf("NewConfigFromContents() returned cluster name filter %+v, want nil", c.ClusterNameFilter)
+	}
+	if !c.ClusterNameFilter.Match("outbound|80||foo.default") {
+		t.Fatal("nil NameFilter rejected a name")
+	}
+}
+
+func TestNewConfigWithInvalidClusterNameFilter(t *testing.T) {
+	_, err := NewConfigFromContents([]byte(`
+	{
+		"xds_servers" : [{
+			"server_uri": "trafficdirector.googleapis.com:443",
+			"channel_creds": [{ "type": "insecure" }]
+		}],
+		"cluster_name_filter": {
+			"allow": ["foo", "(bar"],
+			"deny": [42]
+		}
+	}`))
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("NewConfigFromContents() returned error %v, want a *ValidationError", err)
+	}
+	var paths []string
+	for _, fe := range verr.Errors {
+		paths = append(paths, fe.Path)
+	}
+	if len(paths) != 2 || paths[0] != "cluster_name_filter.allow[1]" || paths[1] != "cluster_name_filter.deny[0]" {
+		t.Fatalf("NewConfigFromContents() returned errors at %q, want cluster_name_filter.allow[1] and cluster_name_filter.deny[0]", paths)
+	}
+}
@@ -26,7 +26,10 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
@@ -34,16 +37,28 @@ import (
 import (
 	dubbogoLogger "github.com/dubbogo/gost/log/logger"
 
+	"github.com/golang/protobuf/proto"
 	_struct "github.com/golang/protobuf/ptypes/struct"
 )
 
 import (
 	"dubbo.apache.org/dubbo-go/v3/xds/client/bootstrap"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/controller"
 	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
 	"dubbo.apache.org/dubbo-go/v3/xds/utils/grpcsync"
 	cache "dubbo.apache.org/dubbo-go/v3/xds/utils/xds_cache"
 )
 
+// ErrAuthorityNotFound is returned when a resource name refers to an
+// authority missing from the bootstrap configuration. The returned errors wrap
+// it with the authority name and the known authorities, and can be matched by
+// errors.Is.
+var ErrAuthorityNotFound = errors.New("xds: authority not found in bootstrap configuration")
+
+// ErrClientClosed is passed to the callbacks of the watches still active when
+// the client is closed. It's the last callback of those watches.
+var ErrClientClosed = errors.New("xds: client closed")
+
 // clientImpl is the real implementation of the xds client. The exported Client
 // is a wrapper of this struct with a ref count.
 //
@@ -56,6 +71,16 @@ type clientImpl struct {
 	config                *bootstrap.Config
 	refreshMetadataCancel func()
 
+	// metadataMu serializes the metadata updates, and protects metadata, the
+	// node metadata last set, before the resolution of the ${VAR} references.
+	metadataMu sync.Mutex
+	metadata   *_struct.Struct
+
+	// warmMu protects warmWatches, the watches started by Warm, by resource
+	// type and name.
+	warmMu      sync.Mutex
+	warmWatches map[string]*warmWatch
+
 	// authorityMu protects the authority fields. It's necessary because an
 	// authority is created when it's used.
 	authorityMu sync.Mutex
@@ -70,25 +95,67 @@ type clientImpl struct {
 	authorities map[string]*authority
 	// idleAuthorities keeps the authorities that are not used (the last
 	// watch on it was canceled). They are kept in the cache and will be deleted
-	// after a timeout. The key is ServerConfig.String().
+	// after a jittered timeout. The key is ServerConfig.String().
 	//
 	// An authority is either in authorities, or idleAuthorities,
 	// never both.
 	idleAuthorities *cache.TimeoutCache
 
-	logger             dubbogoLogger.Logger
+	// logger drops the logs below the level set by WithLogLevel or
+	// SetLogLevel.
+	logger *levelLogger
+
+	// watchExpiryMu protects watchExpiryTimeout, which can be updated at
+	// runtime by SetWatchExpiryTimeout.
+	watchExpiryMu      sync.Mutex
 	watchExpiryTimeout time.Duration
+
+	// ackCallback is set on the controllers of all the authorities, it's
+	// protected by authorityMu.
+	ackCallback func(typeURL, version, nonce string, err error)
+	// maxStaleness is set on the pubsubs of all the authorities, it's
+	// protected by authorityMu.
+	maxStaleness map[resource.ResourceType]time.Duration
+
+	// endpointsDebounce is the window set by WithEndpointsDebounce.
+	endpointsDebounce time.Duration
+	// watchExpiryTimeouts are the per type timeouts set by
+	// WithWatchExpiryTimeouts, taking precedence over watchExpiryTimeout.
+	watchExpiryTimeouts map[resource.ResourceType]time.Duration
+	// ackPolicy is the policy set by WithAckPolicy on the controllers of all
+	// the authorities.
+	ackPolicy controller.AckPolicy
+	// validation is the report of the resources received, it's set only if
+	// the client is created with WithValidateOnly.
+	validation *validationReport
 }
 
-// newWithConfig returns a new xdsClient with the given config.
-func newWithConfig(config *bootstrap.Config, watchExpiryTimeout time.Duration, idleAuthorityDeleteTimeout time.Duration) (_ *clientImpl, retErr error) {
+// newWithConfig returns a new xdsClient with the given config. The idle
+// authorities are deleted after idleAuthorityDeleteTimeout plus a random part
+// of up to idleAuthorityDeleteJitter times it.
+func newWithConfig(config *bootstrap.Config, watchExpiryTimeout time.Duration, idleAuthorityDeleteTimeout time.Duration,
+	idleAuthorityDeleteJitter float64, opts ...Option) (_ *clientImpl, retErr error) {
+	o := clientOptions{logger: dubbogoLogger.GetLogger()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	c := &clientImpl{
 		done:               grpcsync.NewEvent(),
 		config:             config,
 		watchExpiryTimeout: watchExpiryTimeout,
 
 		authorities:     make(map[string]*authority),
-		idleAuthorities: cache.NewTimeoutCache(idleAuthorityDeleteTimeout),
+		idleAuthorities: cache.NewTimeoutCacheWithJitter(idleAuthorityDeleteTimeout, idleAuthorityDeleteJitter),
+		maxStaleness:    make(map[resource.ResourceType]time.Duration),
+		logger:          newLevelLogger(o.logger, o.level),
+
+		endpointsDebounce:   o.endpointsDebounce,
+		watchExpiryTimeouts: o.watchExpiryTimeouts,
+		ackPolicy:           o.ackPolicy,
+	}
+	if o.validateOnly {
+		c.validation = newValidationReport()
 	}
 
 	defer func() {
@@ -97,25 +164,180 @@ func newWithConfig(config *bootstrap.Config, watchExpiryTimeout time.Duration, i
 		}
 	}()
 
-	c.logger = dubbogoLogger.GetLogger()
 	c.logger.Infof("Created ClientConn to xDS management server: %s", config.XDSServer)
 
 	c.logger.Infof("Created")
 	return c, nil
 }
 
+// SetMetadata sets the node metadata sent to the management server of the
+// default authority. The ${VAR} references in string values are resolved from
+// the environment, see ResolveMetadataEnv.
 func (c *clientImpl) SetMetadata(m *_struct.Struct) error {
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	return c.setMetadataLocked(m)
+}
+
+// UpdateMetadata calls update with a copy of the node metadata last set, and
+// sets the result like SetMetadata, so that several fields are changed with a
+// single flush to the management server, see BatchMetadata. The updates are
+// serialized, update is called with an empty metadata the first time.
+func (c *clientImpl) UpdateMetadata(update func(*_struct.Struct)) error {
+	c.metadataMu.Lock()
+	defer c.metadataMu.Unlock()
+	m := &_struct.Struct{}
+	if c.metadata != nil {
+		m = proto.Clone(c.metadata).(*_struct.Struct)
+	}
+	if m.Fields == nil {
+		m.Fields = make(map[string]*_struct.Value)
+	}
+	update(m)
+	return c.setMetadataLocked(m)
+}
+
+// setMetadataLocked sets and flushes the node metadata of the default
+// authority.
+//
+// Caller must hold c.metadataMu.
+func (c *clientImpl) setMetadataLocked(m *_struct.Struct) error {
 	a, _, err := c.findAuthority(resource.ParseName(""))
 	if err != nil {
 		return err
 	}
-	if err := a.SetMetadata(m); err != nil {
+	if err := a.SetMetadata(ResolveMetadataEnv(m)); err != nil {
 		return err
 	}
-	a.watchEndpoints("", func(update resource.EndpointsUpdate, err error) {})
+	c.metadata = m
+	a.flushMetadata()
 	return nil
 }
 
+// WaitForReady blocks until the ADS stream to the default management server
+// has received its first response, the client is closed, or ctx is done. The
+// server only responds to the watches, so it blocks until a resource is
+// watched when nothing is.
+func (c *clientImpl) WaitForReady(ctx context.Context) error {
+	a, unref, err := c.findAuthority(resource.ParseName(""))
+	if err != nil {
+		return err
+	}
+	defer unref()
+
+	select {
+	case <-a.ready():
+		return nil
+	case <-c.done.Done():
+		return errors.New("the xds-client is closed")
+	case <-ctx.Done():
+		return fmt.Errorf("xds: waiting for management server %s: %w", c.config.XDSServer, ctx.Err())
+	}
+}
+
+// ActiveServer returns the management server the default authority is
+// connected to. It's the first server in the bootstrap file, unless the client
+// failed over to one of the fallback servers.
+func (c *clientImpl) ActiveServer() *bootstrap.ServerConfig {
+	c.authorityMu.Lock()
+	defer c.authorityMu.Unlock()
+	if a, ok := c.authorities[c.config.XDSServer.String()]; ok {
+		return a.controller.ActiveServer()
+	}
+	return c.config.XDSServer
+}
+
+// SetAckCallback sets the callback called for every ACK and NACK sent by the
+// authorities, in use, idle or created later, e.g. to record the versions the
+// client rejects. nil removes it.
+func (c *clientImpl) SetAckCallback(cb func(typeURL, version, nonce string, err error)) {
+	c.authorityMu.Lock()
+	defer c.authorityMu.Unlock()
+	c.ackCallback = cb
+	for _, a := range c.authorities {
+		a.controller.SetAckCallback(cb)
+	}
+	for _, item := range c.idleAuthorities.Items() {
+		if a, ok := item.(*authority); ok {
+			a.controller.SetAckCallback(cb)
+		}
+	}
+}
+
+// SetMaxStaleness sets the max staleness of the resources of type rType, for
+// the authorities in use, idle or created later. When a resource isn't updated
+// by its management server for longer than d, its watchers get an error of
+// type resource.ErrorTypeResourceStale, the resource is not removed. d <= 0
+// disables the check, which is the default.
+func (c *clientImpl) SetMaxStaleness(rType resource.ResourceType, d time.Duration) {
+	c.authorityMu.Lock()
+	defer c.authorityMu.Unlock()
+	if d <= 0 {
+		delete(c.maxStaleness, rType)
+	} else {
+		c.maxStaleness[rType] = d
+	}
+	for _, a := range c.authorities {
+		a.pubsub.SetMaxStaleness(rType, d)
+	}
+	for _, item := range c.idleAuthorities.Items() {
+		if a, ok := item.(*authority); ok {
+			a.pubsub.SetMaxStaleness(rType, d)
+		}
+	}
+}
+
+// SetLogLevel sets the minimum level of the logs of the client, e.g.
+// LogLevelDebug to see every ADS message while debugging. It applies to all
+// the logs from now on.
+func (c *clientImpl) SetLogLevel(level LogLevel) {
+	c.logger.setLevel(level)
+}
+
+// SetWatchExpiryTimeout updates the watch expiry timeout. It applies to the
+// watches started after this call, the timers of existing watches keep their
+// original deadline. The types set by WithWatchExpiryTimeouts keep their own
+// timeout.
+func (c *clientImpl) SetWatchExpiryTimeout(d time.Duration) {
+	c.watchExpiryMu.Lock()
+	c.watchExpiryTimeout = d
+	c.watchExpiryMu.Unlock()
+
+	c.authorityMu.Lock()
+	defer c.authorityMu.Unlock()
+	for _, a := range c.authorities {
+		a.pubsub.SetWatchExpiryTimeout(c.pubsubWatchExpiryTimeout())
+	}
+}
+
+// pubsubWatchExpiryTimeout returns the watch expiry timeout of the pubsubs,
+// which is disabled in validate only mode, since the watches never receive
+// their resource.
+func (c *clientImpl) pubsubWatchExpiryTimeout() time.Duration {
+	if c.validation != nil {
+		return 0
+	}
+	return c.WatchExpiryTimeout()
+}
+
+// WatchExpiryTimeout returns the current watch expiry timeout.
+func (c *clientImpl) WatchExpiryTimeout() time.Duration {
+	c.watchExpiryMu.Lock()
+	defer c.watchExpiryMu.Unlock()
+	return c.watchExpiryTimeout
+}
+
+// knownAuthorities returns the sorted names of the authorities in the
+// bootstrap configuration.
+func (c *clientImpl) knownAuthorities() []string {
+	names := make([]string, 0, len(c.config.Authorities))
+	for name := range c.config.Authorities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // BootstrapConfig returns the configuration read from the bootstrap file.
 // Callers must treat the return value as read-only.
 func (c *clientRefCounted) BootstrapConfig() *bootstrap.Config {
@@ -123,25 +345,33 @@ func (c *clientRefCounted) BootstrapConfig() *bootstrap.Config {
 }
 
 // Close closes the gRPC connection to the management server.
+//
+// The callbacks of the watches still active are invoked once with
+// ErrClientClosed, so that the watchers can stop waiting for updates.
 func (c *clientImpl) Close() {
 	if c.done.HasFired() {
 		return
 	}
 	c.done.Fire()
-	// TODO: Should we invoke the registered callbacks here with an error that
-	// the client is closed?
 
 	// Note that Close needs to check for nils even if some of them are always
 	// set in the constructor. This is because the constructor defers Close() in
 	// error cases, and the fields might not be set when the error happens.
 
 	c.authorityMu.Lock()
+	authorities := make([]*authority, 0, len(c.authorities))
 	for _, a := range c.authorities {
-		a.close()
+		authorities = append(authorities, a)
 	}
 	c.idleAuthorities.Clear(true)
 	c.authorityMu.Unlock()
 
+	// The callbacks are invoked without c.authorityMu, because canceling a
+	// watch from the callback unrefs the authority.
+	for _, a := range authorities {
+		a.closeWithError(ErrClientClosed)
+	}
+
 	c.logger.Infof("Shutdown")
 }
 
@@ -169,6 +399,20 @@ func (c *clientImpl) securityConfigUpdateValidator(sc *resource.SecurityConfig)
 	return nil
 }
 
+// clusterSecurityConfigUpdateValidator validates the security configuration
+// of a cluster, which is used on the client-side. A cluster without a root
+// certificate provider instance verifies the server certificates against the
+// system root certificates, which the bootstrap file must allow explicitly.
+func (c *clientImpl) clusterSecurityConfigUpdateValidator(sc *resource.SecurityConfig) error {
+	if err := c.securityConfigUpdateValidator(sc); err != nil {
+		return err
+	}
+	if sc != nil && sc.RootInstanceName == "" && !c.config.UseSystemRootCerts {
+		return errors.New("security configuration on the client-side does not contain root certificate provider instance name, and use_system_root_certs is not set in bootstrap configuration")
+	}
+	return nil
+}
+
 func (c *clientImpl) updateValidator(u any) error {
 	switch update := u.(type) {
 	case resource.ListenerUpdate:
@@ -177,7 +421,7 @@ func (c *clientImpl) updateValidator(u any) error {
 		}
 		return update.InboundListenerCfg.FilterChains.Validate(c.filterChainUpdateValidator)
 	case resource.ClusterUpdate:
-		return c.securityConfigUpdateValidator(update.SecurityCfg)
+		return c.clusterSecurityConfigUpdateValidator(update.SecurityCfg)
 	default:
 		// We currently invoke this update validation function only for LDS and
 		// CDS updates. In the future, if we wish to invoke it for other xDS
@@ -0,0 +1,72 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"testing"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/bootstrap"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+	"dubbo.apache.org/dubbo-go/v3/xds/credentials/certprovider"
+)
+
+func TestClusterSecurityConfigUpdateValidator(t *testing.T) {
+	tests := []struct {
+		name               string
+		sc                 *resource.SecurityConfig
+		useSystemRootCerts bool
+		wantErr            bool
+	}{
+		{
+			name: "no security config",
+		},
+		{
+			name: "named root provider",
+			sc:   &resource.SecurityConfig{RootInstanceName: "root"},
+		},
+		{
+			name:    "named root provider missing in bootstrap",
+			sc:      &resource.SecurityConfig{RootInstanceName: "unknown"},
+			wantErr: true,
+		},
+		{
+			name:               "system root certs",
+			sc:                 &resource.SecurityConfig{},
+			useSystemRootCerts: true,
+		},
+		{
+			name:    "neither named root provider nor system root certs",
+			sc:      &resource.SecurityConfig{},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &clientImpl{config: &bootstrap.Config{
+				CertProviderConfigs: map[string]*certprovider.BuildableConfig{"root": nil},
+				UseSystemRootCerts:  test.useSystemRootCerts,
+			}}
+			err := c.updateValidator(resource.ClusterUpdate{SecurityCfg: test.sc})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("updateValidator() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// callbackErrs records the errors passed to the callbacks of a watch.
+type callbackErrs struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (c *callbackErrs) add(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errs = append(c.errs, err)
+}
+
+func (c *callbackErrs) get() []error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]error(nil), c.errs...)
+}
+
+func TestCloseNotifiesWatchers(t *testing.T) {
+	c, _ := newFakeControllerClient(t)
+
+	var cluster, endpoints, canceled, selfCancel callbackErrs
+	c.WatchCluster("cds-a", func(_ resource.ClusterUpdate, err error) { cluster.add(err) })
+	c.WatchEndpoints("eds-a", func(_ resource.EndpointsUpdate, err error) { endpoints.add(err) })
+	cancel := c.WatchCluster("cds-b", func(_ resource.ClusterUpdate, err error) { canceled.add(err) })
+	cancel()
+	// Canceling the watch from its callback must not deadlock.
+	var cancelSelf func()
+	cancelSelf = c.WatchRouteConfig("route-a", func(_ resource.RouteConfigUpdate, err error) {
+		selfCancel.add(err)
+		cancelSelf()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		c.Close()
+		// Close is idempotent, and doesn't invoke the callbacks again.
+		c.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for Close")
+	}
+
+	for name, w := range map[string]*callbackErrs{"cds-a": &cluster, "eds-a": &endpoints, "route-a": &selfCancel} {
+		errs := w.get()
+		if len(errs) != 1 || !errors.Is(errs[0], ErrClientClosed) {
+			t.Errorf("%s watch got errors %v, want ErrClientClosed once", name, errs)
+		}
+	}
+	if errs := canceled.get(); len(errs) != 0 {
+		t.Errorf("canceled watch got errors %v, want none", errs)
+	}
+
+	// A watch started after Close fails right away.
+	var after callbackErrs
+	c.WatchCluster("cds-c", func(_ resource.ClusterUpdate, err error) { after.add(err) })
+	if errs := after.get(); len(errs) != 1 || errs[0] == nil {
+		t.Errorf("watch started after Close got errors %v, want one error", errs)
+	}
+}
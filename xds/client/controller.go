@@ -42,9 +42,15 @@ type controllerInterface interface {
 	RemoveWatch(resourceType resource.ResourceType, resourceName string)
 	ReportLoad(server string) (*load.Store, func())
 	SetMetadata(m *_struct.Struct) error
+	FlushMetadata()
+	Ready() <-chan struct{}
+	ActiveServer() *bootstrap.ServerConfig
+	Health() controller.Health
+	SetAckCallback(cb controller.AckCallback)
+	SetAckPolicy(p controller.AckPolicy)
 	Close()
 }
 
-var newController = func(config *bootstrap.ServerConfig, pubsub *pubsub.Pubsub, validator resource.UpdateValidatorFunc, logger dubbogoLogger.Logger) (controllerInterface, error) {
-	return controller.New(config, pubsub, validator, logger)
+var newController = func(config *bootstrap.ServerConfig, fallbacks []*bootstrap.ServerConfig, updateHandler pubsub.UpdateHandler, validator resource.UpdateValidatorFunc, logger dubbogoLogger.Logger) (controllerInterface, error) {
+	return controller.New(config, updateHandler, validator, logger, fallbacks...)
 }
@@ -0,0 +1,137 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"fmt"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+	resourceversion "dubbo.apache.org/dubbo-go/v3/xds/client/resource/version"
+)
+
+// AckCallback is called for every response acknowledged on the ADS stream,
+// with the type URL, version and nonce of the response. err is the reason of
+// the rejection for a NACK, and nil for an ACK.
+type AckCallback func(typeURL, version, nonce string, err error)
+
+// AckPolicy is how a response carrying invalid resources is acknowledged.
+type AckPolicy int
+
+const (
+	// AckPolicyStrict NACKs a response carrying any invalid resource, the
+	// default.
+	AckPolicyStrict AckPolicy = iota
+	// AckPolicyPermissive ACKs a response carrying invalid resources, only
+	// logging them. The invalid resources are still not applied, the
+	// watchers keep the previous version of them. A response which can't be
+	// parsed at all is still NACKed.
+	AckPolicyPermissive
+)
+
+// String returns the name of the policy.
+func (p AckPolicy) String() string {
+	switch p {
+	case AckPolicyStrict:
+		return "strict"
+	case AckPolicyPermissive:
+		return "permissive"
+	default:
+		return fmt.Sprintf("AckPolicy(%d)", int(p))
+	}
+}
+
+// SetAckPolicy sets how the responses carrying invalid resources are
+// acknowledged, AckPolicyStrict by default.
+func (t *Controller) SetAckPolicy(p AckPolicy) {
+	t.ackMu.Lock()
+	defer t.ackMu.Unlock()
+	t.ackPolicy = p
+}
+
+// acceptInvalid returns whether the error err about the invalid resources of
+// a response is ignored by the ACK policy, logging them if so.
+func (t *Controller) acceptInvalid(rType resource.ResourceType, version string, err error) bool {
+	t.ackMu.Lock()
+	p := t.ackPolicy
+	t.ackMu.Unlock()
+	if p != AckPolicyPermissive {
+		return false
+	}
+	t.logger.Warnf("xds: ignoring invalid resources of type_url: %s, version: %s, under the %v ACK policy: %v", t.typeURL(rType), version, p, err)
+	return true
+}
+
+// SetAckCallback sets the callback called for every ACK and NACK, nil removes
+// it.
+func (t *Controller) SetAckCallback(cb AckCallback) {
+	t.ackMu.Lock()
+	defer t.ackMu.Unlock()
+	t.ackCallback = cb
+}
+
+// reportAck logs the ACK, or the NACK if err is not nil, of a response and
+// calls the ack callback.
+func (t *Controller) reportAck(rType resource.ResourceType, version, nonce string, err error) {
+	typeURL := t.typeURL(rType)
+	if err != nil {
+		t.logger.Warnf("xds: sending NACK, type_url: %s, version: %s, nonce: %s, reason: %v", typeURL, version, nonce, err)
+	} else {
+		t.logger.Infof("xds: sending ACK, type_url: %s, version: %s, nonce: %s", typeURL, version, nonce)
+	}
+
+	t.ackMu.Lock()
+	cb := t.ackCallback
+	t.ackMu.Unlock()
+	if cb != nil {
+		cb(typeURL, version, nonce, err)
+	}
+}
+
+// typeURL returns the type URL of rType in the transport API of the active
+// management server.
+func (t *Controller) typeURL(rType resource.ResourceType) string {
+	t.connMu.Lock()
+	v3 := t.config.TransportAPI == resourceversion.TransportV3
+	t.connMu.Unlock()
+	switch rType {
+	case resource.ListenerResource:
+		if v3 {
+			return resourceversion.V3ListenerURL
+		}
+		return resourceversion.V2ListenerURL
+	case resource.RouteConfigResource:
+		if v3 {
+			return resourceversion.V3RouteConfigURL
+		}
+		return resourceversion.V2RouteConfigURL
+	case resource.ClusterResource:
+		if v3 {
+			return resourceversion.V3ClusterURL
+		}
+		return resourceversion.V2ClusterURL
+	case resource.EndpointsResource:
+		if v3 {
+			return resourceversion.V3EndpointsURL
+		}
+		return resourceversion.V2EndpointsURL
+	default:
+		return rType.String()
+	}
+}
@@ -42,6 +42,7 @@ import (
 
 	v3corepb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 
+	"github.com/golang/protobuf/proto"
 	_struct "github.com/golang/protobuf/ptypes/struct"
 
 	"google.golang.org/grpc"
@@ -55,6 +56,7 @@ import (
 	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
 	"dubbo.apache.org/dubbo-go/v3/xds/utils/backoff"
 	"dubbo.apache.org/dubbo-go/v3/xds/utils/buffer"
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/grpcsync"
 )
 
 // Controller manages the connection and stream to the control plane.
@@ -65,18 +67,49 @@ import (
 // It takes a pubsub (as an interface) as input. When a response is received,
 // it's parsed, and the updates are sent to the pubsub.
 type Controller struct {
-	config          *bootstrap.ServerConfig
+	// servers is the prioritized list of management servers, servers[0] is
+	// the primary one.
+	servers         []*bootstrap.ServerConfig
 	updateHandler   pubsub.UpdateHandler
 	updateValidator resource.UpdateValidatorFunc
 	logger          dubbogoLogger.Logger
 
-	cc               *grpc.ClientConn // Connection to the management server.
-	vClient          version.MetadataWrappedVersionClient
+	// connMu protects the fields below, which are replaced when the
+	// controller fails over to another management server, or when the
+	// metadata is updated.
+	connMu   sync.Mutex
+	config   *bootstrap.ServerConfig // The active management server.
+	active   int                     // Index of config in servers.
+	metadata *_struct.Struct
+	cc       *grpc.ClientConn // Connection to the management server.
+	vClient  version.MetadataWrappedVersionClient
+	// closed is set by Close, the connections made after it are closed
+	// right away.
+	closed bool
+
 	stopRunGoroutine context.CancelFunc
 
-	backoff  func(int) time.Duration
-	streamCh chan grpc.ClientStream
-	sendCh   *buffer.Unbounded
+	// delta is set when the ADS streams use the delta variant of the
+	// protocol. Like for the pubsub, the primary server decides.
+	delta bool
+
+	backoff func(int) time.Duration
+	// healthyDuration is how long an ADS stream must stay up, receiving
+	// responses, for the backoff to be reset.
+	healthyDuration time.Duration
+	streamCh        chan grpc.ClientStream
+	sendCh          *buffer.Unbounded
+	// ready is fired when the first response is received on an ADS stream.
+	ready *grpcsync.Event
+	// healthMu protects health, the state of the ADS stream reported by
+	// Health.
+	healthMu sync.Mutex
+	health   Health
+	// ackMu protects ackCallback, called for every ACK and NACK, and
+	// ackPolicy.
+	ackMu       sync.Mutex
+	ackCallback AckCallback
+	ackPolicy   AckPolicy
 
 	mu sync.Mutex
 	// Message specific watch infos, protected by the above mutex. These are
@@ -93,6 +126,11 @@ type Controller struct {
 	versionMap map[resource.ResourceType]string
 	// nonceMap contains the nonce from the most recent received response.
 	nonceMap map[resource.ResourceType]string
+	// deltaVersions contains the versions of the resources received on delta
+	// streams, per type and name. They are sent as the initial versions of
+	// the resources on new streams, so that only the changed ones are sent
+	// again.
+	deltaVersions map[resource.ResourceType]map[string]string
 
 	// Changes to map lrsClients and the lrsClient inside the map need to be
 	// protected by lrsMu.
@@ -105,7 +143,11 @@ type Controller struct {
 }
 
 // New creates a new controller.
-func New(config *bootstrap.ServerConfig, updateHandler pubsub.UpdateHandler, validator resource.UpdateValidatorFunc, logger dubbogoLogger.Logger) (_ *Controller, retErr error) {
+//
+// The controller connects to config first. When the ADS stream to it keeps
+// failing, it fails over to the fallbacks in order, and fails back to config
+// once it's reachable again.
+func New(config *bootstrap.ServerConfig, updateHandler pubsub.UpdateHandler, validator resource.UpdateValidatorFunc, logger dubbogoLogger.Logger, fallbacks ...*bootstrap.ServerConfig) (_ *Controller, retErr error) {
 	switch {
 	case config == nil:
 		return nil, errors.New("xds: no xds_server provided")
@@ -117,50 +159,41 @@ func New(config *bootstrap.ServerConfig, updateHandler pubsub.UpdateHandler, val
 		return nil, errors.New("xds: no node_proto provided in options")
 	}
 
-	dopts := []grpc.DialOption{
-		config.Creds,
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:    5 * time.Minute,
-			Timeout: 20 * time.Second,
-		}),
-	}
-
 	ret := &Controller{
+		servers:         append([]*bootstrap.ServerConfig{config}, fallbacks...),
 		config:          config,
 		updateValidator: validator,
 		updateHandler:   updateHandler,
 		logger:          logger,
-		backoff:         backoff.DefaultExponential.Backoff, // TODO: should this be configurable?
 		streamCh:        make(chan grpc.ClientStream, 1),
 		sendCh:          buffer.NewUnbounded(),
+		ready:           grpcsync.NewEvent(),
 		watchMap:        make(map[resource.ResourceType]map[string]bool),
 		versionMap:      make(map[resource.ResourceType]string),
 		nonceMap:        make(map[resource.ResourceType]string),
+		delta:           config.DeltaADS,
+		deltaVersions:   make(map[resource.ResourceType]map[string]string),
 
 		lrsClients: make(map[string]*lrsClient),
 	}
 
+	ret.backoff, ret.healthyDuration = backoffFromConfig(config.Backoff)
+
 	defer func() {
 		if retErr != nil {
 			ret.Close()
 		}
 	}()
 
-	cc, err := grpc.Dial(config.ServerURI, dopts...)
-	if err != nil {
-		// An error from a non-blocking dial indicates something serious.
-		return nil, fmt.Errorf("xds: failed to dial control plane {%s}: %v", config.ServerURI, err)
-	}
-	ret.cc = cc
-
-	builder := version.GetAPIClientBuilder(config.TransportAPI)
-	if builder == nil {
-		return nil, fmt.Errorf("no client builder for xDS API version: %v", config.TransportAPI)
-	}
-	apiClient, err := builder(version.BuildOptions{NodeProto: config.NodeProto, Logger: logger})
+	cc, apiClient, err := ret.dial(config, nil)
 	if err != nil {
 		return nil, err
 	}
+	if _, ok := apiClient.(version.DeltaClient); ret.delta && !ok {
+		cc.Close()
+		return nil, fmt.Errorf("xds: delta ADS is not supported by the xDS API version %v", config.TransportAPI)
+	}
+	ret.cc = cc
 	ret.vClient = apiClient
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -170,41 +203,112 @@ func New(config *bootstrap.ServerConfig, updateHandler pubsub.UpdateHandler, val
 	return ret, nil
 }
 
-func (t *Controller) SetMetadata(m *_struct.Struct) error {
-	dopts := []grpc.DialOption{
-		t.config.Creds,
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:    5 * time.Minute,
-			Timeout: 20 * time.Second,
-		}),
+// dial creates a ClientConn to the management server in config, and the API
+// client for its transport version. If m is not nil, it's set as the metadata
+// of a copy of the node proto, as the API clients in use keep sending the one
+// they were built with.
+func (t *Controller) dial(config *bootstrap.ServerConfig, m *_struct.Struct) (*grpc.ClientConn, version.MetadataWrappedVersionClient, error) {
+	builder := version.GetAPIClientBuilder(config.TransportAPI)
+	if builder == nil {
+		return nil, nil, fmt.Errorf("no client builder for xDS API version: %v", config.TransportAPI)
+	}
+	nodeProto := config.NodeProto
+	if v3PBNode, ok := nodeProto.(*v3corepb.Node); ok && m != nil {
+		v3PBNode = proto.Clone(v3PBNode).(*v3corepb.Node)
+		v3PBNode.Metadata = m
+		nodeProto = v3PBNode
+	}
+	apiClient, err := builder(version.BuildOptions{NodeProto: nodeProto, Logger: t.logger})
+	if err != nil {
+		return nil, nil, err
 	}
 
-	cc, err := grpc.Dial(t.config.ServerURI, dopts...)
+	cc, err := grpc.Dial(config.ServerURI, dialOptions(config)...)
 	if err != nil {
 		// An error from a non-blocking dial indicates something serious.
-		return fmt.Errorf("xds: failed to dial control plane {%s}: %v", t.config.ServerURI, err)
+		return nil, nil, fmt.Errorf("xds: failed to dial control plane {%s}: %v", config.ServerURI, err)
 	}
-	t.cc = cc
-	builder := version.GetAPIClientBuilder(t.config.TransportAPI)
-	if builder == nil {
-		return fmt.Errorf("no client builder for xDS API version: %v", t.config.TransportAPI)
+	return cc, apiClient, nil
+}
+
+// backoffFromConfig returns the reconnect backoff of ADS streams, and how long
+// a stream must stay healthy for the backoff to be reset.
+func backoffFromConfig(bc *bootstrap.BackoffConfig) (func(int) time.Duration, time.Duration) {
+	if bc == nil {
+		return backoff.DefaultExponential.Backoff, 0
 	}
-	v3PBNode, ok := t.config.NodeProto.(*v3corepb.Node)
-	if ok {
-		v3PBNode.Metadata = m
+	cfg := backoff.DefaultExponential.Config
+	if bc.BaseDelay > 0 {
+		cfg.BaseDelay = bc.BaseDelay
+	}
+	if bc.MaxDelay > 0 {
+		cfg.MaxDelay = bc.MaxDelay
 	}
-	apiClient, err := builder(version.BuildOptions{NodeProto: t.config.NodeProto, Logger: t.logger})
+	return backoff.Exponential{Config: cfg}.Backoff, bc.HealthyDuration
+}
+
+func dialOptions(config *bootstrap.ServerConfig) []grpc.DialOption {
+	opts := []grpc.DialOption{
+		config.Creds,
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    5 * time.Minute,
+			Timeout: 20 * time.Second,
+		}),
+	}
+	return append(opts, config.DialOptions...)
+}
+
+func (t *Controller) SetMetadata(m *_struct.Struct) error {
+	t.connMu.Lock()
+	config := t.config
+	t.connMu.Unlock()
+
+	cc, apiClient, err := t.dial(config, m)
 	if err != nil {
 		return err
 	}
+	t.connMu.Lock()
+	if t.closed {
+		t.connMu.Unlock()
+		cc.Close()
+		return nil
+	}
+	oldCC := t.cc
+	t.metadata = m
+	t.cc = cc
 	t.vClient = apiClient
+	t.connMu.Unlock()
+
 	t.stopRunGoroutine()
 	ctx, cancel := context.WithCancel(context.Background())
 	t.stopRunGoroutine = cancel
 	go t.run(ctx)
+	// The stream on the previous ClientConn is canceled with the run
+	// goroutine.
+	oldCC.Close()
 	return nil
 }
 
+// conn returns the ClientConn and the API client of the active management
+// server.
+func (t *Controller) conn() (*grpc.ClientConn, version.MetadataWrappedVersionClient) {
+	t.connMu.Lock()
+	defer t.connMu.Unlock()
+	return t.cc, t.vClient
+}
+
+// apiClient returns the API client of the active management server.
+func (t *Controller) apiClient() version.MetadataWrappedVersionClient {
+	_, vClient := t.conn()
+	return vClient
+}
+
+// Ready returns a channel that is closed once the first response has been
+// received from the management server.
+func (t *Controller) Ready() <-chan struct{} {
+	return t.ready.Done()
+}
+
 // Close closes the controller.
 func (t *Controller) Close() {
 	// Note that Close needs to check for nils even if some of them are always
@@ -213,6 +317,9 @@ func (t *Controller) Close() {
 	if t.stopRunGoroutine != nil {
 		t.stopRunGoroutine()
 	}
+	t.connMu.Lock()
+	defer t.connMu.Unlock()
+	t.closed = true
 	if t.cc != nil {
 		t.cc.Close()
 	}
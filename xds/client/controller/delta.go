@@ -0,0 +1,224 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"google.golang.org/grpc"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/controller/version"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// This file implements the delta (incremental) variant of the ADS protocol,
+// used instead of the state of the world one when the management server sets
+// the "delta_ads" server feature.
+//
+// The requests of a delta stream only carry the changes of the watches: a new
+// watch subscribes to its resource name, and a canceled one unsubscribes from
+// it. The responses only carry the resources added or changed, and the names
+// of the removed ones. The controller keeps the version of every resource
+// received, so that the requests of a new stream tell the server which
+// resources the client already has.
+
+// recvDelta receives the responses of a delta ADS stream, until it breaks. It
+// returns whether a response was accepted.
+func (t *Controller) recvDelta(dc version.DeltaClient, stream grpc.ClientStream) bool {
+	success := false
+	for {
+		resp, err := dc.RecvDeltaResponse(stream)
+		if err != nil {
+			t.streamFailed(err)
+			t.updateHandler.NewConnectionError(err)
+			t.logger.Warnf("Delta ADS stream is closed with error: %v", err)
+			return success
+		}
+		t.ready.Fire()
+		t.responseReceived()
+
+		r, err := dc.ParseDeltaResponse(resp)
+		if e, ok := err.(version.ErrResourceTypeUnsupported); ok {
+			t.logger.Warnf("%s", e.ErrStr)
+			continue
+		}
+		if err != nil {
+			t.logger.Warnf("xds: dropping invalid delta ADS response: %v", err)
+			continue
+		}
+
+		err = t.handleDeltaResponse(r)
+		if err != nil && t.acceptInvalid(r.Type, r.SystemVersion, err) {
+			err = nil
+		}
+		ack := &ackAction{
+			rType:   r.Type,
+			version: r.SystemVersion,
+			nonce:   r.Nonce,
+			stream:  stream,
+		}
+		if err != nil {
+			ack.errMsg = err.Error()
+		}
+		t.sendCh.Put(ack)
+		t.reportAck(r.Type, r.SystemVersion, r.Nonce, err)
+		if err == nil {
+			success = true
+		}
+	}
+}
+
+// handleDeltaResponse sends the resources added or changed by r and the ones
+// it removes to the update handler, and records the versions of the
+// resources.
+//
+// The versions are only recorded when all the resources are valid. Otherwise,
+// the valid ones are still used, but the server sends them again on the next
+// stream.
+func (t *Controller) handleDeltaResponse(r *version.DeltaResponse) error {
+	var err error
+	if len(r.Resources) != 0 {
+		resources := make([]*anypb.Any, 0, len(r.Resources))
+		for _, res := range r.Resources {
+			resources = append(resources, res.Resource)
+		}
+		err = t.handleResources(r.Type, &resource.UnmarshalOptions{
+			Version:         r.SystemVersion,
+			Resources:       resources,
+			Logger:          t.logger,
+			UpdateValidator: t.updateValidator,
+		})
+	}
+	if len(r.Removed) != 0 {
+		t.updateHandler.RemoveResources(r.Type, r.Removed)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	versions := t.deltaVersions[r.Type]
+	if versions == nil {
+		versions = make(map[string]string)
+		t.deltaVersions[r.Type] = versions
+	}
+	if err == nil {
+		for _, res := range r.Resources {
+			versions[res.Name] = res.Version
+		}
+	}
+	for _, name := range r.Removed {
+		delete(versions, name)
+	}
+	return err
+}
+
+// sendDelta processes an action of the send goroutine, and sends the request
+// it makes on the delta stream, if any. It returns false if the send failed.
+func (t *Controller) sendDelta(stream grpc.ClientStream, u any) bool {
+	var req *version.DeltaRequest
+	switch update := u.(type) {
+	case *watchAction:
+		req = t.processDeltaWatch(update)
+	case *ackAction:
+		req = t.processDeltaAck(update, stream)
+	case *flushMetadataAction:
+		if target, rType, _, _, send := t.processFlushMetadata(); send {
+			// Subscribing again to the watched resources changes nothing
+			// but the metadata.
+			req = &version.DeltaRequest{Type: rType, Subscribe: target}
+		}
+	}
+	if req == nil || stream == nil {
+		// Without a stream, the watches are sent on the next one.
+		return true
+	}
+	dc, ok := t.apiClient().(version.DeltaClient)
+	if !ok {
+		return false
+	}
+	if err := dc.SendDeltaRequest(stream, req); err != nil {
+		t.logger.Warnf("Delta ADS request for {subscribe: %q, unsubscribe: %q, type: %v, nonce: %q} failed: %v", req.Subscribe, req.Unsubscribe, req.Type, req.Nonce, err)
+		return false
+	}
+	return true
+}
+
+// processDeltaWatch updates the watch map with w, and returns the request
+// subscribing to or unsubscribing from its resource.
+func (t *Controller) processDeltaWatch(w *watchAction) *version.DeltaRequest {
+	t.processWatchInfo(w)
+
+	req := &version.DeltaRequest{Type: w.rType}
+	if !w.remove {
+		req.Subscribe = []string{w.resource}
+		return req
+	}
+	req.Unsubscribe = []string{w.resource}
+	// The server doesn't send the updates of the resource anymore, so its
+	// version gets stale.
+	t.mu.Lock()
+	delete(t.deltaVersions[w.rType], w.resource)
+	t.mu.Unlock()
+	return req
+}
+
+// processDeltaAck returns the ACK or NACK request of ack, nil if it's not to
+// be sent.
+func (t *Controller) processDeltaAck(ack *ackAction, stream grpc.ClientStream) *version.DeltaRequest {
+	if ack.stream != stream {
+		// The ACK is for a previous stream, see processAckInfo.
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nonceMap[ack.rType] = ack.nonce
+	if len(t.watchMap[ack.rType]) == 0 {
+		// Like with state of the world streams, the first request of a type
+		// without resource names would be a wildcard subscription.
+		return nil
+	}
+	return &version.DeltaRequest{Type: ack.rType, Nonce: ack.nonce, ErrMsg: ack.errMsg}
+}
+
+// sendExistingDelta subscribes to the watched resources on a new delta stream,
+// with the versions of the resources already received. See sendExisting.
+func (t *Controller) sendExistingDelta(stream grpc.ClientStream) bool {
+	dc, ok := t.apiClient().(version.DeltaClient)
+	if !ok {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.nonceMap = make(map[resource.ResourceType]string)
+	for rType, s := range t.watchMap {
+		req := &version.DeltaRequest{
+			Type:            rType,
+			Subscribe:       mapToSlice(s),
+			InitialVersions: t.deltaVersions[rType],
+		}
+		if err := dc.SendDeltaRequest(stream, req); err != nil {
+			t.logger.Warnf("Delta ADS request failed: %v", err)
+			return false
+		}
+	}
+	return true
+}
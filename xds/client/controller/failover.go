@@ -0,0 +1,150 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"fmt"
+)
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/bootstrap"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// failoverThreshold is the number of consecutive unhealthy ADS streams after
+// which the controller fails over to the next management server.
+const failoverThreshold = 3
+
+// ActiveServer returns the management server the controller is currently
+// connected to.
+func (t *Controller) ActiveServer() *bootstrap.ServerConfig {
+	t.connMu.Lock()
+	defer t.connMu.Unlock()
+	return t.config
+}
+
+// failover switches to the management server after the active one, if
+// there's any. It returns whether the active server was changed.
+func (t *Controller) failover() bool {
+	if len(t.servers) < 2 {
+		return false
+	}
+	t.connMu.Lock()
+	next := (t.active + 1) % len(t.servers)
+	t.connMu.Unlock()
+	return t.switchServer(next)
+}
+
+// switchServer makes servers[i] the active management server. The existing
+// watches are resent when the stream to the new server is created.
+func (t *Controller) switchServer(i int) bool {
+	t.connMu.Lock()
+	m := t.metadata
+	t.connMu.Unlock()
+
+	config := t.servers[i]
+	cc, apiClient, err := t.dial(config, m)
+	if err != nil {
+		t.logger.Warnf("xds: failed to switch to management server %s: %v", config.ServerURI, err)
+		return false
+	}
+
+	t.connMu.Lock()
+	if t.closed {
+		t.connMu.Unlock()
+		cc.Close()
+		return false
+	}
+	from, oldCC := t.config, t.cc
+	t.config, t.active, t.cc, t.vClient = config, i, cc, apiClient
+	t.connMu.Unlock()
+
+	// The versions of the resources received from the old server mean
+	// nothing to the new one.
+	t.mu.Lock()
+	t.deltaVersions = make(map[resource.ResourceType]map[string]string)
+	t.mu.Unlock()
+
+	// Load reporting streams on the old ClientConn fail and are recreated on
+	// the new one.
+	oldCC.Close()
+	t.logger.Infof("xds: switched management server from %s to %s", from.ServerURI, config.ServerURI)
+	return true
+}
+
+// waitForConnection waits for cc to be ready. It fails if the connection
+// attempt fails, or when ctx is done.
+func (t *Controller) waitForConnection(ctx context.Context, cc *grpc.ClientConn) error {
+	cc.Connect()
+	for state := cc.GetState(); state != connectivity.Ready; state = cc.GetState() {
+		if state == connectivity.TransientFailure {
+			return fmt.Errorf("xds: failed to connect to management server %s", t.ActiveServer().ServerURI)
+		}
+		if !cc.WaitForStateChange(ctx, state) {
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// probePrimary watches the primary management server while a fallback one is
+// active. Once a connection to the primary is ready, onReady is called and
+// the returned channel is closed. The probe stops when ctx is done.
+//
+// The returned channel is nil, and so never ready, if the primary server is
+// active.
+func (t *Controller) probePrimary(ctx context.Context, onReady func()) <-chan struct{} {
+	t.connMu.Lock()
+	active := t.active
+	t.connMu.Unlock()
+	if active == 0 {
+		return nil
+	}
+
+	ready := make(chan struct{})
+	go func() {
+		primary := t.servers[0]
+		cc, err := grpc.Dial(primary.ServerURI, dialOptions(primary)...)
+		if err != nil {
+			t.logger.Warnf("xds: failed to dial primary management server {%s}: %v", primary.ServerURI, err)
+			return
+		}
+		defer cc.Close()
+
+		for state := cc.GetState(); state != connectivity.Ready; state = cc.GetState() {
+			// The connection goes idle after a failed attempt, and is only
+			// attempted again when asked to.
+			if state == connectivity.Idle {
+				cc.Connect()
+			}
+			if !cc.WaitForStateChange(ctx, state) {
+				return
+			}
+		}
+		t.logger.Infof("xds: primary management server %s is reachable again", primary.ServerURI)
+		close(ready)
+		onReady()
+	}()
+	return ready
+}
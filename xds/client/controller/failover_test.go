@@ -0,0 +1,160 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+
+	v3clusterpb "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	v3corepb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/bootstrap"
+	_ "dubbo.apache.org/dubbo-go/v3/xds/client/controller/version/v3"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+	resourceversion "dubbo.apache.org/dubbo-go/v3/xds/client/resource/version"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/testutils/fakeserver"
+)
+
+const failoverClusterName = "cds.example.com"
+
+// recordingUpdateHandler counts the connection errors, one per failed ADS
+// stream.
+type recordingUpdateHandler struct {
+	noopUpdateHandler
+
+	mu               sync.Mutex
+	connectionErrors int
+}
+
+func (h *recordingUpdateHandler) NewConnectionError(error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connectionErrors++
+}
+
+func (h *recordingUpdateHandler) ConnectionErrors() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.connectionErrors
+}
+
+// unreachableConfig returns the config of s, which can't be reached while
+// down is set, with a short reconnect backoff.
+func unreachableConfig(s *fakeserver.Server, down *atomic.Bool) *bootstrap.ServerConfig {
+	config := s.ServerConfig()
+	config.DialOptions = []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			if down.Load() {
+				return nil, errors.New("server down")
+			}
+			return s.Dial(ctx)
+		}),
+	}
+	config.Backoff = &bootstrap.BackoffConfig{BaseDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	return config
+}
+
+func failoverCluster() *v3clusterpb.Cluster {
+	ads := &v3corepb.ConfigSource{
+		ConfigSourceSpecifier: &v3corepb.ConfigSource_Ads{Ads: &v3corepb.AggregatedConfigSource{}},
+	}
+	return &v3clusterpb.Cluster{
+		Name:                 failoverClusterName,
+		ClusterDiscoveryType: &v3clusterpb.Cluster_Type{Type: v3clusterpb.Cluster_EDS},
+		EdsClusterConfig:     &v3clusterpb.Cluster_EdsClusterConfig{EdsConfig: ads},
+		LbPolicy:             v3clusterpb.Cluster_ROUND_ROBIN,
+	}
+}
+
+func TestFailover(t *testing.T) {
+	primary, fallback := fakeserver.New(), fakeserver.New()
+	defer primary.Stop()
+	defer fallback.Stop()
+	versions := make(map[*fakeserver.Server]string)
+	for _, s := range []*fakeserver.Server{primary, fallback} {
+		v, err := s.Update(resourceversion.V3ClusterURL, failoverCluster())
+		if err != nil {
+			t.Fatalf("Update() failed: %v", err)
+		}
+		versions[s] = v
+	}
+
+	var down atomic.Bool
+	down.Store(true)
+	primaryConfig, fallbackConfig := unreachableConfig(primary, &down), fallback.ServerConfig()
+	h := &recordingUpdateHandler{}
+	ctrl, err := New(primaryConfig, h, func(any) error { return nil }, dubbogoLogger.GetLogger(), fallbackConfig)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer ctrl.Close()
+	ctrl.AddWatch(resource.ClusterResource, failoverClusterName)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The primary server is unreachable, the controller fails over after
+	// failoverThreshold failed streams, and sends the watch to the fallback.
+	if a, err := fallback.WaitForAck(ctx, resourceversion.V3ClusterURL, versions[fallback]); err != nil || a.Nack() {
+		t.Fatalf("got %+v, %v from the fallback server, want an ACK", a, err)
+	}
+	if got := h.ConnectionErrors(); got < failoverThreshold {
+		t.Fatalf("failed over after %d failed streams, want at least %d", got, failoverThreshold)
+	}
+	if got := ctrl.ActiveServer(); got != fallbackConfig {
+		t.Fatalf("got active server %s, want the fallback %s", got.ServerURI, fallbackConfig.ServerURI)
+	}
+	fallbackCC, _ := ctrl.conn()
+
+	// The primary server is reachable again, the controller fails back, and
+	// sends the watch to it.
+	down.Store(false)
+	if a, err := primary.WaitForAck(ctx, resourceversion.V3ClusterURL, versions[primary]); err != nil || a.Nack() {
+		t.Fatalf("got %+v, %v from the primary server, want an ACK", a, err)
+	}
+	if got := ctrl.ActiveServer(); got != primaryConfig {
+		t.Fatalf("got active server %s, want the primary %s", got.ServerURI, primaryConfig.ServerURI)
+	}
+	if state := fallbackCC.GetState(); state != connectivity.Shutdown {
+		t.Fatalf("got state %v of the connection to the fallback server, want it closed", state)
+	}
+}
+
+func TestFailoverSingleServer(t *testing.T) {
+	s := fakeserver.New()
+	defer s.Stop()
+	ctrl := &Controller{servers: []*bootstrap.ServerConfig{s.ServerConfig()}}
+	if ctrl.failover() {
+		t.Fatal("failover() succeeded without a fallback server")
+	}
+}
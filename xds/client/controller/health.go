@@ -0,0 +1,57 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"time"
+)
+
+// Health is the state of the ADS stream of a controller.
+type Health struct {
+	// Connected is true while the ADS stream is up and has received a
+	// response.
+	Connected bool
+	// LastError is the last error the ADS stream failed with, if any.
+	LastError error
+	// LastResponseTime is when the last response was received, zero if none
+	// was.
+	LastResponseTime time.Time
+}
+
+// Health returns the state of the ADS stream.
+func (t *Controller) Health() Health {
+	t.healthMu.Lock()
+	defer t.healthMu.Unlock()
+	return t.health
+}
+
+// responseReceived records a response received on the ADS stream.
+func (t *Controller) responseReceived() {
+	t.healthMu.Lock()
+	defer t.healthMu.Unlock()
+	t.health.Connected = true
+	t.health.LastResponseTime = time.Now()
+}
+
+// streamFailed records the failure of the ADS stream, or of its creation.
+func (t *Controller) streamFailed(err error) {
+	t.healthMu.Lock()
+	defer t.healthMu.Unlock()
+	t.health.Connected = false
+	t.health.LastError = err
+}
@@ -131,12 +131,12 @@ func (lrsC *lrsClient) startStream() {
 	var cc *grpc.ClientConn
 
 	lrsC.parent.logger.Infof("Starting load report to server: %s", lrsC.server)
-	if lrsC.server == "" || lrsC.server == lrsC.parent.config.ServerURI {
-		// Reuse the xDS client if server is the same.
-		cc = lrsC.parent.cc
-	} else {
+	// Reuse the xDS client if server is the same. cc is left nil then, so the
+	// stream follows the management server on failover.
+	config := lrsC.parent.ActiveServer()
+	if lrsC.server != "" && lrsC.server != config.ServerURI {
 		lrsC.parent.logger.Infof("LRS server is different from management server, starting a new ClientConn")
-		ccNew, err := grpc.Dial(lrsC.server, lrsC.parent.config.Creds)
+		ccNew, err := grpc.Dial(lrsC.server, config.Creds)
 		if err != nil {
 			// An error from a non-blocking dial indicates something serious.
 			lrsC.parent.logger.Infof("xds: failed to dial load report server {%s}: %v", lrsC.server, err)
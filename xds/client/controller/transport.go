@@ -25,6 +25,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 )
@@ -50,6 +51,14 @@ func (t *Controller) AddWatch(rType resource.ResourceType, resourceName string)
 	})
 }
 
+// FlushMetadata sends the node metadata to the management server. The
+// metadata is carried by every request, so the current subscriptions of a
+// watched type are sent again. When nothing is watched yet, nothing is sent,
+// and the metadata goes with the first watch.
+func (t *Controller) FlushMetadata() {
+	t.sendCh.Put(&flushMetadataAction{})
+}
+
 // RemoveWatch cancels an already registered watch for an xDS resource
 // given its type and name.
 func (t *Controller) RemoveWatch(rType resource.ResourceType, resourceName string) {
@@ -60,9 +69,12 @@ func (t *Controller) RemoveWatch(rType resource.ResourceType, resourceName strin
 	})
 }
 
-// run starts an ADS stream (and backs off exponentially, if the previous
-// stream failed without receiving a single reply) and runs the sender and
+// run starts an ADS stream (and backs off exponentially with jitter, if the
+// previous stream wasn't healthy, see runStream) and runs the sender and
 // receiver routines to send and receive data from the stream respectively.
+//
+// After failoverThreshold consecutive unhealthy streams, it fails over to the
+// next management server.
 func (t *Controller) run(ctx context.Context) {
 	go t.send(ctx)
 	// TODO: start a goroutine monitoring ClientConn's connectivity state, and
@@ -89,25 +101,79 @@ func (t *Controller) run(ctx context.Context) {
 		}
 
 		retries++
-		stream, err := t.vClient.NewStream(ctx, t.cc)
-		if err != nil {
-			t.updateHandler.NewConnectionError(err)
-			t.logger.Warnf("xds: ADS stream creation failed: %v", err)
-			continue
+		streamCtx, cancelStream := context.WithCancel(ctx)
+		// While failed over, the stream is canceled once the primary
+		// server is reachable again, to fail back to it.
+		primaryReady := t.probePrimary(streamCtx, cancelStream)
+		if t.runStream(streamCtx) {
+			retries = 0
 		}
-		t.logger.Infof("ADS stream created")
+		cancelStream()
 
 		select {
-		case <-t.streamCh:
+		case <-primaryReady:
+			if t.switchServer(0) {
+				retries = 0
+			}
+			continue
 		default:
 		}
-		t.streamCh <- stream
-		if t.recv(stream) {
+		if retries >= failoverThreshold && t.failover() {
 			retries = 0
 		}
 	}
 }
 
+// runStream creates an ADS stream to the active management server, hands it
+// to the send goroutine and receives on it until it breaks. It returns
+// whether the stream was healthy: it received a response and stayed up for at
+// least healthyDuration.
+func (t *Controller) runStream(ctx context.Context) bool {
+	cc, vClient := t.conn()
+	newStream, recv := vClient.NewStream, t.recv
+	if t.delta {
+		dc, ok := vClient.(resourceversion.DeltaClient)
+		if !ok {
+			err := errors.New("xds: delta ADS is not supported by the active management server's xDS API version")
+			t.streamFailed(err)
+			t.updateHandler.NewConnectionError(err)
+			t.logger.Warnf("%v", err)
+			return false
+		}
+		newStream = dc.NewDeltaStream
+		recv = func(stream grpc.ClientStream) bool {
+			return t.recvDelta(dc, stream)
+		}
+	}
+	if len(t.servers) > 1 {
+		// The streams wait for the connection to be ready, so the failures
+		// to connect must be counted here, for the controller to fail over
+		// when the active server is unreachable.
+		if err := t.waitForConnection(ctx, cc); err != nil {
+			t.streamFailed(err)
+			t.updateHandler.NewConnectionError(err)
+			t.logger.Warnf("%v", err)
+			return false
+		}
+	}
+	stream, err := newStream(ctx, cc)
+	if err != nil {
+		t.streamFailed(err)
+		t.updateHandler.NewConnectionError(err)
+		t.logger.Warnf("xds: ADS stream creation failed: %v", err)
+		return false
+	}
+	t.logger.Infof("ADS stream created")
+
+	select {
+	case <-t.streamCh:
+	default:
+	}
+	t.streamCh <- stream
+	start := time.Now()
+	return recv(stream) && time.Since(start) >= t.healthyDuration
+}
+
 // send is a separate goroutine for sending watch requests on the xds stream.
 //
 // It watches the stream channel for new streams, and the request channel for
@@ -138,6 +204,14 @@ func (t *Controller) send(ctx context.Context) {
 		case u := <-t.sendCh.Get():
 			t.sendCh.Load()
 
+			if t.delta {
+				if !t.sendDelta(stream, u) {
+					// send failed, clear the current stream.
+					stream = nil
+				}
+				continue
+			}
+
 			var (
 				target                 []string
 				rType                  resource.ResourceType
@@ -153,6 +227,11 @@ func (t *Controller) send(ctx context.Context) {
 					continue
 				}
 				errMsg = update.errMsg
+			case *flushMetadataAction:
+				target, rType, version, nonce, send = t.processFlushMetadata()
+				if !send {
+					continue
+				}
 			}
 			if stream == nil {
 				// There's no stream yet. Skip the request. This request
@@ -161,7 +240,7 @@ func (t *Controller) send(ctx context.Context) {
 				// sending response back).
 				continue
 			}
-			if err := t.vClient.SendRequest(stream, target, rType, version, nonce, errMsg); err != nil {
+			if err := t.apiClient().SendRequest(stream, target, rType, version, nonce, errMsg); err != nil {
 				t.logger.Warnf("ADS request for {target: %q, type: %v, version: %q, nonce: %q} failed: %v", target, rType, version, nonce, err)
 				// send failed, clear the current stream.
 				stream = nil
@@ -178,6 +257,11 @@ func (t *Controller) send(ctx context.Context) {
 // quickly (once it pushes the message onto the transport layer) and is only
 // ever blocked if we don't have enough flow control quota.
 func (t *Controller) sendExisting(stream grpc.ClientStream) bool {
+	if t.delta {
+		return t.sendExistingDelta(stream)
+	}
+	vClient := t.apiClient()
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -186,28 +270,31 @@ func (t *Controller) sendExisting(stream grpc.ClientStream) bool {
 	t.nonceMap = make(map[resource.ResourceType]string)
 
 	for rType, s := range t.watchMap {
-		if err := t.vClient.SendRequest(stream, mapToSlice(s), rType, "", "", ""); err != nil {
+		if err := vClient.SendRequest(stream, mapToSlice(s), rType, "", "", ""); err != nil {
 			t.logger.Warnf("ADS request failed: %v", err)
 			return false
 		}
 	}
-
 	return true
 }
 
 // recv receives xDS responses on the provided ADS stream and branches out to
 // message specific handlers.
 func (t *Controller) recv(stream grpc.ClientStream) bool {
+	vClient := t.apiClient()
 	success := false
 	for {
-		resp, err := t.vClient.RecvResponse(stream)
+		resp, err := vClient.RecvResponse(stream)
 		if err != nil {
+			t.streamFailed(err)
 			t.updateHandler.NewConnectionError(err)
 			t.logger.Warnf("ADS stream is closed with error: %v", err)
 			return success
 		}
+		t.ready.Fire()
+		t.responseReceived()
 
-		rType, version, nonce, err := t.handleResponse(resp)
+		rType, version, nonce, err := t.handleResponse(vClient, resp)
 
 		if e, ok := err.(resourceversion.ErrResourceTypeUnsupported); ok {
 			t.logger.Warnf("%s", e.ErrStr)
@@ -221,7 +308,7 @@ func (t *Controller) recv(stream grpc.ClientStream) bool {
 				errMsg:  err.Error(),
 				stream:  stream,
 			})
-			t.logger.Warnf("Sending NACK for response type: %v, version: %v, nonce: %v, reason: %v", rType, version, nonce, err)
+			t.reportAck(rType, version, nonce, err)
 			continue
 		}
 		t.sendCh.Put(&ackAction{
@@ -230,13 +317,13 @@ func (t *Controller) recv(stream grpc.ClientStream) bool {
 			nonce:   nonce,
 			stream:  stream,
 		})
-		t.logger.Infof("Sending ACK for response type: %v, version: %v, nonce: %v", rType, version, nonce)
+		t.reportAck(rType, version, nonce, nil)
 		success = true
 	}
 }
 
-func (t *Controller) handleResponse(resp proto.Message) (resource.ResourceType, string, string, error) {
-	rType, resources, version, nonce, err := t.vClient.ParseResponse(resp)
+func (t *Controller) handleResponse(vClient resourceversion.VersionedClient, resp proto.Message) (resource.ResourceType, string, string, error) {
+	rType, resources, version, nonce, err := vClient.ParseResponse(resp)
 	if err != nil {
 		return rType, version, nonce, err
 	}
@@ -246,7 +333,20 @@ func (t *Controller) handleResponse(resp proto.Message) (resource.ResourceType,
 		Logger:          t.logger,
 		UpdateValidator: t.updateValidator,
 	}
-	var md resource.UpdateMetadata
+	err = t.handleResources(rType, opts)
+	if _, ok := err.(resourceversion.ErrResourceTypeUnsupported); !ok && err != nil && t.acceptInvalid(rType, version, err) {
+		err = nil
+	}
+	return rType, version, nonce, err
+}
+
+// handleResources unmarshals the resources of type rType in opts, and sends
+// them to the update handler.
+func (t *Controller) handleResources(rType resource.ResourceType, opts *resource.UnmarshalOptions) error {
+	var (
+		md  resource.UpdateMetadata
+		err error
+	)
 	switch rType {
 	case resource.ListenerResource:
 		var update map[string]resource.ListenerUpdateErrTuple
@@ -265,11 +365,11 @@ func (t *Controller) handleResponse(resp proto.Message) (resource.ResourceType,
 		update, md, err = resource.UnmarshalEndpoints(opts)
 		t.updateHandler.NewEndpoints(update, md)
 	default:
-		return rType, "", "", resourceversion.ErrResourceTypeUnsupported{
+		return resourceversion.ErrResourceTypeUnsupported{
 			ErrStr: fmt.Sprintf("Resource type %v unknown in response from server", rType),
 		}
 	}
-	return rType, version, nonce, err
+	return err
 }
 
 func mapToSlice(m map[string]bool) []string {
@@ -319,6 +419,28 @@ func (t *Controller) processWatchInfo(w *watchAction) (target []string, rType re
 	return target, rType, ver, nonce
 }
 
+type flushMetadataAction struct{}
+
+// processFlushMetadata returns the fields of the request flushing the node
+// metadata, which sends the current subscriptions of the first watched type
+// again, with its last version and nonce. send is false if nothing is
+// watched.
+func (t *Controller) processFlushMetadata() (target []string, rType resource.ResourceType, version, nonce string, send bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rType = resource.UnknownResource
+	for rt := range t.watchMap {
+		if rType == resource.UnknownResource || rt < rType {
+			rType = rt
+		}
+	}
+	if rType == resource.UnknownResource {
+		return nil, rType, "", "", false
+	}
+	return mapToSlice(t.watchMap[rType]), rType, t.versionMap[rType], t.nonceMap[rType], true
+}
+
 type ackAction struct {
 	rType   resource.ResourceType
 	version string // NACK if version is an empty string.
@@ -378,7 +500,8 @@ func (t *Controller) processAckInfo(ack *ackAction, stream grpc.ClientStream) (t
 }
 
 // reportLoad starts an LRS stream to report load data to the management server.
-// It blocks until the context is canceled.
+// It blocks until the context is canceled. A nil cc means the ClientConn of
+// the active management server.
 func (t *Controller) reportLoad(ctx context.Context, cc *grpc.ClientConn, opts resourceversion.LoadReportingOptions) {
 	retries := 0
 	for {
@@ -399,39 +522,79 @@ func (t *Controller) reportLoad(ctx context.Context, cc *grpc.ClientConn, opts r
 		}
 
 		retries++
-		stream, err := t.vClient.NewLoadStatsStream(ctx, cc)
+		streamCC, vClient := cc, t.apiClient()
+		if streamCC == nil {
+			// Reporting to the management server, whose ClientConn changes
+			// on failover.
+			streamCC, _ = t.conn()
+		}
+		stream, err := vClient.NewLoadStatsStream(ctx, streamCC)
 		if err != nil {
 			t.logger.Warnf("lrs: failed to create stream: %v", err)
 			continue
 		}
 		t.logger.Infof("lrs: created LRS stream")
 
-		if err = t.vClient.SendFirstLoadStatsRequest(stream); err != nil {
+		if err = vClient.SendFirstLoadStatsRequest(stream); err != nil {
 			t.logger.Warnf("lrs: failed to send first request: %v", err)
 			continue
 		}
 
-		clusters, interval, err := t.vClient.HandleLoadStatsResponse(stream)
+		clusters, interval, err := vClient.HandleLoadStatsResponse(stream)
 		if err != nil {
 			t.logger.Warnf("%v", err)
 			continue
 		}
 
 		retries = 0
-		t.sendLoads(ctx, stream, opts.LoadStore, clusters, interval)
+		t.sendLoads(ctx, vClient, stream, opts.LoadStore, clusters, interval)
 	}
 }
 
-func (t *Controller) sendLoads(ctx context.Context, stream grpc.ClientStream, store *load.Store, clusterNames []string, interval time.Duration) {
+// lrsConfig is the load reporting requested by an LRS response.
+type lrsConfig struct {
+	clusters []string
+	interval time.Duration
+}
+
+// sendLoads sends the loads of clusterNames every interval on stream, until
+// ctx is done or the stream breaks. The server can change the clusters and
+// the interval with new responses on the stream.
+func (t *Controller) sendLoads(ctx context.Context, vClient resourceversion.MetadataWrappedVersionClient, stream grpc.ClientStream, store *load.Store, clusterNames []string, interval time.Duration) {
+	updates := make(chan lrsConfig)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			clusters, interval, err := vClient.HandleLoadStatsResponse(stream)
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case updates <- lrsConfig{clusters: clusters, interval: interval}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
 	tick := time.NewTicker(interval)
 	defer tick.Stop()
 	for {
 		select {
 		case <-tick.C:
+		case u := <-updates:
+			t.logger.Infof("lrs: reporting the loads of clusters %v every %v", u.clusters, u.interval)
+			clusterNames = u.clusters
+			tick.Reset(u.interval)
+			continue
+		case err := <-recvErr:
+			t.logger.Warnf("%v", err)
+			return
 		case <-ctx.Done():
 			return
 		}
-		if err := t.vClient.SendLoadStatsRequest(stream, store.Stats(clusterNames)); err != nil {
+		if err := vClient.SendLoadStatsRequest(stream, store.Stats(clusterNames)); err != nil {
 			t.logger.Warnf("%v", err)
 			return
 		}
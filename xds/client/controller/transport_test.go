@@ -0,0 +1,306 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+
+	"github.com/golang/protobuf/proto"
+	anypb "github.com/golang/protobuf/ptypes/any"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/grpc"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/bootstrap"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/controller/version"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+	resourceversion "dubbo.apache.org/dubbo-go/v3/xds/client/resource/version"
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/buffer"
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/grpcsync"
+)
+
+var errStreamBroken = errors.New("stream broken")
+
+// fakeStreamBehavior describes what the fake management server does with one
+// ADS stream.
+type fakeStreamBehavior struct {
+	// reject fails the stream creation.
+	reject bool
+	// lifetime is how long the stream stays up after sending one response.
+	lifetime time.Duration
+}
+
+type fakeStream struct {
+	grpc.ClientStream
+	lifetime time.Duration
+	recvs    int
+}
+
+// fakeServerClient is an API client talking to a fake management server, which
+// handles the ADS streams as described by behaviors, in order. Stream
+// creations after the last behavior are rejected.
+type fakeServerClient struct {
+	version.MetadataWrappedVersionClient
+
+	mu        sync.Mutex
+	behaviors []fakeStreamBehavior
+	done      chan struct{}
+	// parseErr is returned by ParseResponse, making the controller NACK.
+	parseErr error
+}
+
+func (c *fakeServerClient) NewStream(context.Context, *grpc.ClientConn) (grpc.ClientStream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.behaviors) == 0 {
+		select {
+		case <-c.done:
+		default:
+			close(c.done)
+		}
+		return nil, errStreamBroken
+	}
+	b := c.behaviors[0]
+	c.behaviors = c.behaviors[1:]
+	if b.reject {
+		return nil, errStreamBroken
+	}
+	return &fakeStream{lifetime: b.lifetime}, nil
+}
+
+func (c *fakeServerClient) SendRequest(grpc.ClientStream, []string, resource.ResourceType, string, string, string) error {
+	return nil
+}
+
+func (c *fakeServerClient) RecvResponse(s grpc.ClientStream) (proto.Message, error) {
+	stream := s.(*fakeStream)
+	stream.recvs++
+	if stream.recvs > 1 {
+		time.Sleep(stream.lifetime)
+		return nil, errStreamBroken
+	}
+	return &anypb.Any{}, nil
+}
+
+func (c *fakeServerClient) ParseResponse(proto.Message) (resource.ResourceType, []*anypb.Any, string, string, error) {
+	return resource.ListenerResource, nil, "1", "1", c.parseErr
+}
+
+type noopUpdateHandler struct{}
+
+func (noopUpdateHandler) NewListeners(map[string]resource.ListenerUpdateErrTuple, resource.UpdateMetadata) {
+}
+
+func (noopUpdateHandler) NewRouteConfigs(map[string]resource.RouteConfigUpdateErrTuple, resource.UpdateMetadata) {
+}
+
+func (noopUpdateHandler) NewClusters(map[string]resource.ClusterUpdateErrTuple, resource.UpdateMetadata) {
+}
+
+func (noopUpdateHandler) NewEndpoints(map[string]resource.EndpointsUpdateErrTuple, resource.UpdateMetadata) {
+}
+
+func (noopUpdateHandler) RemoveResources(resource.ResourceType, []string) {}
+
+func (noopUpdateHandler) NewConnectionError(error) {}
+
+func TestReconnectBackoff(t *testing.T) {
+	const healthyDuration = 50 * time.Millisecond
+	client := &fakeServerClient{
+		behaviors: []fakeStreamBehavior{
+			{reject: true},
+			{reject: true},
+			{reject: true},
+			// Receives a response, but breaks before being healthy.
+			{lifetime: 0},
+			{lifetime: 2 * healthyDuration},
+			{reject: true},
+			{reject: true},
+		},
+		done: make(chan struct{}),
+	}
+
+	var (
+		mu      sync.Mutex
+		retries []int
+	)
+	config := &bootstrap.ServerConfig{ServerURI: "fake-server"}
+	ctr := &Controller{
+		servers:         []*bootstrap.ServerConfig{config},
+		config:          config,
+		updateHandler:   noopUpdateHandler{},
+		updateValidator: func(any) error { return nil },
+		logger:          dubbogoLogger.GetLogger(),
+		vClient:         client,
+		backoff: func(r int) time.Duration {
+			mu.Lock()
+			retries = append(retries, r)
+			mu.Unlock()
+			return 0
+		},
+		healthyDuration: healthyDuration,
+		streamCh:        make(chan grpc.ClientStream, 1),
+		sendCh:          buffer.NewUnbounded(),
+		ready:           grpcsync.NewEvent(),
+		watchMap:        make(map[resource.ResourceType]map[string]bool),
+		versionMap:      make(map[resource.ResourceType]string),
+		nonceMap:        make(map[resource.ResourceType]string),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ctr.run(ctx)
+
+	select {
+	case <-client.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the controller to use all the fake streams")
+	}
+	cancel()
+
+	mu.Lock()
+	defer mu.Unlock()
+	// The delays grow while the streams are rejected or unhealthy, and are
+	// reset by the healthy stream.
+	want := []int{1, 2, 3, 4, 1, 2}
+	if diff := cmp.Diff(want, retries[:len(want)]); diff != "" {
+		t.Fatalf("unexpected backoff retries (-want +got):\n%s", diff)
+	}
+}
+
+func TestBackoffFromConfig(t *testing.T) {
+	bo, healthy := backoffFromConfig(&bootstrap.BackoffConfig{
+		BaseDelay:       time.Second,
+		MaxDelay:        4 * time.Second,
+		HealthyDuration: time.Minute,
+	})
+	if healthy != time.Minute {
+		t.Fatalf("healthy duration = %v, want %v", healthy, time.Minute)
+	}
+	for retries := 1; retries < 10; retries++ {
+		// The jitter is 20% of the delay.
+		if d := bo(retries); d > 4*time.Second*6/5 {
+			t.Fatalf("backoff(%d) = %v, want at most %v", retries, d, 4*time.Second*6/5)
+		}
+	}
+}
+
+func TestHealth(t *testing.T) {
+	client := &fakeServerClient{
+		behaviors: []fakeStreamBehavior{{lifetime: 0}},
+		done:      make(chan struct{}),
+	}
+	config := &bootstrap.ServerConfig{ServerURI: "fake-server"}
+	ctr := &Controller{
+		servers:         []*bootstrap.ServerConfig{config},
+		config:          config,
+		updateHandler:   noopUpdateHandler{},
+		updateValidator: func(any) error { return nil },
+		logger:          dubbogoLogger.GetLogger(),
+		vClient:         client,
+		streamCh:        make(chan grpc.ClientStream, 1),
+		sendCh:          buffer.NewUnbounded(),
+		ready:           grpcsync.NewEvent(),
+		watchMap:        make(map[resource.ResourceType]map[string]bool),
+		versionMap:      make(map[resource.ResourceType]string),
+		nonceMap:        make(map[resource.ResourceType]string),
+	}
+	if h := ctr.Health(); h.Connected || h.LastError != nil || !h.LastResponseTime.IsZero() {
+		t.Fatalf("Health() = %+v before any stream, want zero", h)
+	}
+
+	// The stream receives a response, then breaks.
+	before := time.Now()
+	ctr.runStream(context.Background())
+	h := ctr.Health()
+	if h.Connected {
+		t.Fatal("Health() reports connected after the stream broke")
+	}
+	if !errors.Is(h.LastError, errStreamBroken) {
+		t.Fatalf("Health().LastError = %v, want %v", h.LastError, errStreamBroken)
+	}
+	if h.LastResponseTime.Before(before) {
+		t.Fatalf("Health().LastResponseTime = %v, want after %v", h.LastResponseTime, before)
+	}
+
+	// The stream creation is rejected, the last response time is kept.
+	ctr.runStream(context.Background())
+	if got := ctr.Health(); got.Connected || got.LastResponseTime != h.LastResponseTime {
+		t.Fatalf("Health() = %+v after a rejected stream, want disconnected with the last response time kept", got)
+	}
+}
+
+func TestAckCallback(t *testing.T) {
+	errBadResponse := errors.New("bad response")
+	for _, test := range []struct {
+		name     string
+		parseErr error
+	}{
+		{"ack", nil},
+		{"nack", errBadResponse},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			client := &fakeServerClient{
+				behaviors: []fakeStreamBehavior{{lifetime: 0}},
+				done:      make(chan struct{}),
+				parseErr:  test.parseErr,
+			}
+			config := &bootstrap.ServerConfig{ServerURI: "fake-server", TransportAPI: resourceversion.TransportV3}
+			ctr := &Controller{
+				servers:         []*bootstrap.ServerConfig{config},
+				config:          config,
+				updateHandler:   noopUpdateHandler{},
+				updateValidator: func(any) error { return nil },
+				logger:          dubbogoLogger.GetLogger(),
+				vClient:         client,
+				streamCh:        make(chan grpc.ClientStream, 1),
+				sendCh:          buffer.NewUnbounded(),
+				ready:           grpcsync.NewEvent(),
+				watchMap:        make(map[resource.ResourceType]map[string]bool),
+				versionMap:      make(map[resource.ResourceType]string),
+				nonceMap:        make(map[resource.ResourceType]string),
+			}
+
+			type ack struct {
+				typeURL, version, nonce string
+				err                     error
+			}
+			var acks []ack
+			ctr.SetAckCallback(func(typeURL, version, nonce string, err error) {
+				acks = append(acks, ack{typeURL: typeURL, version: version, nonce: nonce, err: err})
+			})
+			ctr.runStream(context.Background())
+
+			want := []ack{{typeURL: resourceversion.V3ListenerURL, version: "1", nonce: "1", err: test.parseErr}}
+			if diff := cmp.Diff(want, acks, cmp.AllowUnexported(ack{}), cmp.Comparer(func(a, b error) bool { return a == b })); diff != "" {
+				t.Fatalf("unexpected acks (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
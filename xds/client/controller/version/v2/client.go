@@ -72,7 +72,11 @@ func newClient(opts controllerversion.BuildOptions) (controllerversion.MetadataW
 	if !ok {
 		return nil, fmt.Errorf("xds: unsupported Node proto type: %T, want %T", opts.NodeProto, (*v2corepb.Node)(nil))
 	}
-	v2c := &client{nodeProto: nodeProto, logger: dubbogoLogger.GetLogger()}
+	logger := opts.Logger
+	if logger == nil {
+		logger = dubbogoLogger.GetLogger()
+	}
+	v2c := &client{nodeProto: nodeProto, logger: logger}
 	return v2c, nil
 }
 
@@ -123,7 +127,7 @@ func (v2c *client) SendRequest(s grpc.ClientStream, resourceNames []string, rTyp
 	if err := stream.Send(req); err != nil {
 		return fmt.Errorf("xds: stream.Send(%+v) failed: %v", req, err)
 	}
-	v2c.logger.Debugf("ADS request sent: %v", pretty.ToJSON(req))
+	v2c.logger.Debugf("ADS request sent: %v", pretty.Lazy(req))
 	return nil
 }
 
@@ -140,7 +144,7 @@ func (v2c *client) RecvResponse(s grpc.ClientStream) (proto.Message, error) {
 		return nil, fmt.Errorf("xds: stream.Recv() failed: %v", err)
 	}
 	v2c.logger.Infof("ADS response received, type: %v", resp.GetTypeUrl())
-	v2c.logger.Debugf("ADS response received: %v", pretty.ToJSON(resp))
+	v2c.logger.Debugf("ADS response received: %v", pretty.Lazy(resp))
 	return resp, nil
 }
 
@@ -71,8 +71,12 @@ func newClient(opts controllerversion.BuildOptions) (controllerversion.MetadataW
 	if !ok {
 		return nil, fmt.Errorf("xds: unsupported Node proto type: %T, want %T", opts.NodeProto, v3corepb.Node{})
 	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = dubbogoLogger.GetLogger()
+	}
 	v3c := &client{
-		nodeProto: nodeProto, logger: dubbogoLogger.GetLogger(),
+		nodeProto: nodeProto, logger: logger,
 	}
 	return v3c, nil
 }
@@ -124,7 +128,7 @@ func (v3c *client) SendRequest(s grpc.ClientStream, resourceNames []string, rTyp
 	if err := stream.Send(req); err != nil {
 		return fmt.Errorf("xds: stream.Send(%+v) failed: %v", req, err)
 	}
-	v3c.logger.Debugf("ADS request sent: %v", pretty.ToJSON(req))
+	v3c.logger.Debugf("ADS request sent: %v", pretty.Lazy(req))
 	return nil
 }
 
@@ -141,36 +145,42 @@ func (v3c *client) RecvResponse(s grpc.ClientStream) (proto.Message, error) {
 		return nil, fmt.Errorf("xds: stream.Recv() failed: %v", err)
 	}
 	v3c.logger.Infof("ADS response received, type: %v", resp.GetTypeUrl())
-	v3c.logger.Debugf("ADS response received: %+v", pretty.ToJSON(resp))
+	v3c.logger.Debugf("ADS response received: %+v", pretty.Lazy(resp))
 	return resp, nil
 }
 
 func (v3c *client) ParseResponse(r proto.Message) (resource.ResourceType, []*anypb.Any, string, string, error) {
-	rType := resource.UnknownResource
 	resp, ok := r.(*v3discoverypb.DiscoveryResponse)
 	if !ok {
-		return rType, nil, "", "", fmt.Errorf("xds: unsupported message type: %T", resp)
+		return resource.UnknownResource, nil, "", "", fmt.Errorf("xds: unsupported message type: %T", resp)
 	}
 
+	rType, err := resourceTypeFromURL(resp.GetTypeUrl())
+	if err != nil {
+		return rType, nil, "", "", err
+	}
+	return rType, resp.GetResources(), resp.GetVersionInfo(), resp.GetNonce(), nil
+}
+
+// resourceTypeFromURL returns the resource type of the type URL of a
+// response.
+func resourceTypeFromURL(url string) (resource.ResourceType, error) {
 	// Note that the xDS transport protocol is versioned independently of
 	// the resource types, and it is supported to transfer older versions
 	// of resource types using new versions of the transport protocol, or
 	// vice-versa. Hence we need to handle v3 type_urls as well here.
-	var err error
-	url := resp.GetTypeUrl()
 	switch {
 	case resource.IsListenerResource(url):
-		rType = resource.ListenerResource
+		return resource.ListenerResource, nil
 	case resource.IsRouteConfigResource(url):
-		rType = resource.RouteConfigResource
+		return resource.RouteConfigResource, nil
 	case resource.IsClusterResource(url):
-		rType = resource.ClusterResource
+		return resource.ClusterResource, nil
 	case resource.IsEndpointsResource(url):
-		rType = resource.EndpointsResource
+		return resource.EndpointsResource, nil
 	default:
-		return rType, nil, "", "", controllerversion.ErrResourceTypeUnsupported{
-			ErrStr: fmt.Sprintf("Resource type %v unknown in response from server", resp.GetTypeUrl()),
+		return resource.UnknownResource, controllerversion.ErrResourceTypeUnsupported{
+			ErrStr: fmt.Sprintf("Resource type %v unknown in response from server", url),
 		}
 	}
-	return rType, resp.GetResources(), resp.GetVersionInfo(), resp.GetNonce(), err
 }
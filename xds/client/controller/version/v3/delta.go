@@ -0,0 +1,117 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	"context"
+	"fmt"
+)
+
+import (
+	v3discoverypb "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+
+	"github.com/golang/protobuf/proto"
+
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+import (
+	controllerversion "dubbo.apache.org/dubbo-go/v3/xds/client/controller/version"
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/pretty"
+)
+
+var _ controllerversion.DeltaClient = (*client)(nil)
+
+type deltaADSStream v3discoverypb.AggregatedDiscoveryService_DeltaAggregatedResourcesClient
+
+func (v3c *client) NewDeltaStream(ctx context.Context, cc *grpc.ClientConn) (grpc.ClientStream, error) {
+	return v3discoverypb.NewAggregatedDiscoveryServiceClient(cc).DeltaAggregatedResources(ctx, grpc.WaitForReady(true))
+}
+
+// SendDeltaRequest sends out a DeltaDiscoveryRequest on the provided delta
+// stream. The node is sent with every request, so that a metadata update is
+// seen by the server whatever request comes first on a new stream.
+func (v3c *client) SendDeltaRequest(s grpc.ClientStream, r *controllerversion.DeltaRequest) error {
+	stream, ok := s.(deltaADSStream)
+	if !ok {
+		return fmt.Errorf("xds: Attempt to send delta request on unsupported stream type: %T", s)
+	}
+	req := &v3discoverypb.DeltaDiscoveryRequest{
+		Node:                     v3c.nodeProto,
+		TypeUrl:                  resourceTypeToURL[r.Type],
+		ResourceNamesSubscribe:   r.Subscribe,
+		ResourceNamesUnsubscribe: r.Unsubscribe,
+		InitialResourceVersions:  r.InitialVersions,
+		ResponseNonce:            r.Nonce,
+	}
+	if r.ErrMsg != "" {
+		req.ErrorDetail = &statuspb.Status{
+			Code: int32(codes.InvalidArgument), Message: r.ErrMsg,
+		}
+	}
+	if err := stream.Send(req); err != nil {
+		return fmt.Errorf("xds: stream.Send(%+v) failed: %v", req, err)
+	}
+	v3c.logger.Debugf("Delta ADS request sent: %v", pretty.Lazy(req))
+	return nil
+}
+
+// RecvDeltaResponse blocks on the receipt of one response message on the
+// provided delta stream.
+func (v3c *client) RecvDeltaResponse(s grpc.ClientStream) (proto.Message, error) {
+	stream, ok := s.(deltaADSStream)
+	if !ok {
+		return nil, fmt.Errorf("xds: Attempt to receive delta response on unsupported stream type: %T", s)
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, fmt.Errorf("xds: stream.Recv() failed: %v", err)
+	}
+	v3c.logger.Infof("Delta ADS response received, type: %v", resp.GetTypeUrl())
+	v3c.logger.Debugf("Delta ADS response received: %+v", pretty.Lazy(resp))
+	return resp, nil
+}
+
+func (v3c *client) ParseDeltaResponse(r proto.Message) (*controllerversion.DeltaResponse, error) {
+	resp, ok := r.(*v3discoverypb.DeltaDiscoveryResponse)
+	if !ok {
+		return nil, fmt.Errorf("xds: unsupported message type: %T", r)
+	}
+	rType, err := resourceTypeFromURL(resp.GetTypeUrl())
+	if err != nil {
+		return nil, err
+	}
+	ret := &controllerversion.DeltaResponse{
+		Type:          rType,
+		Removed:       resp.GetRemovedResources(),
+		SystemVersion: resp.GetSystemVersionInfo(),
+		Nonce:         resp.GetNonce(),
+	}
+	for _, res := range resp.GetResources() {
+		ret.Resources = append(ret.Resources, controllerversion.DeltaResource{
+			Name:     res.GetName(),
+			Version:  res.GetVersion(),
+			Resource: res.GetResource(),
+		})
+	}
+	return ret, nil
+}
@@ -124,9 +124,10 @@ type VersionedClient interface {
 	// SendFirstLoadStatsRequest constructs and sends the first request on the
 	// LRS stream.
 	SendFirstLoadStatsRequest(s grpc.ClientStream) error
-	// HandleLoadStatsResponse receives the first response from the server which
+	// HandleLoadStatsResponse receives the next response from the server which
 	// contains the load reporting interval and the clusters for which the
-	// server asks the client to report load for.
+	// server asks the client to report load for. The first one is received
+	// before any load is sent, the following ones update the reporting.
 	//
 	// If the response sets SendAllClusters to true, the returned clusters is
 	// nil.
@@ -141,3 +142,61 @@ type MetadataWrappedVersionClient interface {
 	VersionedClient
 	SetMetadata(p *_struct.Struct)
 }
+
+// DeltaClient is implemented by the versioned clients supporting the delta
+// (incremental) variant of the ADS protocol. Its requests subscribe to and
+// unsubscribe from resource names, and its responses only carry the added and
+// changed resources, and the names of the removed ones.
+type DeltaClient interface {
+	// NewDeltaStream returns a new delta ADS stream.
+	NewDeltaStream(ctx context.Context, cc *grpc.ClientConn) (grpc.ClientStream, error)
+	// SendDeltaRequest constructs and sends out a DeltaDiscoveryRequest
+	// message on a delta ADS stream.
+	SendDeltaRequest(s grpc.ClientStream, req *DeltaRequest) error
+	// RecvDeltaResponse uses the provided delta ADS stream to receive a
+	// response.
+	RecvDeltaResponse(s grpc.ClientStream) (proto.Message, error)
+	// ParseDeltaResponse type asserts message to the versioned delta response,
+	// and retrieves the fields.
+	ParseDeltaResponse(r proto.Message) (*DeltaResponse, error)
+}
+
+// DeltaRequest is a request on a delta ADS stream.
+type DeltaRequest struct {
+	// Type is the type of the resources of the request.
+	Type resource.ResourceType
+	// Subscribe are the resource names added to the subscription.
+	Subscribe []string
+	// Unsubscribe are the resource names removed from the subscription.
+	Unsubscribe []string
+	// InitialVersions are the versions of the resources already received,
+	// set on the first request of a type on a new stream so that the server
+	// only sends the ones which changed since.
+	InitialVersions map[string]string
+	// Nonce is the nonce of the response acknowledged, empty if the request
+	// doesn't acknowledge a response.
+	Nonce string
+	// ErrMsg is the reason of the rejection of the response, empty unless the
+	// request is a NACK.
+	ErrMsg string
+}
+
+// DeltaResource is a resource added or changed by a delta response.
+type DeltaResource struct {
+	Name     string
+	Version  string
+	Resource *anypb.Any
+}
+
+// DeltaResponse is a response on a delta ADS stream.
+type DeltaResponse struct {
+	Type resource.ResourceType
+	// Resources are the resources added or changed.
+	Resources []DeltaResource
+	// Removed are the names of the resources removed.
+	Removed []string
+	// SystemVersion is the version of the server state, only used for
+	// debugging.
+	SystemVersion string
+	Nonce         string
+}
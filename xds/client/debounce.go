@@ -0,0 +1,103 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// debouncer coalesces the updates passed to a watch callback within a
+// window. The first update after a quiet window is delivered right away and
+// opens a window, the following ones are held until the end of the window,
+// where only the latest one is delivered and opens a new window. So under
+// continuous churn, the callback is called at most once per window, and the
+// final update is always delivered.
+type debouncer[T any] struct {
+	window time.Duration
+	cb     func(T, error)
+
+	// cbMu serializes the callbacks, so that a trailing update can't overtake
+	// a leading one. It's never held with mu, so that the callback can stop
+	// the debouncer.
+	cbMu sync.Mutex
+
+	mu      sync.Mutex
+	timer   *time.Timer // Set while a window is open.
+	pending bool
+	update  T
+	err     error
+	stopped bool
+}
+
+func newDebouncer[T any](window time.Duration, cb func(T, error)) *debouncer[T] {
+	return &debouncer[T]{window: window, cb: cb}
+}
+
+// push delivers the update right away if no window is open, or else holds it
+// until the end of the window, replacing the one held.
+func (d *debouncer[T]) push(update T, err error) {
+	d.cbMu.Lock()
+	defer d.cbMu.Unlock()
+
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return
+	}
+	if d.timer != nil {
+		d.pending, d.update, d.err = true, update, err
+		d.mu.Unlock()
+		return
+	}
+	d.timer = time.AfterFunc(d.window, d.flush)
+	d.mu.Unlock()
+
+	d.cb(update, err)
+}
+
+// flush closes the window, delivering the update held if any.
+func (d *debouncer[T]) flush() {
+	d.cbMu.Lock()
+	defer d.cbMu.Unlock()
+
+	d.mu.Lock()
+	if d.stopped || !d.pending {
+		d.timer = nil
+		d.mu.Unlock()
+		return
+	}
+	var zero T
+	update, err := d.update, d.err
+	d.pending, d.update, d.err = false, zero, nil
+	d.timer = time.AfterFunc(d.window, d.flush)
+	d.mu.Unlock()
+
+	d.cb(update, err)
+}
+
+// stop drops the update held, if any. No update is delivered after it
+// returns, except for a callback already running.
+func (d *debouncer[T]) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stopped = true
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+}
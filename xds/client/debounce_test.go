@@ -0,0 +1,126 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+const (
+	testDebounceWindow = 50 * time.Millisecond
+	testDebounceWait   = 10 * time.Second
+)
+
+func newRecordingDebouncer() (*debouncer[int], chan int) {
+	ch := make(chan int, 100)
+	return newDebouncer(testDebounceWindow, func(u int, _ error) { ch <- u }), ch
+}
+
+func receive(t *testing.T, ch chan int) int {
+	t.Helper()
+	select {
+	case u := <-ch:
+		return u
+	case <-time.After(testDebounceWait):
+		t.Fatal("timeout waiting for an update")
+		return 0
+	}
+}
+
+func expectNothing(t *testing.T, ch chan int, d time.Duration) {
+	t.Helper()
+	select {
+	case u := <-ch:
+		t.Fatalf("received update %d, want none", u)
+	case <-time.After(d):
+	}
+}
+
+func TestDebouncerCoalescesBurst(t *testing.T) {
+	d, ch := newRecordingDebouncer()
+	defer d.stop()
+
+	for i := 1; i <= 10; i++ {
+		d.push(i, nil)
+	}
+	// The leading update is delivered right away, the latest one at the end of
+	// the window, and the ones in between are dropped.
+	if u := <-ch; u != 1 {
+		t.Fatalf("received leading update %d, want 1", u)
+	}
+	if u := receive(t, ch); u != 10 {
+		t.Fatalf("received trailing update %d, want 10", u)
+	}
+	expectNothing(t, ch, 3*testDebounceWindow)
+
+	// After a quiet window, an update is delivered right away again.
+	d.push(11, nil)
+	if u := <-ch; u != 11 {
+		t.Fatalf("received update %d, want 11", u)
+	}
+}
+
+func TestDebouncerContinuousChurn(t *testing.T) {
+	d, ch := newRecordingDebouncer()
+	defer d.stop()
+
+	start := time.Now()
+	const churn = 6 * testDebounceWindow
+	last := 0
+	for time.Since(start) < churn {
+		last++
+		d.push(last, nil)
+		time.Sleep(testDebounceWindow / 10)
+	}
+
+	var got []int
+	for u := receive(t, ch); ; u = receive(t, ch) {
+		got = append(got, u)
+		if u == last {
+			break
+		}
+	}
+	// Updates keep being delivered during the churn, at most once per window
+	// plus the leading one, and the final update is always delivered.
+	if len(got) < 3 {
+		t.Fatalf("received %v during the churn, want updates delivered as the windows close", got)
+	}
+	if limit := int(time.Since(start)/testDebounceWindow) + 2; len(got) > limit {
+		t.Fatalf("received %d updates, want at most %d", len(got), limit)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Fatalf("received updates %v out of order", got)
+		}
+	}
+	expectNothing(t, ch, 3*testDebounceWindow)
+}
+
+func TestDebouncerStop(t *testing.T) {
+	d, ch := newRecordingDebouncer()
+
+	d.push(1, nil)
+	d.push(2, nil)
+	if u := <-ch; u != 1 {
+		t.Fatalf("received leading update %d, want 1", u)
+	}
+	d.stop()
+	d.push(3, nil)
+	expectNothing(t, ch, 3*testDebounceWindow)
+}
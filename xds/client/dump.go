@@ -23,6 +23,12 @@
 
 package client
 
+import (
+	"github.com/golang/protobuf/proto"
+
+	anypb "github.com/golang/protobuf/ptypes/any"
+)
+
 import (
 	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
 )
@@ -66,3 +72,38 @@ func (c *clientImpl) DumpCDS() map[string]resource.UpdateWithMD {
 func (c *clientImpl) DumpEDS() map[string]resource.UpdateWithMD {
 	return c.dump(resource.EndpointsResource)
 }
+
+// DumpResources returns the status and contents of all the resources cached by
+// every authority, keyed by resource type (e.g. "ListenerResource") and then
+// by resource name. The returned maps are deep copies and can be modified by
+// the caller.
+func (c *clientImpl) DumpResources() map[string]map[string]resource.UpdateWithMD {
+	types := []resource.ResourceType{
+		resource.ListenerResource,
+		resource.RouteConfigResource,
+		resource.ClusterResource,
+		resource.EndpointsResource,
+	}
+	ret := make(map[string]map[string]resource.UpdateWithMD, len(types))
+	for _, t := range types {
+		m := c.dump(t)
+		for name, u := range m {
+			m[name] = copyUpdateWithMD(u)
+		}
+		ret[t.String()] = m
+	}
+	return ret
+}
+
+// copyUpdateWithMD returns a deep copy of u, so it doesn't share the raw
+// message or the error state with the pubsub cache.
+func copyUpdateWithMD(u resource.UpdateWithMD) resource.UpdateWithMD {
+	if u.Raw != nil {
+		u.Raw = proto.Clone(u.Raw).(*anypb.Any)
+	}
+	if u.MD.ErrState != nil {
+		errState := *u.MD.ErrState
+		u.MD.ErrState = &errState
+	}
+	return u
+}
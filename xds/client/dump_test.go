@@ -0,0 +1,97 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// TestDumpResourcesCopy verifies that modifying the dumped resources doesn't
+// change the cached ones.
+func TestDumpResourcesCopy(t *testing.T) {
+	c, _ := newFakeControllerClient(t)
+	cancelA := c.WatchCluster("cds-a", func(resource.ClusterUpdate, error) {})
+	defer cancelA()
+	cancelB := c.WatchCluster("cds-b", func(resource.ClusterUpdate, error) {})
+	defer cancelB()
+	a, unref, err := c.findAuthority(resource.ParseName(""))
+	if err != nil {
+		t.Fatalf("findAuthority() failed: %v", err)
+	}
+	defer unref()
+
+	// cds-b is NACKed, so its metadata has an error state. cds-a is ACKed, with
+	// the version of the response.
+	a.pubsub.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"cds-a": {Update: resource.ClusterUpdate{ClusterName: "cds-a", Raw: &anypb.Any{TypeUrl: "cds", Value: []byte("a")}}},
+		"cds-b": {Err: errors.New("invalid cds-b")},
+	}, resource.UpdateMetadata{
+		Status:   resource.ServiceStatusNACKed,
+		Version:  "1",
+		ErrState: &resource.UpdateErrorMetadata{Version: "2", Err: errors.New("invalid cds-b")},
+	})
+
+	got := c.DumpResources()
+	for _, typ := range []string{"ListenerResource", "RouteConfigResource", "ClusterResource", "EndpointsResource"} {
+		if _, ok := got[typ]; !ok {
+			t.Fatalf("DumpResources() has no %s", typ)
+		}
+	}
+	clusters := got["ClusterResource"]
+	if clusters["cds-a"].Raw == nil || clusters["cds-b"].MD.ErrState == nil {
+		t.Fatalf("DumpResources() returned clusters %+v, want cds-a with its raw message and cds-b with its error state", clusters)
+	}
+	clusters["cds-a"].Raw.Value[0] = 'x'
+	clusters["cds-b"].MD.ErrState.Version = "x"
+	delete(clusters, "cds-a")
+	delete(got, "ListenerResource")
+
+	got = c.DumpResources()
+	if _, ok := got["ListenerResource"]; !ok {
+		t.Fatal("DumpResources() has no ListenerResource after it's deleted from a previous dump")
+	}
+	want := map[string]resource.UpdateWithMD{
+		"cds-a": {
+			MD:  resource.UpdateMetadata{Status: resource.ServiceStatusACKed, Version: "2"},
+			Raw: &anypb.Any{TypeUrl: "cds", Value: []byte("a")},
+		},
+		"cds-b": {
+			MD: resource.UpdateMetadata{
+				Status:   resource.ServiceStatusNACKed,
+				ErrState: &resource.UpdateErrorMetadata{Version: "2", Err: errors.New("invalid cds-b")},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got["ClusterResource"], protocmp.Transform(), cmpopts.IgnoreFields(resource.UpdateMetadata{}, "LastUpdated"),
+		cmp.Comparer(func(x, y error) bool { return x.Error() == y.Error() })); diff != "" {
+		t.Fatalf("DumpResources() changed after modifying a previous dump (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+
+	_struct "github.com/golang/protobuf/ptypes/struct"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/bootstrap"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/controller"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/load"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/pubsub"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// fakeController records the metadata set, the number of flushes and the
+// resources watched.
+type fakeController struct {
+	metadata []*_struct.Struct
+	flushes  int
+	watches  chan string
+}
+
+func (f *fakeController) AddWatch(_ resource.ResourceType, name string) {
+	select {
+	case f.watches <- name:
+	default:
+	}
+}
+
+func (f *fakeController) RemoveWatch(resource.ResourceType, string) {}
+func (f *fakeController) ReportLoad(string) (*load.Store, func())   { return nil, func() {} }
+func (f *fakeController) Ready() <-chan struct{}                    { return make(chan struct{}) }
+func (f *fakeController) ActiveServer() *bootstrap.ServerConfig     { return nil }
+func (f *fakeController) Health() controller.Health                 { return controller.Health{} }
+func (f *fakeController) SetAckCallback(controller.AckCallback)     {}
+func (f *fakeController) SetAckPolicy(controller.AckPolicy)         {}
+func (f *fakeController) Close()                                    {}
+
+func (f *fakeController) SetMetadata(m *_struct.Struct) error {
+	f.metadata = append(f.metadata, m)
+	return nil
+}
+
+func (f *fakeController) FlushMetadata() {
+	f.flushes++
+}
+
+// newFakeControllerClient returns a client whose authorities all talk to
+// the returned fakeController instead of a management server.
+func newFakeControllerClient(t *testing.T) (*clientImpl, *fakeController) {
+	t.Helper()
+	fc := &fakeController{watches: make(chan string, 10)}
+	oldNewController := newController
+	newController = func(*bootstrap.ServerConfig, []*bootstrap.ServerConfig, pubsub.UpdateHandler, resource.UpdateValidatorFunc, dubbogoLogger.Logger) (controllerInterface, error) {
+		return fc, nil
+	}
+	t.Cleanup(func() { newController = oldNewController })
+
+	c, err := newWithConfig(&bootstrap.Config{XDSServer: &bootstrap.ServerConfig{ServerURI: "xds.example.com:443"}}, time.Second, time.Minute, 0)
+	if err != nil {
+		t.Fatalf("newWithConfig() failed: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c, fc
+}
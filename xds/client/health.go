@@ -0,0 +1,104 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"time"
+)
+
+// AuthorityState is the state of the connection of an authority to its
+// management server.
+type AuthorityState int
+
+const (
+	// AuthorityConnected means the ADS stream is up and has received a
+	// response.
+	AuthorityConnected AuthorityState = iota
+	// AuthorityDisconnected means the ADS stream is down, or hasn't received
+	// any response yet.
+	AuthorityDisconnected
+	// AuthorityIdle means the authority has no watch left and is waiting to
+	// be deleted, its state doesn't matter to the watches.
+	AuthorityIdle
+)
+
+var authorityStateStrings = [...]string{
+	"connected",
+	"disconnected",
+	"idle",
+}
+
+func (s AuthorityState) String() string {
+	if s < 0 || int(s) >= len(authorityStateStrings) {
+		return "unknown"
+	}
+	return authorityStateStrings[s]
+}
+
+// AuthorityStatus is the health of an authority.
+type AuthorityStatus struct {
+	State AuthorityState
+	// Connected is true while the ADS stream is up and has received a
+	// response. An idle authority may still be connected.
+	Connected bool
+	// LastError is the last error the ADS stream failed with, if any.
+	LastError error
+	// LastResponseTime is when the last response was received, zero if none
+	// was.
+	LastResponseTime time.Time
+	// ResourceCount is the number of resources received and cached.
+	ResourceCount int
+}
+
+// AuthorityHealth returns the health of the authorities in use and of the idle
+// ones, so that the loss of some of the management servers can be told apart
+// from a total outage. The key is ServerConfig.String(), as for the
+// authorities.
+func (c *clientImpl) AuthorityHealth() map[string]AuthorityStatus {
+	c.authorityMu.Lock()
+	defer c.authorityMu.Unlock()
+	ret := make(map[string]AuthorityStatus, len(c.authorities))
+	for key, a := range c.authorities {
+		ret[key] = a.status(false)
+	}
+	for _, item := range c.idleAuthorities.Items() {
+		if a, ok := item.(*authority); ok {
+			ret[a.config.String()] = a.status(true)
+		}
+	}
+	return ret
+}
+
+// status returns the health of the authority.
+func (a *authority) status(idle bool) AuthorityStatus {
+	h := a.controller.Health()
+	status := AuthorityStatus{
+		State:            AuthorityDisconnected,
+		Connected:        h.Connected,
+		LastError:        h.LastError,
+		LastResponseTime: h.LastResponseTime,
+		ResourceCount:    a.pubsub.ResourceCount(),
+	}
+	switch {
+	case idle:
+		status.State = AuthorityIdle
+	case h.Connected:
+		status.State = AuthorityConnected
+	}
+	return status
+}
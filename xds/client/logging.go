@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+)
+
+// LogLevel is the minimum level of the logs of an xds client. The logs below
+// it are dropped before they reach the logger.
+type LogLevel int32
+
+const (
+	// LogLevelDebug keeps all the logs, including the ones of every ADS
+	// message. It's the default, the level of the logger still applies.
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+var logLevelNames = map[LogLevel]string{
+	LogLevelDebug: "debug",
+	LogLevelInfo:  "info",
+	LogLevelWarn:  "warn",
+	LogLevelError: "error",
+}
+
+func (l LogLevel) String() string {
+	if name, ok := logLevelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("LogLevel(%d)", int32(l))
+}
+
+// ParseLogLevel returns the level named s, one of "debug", "info", "warn" and
+// "error", case insensitive.
+func ParseLogLevel(s string) (LogLevel, error) {
+	for l, name := range logLevelNames {
+		if strings.EqualFold(s, name) {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("xds: unknown log level %q", s)
+}
+
+// levelLogger drops the logs below its level, which can be changed at
+// runtime. Fatal logs are never dropped.
+type levelLogger struct {
+	dubbogoLogger.Logger
+	level int32 // LogLevel, accessed atomically.
+}
+
+func newLevelLogger(logger dubbogoLogger.Logger, level LogLevel) *levelLogger {
+	return &levelLogger{Logger: logger, level: int32(level)}
+}
+
+func (l *levelLogger) setLevel(level LogLevel) {
+	atomic.StoreInt32(&l.level, int32(level))
+}
+
+func (l *levelLogger) enabled(level LogLevel) bool {
+	return LogLevel(atomic.LoadInt32(&l.level)) <= level
+}
+
+func (l *levelLogger) Debug(args ...any) {
+	if l.enabled(LogLevelDebug) {
+		l.Logger.Debug(args...)
+	}
+}
+
+func (l *levelLogger) Debugf(format string, args ...any) {
+	if l.enabled(LogLevelDebug) {
+		l.Logger.Debugf(format, args...)
+	}
+}
+
+func (l *levelLogger) Info(args ...any) {
+	if l.enabled(LogLevelInfo) {
+		l.Logger.Info(args...)
+	}
+}
+
+func (l *levelLogger) Infof(format string, args ...any) {
+	if l.enabled(LogLevelInfo) {
+		l.Logger.Infof(format, args...)
+	}
+}
+
+func (l *levelLogger) Warn(args ...any) {
+	if l.enabled(LogLevelWarn) {
+		l.Logger.Warn(args...)
+	}
+}
+
+func (l *levelLogger) Warnf(format string, args ...any) {
+	if l.enabled(LogLevelWarn) {
+		l.Logger.Warnf(format, args...)
+	}
+}
+
+func (l *levelLogger) Error(args ...any) {
+	if l.enabled(LogLevelError) {
+		l.Logger.Error(args...)
+	}
+}
+
+func (l *levelLogger) Errorf(format string, args ...any) {
+	if l.enabled(LogLevelError) {
+		l.Logger.Errorf(format, args...)
+	}
+}
@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"os"
+	"regexp"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+
+	"github.com/golang/protobuf/proto"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+)
+
+// envVarPattern matches the ${VAR} references in node metadata values.
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ResolveMetadataEnv returns a copy of the node metadata m, with the ${VAR}
+// references in string values, including the nested ones, replaced by the
+// value of the environment variable VAR. Unset variables are replaced by empty
+// strings.
+func ResolveMetadataEnv(m *_struct.Struct) *_struct.Struct {
+	if m == nil {
+		return nil
+	}
+	ret := proto.Clone(m).(*_struct.Struct)
+	resolveStructEnv(ret)
+	return ret
+}
+
+func resolveStructEnv(s *_struct.Struct) {
+	for _, v := range s.GetFields() {
+		resolveValueEnv(v)
+	}
+}
+
+func resolveValueEnv(v *_struct.Value) {
+	switch kind := v.GetKind().(type) {
+	case *_struct.Value_StringValue:
+		kind.StringValue = expandEnv(kind.StringValue)
+	case *_struct.Value_StructValue:
+		resolveStructEnv(kind.StructValue)
+	case *_struct.Value_ListValue:
+		for _, item := range kind.ListValue.GetValues() {
+			resolveValueEnv(item)
+		}
+	}
+}
+
+func expandEnv(s string) string {
+	return envVarPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envVarPattern.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			dubbogoLogger.Debugf("[XDS Metadata] environment variable %s referenced by node metadata is not set", name)
+		}
+		return value
+	})
+}
+
+// BatchMetadata collects node metadata fields to be changed at once with
+// UpdateMetadata, flushing them to the management server together, e.g.
+//
+//	err := c.UpdateMetadata(NewBatchMetadata().
+//		SetString("POD_NAME", "${POD_NAME}").
+//		SetString("POD_NAMESPACE", "${POD_NAMESPACE}").
+//		Apply)
+//
+// The changes are applied in the order they are made.
+type BatchMetadata struct {
+	changes []metadataChange
+}
+
+// metadataChange sets the field key to value, or deletes it if value is nil.
+type metadataChange struct {
+	key   string
+	value *_struct.Value
+}
+
+// NewBatchMetadata returns an empty BatchMetadata.
+func NewBatchMetadata() *BatchMetadata {
+	return &BatchMetadata{}
+}
+
+// Set sets the field key to value.
+func (b *BatchMetadata) Set(key string, value *_struct.Value) *BatchMetadata {
+	b.changes = append(b.changes, metadataChange{key: key, value: value})
+	return b
+}
+
+// SetString sets the field key to the string value.
+func (b *BatchMetadata) SetString(key, value string) *BatchMetadata {
+	return b.Set(key, &_struct.Value{Kind: &_struct.Value_StringValue{StringValue: value}})
+}
+
+// Delete deletes the field key.
+func (b *BatchMetadata) Delete(key string) *BatchMetadata {
+	b.changes = append(b.changes, metadataChange{key: key})
+	return b
+}
+
+// Apply applies the changes to m. It's meant to be given to UpdateMetadata.
+func (b *BatchMetadata) Apply(m *_struct.Struct) {
+	if m.Fields == nil {
+		m.Fields = make(map[string]*_struct.Value)
+	}
+	for _, c := range b.changes {
+		if c.value == nil {
+			delete(m.Fields, c.key)
+			continue
+		}
+		m.Fields[c.key] = proto.Clone(c.value).(*_struct.Value)
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/golang/protobuf/proto"
+	_struct "github.com/golang/protobuf/ptypes/struct"
+)
+
+func stringValue(s string) *_struct.Value {
+	return &_struct.Value{Kind: &_struct.Value_StringValue{StringValue: s}}
+}
+
+func TestUpdateMetadataFlushesOnce(t *testing.T) {
+	t.Setenv("TEST_POD_NAME", "foo-7d9f")
+	c, fc := newFakeControllerClient(t)
+
+	if err := c.SetMetadata(&_struct.Struct{Fields: map[string]*_struct.Value{
+		"CLUSTER_ID": stringValue("Kubernetes"),
+		"STALE":      stringValue("x"),
+	}}); err != nil {
+		t.Fatalf("SetMetadata() failed: %v", err)
+	}
+	if fc.flushes != 1 {
+		t.Fatalf("SetMetadata() flushed %d times, want 1", fc.flushes)
+	}
+
+	err := c.UpdateMetadata(NewBatchMetadata().
+		SetString("POD_NAME", "${TEST_POD_NAME}").
+		SetString("NAMESPACE", "default").
+		Set("LABELS", &_struct.Value{Kind: &_struct.Value_StructValue{StructValue: &_struct.Struct{
+			Fields: map[string]*_struct.Value{"app": stringValue("foo")},
+		}}}).
+		Delete("STALE").
+		Apply)
+	if err != nil {
+		t.Fatalf("UpdateMetadata() failed: %v", err)
+	}
+	if fc.flushes != 2 || len(fc.metadata) != 2 {
+		t.Fatalf("UpdateMetadata() set the metadata %d times and flushed it %d times, want once more each", len(fc.metadata)-1, fc.flushes-1)
+	}
+	want := &_struct.Struct{Fields: map[string]*_struct.Value{
+		"CLUSTER_ID": stringValue("Kubernetes"),
+		"POD_NAME":   stringValue("foo-7d9f"),
+		"NAMESPACE":  stringValue("default"),
+		"LABELS": {Kind: &_struct.Value_StructValue{StructValue: &_struct.Struct{
+			Fields: map[string]*_struct.Value{"app": stringValue("foo")},
+		}}},
+	}}
+	if got := fc.metadata[1]; !proto.Equal(got, want) {
+		t.Fatalf("UpdateMetadata() set metadata %v, want %v", got, want)
+	}
+
+	// The next update starts from the metadata last set, before the
+	// resolution of the environment variables.
+	if err := c.UpdateMetadata(func(m *_struct.Struct) {
+		if got := m.GetFields()["POD_NAME"].GetStringValue(); got != "${TEST_POD_NAME}" {
+			t.Errorf("UpdateMetadata() got POD_NAME %q, want ${TEST_POD_NAME}", got)
+		}
+	}); err != nil {
+		t.Fatalf("UpdateMetadata() failed: %v", err)
+	}
+	if fc.flushes != 3 {
+		t.Fatalf("UpdateMetadata() flushed %d times in total, want 3", fc.flushes)
+	}
+}
+
+func TestBatchMetadataApply(t *testing.T) {
+	m := &_struct.Struct{}
+	NewBatchMetadata().
+		SetString("a", "1").
+		SetString("b", "2").
+		Delete("a").
+		SetString("b", "3").
+		Apply(m)
+	want := &_struct.Struct{Fields: map[string]*_struct.Value{"b": stringValue("3")}}
+	if !proto.Equal(m, want) {
+		t.Fatalf("Apply() returned %v, want %v", m, want)
+	}
+}
+
+func TestResolveMetadataEnv(t *testing.T) {
+	t.Setenv("TEST_POD_NAME", "foo-7d9f")
+	t.Setenv("TEST_ZONE", "zone-a")
+	structValue := func(fields map[string]*_struct.Value) *_struct.Value {
+		return &_struct.Value{Kind: &_struct.Value_StructValue{StructValue: &_struct.Struct{Fields: fields}}}
+	}
+	listValue := func(values ...*_struct.Value) *_struct.Value {
+		return &_struct.Value{Kind: &_struct.Value_ListValue{ListValue: &_struct.ListValue{Values: values}}}
+	}
+
+	m := &_struct.Struct{Fields: map[string]*_struct.Value{
+		"POD_NAME": stringValue("${TEST_POD_NAME}"),
+		"LOCALITY": stringValue("${TEST_ZONE}/${TEST_POD_NAME}"),
+		"UNSET":    stringValue("x${TEST_UNSET_VAR}y"),
+		"LITERAL":  stringValue("$TEST_ZONE ${not a var}"),
+		"NUMBER":   {Kind: &_struct.Value_NumberValue{NumberValue: 1}},
+		"LABELS":   structValue(map[string]*_struct.Value{"zone": stringValue("${TEST_ZONE}")}),
+		"TAGS":     listValue(stringValue("${TEST_ZONE}"), structValue(map[string]*_struct.Value{"pod": stringValue("${TEST_POD_NAME}")})),
+	}}
+	orig := proto.Clone(m)
+
+	want := &_struct.Struct{Fields: map[string]*_struct.Value{
+		"POD_NAME": stringValue("foo-7d9f"),
+		"LOCALITY": stringValue("zone-a/foo-7d9f"),
+		"UNSET":    stringValue("xy"),
+		"LITERAL":  stringValue("$TEST_ZONE ${not a var}"),
+		"NUMBER":   {Kind: &_struct.Value_NumberValue{NumberValue: 1}},
+		"LABELS":   structValue(map[string]*_struct.Value{"zone": stringValue("zone-a")}),
+		"TAGS":     listValue(stringValue("zone-a"), structValue(map[string]*_struct.Value{"pod": stringValue("foo-7d9f")})),
+	}}
+	if got := ResolveMetadataEnv(m); !proto.Equal(got, want) {
+		t.Fatalf("ResolveMetadataEnv() = %v, want %v", got, want)
+	}
+	if !proto.Equal(m, orig) {
+		t.Fatalf("ResolveMetadataEnv() modified its argument to %v", m)
+	}
+	if got := ResolveMetadataEnv(nil); got != nil {
+		t.Fatalf("ResolveMetadataEnv(nil) = %v, want nil", got)
+	}
+}
+
+func TestSetMetadataResolvesEnv(t *testing.T) {
+	t.Setenv("TEST_POD_NAME", "foo-7d9f")
+	c, fc := newFakeControllerClient(t)
+
+	if err := c.SetMetadata(&_struct.Struct{Fields: map[string]*_struct.Value{
+		"POD_NAME": stringValue("${TEST_POD_NAME}"),
+	}}); err != nil {
+		t.Fatalf("SetMetadata() failed: %v", err)
+	}
+	want := &_struct.Struct{Fields: map[string]*_struct.Value{"POD_NAME": stringValue("foo-7d9f")}}
+	if len(fc.metadata) != 1 || !proto.Equal(fc.metadata[0], want) {
+		t.Fatalf("SetMetadata() set metadata %v, want [%v]", fc.metadata, want)
+	}
+}
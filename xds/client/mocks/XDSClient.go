@@ -19,6 +19,10 @@
 
 package mocks
 
+import (
+	context "context"
+)
+
 import (
 	mock "github.com/stretchr/testify/mock"
 
@@ -160,6 +164,48 @@ func (_m *XDSClient) SetMetadata(_a0 *structpb.Struct) error {
 	return r0
 }
 
+// UpdateMetadata provides a mock function with given fields: update
+func (_m *XDSClient) UpdateMetadata(update func(*structpb.Struct)) error {
+	ret := _m.Called(update)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(func(*structpb.Struct)) error); ok {
+		r0 = rf(update)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Warm provides a mock function with given fields: ctx, resources
+func (_m *XDSClient) Warm(ctx context.Context, resources []resource.Name) error {
+	ret := _m.Called(ctx, resources)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []resource.Name) error); ok {
+		r0 = rf(ctx, resources)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WaitForReady provides a mock function with given fields: ctx
+func (_m *XDSClient) WaitForReady(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // WatchCluster provides a mock function with given fields: _a0, _a1
 func (_m *XDSClient) WatchCluster(_a0 string, _a1 func(resource.ClusterUpdate, error)) func() {
 	ret := _m.Called(_a0, _a1)
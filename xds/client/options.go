@@ -0,0 +1,106 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"time"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/controller"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// Option configures an xds client.
+type Option func(*clientOptions)
+
+type clientOptions struct {
+	logger            dubbogoLogger.Logger
+	level             LogLevel
+	endpointsDebounce time.Duration
+	validateOnly      bool
+	ackPolicy         controller.AckPolicy
+	// watchExpiryTimeouts is nil unless WithWatchExpiryTimeouts is set.
+	watchExpiryTimeouts map[resource.ResourceType]time.Duration
+}
+
+// WithLogger sets the logger of the client, instead of the global one.
+func WithLogger(logger dubbogoLogger.Logger) Option {
+	return func(o *clientOptions) {
+		o.logger = logger
+	}
+}
+
+// WithLogLevel sets the minimum level of the logs of the client. It can be
+// changed later with SetLogLevel.
+func WithLogLevel(level LogLevel) Option {
+	return func(o *clientOptions) {
+		o.level = level
+	}
+}
+
+// WithEndpointsDebounce coalesces the EDS updates of each endpoints watch
+// within window, to smooth out the balancer churn when the endpoints flap,
+// e.g. during a rollout. The first update after a quiet window is delivered
+// right away, the following ones at the end of the window, only the latest
+// one being delivered. A window of zero, the default, disables it.
+func WithEndpointsDebounce(window time.Duration) Option {
+	return func(o *clientOptions) {
+		o.endpointsDebounce = window
+	}
+}
+
+// WithWatchExpiryTimeouts sets the watch expiry timeouts of some resource
+// types, e.g. a longer one for EDS, whose resources can legitimately take
+// longer to arrive than the LDS ones. The types missing from timeouts use
+// the client watch expiry timeout.
+func WithWatchExpiryTimeouts(timeouts map[resource.ResourceType]time.Duration) Option {
+	return func(o *clientOptions) {
+		o.watchExpiryTimeouts = make(map[resource.ResourceType]time.Duration, len(timeouts))
+		for rType, d := range timeouts {
+			o.watchExpiryTimeouts[rType] = d
+		}
+	}
+}
+
+// WithValidateOnly makes the client a dry run: it connects to the management
+// servers, receives and validates the resources watched, and ACKs or NACKs
+// them, but it doesn't apply them, so the watchers never receive any update,
+// nor any error. What would be done with each resource is logged instead, and
+// reported by ValidationReport. It allows to vet a control plane safely.
+func WithValidateOnly() Option {
+	return func(o *clientOptions) {
+		o.validateOnly = true
+	}
+}
+
+// WithAckPolicy sets how the responses carrying invalid resources are
+// acknowledged. With controller.AckPolicyPermissive, they are ACKed and the
+// invalid resources are only logged, e.g. to keep a control plane which
+// doesn't handle NACKs from resending them, while controller.AckPolicyStrict,
+// the default, NACKs them. In both cases the invalid resources aren't
+// applied.
+func WithAckPolicy(p controller.AckPolicy) Option {
+	return func(o *clientOptions) {
+		o.ackPolicy = p
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pubsub
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// SetClusterNameFilter sets the filter of the CDS resources received for the
+// wildcard watch: the clusters rejected by accept are neither cached nor sent
+// to the watchers, as if the server didn't send them. The clusters watched by
+// name are always accepted, so that they are never reported as not found
+// because of the filter. A nil accept removes the filter.
+func (pb *Pubsub) SetClusterNameFilter(accept func(name string) bool) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.acceptCluster = accept
+}
+
+// filterClustersLocked returns the updates of the clusters accepted by the
+// cluster name filter.
+//
+// Caller must hold pb.mu.
+func (pb *Pubsub) filterClustersLocked(updates map[string]resource.ClusterUpdateErrTuple) map[string]resource.ClusterUpdateErrTuple {
+	if pb.acceptCluster == nil {
+		return updates
+	}
+	filtered := make(map[string]resource.ClusterUpdateErrTuple, len(updates))
+	for name, uErr := range updates {
+		if _, ok := pb.cdsWatchers[name]; !ok && !pb.acceptCluster(name) {
+			pb.logger.Debugf("CDS resource with name %v rejected by the cluster name filter", name)
+			continue
+		}
+		filtered[name] = uErr
+	}
+	return filtered
+}
@@ -0,0 +1,85 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pubsub
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+func TestClusterNameFilter(t *testing.T) {
+	pb := New(testWatchExpiryTimeout, dubbogoLogger.GetLogger())
+	defer pb.Close()
+	pb.SetClusterNameFilter(func(name string) bool { return strings.HasPrefix(name, "keep-") })
+
+	wildcard := watchClusterCh(pb, "*")
+	watched := watchClusterCh(pb, "drop-watched")
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"keep-a":       {Update: resource.ClusterUpdate{ClusterName: "keep-a"}},
+		"drop-b":       {Update: resource.ClusterUpdate{ClusterName: "drop-b"}},
+		"drop-watched": {Update: resource.ClusterUpdate{ClusterName: "drop-watched"}},
+	}, resource.UpdateMetadata{})
+
+	if r := receiveCluster(t, wildcard); r.err != nil || r.name != "keep-a" {
+		t.Fatalf("wildcard watch got %+v, want the keep-a update", r)
+	}
+	// The clusters watched by name are accepted even if the filter rejects
+	// them.
+	if r := receiveCluster(t, watched); r.err != nil || r.name != "drop-watched" {
+		t.Fatalf("drop-watched watch got %+v, want its update", r)
+	}
+	select {
+	case r := <-wildcard:
+		t.Fatalf("wildcard watch got unexpected %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	pb.mu.Lock()
+	cached := keys(pb.cdsCache)
+	pb.mu.Unlock()
+	sort.Strings(cached)
+	if diff := cmp.Diff([]string{"drop-watched", "keep-a"}, cached); diff != "" {
+		t.Fatalf("cached clusters mismatch (-want +got):\n%s", diff)
+	}
+
+	// A rejected cluster missing from the next response is not reported as
+	// removed, and the accepted ones are left untouched.
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"keep-a":       {Update: resource.ClusterUpdate{ClusterName: "keep-a"}},
+		"drop-watched": {Update: resource.ClusterUpdate{ClusterName: "drop-watched"}},
+	}, resource.UpdateMetadata{})
+	select {
+	case r := <-wildcard:
+		t.Fatalf("wildcard watch got unexpected %+v", r)
+	case r := <-watched:
+		t.Fatalf("drop-watched watch got unexpected %+v", r)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -41,6 +41,9 @@ type UpdateHandler interface {
 	// NewEndpoints handles updates to xDS ClusterLoadAssignment (or tersely
 	// referred to as Endpoints) resources.
 	NewEndpoints(map[string]resource.EndpointsUpdateErrTuple, resource.UpdateMetadata)
+	// RemoveResources handles the resources explicitly removed by the server,
+	// with the delta variant of the ADS protocol.
+	RemoveResources(resource.ResourceType, []string)
 	// NewConnectionError handles connection errors from the xDS stream. The
 	// error will be reported to all the resource watchers.
 	NewConnectionError(err error)
@@ -52,6 +52,10 @@ type Pubsub struct {
 	done               *grpcsync.Event
 	logger             dubbogoLogger.Logger
 	watchExpiryTimeout time.Duration
+	// watchExpiryTimeouts are the per type expiry timeouts set by
+	// SetTypeWatchExpiryTimeout, they take precedence over
+	// watchExpiryTimeout. Both are protected by mu.
+	watchExpiryTimeouts map[resource.ResourceType]time.Duration
 
 	updateCh *buffer.Unbounded // chan *watcherInfoWithUpdate
 	// All the following maps are to keep the updates/metadata in a cache.
@@ -68,6 +72,21 @@ type Pubsub struct {
 	edsWatchers map[string]map[*watchInfo]bool
 	edsCache    map[string]resource.EndpointsUpdate
 	edsMD       map[string]resource.UpdateMetadata
+
+	// maxStaleness is the max staleness per resource type, set by
+	// SetMaxStaleness. stalenessTimer runs the next check, it's nil when no
+	// max staleness is set. Both are protected by mu.
+	maxStaleness   map[resource.ResourceType]time.Duration
+	stalenessTimer *time.Timer
+	// now returns the current time, it's replaced in tests.
+	now func() time.Time
+	// ignoreResourceDeletion is set by SetIgnoreResourceDeletion, it's
+	// protected by mu.
+	ignoreResourceDeletion bool
+	// incremental is set by SetIncremental, it's protected by mu.
+	incremental bool
+	// acceptCluster is set by SetClusterNameFilter, it's protected by mu.
+	acceptCluster func(name string) bool
 }
 
 // New creates a new Pubsub.
@@ -77,6 +96,8 @@ func New(watchExpiryTimeout time.Duration, logger dubbogoLogger.Logger) *Pubsub
 		logger:             logger,
 		watchExpiryTimeout: watchExpiryTimeout,
 
+		watchExpiryTimeouts: make(map[resource.ResourceType]time.Duration),
+
 		updateCh:    buffer.NewUnbounded(),
 		ldsWatchers: make(map[string]map[*watchInfo]bool),
 		ldsCache:    make(map[string]resource.ListenerUpdate),
@@ -90,6 +111,9 @@ func New(watchExpiryTimeout time.Duration, logger dubbogoLogger.Logger) *Pubsub
 		edsWatchers: make(map[string]map[*watchInfo]bool),
 		edsCache:    make(map[string]resource.EndpointsUpdate),
 		edsMD:       make(map[string]resource.UpdateMetadata),
+
+		maxStaleness: make(map[resource.ResourceType]time.Duration),
+		now:          time.Now,
 	}
 	go pb.run()
 	return pb
@@ -106,9 +130,7 @@ func (pb *Pubsub) WatchListener(serviceName string, cb func(resource.ListenerUpd
 		ldsCallback: cb,
 	}
 
-	wi.expiryTimer = time.AfterFunc(pb.watchExpiryTimeout, func() {
-		wi.timeout()
-	})
+	pb.startExpiryTimer(wi)
 	return pb.watch(wi)
 }
 
@@ -123,9 +145,7 @@ func (pb *Pubsub) WatchRouteConfig(routeName string, cb func(resource.RouteConfi
 		rdsCallback: cb,
 	}
 
-	wi.expiryTimer = time.AfterFunc(pb.watchExpiryTimeout, func() {
-		wi.timeout()
-	})
+	pb.startExpiryTimer(wi)
 	return pb.watch(wi)
 }
 
@@ -140,9 +160,7 @@ func (pb *Pubsub) WatchCluster(clusterName string, cb func(resource.ClusterUpdat
 		cdsCallback: cb,
 	}
 
-	wi.expiryTimer = time.AfterFunc(pb.watchExpiryTimeout, func() {
-		wi.timeout()
-	})
+	pb.startExpiryTimer(wi)
 	return pb.watch(wi)
 }
 
@@ -157,18 +175,116 @@ func (pb *Pubsub) WatchEndpoints(clusterName string, cb func(resource.EndpointsU
 		edsCallback: cb,
 	}
 
-	wi.expiryTimer = time.AfterFunc(pb.watchExpiryTimeout, func() {
-		wi.timeout()
-	})
+	pb.startExpiryTimer(wi)
 	return pb.watch(wi)
 }
 
+// startExpiryTimer starts the expiry timer of wi, unless the watch expiry
+// timeout of its type is not positive.
+func (pb *Pubsub) startExpiryTimer(wi *watchInfo) {
+	if d := pb.TypeWatchExpiryTimeout(wi.rType); d > 0 {
+		wi.expiryTimer = time.AfterFunc(d, wi.timeout)
+	}
+}
+
+// SetWatchExpiryTimeout updates the expiry timeout of the watches started
+// after this call. Timers of existing watches keep their original deadline.
+// A timeout that is not positive disables the expiry, the watches then wait
+// for their resource forever.
+func (pb *Pubsub) SetWatchExpiryTimeout(d time.Duration) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.watchExpiryTimeout = d
+}
+
+// SetIgnoreResourceDeletion sets whether the LDS and CDS resources previously
+// received and missing from a response are kept, as required by the
+// "ignore_resource_deletion" server feature. When set, such a resource stays
+// in the cache and its watchers keep the last update they received, instead of
+// getting a resource not found error. A warning is logged instead.
+func (pb *Pubsub) SetIgnoreResourceDeletion(ignore bool) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.ignoreResourceDeletion = ignore
+}
+
+// SetIncremental sets whether the updates are incremental, as sent with the
+// delta variant of the ADS protocol. An incremental update only carries the
+// added and changed resources, and the removed ones are passed to
+// RemoveResources. A resource missing from such an update is neither deleted
+// nor reported as not found.
+func (pb *Pubsub) SetIncremental(incremental bool) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.incremental = incremental
+}
+
+// WatchExpiryTimeout returns the expiry timeout used by new watches.
+func (pb *Pubsub) WatchExpiryTimeout() time.Duration {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	return pb.watchExpiryTimeout
+}
+
+// SetTypeWatchExpiryTimeout sets the expiry timeout of the watches of rType
+// started after this call, instead of the one set by SetWatchExpiryTimeout.
+// It allows to wait longer for the types which legitimately take longer to
+// arrive, e.g. EDS.
+func (pb *Pubsub) SetTypeWatchExpiryTimeout(rType resource.ResourceType, d time.Duration) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.watchExpiryTimeouts[rType] = d
+}
+
+// TypeWatchExpiryTimeout returns the expiry timeout used by new watches of
+// rType.
+func (pb *Pubsub) TypeWatchExpiryTimeout(rType resource.ResourceType) time.Duration {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if d, ok := pb.watchExpiryTimeouts[rType]; ok {
+		return d
+	}
+	return pb.watchExpiryTimeout
+}
+
 // Close closes the pubsub.
 func (pb *Pubsub) Close() {
 	if pb.done.HasFired() {
 		return
 	}
 	pb.done.Fire()
+	pb.stopStalenessCheck()
+}
+
+// CloseWithError closes the pubsub, and invokes the callback of every active
+// watcher once with err, so that the watchers don't wait forever for updates.
+// The watches are canceled by this call, their callbacks are not invoked
+// again.
+//
+// The callbacks are invoked inline, without pb.mu, so they can cancel their
+// watches. Callbacks already scheduled before this call may still be running
+// concurrently.
+func (pb *Pubsub) CloseWithError(err error) {
+	if pb.done.HasFired() {
+		return
+	}
+	pb.done.Fire()
+	pb.stopStalenessCheck()
+
+	var wis []*watchInfo
+	pb.mu.Lock()
+	for _, watchers := range []map[string]map[*watchInfo]bool{pb.ldsWatchers, pb.rdsWatchers, pb.cdsWatchers, pb.edsWatchers} {
+		for _, s := range watchers {
+			for wi := range s {
+				wis = append(wis, wi)
+			}
+		}
+	}
+	pb.mu.Unlock()
+
+	for _, wi := range wis {
+		wi.closeWithError(err)
+	}
 }
 
 // run is a goroutine for all the callbacks.
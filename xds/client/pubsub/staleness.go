@@ -0,0 +1,158 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pubsub
+
+import (
+	"time"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// SetMaxStaleness sets the max staleness of the resources of type rType. A
+// cached resource becomes stale when it wasn't updated for longer than d, that
+// is when it wasn't received in an accepted response for longer than d. The
+// watchers of a stale resource get an error of type
+// resource.ErrorTypeResourceStale, once until the resource is updated again.
+// The resource is kept in the cache, and the watchers can keep using the last
+// update they received.
+//
+// The resources are checked every half of the smallest max staleness, so the
+// error is sent between d and 1.5*d after the last update. d <= 0 disables the
+// check for rType, which is the default.
+func (pb *Pubsub) SetMaxStaleness(rType resource.ResourceType, d time.Duration) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if d <= 0 {
+		delete(pb.maxStaleness, rType)
+	} else {
+		pb.maxStaleness[rType] = d
+	}
+	pb.scheduleStalenessCheckLocked()
+}
+
+// MaxStaleness returns the max staleness of the resources of type rType, 0 if
+// the check is disabled.
+func (pb *Pubsub) MaxStaleness(rType resource.ResourceType) time.Duration {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	return pb.maxStaleness[rType]
+}
+
+// scheduleStalenessCheckLocked (re)starts the staleness check timer, or stops
+// it if no max staleness is set.
+//
+// Caller must hold pb.mu.
+func (pb *Pubsub) scheduleStalenessCheckLocked() {
+	if pb.stalenessTimer != nil {
+		pb.stalenessTimer.Stop()
+		pb.stalenessTimer = nil
+	}
+	if pb.done.HasFired() {
+		return
+	}
+	var interval time.Duration
+	for _, d := range pb.maxStaleness {
+		if interval == 0 || d < interval {
+			interval = d
+		}
+	}
+	if interval == 0 {
+		return
+	}
+	pb.stalenessTimer = time.AfterFunc(interval/2, pb.checkStaleness)
+}
+
+// stopStalenessCheck stops the staleness check timer, called when the pubsub is
+// closed.
+func (pb *Pubsub) stopStalenessCheck() {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if pb.stalenessTimer != nil {
+		pb.stalenessTimer.Stop()
+		pb.stalenessTimer = nil
+	}
+}
+
+// checkStaleness notifies the watchers of the resources that became stale, and
+// schedules the next check.
+func (pb *Pubsub) checkStaleness() {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if pb.done.HasFired() {
+		return
+	}
+
+	now := pb.now()
+	for rType, d := range pb.maxStaleness {
+		switch rType {
+		case resource.ListenerResource:
+			pb.markStaleLocked(rType, d, now, keys(pb.ldsCache), pb.ldsMD, pb.ldsWatchers)
+		case resource.RouteConfigResource:
+			pb.markStaleLocked(rType, d, now, keys(pb.rdsCache), pb.rdsMD, pb.rdsWatchers)
+		case resource.ClusterResource:
+			pb.markStaleLocked(rType, d, now, keys(pb.cdsCache), pb.cdsMD, pb.cdsWatchers)
+		case resource.EndpointsResource:
+			pb.markStaleLocked(rType, d, now, keys(pb.edsCache), pb.edsMD, pb.edsWatchers)
+		}
+	}
+	pb.scheduleStalenessCheckLocked()
+}
+
+// markStaleLocked marks the resources in names which weren't updated for longer
+// than d as stale, and sends the staleness error to their watchers. Resources
+// already marked, or never received, are skipped, so the watchers are notified
+// only once until the resource is updated again.
+//
+// Caller must hold pb.mu.
+func (pb *Pubsub) markStaleLocked(rType resource.ResourceType, d time.Duration, now time.Time, names []string,
+	mds map[string]resource.UpdateMetadata, watchers map[string]map[*watchInfo]bool) {
+	for _, name := range names {
+		md, ok := mds[name]
+		if !ok || md.Stale || md.LastUpdated.IsZero() {
+			continue
+		}
+		age := now.Sub(md.LastUpdated)
+		if age <= d {
+			continue
+		}
+		md.Stale = true
+		mds[name] = md
+		pb.logger.Warnf("xds: %v resource %s is stale, it was last updated %v ago", rType, name, age)
+
+		s, ok := watchers[name]
+		if !ok && rType == resource.ClusterResource {
+			// The wildcard CDS watchers get the clusters without a watch of
+			// their own, same as in NewClusters.
+			s = watchers["*"]
+		}
+		for wi := range s {
+			wi.newError(resource.NewErrorf(resource.ErrorTypeResourceStale,
+				"xds: %v resource %s is stale, it was last updated %v ago, max staleness %v", rType, name, age, d))
+		}
+	}
+}
+
+func keys[V any](m map[string]V) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, k)
+	}
+	return ret
+}
@@ -0,0 +1,133 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pubsub
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// fakeClock is the clock of a pubsub under test, advanced by the test.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newStalenessPubsub returns a pubsub using clock, with a max staleness of an
+// hour for the clusters. The staleness checks are run by the tests, the timer
+// doesn't fire before they end.
+func newStalenessPubsub(t *testing.T, clock *fakeClock) *Pubsub {
+	t.Helper()
+	pb := New(testWatchExpiryTimeout, dubbogoLogger.GetLogger())
+	pb.mu.Lock()
+	pb.now = func() time.Time { return clock.now }
+	pb.mu.Unlock()
+	pb.SetMaxStaleness(resource.ClusterResource, time.Hour)
+	return pb
+}
+
+// TestStalenessUnchangedResource verifies that a resource not updated for
+// longer than the max staleness becomes stale, even though the stream is up.
+func TestStalenessUnchangedResource(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	pb := newStalenessPubsub(t, clock)
+	defer pb.Close()
+
+	ch := watchClusterCh(pb, "cds-a")
+	update := map[string]resource.ClusterUpdateErrTuple{
+		"cds-a": {Update: resource.ClusterUpdate{ClusterName: "cds-a"}},
+	}
+	pb.NewClusters(update, resource.UpdateMetadata{Timestamp: clock.now})
+	receiveCluster(t, ch)
+
+	clock.advance(30 * time.Minute)
+	pb.checkStaleness()
+	expectNoCallback(t, ch)
+
+	clock.advance(31 * time.Minute)
+	pb.checkStaleness()
+	if r := receiveCluster(t, ch); resource.ErrType(r.err) != resource.ErrorTypeResourceStale {
+		t.Fatalf("got %+v, want a staleness error", r)
+	}
+	// The watchers are notified once.
+	clock.advance(time.Hour)
+	pb.checkStaleness()
+	expectNoCallback(t, ch)
+
+	// The cluster is sent again, unchanged, and is not stale anymore.
+	pb.NewClusters(update, resource.UpdateMetadata{Timestamp: clock.now})
+	pb.checkStaleness()
+	expectNoCallback(t, ch)
+	pb.mu.Lock()
+	stale := pb.cdsMD["cds-a"].Stale
+	pb.mu.Unlock()
+	if stale {
+		t.Fatal("cds-a is still stale after it was updated")
+	}
+
+	// It becomes stale again when it isn't updated for long enough.
+	clock.advance(2 * time.Hour)
+	pb.checkStaleness()
+	if r := receiveCluster(t, ch); resource.ErrType(r.err) != resource.ErrorTypeResourceStale {
+		t.Fatalf("got %+v, want a staleness error", r)
+	}
+}
+
+// TestStalenessPerResource verifies that the staleness of each resource is
+// measured from its own last update, which a NACKed response doesn't refresh.
+func TestStalenessPerResource(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	pb := newStalenessPubsub(t, clock)
+	defer pb.Close()
+
+	chA := watchClusterCh(pb, "cds-a")
+	chB := watchClusterCh(pb, "cds-b")
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"cds-a": {Update: resource.ClusterUpdate{ClusterName: "cds-a"}},
+		"cds-b": {Update: resource.ClusterUpdate{ClusterName: "cds-b"}},
+	}, resource.UpdateMetadata{Timestamp: clock.now})
+	receiveCluster(t, chA)
+	receiveCluster(t, chB)
+
+	// cds-a is NACKed while cds-b is updated.
+	clock.advance(50 * time.Minute)
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"cds-a": {Err: errors.New("invalid cluster")},
+		"cds-b": {Update: resource.ClusterUpdate{ClusterName: "cds-b", EDSServiceName: "eds-b"}},
+	}, resource.UpdateMetadata{Timestamp: clock.now, ErrState: &resource.UpdateErrorMetadata{Err: errors.New("invalid cluster")}})
+	receiveCluster(t, chA)
+
+	clock.advance(11 * time.Minute)
+	pb.checkStaleness()
+	if r := receiveCluster(t, chA); resource.ErrType(r.err) != resource.ErrorTypeResourceStale {
+		t.Fatalf("got %+v for cds-a, want a staleness error", r)
+	}
+	expectNoCallback(t, chB)
+}
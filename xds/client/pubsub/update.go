@@ -114,7 +114,7 @@ func (pb *Pubsub) NewListeners(updates map[string]resource.ListenerUpdateErrTupl
 				}
 			}
 			// Sync cache.
-			pb.logger.Debugf("LDS resource with name %v, value %+v added to cache", name, pretty.ToJSON(uErr))
+			pb.logger.Debugf("LDS resource with name %v, value %+v added to cache", name, pretty.Lazy(uErr))
 			pb.ldsCache[name] = uErr.Update
 			// Set status to ACK, and clear error state. The metadata might be a
 			// NACK metadata because some other resources in the same response
@@ -122,16 +122,26 @@ func (pb *Pubsub) NewListeners(updates map[string]resource.ListenerUpdateErrTupl
 			mdCopy := metadata
 			mdCopy.Status = resource.ServiceStatusACKed
 			mdCopy.ErrState = nil
+			mdCopy.LastUpdated = pb.now()
 			if metadata.ErrState != nil {
 				mdCopy.Version = metadata.ErrState.Version
 			}
 			pb.ldsMD[name] = mdCopy
 		}
 	}
+	if pb.incremental {
+		// Incremental updates don't carry the unchanged resources, and the
+		// removed ones are passed to RemoveResources.
+		return
+	}
 	// Resources not in the new update were removed by the server, so delete
 	// them.
 	for name := range pb.ldsCache {
 		if _, ok := updates[name]; !ok {
+			if pb.ignoreResourceDeletion {
+				pb.logger.Warnf("xds: LDS resource %s missing from the response is kept, as the server sets ignore_resource_deletion", name)
+				continue
+			}
 			// If resource exists in cache, but not in the new update, delete
 			// the resource from cache, and also send an resource not found
 			// error to indicate resource removed.
@@ -142,6 +152,18 @@ func (pb *Pubsub) NewListeners(updates map[string]resource.ListenerUpdateErrTupl
 			}
 		}
 	}
+	// LDS responses contain all the requested resources, so the watched
+	// resources never received and absent from this response don't exist.
+	// Notify the watchers now, instead of waiting for the watch expiry timeout.
+	for name, s := range pb.ldsWatchers {
+		if _, ok := updates[name]; ok || pb.ldsMD[name].Status != resource.ServiceStatusRequested {
+			continue
+		}
+		pb.ldsMD[name] = resource.UpdateMetadata{Status: resource.ServiceStatusNotExist}
+		for wi := range s {
+			wi.resourceNotFound()
+		}
+	}
 	// When LDS resource is removed, we don't delete corresponding RDS cached
 	// data. The RDS watch will be canceled, and cache entry is removed when the
 	// last watch is canceled.
@@ -176,7 +198,7 @@ func (pb *Pubsub) NewRouteConfigs(updates map[string]resource.RouteConfigUpdateE
 				}
 			}
 			// Sync cache.
-			pb.logger.Debugf("RDS resource with name %v, value %+v added to cache", name, pretty.ToJSON(uErr))
+			pb.logger.Debugf("RDS resource with name %v, value %+v added to cache", name, pretty.Lazy(uErr))
 			pb.rdsCache[name] = uErr.Update
 			// Set status to ACK, and clear error state. The metadata might be a
 			// NACK metadata because some other resources in the same response
@@ -184,6 +206,7 @@ func (pb *Pubsub) NewRouteConfigs(updates map[string]resource.RouteConfigUpdateE
 			mdCopy := metadata
 			mdCopy.Status = resource.ServiceStatusACKed
 			mdCopy.ErrState = nil
+			mdCopy.LastUpdated = pb.now()
 			if metadata.ErrState != nil {
 				mdCopy.Version = metadata.ErrState.Version
 			}
@@ -196,20 +219,25 @@ func (pb *Pubsub) NewRouteConfigs(updates map[string]resource.RouteConfigUpdateE
 func (pb *Pubsub) NewClusters(updates map[string]resource.ClusterUpdateErrTuple, metadata resource.UpdateMetadata) {
 	pb.mu.Lock()
 	defer pb.mu.Unlock()
+	updates = pb.filterClustersLocked(updates)
 
 	for k, update := range pb.cdsCache {
-		if _, ok := updates[k]; !ok {
-			// this is a delete event
-			s, ok := pb.cdsWatchers[k]
-			if !ok {
-				s, ok = pb.cdsWatchers["*"]
-			}
-			if ok {
-				for wi := range s {
-					// delete
-					update.ClusterName = "-" + update.ClusterName
-					wi.newUpdate(update)
-				}
+		if _, ok := updates[k]; ok || pb.incremental {
+			continue
+		}
+		if pb.ignoreResourceDeletion {
+			pb.logger.Warnf("xds: CDS resource %s missing from the response is kept, as the server sets ignore_resource_deletion", k)
+			continue
+		}
+		// this is a delete event
+		s, ok := pb.cdsWatchers[k]
+		if !ok {
+			s, ok = pb.cdsWatchers["*"]
+		}
+		if ok {
+			update.ClusterName = "-" + update.ClusterName
+			for wi := range s {
+				wi.newUpdate(update)
 			}
 		}
 	}
@@ -243,7 +271,7 @@ func (pb *Pubsub) NewClusters(updates map[string]resource.ClusterUpdateErrTuple,
 				}
 			}
 			// Sync cache.
-			pb.logger.Debugf("CDS resource with name %v, value %+v added to cache", name, pretty.ToJSON(uErr))
+			pb.logger.Debugf("CDS resource with name %v, value %+v added to cache", name, pretty.Lazy(uErr))
 			pb.cdsCache[name] = uErr.Update
 			// Set status to ACK, and clear error state. The metadata might be a
 			// NACK metadata because some other resources in the same response
@@ -251,26 +279,51 @@ func (pb *Pubsub) NewClusters(updates map[string]resource.ClusterUpdateErrTuple,
 			mdCopy := metadata
 			mdCopy.Status = resource.ServiceStatusACKed
 			mdCopy.ErrState = nil
+			mdCopy.LastUpdated = pb.now()
 			if metadata.ErrState != nil {
 				mdCopy.Version = metadata.ErrState.Version
 			}
 			pb.cdsMD[name] = mdCopy
 		}
 	}
+	if pb.incremental {
+		// Incremental updates don't carry the unchanged resources, and the
+		// removed ones are passed to RemoveResources.
+		return
+	}
 	// Resources not in the new update were removed by the server, so delete
 	// them.
 	for name := range pb.cdsCache {
 		if _, ok := updates[name]; !ok {
+			if pb.ignoreResourceDeletion {
+				// Already logged with the delete events above.
+				continue
+			}
 			// If resource exists in cache, but not in the new update, delete it
 			// from cache, and also send an resource not found error to indicate
 			// resource removed.
 			delete(pb.cdsCache, name)
-			pb.ldsMD[name] = resource.UpdateMetadata{Status: resource.ServiceStatusNotExist}
+			pb.cdsMD[name] = resource.UpdateMetadata{Status: resource.ServiceStatusNotExist}
 			for wi := range pb.cdsWatchers[name] {
 				wi.resourceNotFound()
 			}
 		}
 	}
+	// CDS responses contain all the requested resources, so the watched
+	// resources never received and absent from this response don't exist. The
+	// wildcard watch is not a resource, and is never not found.
+	for name, s := range pb.cdsWatchers {
+		if name == "*" {
+			continue
+		}
+		if _, ok := updates[name]; ok || pb.cdsMD[name].Status != resource.ServiceStatusRequested {
+			continue
+		}
+		pb.cdsMD[name] = resource.UpdateMetadata{Status: resource.ServiceStatusNotExist}
+		for wi := range s {
+			wi.resourceNotFound()
+		}
+	}
 	// When CDS resource is removed, we don't delete corresponding EDS cached
 	// data. The EDS watch will be canceled, and cache entry is removed when the
 	// last watch is canceled.
@@ -306,7 +359,7 @@ func (pb *Pubsub) NewEndpoints(updates map[string]resource.EndpointsUpdateErrTup
 				}
 			}
 			// Sync cache.
-			pb.logger.Debugf("EDS resource with name %v, value %+v added to cache", name, pretty.ToJSON(uErr))
+			pb.logger.Debugf("EDS resource with name %v, value %+v added to cache", name, pretty.Lazy(uErr))
 			pb.edsCache[name] = uErr.Update
 			// Set status to ACK, and clear error state. The metadata might be a
 			// NACK metadata because some other resources in the same response
@@ -314,6 +367,7 @@ func (pb *Pubsub) NewEndpoints(updates map[string]resource.EndpointsUpdateErrTup
 			mdCopy := metadata
 			mdCopy.Status = resource.ServiceStatusACKed
 			mdCopy.ErrState = nil
+			mdCopy.LastUpdated = pb.now()
 			if metadata.ErrState != nil {
 				mdCopy.Version = metadata.ErrState.Version
 			}
@@ -322,6 +376,67 @@ func (pb *Pubsub) NewEndpoints(updates map[string]resource.EndpointsUpdateErrTup
 	}
 }
 
+// RemoveResources is called when the management server explicitly removes
+// resources of type rType, with the delta variant of the ADS protocol. The
+// removed resources are deleted from the cache, and their watchers are told
+// that they don't exist anymore.
+func (pb *Pubsub) RemoveResources(rType resource.ResourceType, names []string) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	for _, name := range names {
+		switch rType {
+		case resource.ListenerResource:
+			if pb.ignoreResourceDeletion {
+				pb.logger.Warnf("xds: removed LDS resource %s is kept, as the server sets ignore_resource_deletion", name)
+				continue
+			}
+			delete(pb.ldsCache, name)
+			pb.resourceRemovedLocked(name, pb.ldsWatchers, pb.ldsMD)
+		case resource.RouteConfigResource:
+			delete(pb.rdsCache, name)
+			pb.resourceRemovedLocked(name, pb.rdsWatchers, pb.rdsMD)
+		case resource.ClusterResource:
+			if pb.ignoreResourceDeletion {
+				pb.logger.Warnf("xds: removed CDS resource %s is kept, as the server sets ignore_resource_deletion", name)
+				continue
+			}
+			if update, ok := pb.cdsCache[name]; ok {
+				// Like with state of the world updates, the watchers get a
+				// delete event first.
+				s, ok := pb.cdsWatchers[name]
+				if !ok {
+					s = pb.cdsWatchers["*"]
+				}
+				update.ClusterName = "-" + update.ClusterName
+				for wi := range s {
+					wi.newUpdate(update)
+				}
+			}
+			delete(pb.cdsCache, name)
+			pb.resourceRemovedLocked(name, pb.cdsWatchers, pb.cdsMD)
+		case resource.EndpointsResource:
+			delete(pb.edsCache, name)
+			pb.resourceRemovedLocked(name, pb.edsWatchers, pb.edsMD)
+		}
+	}
+}
+
+// resourceRemovedLocked marks the resource name as not existing, and notifies
+// its watchers. The resources which aren't watched anymore are ignored.
+//
+// Caller must hold pb.mu.
+func (pb *Pubsub) resourceRemovedLocked(name string, watchers map[string]map[*watchInfo]bool, mds map[string]resource.UpdateMetadata) {
+	s, ok := watchers[name]
+	if !ok {
+		return
+	}
+	mds[name] = resource.UpdateMetadata{Status: resource.ServiceStatusNotExist}
+	for wi := range s {
+		wi.resourceNotFound()
+	}
+}
+
 // NewConnectionError is called by the underlying xdsAPIClient when it receives
 // a connection error. The error will be forwarded to all the resource watchers.
 func (pb *Pubsub) NewConnectionError(err error) {
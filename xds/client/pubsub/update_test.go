@@ -0,0 +1,359 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pubsub
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// testWatchExpiryTimeout is long enough for the watches not to expire during
+// the tests.
+const testWatchExpiryTimeout = time.Minute
+
+type listenerResult struct {
+	route string
+	err   error
+}
+
+func watchListenerCh(pb *Pubsub, name string) chan listenerResult {
+	ch := make(chan listenerResult, 10)
+	pb.WatchListener(name, func(u resource.ListenerUpdate, err error) {
+		ch <- listenerResult{route: u.RouteConfigName, err: err}
+	})
+	return ch
+}
+
+func receiveListener(t *testing.T, ch chan listenerResult) listenerResult {
+	t.Helper()
+	select {
+	case r := <-ch:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for a LDS callback")
+		return listenerResult{}
+	}
+}
+
+// expectNoCallback fails the test if any of the channels receives a callback
+// in a short while.
+func expectNoCallback[T any](t *testing.T, chs ...chan T) {
+	t.Helper()
+	time.Sleep(50 * time.Millisecond)
+	for _, ch := range chs {
+		select {
+		case r := <-ch:
+			t.Fatalf("got unexpected callback %+v", r)
+		default:
+		}
+	}
+}
+
+type clusterResult struct {
+	name string
+	err  error
+}
+
+func watchClusterCh(pb *Pubsub, name string) chan clusterResult {
+	ch := make(chan clusterResult, 10)
+	pb.WatchCluster(name, func(u resource.ClusterUpdate, err error) {
+		ch <- clusterResult{name: u.ClusterName, err: err}
+	})
+	return ch
+}
+
+func receiveCluster(t *testing.T, ch chan clusterResult) clusterResult {
+	t.Helper()
+	select {
+	case r := <-ch:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for a CDS callback")
+		return clusterResult{}
+	}
+}
+
+// TestResourceNotFound verifies that the watched resources never received and
+// absent from a full response are reported as not found right away.
+func TestResourceNotFound(t *testing.T) {
+	pb := New(testWatchExpiryTimeout, dubbogoLogger.GetLogger())
+	defer pb.Close()
+
+	ldsA := watchListenerCh(pb, "lds-a")
+	ldsB := watchListenerCh(pb, "lds-b")
+	pb.NewListeners(map[string]resource.ListenerUpdateErrTuple{
+		"lds-a": {Update: resource.ListenerUpdate{RouteConfigName: "route-a"}},
+	}, resource.UpdateMetadata{})
+	if r := receiveListener(t, ldsA); r.err != nil || r.route != "route-a" {
+		t.Fatalf("lds-a watch got %+v, want its update", r)
+	}
+	if r := receiveListener(t, ldsB); !errors.Is(r.err, resource.ErrResourceNotFound) {
+		t.Fatalf("lds-b watch got %+v, want resource not found", r)
+	}
+
+	wildcard := watchClusterCh(pb, "*")
+	cdsB := watchClusterCh(pb, "cds-b")
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"cds-a": {Update: resource.ClusterUpdate{ClusterName: "cds-a"}},
+	}, resource.UpdateMetadata{})
+	if r := receiveCluster(t, cdsB); !errors.Is(r.err, resource.ErrResourceNotFound) {
+		t.Fatalf("cds-b watch got %+v, want resource not found", r)
+	}
+	// The wildcard watch gets the clusters of the response, and is never not
+	// found.
+	if r := receiveCluster(t, wildcard); r.err != nil || r.name != "cds-a" {
+		t.Fatalf("wildcard watch got %+v, want the cds-a update", r)
+	}
+	expectNoCallback(t, wildcard, cdsB)
+	expectNoCallback(t, ldsA, ldsB)
+
+	pb.mu.Lock()
+	ldsStatus, cdsStatus := pb.ldsMD["lds-b"].Status, pb.cdsMD["cds-b"].Status
+	pb.mu.Unlock()
+	if ldsStatus != resource.ServiceStatusNotExist || cdsStatus != resource.ServiceStatusNotExist {
+		t.Fatalf("got status %v for lds-b and %v for cds-b, want both %v", ldsStatus, cdsStatus, resource.ServiceStatusNotExist)
+	}
+}
+
+// TestResourceNotFoundIncremental verifies that the watched resources absent
+// from an incremental response are not reported as not found, as the response
+// only carries the changed resources.
+func TestResourceNotFoundIncremental(t *testing.T) {
+	pb := New(testWatchExpiryTimeout, dubbogoLogger.GetLogger())
+	defer pb.Close()
+	pb.SetIncremental(true)
+
+	lds := watchListenerCh(pb, "lds-b")
+	cds := watchClusterCh(pb, "cds-b")
+	pb.NewListeners(map[string]resource.ListenerUpdateErrTuple{
+		"lds-a": {Update: resource.ListenerUpdate{RouteConfigName: "route-a"}},
+	}, resource.UpdateMetadata{})
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"cds-a": {Update: resource.ClusterUpdate{ClusterName: "cds-a"}},
+	}, resource.UpdateMetadata{})
+	expectNoCallback(t, lds)
+	expectNoCallback(t, cds)
+
+	pb.mu.Lock()
+	ldsStatus, cdsStatus := pb.ldsMD["lds-b"].Status, pb.cdsMD["cds-b"].Status
+	pb.mu.Unlock()
+	if ldsStatus != resource.ServiceStatusRequested || cdsStatus != resource.ServiceStatusRequested {
+		t.Fatalf("got status %v for lds-b and %v for cds-b, want both %v", ldsStatus, cdsStatus, resource.ServiceStatusRequested)
+	}
+}
+
+func TestIgnoreResourceDeletionListeners(t *testing.T) {
+	pb := New(testWatchExpiryTimeout, dubbogoLogger.GetLogger())
+	defer pb.Close()
+	pb.SetIgnoreResourceDeletion(true)
+
+	ch := watchListenerCh(pb, "lds-a")
+	pb.NewListeners(map[string]resource.ListenerUpdateErrTuple{
+		"lds-a": {Update: resource.ListenerUpdate{RouteConfigName: "route-a"}},
+	}, resource.UpdateMetadata{})
+	if r := receiveListener(t, ch); r.err != nil || r.route != "route-a" {
+		t.Fatalf("lds-a watch got %+v, want its update", r)
+	}
+
+	// The next response doesn't carry lds-a, which is kept.
+	pb.NewListeners(map[string]resource.ListenerUpdateErrTuple{}, resource.UpdateMetadata{})
+	expectNoCallback(t, ch)
+
+	pb.mu.Lock()
+	_, cached := pb.ldsCache["lds-a"]
+	status := pb.ldsMD["lds-a"].Status
+	pb.mu.Unlock()
+	if !cached || status != resource.ServiceStatusACKed {
+		t.Fatalf("lds-a cached %v with status %v, want it kept as ACKed", cached, status)
+	}
+}
+
+func TestIgnoreResourceDeletionClusters(t *testing.T) {
+	pb := New(testWatchExpiryTimeout, dubbogoLogger.GetLogger())
+	defer pb.Close()
+	pb.SetIgnoreResourceDeletion(true)
+
+	wildcard := watchClusterCh(pb, "*")
+	watched := watchClusterCh(pb, "cds-b")
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"cds-a": {Update: resource.ClusterUpdate{ClusterName: "cds-a"}},
+		"cds-b": {Update: resource.ClusterUpdate{ClusterName: "cds-b"}},
+	}, resource.UpdateMetadata{})
+	if r := receiveCluster(t, wildcard); r.err != nil || r.name != "cds-a" {
+		t.Fatalf("wildcard watch got %+v, want the cds-a update", r)
+	}
+	if r := receiveCluster(t, watched); r.err != nil || r.name != "cds-b" {
+		t.Fatalf("cds-b watch got %+v, want its update", r)
+	}
+
+	// Neither the delete events nor resource not found are sent for the
+	// clusters missing from the next response.
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{}, resource.UpdateMetadata{})
+	expectNoCallback(t, wildcard, watched)
+
+	pb.mu.Lock()
+	n := len(pb.cdsCache)
+	pb.mu.Unlock()
+	if n != 2 {
+		t.Fatalf("got %d cached clusters, want 2", n)
+	}
+}
+
+func TestClusterDeleteEvent(t *testing.T) {
+	pb := New(testWatchExpiryTimeout, dubbogoLogger.GetLogger())
+	defer pb.Close()
+
+	wildcard := watchClusterCh(pb, "*")
+	watched := watchClusterCh(pb, "cds-b")
+	// A second watcher of cds-b makes sure the delete event isn't prefixed
+	// once per watcher.
+	watched2 := watchClusterCh(pb, "cds-b")
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"cds-a": {Update: resource.ClusterUpdate{ClusterName: "cds-a"}},
+		"cds-b": {Update: resource.ClusterUpdate{ClusterName: "cds-b"}},
+	}, resource.UpdateMetadata{})
+	receiveCluster(t, wildcard)
+	receiveCluster(t, watched)
+	receiveCluster(t, watched2)
+
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{}, resource.UpdateMetadata{})
+	if r := receiveCluster(t, wildcard); r.err != nil || r.name != "-cds-a" {
+		t.Fatalf("wildcard watch got %+v, want the cds-a delete event", r)
+	}
+	for _, ch := range []chan clusterResult{watched, watched2} {
+		if r := receiveCluster(t, ch); r.err != nil || r.name != "-cds-b" {
+			t.Fatalf("cds-b watch got %+v, want its delete event", r)
+		}
+		if r := receiveCluster(t, ch); resource.ErrType(r.err) != resource.ErrorTypeResourceNotFound {
+			t.Fatalf("cds-b watch got %+v, want resource not found", r)
+		}
+	}
+}
+
+// rawCluster returns a cluster update carrying raw, so that the updates with
+// different raw values are seen as changes.
+func rawCluster(name, raw string) resource.ClusterUpdateErrTuple {
+	return resource.ClusterUpdateErrTuple{Update: resource.ClusterUpdate{
+		ClusterName: name,
+		Raw:         &anypb.Any{Value: []byte(raw)},
+	}}
+}
+
+func TestIncrementalUpdates(t *testing.T) {
+	pb := New(testWatchExpiryTimeout, dubbogoLogger.GetLogger())
+	defer pb.Close()
+	pb.SetIncremental(true)
+
+	wildcard := watchClusterCh(pb, "*")
+	watched := watchClusterCh(pb, "cds-b")
+	lds := watchListenerCh(pb, "lds-a")
+
+	// Added.
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{"cds-a": rawCluster("cds-a", "1")}, resource.UpdateMetadata{})
+	if r := receiveCluster(t, wildcard); r.err != nil || r.name != "cds-a" {
+		t.Fatalf("wildcard watch got %+v, want the cds-a update", r)
+	}
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{"cds-b": rawCluster("cds-b", "1")}, resource.UpdateMetadata{})
+	if r := receiveCluster(t, watched); r.err != nil || r.name != "cds-b" {
+		t.Fatalf("cds-b watch got %+v, want its update", r)
+	}
+	pb.NewListeners(map[string]resource.ListenerUpdateErrTuple{
+		"lds-a": {Update: resource.ListenerUpdate{RouteConfigName: "route-a"}},
+	}, resource.UpdateMetadata{})
+	receiveListener(t, lds)
+	// cds-a is absent from the second response, but an incremental update
+	// doesn't remove it.
+	expectNoCallback(t, wildcard)
+
+	// Changed.
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{"cds-a": rawCluster("cds-a", "2")}, resource.UpdateMetadata{})
+	if r := receiveCluster(t, wildcard); r.err != nil || r.name != "cds-a" {
+		t.Fatalf("wildcard watch got %+v, want the changed cds-a", r)
+	}
+	// The watched resources missing from an incremental response are not
+	// reported as not found.
+	pb.NewListeners(map[string]resource.ListenerUpdateErrTuple{}, resource.UpdateMetadata{})
+	expectNoCallback(t, watched)
+	expectNoCallback(t, lds)
+
+	// Removed.
+	pb.RemoveResources(resource.ClusterResource, []string{"cds-b"})
+	if r := receiveCluster(t, watched); r.err != nil || r.name != "-cds-b" {
+		t.Fatalf("cds-b watch got %+v, want its delete event", r)
+	}
+	if r := receiveCluster(t, watched); resource.ErrType(r.err) != resource.ErrorTypeResourceNotFound {
+		t.Fatalf("cds-b watch got %+v, want resource not found", r)
+	}
+	pb.RemoveResources(resource.ListenerResource, []string{"lds-a"})
+	if r := receiveListener(t, lds); resource.ErrType(r.err) != resource.ErrorTypeResourceNotFound {
+		t.Fatalf("lds-a watch got %+v, want resource not found", r)
+	}
+
+	pb.mu.Lock()
+	_, cdsCached := pb.cdsCache["cds-b"]
+	_, ldsCached := pb.ldsCache["lds-a"]
+	pb.mu.Unlock()
+	if cdsCached || ldsCached {
+		t.Fatalf("removed resources still cached: cds-b %v, lds-a %v", cdsCached, ldsCached)
+	}
+}
+
+func TestRemoveResourcesIgnoreResourceDeletion(t *testing.T) {
+	pb := New(testWatchExpiryTimeout, dubbogoLogger.GetLogger())
+	defer pb.Close()
+	pb.SetIncremental(true)
+	pb.SetIgnoreResourceDeletion(true)
+
+	wildcard := watchClusterCh(pb, "*")
+	watched := watchClusterCh(pb, "cds-b")
+	lds := watchListenerCh(pb, "lds-a")
+	pb.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"cds-a": rawCluster("cds-a", "1"),
+		"cds-b": rawCluster("cds-b", "1"),
+	}, resource.UpdateMetadata{})
+	pb.NewListeners(map[string]resource.ListenerUpdateErrTuple{
+		"lds-a": {Update: resource.ListenerUpdate{RouteConfigName: "route-a"}},
+	}, resource.UpdateMetadata{})
+	receiveCluster(t, wildcard)
+	receiveCluster(t, watched)
+	receiveListener(t, lds)
+
+	pb.RemoveResources(resource.ClusterResource, []string{"cds-a", "cds-b"})
+	pb.RemoveResources(resource.ListenerResource, []string{"lds-a"})
+	expectNoCallback(t, wildcard, watched)
+	expectNoCallback(t, lds)
+
+	pb.mu.Lock()
+	n := len(pb.cdsCache)
+	_, ldsCached := pb.ldsCache["lds-a"]
+	pb.mu.Unlock()
+	if n != 2 || !ldsCached {
+		t.Fatalf("got %d cached clusters and lds-a cached %v, want all kept", n, ldsCached)
+	}
+}
@@ -54,6 +54,7 @@ type watchInfo struct {
 	cdsCallback func(resource.ClusterUpdate, error)
 	edsCallback func(resource.EndpointsUpdate, error)
 
+	// expiryTimer is nil if the expiry is disabled.
 	expiryTimer *time.Timer
 
 	// mu protects state, and c.scheduleCallback().
@@ -63,6 +64,13 @@ type watchInfo struct {
 	state watchInfoState
 }
 
+// stopExpiryTimer stops the expiry timer, if the expiry is enabled.
+func (wi *watchInfo) stopExpiryTimer() {
+	if wi.expiryTimer != nil {
+		wi.expiryTimer.Stop()
+	}
+}
+
 func (wi *watchInfo) newUpdate(update any) {
 	wi.mu.Lock()
 	defer wi.mu.Unlock()
@@ -70,7 +78,7 @@ func (wi *watchInfo) newUpdate(update any) {
 		return
 	}
 	wi.state = watchInfoStateRespReceived
-	wi.expiryTimer.Stop()
+	wi.stopExpiryTimer()
 	wi.c.scheduleCallback(wi, update, nil)
 }
 
@@ -81,7 +89,7 @@ func (wi *watchInfo) newError(err error) {
 		return
 	}
 	wi.state = watchInfoStateRespReceived
-	wi.expiryTimer.Stop()
+	wi.stopExpiryTimer()
 	wi.sendErrorLocked(err)
 }
 
@@ -92,7 +100,7 @@ func (wi *watchInfo) resourceNotFound() {
 		return
 	}
 	wi.state = watchInfoStateRespReceived
-	wi.expiryTimer.Stop()
+	wi.stopExpiryTimer()
 	wi.sendErrorLocked(resource.NewErrorf(resource.ErrorTypeResourceNotFound, "xds: %v target %s not found in received response", wi.rType, wi.target))
 }
 
@@ -130,10 +138,34 @@ func (wi *watchInfo) cancel() {
 	if wi.state == watchInfoStateCanceled {
 		return
 	}
-	wi.expiryTimer.Stop()
+	wi.stopExpiryTimer()
 	wi.state = watchInfoStateCanceled
 }
 
+// closeWithError invokes the callback with err, unless the watch is canceled,
+// and cancels the watch so that no callback is called after this.
+func (wi *watchInfo) closeWithError(err error) {
+	wi.mu.Lock()
+	if wi.state == watchInfoStateCanceled {
+		wi.mu.Unlock()
+		return
+	}
+	wi.stopExpiryTimer()
+	wi.state = watchInfoStateCanceled
+	wi.mu.Unlock()
+
+	switch wi.rType {
+	case resource.ListenerResource:
+		wi.ldsCallback(resource.ListenerUpdate{}, err)
+	case resource.RouteConfigResource:
+		wi.rdsCallback(resource.RouteConfigUpdate{}, err)
+	case resource.ClusterResource:
+		wi.cdsCallback(resource.ClusterUpdate{}, err)
+	case resource.EndpointsResource:
+		wi.edsCallback(resource.EndpointsUpdate{}, err)
+	}
+}
+
 func (pb *Pubsub) watch(wi *watchInfo) (first bool, cancel func() bool) {
 	pb.mu.Lock()
 	defer pb.mu.Unlock()
@@ -183,26 +215,26 @@ func (pb *Pubsub) watch(wi *watchInfo) (first bool, cancel func() bool) {
 	switch wi.rType {
 	case resource.ListenerResource:
 		if v, ok := pb.ldsCache[resourceName]; ok {
-			pb.logger.Debugf("LDS resource with name %v found in cache: %+v", wi.target, pretty.ToJSON(v))
+			pb.logger.Debugf("LDS resource with name %v found in cache: %+v", wi.target, pretty.Lazy(v))
 			wi.newUpdate(v)
 		}
 	case resource.RouteConfigResource:
 		if v, ok := pb.rdsCache[resourceName]; ok {
-			pb.logger.Debugf("RDS resource with name %v found in cache: %+v", wi.target, pretty.ToJSON(v))
+			pb.logger.Debugf("RDS resource with name %v found in cache: %+v", wi.target, pretty.Lazy(v))
 			wi.newUpdate(v)
 		}
 	case resource.ClusterResource:
 		if v, ok := pb.cdsCache["*"]; ok {
-			pb.logger.Debugf("CDS resource with name * found in cache: %+v", pretty.ToJSON(v))
+			pb.logger.Debugf("CDS resource with name * found in cache: %+v", pretty.Lazy(v))
 			wi.newUpdate(v)
 		}
 		if v, ok := pb.cdsCache[resourceName]; ok {
-			pb.logger.Debugf("CDS resource with name %v found in cache: %+v", wi.target, pretty.ToJSON(v))
+			pb.logger.Debugf("CDS resource with name %v found in cache: %+v", wi.target, pretty.Lazy(v))
 			wi.newUpdate(v)
 		}
 	case resource.EndpointsResource:
 		if v, ok := pb.edsCache[resourceName]; ok {
-			pb.logger.Debugf("EDS resource with name %v found in cache: %+v", wi.target, pretty.ToJSON(v))
+			pb.logger.Debugf("EDS resource with name %v found in cache: %+v", wi.target, pretty.Lazy(v))
 			wi.newUpdate(v)
 		}
 	}
@@ -242,3 +274,29 @@ func (pb *Pubsub) watch(wi *watchInfo) (first bool, cancel func() bool) {
 		return lastWatcher
 	}
 }
+
+// WatchCounts returns the number of watchers per resource type.
+func (pb *Pubsub) WatchCounts() map[resource.ResourceType]int {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	ret := make(map[resource.ResourceType]int, 4)
+	for rType, watchers := range map[resource.ResourceType]map[string]map[*watchInfo]bool{
+		resource.ListenerResource:    pb.ldsWatchers,
+		resource.RouteConfigResource: pb.rdsWatchers,
+		resource.ClusterResource:     pb.cdsWatchers,
+		resource.EndpointsResource:   pb.edsWatchers,
+	} {
+		for _, s := range watchers {
+			ret[rType] += len(s)
+		}
+	}
+	return ret
+}
+
+// ResourceCount returns the number of resources received from the management
+// server and cached, across all the resource types.
+func (pb *Pubsub) ResourceCount() int {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	return len(pb.ldsCache) + len(pb.rdsCache) + len(pb.cdsCache) + len(pb.edsCache)
+}
@@ -24,6 +24,7 @@
 package resource
 
 import (
+	"errors"
 	"fmt"
 )
 
@@ -41,8 +42,23 @@ const (
 	// response. It's typically returned if the resource is removed in the xds
 	// server.
 	ErrorTypeResourceNotFound
+	// ErrorTypeResourceStale indicates a cached resource wasn't updated by the
+	// management server for longer than the max staleness of its type. The
+	// resource is not removed, the watchers can keep using the last update
+	// they received.
+	ErrorTypeResourceStale
 )
 
+// ErrResourceNotFound matches, with errors.Is, the errors passed to watchers
+// when the watched resource doesn't exist on the management server, i.e. the
+// errors of type ErrorTypeResourceNotFound.
+var ErrResourceNotFound = errors.New("xds: resource not found")
+
+// ErrResourceStale matches, with errors.Is, the errors passed to watchers
+// when the watched resource is stale, i.e. the errors of type
+// ErrorTypeResourceStale.
+var ErrResourceStale = errors.New("xds: resource stale")
+
 type xdsClientError struct {
 	t    ErrorType
 	desc string
@@ -52,6 +68,19 @@ func (e *xdsClientError) Error() string {
 	return e.desc
 }
 
+// Is makes errors.Is(err, ErrResourceNotFound) report resource not found
+// errors, and errors.Is(err, ErrResourceStale) stale resource errors.
+func (e *xdsClientError) Is(target error) bool {
+	switch target {
+	case ErrResourceNotFound:
+		return e.t == ErrorTypeResourceNotFound
+	case ErrResourceStale:
+		return e.t == ErrorTypeResourceStale
+	default:
+		return false
+	}
+}
+
 // NewErrorf creates an xds client error. The callbacks are called with this
 // error, to pass additional information about the error.
 func NewErrorf(t ErrorType, format string, args ...any) error {
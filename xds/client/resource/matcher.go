@@ -25,10 +25,13 @@ package resource
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 )
 
 import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+
 	"google.golang.org/grpc/metadata"
 )
 
@@ -76,11 +79,54 @@ func RouteToMatcher(r *Route) (*CompositeMatcher, error) {
 		headerMatchers = append(headerMatchers, matcherT)
 	}
 
+	queryMatchers := make([]queryParameterMatcher, 0, len(r.QueryParameters))
+	for _, q := range r.QueryParameters {
+		switch {
+		case q.StringMatch != nil:
+			queryMatchers = append(queryMatchers, queryParameterMatcher{name: q.Name, sm: q.StringMatch})
+		case q.PresentMatch != nil:
+			queryMatchers = append(queryMatchers, queryParameterMatcher{name: q.Name, present: *q.PresentMatch})
+		default:
+			return nil, fmt.Errorf("illegal route: missing query_parameter_match_specifier")
+		}
+	}
+
 	var fractionMatcher *fractionMatcher
 	if r.Fraction != nil {
 		fractionMatcher = newFractionMatcher(*r.Fraction)
 	}
-	return newCompositeMatcher(pm, headerMatchers, fractionMatcher), nil
+	m := newCompositeMatcher(pm, headerMatchers, fractionMatcher)
+	m.qms = queryMatchers
+	return m, nil
+}
+
+// MatchRoute returns the first of routes matching a request to path, which
+// may have a query string, with the given headers. The header names are case
+// insensitive. The routes which can't be converted to a matcher are skipped.
+func MatchRoute(routes []Route, headers map[string][]string, path string) (*Route, bool) {
+	path, rawQuery, _ := strings.Cut(path, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		// Keep the valid parameters, like a server would.
+		dubbogoLogger.Debugf("xds: invalid query string %q: %v", rawQuery, err)
+	}
+	md := make(metadata.MD, len(headers))
+	for k, vs := range headers {
+		k = strings.ToLower(k)
+		md[k] = append(md[k], vs...)
+	}
+
+	for i := range routes {
+		m, err := RouteToMatcher(&routes[i])
+		if err != nil {
+			dubbogoLogger.Warnf("xds: skipping route %+v: %v", routes[i], err)
+			continue
+		}
+		if m.match(path, md, query) {
+			return &routes[i], true
+		}
+	}
+	return nil, false
 }
 
 // CompositeMatcher is a matcher that holds onto many matchers and aggregates
@@ -88,6 +134,7 @@ func RouteToMatcher(r *Route) (*CompositeMatcher, error) {
 type CompositeMatcher struct {
 	pm  pathMatcher
 	hms []matcher.HeaderMatcher
+	qms []queryParameterMatcher
 	fm  *fractionMatcher
 }
 
@@ -95,12 +142,9 @@ func newCompositeMatcher(pm pathMatcher, hms []matcher.HeaderMatcher, fm *fracti
 	return &CompositeMatcher{pm: pm, hms: hms, fm: fm}
 }
 
-// Match returns true if all matchers return true.
+// Match returns true if all matchers return true. RPCs have no query
+// parameters, so a route with query parameter matchers never matches them.
 func (a *CompositeMatcher) Match(info iresolver.RPCInfo) bool {
-	if a.pm != nil && !a.pm.match(info.Method) {
-		return false
-	}
-
 	// Call headerMatchers even if md is nil, because routes may match
 	// non-presence of some headers.
 	var md metadata.MD
@@ -117,11 +161,23 @@ func (a *CompositeMatcher) Match(info iresolver.RPCInfo) bool {
 			}
 		}
 	}
+	return a.match(info.Method, md, nil)
+}
+
+func (a *CompositeMatcher) match(path string, md metadata.MD, query url.Values) bool {
+	if a.pm != nil && !a.pm.match(path) {
+		return false
+	}
 	for _, m := range a.hms {
 		if !m.Match(md) {
 			return false
 		}
 	}
+	for _, m := range a.qms {
+		if !m.match(query) {
+			return false
+		}
+	}
 
 	if a.fm != nil && !a.fm.match() {
 		return false
@@ -137,12 +193,43 @@ func (a *CompositeMatcher) String() string {
 	for _, m := range a.hms {
 		ret += m.String()
 	}
+	for _, m := range a.qms {
+		ret += m.String()
+	}
 	if a.fm != nil {
 		ret += a.fm.String()
 	}
 	return ret
 }
 
+// queryParameterMatcher matches a query parameter, on one of its values if sm
+// is set, otherwise on its presence.
+type queryParameterMatcher struct {
+	name    string
+	sm      *matcher.StringMatcher
+	present bool
+}
+
+func (qm queryParameterMatcher) match(query url.Values) bool {
+	values, ok := query[qm.name]
+	if qm.sm == nil {
+		return ok == qm.present
+	}
+	for _, v := range values {
+		if qm.sm.Match(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (qm queryParameterMatcher) String() string {
+	if qm.sm == nil {
+		return fmt.Sprintf("queryPresent:%v:%v", qm.name, qm.present)
+	}
+	return fmt.Sprintf("queryString:%v:%+v", qm.name, *qm.sm)
+}
+
 type fractionMatcher struct {
 	fraction int64 // real fraction is fraction/1,000,000.
 }
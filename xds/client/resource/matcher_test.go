@@ -0,0 +1,223 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"regexp"
+	"testing"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/matcher"
+)
+
+func newString(s string) *string {
+	return &s
+}
+
+func newBool(b bool) *bool {
+	return &b
+}
+
+// clusterRoute returns a route to cluster with the prefix "/" and the given
+// matchers.
+func clusterRoute(cluster string, headers []*HeaderMatcher, query []*QueryParameterMatcher) Route {
+	return Route{
+		Prefix:           newString("/"),
+		Headers:          headers,
+		QueryParameters:  query,
+		WeightedClusters: map[string]WeightedCluster{cluster: {Weight: 1}},
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	exact := matcher.StringMatcherForTesting(newString("v2"), nil, nil, nil, nil, false)
+	tests := []struct {
+		name    string
+		route   Route
+		headers map[string][]string
+		path    string
+		want    bool
+	}{
+		{
+			name:  "path prefix",
+			route: Route{Prefix: newString("/com.foo.Service/")},
+			path:  "/com.foo.Service/Hello",
+			want:  true,
+		},
+		{
+			name:  "path prefix mismatch",
+			route: Route{Prefix: newString("/com.foo.Service/")},
+			path:  "/com.bar.Service/Hello",
+		},
+		{
+			name:  "path exact ignores the query string",
+			route: Route{Path: newString("/com.foo.Service/Hello")},
+			path:  "/com.foo.Service/Hello?version=v2",
+			want:  true,
+		},
+		{
+			name:  "path regex",
+			route: Route{Regex: regexp.MustCompile(`/com\.foo\.\w+/Hello`)},
+			path:  "/com.foo.Service/Hello",
+			want:  true,
+		},
+		{
+			name:    "header exact",
+			route:   clusterRoute("canary", []*HeaderMatcher{{Name: "x-canary", ExactMatch: newString("true")}}, nil),
+			headers: map[string][]string{"X-Canary": {"true"}},
+			want:    true,
+		},
+		{
+			name:    "header exact mismatch",
+			route:   clusterRoute("canary", []*HeaderMatcher{{Name: "x-canary", ExactMatch: newString("true")}}, nil),
+			headers: map[string][]string{"x-canary": {"false"}},
+		},
+		{
+			name:    "header exact inverted",
+			route:   clusterRoute("canary", []*HeaderMatcher{{Name: "x-canary", ExactMatch: newString("true"), InvertMatch: newBool(true)}}, nil),
+			headers: map[string][]string{"x-canary": {"false"}},
+			want:    true,
+		},
+		{
+			name:    "header prefix",
+			route:   clusterRoute("canary", []*HeaderMatcher{{Name: "x-user", PrefixMatch: newString("beta-")}}, nil),
+			headers: map[string][]string{"x-user": {"beta-42"}},
+			want:    true,
+		},
+		{
+			name:    "header prefix mismatch",
+			route:   clusterRoute("canary", []*HeaderMatcher{{Name: "x-user", PrefixMatch: newString("beta-")}}, nil),
+			headers: map[string][]string{"x-user": {"alpha-42"}},
+		},
+		{
+			name:    "header regex",
+			route:   clusterRoute("canary", []*HeaderMatcher{{Name: "x-user", RegexMatch: regexp.MustCompile(`beta-\d+`)}}, nil),
+			headers: map[string][]string{"x-user": {"beta-42"}},
+			want:    true,
+		},
+		{
+			name:    "header regex is a full match",
+			route:   clusterRoute("canary", []*HeaderMatcher{{Name: "x-user", RegexMatch: regexp.MustCompile(`beta-\d+`)}}, nil),
+			headers: map[string][]string{"x-user": {"beta-42-x"}},
+		},
+		{
+			name:    "header present",
+			route:   clusterRoute("canary", []*HeaderMatcher{{Name: "x-canary", PresentMatch: newBool(true)}}, nil),
+			headers: map[string][]string{"x-canary": {"yes"}},
+			want:    true,
+		},
+		{
+			name:  "header present missing",
+			route: clusterRoute("canary", []*HeaderMatcher{{Name: "x-canary", PresentMatch: newBool(true)}}, nil),
+		},
+		{
+			name:  "header absent",
+			route: clusterRoute("canary", []*HeaderMatcher{{Name: "x-canary", PresentMatch: newBool(false)}}, nil),
+			want:  true,
+		},
+		{
+			name:  "query string match",
+			route: clusterRoute("canary", nil, []*QueryParameterMatcher{{Name: "version", StringMatch: &exact}}),
+			path:  "/com.foo.Service/Hello?version=v1&version=v2",
+			want:  true,
+		},
+		{
+			name:  "query string mismatch",
+			route: clusterRoute("canary", nil, []*QueryParameterMatcher{{Name: "version", StringMatch: &exact}}),
+			path:  "/com.foo.Service/Hello?version=v1",
+		},
+		{
+			name:  "query present",
+			route: clusterRoute("canary", nil, []*QueryParameterMatcher{{Name: "debug", PresentMatch: newBool(true)}}),
+			path:  "/com.foo.Service/Hello?debug",
+			want:  true,
+		},
+		{
+			name:  "query present missing",
+			route: clusterRoute("canary", nil, []*QueryParameterMatcher{{Name: "debug", PresentMatch: newBool(true)}}),
+			path:  "/com.foo.Service/Hello",
+		},
+		{
+			name:  "query absent",
+			route: clusterRoute("canary", nil, []*QueryParameterMatcher{{Name: "debug", PresentMatch: newBool(false)}}),
+			path:  "/com.foo.Service/Hello?version=v2",
+			want:  true,
+		},
+		{
+			name: "all matchers",
+			route: clusterRoute("canary",
+				[]*HeaderMatcher{{Name: "x-canary", ExactMatch: newString("true")}},
+				[]*QueryParameterMatcher{{Name: "version", StringMatch: &exact}}),
+			headers: map[string][]string{"x-canary": {"true"}},
+			path:    "/com.foo.Service/Hello?version=v2",
+			want:    true,
+		},
+		{
+			name: "all matchers but one",
+			route: clusterRoute("canary",
+				[]*HeaderMatcher{{Name: "x-canary", ExactMatch: newString("true")}},
+				[]*QueryParameterMatcher{{Name: "version", StringMatch: &exact}}),
+			headers: map[string][]string{"x-canary": {"true"}},
+			path:    "/com.foo.Service/Hello?version=v1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := test.path
+			if path == "" {
+				path = "/com.foo.Service/Hello"
+			}
+			got, ok := MatchRoute([]Route{test.route}, test.headers, path)
+			if ok != test.want {
+				t.Fatalf("MatchRoute() = %v, want %v", ok, test.want)
+			}
+			if ok && got == nil {
+				t.Fatal("MatchRoute() matched a nil route")
+			}
+		})
+	}
+}
+
+func TestMatchRouteSelectsFirstMatch(t *testing.T) {
+	routes := []Route{
+		{Path: newString("/com.foo.Service/Hello")},
+		clusterRoute("canary", []*HeaderMatcher{{Name: "x-canary", ExactMatch: newString("true")}}, nil),
+		clusterRoute("stable", nil, nil),
+	}
+
+	got, ok := MatchRoute(routes, map[string][]string{"x-canary": {"true"}}, "/com.foo.Service/Bye")
+	if !ok || got != &routes[1] {
+		t.Fatalf("MatchRoute() with the canary header = %+v, %v, want the canary route", got, ok)
+	}
+	got, ok = MatchRoute(routes, nil, "/com.foo.Service/Bye")
+	if !ok || got != &routes[2] {
+		t.Fatalf("MatchRoute() without the canary header = %+v, %v, want the stable route", got, ok)
+	}
+	got, ok = MatchRoute(routes, map[string][]string{"x-canary": {"true"}}, "/com.foo.Service/Hello")
+	if !ok || got != &routes[0] {
+		t.Fatalf("MatchRoute() = %+v, %v, want the first route", got, ok)
+	}
+	if _, ok := MatchRoute(routes[:1], nil, "/com.foo.Service/Bye"); ok {
+		t.Fatal("MatchRoute() matched without a matching route")
+	}
+	// A route without path matcher is skipped.
+	if _, ok := MatchRoute([]Route{{}}, nil, "/com.foo.Service/Bye"); ok {
+		t.Fatal("MatchRoute() matched an illegal route")
+	}
+}
@@ -51,10 +51,18 @@ type UpdateMetadata struct {
 	// of the resource in use (previous ACKed). If a response is NACKed, the
 	// NACKed version is in ErrState.
 	Version string
-	// Timestamp is when the response is received.
+	// Timestamp is when the response is received. It's kept when a response
+	// is NACKed, so it's the time of the last successful update.
 	Timestamp time.Time
 	// ErrState is set when the update is NACKed.
 	ErrState *UpdateErrorMetadata
+	// LastUpdated is when the resource was last received in a response and
+	// accepted, by the clock of the client. It's kept when a response is
+	// NACKed, and the staleness of the resource is measured from it.
+	LastUpdated time.Time
+	// Stale is set when the resource wasn't updated for longer than the max
+	// staleness of its type. It's cleared by the next update of the resource.
+	Stale bool
 }
 
 // IsListenerResource returns true if the provider URL corresponds to an xDS
@@ -23,6 +23,10 @@
 
 package resource
 
+import (
+	"time"
+)
+
 import (
 	"google.golang.org/protobuf/types/known/anypb"
 )
@@ -50,6 +54,38 @@ type ClusterLBPolicyRingHash struct {
 	MaximumRingSize uint64
 }
 
+// CircuitBreakerThresholds are the circuit breaking thresholds of a cluster,
+// for the DEFAULT routing priority. A nil threshold is unlimited.
+type CircuitBreakerThresholds struct {
+	// MaxConnections is the max number of connections to the cluster.
+	MaxConnections *uint32
+	// MaxPendingRequests is the max number of requests waiting for a
+	// connection to the cluster.
+	MaxPendingRequests *uint32
+	// MaxRequests is the max number of concurrent requests to the cluster.
+	MaxRequests *uint32
+	// MaxRetries is the max number of concurrent retries to the cluster.
+	MaxRetries *uint32
+}
+
+// OutlierDetection is the outlier detection of a cluster, ejecting for a
+// while the hosts which fail consecutively. The fields not set in the CDS
+// resource have the default values of Envoy.
+type OutlierDetection struct {
+	// Consecutive5xx is the number of consecutive failures ejecting a host.
+	// Defaults to 5.
+	Consecutive5xx uint32
+	// Interval is the time between the sweeps re-admitting the ejected hosts.
+	// Defaults to 10s.
+	Interval time.Duration
+	// BaseEjectionTime is the ejection time of a host, multiplied by the
+	// number of times it has been ejected in a row. Defaults to 30s.
+	BaseEjectionTime time.Duration
+	// MaxEjectionPercent is the max percentage of the hosts of the cluster
+	// which can be ejected at once. Defaults to 10.
+	MaxEjectionPercent uint32
+}
+
 // ClusterUpdate contains information from a received CDS response, which is of
 // interest to the registered CDS watcher.
 type ClusterUpdate struct {
@@ -63,8 +99,14 @@ type ClusterUpdate struct {
 	EnableLRS bool
 	// SecurityCfg contains security configuration sent by the control plane.
 	SecurityCfg *SecurityConfig
-	// MaxRequests for circuit breaking, if any (otherwise nil).
+	// MaxRequests for circuit breaking, if any (otherwise nil). It's the same
+	// as CircuitBreakers.MaxRequests.
 	MaxRequests *uint32
+	// CircuitBreakers are the circuit breaking thresholds of the cluster.
+	CircuitBreakers CircuitBreakerThresholds
+	// OutlierDetection is the outlier detection of the cluster, nil if it's
+	// disabled.
+	OutlierDetection *OutlierDetection
 	// DNSHostName is used only for cluster type DNS. It's the DNS name to
 	// resolve in "host:port" form
 	DNSHostName string
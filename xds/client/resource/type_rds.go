@@ -24,6 +24,7 @@
 package resource
 
 import (
+	"context"
 	"regexp"
 	"time"
 )
@@ -74,9 +75,30 @@ type RetryConfig struct {
 	// RetryOn is a set of status codes on which to retry.  Only Canceled,
 	// DeadlineExceeded, Internal, ResourceExhausted, and Unavailable are
 	// supported; any other values will be omitted.
-	RetryOn      map[codes.Code]bool
-	NumRetries   uint32       // maximum number of retry attempts
-	RetryBackoff RetryBackoff // retry backoff policy
+	RetryOn map[codes.Code]bool
+	// RetryOnConditions is the set of the retry_on conditions which are not
+	// status codes, e.g. "5xx", "reset" or "connect-failure", for the
+	// invocation layers retrying on them rather than on status codes. Only the
+	// conditions in RetryConditions are kept.
+	RetryOnConditions map[string]bool
+	NumRetries        uint32       // maximum number of retry attempts
+	RetryBackoff      RetryBackoff // retry backoff policy
+	// PerTryTimeout is the timeout of each attempt, including the first one.
+	// If nil, the attempts are only limited by the timeout of the request.
+	PerTryTimeout *time.Duration
+}
+
+// RetryConditions are the retry_on conditions of Envoy, other than the status
+// codes, kept in RetryConfig.RetryOnConditions.
+var RetryConditions = map[string]bool{
+	"5xx":                    true,
+	"gateway-error":          true,
+	"reset":                  true,
+	"connect-failure":        true,
+	"retriable-4xx":          true,
+	"refused-stream":         true,
+	"retriable-status-codes": true,
+	"retriable-headers":      true,
 }
 
 // RetryBackoff describes the backoff policy for retries.
@@ -136,6 +158,7 @@ type Route struct {
 	// is false (case sensitive).
 	CaseInsensitive bool
 	Headers         []*HeaderMatcher
+	QueryParameters []*QueryParameterMatcher
 	Fraction        *uint32
 
 	HashPolicies []*HashPolicy
@@ -147,6 +170,9 @@ type Route struct {
 	// MaxStreamDuration field should be used.  If MaxStreamDuration is set to
 	// an explicit zero duration, the application's deadline should be used.
 	MaxStreamDuration *time.Duration
+	// Timeout is the timeout of the route action, nil if it's unset or zero,
+	// which disables it. MaxStreamDuration takes precedence over it.
+	Timeout *time.Duration
 	// HTTPFilterConfigOverride contains any HTTP filter config overrides for
 	// the route which may be present.  An individual filter's override may be
 	// unused if the matching WeightedCluster contains an override for that
@@ -164,6 +190,43 @@ type Route struct {
 	ClusterSpecifierPlugin string
 }
 
+// EffectiveTimeout returns the route-level timeout of r: its
+// MaxStreamDuration if set, otherwise its Timeout. It returns false if there is
+// none, including when MaxStreamDuration is an explicit zero, in which case
+// the timeout of the invocation should be used.
+func (r *Route) EffectiveTimeout() (time.Duration, bool) {
+	if r.MaxStreamDuration != nil {
+		return *r.MaxStreamDuration, *r.MaxStreamDuration > 0
+	}
+	if r.Timeout != nil {
+		return *r.Timeout, true
+	}
+	return 0, false
+}
+
+// WithTimeout returns a copy of ctx with the deadline of the route-level
+// timeout of r, see EffectiveTimeout. The deadline of ctx is kept if it's
+// earlier, or if r has no route-level timeout. The returned cancel function
+// must be called once the invocation is done.
+func (r *Route) WithTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout, ok := r.EffectiveTimeout()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// EffectiveRetryConfig returns the retry config of r in the virtual host vh:
+// the one of r if it has one, otherwise the default one of vh. Like Envoy,
+// the retry config of a route replaces the one of its virtual host as a
+// whole, their fields are not merged.
+func (r *Route) EffectiveRetryConfig(vh *VirtualHost) *RetryConfig {
+	if r.RetryConfig != nil || vh == nil {
+		return r.RetryConfig
+	}
+	return vh.RetryConfig
+}
+
 // WeightedCluster contains settings for an xds ActionType.WeightedCluster.
 type WeightedCluster struct {
 	// Weight is the relative weight of the cluster.  It will never be zero.
@@ -191,6 +254,14 @@ type Int64Range struct {
 	End   int64
 }
 
+// QueryParameterMatcher represents query parameter matchers. Only one of
+// StringMatch and PresentMatch is set.
+type QueryParameterMatcher struct {
+	Name         string
+	StringMatch  *matcher.StringMatcher
+	PresentMatch *bool
+}
+
 // SecurityConfig contains the security configuration received as part of the
 // Cluster resource on the client-side, and as part of the Listener resource on
 // the server-side.
@@ -28,6 +28,7 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"time"
 )
 
 import (
@@ -41,6 +42,7 @@ import (
 	"github.com/golang/protobuf/proto"
 
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 import (
@@ -72,7 +74,7 @@ func unmarshalClusterResource(r *anypb.Any, f UpdateValidatorFunc, logger dubbog
 	if err := proto.Unmarshal(r.GetValue(), cluster); err != nil {
 		return "", ClusterUpdate{}, fmt.Errorf("failed to unmarshal resource: %v", err)
 	}
-	dubbogoLogger.Debugf("Resource with name: %v, type: %T, contains: %v", cluster.GetName(), cluster, pretty.ToJSON(cluster))
+	dubbogoLogger.Debugf("Resource with name: %v, type: %T, contains: %v", cluster.GetName(), cluster, pretty.Lazy(cluster))
 	cu, err := validateClusterAndConstructClusterUpdate(cluster)
 	if err != nil {
 		return cluster.GetName(), ClusterUpdate{}, err
@@ -141,12 +143,20 @@ func validateClusterAndConstructClusterUpdate(cluster *v3clusterpb.Cluster) (Clu
 		}
 	}
 
+	od, err := outlierDetectionFromCluster(cluster)
+	if err != nil {
+		return ClusterUpdate{}, err
+	}
+
+	circuitBreakers := circuitBreakersFromCluster(cluster)
 	ret := ClusterUpdate{
-		ClusterName: cluster.GetName(),
-		EnableLRS:   cluster.GetLrsServer().GetSelf() != nil,
-		SecurityCfg: sc,
-		MaxRequests: circuitBreakersFromCluster(cluster),
-		LBPolicy:    lbPolicy,
+		ClusterName:      cluster.GetName(),
+		EnableLRS:        cluster.GetLrsServer().GetSelf() != nil,
+		SecurityCfg:      sc,
+		MaxRequests:      circuitBreakers.MaxRequests,
+		CircuitBreakers:  circuitBreakers,
+		OutlierDetection: od,
+		LBPolicy:         lbPolicy,
 	}
 
 	// Validate and set cluster type from the response.
@@ -287,14 +297,12 @@ func securityConfigFromCommonTLSContext(common *v3tlspb.CommonTlsContext, server
 	if sc != nil {
 		// sc == nil is a valid case where the control plane has not sent us any
 		// security configuration. xDS creds will use fallback creds.
-		if server {
-			if sc.IdentityInstanceName == "" {
-				return nil, errors.New("security configuration on the server-side does not contain identity certificate provider instance name")
-			}
-		} else {
-			if sc.RootInstanceName == "" {
-				return nil, errors.New("security configuration on the client-side does not contain root certificate provider instance name")
-			}
+		//
+		// On the client-side, a missing root certificate provider instance
+		// name means that the system root certificates are to be used, which
+		// the update validator accepts only if the bootstrap file allows it.
+		if server && sc.IdentityInstanceName == "" {
+			return nil, errors.New("security configuration on the server-side does not contain identity certificate provider instance name")
 		}
 	}
 	return sc, nil
@@ -413,11 +421,13 @@ func securityConfigFromCommonTLSContextUsingNewFields(common *v3tlspb.CommonTlsC
 		return nil, fmt.Errorf("validation context contains unexpected type: %T", typ)
 	}
 	// If we get here, it means that the `CertificateValidationContext` message
-	// was found through one of the supported ways. It is an error if the
-	// validation context is specified, but it does not contain the
-	// ca_certificate_provider_instance field which contains information about
-	// the certificate provider to be used for the root certificates.
-	if validationCtx.GetCaCertificateProviderInstance() == nil {
+	// was found through one of the supported ways. On the server-side, it is
+	// an error if the validation context is specified, but it does not
+	// contain the ca_certificate_provider_instance field which contains
+	// information about the certificate provider to be used for the root
+	// certificates. On the client-side, the system root certificates may be
+	// used instead.
+	if server && validationCtx.GetCaCertificateProviderInstance() == nil {
 		return nil, fmt.Errorf("expected field ca_certificate_provider_instance is missing in CommonTlsContext message: %+v", common)
 	}
 	// The following fields are ignored:
@@ -458,19 +468,78 @@ func securityConfigFromCommonTLSContextUsingNewFields(common *v3tlspb.CommonTlsC
 }
 
 // circuitBreakersFromCluster extracts the circuit breakers configuration from
-// the received cluster resource. Returns nil if no CircuitBreakers or no
-// Thresholds in CircuitBreakers.
-func circuitBreakersFromCluster(cluster *v3clusterpb.Cluster) *uint32 {
+// the received cluster resource. The thresholds are unlimited if there are no
+// CircuitBreakers, no Thresholds for the DEFAULT priority in CircuitBreakers,
+// or if they are not set in these Thresholds.
+func circuitBreakersFromCluster(cluster *v3clusterpb.Cluster) CircuitBreakerThresholds {
 	for _, threshold := range cluster.GetCircuitBreakers().GetThresholds() {
 		if threshold.GetPriority() != v3corepb.RoutingPriority_DEFAULT {
 			continue
 		}
-		maxRequestsPb := threshold.GetMaxRequests()
-		if maxRequestsPb == nil {
-			return nil
+		return CircuitBreakerThresholds{
+			MaxConnections:     uint32Value(threshold.GetMaxConnections()),
+			MaxPendingRequests: uint32Value(threshold.GetMaxPendingRequests()),
+			MaxRequests:        uint32Value(threshold.GetMaxRequests()),
+			MaxRetries:         uint32Value(threshold.GetMaxRetries()),
 		}
-		maxRequests := maxRequestsPb.GetValue()
-		return &maxRequests
 	}
-	return nil
+	return CircuitBreakerThresholds{}
+}
+
+// Defaults of the outlier detection fields, as documented by Envoy.
+const (
+	defaultConsecutive5xx     = 5
+	defaultODInterval         = 10 * time.Second
+	defaultBaseEjectionTime   = 30 * time.Second
+	defaultMaxEjectionPercent = 10
+)
+
+// outlierDetectionFromCluster extracts the outlier detection of the received
+// cluster resource, nil if it has none. The fields not set get their default
+// values.
+func outlierDetectionFromCluster(cluster *v3clusterpb.Cluster) (*OutlierDetection, error) {
+	od := cluster.GetOutlierDetection()
+	if od == nil {
+		return nil, nil
+	}
+	ret := &OutlierDetection{
+		Consecutive5xx:     defaultConsecutive5xx,
+		Interval:           defaultODInterval,
+		BaseEjectionTime:   defaultBaseEjectionTime,
+		MaxEjectionPercent: defaultMaxEjectionPercent,
+	}
+	if v := od.GetConsecutive_5Xx(); v != nil {
+		ret.Consecutive5xx = v.GetValue()
+	}
+	if d := od.GetInterval(); d != nil {
+		if err := d.CheckValid(); err != nil {
+			return nil, fmt.Errorf("outlier_detection.interval is invalid: %v", err)
+		}
+		if ret.Interval = d.AsDuration(); ret.Interval <= 0 {
+			return nil, fmt.Errorf("outlier_detection.interval %v must be positive", ret.Interval)
+		}
+	}
+	if d := od.GetBaseEjectionTime(); d != nil {
+		if err := d.CheckValid(); err != nil {
+			return nil, fmt.Errorf("outlier_detection.base_ejection_time is invalid: %v", err)
+		}
+		if ret.BaseEjectionTime = d.AsDuration(); ret.BaseEjectionTime < 0 {
+			return nil, fmt.Errorf("outlier_detection.base_ejection_time %v must not be negative", ret.BaseEjectionTime)
+		}
+	}
+	if v := od.GetMaxEjectionPercent(); v != nil {
+		if ret.MaxEjectionPercent = v.GetValue(); ret.MaxEjectionPercent > 100 {
+			return nil, fmt.Errorf("outlier_detection.max_ejection_percent %v is greater than 100", ret.MaxEjectionPercent)
+		}
+	}
+	return ret, nil
+}
+
+// uint32Value returns the value of v, or nil if v isn't set.
+func uint32Value(v *wrapperspb.UInt32Value) *uint32 {
+	if v == nil {
+		return nil
+	}
+	value := v.GetValue()
+	return &value
 }
@@ -0,0 +1,183 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"testing"
+	"time"
+)
+
+import (
+	v3clusterpb "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	v3corepb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newUint32(v uint32) *uint32 {
+	return &v
+}
+
+func TestCircuitBreakersFromCluster(t *testing.T) {
+	tests := []struct {
+		name    string
+		cluster *v3clusterpb.Cluster
+		want    CircuitBreakerThresholds
+	}{
+		{
+			name:    "no circuit breakers",
+			cluster: &v3clusterpb.Cluster{},
+		},
+		{
+			name: "all thresholds",
+			cluster: &v3clusterpb.Cluster{
+				CircuitBreakers: &v3clusterpb.CircuitBreakers{
+					Thresholds: []*v3clusterpb.CircuitBreakers_Thresholds{{
+						Priority:           v3corepb.RoutingPriority_DEFAULT,
+						MaxConnections:     wrapperspb.UInt32(10),
+						MaxPendingRequests: wrapperspb.UInt32(20),
+						MaxRequests:        wrapperspb.UInt32(30),
+						MaxRetries:         wrapperspb.UInt32(0),
+					}},
+				},
+			},
+			want: CircuitBreakerThresholds{
+				MaxConnections:     newUint32(10),
+				MaxPendingRequests: newUint32(20),
+				MaxRequests:        newUint32(30),
+				MaxRetries:         newUint32(0),
+			},
+		},
+		{
+			name: "omitted thresholds are unlimited",
+			cluster: &v3clusterpb.Cluster{
+				CircuitBreakers: &v3clusterpb.CircuitBreakers{
+					Thresholds: []*v3clusterpb.CircuitBreakers_Thresholds{{
+						Priority:    v3corepb.RoutingPriority_DEFAULT,
+						MaxRequests: wrapperspb.UInt32(30),
+					}},
+				},
+			},
+			want: CircuitBreakerThresholds{MaxRequests: newUint32(30)},
+		},
+		{
+			name: "high priority only",
+			cluster: &v3clusterpb.Cluster{
+				CircuitBreakers: &v3clusterpb.CircuitBreakers{
+					Thresholds: []*v3clusterpb.CircuitBreakers_Thresholds{{
+						Priority:    v3corepb.RoutingPriority_HIGH,
+						MaxRequests: wrapperspb.UInt32(30),
+					}},
+				},
+			},
+		},
+		{
+			name: "default priority among others",
+			cluster: &v3clusterpb.Cluster{
+				CircuitBreakers: &v3clusterpb.CircuitBreakers{
+					Thresholds: []*v3clusterpb.CircuitBreakers_Thresholds{
+						{
+							Priority:    v3corepb.RoutingPriority_HIGH,
+							MaxRequests: wrapperspb.UInt32(30),
+						},
+						{
+							Priority:       v3corepb.RoutingPriority_DEFAULT,
+							MaxConnections: wrapperspb.UInt32(5),
+						},
+					},
+				},
+			},
+			want: CircuitBreakerThresholds{MaxConnections: newUint32(5)},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := circuitBreakersFromCluster(test.cluster)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("circuitBreakersFromCluster() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOutlierDetectionFromCluster(t *testing.T) {
+	tests := []struct {
+		name    string
+		od      *v3clusterpb.OutlierDetection
+		want    *OutlierDetection
+		wantErr bool
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name: "defaults",
+			od:   &v3clusterpb.OutlierDetection{},
+			want: &OutlierDetection{
+				Consecutive5xx:     5,
+				Interval:           10 * time.Second,
+				BaseEjectionTime:   30 * time.Second,
+				MaxEjectionPercent: 10,
+			},
+		},
+		{
+			name: "all fields",
+			od: &v3clusterpb.OutlierDetection{
+				Consecutive_5Xx:    wrapperspb.UInt32(3),
+				Interval:           durationpb.New(time.Second),
+				BaseEjectionTime:   durationpb.New(time.Minute),
+				MaxEjectionPercent: wrapperspb.UInt32(50),
+			},
+			want: &OutlierDetection{
+				Consecutive5xx:     3,
+				Interval:           time.Second,
+				BaseEjectionTime:   time.Minute,
+				MaxEjectionPercent: 50,
+			},
+		},
+		{
+			name:    "zero interval",
+			od:      &v3clusterpb.OutlierDetection{Interval: durationpb.New(0)},
+			wantErr: true,
+		},
+		{
+			name:    "negative base ejection time",
+			od:      &v3clusterpb.OutlierDetection{BaseEjectionTime: durationpb.New(-time.Second)},
+			wantErr: true,
+		},
+		{
+			name:    "max ejection percent over 100",
+			od:      &v3clusterpb.OutlierDetection{MaxEjectionPercent: wrapperspb.UInt32(101)},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := outlierDetectionFromCluster(&v3clusterpb.Cluster{OutlierDetection: test.od})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("outlierDetectionFromCluster() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("outlierDetectionFromCluster() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -63,7 +63,7 @@ func unmarshalEndpointsResource(r *anypb.Any, logger dubbogoLogger.Logger) (stri
 	if err := proto.Unmarshal(r.GetValue(), cla); err != nil {
 		return "", EndpointsUpdate{}, fmt.Errorf("failed to unmarshal resource: %v", err)
 	}
-	dubbogoLogger.Debugf("Resource with name: %v, type: %T, contains: %v", cla.GetClusterName(), cla, pretty.ToJSON(cla))
+	dubbogoLogger.Debugf("Resource with name: %v, type: %T, contains: %v", cla.GetClusterName(), cla, pretty.Lazy(cla))
 
 	u, err := parseEDSRespProto(cla)
 	if err != nil {
@@ -71,7 +71,7 @@ func unmarshalListenerResource(r *anypb.Any, f UpdateValidatorFunc, logger dubbo
 	if err := proto.Unmarshal(r.GetValue(), lis); err != nil {
 		return "", ListenerUpdate{}, fmt.Errorf("failed to unmarshal resource: %v", err)
 	}
-	dubbogoLogger.Debugf("Resource with name: %v, type: %T, contains: %v", lis.GetName(), lis, pretty.ToJSON(lis))
+	dubbogoLogger.Debugf("Resource with name: %v, type: %T, contains: %v", lis.GetName(), lis, pretty.Lazy(lis))
 
 	lu, err := processListener(lis, logger, v2)
 	if err != nil {
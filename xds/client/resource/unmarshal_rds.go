@@ -47,6 +47,7 @@ import (
 	"dubbo.apache.org/dubbo-go/v3/xds/client/resource/version"
 	"dubbo.apache.org/dubbo-go/v3/xds/clusterspecifier"
 	"dubbo.apache.org/dubbo-go/v3/xds/utils/envconfig"
+	"dubbo.apache.org/dubbo-go/v3/xds/utils/matcher"
 	"dubbo.apache.org/dubbo-go/v3/xds/utils/pretty"
 )
 
@@ -68,7 +69,7 @@ func unmarshalRouteConfigResource(r *anypb.Any, logger dubbogoLogger.Logger) (st
 	if err := proto.Unmarshal(r.GetValue(), rc); err != nil {
 		return "", RouteConfigUpdate{}, fmt.Errorf("failed to unmarshal resource: %v", err)
 	}
-	dubbogoLogger.Debugf("Resource with name: %v, type: %T, contains: %v.", rc.GetName(), rc, pretty.ToJSON(rc))
+	dubbogoLogger.Debugf("Resource with name: %v, type: %T, contains: %v.", rc.GetName(), rc, pretty.Lazy(rc))
 
 	// TODO: Pass version.TransportAPI instead of relying upon the type URL
 	v2 := r.GetTypeUrl() == version.V2RouteConfigURL
@@ -181,9 +182,9 @@ func generateRetryConfig(rp *v3routepb.RetryPolicy) (*RetryConfig, error) {
 		return nil, nil
 	}
 
-	cfg := &RetryConfig{RetryOn: make(map[codes.Code]bool)}
+	cfg := &RetryConfig{RetryOn: make(map[codes.Code]bool), RetryOnConditions: make(map[string]bool)}
 	for _, s := range strings.Split(rp.GetRetryOn(), ",") {
-		switch strings.TrimSpace(strings.ToLower(s)) {
+		switch s = strings.TrimSpace(strings.ToLower(s)); s {
 		// FIXME, is this misspelled by grpc?
 		case "cancel" + "led":
 			cfg.RetryOn[codes.Canceled] = true
@@ -195,6 +196,10 @@ func generateRetryConfig(rp *v3routepb.RetryPolicy) (*RetryConfig, error) {
 			cfg.RetryOn[codes.ResourceExhausted] = true
 		case "unavailable":
 			cfg.RetryOn[codes.Unavailable] = true
+		default:
+			if RetryConditions[s] {
+				cfg.RetryOnConditions[s] = true
+			}
 		}
 	}
 
@@ -225,7 +230,19 @@ func generateRetryConfig(rp *v3routepb.RetryPolicy) (*RetryConfig, error) {
 		}
 	}
 
-	if len(cfg.RetryOn) == 0 {
+	if d := rp.GetPerTryTimeout(); d != nil {
+		if err := d.CheckValid(); err != nil {
+			return nil, fmt.Errorf("retry_policy.per_try_timeout is invalid: %v", err)
+		}
+		// A zero per try timeout is the same as none.
+		if timeout := d.AsDuration(); timeout < 0 {
+			return nil, fmt.Errorf("retry_policy.per_try_timeout = %v; must be >= 0", timeout)
+		} else if timeout > 0 {
+			cfg.PerTryTimeout = &timeout
+		}
+	}
+
+	if len(cfg.RetryOn) == 0 && len(cfg.RetryOnConditions) == 0 {
 		return &RetryConfig{}, nil
 	}
 	return cfg, nil
@@ -240,12 +257,6 @@ func routesProtoToSlice(routes []*v3routepb.Route, csps map[string]clusterspecif
 			return nil, nil, fmt.Errorf("route %+v doesn't have a match", r)
 		}
 
-		if len(match.GetQueryParameters()) != 0 {
-			// Ignore route with query parameters.
-			logger.Warnf("route %+v has query parameter matchers, the route will be ignored", r)
-			continue
-		}
-
 		pathSp := match.GetPathSpecifier()
 		if pathSp == nil {
 			return nil, nil, fmt.Errorf("route %+v doesn't have a path specifier", r)
@@ -304,6 +315,23 @@ func routesProtoToSlice(routes []*v3routepb.Route, csps map[string]clusterspecif
 			route.Headers = append(route.Headers, &header)
 		}
 
+		for _, q := range match.GetQueryParameters() {
+			query := QueryParameterMatcher{Name: q.GetName()}
+			switch qt := q.GetQueryParameterMatchSpecifier().(type) {
+			case *v3routepb.QueryParameterMatcher_StringMatch:
+				sm, err := matcher.StringMatcherFromProto(qt.StringMatch)
+				if err != nil {
+					return nil, nil, fmt.Errorf("route %+v has an invalid query parameter matcher: %v", r, err)
+				}
+				query.StringMatch = &sm
+			case *v3routepb.QueryParameterMatcher_PresentMatch:
+				query.PresentMatch = &qt.PresentMatch
+			default:
+				return nil, nil, fmt.Errorf("route %+v has an unrecognized query parameter matcher: %+v", r, qt)
+			}
+			route.QueryParameters = append(route.QueryParameters, &query)
+		}
+
 		if fr := match.GetRuntimeFraction(); fr != nil {
 			d := fr.GetDefaultValue()
 			n := d.GetNumerator()
@@ -395,6 +423,18 @@ func routesProtoToSlice(routes []*v3routepb.Route, csps map[string]clusterspecif
 				route.MaxStreamDuration = &d
 			}
 
+			if timeout := action.GetTimeout(); timeout != nil {
+				if err := timeout.CheckValid(); err != nil {
+					return nil, nil, fmt.Errorf("route %+v, action %+v: invalid timeout: %v", r, action, err)
+				}
+				// A zero timeout disables the route timeout.
+				if d := timeout.AsDuration(); d < 0 {
+					return nil, nil, fmt.Errorf("route %+v, action %+v: timeout %v must not be negative", r, action, d)
+				} else if d > 0 {
+					route.Timeout = &d
+				}
+			}
+
 			var err error
 			route.RetryConfig, err = generateRetryConfig(action.GetRetryPolicy())
 			if err != nil {
@@ -0,0 +1,262 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+
+	v3routepb "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/grpc/codes"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newDuration(d time.Duration) *time.Duration {
+	return &d
+}
+
+func TestGenerateRetryConfig(t *testing.T) {
+	defaultBackoff := RetryBackoff{BaseInterval: 25 * time.Millisecond, MaxInterval: 250 * time.Millisecond}
+	tests := []struct {
+		name    string
+		rp      *v3routepb.RetryPolicy
+		want    *RetryConfig
+		wantErr bool
+	}{
+		{
+			name: "no retry policy",
+		},
+		{
+			name: "status codes and conditions",
+			rp: &v3routepb.RetryPolicy{
+				RetryOn:       "5xx, reset,connect-failure,unavailable,unknown-condition",
+				NumRetries:    wrapperspb.UInt32(3),
+				PerTryTimeout: durationpb.New(2 * time.Second),
+			},
+			want: &RetryConfig{
+				RetryOn:           map[codes.Code]bool{codes.Unavailable: true},
+				RetryOnConditions: map[string]bool{"5xx": true, "reset": true, "connect-failure": true},
+				NumRetries:        3,
+				RetryBackoff:      defaultBackoff,
+				PerTryTimeout:     newDuration(2 * time.Second),
+			},
+		},
+		{
+			name: "conditions only",
+			rp:   &v3routepb.RetryPolicy{RetryOn: "connect-failure"},
+			want: &RetryConfig{
+				RetryOn:           map[codes.Code]bool{},
+				RetryOnConditions: map[string]bool{"connect-failure": true},
+				NumRetries:        1,
+				RetryBackoff:      defaultBackoff,
+			},
+		},
+		{
+			name: "no supported condition",
+			rp:   &v3routepb.RetryPolicy{RetryOn: "unknown-condition", NumRetries: wrapperspb.UInt32(3)},
+			want: &RetryConfig{},
+		},
+		{
+			name: "zero per try timeout",
+			rp:   &v3routepb.RetryPolicy{RetryOn: "5xx", PerTryTimeout: durationpb.New(0)},
+			want: &RetryConfig{
+				RetryOn:           map[codes.Code]bool{},
+				RetryOnConditions: map[string]bool{"5xx": true},
+				NumRetries:        1,
+				RetryBackoff:      defaultBackoff,
+			},
+		},
+		{
+			name:    "negative per try timeout",
+			rp:      &v3routepb.RetryPolicy{RetryOn: "5xx", PerTryTimeout: durationpb.New(-time.Second)},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := generateRetryConfig(test.rp)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("generateRetryConfig() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("generateRetryConfig() diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEffectiveRetryConfigInheritance(t *testing.T) {
+	clusterAction := &v3routepb.Route_Route{Route: &v3routepb.RouteAction{
+		ClusterSpecifier: &v3routepb.RouteAction_Cluster{Cluster: "cluster"},
+	}}
+	rc := &v3routepb.RouteConfiguration{
+		VirtualHosts: []*v3routepb.VirtualHost{{
+			Domains:     []string{"*"},
+			RetryPolicy: &v3routepb.RetryPolicy{RetryOn: "5xx", NumRetries: wrapperspb.UInt32(2)},
+			Routes: []*v3routepb.Route{
+				{
+					Match: &v3routepb.RouteMatch{PathSpecifier: &v3routepb.RouteMatch_Prefix{Prefix: "/com.foo.Service/"}},
+					Action: &v3routepb.Route_Route{Route: &v3routepb.RouteAction{
+						ClusterSpecifier: &v3routepb.RouteAction_Cluster{Cluster: "cluster"},
+						RetryPolicy:      &v3routepb.RetryPolicy{RetryOn: "reset", NumRetries: wrapperspb.UInt32(4)},
+					}},
+				},
+				{
+					Match:  &v3routepb.RouteMatch{PathSpecifier: &v3routepb.RouteMatch_Prefix{Prefix: "/"}},
+					Action: clusterAction,
+				},
+			},
+		}},
+	}
+	update, err := generateRDSUpdateFromRouteConfiguration(rc, dubbogoLogger.GetLogger(), false)
+	if err != nil {
+		t.Fatalf("generateRDSUpdateFromRouteConfiguration() failed: %v", err)
+	}
+	vh := update.VirtualHosts[0]
+
+	// The route retry policy overrides the one of the virtual host as a whole.
+	got := vh.Routes[0].EffectiveRetryConfig(vh)
+	if want := map[string]bool{"reset": true}; !cmp.Equal(got.RetryOnConditions, want) || got.NumRetries != 4 {
+		t.Errorf("route retry config = %+v, want conditions %v and 4 retries", got, want)
+	}
+	// Without one, the route inherits the retry policy of the virtual host.
+	got = vh.Routes[1].EffectiveRetryConfig(vh)
+	if want := map[string]bool{"5xx": true}; !cmp.Equal(got.RetryOnConditions, want) || got.NumRetries != 2 {
+		t.Errorf("inherited retry config = %+v, want conditions %v and 2 retries", got, want)
+	}
+	if got := vh.Routes[1].EffectiveRetryConfig(nil); got != nil {
+		t.Errorf("retry config without virtual host = %+v, want nil", got)
+	}
+}
+
+func TestRouteTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      *v3routepb.RouteAction
+		wantTimeout *time.Duration
+		wantMSD     *time.Duration
+		wantRoute   time.Duration
+		wantOK      bool
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:        "timeout",
+			action:      &v3routepb.RouteAction{Timeout: durationpb.New(3 * time.Second)},
+			wantTimeout: newDuration(3 * time.Second),
+			wantRoute:   3 * time.Second,
+			wantOK:      true,
+		},
+		{
+			name:   "zero timeout",
+			action: &v3routepb.RouteAction{Timeout: durationpb.New(0)},
+		},
+		{
+			name: "max stream duration over timeout",
+			action: &v3routepb.RouteAction{
+				Timeout:           durationpb.New(3 * time.Second),
+				MaxStreamDuration: &v3routepb.RouteAction_MaxStreamDuration{MaxStreamDuration: durationpb.New(time.Second)},
+			},
+			wantTimeout: newDuration(3 * time.Second),
+			wantMSD:     newDuration(time.Second),
+			wantRoute:   time.Second,
+			wantOK:      true,
+		},
+		{
+			name: "grpc timeout header max over max stream duration",
+			action: &v3routepb.RouteAction{MaxStreamDuration: &v3routepb.RouteAction_MaxStreamDuration{
+				MaxStreamDuration:    durationpb.New(time.Second),
+				GrpcTimeoutHeaderMax: durationpb.New(2 * time.Second),
+			}},
+			wantMSD:   newDuration(2 * time.Second),
+			wantRoute: 2 * time.Second,
+			wantOK:    true,
+		},
+		{
+			name: "zero max stream duration disables the timeout",
+			action: &v3routepb.RouteAction{
+				Timeout:           durationpb.New(3 * time.Second),
+				MaxStreamDuration: &v3routepb.RouteAction_MaxStreamDuration{MaxStreamDuration: durationpb.New(0)},
+			},
+			wantTimeout: newDuration(3 * time.Second),
+			wantMSD:     newDuration(0),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			action := test.action
+			if action == nil {
+				action = &v3routepb.RouteAction{}
+			}
+			action.ClusterSpecifier = &v3routepb.RouteAction_Cluster{Cluster: "cluster"}
+			routes, _, err := routesProtoToSlice([]*v3routepb.Route{{
+				Match:  &v3routepb.RouteMatch{PathSpecifier: &v3routepb.RouteMatch_Prefix{Prefix: "/"}},
+				Action: &v3routepb.Route_Route{Route: action},
+			}}, nil, dubbogoLogger.GetLogger(), false)
+			if err != nil {
+				t.Fatalf("routesProtoToSlice() failed: %v", err)
+			}
+			route := routes[0]
+			if diff := cmp.Diff(test.wantTimeout, route.Timeout); diff != "" {
+				t.Errorf("Timeout diff (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantMSD, route.MaxStreamDuration); diff != "" {
+				t.Errorf("MaxStreamDuration diff (-want +got):\n%s", diff)
+			}
+			if got, ok := route.EffectiveTimeout(); got != test.wantRoute || ok != test.wantOK {
+				t.Errorf("EffectiveTimeout() = %v, %v, want %v, %v", got, ok, test.wantRoute, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestRouteWithTimeout(t *testing.T) {
+	route := &Route{Timeout: newDuration(time.Hour)}
+	ctx, cancel := route.WithTimeout(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Hour {
+		t.Errorf("deadline = %v, %v, want within an hour", deadline, ok)
+	}
+
+	// An earlier deadline of the invocation is kept.
+	parent, parentCancel := context.WithTimeout(context.Background(), time.Minute)
+	defer parentCancel()
+	ctx, cancel = route.WithTimeout(parent)
+	defer cancel()
+	if deadline, _ := ctx.Deadline(); time.Until(deadline) > time.Minute {
+		t.Errorf("deadline = %v, want the one of the invocation", deadline)
+	}
+
+	// Without route-level timeout, there is no deadline.
+	ctx, cancel = (&Route{}).WithTimeout(context.Background())
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); ok {
+		t.Errorf("deadline = %v, want none", deadline)
+	}
+}
@@ -0,0 +1,55 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"sort"
+)
+
+import (
+	xxhash "github.com/cespare/xxhash/v2"
+)
+
+// PickWeightedCluster returns the name of one of the weighted clusters of
+// route, picked with hashKey in proportion to their weights. The same hash
+// key always picks the same cluster as long as the weighted clusters don't
+// change, so that e.g. the retries of a request hit the same canary. It
+// returns "" if route has no weighted clusters.
+func PickWeightedCluster(route *Route, hashKey string) string {
+	names := make([]string, 0, len(route.WeightedClusters))
+	var total uint64
+	for name, wc := range route.WeightedClusters {
+		names = append(names, name)
+		total += uint64(wc.Weight)
+	}
+	if total == 0 {
+		return ""
+	}
+	// The clusters are in a map, sort them to always pick in the same order.
+	sort.Strings(names)
+
+	pick := xxhash.Sum64String(hashKey) % total
+	for _, name := range names {
+		w := uint64(route.WeightedClusters[name].Weight)
+		if pick < w {
+			return name
+		}
+		pick -= w
+	}
+	return names[len(names)-1]
+}
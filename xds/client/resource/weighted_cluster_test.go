@@ -0,0 +1,73 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestPickWeightedClusterDistribution(t *testing.T) {
+	route := &Route{WeightedClusters: map[string]WeightedCluster{
+		"stable":   {Weight: 70},
+		"canary":   {Weight: 20},
+		"fallback": {Weight: 10},
+	}}
+	const keys = 100000
+	got := make(map[string]int)
+	for i := 0; i < keys; i++ {
+		got[PickWeightedCluster(route, "request-"+strconv.Itoa(i))]++
+	}
+	if len(got) != len(route.WeightedClusters) {
+		t.Fatalf("picked clusters %v, want %d clusters", got, len(route.WeightedClusters))
+	}
+	for name, wc := range route.WeightedClusters {
+		ratio := float64(got[name]) / keys
+		want := float64(wc.Weight) / 100
+		if math.Abs(ratio-want) > 0.01 {
+			t.Errorf("cluster %s picked for %.3f of the keys, want %.3f", name, ratio, want)
+		}
+	}
+}
+
+func TestPickWeightedClusterStable(t *testing.T) {
+	route := &Route{WeightedClusters: map[string]WeightedCluster{
+		"stable": {Weight: 50},
+		"canary": {Weight: 50},
+	}}
+	for i := 0; i < 100; i++ {
+		key := "request-" + strconv.Itoa(i)
+		want := PickWeightedCluster(route, key)
+		for j := 0; j < 10; j++ {
+			if got := PickWeightedCluster(route, key); got != want {
+				t.Fatalf("PickWeightedCluster(%q) = %q, then %q", key, want, got)
+			}
+		}
+	}
+}
+
+func TestPickWeightedClusterSingleOrNone(t *testing.T) {
+	single := &Route{WeightedClusters: map[string]WeightedCluster{"only": {Weight: 1}}}
+	if got := PickWeightedCluster(single, "key"); got != "only" {
+		t.Errorf("PickWeightedCluster() = %q, want %q", got, "only")
+	}
+	if got := PickWeightedCluster(&Route{}, "key"); got != "" {
+		t.Errorf("PickWeightedCluster() without clusters = %q, want \"\"", got)
+	}
+}
@@ -27,10 +27,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"runtime"
 	"sync"
 	"time"
 )
 
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+)
+
 import (
 	"dubbo.apache.org/dubbo-go/v3/xds/client/bootstrap"
 )
@@ -38,6 +43,10 @@ import (
 const (
 	defaultWatchExpiryTimeout         = 15 * time.Second
 	defaultIdleAuthorityDeleteTimeout = 5 * time.Minute
+	// defaultIdleAuthorityDeleteJitter spreads the deletions of the idle
+	// authorities over a window, so that the clients of a fleet don't all
+	// reconnect at the same time when the authorities are revived.
+	defaultIdleAuthorityDeleteJitter = 0.2
 )
 
 // This is the Client returned by New(). It contains one client implementation,
@@ -54,7 +63,7 @@ type clientRefCounted struct {
 
 	// This mu protects all the fields, including the embedded clientImpl above.
 	mu       sync.Mutex
-	refCount int
+	refCount int32
 }
 
 // New returns a new xdsClient configured by the bootstrap file specified in env
@@ -83,7 +92,7 @@ func newRefCounted() (*clientRefCounted, error) {
 	// If the client implementation was created, increment ref count and return
 	// the client.
 	if singletonClient.clientImpl != nil {
-		singletonClient.refCount++
+		singletonClient.incRefLocked(2)
 		return singletonClient, nil
 	}
 
@@ -92,13 +101,13 @@ func newRefCounted() (*clientRefCounted, error) {
 	if err != nil {
 		return nil, fmt.Errorf("xds: failed to read bootstrap file: %v", err)
 	}
-	c, err := newWithConfig(config, defaultWatchExpiryTimeout, defaultIdleAuthorityDeleteTimeout)
+	c, err := newWithConfig(config, defaultWatchExpiryTimeout, defaultIdleAuthorityDeleteTimeout, defaultIdleAuthorityDeleteJitter)
 	if err != nil {
 		return nil, err
 	}
 
 	singletonClient.clientImpl = c
-	singletonClient.refCount++
+	singletonClient.incRefLocked(2)
 	return singletonClient, nil
 }
 
@@ -111,26 +120,29 @@ func newRefCounted() (*clientRefCounted, error) {
 // singleton. The following calls will return the singleton xds client without
 // checking or using the config.
 //
+// The opts, e.g. WithLogger and WithLogLevel, only apply when the client is
+// created.
+//
 // This function is internal only, for c2p resolver and testing to use. DO NOT
 // use this elsewhere. Use New() instead.
-func NewWithConfig(config *bootstrap.Config) (XDSClient, error) {
+func NewWithConfig(config *bootstrap.Config, opts ...Option) (XDSClient, error) {
 	singletonClient.mu.Lock()
 	defer singletonClient.mu.Unlock()
 	// If the client implementation was created, increment ref count and return
 	// the client.
 	if singletonClient.clientImpl != nil {
-		singletonClient.refCount++
+		singletonClient.incRefLocked(1)
 		return singletonClient, nil
 	}
 
 	// Create the new client implementation.
-	c, err := newWithConfig(config, defaultWatchExpiryTimeout, defaultIdleAuthorityDeleteTimeout)
+	c, err := newWithConfig(config, defaultWatchExpiryTimeout, defaultIdleAuthorityDeleteTimeout, defaultIdleAuthorityDeleteJitter, opts...)
 	if err != nil {
 		return nil, err
 	}
 
 	singletonClient.clientImpl = c
-	singletonClient.refCount++
+	singletonClient.incRefLocked(1)
 	return singletonClient, nil
 }
 
@@ -140,8 +152,7 @@ func NewWithConfig(config *bootstrap.Config) (XDSClient, error) {
 func (c *clientRefCounted) Close() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.refCount--
-	if c.refCount == 0 {
+	if refCount, ok := c.decRefLocked(1); ok && refCount == 0 {
 		c.clientImpl.Close()
 		// Set clientImpl back to nil. So if New() is called after this, a new
 		// implementation will be created.
@@ -149,12 +160,59 @@ func (c *clientRefCounted) Close() {
 	}
 }
 
+// RefCount returns the number of references to the client, i.e. the number of
+// New calls not yet matched by a Close.
+func (c *clientRefCounted) RefCount() int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refCount
+}
+
+// incRefLocked increments the ref count. The depth is the number of frames
+// between incRefLocked and the caller of the exported function, e.g. 1 if it's
+// called by New directly. Caller must hold c.mu.
+func (c *clientRefCounted) incRefLocked(depth int) int32 {
+	c.refCount++
+	if c.clientImpl.logger.enabled(LogLevelDebug) {
+		c.clientImpl.logger.Debugf("[xds] client ref count incremented to %d by %s", c.refCount, callerSite(depth+1))
+	}
+	return c.refCount
+}
+
+// decRefLocked decrements the ref count. If the count would go negative, which
+// means the client is closed more times than it's created, the extra Close is
+// logged at error level with its site and ignored, and false is returned. The
+// depth is as in incRefLocked. Caller must hold c.mu.
+func (c *clientRefCounted) decRefLocked(depth int) (int32, bool) {
+	if c.refCount <= 0 {
+		dubbogoLogger.Errorf("[xds] client closed more times than created, ref count %d, Close called by %s", c.refCount, callerSite(depth+1))
+		return c.refCount, false
+	}
+	c.refCount--
+	if c.clientImpl.logger.enabled(LogLevelDebug) {
+		c.clientImpl.logger.Debugf("[xds] client ref count decremented to %d by %s", c.refCount, callerSite(depth+1))
+	}
+	return c.refCount, true
+}
+
+// callerSite returns the file:line of the frame skip levels above the caller
+// of callerSite, as in runtime.Caller, e.g. callerSite(1) returns the site
+// calling the function which calls callerSite.
+func callerSite(skip int) string {
+	// Skip callerSite itself.
+	_, file, line, ok := runtime.Caller(skip + 1)
+	if !ok {
+		return "unknown"
+	}
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
 // NewWithConfigForTesting is exported for testing only.
 //
 // Note that this function doesn't set the singleton, so that the testing states
 // don't leak.
-func NewWithConfigForTesting(config *bootstrap.Config, watchExpiryTimeout time.Duration) (XDSClient, error) {
-	cl, err := newWithConfig(config, watchExpiryTimeout, defaultIdleAuthorityDeleteTimeout)
+func NewWithConfigForTesting(config *bootstrap.Config, watchExpiryTimeout time.Duration, opts ...Option) (XDSClient, error) {
+	cl, err := newWithConfig(config, watchExpiryTimeout, defaultIdleAuthorityDeleteTimeout, defaultIdleAuthorityDeleteJitter, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -180,7 +238,7 @@ func NewClientWithBootstrapContents(contents []byte) (XDSClient, error) {
 		// Since we don't remove the *Client from the map when it is closed, we
 		// need to recreate the impl if the ref count dropped to zero.
 		if c.refCount > 0 {
-			c.refCount++
+			c.incRefLocked(1)
 			c.mu.Unlock()
 			return c, nil
 		}
@@ -192,7 +250,7 @@ func NewClientWithBootstrapContents(contents []byte) (XDSClient, error) {
 		return nil, fmt.Errorf("xds: error with bootstrap config: %v", err)
 	}
 
-	cImpl, err := newWithConfig(bcfg, defaultWatchExpiryTimeout, defaultIdleAuthorityDeleteTimeout)
+	cImpl, err := newWithConfig(bcfg, defaultWatchExpiryTimeout, defaultIdleAuthorityDeleteTimeout, defaultIdleAuthorityDeleteJitter)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,132 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+)
+
+// debugLogger records the debug logs.
+type debugLogger struct {
+	dubbogoLogger.Logger
+
+	mu   sync.Mutex
+	logs []string
+}
+
+func (l *debugLogger) Debugf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, fmt.Sprintf(format, args...))
+}
+
+// withSingleton replaces the singleton client by one with a single reference,
+// logging to logger at level, and restores it when the test ends.
+func withSingleton(t *testing.T, logger dubbogoLogger.Logger, level LogLevel) {
+	t.Helper()
+	old := singletonClient
+	singletonClient = &clientRefCounted{clientImpl: &clientImpl{logger: newLevelLogger(logger, level)}, refCount: 1}
+	t.Cleanup(func() { singletonClient = old })
+}
+
+// TestRefCountCallerSite verifies that the ref count logs report the site
+// calling New, NewWithConfig and Close.
+func TestRefCountCallerSite(t *testing.T) {
+	logger := &debugLogger{Logger: dubbogoLogger.GetLogger()}
+	withSingleton(t, logger, LogLevelDebug)
+
+	_, file, line, _ := runtime.Caller(0)
+	c1, err := New()
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	c2, err := NewWithConfig(nil)
+	if err != nil {
+		t.Fatalf("NewWithConfig() failed: %v", err)
+	}
+	c2.Close()
+	c1.Close()
+
+	want := []string{
+		fmt.Sprintf("[xds] client ref count incremented to 2 by %s:%d", file, line+1),
+		fmt.Sprintf("[xds] client ref count incremented to 3 by %s:%d", file, line+5),
+		fmt.Sprintf("[xds] client ref count decremented to 2 by %s:%d", file, line+9),
+		fmt.Sprintf("[xds] client ref count decremented to 1 by %s:%d", file, line+10),
+	}
+	if got := strings.Join(logger.logs, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("got logs:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+// TestRefCountLogLevel verifies that nothing is logged when the debug logs are
+// dropped.
+func TestRefCountLogLevel(t *testing.T) {
+	logger := &debugLogger{Logger: dubbogoLogger.GetLogger()}
+	withSingleton(t, logger, LogLevelInfo)
+
+	c, err := New()
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	c.Close()
+	if len(logger.logs) != 0 {
+		t.Fatalf("got debug logs %q, want none", logger.logs)
+	}
+}
+
+// errorLogger records the error logs.
+type errorLogger struct {
+	dubbogoLogger.Logger
+
+	mu   sync.Mutex
+	logs []string
+}
+
+func (l *errorLogger) Errorf(format string, args ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, fmt.Sprintf(format, args...))
+}
+
+// TestCloseMoreThanCreated verifies that closing a client more times than it's
+// created is logged with the site of the extra Close, and ignored.
+func TestCloseMoreThanCreated(t *testing.T) {
+	old := dubbogoLogger.GetLogger()
+	logger := &errorLogger{Logger: old}
+	dubbogoLogger.SetLogger(logger)
+	defer dubbogoLogger.SetLogger(old)
+
+	c := &clientRefCounted{}
+	_, file, line, _ := runtime.Caller(0)
+	c.Close()
+	if got := c.RefCount(); got != 0 {
+		t.Errorf("RefCount() = %d after an extra Close, want 0", got)
+	}
+	want := []string{fmt.Sprintf("[xds] client closed more times than created, ref count 0, Close called by %s:%d", file, line+1)}
+	if got := strings.Join(logger.logs, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("got logs:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
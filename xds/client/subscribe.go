@@ -0,0 +1,114 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"sync"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// SubscribeListener is like WatchListener, but delivers the updates on the
+// returned channel instead of calling a callback. See subscribe for the
+// delivery semantics.
+func (c *clientImpl) SubscribeListener(serviceName string) (<-chan resource.ListenerUpdateErrTuple, func()) {
+	return subscribe(serviceName, c.WatchListener, func(u resource.ListenerUpdate, err error) resource.ListenerUpdateErrTuple {
+		return resource.ListenerUpdateErrTuple{Update: u, Err: err}
+	})
+}
+
+// SubscribeRouteConfig is like WatchRouteConfig, but delivers the updates on
+// the returned channel instead of calling a callback. See subscribe for the
+// delivery semantics.
+func (c *clientImpl) SubscribeRouteConfig(routeName string) (<-chan resource.RouteConfigUpdateErrTuple, func()) {
+	return subscribe(routeName, c.WatchRouteConfig, func(u resource.RouteConfigUpdate, err error) resource.RouteConfigUpdateErrTuple {
+		return resource.RouteConfigUpdateErrTuple{Update: u, Err: err}
+	})
+}
+
+// SubscribeCluster is like WatchCluster, but delivers the updates on the
+// returned channel instead of calling a callback. See subscribe for the
+// delivery semantics.
+func (c *clientImpl) SubscribeCluster(clusterName string) (<-chan resource.ClusterUpdateErrTuple, func()) {
+	return subscribe(clusterName, c.WatchCluster, func(u resource.ClusterUpdate, err error) resource.ClusterUpdateErrTuple {
+		return resource.ClusterUpdateErrTuple{Update: u, Err: err}
+	})
+}
+
+// SubscribeEndpoints is like WatchEndpoints, but delivers the updates on the
+// returned channel instead of calling a callback. See subscribe for the
+// delivery semantics.
+func (c *clientImpl) SubscribeEndpoints(clusterName string) (<-chan resource.EndpointsUpdateErrTuple, func()) {
+	return subscribe(clusterName, c.WatchEndpoints, func(u resource.EndpointsUpdate, err error) resource.EndpointsUpdateErrTuple {
+		return resource.EndpointsUpdateErrTuple{Update: u, Err: err}
+	})
+}
+
+// subscribe starts a watch on name and returns a channel receiving its
+// updates and errors, and the func canceling the watch.
+//
+// The channel has room for a single update. An update replaces the one not
+// yet received, so that the watch callbacks never block the xDS stream and
+// a slow reader only sees the latest state. Once canceled, no update is
+// delivered anymore and the channel is closed.
+func subscribe[U, T any](name string, watch func(string, func(U, error)) func(), tuple func(U, error) T) (<-chan T, func()) {
+	s := &subscription[T]{ch: make(chan T, 1)}
+	cancelWatch := watch(name, func(u U, err error) {
+		s.push(tuple(u, err))
+	})
+	var once sync.Once
+	return s.ch, func() {
+		once.Do(func() {
+			cancelWatch()
+			s.close()
+		})
+	}
+}
+
+// subscription is the channel of a subscribe call.
+type subscription[T any] struct {
+	mu sync.Mutex
+	// closed is set once the subscription is canceled, since the callback
+	// can race with the cancel of the watch.
+	closed bool
+	ch     chan T
+}
+
+func (s *subscription[T]) push(v T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	// Drop the update not received yet, if any. Since pushes are serialized,
+	// the send below can't block.
+	select {
+	case <-s.ch:
+	default:
+	}
+	s.ch <- v
+}
+
+func (s *subscription[T]) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.ch)
+}
@@ -0,0 +1,83 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"errors"
+	"testing"
+)
+
+// fakeWatch is a watch func recording its callback and whether it's canceled.
+type fakeWatch struct {
+	cb       func(string, error)
+	canceled bool
+}
+
+func (w *fakeWatch) watch(name string, cb func(string, error)) func() {
+	w.cb = cb
+	return func() { w.canceled = true }
+}
+
+func newTuple(u string, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return u
+}
+
+func TestSubscribeLatestWins(t *testing.T) {
+	w := &fakeWatch{}
+	ch, cancel := subscribe("foo", w.watch, newTuple)
+	defer cancel()
+
+	w.cb("v1", nil)
+	w.cb("v2", nil)
+	if got := <-ch; got != "v2" {
+		t.Fatalf("received %q, want the latest update %q", got, "v2")
+	}
+	w.cb("", errors.New("v3 failed"))
+	if got := <-ch; got != "v3 failed" {
+		t.Fatalf("received %q, want the error %q", got, "v3 failed")
+	}
+	select {
+	case got := <-ch:
+		t.Fatalf("received %q, want no more updates", got)
+	default:
+	}
+}
+
+func TestSubscribeCancel(t *testing.T) {
+	w := &fakeWatch{}
+	ch, cancel := subscribe("foo", w.watch, newTuple)
+
+	w.cb("v1", nil)
+	cancel()
+	if !w.canceled {
+		t.Fatal("cancel didn't cancel the watch")
+	}
+	// Callbacks racing with the cancel must not be delivered, nor panic on
+	// the closed channel.
+	w.cb("v2", nil)
+	for got := range ch {
+		if got != "v1" {
+			t.Fatalf("received %q after cancel, want only %q", got, "v1")
+		}
+	}
+	// The cancel func can be called more than once.
+	cancel()
+}
@@ -0,0 +1,626 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package fakeserver provides an in-memory ADS management server for tests.
+//
+// The server listens on a bufconn, and a real xds client connects to it with
+// the config returned by ServerConfig or BootstrapConfig. Tests push the
+// resources with Update, and wait for the client to ACK or NACK them with
+// WaitForAck.
+//
+// Both variants of the ADS protocol are implemented. On state of the world
+// streams, every response of a type contains all the resources of the type,
+// whatever names the client requested. On delta streams, the responses only
+// contain the subscribed resources which changed, and the names of the
+// subscribed resources which don't exist.
+//
+// The server also implements LRS: SetLoadReporting asks the clients for their
+// loads, which are returned by LoadReports.
+package fakeserver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+import (
+	v3corepb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	v3discoverypb "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	lrspb "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v3"
+
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/bootstrap"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource/version"
+)
+
+const bufSize = 1 << 20
+
+// serverCount makes the server URIs unique, so that the clients don't share
+// authorities between servers.
+var serverCount int32
+
+// Ack is an ACK or a NACK sent by a client.
+type Ack struct {
+	// TypeURL is the type of the response acknowledged.
+	TypeURL string
+	// Version is the version of the response acknowledged, set by Update.
+	Version string
+	// ClientVersion is the version sent by the client. It's Version for an
+	// ACK, and the last version accepted for a NACK. It's always empty on
+	// delta streams, whose requests don't carry a version.
+	ClientVersion string
+	// Nonce is the nonce of the response acknowledged.
+	Nonce string
+	// ErrorDetail is the reason of the rejection of a NACK, nil for an ACK.
+	ErrorDetail *statuspb.Status
+}
+
+// Nack returns whether the response was rejected.
+func (a Ack) Nack() bool {
+	return a.ErrorDetail != nil
+}
+
+// Request is a request received from a client.
+type Request struct {
+	// TypeURL is the type of the resources requested.
+	TypeURL string
+	// ResourceNames are the names requested. On delta streams, they are the
+	// names subscribed to by the request.
+	ResourceNames []string
+	// Node is the node sent with the request.
+	Node *v3corepb.Node
+}
+
+// typeResources are the resources of one type, as set by the last Update.
+type typeResources struct {
+	version   string
+	resources []*anypb.Any
+	// names are the names of the resources, in the same order. versions are
+	// the versions of the Updates which last changed each of them, sent on
+	// delta streams.
+	names    []string
+	versions map[string]string
+}
+
+// Server is an in-memory ADS management server.
+type Server struct {
+	v3discoverypb.UnimplementedAggregatedDiscoveryServiceServer
+	lrspb.UnimplementedLoadReportingServiceServer
+
+	uri string
+	lis *bufconn.Listener
+	gs  *grpc.Server
+
+	mu        sync.Mutex
+	version   int
+	nonce     int
+	resources map[string]typeResources
+	// nonces maps the nonce of the responses sent to their version.
+	nonces       map[string]string
+	streams      map[*stream]bool
+	deltaStreams map[*deltaStream]bool
+	acks         []Ack
+	requests     []Request
+	// ackCh is closed, and replaced, when an ACK or NACK is received.
+	ackCh chan struct{}
+	lrs   lrsState
+}
+
+// New starts a new server. Stop must be called to release it.
+func New() *Server {
+	s := &Server{
+		uri:          fmt.Sprintf("passthrough:///fakeserver-%d", atomic.AddInt32(&serverCount, 1)),
+		lis:          bufconn.Listen(bufSize),
+		gs:           grpc.NewServer(),
+		resources:    make(map[string]typeResources),
+		nonces:       make(map[string]string),
+		streams:      make(map[*stream]bool),
+		deltaStreams: make(map[*deltaStream]bool),
+		ackCh:        make(chan struct{}),
+		lrs: lrsState{
+			streams: make(map[*lrsStream]bool),
+			loadCh:  make(chan struct{}),
+		},
+	}
+	v3discoverypb.RegisterAggregatedDiscoveryServiceServer(s.gs, s)
+	lrspb.RegisterLoadReportingServiceServer(s.gs, s)
+	go func() {
+		_ = s.gs.Serve(s.lis)
+	}()
+	return s
+}
+
+// Stop stops the server, and closes all the streams.
+func (s *Server) Stop() {
+	s.gs.Stop()
+}
+
+// ServerConfig returns the config to connect to the server, with the xDS v3
+// transport.
+func (s *Server) ServerConfig() *bootstrap.ServerConfig {
+	return &bootstrap.ServerConfig{
+		ServerURI:    s.uri,
+		Creds:        grpc.WithTransportCredentials(insecure.NewCredentials()),
+		CredsType:    "insecure",
+		TransportAPI: version.TransportV3,
+		NodeProto:    &v3corepb.Node{Id: "fakeserver-client"},
+		DialOptions: []grpc.DialOption{
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return s.Dial(ctx)
+			}),
+		},
+	}
+}
+
+// Dial connects to the server. It's the dialer of the config returned by
+// ServerConfig, for the tests which need to wrap it, e.g. to make the server
+// unreachable for a while.
+func (s *Server) Dial(ctx context.Context) (net.Conn, error) {
+	return s.lis.DialContext(ctx)
+}
+
+// BootstrapConfig returns a bootstrap config with the server as the only
+// management server.
+func (s *Server) BootstrapConfig() *bootstrap.Config {
+	return &bootstrap.Config{
+		XDSServer: s.ServerConfig(),
+		ClientDefaultListenerResourceNameTemplate: "%s",
+	}
+}
+
+// Update replaces the resources of type typeURL, e.g. version.V3ListenerURL,
+// and pushes them to the clients which requested the type. An Update without
+// resources removes all the resources of the type.
+//
+// It returns the version of the new resources, to wait for the clients to
+// acknowledge it with WaitForAck.
+func (s *Server) Update(typeURL string, resources ...proto.Message) (string, error) {
+	anys := make([]*anypb.Any, 0, len(resources))
+	names := make([]string, 0, len(resources))
+	for _, r := range resources {
+		a, err := anypb.New(r)
+		if err != nil {
+			return "", fmt.Errorf("fakeserver: marshaling %T: %v", r, err)
+		}
+		if a.GetTypeUrl() != typeURL {
+			return "", fmt.Errorf("fakeserver: resource of type %s in update of type %s", a.GetTypeUrl(), typeURL)
+		}
+		anys = append(anys, a)
+		names = append(names, resourceName(r))
+	}
+
+	type pendingResponse struct {
+		st   *stream
+		resp *v3discoverypb.DiscoveryResponse
+	}
+	type pendingDeltaResponse struct {
+		st   *deltaStream
+		resp *v3discoverypb.DeltaDiscoveryResponse
+	}
+	var (
+		pending      []pendingResponse
+		pendingDelta []pendingDeltaResponse
+	)
+	s.mu.Lock()
+	s.version++
+	ver := strconv.Itoa(s.version)
+	s.resources[typeURL] = newTypeResources(ver, anys, names, s.resources[typeURL])
+	for st := range s.streams {
+		if len(st.names[typeURL]) == 0 {
+			continue
+		}
+		pending = append(pending, pendingResponse{st: st, resp: s.responseLocked(typeURL)})
+	}
+	for st := range s.deltaStreams {
+		if resp := s.deltaResponseLocked(st, typeURL); resp != nil {
+			pendingDelta = append(pendingDelta, pendingDeltaResponse{st: st, resp: resp})
+		}
+	}
+	s.mu.Unlock()
+
+	// A failed send means the stream is closing, the client gets the
+	// resources on its next stream.
+	for _, p := range pending {
+		_ = p.st.send(p.resp)
+	}
+	for _, p := range pendingDelta {
+		_ = p.st.send(p.resp)
+	}
+	return ver, nil
+}
+
+// newTypeResources returns the resources of an Update with version ver. The
+// resources unchanged since old keep their version.
+func newTypeResources(ver string, resources []*anypb.Any, names []string, old typeResources) typeResources {
+	oldResources := make(map[string]*anypb.Any, len(old.names))
+	for i, name := range old.names {
+		oldResources[name] = old.resources[i]
+	}
+	versions := make(map[string]string, len(names))
+	for i, name := range names {
+		if r, ok := oldResources[name]; ok && proto.Equal(r, resources[i]) {
+			versions[name] = old.versions[name]
+			continue
+		}
+		versions[name] = ver
+	}
+	return typeResources{version: ver, resources: resources, names: names, versions: versions}
+}
+
+// resourceName returns the name of the xDS resource r.
+func resourceName(r proto.Message) string {
+	switch r := r.(type) {
+	case interface{ GetName() string }:
+		// Listener, RouteConfiguration and Cluster.
+		return r.GetName()
+	case interface{ GetClusterName() string }:
+		// ClusterLoadAssignment.
+		return r.GetClusterName()
+	}
+	return ""
+}
+
+// Acks returns the ACKs and NACKs received so far, in order.
+func (s *Server) Acks() []Ack {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Ack(nil), s.acks...)
+}
+
+// Requests returns the requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// WaitForAck blocks until a client ACKs or NACKs the response of type typeURL
+// with the version returned by Update, or ctx is done.
+func (s *Server) WaitForAck(ctx context.Context, typeURL, version string) (Ack, error) {
+	for {
+		s.mu.Lock()
+		for _, a := range s.acks {
+			if a.TypeURL == typeURL && a.Version == version {
+				s.mu.Unlock()
+				return a, nil
+			}
+		}
+		ch := s.ackCh
+		s.mu.Unlock()
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return Ack{}, fmt.Errorf("fakeserver: waiting for the ack of %s version %s: %w", typeURL, version, ctx.Err())
+		}
+	}
+}
+
+// DropStreams closes all the open streams with an Unavailable error, to test
+// the reconnects of the clients. The server keeps accepting new streams.
+func (s *Server) DropStreams() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for st := range s.streams {
+		st.dropOnce.Do(func() { close(st.drop) })
+	}
+	for st := range s.deltaStreams {
+		st.dropOnce.Do(func() { close(st.drop) })
+	}
+}
+
+// StreamAggregatedResources implements the ADS service.
+func (s *Server) StreamAggregatedResources(ads v3discoverypb.AggregatedDiscoveryService_StreamAggregatedResourcesServer) error {
+	st := &stream{
+		ads:   ads,
+		names: make(map[string][]string),
+		drop:  make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.streams[st] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.streams, st)
+		s.mu.Unlock()
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.recv(st)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-st.drop:
+		return status.Error(codes.Unavailable, "fakeserver: stream dropped")
+	}
+}
+
+// recv handles the requests of st until the stream fails.
+func (s *Server) recv(st *stream) error {
+	for {
+		req, err := st.ads.Recv()
+		if err != nil {
+			return err
+		}
+		if resp := s.handleRequest(st, req); resp != nil {
+			if err := st.send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleRequest records the ACK or NACK in req, and returns the response to
+// send, nil if none. The resources are sent when the client requests new names
+// of a type, not when it only acknowledges a response.
+func (s *Server) handleRequest(st *stream, req *v3discoverypb.DiscoveryRequest) *v3discoverypb.DiscoveryResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	typeURL := req.GetTypeUrl()
+	s.requests = append(s.requests, Request{TypeURL: typeURL, ResourceNames: req.GetResourceNames(), Node: req.GetNode()})
+	if nonce := req.GetResponseNonce(); nonce != "" {
+		s.recordAckLocked(Ack{
+			TypeURL:       typeURL,
+			Version:       s.nonces[nonce],
+			ClientVersion: req.GetVersionInfo(),
+			Nonce:         nonce,
+			ErrorDetail:   req.GetErrorDetail(),
+		})
+	}
+
+	names := req.GetResourceNames()
+	old, subscribed := st.names[typeURL]
+	st.names[typeURL] = names
+	if req.GetErrorDetail() != nil || len(names) == 0 || (subscribed && reflect.DeepEqual(old, names)) {
+		return nil
+	}
+	if _, ok := s.resources[typeURL]; !ok {
+		// Nothing to send yet, the resources are pushed by the first Update
+		// of the type.
+		return nil
+	}
+	return s.responseLocked(typeURL)
+}
+
+// recordAckLocked records an ACK or NACK, and wakes up WaitForAck.
+//
+// Caller must hold s.mu.
+func (s *Server) recordAckLocked(a Ack) {
+	s.acks = append(s.acks, a)
+	close(s.ackCh)
+	s.ackCh = make(chan struct{})
+}
+
+// responseLocked returns the response with all the resources of typeURL.
+//
+// Caller must hold s.mu.
+func (s *Server) responseLocked(typeURL string) *v3discoverypb.DiscoveryResponse {
+	tr := s.resources[typeURL]
+	s.nonce++
+	nonce := strconv.Itoa(s.nonce)
+	s.nonces[nonce] = tr.version
+	return &v3discoverypb.DiscoveryResponse{
+		VersionInfo: tr.version,
+		Resources:   tr.resources,
+		TypeUrl:     typeURL,
+		Nonce:       nonce,
+	}
+}
+
+// stream is an ADS stream from a client.
+type stream struct {
+	ads v3discoverypb.AggregatedDiscoveryService_StreamAggregatedResourcesServer
+
+	// sendMu serializes the responses sent by Update and by recv.
+	sendMu sync.Mutex
+
+	// names are the resource names last requested per type, protected by the
+	// server mu.
+	names map[string][]string
+
+	drop     chan struct{}
+	dropOnce sync.Once
+}
+
+func (st *stream) send(resp *v3discoverypb.DiscoveryResponse) error {
+	st.sendMu.Lock()
+	defer st.sendMu.Unlock()
+	return st.ads.Send(resp)
+}
+
+// DeltaAggregatedResources implements the delta variant of the ADS service.
+func (s *Server) DeltaAggregatedResources(ads v3discoverypb.AggregatedDiscoveryService_DeltaAggregatedResourcesServer) error {
+	st := &deltaStream{
+		ads:        ads,
+		subscribed: make(map[string]map[string]bool),
+		known:      make(map[string]map[string]string),
+		drop:       make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.deltaStreams[st] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.deltaStreams, st)
+		s.mu.Unlock()
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.recvDelta(st)
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-st.drop:
+		return status.Error(codes.Unavailable, "fakeserver: stream dropped")
+	}
+}
+
+// recvDelta handles the requests of the delta stream st until it fails.
+func (s *Server) recvDelta(st *deltaStream) error {
+	for {
+		req, err := st.ads.Recv()
+		if err != nil {
+			return err
+		}
+		if resp := s.handleDeltaRequest(st, req); resp != nil {
+			if err := st.send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleDeltaRequest records the ACK or NACK in req, and the changes of the
+// subscription. It returns the response to send, nil if none. Like on state
+// of the world streams, the resources are only sent when the client
+// subscribes to new names.
+func (s *Server) handleDeltaRequest(st *deltaStream, req *v3discoverypb.DeltaDiscoveryRequest) *v3discoverypb.DeltaDiscoveryResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	typeURL := req.GetTypeUrl()
+	s.requests = append(s.requests, Request{TypeURL: typeURL, ResourceNames: req.GetResourceNamesSubscribe(), Node: req.GetNode()})
+	if nonce := req.GetResponseNonce(); nonce != "" {
+		s.recordAckLocked(Ack{
+			TypeURL:     typeURL,
+			Version:     s.nonces[nonce],
+			Nonce:       nonce,
+			ErrorDetail: req.GetErrorDetail(),
+		})
+	}
+
+	subscribed, known := st.subscribed[typeURL], st.known[typeURL]
+	if subscribed == nil {
+		subscribed, known = make(map[string]bool), make(map[string]string)
+		st.subscribed[typeURL], st.known[typeURL] = subscribed, known
+	}
+	for name, ver := range req.GetInitialResourceVersions() {
+		known[name] = ver
+	}
+	for _, name := range req.GetResourceNamesSubscribe() {
+		subscribed[name] = true
+	}
+	for _, name := range req.GetResourceNamesUnsubscribe() {
+		delete(subscribed, name)
+		delete(known, name)
+	}
+	if req.GetErrorDetail() != nil || len(req.GetResourceNamesSubscribe()) == 0 {
+		return nil
+	}
+	return s.deltaResponseLocked(st, typeURL)
+}
+
+// deltaResponseLocked returns the response with the resources of typeURL
+// subscribed by st which changed since they were last sent, and the names of
+// the subscribed ones which don't exist. It returns nil if there's no change,
+// or if no Update of the type was made yet.
+//
+// Caller must hold s.mu.
+func (s *Server) deltaResponseLocked(st *deltaStream, typeURL string) *v3discoverypb.DeltaDiscoveryResponse {
+	tr, ok := s.resources[typeURL]
+	subscribed, known := st.subscribed[typeURL], st.known[typeURL]
+	if !ok || len(subscribed) == 0 {
+		return nil
+	}
+
+	resp := &v3discoverypb.DeltaDiscoveryResponse{
+		SystemVersionInfo: tr.version,
+		TypeUrl:           typeURL,
+	}
+	for i, name := range tr.names {
+		if !subscribed[name] {
+			continue
+		}
+		ver := tr.versions[name]
+		if known[name] == ver {
+			continue
+		}
+		known[name] = ver
+		resp.Resources = append(resp.Resources, &v3discoverypb.Resource{
+			Name:     name,
+			Version:  ver,
+			Resource: tr.resources[i],
+		})
+	}
+	for name := range subscribed {
+		if _, ok := tr.versions[name]; ok {
+			continue
+		}
+		// An empty known version means that the client was already told
+		// that the resource doesn't exist.
+		if ver, ok := known[name]; ok && ver == "" {
+			continue
+		}
+		known[name] = ""
+		resp.RemovedResources = append(resp.RemovedResources, name)
+	}
+	if len(resp.Resources) == 0 && len(resp.RemovedResources) == 0 {
+		return nil
+	}
+	sort.Strings(resp.RemovedResources)
+
+	s.nonce++
+	resp.Nonce = strconv.Itoa(s.nonce)
+	s.nonces[resp.Nonce] = tr.version
+	return resp
+}
+
+// deltaStream is a delta ADS stream from a client.
+type deltaStream struct {
+	ads v3discoverypb.AggregatedDiscoveryService_DeltaAggregatedResourcesServer
+
+	// sendMu serializes the responses sent by Update and by recvDelta.
+	sendMu sync.Mutex
+
+	// subscribed are the resource names subscribed per type, and known the
+	// versions of the resources the client has per type and name, both
+	// protected by the server mu.
+	subscribed map[string]map[string]bool
+	known      map[string]map[string]string
+
+	drop     chan struct{}
+	dropOnce sync.Once
+}
+
+func (st *deltaStream) send(resp *v3discoverypb.DeltaDiscoveryResponse) error {
+	st.sendMu.Lock()
+	defer st.sendMu.Unlock()
+	return st.ads.Send(resp)
+}
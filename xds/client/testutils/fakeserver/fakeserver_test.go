@@ -0,0 +1,935 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fakeserver_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+import (
+	dubbogoLogger "github.com/dubbogo/gost/log/logger"
+
+	v3clusterpb "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	v3corepb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	v3endpointpb "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	v3listenerpb "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	v3routepb "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	v3routerpb "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	v3httppb "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/controller"
+	_ "dubbo.apache.org/dubbo-go/v3/xds/client/controller/version/v3"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource/version"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/testutils/fakeserver"
+	_ "dubbo.apache.org/dubbo-go/v3/xds/httpfilter/router"
+)
+
+const (
+	defaultTestTimeout      = 10 * time.Second
+	defaultTestShortTimeout = 100 * time.Millisecond
+
+	ldsName = "lds.example.com"
+	rdsName = "rds.example.com"
+	cdsName = "cds.example.com"
+	edsName = "eds.example.com"
+)
+
+var adsSource = &v3corepb.ConfigSource{
+	ConfigSourceSpecifier: &v3corepb.ConfigSource_Ads{Ads: &v3corepb.AggregatedConfigSource{}},
+}
+
+func mustAny(t *testing.T, m proto.Message) *anypb.Any {
+	t.Helper()
+	a, err := anypb.New(m)
+	if err != nil {
+		t.Fatalf("anypb.New(%T) failed: %v", m, err)
+	}
+	return a
+}
+
+func listener(t *testing.T, routeName string) *v3listenerpb.Listener {
+	t.Helper()
+	hcm := &v3httppb.HttpConnectionManager{
+		RouteSpecifier: &v3httppb.HttpConnectionManager_Rds{Rds: &v3httppb.Rds{
+			ConfigSource:    adsSource,
+			RouteConfigName: routeName,
+		}},
+		HttpFilters: []*v3httppb.HttpFilter{{
+			Name:       "router",
+			ConfigType: &v3httppb.HttpFilter_TypedConfig{TypedConfig: mustAny(t, &v3routerpb.Router{})},
+		}},
+	}
+	return &v3listenerpb.Listener{
+		Name:        ldsName,
+		ApiListener: &v3listenerpb.ApiListener{ApiListener: mustAny(t, hcm)},
+	}
+}
+
+func routeConfig() *v3routepb.RouteConfiguration {
+	return &v3routepb.RouteConfiguration{
+		Name: rdsName,
+		VirtualHosts: []*v3routepb.VirtualHost{{
+			Domains: []string{ldsName},
+			Routes: []*v3routepb.Route{{
+				Match: &v3routepb.RouteMatch{PathSpecifier: &v3routepb.RouteMatch_Prefix{Prefix: "/"}},
+				Action: &v3routepb.Route_Route{Route: &v3routepb.RouteAction{
+					ClusterSpecifier: &v3routepb.RouteAction_Cluster{Cluster: cdsName},
+				}},
+			}},
+		}},
+	}
+}
+
+func cluster() *v3clusterpb.Cluster {
+	return clusterAt(cdsName, edsName)
+}
+
+// clusterAt returns the EDS cluster name, whose endpoints are those of
+// serviceName.
+func clusterAt(name, serviceName string) *v3clusterpb.Cluster {
+	return &v3clusterpb.Cluster{
+		Name:                 name,
+		ClusterDiscoveryType: &v3clusterpb.Cluster_Type{Type: v3clusterpb.Cluster_EDS},
+		EdsClusterConfig: &v3clusterpb.Cluster_EdsClusterConfig{
+			EdsConfig:   adsSource,
+			ServiceName: serviceName,
+		},
+		LbPolicy: v3clusterpb.Cluster_ROUND_ROBIN,
+	}
+}
+
+func endpoints() *v3endpointpb.ClusterLoadAssignment {
+	return endpointsAt(edsName, 20000)
+}
+
+// endpointsAt returns the endpoints of the cluster name, with one endpoint
+// listening on port.
+func endpointsAt(name string, port uint32) *v3endpointpb.ClusterLoadAssignment {
+	return &v3endpointpb.ClusterLoadAssignment{
+		ClusterName: name,
+		Endpoints: []*v3endpointpb.LocalityLbEndpoints{{
+			Locality: &v3corepb.Locality{Region: "region"},
+			LbEndpoints: []*v3endpointpb.LbEndpoint{{
+				HostIdentifier: &v3endpointpb.LbEndpoint_Endpoint{Endpoint: &v3endpointpb.Endpoint{
+					Address: &v3corepb.Address{Address: &v3corepb.Address_SocketAddress{SocketAddress: &v3corepb.SocketAddress{
+						Address:       "127.0.0.1",
+						PortSpecifier: &v3corepb.SocketAddress_PortValue{PortValue: port},
+					}}},
+				}},
+			}},
+		}},
+	}
+}
+
+// addresses returns the addresses of the endpoints of eu.
+func addresses(eu resource.EndpointsUpdate) []string {
+	var addrs []string
+	for _, l := range eu.Localities {
+		for _, e := range l.Endpoints {
+			addrs = append(addrs, e.Address)
+		}
+	}
+	return addrs
+}
+
+type result[T any] struct {
+	update T
+	err    error
+}
+
+// wait returns the first result received on ch.
+func wait[T any](ctx context.Context, t *testing.T, ch chan result[T]) T {
+	t.Helper()
+	select {
+	case r := <-ch:
+		if r.err != nil {
+			t.Fatalf("watch failed: %v", r.err)
+		}
+		return r.update
+	case <-ctx.Done():
+		t.Fatalf("timeout waiting for an update: %v", ctx.Err())
+	}
+	var zero T
+	return zero
+}
+
+func watchCallback[T any](ch chan result[T]) func(T, error) {
+	return func(u T, err error) {
+		select {
+		case ch <- result[T]{update: u, err: err}:
+		default:
+		}
+	}
+}
+
+func TestFullFlow(t *testing.T) {
+	s := fakeserver.New()
+	defer s.Stop()
+
+	c, err := client.NewWithConfigForTesting(s.BootstrapConfig(), defaultTestTimeout)
+	if err != nil {
+		t.Fatalf("failed to create the xds client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+
+	versions := make(map[string]string)
+	for _, u := range []struct {
+		typeURL  string
+		resource proto.Message
+	}{
+		{version.V3ListenerURL, listener(t, rdsName)},
+		{version.V3RouteConfigURL, routeConfig()},
+		{version.V3ClusterURL, cluster()},
+		{version.V3EndpointsURL, endpoints()},
+	} {
+		v, err := s.Update(u.typeURL, u.resource)
+		if err != nil {
+			t.Fatalf("Update(%s) failed: %v", u.typeURL, err)
+		}
+		versions[u.typeURL] = v
+	}
+
+	ldsCh := make(chan result[resource.ListenerUpdate], 1)
+	defer c.WatchListener(ldsName, watchCallback(ldsCh))()
+	lu := wait(ctx, t, ldsCh)
+	if lu.RouteConfigName != rdsName {
+		t.Fatalf("got route config name %q, want %q", lu.RouteConfigName, rdsName)
+	}
+
+	rdsCh := make(chan result[resource.RouteConfigUpdate], 1)
+	defer c.WatchRouteConfig(lu.RouteConfigName, watchCallback(rdsCh))()
+	ru := wait(ctx, t, rdsCh)
+	if len(ru.VirtualHosts) != 1 || len(ru.VirtualHosts[0].Routes) != 1 {
+		t.Fatalf("got virtual hosts %+v, want one with one route", ru.VirtualHosts)
+	}
+	var clusterName string
+	for name := range ru.VirtualHosts[0].Routes[0].WeightedClusters {
+		clusterName = name
+	}
+	if clusterName != cdsName {
+		t.Fatalf("got cluster %q, want %q", clusterName, cdsName)
+	}
+
+	cdsCh := make(chan result[resource.ClusterUpdate], 1)
+	defer c.WatchCluster(clusterName, watchCallback(cdsCh))()
+	cu := wait(ctx, t, cdsCh)
+	if cu.EDSServiceName != edsName {
+		t.Fatalf("got EDS service name %q, want %q", cu.EDSServiceName, edsName)
+	}
+
+	edsCh := make(chan result[resource.EndpointsUpdate], 1)
+	defer c.WatchEndpoints(cu.EDSServiceName, watchCallback(edsCh))()
+	eu := wait(ctx, t, edsCh)
+	if diff := cmp.Diff([]string{"127.0.0.1:20000"}, addresses(eu)); diff != "" {
+		t.Fatalf("unexpected endpoints (-want +got):\n%s", diff)
+	}
+
+	for typeURL, v := range versions {
+		a, err := s.WaitForAck(ctx, typeURL, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Nack() {
+			t.Fatalf("%s version %s NACKed: %v", typeURL, v, a.ErrorDetail.GetMessage())
+		}
+	}
+}
+
+func TestNack(t *testing.T) {
+	s := fakeserver.New()
+	defer s.Stop()
+
+	c, err := client.NewWithConfigForTesting(s.BootstrapConfig(), defaultTestTimeout)
+	if err != nil {
+		t.Fatalf("failed to create the xds client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+
+	good, err := s.Update(version.V3ListenerURL, listener(t, rdsName))
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	ldsCh := make(chan result[resource.ListenerUpdate], 1)
+	defer c.WatchListener(ldsName, watchCallback(ldsCh))()
+	wait(ctx, t, ldsCh)
+
+	// An empty route config name is invalid, the client must reject it and
+	// keep the previous version.
+	bad, err := s.Update(version.V3ListenerURL, listener(t, ""))
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	a, err := s.WaitForAck(ctx, version.V3ListenerURL, bad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Nack() {
+		t.Fatalf("version %s ACKed, want NACK", bad)
+	}
+	if a.ClientVersion != good {
+		t.Fatalf("NACK with version %q, want the last accepted version %q", a.ClientVersion, good)
+	}
+}
+
+// TestAckPolicy verifies that a response carrying an invalid resource is
+// NACKed under the strict ACK policy and ACKed under the permissive one, and
+// that in both cases the watcher keeps the previous version.
+func TestAckPolicy(t *testing.T) {
+	for _, test := range []struct {
+		policy   controller.AckPolicy
+		wantNack bool
+	}{
+		{policy: controller.AckPolicyStrict, wantNack: true},
+		{policy: controller.AckPolicyPermissive, wantNack: false},
+	} {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			s := fakeserver.New()
+			defer s.Stop()
+
+			c, err := client.NewWithConfigForTesting(s.BootstrapConfig(), defaultTestTimeout, client.WithAckPolicy(test.policy))
+			if err != nil {
+				t.Fatalf("failed to create the xds client: %v", err)
+			}
+			defer c.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+			defer cancel()
+
+			good, err := s.Update(version.V3ListenerURL, listener(t, rdsName))
+			if err != nil {
+				t.Fatalf("Update() failed: %v", err)
+			}
+			ldsCh := make(chan result[resource.ListenerUpdate], 1)
+			defer c.WatchListener(ldsName, watchCallback(ldsCh))()
+			wait(ctx, t, ldsCh)
+
+			bad, err := s.Update(version.V3ListenerURL, listener(t, ""))
+			if err != nil {
+				t.Fatalf("Update() failed: %v", err)
+			}
+			a, err := s.WaitForAck(ctx, version.V3ListenerURL, bad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Nack() != test.wantNack {
+				t.Fatalf("got NACK %v for version %s, want %v", a.Nack(), bad, test.wantNack)
+			}
+			wantVersion := bad
+			if test.wantNack {
+				wantVersion = good
+			}
+			if a.ClientVersion != wantVersion {
+				t.Fatalf("got client version %q, want %q", a.ClientVersion, wantVersion)
+			}
+
+			// The invalid listener is never applied.
+			sCtx, sCancel := context.WithTimeout(ctx, defaultTestShortTimeout)
+			defer sCancel()
+			select {
+			case r := <-ldsCh:
+				if r.err == nil {
+					t.Fatalf("got listener update %+v after the invalid version, want none", r.update)
+				}
+			case <-sCtx.Done():
+			}
+		})
+	}
+}
+
+// TestDeltaADS verifies that with the delta_ads server feature, the client
+// receives the added, changed and removed resources on a delta stream, and
+// that the changes of the resources it doesn't watch aren't sent to it.
+func TestDeltaADS(t *testing.T) {
+	s := fakeserver.New()
+	defer s.Stop()
+
+	config := s.BootstrapConfig()
+	config.XDSServer.DeltaADS = true
+	c, err := client.NewWithConfigForTesting(config, defaultTestTimeout)
+	if err != nil {
+		t.Fatalf("failed to create the xds client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+
+	// The watched resource is added.
+	if _, err := s.Update(version.V3EndpointsURL, endpoints()); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	edsCh := make(chan result[resource.EndpointsUpdate], 1)
+	defer c.WatchEndpoints(edsName, watchCallback(edsCh))()
+	if diff := cmp.Diff([]string{"127.0.0.1:20000"}, addresses(wait(ctx, t, edsCh))); diff != "" {
+		t.Fatalf("unexpected endpoints (-want +got):\n%s", diff)
+	}
+
+	// The watched resource changes, and another one is added.
+	const otherName = "other.example.com"
+	v, err := s.Update(version.V3EndpointsURL, endpointsAt(edsName, 20001), endpointsAt(otherName, 30000))
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{"127.0.0.1:20001"}, addresses(wait(ctx, t, edsCh))); diff != "" {
+		t.Fatalf("unexpected endpoints (-want +got):\n%s", diff)
+	}
+	a, err := s.WaitForAck(ctx, version.V3EndpointsURL, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Nack() {
+		t.Fatalf("version %s NACKed: %v", v, a.ErrorDetail.GetMessage())
+	}
+
+	// Only the resource the client doesn't watch changes, so nothing is sent.
+	v, err = s.Update(version.V3EndpointsURL, endpointsAt(edsName, 20001), endpointsAt(otherName, 30001))
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	sCtx, sCancel := context.WithTimeout(ctx, defaultTestShortTimeout)
+	defer sCancel()
+	if a, err := s.WaitForAck(sCtx, version.V3EndpointsURL, v); err == nil {
+		t.Fatalf("got %+v for an update of an unwatched resource, want nothing sent", a)
+	}
+
+	// The watched resource is removed.
+	if _, err := s.Update(version.V3EndpointsURL, endpointsAt(otherName, 30001)); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	select {
+	case r := <-edsCh:
+		if resource.ErrType(r.err) != resource.ErrorTypeResourceNotFound {
+			t.Fatalf("got update %+v and error %v, want a resource not found error", r.update, r.err)
+		}
+	case <-ctx.Done():
+		t.Fatalf("timeout waiting for the removal: %v", ctx.Err())
+	}
+}
+
+// TestDeltaADSClusters verifies that the clusters added, changed and removed
+// on a delta stream reach the watcher, and that with the
+// ignore_resource_deletion server feature the removed cluster is kept.
+func TestDeltaADSClusters(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		ignore bool
+	}{
+		{name: "delete"},
+		{name: "ignore_resource_deletion", ignore: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := fakeserver.New()
+			defer s.Stop()
+
+			config := s.BootstrapConfig()
+			config.XDSServer.DeltaADS = true
+			config.XDSServer.IgnoreResourceDeletion = test.ignore
+			c, err := client.NewWithConfigForTesting(config, defaultTestTimeout)
+			if err != nil {
+				t.Fatalf("failed to create the xds client: %v", err)
+			}
+			defer c.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+			defer cancel()
+
+			// The watched cluster is added.
+			const otherName = "other.example.com"
+			if _, err := s.Update(version.V3ClusterURL, cluster(), clusterAt(otherName, otherName)); err != nil {
+				t.Fatalf("Update() failed: %v", err)
+			}
+			cdsCh := make(chan result[resource.ClusterUpdate], 2)
+			defer c.WatchCluster(cdsName, watchCallback(cdsCh))()
+			if cu := wait(ctx, t, cdsCh); cu.EDSServiceName != edsName {
+				t.Fatalf("got EDS service name %q, want %q", cu.EDSServiceName, edsName)
+			}
+
+			// The watched cluster changes.
+			v, err := s.Update(version.V3ClusterURL, clusterAt(cdsName, otherName), clusterAt(otherName, otherName))
+			if err != nil {
+				t.Fatalf("Update() failed: %v", err)
+			}
+			if cu := wait(ctx, t, cdsCh); cu.EDSServiceName != otherName {
+				t.Fatalf("got EDS service name %q, want %q", cu.EDSServiceName, otherName)
+			}
+			if a, err := s.WaitForAck(ctx, version.V3ClusterURL, v); err != nil || a.Nack() {
+				t.Fatalf("got %+v, %v for version %s, want an ACK", a, err, v)
+			}
+
+			// The watched cluster is removed.
+			v, err = s.Update(version.V3ClusterURL, clusterAt(otherName, otherName))
+			if err != nil {
+				t.Fatalf("Update() failed: %v", err)
+			}
+			if a, err := s.WaitForAck(ctx, version.V3ClusterURL, v); err != nil || a.Nack() {
+				t.Fatalf("got %+v, %v for version %s, want an ACK", a, err, v)
+			}
+			if test.ignore {
+				sCtx, sCancel := context.WithTimeout(ctx, defaultTestShortTimeout)
+				defer sCancel()
+				select {
+				case r := <-cdsCh:
+					t.Fatalf("got update %+v and error %v for the removed cluster, want it kept", r.update, r.err)
+				case <-sCtx.Done():
+				}
+				return
+			}
+			// The watcher gets the delete event, then the resource not found
+			// error.
+			if cu := wait(ctx, t, cdsCh); cu.ClusterName != "-"+cdsName {
+				t.Fatalf("got cluster %q, want the delete event of %q", cu.ClusterName, cdsName)
+			}
+			select {
+			case r := <-cdsCh:
+				if resource.ErrType(r.err) != resource.ErrorTypeResourceNotFound {
+					t.Fatalf("got update %+v and error %v, want a resource not found error", r.update, r.err)
+				}
+			case <-ctx.Done():
+				t.Fatalf("timeout waiting for the removal: %v", ctx.Err())
+			}
+		})
+	}
+}
+
+// TestWaitForReady verifies that WaitForReady blocks while nothing is watched,
+// and returns once the response of the first watch is received.
+func TestWaitForReady(t *testing.T) {
+	s := fakeserver.New()
+	defer s.Stop()
+
+	c, err := client.NewWithConfigForTesting(s.BootstrapConfig(), defaultTestTimeout)
+	if err != nil {
+		t.Fatalf("failed to create the xds client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+
+	sCtx, sCancel := context.WithTimeout(ctx, defaultTestShortTimeout)
+	defer sCancel()
+	if err := c.WaitForReady(sCtx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitForReady() = %v without a watch, want %v", err, context.DeadlineExceeded)
+	}
+
+	if _, err := s.Update(version.V3ClusterURL, cluster()); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- c.WaitForReady(ctx) }()
+	cancelWatch := c.WatchCluster(cdsName, func(resource.ClusterUpdate, error) {})
+	if err := <-errCh; err != nil {
+		t.Fatalf("WaitForReady() = %v once a watch is started, want nil", err)
+	}
+	// The client stays ready once the watch is canceled.
+	cancelWatch()
+	if err := c.WaitForReady(ctx); err != nil {
+		t.Fatalf("WaitForReady() = %v after the watch was canceled, want nil", err)
+	}
+}
+
+// metadataVersion returns the "version" field of the node metadata of r.
+func metadataVersion(r fakeserver.Request) string {
+	return r.Node.GetMetadata().GetFields()["version"].GetStringValue()
+}
+
+// TestFlushMetadata verifies that the node metadata set before the first watch
+// is sent with it, that the metadata set later is sent with the subscriptions
+// of the watched resources, and that an empty resource name is never
+// requested.
+func TestFlushMetadata(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		delta bool
+	}{
+		{name: "sotw"},
+		{name: "delta", delta: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := fakeserver.New()
+			defer s.Stop()
+
+			config := s.BootstrapConfig()
+			config.XDSServer.DeltaADS = test.delta
+			c, err := client.NewWithConfigForTesting(config, defaultTestTimeout)
+			if err != nil {
+				t.Fatalf("failed to create the xds client: %v", err)
+			}
+			defer c.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+			defer cancel()
+
+			metadata := func(v string) *structpb.Struct {
+				return &structpb.Struct{Fields: map[string]*structpb.Value{"version": structpb.NewStringValue(v)}}
+			}
+			if err := c.SetMetadata(metadata("1")); err != nil {
+				t.Fatalf("SetMetadata() failed: %v", err)
+			}
+			// Nothing is watched, so nothing is requested.
+			time.Sleep(defaultTestShortTimeout)
+			if reqs := s.Requests(); len(reqs) != 0 {
+				t.Fatalf("got requests %+v before the first watch, want none", reqs)
+			}
+
+			if _, err := s.Update(version.V3ClusterURL, cluster()); err != nil {
+				t.Fatalf("Update() failed: %v", err)
+			}
+			cdsCh := make(chan result[resource.ClusterUpdate], 1)
+			defer c.WatchCluster(cdsName, watchCallback(cdsCh))()
+			wait(ctx, t, cdsCh)
+			if got := metadataVersion(s.Requests()[0]); got != "1" {
+				t.Fatalf("got metadata version %q with the first watch, want 1", got)
+			}
+
+			if err := c.SetMetadata(metadata("2")); err != nil {
+				t.Fatalf("SetMetadata() failed: %v", err)
+			}
+			for found := false; !found; {
+				for _, r := range s.Requests() {
+					if metadataVersion(r) == "2" && r.TypeURL == version.V3ClusterURL && cmp.Equal(r.ResourceNames, []string{cdsName}) {
+						found = true
+					}
+				}
+				select {
+				case <-ctx.Done():
+					t.Fatalf("timeout waiting for the watch to be sent with the new metadata: %v", ctx.Err())
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+
+			for _, r := range s.Requests() {
+				for _, name := range r.ResourceNames {
+					if name == "" {
+						t.Fatalf("got request %+v for an empty resource name", r)
+					}
+				}
+			}
+		})
+	}
+}
+
+// recordingLogger counts the debug and info logs.
+type recordingLogger struct {
+	dubbogoLogger.Logger
+
+	mu          sync.Mutex
+	debug, info int
+}
+
+func (l *recordingLogger) Debugf(string, ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.debug++
+}
+
+func (l *recordingLogger) Infof(string, ...any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.info++
+}
+
+func (l *recordingLogger) counts() (debug, info int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.debug, l.info
+}
+
+// TestLogLevel verifies that the logs of a client below its level are
+// dropped, and that the level can be lowered at runtime.
+func TestLogLevel(t *testing.T) {
+	s := fakeserver.New()
+	defer s.Stop()
+
+	logger := &recordingLogger{Logger: dubbogoLogger.GetLogger()}
+	c, err := client.NewWithConfigForTesting(s.BootstrapConfig(), defaultTestTimeout, client.WithLogger(logger), client.WithLogLevel(client.LogLevelWarn))
+	if err != nil {
+		t.Fatalf("failed to create the xds client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+
+	if _, err := s.Update(version.V3ListenerURL, listener(t, rdsName)); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	ldsCh := make(chan result[resource.ListenerUpdate], 1)
+	defer c.WatchListener(ldsName, watchCallback(ldsCh))()
+	wait(ctx, t, ldsCh)
+	if debug, info := logger.counts(); debug != 0 || info != 0 {
+		t.Fatalf("got %d debug and %d info logs at level warn, want none", debug, info)
+	}
+
+	c.(interface{ SetLogLevel(client.LogLevel) }).SetLogLevel(client.LogLevelDebug)
+	if _, err := s.Update(version.V3ListenerURL, listener(t, "other-"+rdsName)); err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	wait(ctx, t, ldsCh)
+	if debug, info := logger.counts(); debug == 0 || info == 0 {
+		t.Fatalf("got %d debug and %d info logs at level debug, want some of both", debug, info)
+	}
+}
+
+// TestValidateOnly verifies that a client in validate only mode ACKs and NACKs
+// the resources and reports them, but never applies them to its watchers.
+func TestValidateOnly(t *testing.T) {
+	s := fakeserver.New()
+	defer s.Stop()
+
+	// The watch expiry is disabled in validate only mode, so the short timeout
+	// doesn't send resource not found errors to the watchers either.
+	c, err := client.NewWithConfigForTesting(s.BootstrapConfig(), defaultTestShortTimeout, client.WithValidateOnly())
+	if err != nil {
+		t.Fatalf("failed to create the xds client: %v", err)
+	}
+	defer c.Close()
+	reporter := c.(interface {
+		ValidationReport() []client.ValidatedResource
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+
+	good, err := s.Update(version.V3ListenerURL, listener(t, rdsName))
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	ldsCh := make(chan result[resource.ListenerUpdate], 1)
+	defer c.WatchListener(ldsName, watchCallback(ldsCh))()
+	if a, err := s.WaitForAck(ctx, version.V3ListenerURL, good); err != nil || a.Nack() {
+		t.Fatalf("WaitForAck(%s) = %+v, %v, want an ACK", good, a, err)
+	}
+	report := reporter.ValidationReport()
+	if len(report) != 1 || report[0].Name != ldsName || report[0].Version != good || !report[0].Accepted() {
+		t.Fatalf("ValidationReport() = %+v, want %s version %s accepted", report, ldsName, good)
+	}
+
+	bad, err := s.Update(version.V3ListenerURL, listener(t, ""))
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+	if a, err := s.WaitForAck(ctx, version.V3ListenerURL, bad); err != nil || !a.Nack() {
+		t.Fatalf("WaitForAck(%s) = %+v, %v, want a NACK", bad, a, err)
+	}
+	report = reporter.ValidationReport()
+	if len(report) != 1 || report[0].Version != bad || report[0].Accepted() {
+		t.Fatalf("ValidationReport() = %+v, want %s version %s rejected", report, ldsName, bad)
+	}
+
+	// Neither the accepted nor the rejected version was applied.
+	select {
+	case r := <-ldsCh:
+		t.Fatalf("got update %+v and error %v in validate only mode, want none", r.update, r.err)
+	case <-time.After(2 * defaultTestShortTimeout):
+	}
+	if md := c.DumpLDS()[ldsName].MD; md.Status != resource.ServiceStatusRequested {
+		t.Fatalf("DumpLDS() has %s with metadata %+v in validate only mode, want it still requested", ldsName, md)
+	}
+}
+
+// TestWatchExpiryTimeouts verifies that the watches of each type expire after
+// the timeout of their type, or else after the client one.
+func TestWatchExpiryTimeouts(t *testing.T) {
+	s := fakeserver.New()
+	defer s.Stop()
+
+	const (
+		ldsTimeout = 50 * time.Millisecond
+		edsTimeout = 500 * time.Millisecond
+	)
+	c, err := client.NewWithConfigForTesting(s.BootstrapConfig(), defaultTestTimeout, client.WithWatchExpiryTimeouts(map[resource.ResourceType]time.Duration{
+		resource.ListenerResource:  ldsTimeout,
+		resource.EndpointsResource: edsTimeout,
+	}))
+	if err != nil {
+		t.Fatalf("failed to create the xds client: %v", err)
+	}
+	defer c.Close()
+
+	// The server has no resources, so every watch ends up expiring.
+	start := time.Now()
+	ldsCh := make(chan result[resource.ListenerUpdate], 1)
+	defer c.WatchListener(ldsName, watchCallback(ldsCh))()
+	edsCh := make(chan result[resource.EndpointsUpdate], 1)
+	defer c.WatchEndpoints(edsName, watchCallback(edsCh))()
+	cdsCh := make(chan result[resource.ClusterUpdate], 1)
+	defer c.WatchCluster(cdsName, watchCallback(cdsCh))()
+
+	expired := func(name string, errCh <-chan error, want time.Duration) {
+		t.Helper()
+		select {
+		case err := <-errCh:
+			if err == nil {
+				t.Fatalf("got an update for %s, want an expiry error", name)
+			}
+			if elapsed := time.Since(start); elapsed < want || elapsed > edsTimeout+want {
+				t.Fatalf("%s expired after %v, want about %v", name, elapsed, want)
+			}
+		case <-time.After(defaultTestTimeout):
+			t.Fatalf("timeout waiting for the expiry of %s", name)
+		}
+	}
+	expired(ldsName, errOf(ldsCh), ldsTimeout)
+	expired(edsName, errOf(edsCh), edsTimeout)
+
+	// The client timeout applies to CDS, which has no timeout of its own.
+	select {
+	case r := <-cdsCh:
+		t.Fatalf("got update %+v and error %v for %s, want the watch still waiting", r.update, r.err, cdsName)
+	case <-time.After(edsTimeout):
+	}
+}
+
+// errOf forwards the error of the first result received on ch.
+func errOf[T any](ch chan result[T]) <-chan error {
+	errCh := make(chan error, 1)
+	go func() {
+		r := <-ch
+		errCh <- r.err
+	}()
+	return errCh
+}
+
+// normalizeLoads returns a copy of stats without the report interval, which
+// depends on the timing, and with the localities sorted.
+func normalizeLoads(stats *v3endpointpb.ClusterStats) *v3endpointpb.ClusterStats {
+	stats = proto.Clone(stats).(*v3endpointpb.ClusterStats)
+	stats.LoadReportInterval = nil
+	sort.Slice(stats.UpstreamLocalityStats, func(i, j int) bool {
+		return stats.UpstreamLocalityStats[i].GetLocality().GetZone() < stats.UpstreamLocalityStats[j].GetLocality().GetZone()
+	})
+	return stats
+}
+
+func TestLoadReporting(t *testing.T) {
+	s := fakeserver.New()
+	defer s.Stop()
+
+	c, err := client.NewWithConfigForTesting(s.BootstrapConfig(), defaultTestTimeout)
+	if err != nil {
+		t.Fatalf("failed to create the xds client: %v", err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTestTimeout)
+	defer cancel()
+
+	// Report to the management server.
+	store, cancelLRS := c.ReportLoad("")
+	defer cancelLRS()
+
+	// Simulate traffic before the server asks for the loads, so that it's all
+	// in the first report.
+	zoneA, _ := resource.LocalityID{Zone: "a"}.ToString()
+	zoneB, _ := resource.LocalityID{Zone: "b"}.ToString()
+	reporter := store.PerCluster(cdsName, edsName)
+	for i := 0; i < 5; i++ {
+		reporter.CallStarted(zoneA)
+	}
+	for i := 0; i < 3; i++ {
+		reporter.CallFinished(zoneA, nil)
+	}
+	reporter.CallFinished(zoneA, errors.New("failed"))
+	reporter.CallStarted(zoneB)
+	reporter.CallFinished(zoneB, nil)
+	reporter.CallServerLoad(zoneB, "cpu_utilization", 0.5)
+	reporter.CallDropped("throttle")
+	reporter.CallDropped("throttle")
+	reporter.CallDropped("")
+	const otherCluster = "other.example.com"
+	store.PerCluster(otherCluster, "").CallStarted(zoneA)
+
+	s.SetLoadReporting(10*time.Millisecond, cdsName)
+	loads, err := s.WaitForLoadReports(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &v3endpointpb.ClusterStats{
+		ClusterName:        cdsName,
+		ClusterServiceName: edsName,
+		UpstreamLocalityStats: []*v3endpointpb.UpstreamLocalityStats{
+			{
+				Locality:                &v3corepb.Locality{Zone: "a"},
+				TotalSuccessfulRequests: 3,
+				TotalErrorRequests:      1,
+				TotalRequestsInProgress: 1,
+			},
+			{
+				Locality:                &v3corepb.Locality{Zone: "b"},
+				TotalSuccessfulRequests: 1,
+				LoadMetricStats: []*v3endpointpb.EndpointLoadMetricStats{{
+					MetricName:                    "cpu_utilization",
+					NumRequestsFinishedWithMetric: 1,
+					TotalMetricValue:              0.5,
+				}},
+			},
+		},
+		TotalDroppedRequests: 3,
+		DroppedRequests: []*v3endpointpb.ClusterStats_DroppedRequests{{
+			Category:     "throttle",
+			DroppedCount: 2,
+		}},
+	}
+	if diff := cmp.Diff(want, normalizeLoads(loads[0]), protocmp.Transform()); diff != "" {
+		t.Fatalf("unexpected first load report (-want +got):\n%s", diff)
+	}
+
+	// Only the requested cluster is reported, until the server asks for all
+	// of them.
+	for _, l := range s.LoadReports() {
+		if l.GetClusterName() == otherCluster {
+			t.Fatalf("got a load report of %s, which was not requested", otherCluster)
+		}
+	}
+	s.SetLoadReporting(10 * time.Millisecond)
+	for n := len(s.LoadReports()) + 1; ; n++ {
+		loads, err := s.WaitForLoadReports(ctx, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l := loads[n-1]; l.GetClusterName() == otherCluster {
+			if got := l.GetUpstreamLocalityStats()[0].GetTotalRequestsInProgress(); got != 1 {
+				t.Fatalf("got %d requests in progress in %s, want 1", got, otherCluster)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,162 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fakeserver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+import (
+	v3endpointpb "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	lrspb "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v3"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// lrsState is the LRS part of the server, protected by the server mu.
+type lrsState struct {
+	// resp is the response set by SetLoadReporting, nil before.
+	resp    *lrspb.LoadStatsResponse
+	streams map[*lrsStream]bool
+	loads   []*v3endpointpb.ClusterStats
+	// loadCh is closed, and replaced, when loads are received.
+	loadCh chan struct{}
+}
+
+// SetLoadReporting asks the clients to report the loads of clusters, or of
+// all their clusters if none is given, every interval. It's sent on the open
+// LRS streams, and on the new ones once the client sends its first request.
+// Until it's called, the clients wait for the LRS response and report no
+// load.
+func (s *Server) SetLoadReporting(interval time.Duration, clusters ...string) {
+	resp := &lrspb.LoadStatsResponse{
+		Clusters:              clusters,
+		SendAllClusters:       len(clusters) == 0,
+		LoadReportingInterval: durationpb.New(interval),
+	}
+	s.mu.Lock()
+	s.lrs.resp = resp
+	var started []*lrsStream
+	for st := range s.lrs.streams {
+		if st.started {
+			started = append(started, st)
+		}
+	}
+	s.mu.Unlock()
+
+	// A failed send means the stream is closing, the client gets the
+	// response on its next stream.
+	for _, st := range started {
+		_ = st.send(resp)
+	}
+}
+
+// LoadReports returns the cluster stats reported by the clients so far, in
+// order.
+func (s *Server) LoadReports() []*v3endpointpb.ClusterStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*v3endpointpb.ClusterStats(nil), s.lrs.loads...)
+}
+
+// WaitForLoadReports blocks until the clients reported at least n cluster
+// stats, or ctx is done. It returns all the stats reported so far.
+func (s *Server) WaitForLoadReports(ctx context.Context, n int) ([]*v3endpointpb.ClusterStats, error) {
+	for {
+		s.mu.Lock()
+		if len(s.lrs.loads) >= n {
+			loads := append([]*v3endpointpb.ClusterStats(nil), s.lrs.loads...)
+			s.mu.Unlock()
+			return loads, nil
+		}
+		ch := s.lrs.loadCh
+		s.mu.Unlock()
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("fakeserver: waiting for %d load reports: %w", n, ctx.Err())
+		}
+	}
+}
+
+// StreamLoadStats implements the LRS service.
+func (s *Server) StreamLoadStats(lrs lrspb.LoadReportingService_StreamLoadStatsServer) error {
+	st := &lrsStream{lrs: lrs}
+	s.mu.Lock()
+	s.lrs.streams[st] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.lrs.streams, st)
+		s.mu.Unlock()
+	}()
+
+	for {
+		req, err := lrs.Recv()
+		if err != nil {
+			return err
+		}
+		if resp := s.handleLoadStatsRequest(st, req); resp != nil {
+			if err := st.send(resp); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handleLoadStatsRequest records the loads in req, and returns the response
+// to send, nil if none. The response is sent after the first request of the
+// stream, if SetLoadReporting was called.
+func (s *Server) handleLoadStatsRequest(st *lrsStream, req *lrspb.LoadStatsRequest) *lrspb.LoadStatsResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stats := req.GetClusterStats(); len(stats) != 0 {
+		s.lrs.loads = append(s.lrs.loads, stats...)
+		close(s.lrs.loadCh)
+		s.lrs.loadCh = make(chan struct{})
+	}
+	if st.started {
+		return nil
+	}
+	st.started = true
+	return s.lrs.resp
+}
+
+// lrsStream is an LRS stream from a client.
+type lrsStream struct {
+	lrs lrspb.LoadReportingService_StreamLoadStatsServer
+
+	// sendMu serializes the responses sent by SetLoadReporting and by
+	// StreamLoadStats.
+	sendMu sync.Mutex
+
+	// started is set once the first request is received, protected by the
+	// server mu.
+	started bool
+}
+
+func (st *lrsStream) send(resp *lrspb.LoadStatsResponse) error {
+	st.sendMu.Lock()
+	defer st.sendMu.Unlock()
+	return st.lrs.Send(resp)
+}
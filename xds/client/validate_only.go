@@ -0,0 +1,178 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// ValidatedResource is the outcome of the validation of a resource received
+// by a client created with WithValidateOnly.
+type ValidatedResource struct {
+	Type resource.ResourceType
+	Name string
+	// Server is the URI of the management server which sent the resource.
+	Server string
+	// Version is the version of the response carrying the resource.
+	Version string
+	// Err is why the resource was rejected, it's nil if the resource was
+	// accepted.
+	Err error
+	// Removed is set if the server removed the resource, instead of sending
+	// it. The other fields are those of the last version received.
+	Removed bool
+	// Timestamp is when the response was received.
+	Timestamp time.Time
+}
+
+// Accepted returns whether the resource passed the validation.
+func (r ValidatedResource) Accepted() bool {
+	return r.Err == nil
+}
+
+// validationReport keeps the latest outcome per resource of the validations
+// made in validate only mode.
+type validationReport struct {
+	mu        sync.Mutex
+	resources map[resource.ResourceType]map[string]ValidatedResource
+}
+
+func newValidationReport() *validationReport {
+	return &validationReport{resources: make(map[resource.ResourceType]map[string]ValidatedResource)}
+}
+
+func (r *validationReport) record(v ValidatedResource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.resources[v.Type] == nil {
+		r.resources[v.Type] = make(map[string]ValidatedResource)
+	}
+	r.resources[v.Type][v.Name] = v
+}
+
+func (r *validationReport) remove(rType resource.ResourceType, name string) (ValidatedResource, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	v, ok := r.resources[rType][name]
+	if ok {
+		v.Removed = true
+		r.resources[rType][name] = v
+	}
+	return v, ok
+}
+
+// list returns the outcomes sorted by type and name.
+func (r *validationReport) list() []ValidatedResource {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ret []ValidatedResource
+	for _, byName := range r.resources {
+		for _, v := range byName {
+			ret = append(ret, v)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Type != ret[j].Type {
+			return ret[i].Type < ret[j].Type
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// validateOnlyHandler is the update handler of the controllers of a client in
+// validate only mode. It records the outcome of the validation of the
+// resources received and logs what would be done with them, instead of
+// applying them to the pubsub. So the watchers never receive any update.
+type validateOnlyHandler struct {
+	server string
+	report *validationReport
+	logger *levelLogger
+}
+
+func (h *validateOnlyHandler) NewListeners(updates map[string]resource.ListenerUpdateErrTuple, md resource.UpdateMetadata) {
+	for name, u := range updates {
+		h.validated(resource.ListenerResource, name, u.Err, md)
+	}
+}
+
+func (h *validateOnlyHandler) NewRouteConfigs(updates map[string]resource.RouteConfigUpdateErrTuple, md resource.UpdateMetadata) {
+	for name, u := range updates {
+		h.validated(resource.RouteConfigResource, name, u.Err, md)
+	}
+}
+
+func (h *validateOnlyHandler) NewClusters(updates map[string]resource.ClusterUpdateErrTuple, md resource.UpdateMetadata) {
+	for name, u := range updates {
+		h.validated(resource.ClusterResource, name, u.Err, md)
+	}
+}
+
+func (h *validateOnlyHandler) NewEndpoints(updates map[string]resource.EndpointsUpdateErrTuple, md resource.UpdateMetadata) {
+	for name, u := range updates {
+		h.validated(resource.EndpointsResource, name, u.Err, md)
+	}
+}
+
+func (h *validateOnlyHandler) RemoveResources(rType resource.ResourceType, names []string) {
+	for _, name := range names {
+		if _, ok := h.report.remove(rType, name); ok {
+			h.logger.Infof("xds: validate only: would remove %v resource %q from %s", rType, name, h.server)
+		}
+	}
+}
+
+func (h *validateOnlyHandler) NewConnectionError(err error) {
+	h.logger.Warnf("xds: validate only: connection error from %s: %v", h.server, err)
+}
+
+func (h *validateOnlyHandler) validated(rType resource.ResourceType, name string, err error, md resource.UpdateMetadata) {
+	v := ValidatedResource{
+		Type:      rType,
+		Name:      name,
+		Server:    h.server,
+		Version:   md.Version,
+		Err:       err,
+		Timestamp: md.Timestamp,
+	}
+	if md.ErrState != nil {
+		v.Version, v.Timestamp = md.ErrState.Version, md.ErrState.Timestamp
+	}
+	h.report.record(v)
+	if err != nil {
+		h.logger.Warnf("xds: validate only: would reject %v resource %q version %s from %s: %v", rType, name, v.Version, h.server, err)
+		return
+	}
+	h.logger.Infof("xds: validate only: would apply %v resource %q version %s from %s", rType, name, v.Version, h.server)
+}
+
+// ValidationReport returns the outcome of the validation of every resource
+// received so far, sorted by type and name, if the client is created with
+// WithValidateOnly. It returns nil otherwise.
+func (c *clientImpl) ValidationReport() []ValidatedResource {
+	if c.validation == nil {
+		return nil
+	}
+	return c.validation.list()
+}
@@ -0,0 +1,146 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource/version"
+)
+
+// warmWatch is a watch started by Warm, kept until the client is closed.
+type warmWatch struct {
+	desc string
+	// done is closed once the resource is received, or is known not to
+	// exist, in which case err is set.
+	done chan struct{}
+	once sync.Once
+	err  error
+}
+
+func (w *warmWatch) callback(err error) {
+	if err != nil && !errors.Is(err, resource.ErrResourceNotFound) &&
+		!errors.Is(err, ErrAuthorityNotFound) && !errors.Is(err, ErrClientClosed) {
+		// Wait for the resource through the transient errors, e.g. when the
+		// connection to the management server breaks.
+		return
+	}
+	w.once.Do(func() {
+		w.err = err
+		close(w.done)
+	})
+}
+
+// Warm starts watches on resources and blocks until each of them is received,
+// or ctx is done, so that the discovery can happen during the warmup of the
+// application rather than on its first requests. The type of each resource
+// must be set, to the v2 or v3 type of a listener, route configuration,
+// cluster or endpoints resource, e.g. "envoy.config.cluster.v3.Cluster".
+//
+// The watches are kept until the client is closed, so that the resources stay
+// cached for the watches started later. Warming a resource again reuses its
+// watch. It fails if a resource doesn't exist, if its authority is missing
+// from the bootstrap config, if the client is closed, or with ctx.Err() when
+// ctx is done first.
+func (c *clientImpl) Warm(ctx context.Context, resources []resource.Name) error {
+	for _, n := range resources {
+		if warmResourceType(n.Type) == resource.UnknownResource {
+			return fmt.Errorf("xds: cannot warm resource %s of unknown type %q", n.String(), n.Type)
+		}
+	}
+
+	watches := make([]*warmWatch, 0, len(resources))
+	for _, n := range resources {
+		watches = append(watches, c.warmWatch(n))
+	}
+
+	var errs []error
+	for _, w := range watches {
+		select {
+		case <-w.done:
+			if w.err != nil {
+				errs = append(errs, fmt.Errorf("xds: warming %s: %w", w.desc, w.err))
+			}
+		case <-ctx.Done():
+			var pending []string
+			for _, w := range watches {
+				select {
+				case <-w.done:
+				default:
+					pending = append(pending, w.desc)
+				}
+			}
+			sort.Strings(pending)
+			return fmt.Errorf("xds: %d resources not received (%s): %w", len(pending), strings.Join(pending, ", "), ctx.Err())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// warmWatch returns the warm watch of n, starting it if it doesn't exist.
+func (c *clientImpl) warmWatch(n resource.Name) *warmWatch {
+	rType, name := warmResourceType(n.Type), n.String()
+	desc := fmt.Sprintf("%v %s", rType, name)
+
+	c.warmMu.Lock()
+	defer c.warmMu.Unlock()
+	if w, ok := c.warmWatches[desc]; ok {
+		return w
+	}
+	w := &warmWatch{desc: desc, done: make(chan struct{})}
+	if c.warmWatches == nil {
+		c.warmWatches = make(map[string]*warmWatch)
+	}
+	c.warmWatches[desc] = w
+	// The watches are canceled when the client closes the authorities.
+	switch rType {
+	case resource.ListenerResource:
+		c.WatchListener(name, func(_ resource.ListenerUpdate, err error) { w.callback(err) })
+	case resource.RouteConfigResource:
+		c.WatchRouteConfig(name, func(_ resource.RouteConfigUpdate, err error) { w.callback(err) })
+	case resource.ClusterResource:
+		c.WatchCluster(name, func(_ resource.ClusterUpdate, err error) { w.callback(err) })
+	case resource.EndpointsResource:
+		c.WatchEndpoints(name, func(_ resource.EndpointsUpdate, err error) { w.callback(err) })
+	}
+	return w
+}
+
+// warmResourceType returns the resource type of the type name t.
+func warmResourceType(t string) resource.ResourceType {
+	switch t {
+	case version.V2ListenerType, version.V3ListenerType:
+		return resource.ListenerResource
+	case version.V2RouteConfigType, version.V3RouteConfigType:
+		return resource.RouteConfigResource
+	case version.V2ClusterType, version.V3ClusterType:
+		return resource.ClusterResource
+	case version.V2EndpointsType, version.V3EndpointsType:
+		return resource.EndpointsResource
+	default:
+		return resource.UnknownResource
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource/version"
+)
+
+func waitWatches(t *testing.T, fc *fakeController, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case <-fc.watches:
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for %d watches, got %d", n, i)
+		}
+	}
+}
+
+func TestWarm(t *testing.T) {
+	c, fc := newFakeControllerClient(t)
+	a, unref, err := c.findAuthority(resource.ParseName(""))
+	if err != nil {
+		t.Fatalf("findAuthority() failed: %v", err)
+	}
+	defer unref()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.Warm(ctx, []resource.Name{
+			{Type: version.V3ClusterType, ID: "outbound|80||foo"},
+			{Type: version.V3EndpointsType, ID: "outbound|80||foo"},
+		})
+	}()
+	waitWatches(t, fc, 2)
+
+	a.pubsub.NewClusters(map[string]resource.ClusterUpdateErrTuple{
+		"outbound|80||foo": {Update: resource.ClusterUpdate{ClusterName: "outbound|80||foo"}},
+	}, resource.UpdateMetadata{})
+	select {
+	case err := <-errCh:
+		t.Fatalf("Warm() returned %v before the endpoints were received", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	a.pubsub.NewEndpoints(map[string]resource.EndpointsUpdateErrTuple{
+		"outbound|80||foo": {Update: resource.EndpointsUpdate{}},
+	}, resource.UpdateMetadata{})
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("Warm() failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Warm() did not return after all the resources were received")
+	}
+
+	// The resources stay watched, so warming them again returns right away.
+	if err := c.Warm(ctx, []resource.Name{{Type: version.V3ClusterType, ID: "outbound|80||foo"}}); err != nil {
+		t.Fatalf("Warm() of a warm resource failed: %v", err)
+	}
+}
+
+func TestWarmTimeout(t *testing.T) {
+	c, fc := newFakeControllerClient(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := c.Warm(ctx, []resource.Name{{Type: version.V3ListenerType, ID: "foo:80"}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Warm() returned %v, want %v", err, context.DeadlineExceeded)
+	}
+	waitWatches(t, fc, 1)
+}
+
+func TestWarmUnknownType(t *testing.T) {
+	c, fc := newFakeControllerClient(t)
+
+	err := c.Warm(context.Background(), []resource.Name{
+		{Type: version.V3ClusterType, ID: "outbound|80||foo"},
+		{ID: "outbound|80||bar"},
+	})
+	if err == nil {
+		t.Fatal("Warm() of a resource without type succeeded")
+	}
+	select {
+	case name := <-fc.watches:
+		t.Fatalf("Warm() of an invalid list watched %s", name)
+	default:
+	}
+}
@@ -93,19 +93,60 @@ func (c *clientImpl) WatchCluster(clusterName string, cb func(resource.ClusterUp
 // WatchEndpoints can be called multiple times, with same or different
 // clusterNames. Each call will start an independent watcher for the resource.
 //
+// The updates are coalesced if the client is created with
+// WithEndpointsDebounce.
+//
 // Note that during race (e.g. an xDS response is received while the user is
 // calling cancel()), there's a small window where the callback can be called
 // after the watcher is canceled. The caller needs to handle this case.
 func (c *clientImpl) WatchEndpoints(clusterName string, cb func(resource.EndpointsUpdate, error)) (cancel func()) {
+	stopDebounce := func() {}
+	if c.endpointsDebounce > 0 {
+		d := newDebouncer(c.endpointsDebounce, cb)
+		cb, stopDebounce = d.push, d.stop
+	}
 	n := resource.ParseName(clusterName)
 	a, unref, err := c.findAuthority(n)
 	if err != nil {
 		cb(resource.EndpointsUpdate{}, err)
-		return func() {}
+		return stopDebounce
 	}
 	cancelF := a.watchEndpoints(n.String(), cb)
 	return func() {
 		cancelF()
 		unref()
+		stopDebounce()
+	}
+}
+
+// WatchState returns the number of watchers per resource type across all the
+// authorities in use. Watches on idle authorities are reported by
+// IdleWatchState.
+func (c *clientImpl) WatchState() map[resource.ResourceType]int {
+	c.authorityMu.Lock()
+	defer c.authorityMu.Unlock()
+	ret := make(map[resource.ResourceType]int)
+	for _, a := range c.authorities {
+		addWatchCounts(ret, a)
+	}
+	return ret
+}
+
+// IdleWatchState returns the number of watchers per resource type across the
+// idle authorities. Since an authority becomes idle when its last watch is
+// canceled, non-zero counts here point to leaked watches.
+func (c *clientImpl) IdleWatchState() map[resource.ResourceType]int {
+	ret := make(map[resource.ResourceType]int)
+	for _, item := range c.idleAuthorities.Items() {
+		if a, ok := item.(*authority); ok {
+			addWatchCounts(ret, a)
+		}
+	}
+	return ret
+}
+
+func addWatchCounts(counts map[resource.ResourceType]int, a *authority) {
+	for rType, n := range a.watchCounts() {
+		counts[rType] += n
 	}
 }
@@ -0,0 +1,77 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package client
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/google/go-cmp/cmp"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+func TestWatchState(t *testing.T) {
+	c, _ := newFakeControllerClient(t)
+	cancelA := c.WatchCluster("cds-a", func(resource.ClusterUpdate, error) {})
+	cancelA2 := c.WatchCluster("cds-a", func(resource.ClusterUpdate, error) {})
+	cancelRoute := c.WatchRouteConfig("route-a", func(resource.RouteConfigUpdate, error) {})
+
+	if diff := cmp.Diff(map[resource.ResourceType]int{resource.RouteConfigResource: 1, resource.ClusterResource: 2}, c.WatchState()); diff != "" {
+		t.Fatalf("WatchState() returned unexpected counts (-want +got):\n%s", diff)
+	}
+	if got := c.IdleWatchState(); len(got) != 0 {
+		t.Fatalf("IdleWatchState() = %v, want no idle authority", got)
+	}
+
+	// Canceling all the watches moves the authority to the idle ones, which
+	// have no watch left.
+	cancelA()
+	cancelA2()
+	cancelRoute()
+	if got := c.WatchState(); len(got) != 0 {
+		t.Fatalf("WatchState() = %v, want no authority in use", got)
+	}
+	if got := c.IdleWatchState(); len(got) != 0 {
+		t.Fatalf("IdleWatchState() = %v, want no watch on the idle authority", got)
+	}
+}
+
+// TestIdleWatchStateLeak verifies that a watch left on an authority which is
+// idle, i.e. a watch not holding a reference on its authority, is reported by
+// IdleWatchState.
+func TestIdleWatchStateLeak(t *testing.T) {
+	c, _ := newFakeControllerClient(t)
+	a, unref, err := c.findAuthority(resource.ParseName(""))
+	if err != nil {
+		t.Fatalf("findAuthority() failed: %v", err)
+	}
+	cancel := a.watchCluster("cds-a", func(resource.ClusterUpdate, error) {})
+	defer cancel()
+	unref()
+
+	if got := c.WatchState(); len(got) != 0 {
+		t.Fatalf("WatchState() = %v, want no authority in use", got)
+	}
+	if diff := cmp.Diff(map[resource.ResourceType]int{resource.ClusterResource: 1}, c.IdleWatchState()); diff != "" {
+		t.Fatalf("IdleWatchState() returned unexpected counts (-want +got):\n%s", diff)
+	}
+}
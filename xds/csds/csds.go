@@ -31,6 +31,7 @@ package csds
 import (
 	"context"
 	"io"
+	"sort"
 )
 
 import (
@@ -43,6 +44,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 
 	"google.golang.org/grpc/status"
@@ -76,6 +78,23 @@ const (
 	endpointsTypeURL   = "envoy.config.endpoint.v3.ClusterLoadAssignment"
 )
 
+// dumpTypes are the resource types reported by CSDS, in order.
+var dumpTypes = []struct {
+	resourceType resource.ResourceType
+	typeURL      string
+}{
+	{resource.ListenerResource, listenerTypeURL},
+	{resource.RouteConfigResource, routeConfigTypeURL},
+	{resource.ClusterResource, clusterTypeURL},
+	{resource.EndpointsResource, endpointsTypeURL},
+}
+
+// resourcesDumper is implemented by the xds clients able to dump all their
+// resources at once, as a consistent snapshot.
+type resourcesDumper interface {
+	DumpResources() map[string]map[string]resource.UpdateWithMD
+}
+
 // ClientStatusDiscoveryServer implementations interface ClientStatusDiscoveryServiceServer.
 type ClientStatusDiscoveryServer struct {
 	// xdsClient will always be the same in practice. But we keep a copy in each
@@ -89,6 +108,19 @@ func NewClientStatusDiscoveryServer() (*ClientStatusDiscoveryServer, error) {
 	return &ClientStatusDiscoveryServer{xdsClient: newXDSClient()}, nil
 }
 
+// Register creates a ClientStatusDiscoveryServer and registers it on s, so
+// that tools can fetch the resources accepted or rejected by the xds client,
+// with their ACKED, NACKED or REQUESTED status. The returned server must be
+// closed once s is stopped.
+func Register(s *grpc.Server) (*ClientStatusDiscoveryServer, error) {
+	srv, err := NewClientStatusDiscoveryServer()
+	if err != nil {
+		return nil, err
+	}
+	v3statuspb.RegisterClientStatusDiscoveryServiceServer(s, srv)
+	return srv, nil
+}
+
 // StreamClientStatus implementations interface ClientStatusDiscoveryServiceServer.
 func (s *ClientStatusDiscoveryServer) StreamClientStatus(stream v3statuspb.ClientStatusDiscoveryService_StreamClientStatusServer) error {
 	for {
@@ -128,15 +160,11 @@ func (s *ClientStatusDiscoveryServer) buildClientStatusRespForReq(req *v3statusp
 		return nil, status.Errorf(codes.InvalidArgument, "node_matchers are not supported, request contains node_matchers: %v", req.NodeMatchers)
 	}
 
-	lds := dumpToGenericXdsConfig(listenerTypeURL, s.xdsClient.DumpLDS)
-	rds := dumpToGenericXdsConfig(routeConfigTypeURL, s.xdsClient.DumpRDS)
-	cds := dumpToGenericXdsConfig(clusterTypeURL, s.xdsClient.DumpCDS)
-	eds := dumpToGenericXdsConfig(endpointsTypeURL, s.xdsClient.DumpEDS)
-	configs := make([]*v3statuspb.ClientConfig_GenericXdsConfig, 0, len(lds)+len(rds)+len(cds)+len(eds))
-	configs = append(configs, lds...)
-	configs = append(configs, rds...)
-	configs = append(configs, cds...)
-	configs = append(configs, eds...)
+	dumps := s.dumpResources()
+	var configs []*v3statuspb.ClientConfig_GenericXdsConfig
+	for _, t := range dumpTypes {
+		configs = append(configs, dumpToGenericXdsConfig(t.typeURL, dumps[t.resourceType.String()])...)
+	}
 
 	ret := &v3statuspb.ClientStatusResponse{
 		Config: []*v3statuspb.ClientConfig{
@@ -149,6 +177,20 @@ func (s *ClientStatusDiscoveryServer) buildClientStatusRespForReq(req *v3statusp
 	return ret, nil
 }
 
+// dumpResources returns the resources of the xds client by resource type and
+// name, like client.DumpResources.
+func (s *ClientStatusDiscoveryServer) dumpResources() map[string]map[string]resource.UpdateWithMD {
+	if d, ok := s.xdsClient.(resourcesDumper); ok {
+		return d.DumpResources()
+	}
+	return map[string]map[string]resource.UpdateWithMD{
+		resource.ListenerResource.String():    s.xdsClient.DumpLDS(),
+		resource.RouteConfigResource.String(): s.xdsClient.DumpRDS(),
+		resource.ClusterResource.String():     s.xdsClient.DumpCDS(),
+		resource.EndpointsResource.String():   s.xdsClient.DumpEDS(),
+	}
+}
+
 // Close cleans up the resources.
 func (s *ClientStatusDiscoveryServer) Close() {
 	if s.xdsClient != nil {
@@ -187,10 +229,17 @@ func nodeProtoToV3(n proto.Message) *v3corepb.Node {
 	return node
 }
 
-func dumpToGenericXdsConfig(typeURL string, dumpF func() map[string]resource.UpdateWithMD) []*v3statuspb.ClientConfig_GenericXdsConfig {
-	dump := dumpF()
+// dumpToGenericXdsConfig converts the dump of the resources of typeURL, sorted
+// by name so that the responses are stable.
+func dumpToGenericXdsConfig(typeURL string, dump map[string]resource.UpdateWithMD) []*v3statuspb.ClientConfig_GenericXdsConfig {
+	names := make([]string, 0, len(dump))
+	for name := range dump {
+		names = append(names, name)
+	}
+	sort.Strings(names)
 	ret := make([]*v3statuspb.ClientConfig_GenericXdsConfig, 0, len(dump))
-	for name, d := range dump {
+	for _, name := range names {
+		d := dump[name]
 		config := &v3statuspb.ClientConfig_GenericXdsConfig{
 			TypeUrl:      typeURL,
 			Name:         name,
@@ -0,0 +1,202 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package csds
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+import (
+	v3adminpb "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	v3corepb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	v3statuspb "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
+	v3matcherpb "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+
+	"github.com/google/go-cmp/cmp"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/bootstrap"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+var (
+	testNode = &v3corepb.Node{Id: "test-node"}
+	testTime = time.Unix(1000, 0)
+)
+
+// fakeClient is an xds client dumping its resources one type at a time.
+type fakeClient struct {
+	client.XDSClient
+	dumps map[resource.ResourceType]map[string]resource.UpdateWithMD
+}
+
+func (c *fakeClient) DumpLDS() map[string]resource.UpdateWithMD {
+	return c.dumps[resource.ListenerResource]
+}
+
+func (c *fakeClient) DumpRDS() map[string]resource.UpdateWithMD {
+	return c.dumps[resource.RouteConfigResource]
+}
+
+func (c *fakeClient) DumpCDS() map[string]resource.UpdateWithMD {
+	return c.dumps[resource.ClusterResource]
+}
+
+func (c *fakeClient) DumpEDS() map[string]resource.UpdateWithMD {
+	return c.dumps[resource.EndpointsResource]
+}
+
+func (c *fakeClient) BootstrapConfig() *bootstrap.Config {
+	return &bootstrap.Config{XDSServer: &bootstrap.ServerConfig{NodeProto: testNode}}
+}
+
+// snapshotClient is an xds client dumping all its resources at once.
+type snapshotClient struct {
+	fakeClient
+	snapshots int
+}
+
+func (c *snapshotClient) DumpResources() map[string]map[string]resource.UpdateWithMD {
+	c.snapshots++
+	ret := make(map[string]map[string]resource.UpdateWithMD)
+	for t, dump := range c.dumps {
+		ret[t.String()] = dump
+	}
+	return ret
+}
+
+func testDumps(t *testing.T) (map[resource.ResourceType]map[string]resource.UpdateWithMD, *anypb.Any) {
+	t.Helper()
+	raw, err := anypb.New(testNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[resource.ResourceType]map[string]resource.UpdateWithMD{
+		resource.ListenerResource: {
+			"lis-b": {MD: resource.UpdateMetadata{Status: resource.ServiceStatusRequested}},
+			"lis-a": {MD: resource.UpdateMetadata{Status: resource.ServiceStatusACKed, Version: "1", Timestamp: testTime}, Raw: raw},
+		},
+		resource.ClusterResource: {
+			"cluster": {
+				MD: resource.UpdateMetadata{
+					Status:    resource.ServiceStatusNACKed,
+					Version:   "1",
+					Timestamp: testTime,
+					ErrState:  &resource.UpdateErrorMetadata{Version: "2", Err: errors.New("invalid cluster"), Timestamp: testTime},
+				},
+				Raw: raw,
+			},
+		},
+	}, raw
+}
+
+func wantResponse(raw *anypb.Any) *v3statuspb.ClientStatusResponse {
+	return &v3statuspb.ClientStatusResponse{Config: []*v3statuspb.ClientConfig{{
+		Node: testNode,
+		GenericXdsConfigs: []*v3statuspb.ClientConfig_GenericXdsConfig{
+			{
+				TypeUrl:      listenerTypeURL,
+				Name:         "lis-a",
+				VersionInfo:  "1",
+				XdsConfig:    raw,
+				LastUpdated:  timestamppb.New(testTime),
+				ClientStatus: v3adminpb.ClientResourceStatus_ACKED,
+			},
+			{
+				TypeUrl:      listenerTypeURL,
+				Name:         "lis-b",
+				LastUpdated:  timestamppb.New(time.Time{}),
+				ClientStatus: v3adminpb.ClientResourceStatus_REQUESTED,
+			},
+			{
+				TypeUrl:      clusterTypeURL,
+				Name:         "cluster",
+				VersionInfo:  "1",
+				XdsConfig:    raw,
+				LastUpdated:  timestamppb.New(testTime),
+				ClientStatus: v3adminpb.ClientResourceStatus_NACKED,
+				ErrorState: &v3adminpb.UpdateFailureState{
+					LastUpdateAttempt: timestamppb.New(testTime),
+					Details:           "invalid cluster",
+					VersionInfo:       "2",
+				},
+			},
+		},
+	}}}
+}
+
+func TestFetchClientStatus(t *testing.T) {
+	dumps, raw := testDumps(t)
+	s := &ClientStatusDiscoveryServer{xdsClient: &fakeClient{dumps: dumps}}
+	got, err := s.FetchClientStatus(context.Background(), &v3statuspb.ClientStatusRequest{})
+	if err != nil {
+		t.Fatalf("FetchClientStatus() failed: %v", err)
+	}
+	if diff := cmp.Diff(wantResponse(raw), got, protocmp.Transform()); diff != "" {
+		t.Errorf("FetchClientStatus() diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestFetchClientStatusFromSnapshot(t *testing.T) {
+	dumps, raw := testDumps(t)
+	c := &snapshotClient{fakeClient: fakeClient{dumps: dumps}}
+	s := &ClientStatusDiscoveryServer{xdsClient: c}
+	got, err := s.FetchClientStatus(context.Background(), &v3statuspb.ClientStatusRequest{})
+	if err != nil {
+		t.Fatalf("FetchClientStatus() failed: %v", err)
+	}
+	if c.snapshots != 1 {
+		t.Errorf("got %d snapshots of the resources, want 1", c.snapshots)
+	}
+	if diff := cmp.Diff(wantResponse(raw), got, protocmp.Transform()); diff != "" {
+		t.Errorf("FetchClientStatus() diff (-want +got):\n%s", diff)
+	}
+}
+
+func TestFetchClientStatusNodeMatchers(t *testing.T) {
+	s := &ClientStatusDiscoveryServer{xdsClient: &fakeClient{}}
+	_, err := s.FetchClientStatus(context.Background(), &v3statuspb.ClientStatusRequest{
+		NodeMatchers: []*v3matcherpb.NodeMatcher{{}},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("FetchClientStatus() with node matchers = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestFetchClientStatusWithoutClient(t *testing.T) {
+	s := &ClientStatusDiscoveryServer{}
+	got, err := s.FetchClientStatus(context.Background(), &v3statuspb.ClientStatusRequest{})
+	if err != nil {
+		t.Fatalf("FetchClientStatus() failed: %v", err)
+	}
+	if diff := cmp.Diff(&v3statuspb.ClientStatusResponse{}, got, protocmp.Transform()); diff != "" {
+		t.Errorf("FetchClientStatus() diff (-want +got):\n%s", diff)
+	}
+}
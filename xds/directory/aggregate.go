@@ -0,0 +1,306 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"fmt"
+	"sync"
+)
+
+import (
+	"github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// maxAggregateDepth is the max depth of a tree of aggregate clusters, the
+// same as gRPC's.
+const maxAggregateDepth = 16
+
+// ClusterWatcher is the part of the xDS client used by AggregateWatcher.
+type ClusterWatcher interface {
+	EndpointsWatcher
+	WatchCluster(clusterName string, cdsCb func(resource.ClusterUpdate, error)) (cancel func())
+}
+
+// AggregateWatcher is an EndpointsWatcher resolving the clusters with CDS, so
+// that the endpoints of an aggregate cluster are the ones of its underlying
+// clusters. It can be given to NewEndpointsBridge in place of the xDS client.
+type AggregateWatcher struct {
+	client ClusterWatcher
+}
+
+// NewAggregateWatcher returns an AggregateWatcher using client.
+func NewAggregateWatcher(client ClusterWatcher) *AggregateWatcher {
+	return &AggregateWatcher{client: client}
+}
+
+// WatchEndpoints watches the CDS resource of clusterName. The endpoints of an
+// EDS cluster are watched with the EDS service name of the cluster. The
+// underlying clusters of an aggregate cluster are watched recursively, and its
+// endpoints are the merge of theirs, in the order of the aggregate: the
+// priorities of each underlying cluster come after the ones of the clusters
+// before it, so that FlattenEndpoints only fails over to a cluster when the
+// ones before it have no usable endpoint. A cluster found several times in the
+// tree is only used at its first position. The drop configs are only kept when
+// clusterName isn't an aggregate cluster.
+//
+// edsCb is first called once all the clusters of the tree got their updates.
+// An underlying cluster which doesn't exist has no endpoints. A cluster
+// referencing one of its aggregate ancestors, or too deep in the tree, is
+// reported as an error and ignored. Logical DNS clusters are not supported and
+// have no endpoints.
+func (w *AggregateWatcher) WatchEndpoints(clusterName string, edsCb func(resource.EndpointsUpdate, error)) (cancel func()) {
+	aw := &aggregateWatch{client: w.client, cb: edsCb}
+	aw.mu.Lock()
+	aw.root = aw.newNode(clusterName, nil)
+	aw.mu.Unlock()
+	return aw.cancel
+}
+
+// aggregateWatch is an endpoints watch of AggregateWatcher.
+type aggregateWatch struct {
+	client ClusterWatcher
+	cb     func(resource.EndpointsUpdate, error)
+
+	mu       sync.Mutex
+	root     *clusterWatchNode
+	canceled bool
+}
+
+// clusterWatchNode is a cluster of the tree of an aggregateWatch. It's
+// protected by the mutex of the aggregateWatch.
+type clusterWatchNode struct {
+	name      string
+	parent    *clusterWatchNode
+	depth     int
+	cancelCDS func()
+
+	received bool // whether the CDS update is received
+	notFound bool
+	update   resource.ClusterUpdate
+	children []*clusterWatchNode // of an aggregate cluster
+
+	edsName   string
+	cancelEDS func()
+	endpoints *resource.EndpointsUpdate // of an EDS cluster, nil until received
+	deleted   bool
+}
+
+func (aw *aggregateWatch) newNode(name string, parent *clusterWatchNode) *clusterWatchNode {
+	n := &clusterWatchNode{name: name, parent: parent}
+	if parent != nil {
+		n.depth = parent.depth + 1
+	}
+	n.cancelCDS = aw.client.WatchCluster(name, func(update resource.ClusterUpdate, err error) {
+		aw.handleCluster(n, update, err)
+	})
+	return n
+}
+
+func (aw *aggregateWatch) cancel() {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	if aw.canceled {
+		return
+	}
+	aw.canceled = true
+	aw.root.delete()
+}
+
+func (aw *aggregateWatch) handleCluster(n *clusterWatchNode, update resource.ClusterUpdate, err error) {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	if aw.canceled || n.deleted {
+		return
+	}
+	if err != nil {
+		if n.parent == nil || resource.ErrType(err) != resource.ErrorTypeResourceNotFound {
+			aw.cb(resource.EndpointsUpdate{}, err)
+			return
+		}
+		// An underlying cluster which doesn't exist has no endpoints, the
+		// aggregate fails over to the next one.
+		n.deleteChildren()
+		n.stopEDS()
+		n.received, n.notFound = true, true
+		aw.sendLocked()
+		return
+	}
+
+	n.received, n.notFound, n.update = true, false, update
+	switch update.ClusterType {
+	case resource.ClusterTypeAggregate:
+		n.stopEDS()
+		aw.updateChildren(n, update.PrioritizedClusterNames)
+	case resource.ClusterTypeEDS:
+		n.deleteChildren()
+		edsName := update.EDSServiceName
+		if edsName == "" {
+			edsName = n.name
+		}
+		if n.cancelEDS == nil || edsName != n.edsName {
+			n.stopEDS()
+			n.edsName = edsName
+			n.cancelEDS = aw.client.WatchEndpoints(edsName, func(update resource.EndpointsUpdate, err error) {
+				aw.handleEndpoints(n, update, err)
+			})
+		}
+	default:
+		logger.Warnf("[XDS Directory] cluster %s of type %v is not supported, it has no endpoints", n.name, update.ClusterType)
+		n.deleteChildren()
+		n.stopEDS()
+		n.endpoints = &resource.EndpointsUpdate{}
+	}
+	aw.sendLocked()
+}
+
+// updateChildren sets the underlying clusters of the aggregate cluster n to
+// names, reusing the existing nodes of the clusters already watched.
+func (aw *aggregateWatch) updateChildren(n *clusterWatchNode, names []string) {
+	existing := make(map[string]*clusterWatchNode, len(n.children))
+	for _, child := range n.children {
+		existing[child.name] = child
+	}
+	children := make([]*clusterWatchNode, 0, len(names))
+	for _, name := range names {
+		if child, ok := existing[name]; ok {
+			delete(existing, name)
+			children = append(children, child)
+			continue
+		}
+		if err := n.checkChild(name); err != nil {
+			aw.cb(resource.EndpointsUpdate{}, err)
+			continue
+		}
+		children = append(children, aw.newNode(name, n))
+	}
+	for _, child := range existing {
+		child.delete()
+	}
+	n.children = children
+}
+
+// checkChild returns an error if the cluster name can't be an underlying
+// cluster of n.
+func (n *clusterWatchNode) checkChild(name string) error {
+	if n.depth+1 >= maxAggregateDepth {
+		return fmt.Errorf("xds: aggregate cluster %s: underlying cluster %s exceeds the max depth %d", n.name, name, maxAggregateDepth)
+	}
+	for a := n; a != nil; a = a.parent {
+		if a.name == name {
+			return fmt.Errorf("xds: aggregate cluster %s: underlying cluster %s makes a cycle", n.name, name)
+		}
+	}
+	return nil
+}
+
+func (aw *aggregateWatch) handleEndpoints(n *clusterWatchNode, update resource.EndpointsUpdate, err error) {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	if aw.canceled || n.deleted || n.cancelEDS == nil {
+		return
+	}
+	if err != nil {
+		if resource.ErrType(err) != resource.ErrorTypeResourceNotFound {
+			aw.cb(resource.EndpointsUpdate{}, err)
+			return
+		}
+		update = resource.EndpointsUpdate{}
+	}
+	n.endpoints = &update
+	aw.sendLocked()
+}
+
+// sendLocked calls the callback with the merged endpoints of the tree, if all
+// its clusters got their updates.
+//
+// Caller must hold aw.mu.
+func (aw *aggregateWatch) sendLocked() {
+	var (
+		merged resource.EndpointsUpdate
+		offset uint32
+	)
+	if !aw.root.merge(&merged, &offset, make(map[string]bool)) {
+		return
+	}
+	if aw.root.update.ClusterType != resource.ClusterTypeAggregate {
+		merged.Drops = aw.root.endpoints.Drops
+	}
+	aw.cb(merged, nil)
+}
+
+// merge appends the localities of n to merged, their priorities shifted by
+// offset, which is then moved past them. It returns false if a cluster of the
+// tree of n didn't get its update yet.
+func (n *clusterWatchNode) merge(merged *resource.EndpointsUpdate, offset *uint32, seen map[string]bool) bool {
+	if !n.received {
+		return false
+	}
+	if n.notFound {
+		return true
+	}
+	if n.update.ClusterType == resource.ClusterTypeAggregate {
+		for _, child := range n.children {
+			if !child.merge(merged, offset, seen) {
+				return false
+			}
+		}
+		return true
+	}
+	if n.endpoints == nil {
+		return false
+	}
+	if seen[n.name] {
+		return true
+	}
+	seen[n.name] = true
+	var priorities uint32
+	for _, l := range n.endpoints.Localities {
+		priorities = max(priorities, l.Priority+1)
+		l.Priority += *offset
+		merged.Localities = append(merged.Localities, l)
+	}
+	*offset += priorities
+	return true
+}
+
+func (n *clusterWatchNode) delete() {
+	n.deleted = true
+	n.cancelCDS()
+	n.stopEDS()
+	n.deleteChildren()
+}
+
+func (n *clusterWatchNode) deleteChildren() {
+	for _, child := range n.children {
+		child.delete()
+	}
+	n.children = nil
+}
+
+func (n *clusterWatchNode) stopEDS() {
+	if n.cancelEDS != nil {
+		n.cancelEDS()
+		n.cancelEDS = nil
+	}
+	n.edsName = ""
+	n.endpoints = nil
+}
@@ -0,0 +1,226 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"strings"
+	"testing"
+)
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// fakeClusterWatcher records the watches by resource name, for the tests to
+// send the updates.
+type fakeClusterWatcher struct {
+	next int
+	cds  map[string]map[int]func(resource.ClusterUpdate, error)
+	eds  map[string]map[int]func(resource.EndpointsUpdate, error)
+}
+
+func newFakeClusterWatcher() *fakeClusterWatcher {
+	return &fakeClusterWatcher{
+		cds: make(map[string]map[int]func(resource.ClusterUpdate, error)),
+		eds: make(map[string]map[int]func(resource.EndpointsUpdate, error)),
+	}
+}
+
+func addWatch[T any](w *fakeClusterWatcher, watches map[string]map[int]T, name string, cb T) func() {
+	w.next++
+	id := w.next
+	if watches[name] == nil {
+		watches[name] = make(map[int]T)
+	}
+	watches[name][id] = cb
+	return func() {
+		delete(watches[name], id)
+		if len(watches[name]) == 0 {
+			delete(watches, name)
+		}
+	}
+}
+
+func (w *fakeClusterWatcher) WatchCluster(clusterName string, cb func(resource.ClusterUpdate, error)) func() {
+	return addWatch(w, w.cds, clusterName, cb)
+}
+
+func (w *fakeClusterWatcher) WatchEndpoints(clusterName string, cb func(resource.EndpointsUpdate, error)) func() {
+	return addWatch(w, w.eds, clusterName, cb)
+}
+
+func (w *fakeClusterWatcher) sendCluster(name string, update resource.ClusterUpdate, err error) {
+	for _, cb := range w.cds[name] {
+		cb(update, err)
+	}
+}
+
+func (w *fakeClusterWatcher) sendEndpoints(name string, update resource.EndpointsUpdate, err error) {
+	for _, cb := range w.eds[name] {
+		cb(update, err)
+	}
+}
+
+func (w *fakeClusterWatcher) aggregate(name string, children ...string) {
+	w.sendCluster(name, resource.ClusterUpdate{ClusterName: name, ClusterType: resource.ClusterTypeAggregate, PrioritizedClusterNames: children}, nil)
+}
+
+func (w *fakeClusterWatcher) edsCluster(name string) {
+	w.sendCluster(name, resource.ClusterUpdate{ClusterName: name, ClusterType: resource.ClusterTypeEDS, EDSServiceName: name + "-eds"}, nil)
+}
+
+// recordingCallback keeps the last update and the errors of an endpoints watch.
+type recordingCallback struct {
+	updates int
+	last    resource.EndpointsUpdate
+	errs    []error
+}
+
+func (r *recordingCallback) cb(update resource.EndpointsUpdate, err error) {
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return
+	}
+	r.updates++
+	r.last = update
+}
+
+func TestAggregateWatcherMergesPriorities(t *testing.T) {
+	w := newFakeClusterWatcher()
+	r := &recordingCallback{}
+	cancel := NewAggregateWatcher(w).WatchEndpoints("agg", r.cb)
+
+	w.aggregate("agg", "primary", "secondary")
+	w.edsCluster("primary")
+	w.edsCluster("secondary")
+	w.sendEndpoints("primary-eds", resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1, healthy("10.0.0.1:80", 1)),
+		locality(1, 1, healthy("10.0.1.1:80", 1)),
+	}}, nil)
+	if r.updates != 0 {
+		t.Fatalf("got an update before all the clusters got theirs: %+v", r.last)
+	}
+	w.sendEndpoints("secondary-eds", resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1, healthy("10.1.0.1:80", 1)),
+	}}, nil)
+
+	want := resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1, healthy("10.0.0.1:80", 1)),
+		locality(1, 1, healthy("10.0.1.1:80", 1)),
+		locality(2, 1, healthy("10.1.0.1:80", 1)),
+	}}
+	if diff := cmp.Diff(want, r.last, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("unexpected merged endpoints (-want +got):\n%s", diff)
+	}
+
+	// The primary cluster failing over to the secondary one.
+	w.sendEndpoints("primary-eds", resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1, resource.Endpoint{Address: "10.0.0.1:80", HealthStatus: resource.EndpointHealthStatusUnhealthy}),
+	}}, nil)
+	if diff := cmp.Diff([]WeightedAddr{{Addr: "10.1.0.1:80", Weight: 1, Priority: 1}}, FlattenEndpoints(r.last)); diff != "" {
+		t.Fatalf("unexpected flattened endpoints (-want +got):\n%s", diff)
+	}
+
+	// The secondary cluster removed from the aggregate.
+	w.aggregate("agg", "primary")
+	if _, ok := w.cds["secondary"]; ok {
+		t.Fatal("the CDS watch of the removed cluster is not canceled")
+	}
+	if _, ok := w.eds["secondary-eds"]; ok {
+		t.Fatal("the EDS watch of the removed cluster is not canceled")
+	}
+	if got := len(r.last.Localities); got != 1 {
+		t.Fatalf("got %d localities, want 1", got)
+	}
+
+	cancel()
+	if len(w.cds) != 0 || len(w.eds) != 0 {
+		t.Fatalf("watches %v and %v left after cancel", w.cds, w.eds)
+	}
+}
+
+func TestAggregateWatcherNestedAndMissingClusters(t *testing.T) {
+	w := newFakeClusterWatcher()
+	r := &recordingCallback{}
+	NewAggregateWatcher(w).WatchEndpoints("agg", r.cb)
+
+	w.aggregate("agg", "missing", "nested", "leaf")
+	w.aggregate("nested", "leaf", "other")
+	w.edsCluster("leaf")
+	w.edsCluster("other")
+	w.sendCluster("missing", resource.ClusterUpdate{}, resource.NewErrorf(resource.ErrorTypeResourceNotFound, "missing not found"))
+	w.sendEndpoints("leaf-eds", resource.EndpointsUpdate{Localities: []resource.Locality{locality(0, 1, healthy("10.0.0.1:80", 1))}}, nil)
+	w.sendEndpoints("other-eds", resource.EndpointsUpdate{Localities: []resource.Locality{locality(0, 1, healthy("10.0.1.1:80", 1))}}, nil)
+
+	// leaf is only used at its first position, under nested.
+	want := resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1, healthy("10.0.0.1:80", 1)),
+		locality(1, 1, healthy("10.0.1.1:80", 1)),
+	}}
+	if diff := cmp.Diff(want, r.last, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("unexpected merged endpoints (-want +got):\n%s", diff)
+	}
+	if len(r.errs) != 0 {
+		t.Fatalf("unexpected errors %v", r.errs)
+	}
+}
+
+func TestAggregateWatcherCycle(t *testing.T) {
+	w := newFakeClusterWatcher()
+	r := &recordingCallback{}
+	NewAggregateWatcher(w).WatchEndpoints("agg", r.cb)
+
+	w.aggregate("agg", "nested", "leaf")
+	w.aggregate("nested", "agg")
+	w.edsCluster("leaf")
+	w.sendEndpoints("leaf-eds", resource.EndpointsUpdate{Localities: []resource.Locality{locality(0, 1, healthy("10.0.0.1:80", 1))}}, nil)
+
+	if len(r.errs) != 1 || !strings.Contains(r.errs[0].Error(), "cycle") {
+		t.Fatalf("got errors %v, want a cycle error", r.errs)
+	}
+	want := resource.EndpointsUpdate{Localities: []resource.Locality{locality(0, 1, healthy("10.0.0.1:80", 1))}}
+	if diff := cmp.Diff(want, r.last, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("unexpected merged endpoints (-want +got):\n%s", diff)
+	}
+}
+
+func TestAggregateWatcherLeafRoot(t *testing.T) {
+	w := newFakeClusterWatcher()
+	r := &recordingCallback{}
+	NewAggregateWatcher(w).WatchEndpoints("leaf", r.cb)
+
+	w.edsCluster("leaf")
+	update := resource.EndpointsUpdate{
+		Drops:      []resource.OverloadDropConfig{{Category: "lb", Numerator: 1, Denominator: 100}},
+		Localities: []resource.Locality{locality(0, 1, healthy("10.0.0.1:80", 1))},
+	}
+	w.sendEndpoints("leaf-eds", update, nil)
+	if diff := cmp.Diff(update, r.last, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("unexpected endpoints (-want +got):\n%s", diff)
+	}
+
+	w.sendCluster("leaf", resource.ClusterUpdate{}, resource.NewErrorf(resource.ErrorTypeResourceNotFound, "leaf not found"))
+	if len(r.errs) != 1 || resource.ErrType(r.errs[0]) != resource.ErrorTypeResourceNotFound {
+		t.Fatalf("got errors %v, want the not found error of the root cluster", r.errs)
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"context"
+	"math"
+	"sync"
+)
+
+import (
+	"github.com/dubbogo/gost/log/logger"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/protocol"
+	"dubbo.apache.org/dubbo-go/v3/xds/client"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// CircuitBreaker enforces the max concurrent requests of a cluster, as set by
+// the circuit breaking thresholds of its CDS resource. The requests over the
+// limit fail fast with codes.ResourceExhausted instead of being sent.
+//
+// The in-flight requests are counted with the ones of the xds balancers, so
+// the limit is shared with them.
+type CircuitBreaker struct {
+	clusterName string
+	cancel      func()
+
+	mu          sync.Mutex
+	counter     *client.ClusterRequestsCounter
+	maxRequests *uint32
+}
+
+// NewCircuitBreaker returns a CircuitBreaker for clusterName, watching its CDS
+// resource with w. Until the first update, the requests are not limited. Call
+// Destroy to cancel the watch.
+func NewCircuitBreaker(w ClusterWatcher, clusterName string) *CircuitBreaker {
+	b := &CircuitBreaker{
+		clusterName: clusterName,
+		counter:     client.GetClusterRequestsCounter(clusterName, ""),
+	}
+	b.cancel = w.WatchCluster(clusterName, b.handleUpdate)
+	return b
+}
+
+func (b *CircuitBreaker) handleUpdate(update resource.ClusterUpdate, err error) {
+	if err != nil {
+		if resource.ErrType(err) != resource.ErrorTypeResourceNotFound {
+			logger.Warnf("[XDS CircuitBreaker] cluster %s: keeping the thresholds on error: %v", b.clusterName, err)
+			return
+		}
+		// The cluster is gone, and so are its thresholds.
+		update = resource.ClusterUpdate{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.counter = client.GetClusterRequestsCounter(b.clusterName, update.EDSServiceName)
+	b.maxRequests = update.CircuitBreakers.MaxRequests
+}
+
+// Start starts a request to the cluster. It fails with codes.ResourceExhausted
+// if the max concurrent requests of the cluster are in flight, otherwise done
+// must be called once the request ends.
+func (b *CircuitBreaker) Start() (done func(), err error) {
+	b.mu.Lock()
+	counter, max := b.counter, uint32(math.MaxUint32)
+	if b.maxRequests != nil {
+		max = *b.maxRequests
+	}
+	b.mu.Unlock()
+
+	if err := counter.StartRequest(max); err != nil {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return counter.EndRequest, nil
+}
+
+// Invoke invokes invoker within the limit of the cluster, in the same way as
+// a filter would.
+func (b *CircuitBreaker) Invoke(ctx context.Context, invoker protocol.Invoker, invocation protocol.Invocation) protocol.Result {
+	done, err := b.Start()
+	if err != nil {
+		return &protocol.RPCResult{Err: err}
+	}
+	defer done()
+	return invoker.Invoke(ctx, invocation)
+}
+
+// Destroy cancels the watch of the cluster.
+func (b *CircuitBreaker) Destroy() {
+	b.cancel()
+}
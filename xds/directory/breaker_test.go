@@ -0,0 +1,121 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"testing"
+)
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+func limitedCluster(name string, maxRequests uint32) resource.ClusterUpdate {
+	return resource.ClusterUpdate{
+		ClusterName:     name,
+		EDSServiceName:  name + "-eds",
+		MaxRequests:     &maxRequests,
+		CircuitBreakers: resource.CircuitBreakerThresholds{MaxRequests: &maxRequests},
+	}
+}
+
+// startN starts n requests, returning the done funcs of the started ones and
+// the first error.
+func startN(b *CircuitBreaker, n int) ([]func(), error) {
+	var dones []func()
+	for i := 0; i < n; i++ {
+		done, err := b.Start()
+		if err != nil {
+			return dones, err
+		}
+		dones = append(dones, done)
+	}
+	return dones, nil
+}
+
+func TestCircuitBreakerFailsFastOverMaxRequests(t *testing.T) {
+	defer client.ClearAllCountersForTesting()
+	w := newFakeClusterWatcher()
+	b := NewCircuitBreaker(w, "limited")
+	defer b.Destroy()
+	w.sendCluster("limited", limitedCluster("limited", 2), nil)
+
+	dones, err := startN(b, 2)
+	if err != nil {
+		t.Fatalf("Start() failed under the limit: %v", err)
+	}
+	if _, err := b.Start(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Start() over the limit = %v, want code %v", err, codes.ResourceExhausted)
+	}
+
+	dones[0]()
+	done, err := b.Start()
+	if err != nil {
+		t.Fatalf("Start() failed after a request ended: %v", err)
+	}
+	done()
+	dones[1]()
+}
+
+func TestCircuitBreakerUnlimitedByDefault(t *testing.T) {
+	defer client.ClearAllCountersForTesting()
+	w := newFakeClusterWatcher()
+	b := NewCircuitBreaker(w, "unlimited")
+	defer b.Destroy()
+
+	if _, err := startN(b, 100); err != nil {
+		t.Fatalf("Start() failed before any update: %v", err)
+	}
+	w.sendCluster("unlimited", resource.ClusterUpdate{ClusterName: "unlimited"}, nil)
+	if _, err := startN(b, 100); err != nil {
+		t.Fatalf("Start() failed without thresholds: %v", err)
+	}
+}
+
+func TestCircuitBreakerErrors(t *testing.T) {
+	defer client.ClearAllCountersForTesting()
+	w := newFakeClusterWatcher()
+	b := NewCircuitBreaker(w, "flaky")
+	defer b.Destroy()
+	w.sendCluster("flaky", limitedCluster("flaky", 1), nil)
+
+	w.sendCluster("flaky", resource.ClusterUpdate{}, resource.NewErrorf(resource.ErrorTypeConnection, "connection lost"))
+	if _, err := startN(b, 2); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Start() after a connection error = %v, want the thresholds kept", err)
+	}
+
+	w.sendCluster("flaky", resource.ClusterUpdate{}, resource.NewErrorf(resource.ErrorTypeResourceNotFound, "gone"))
+	if _, err := startN(b, 2); err != nil {
+		t.Fatalf("Start() after the cluster was removed = %v, want no limit", err)
+	}
+}
+
+func TestCircuitBreakerDestroy(t *testing.T) {
+	w := newFakeClusterWatcher()
+	b := NewCircuitBreaker(w, "destroyed")
+	b.Destroy()
+	if n := len(w.cds["destroyed"]); n != 0 {
+		t.Fatalf("got %d watches after Destroy, want 0", n)
+	}
+}
@@ -0,0 +1,236 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package directory bridges the endpoints discovered by EDS to the invokers
+// of a dubbo directory.
+package directory
+
+import (
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+import (
+	"github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
+	"dubbo.apache.org/dubbo-go/v3/protocol"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// EndpointsWatcher is the part of the xDS client used by EndpointsBridge.
+type EndpointsWatcher interface {
+	WatchEndpoints(clusterName string, edsCb func(resource.EndpointsUpdate, error)) (cancel func())
+}
+
+// InvokerListener receives the invokers of the endpoints of a cluster,
+// typically to refresh the invokers of a directory. Its methods are called one
+// at a time, and must not call the methods of the EndpointsBridge.
+type InvokerListener interface {
+	// AddInvokers is called with the invokers of the endpoints which became
+	// usable.
+	AddInvokers(invokers []protocol.Invoker)
+	// RemoveInvokers is called with the invokers of the endpoints which are
+	// not usable anymore. They are destroyed once it returns.
+	RemoveInvokers(invokers []protocol.Invoker)
+}
+
+// InvokerFactory creates the invoker of an endpoint from its URL, e.g. by
+// referring it with a protocol.
+type InvokerFactory func(url *common.URL) (protocol.Invoker, error)
+
+// weightScale is the weight of an invoker getting all the traffic.
+const weightScale = 10000
+
+// EndpointsBridge watches the endpoints of a cluster and notifies an
+// InvokerListener of the invokers to add and remove.
+//
+// The endpoints used and their weights are the ones of FlattenEndpoints, the
+// weight of an invoker being its share of the traffic scaled to weightScale.
+// An endpoint whose weight changes gets a new invoker.
+//
+// When the cluster is not found, all the invokers are removed. The other watch
+// errors keep the current invokers.
+type EndpointsBridge struct {
+	clusterName string
+	template    *common.URL
+	factory     InvokerFactory
+	listener    InvokerListener
+	cancel      func()
+
+	mu        sync.Mutex
+	invokers  map[string]protocol.Invoker // by endpoint address
+	weights   map[string]uint64
+	destroyed bool
+}
+
+// NewEndpointsBridge starts watching the endpoints of clusterName with client.
+// The URLs of the invokers are copies of template, e.g. the URL of the
+// referred service, with the address, weight and cluster of the endpoint.
+//
+// Destroy must be called when the directory fed by listener is destroyed.
+func NewEndpointsBridge(client EndpointsWatcher, clusterName string, template *common.URL,
+	factory InvokerFactory, listener InvokerListener) *EndpointsBridge {
+	b := &EndpointsBridge{
+		clusterName: clusterName,
+		template:    template,
+		factory:     factory,
+		listener:    listener,
+		invokers:    make(map[string]protocol.Invoker),
+		weights:     make(map[string]uint64),
+	}
+	cancel := client.WatchEndpoints(clusterName, b.handleUpdate)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cancel = cancel
+	return b
+}
+
+// Invokers returns the current invokers, sorted by endpoint address.
+func (b *EndpointsBridge) Invokers() []protocol.Invoker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	addrs := make([]string, 0, len(b.invokers))
+	for addr := range b.invokers {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	invokers := make([]protocol.Invoker, 0, len(addrs))
+	for _, addr := range addrs {
+		invokers = append(invokers, b.invokers[addr])
+	}
+	return invokers
+}
+
+// Destroy stops watching the endpoints and removes all the invokers. It's safe
+// to call it more than once.
+func (b *EndpointsBridge) Destroy() {
+	b.mu.Lock()
+	if b.destroyed {
+		b.mu.Unlock()
+		return
+	}
+	b.destroyed = true
+	cancel := b.cancel
+	b.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.applyLocked(nil)
+}
+
+func (b *EndpointsBridge) handleUpdate(update resource.EndpointsUpdate, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.destroyed {
+		return
+	}
+	if err != nil {
+		if resource.ErrType(err) == resource.ErrorTypeResourceNotFound {
+			logger.Warnf("[XDS Directory] cluster %s not found, removing its %d invokers", b.clusterName, len(b.invokers))
+			b.applyLocked(nil)
+			return
+		}
+		logger.Warnf("[XDS Directory] endpoints watch of cluster %s failed, keeping its invokers: %v", b.clusterName, err)
+		return
+	}
+	b.applyLocked(usableEndpoints(update))
+}
+
+// applyLocked replaces the invokers with the ones of weights, the weights of
+// the usable endpoints by address.
+//
+// Caller must hold b.mu.
+func (b *EndpointsBridge) applyLocked(weights map[string]uint64) {
+	var removed []protocol.Invoker
+	for addr, invoker := range b.invokers {
+		if w, ok := weights[addr]; ok && w == b.weights[addr] {
+			continue
+		}
+		removed = append(removed, invoker)
+		delete(b.invokers, addr)
+		delete(b.weights, addr)
+	}
+
+	addrs := make([]string, 0, len(weights))
+	for addr := range weights {
+		if _, ok := b.invokers[addr]; !ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Strings(addrs)
+	var added []protocol.Invoker
+	for _, addr := range addrs {
+		url, err := b.endpointURL(addr, weights[addr])
+		if err != nil {
+			logger.Warnf("[XDS Directory] skipping endpoint %s of cluster %s: %v", addr, b.clusterName, err)
+			continue
+		}
+		invoker, err := b.factory(url)
+		if err != nil {
+			logger.Warnf("[XDS Directory] failed to create the invoker of endpoint %s of cluster %s: %v", addr, b.clusterName, err)
+			continue
+		}
+		b.invokers[addr] = invoker
+		b.weights[addr] = weights[addr]
+		added = append(added, invoker)
+	}
+
+	if len(removed) > 0 {
+		b.listener.RemoveInvokers(removed)
+		for _, invoker := range removed {
+			invoker.Destroy()
+		}
+	}
+	if len(added) > 0 {
+		b.listener.AddInvokers(added)
+	}
+}
+
+// endpointURL returns the URL of the invoker of the endpoint at addr.
+func (b *EndpointsBridge) endpointURL(addr string, weight uint64) (*common.URL, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	url := b.template.Clone()
+	url.Ip = host
+	url.Port = port
+	url.Location = addr
+	url.SetParam(constant.WeightKey, strconv.FormatUint(weight, 10))
+	url.SetParam(constant.MeshClusterIDKey, b.clusterName)
+	return url, nil
+}
+
+// usableEndpoints returns the invoker weights of the usable endpoints of
+// update by address.
+func usableEndpoints(update resource.EndpointsUpdate) map[string]uint64 {
+	weights := make(map[string]uint64)
+	for _, addr := range FlattenEndpoints(update) {
+		weights[addr.Addr] += uint64(max(math.Round(addr.Weight*weightScale), 1))
+	}
+	return weights
+}
@@ -0,0 +1,208 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"errors"
+	"testing"
+)
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/common"
+	"dubbo.apache.org/dubbo-go/v3/common/constant"
+	"dubbo.apache.org/dubbo-go/v3/protocol"
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+type fakeWatcher struct {
+	clusterName string
+	cb          func(resource.EndpointsUpdate, error)
+	canceled    bool
+}
+
+func (w *fakeWatcher) WatchEndpoints(clusterName string, cb func(resource.EndpointsUpdate, error)) func() {
+	w.clusterName = clusterName
+	w.cb = cb
+	return func() { w.canceled = true }
+}
+
+type recordingListener struct {
+	added   []string
+	removed []string
+	live    map[string]protocol.Invoker
+}
+
+func (l *recordingListener) AddInvokers(invokers []protocol.Invoker) {
+	for _, invoker := range invokers {
+		l.added = append(l.added, invokerString(invoker))
+		l.live[invoker.GetURL().Location] = invoker
+	}
+}
+
+func (l *recordingListener) RemoveInvokers(invokers []protocol.Invoker) {
+	for _, invoker := range invokers {
+		l.removed = append(l.removed, invokerString(invoker))
+		delete(l.live, invoker.GetURL().Location)
+	}
+}
+
+func (l *recordingListener) reset() {
+	l.added, l.removed = nil, nil
+}
+
+// invokerString is the address and weight of an invoker, e.g. "1.1.1.1:80/5000".
+func invokerString(invoker protocol.Invoker) string {
+	url := invoker.GetURL()
+	return url.Location + "/" + url.GetParam(constant.WeightKey, "")
+}
+
+func newTestBridge(t *testing.T) (*fakeWatcher, *recordingListener, *EndpointsBridge) {
+	t.Helper()
+	template, err := common.NewURL("tri://127.0.0.1:20000/com.foo.Service?version=1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &fakeWatcher{}
+	l := &recordingListener{live: make(map[string]protocol.Invoker)}
+	factory := func(url *common.URL) (protocol.Invoker, error) {
+		return protocol.NewBaseInvoker(url), nil
+	}
+	return w, l, NewEndpointsBridge(w, "outbound|20000||foo", template, factory, l)
+}
+
+func locality(priority, weight uint32, endpoints ...resource.Endpoint) resource.Locality {
+	return resource.Locality{Priority: priority, Weight: weight, Endpoints: endpoints}
+}
+
+func healthy(addr string, weight uint32) resource.Endpoint {
+	return resource.Endpoint{Address: addr, Weight: weight, HealthStatus: resource.EndpointHealthStatusHealthy}
+}
+
+func TestEndpointsBridgeUpdates(t *testing.T) {
+	w, l, b := newTestBridge(t)
+	if w.clusterName != "outbound|20000||foo" {
+		t.Fatalf("watched cluster %q, want outbound|20000||foo", w.clusterName)
+	}
+
+	w.cb(resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 2, healthy("10.0.0.1:80", 1), healthy("10.0.0.2:80", 3)),
+		locality(0, 2,
+			resource.Endpoint{Address: "10.0.0.3:80", HealthStatus: resource.EndpointHealthStatusUnknown},
+			resource.Endpoint{Address: "10.0.0.4:80", HealthStatus: resource.EndpointHealthStatusUnhealthy},
+			resource.Endpoint{Address: "10.0.0.5:80", HealthStatus: resource.EndpointHealthStatusDraining},
+		),
+	}}, nil)
+	if diff := cmp.Diff([]string{"10.0.0.1:80/1250", "10.0.0.2:80/3750", "10.0.0.3:80/5000"}, l.added); diff != "" {
+		t.Fatalf("unexpected added invokers (-want +got):\n%s", diff)
+	}
+	url := b.Invokers()[0].GetURL()
+	if url.Ip != "10.0.0.1" || url.Port != "80" || url.Path != "/com.foo.Service" ||
+		url.GetParam(constant.VersionKey, "") != "1.0.0" || url.GetParam(constant.MeshClusterIDKey, "") != "outbound|20000||foo" {
+		t.Fatalf("unexpected invoker URL %s", url)
+	}
+
+	// 10.0.0.2 goes unhealthy, 10.0.0.1 changes weight and 10.0.0.3 is kept.
+	l.reset()
+	w.cb(resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 2, healthy("10.0.0.1:80", 2),
+			resource.Endpoint{Address: "10.0.0.2:80", Weight: 3, HealthStatus: resource.EndpointHealthStatusUnhealthy}),
+		locality(0, 2, resource.Endpoint{Address: "10.0.0.3:80", HealthStatus: resource.EndpointHealthStatusUnknown}),
+	}}, nil)
+	if diff := cmp.Diff([]string{"10.0.0.1:80/5000"}, l.added); diff != "" {
+		t.Fatalf("unexpected added invokers (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"10.0.0.1:80/1250", "10.0.0.2:80/3750"}, l.removed, cmpopts.SortSlices(func(a, b string) bool { return a < b })); diff != "" {
+		t.Fatalf("unexpected removed invokers (-want +got):\n%s", diff)
+	}
+	if got := len(b.Invokers()); got != 2 {
+		t.Fatalf("got %d invokers, want 2", got)
+	}
+}
+
+func TestEndpointsBridgePriorities(t *testing.T) {
+	w, l, b := newTestBridge(t)
+
+	w.cb(resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(1, 1, healthy("10.0.1.1:80", 1)),
+		locality(0, 1, resource.Endpoint{Address: "10.0.0.1:80", HealthStatus: resource.EndpointHealthStatusUnhealthy}),
+	}}, nil)
+	if diff := cmp.Diff([]string{"10.0.1.1:80/10000"}, l.added); diff != "" {
+		t.Fatalf("the lower priority should be used when the higher one has no usable endpoint (-want +got):\n%s", diff)
+	}
+
+	l.reset()
+	w.cb(resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(1, 1, healthy("10.0.1.1:80", 1)),
+		locality(0, 1, healthy("10.0.0.1:80", 1)),
+	}}, nil)
+	if diff := cmp.Diff([]string{"10.0.0.1:80/10000"}, l.added); diff != "" {
+		t.Fatalf("unexpected added invokers (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"10.0.1.1:80/10000"}, l.removed); diff != "" {
+		t.Fatalf("unexpected removed invokers (-want +got):\n%s", diff)
+	}
+	if got := len(b.Invokers()); got != 1 {
+		t.Fatalf("got %d invokers, want 1", got)
+	}
+}
+
+func TestEndpointsBridgeErrors(t *testing.T) {
+	w, l, b := newTestBridge(t)
+	w.cb(resource.EndpointsUpdate{Localities: []resource.Locality{locality(0, 1, healthy("10.0.0.1:80", 1))}}, nil)
+	invoker := b.Invokers()[0]
+
+	l.reset()
+	w.cb(resource.EndpointsUpdate{}, errors.New("stream broken"))
+	if len(l.removed) != 0 || len(b.Invokers()) != 1 {
+		t.Fatalf("a watch error removed invokers %v", l.removed)
+	}
+
+	w.cb(resource.EndpointsUpdate{}, resource.NewErrorf(resource.ErrorTypeResourceNotFound, "cluster not found"))
+	if diff := cmp.Diff([]string{"10.0.0.1:80/10000"}, l.removed); diff != "" {
+		t.Fatalf("unexpected removed invokers (-want +got):\n%s", diff)
+	}
+	if !invoker.(*protocol.BaseInvoker).IsDestroyed() {
+		t.Fatal("the removed invoker is not destroyed")
+	}
+}
+
+func TestEndpointsBridgeDestroy(t *testing.T) {
+	w, l, b := newTestBridge(t)
+	w.cb(resource.EndpointsUpdate{Localities: []resource.Locality{locality(0, 1, healthy("10.0.0.1:80", 1), healthy("10.0.0.2:80", 1))}}, nil)
+
+	b.Destroy()
+	if !w.canceled {
+		t.Fatal("Destroy() didn't cancel the endpoints watch")
+	}
+	if len(l.live) != 0 || len(b.Invokers()) != 0 {
+		t.Fatalf("invokers %v left after Destroy()", l.live)
+	}
+
+	// The updates racing with Destroy are ignored.
+	l.reset()
+	w.cb(resource.EndpointsUpdate{Localities: []resource.Locality{locality(0, 1, healthy("10.0.0.3:80", 1))}}, nil)
+	b.Destroy()
+	if len(l.added) != 0 || len(l.removed) != 0 {
+		t.Fatalf("got added %v and removed %v invokers after Destroy()", l.added, l.removed)
+	}
+}
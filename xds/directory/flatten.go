@@ -0,0 +1,131 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"sort"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// WeightedAddr is a usable endpoint of an EDS update with its share of the
+// traffic.
+type WeightedAddr struct {
+	Addr string
+	// Weight is the share of the traffic of the endpoint, in (0, 1]. The
+	// weights of the addresses returned by FlattenEndpoints sum to 1.
+	Weight   float64
+	Locality resource.LocalityID
+	Priority uint32
+}
+
+// FlattenEndpoints returns the usable endpoints of update, sorted by address,
+// weighted like Envoy weights them with locality weighted load balancing.
+//
+// An endpoint is usable if its health status is HEALTHY or UNKNOWN. Only the
+// endpoints of the highest priority with usable endpoints, i.e. the lowest
+// value, are returned, so the lower priorities only get traffic when all the
+// endpoints of the higher ones are unhealthy.
+//
+// The traffic of the priority is split between its localities with usable
+// endpoints in proportion to their weight, and the traffic of a locality
+// between its usable endpoints in proportion to their weight. An endpoint
+// weight of 0 is unset and counts as 1. A locality weight of 0 is unset too:
+// the localities are weighted equally if none of them has a weight, otherwise
+// the ones without get no traffic.
+func FlattenEndpoints(update resource.EndpointsUpdate) []WeightedAddr {
+	var (
+		localities []resource.Locality
+		priority   uint32
+	)
+	for _, l := range update.Localities {
+		if !hasUsableEndpoints(l) {
+			continue
+		}
+		if len(localities) == 0 || l.Priority < priority {
+			localities, priority = nil, l.Priority
+		}
+		if l.Priority == priority {
+			localities = append(localities, l)
+		}
+	}
+
+	weighted := false
+	for _, l := range localities {
+		weighted = weighted || l.Weight > 0
+	}
+	var totalLocalityWeight float64
+	for _, l := range localities {
+		totalLocalityWeight += localityWeight(l, weighted)
+	}
+
+	var addrs []WeightedAddr
+	for _, l := range localities {
+		lw := localityWeight(l, weighted)
+		if lw == 0 {
+			continue
+		}
+		var totalEndpointWeight float64
+		for _, e := range l.Endpoints {
+			if usable(e) {
+				totalEndpointWeight += endpointWeight(e)
+			}
+		}
+		for _, e := range l.Endpoints {
+			if !usable(e) {
+				continue
+			}
+			addrs = append(addrs, WeightedAddr{
+				Addr:     e.Address,
+				Weight:   lw / totalLocalityWeight * endpointWeight(e) / totalEndpointWeight,
+				Locality: l.ID,
+				Priority: l.Priority,
+			})
+		}
+	}
+	sort.SliceStable(addrs, func(i, j int) bool { return addrs[i].Addr < addrs[j].Addr })
+	return addrs
+}
+
+func usable(e resource.Endpoint) bool {
+	return e.HealthStatus == resource.EndpointHealthStatusHealthy || e.HealthStatus == resource.EndpointHealthStatusUnknown
+}
+
+func hasUsableEndpoints(l resource.Locality) bool {
+	for _, e := range l.Endpoints {
+		if usable(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func endpointWeight(e resource.Endpoint) float64 {
+	return float64(max(e.Weight, 1))
+}
+
+// localityWeight returns the weight of l, weighted telling whether any
+// locality of its priority has a weight.
+func localityWeight(l resource.Locality, weighted bool) float64 {
+	if !weighted {
+		return 1
+	}
+	return float64(l.Weight)
+}
@@ -0,0 +1,143 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"testing"
+)
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+func TestFlattenEndpoints(t *testing.T) {
+	zoneA := resource.LocalityID{Zone: "a"}
+	zoneB := resource.LocalityID{Zone: "b"}
+	unhealthy := func(addr string) resource.Endpoint {
+		return resource.Endpoint{Address: addr, Weight: 1, HealthStatus: resource.EndpointHealthStatusUnhealthy}
+	}
+	withID := func(id resource.LocalityID, l resource.Locality) resource.Locality {
+		l.ID = id
+		return l
+	}
+
+	tests := []struct {
+		name   string
+		update resource.EndpointsUpdate
+		want   []WeightedAddr
+	}{
+		{
+			name: "endpoint weights",
+			update: resource.EndpointsUpdate{Localities: []resource.Locality{
+				withID(zoneA, locality(0, 1, healthy("10.0.0.1:80", 1), healthy("10.0.0.2:80", 3))),
+			}},
+			want: []WeightedAddr{
+				{Addr: "10.0.0.1:80", Weight: 0.25, Locality: zoneA},
+				{Addr: "10.0.0.2:80", Weight: 0.75, Locality: zoneA},
+			},
+		},
+		{
+			name: "zero weight endpoints count as 1",
+			update: resource.EndpointsUpdate{Localities: []resource.Locality{
+				withID(zoneA, locality(0, 1, healthy("10.0.0.1:80", 0), healthy("10.0.0.2:80", 0), healthy("10.0.0.3:80", 2))),
+			}},
+			want: []WeightedAddr{
+				{Addr: "10.0.0.1:80", Weight: 0.25, Locality: zoneA},
+				{Addr: "10.0.0.2:80", Weight: 0.25, Locality: zoneA},
+				{Addr: "10.0.0.3:80", Weight: 0.5, Locality: zoneA},
+			},
+		},
+		{
+			name: "locality weights",
+			update: resource.EndpointsUpdate{Localities: []resource.Locality{
+				withID(zoneA, locality(0, 3, healthy("10.0.0.1:80", 1), healthy("10.0.0.2:80", 1), unhealthy("10.0.0.3:80"))),
+				withID(zoneB, locality(0, 1, healthy("10.0.1.1:80", 5))),
+			}},
+			want: []WeightedAddr{
+				{Addr: "10.0.0.1:80", Weight: 0.375, Locality: zoneA},
+				{Addr: "10.0.0.2:80", Weight: 0.375, Locality: zoneA},
+				{Addr: "10.0.1.1:80", Weight: 0.25, Locality: zoneB},
+			},
+		},
+		{
+			name: "unweighted localities are weighted equally",
+			update: resource.EndpointsUpdate{Localities: []resource.Locality{
+				withID(zoneA, locality(0, 0, healthy("10.0.0.1:80", 1), healthy("10.0.0.2:80", 1))),
+				withID(zoneB, locality(0, 0, healthy("10.0.1.1:80", 1))),
+			}},
+			want: []WeightedAddr{
+				{Addr: "10.0.0.1:80", Weight: 0.25, Locality: zoneA},
+				{Addr: "10.0.0.2:80", Weight: 0.25, Locality: zoneA},
+				{Addr: "10.0.1.1:80", Weight: 0.5, Locality: zoneB},
+			},
+		},
+		{
+			name: "zero weight locality among weighted ones",
+			update: resource.EndpointsUpdate{Localities: []resource.Locality{
+				withID(zoneA, locality(0, 2, healthy("10.0.0.1:80", 1))),
+				withID(zoneB, locality(0, 0, healthy("10.0.1.1:80", 1))),
+			}},
+			want: []WeightedAddr{
+				{Addr: "10.0.0.1:80", Weight: 1, Locality: zoneA},
+			},
+		},
+		{
+			name: "priority failover",
+			update: resource.EndpointsUpdate{Localities: []resource.Locality{
+				withID(zoneA, locality(0, 1, unhealthy("10.0.0.1:80"), resource.Endpoint{
+					Address: "10.0.0.2:80", HealthStatus: resource.EndpointHealthStatusDraining,
+				})),
+				withID(zoneB, locality(1, 1, healthy("10.0.1.1:80", 1))),
+				withID(zoneA, locality(2, 1, healthy("10.0.2.1:80", 1))),
+			}},
+			want: []WeightedAddr{
+				{Addr: "10.0.1.1:80", Weight: 1, Locality: zoneB, Priority: 1},
+			},
+		},
+		{
+			name: "higher priority preferred",
+			update: resource.EndpointsUpdate{Localities: []resource.Locality{
+				withID(zoneB, locality(1, 1, healthy("10.0.1.1:80", 1))),
+				withID(zoneA, locality(0, 1, healthy("10.0.0.1:80", 1), unhealthy("10.0.0.2:80"))),
+			}},
+			want: []WeightedAddr{
+				{Addr: "10.0.0.1:80", Weight: 1, Locality: zoneA},
+			},
+		},
+		{
+			name: "no usable endpoints",
+			update: resource.EndpointsUpdate{Localities: []resource.Locality{
+				withID(zoneA, locality(0, 1, unhealthy("10.0.0.1:80"))),
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := FlattenEndpoints(test.update)
+			if diff := cmp.Diff(test.want, got, cmpopts.EquateEmpty(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Fatalf("FlattenEndpoints() returned unexpected addresses (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"sync"
+)
+
+import (
+	"github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// DefaultPanicThreshold is the default panic threshold of HealthFilter, in
+// percent, the same as Envoy's.
+const DefaultPanicThreshold = 50
+
+// HealthFilterOption configures a HealthFilter.
+type HealthFilterOption func(*HealthFilter)
+
+// WithUnknownHealthy makes the HealthFilter keep the endpoints whose health
+// status is UNKNOWN, e.g. when the control plane doesn't health check them.
+func WithUnknownHealthy() HealthFilterOption {
+	return func(f *HealthFilter) {
+		f.unknownHealthy = true
+	}
+}
+
+// WithPanicThreshold sets the panic threshold of the HealthFilter, in
+// percent. A threshold of 0 disables the panic mode.
+func WithPanicThreshold(percent float64) HealthFilterOption {
+	return func(f *HealthFilter) {
+		f.panicThreshold = percent
+	}
+}
+
+// HealthFilter is an EndpointsWatcher presenting only the healthy endpoints
+// in the endpoints updates, the HEALTHY ones and optionally the UNKNOWN ones.
+// It can be given to NewEndpointsBridge in place of the xDS client.
+//
+// Like Envoy, when the healthy endpoints fall under the panic threshold
+// percent of all the endpoints of an update, the filter enters the panic
+// mode: all the endpoints are presented as healthy, on the basis that sending
+// the traffic to all of them is better than overloading the few healthy ones.
+type HealthFilter struct {
+	client         EndpointsWatcher
+	unknownHealthy bool
+	panicThreshold float64
+}
+
+// NewHealthFilter returns a HealthFilter using client, with the
+// DefaultPanicThreshold unless set by opts.
+func NewHealthFilter(client EndpointsWatcher, opts ...HealthFilterOption) *HealthFilter {
+	f := &HealthFilter{
+		client:         client,
+		panicThreshold: DefaultPanicThreshold,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// WatchEndpoints watches the endpoints of clusterName, filtering the
+// endpoints updates. The errors are passed through.
+func (f *HealthFilter) WatchEndpoints(clusterName string, edsCb func(resource.EndpointsUpdate, error)) (cancel func()) {
+	w := &healthWatch{filter: f, clusterName: clusterName, cb: edsCb}
+	return f.client.WatchEndpoints(clusterName, w.handleEndpoints)
+}
+
+// healthy reports whether the endpoint ep is kept outside of the panic mode.
+func (f *HealthFilter) healthy(ep resource.Endpoint) bool {
+	switch ep.HealthStatus {
+	case resource.EndpointHealthStatusHealthy:
+		return true
+	case resource.EndpointHealthStatusUnknown:
+		return f.unknownHealthy
+	default:
+		return false
+	}
+}
+
+// healthWatch is an endpoints watch of HealthFilter.
+type healthWatch struct {
+	filter      *HealthFilter
+	clusterName string
+	cb          func(resource.EndpointsUpdate, error)
+
+	mu        sync.Mutex
+	panicking bool
+}
+
+func (w *healthWatch) handleEndpoints(update resource.EndpointsUpdate, err error) {
+	if err != nil {
+		w.cb(update, err)
+		return
+	}
+
+	total, healthy := 0, 0
+	for _, l := range update.Localities {
+		for _, ep := range l.Endpoints {
+			total++
+			if w.filter.healthy(ep) {
+				healthy++
+			}
+		}
+	}
+	panicking := total > 0 && float64(healthy*100) < w.filter.panicThreshold*float64(total)
+
+	w.mu.Lock()
+	if panicking != w.panicking {
+		if panicking {
+			logger.Warnf("[XDS HealthFilter] cluster %s: entering panic mode with %d healthy endpoints out of %d", w.clusterName, healthy, total)
+		} else {
+			logger.Infof("[XDS HealthFilter] cluster %s: leaving panic mode with %d healthy endpoints out of %d", w.clusterName, healthy, total)
+		}
+		w.panicking = panicking
+	}
+	w.mu.Unlock()
+
+	filtered := update
+	filtered.Localities = make([]resource.Locality, len(update.Localities))
+	for i, l := range update.Localities {
+		endpoints := make([]resource.Endpoint, 0, len(l.Endpoints))
+		for _, ep := range l.Endpoints {
+			if panicking {
+				ep.HealthStatus = resource.EndpointHealthStatusHealthy
+			} else if !w.filter.healthy(ep) {
+				continue
+			}
+			endpoints = append(endpoints, ep)
+		}
+		l.Endpoints = endpoints
+		filtered.Localities[i] = l
+	}
+	w.cb(filtered, nil)
+}
@@ -0,0 +1,168 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"errors"
+	"testing"
+)
+
+import (
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+func withHealth(addr string, status resource.EndpointHealthStatus) resource.Endpoint {
+	return resource.Endpoint{Address: addr, Weight: 1, HealthStatus: status}
+}
+
+func TestHealthFilter(t *testing.T) {
+	update := resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1,
+			healthy("10.0.0.1:80", 1),
+			healthy("10.0.0.2:80", 1),
+			withHealth("10.0.0.3:80", resource.EndpointHealthStatusUnknown),
+		),
+		locality(0, 1,
+			healthy("10.0.1.1:80", 1),
+			withHealth("10.0.1.2:80", resource.EndpointHealthStatusUnhealthy),
+			withHealth("10.0.1.3:80", resource.EndpointHealthStatusDraining),
+		),
+	}}
+
+	tests := []struct {
+		name string
+		opts []HealthFilterOption
+		want resource.EndpointsUpdate
+	}{
+		{
+			name: "healthy only",
+			want: resource.EndpointsUpdate{Localities: []resource.Locality{
+				locality(0, 1, healthy("10.0.0.1:80", 1), healthy("10.0.0.2:80", 1)),
+				locality(0, 1, healthy("10.0.1.1:80", 1)),
+			}},
+		},
+		{
+			name: "unknown healthy",
+			opts: []HealthFilterOption{WithUnknownHealthy()},
+			want: resource.EndpointsUpdate{Localities: []resource.Locality{
+				locality(0, 1,
+					healthy("10.0.0.1:80", 1),
+					healthy("10.0.0.2:80", 1),
+					withHealth("10.0.0.3:80", resource.EndpointHealthStatusUnknown),
+				),
+				locality(0, 1, healthy("10.0.1.1:80", 1)),
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := &fakeWatcher{}
+			r := &recordingCallback{}
+			cancel := NewHealthFilter(w, test.opts...).WatchEndpoints("c", r.cb)
+			if w.clusterName != "c" {
+				t.Fatalf("watched cluster %q, want c", w.clusterName)
+			}
+
+			w.cb(update, nil)
+			if diff := cmp.Diff(test.want, r.last, cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("HealthFilter sent unexpected update (-want +got):\n%s", diff)
+			}
+			if got := update.Localities[1].Endpoints[1].HealthStatus; got != resource.EndpointHealthStatusUnhealthy {
+				t.Fatalf("HealthFilter modified the health status of the original update to %v", got)
+			}
+
+			cancel()
+			if !w.canceled {
+				t.Fatal("canceling the HealthFilter watch did not cancel the underlying watch")
+			}
+		})
+	}
+}
+
+func TestHealthFilterPanic(t *testing.T) {
+	w := &fakeWatcher{}
+	r := &recordingCallback{}
+	NewHealthFilter(w, WithPanicThreshold(50)).WatchEndpoints("c", r.cb)
+
+	// 1 healthy endpoint out of 3 is under the threshold: all are presented.
+	w.cb(resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1,
+			healthy("10.0.0.1:80", 1),
+			withHealth("10.0.0.2:80", resource.EndpointHealthStatusUnhealthy),
+			withHealth("10.0.0.3:80", resource.EndpointHealthStatusTimeout),
+		),
+	}}, nil)
+	want := []WeightedAddr{
+		{Addr: "10.0.0.1:80", Weight: 1.0 / 3},
+		{Addr: "10.0.0.2:80", Weight: 1.0 / 3},
+		{Addr: "10.0.0.3:80", Weight: 1.0 / 3},
+	}
+	if diff := cmp.Diff(want, FlattenEndpoints(r.last), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Fatalf("HealthFilter in panic mode sent unexpected endpoints (-want +got):\n%s", diff)
+	}
+
+	// 2 healthy endpoints out of 3 are over the threshold: back to filtering.
+	w.cb(resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1,
+			healthy("10.0.0.1:80", 1),
+			healthy("10.0.0.2:80", 1),
+			withHealth("10.0.0.3:80", resource.EndpointHealthStatusTimeout),
+		),
+	}}, nil)
+	want = []WeightedAddr{
+		{Addr: "10.0.0.1:80", Weight: 0.5},
+		{Addr: "10.0.0.2:80", Weight: 0.5},
+	}
+	if diff := cmp.Diff(want, FlattenEndpoints(r.last), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Fatalf("HealthFilter out of panic mode sent unexpected endpoints (-want +got):\n%s", diff)
+	}
+}
+
+func TestHealthFilterPanicDisabled(t *testing.T) {
+	w := &fakeWatcher{}
+	r := &recordingCallback{}
+	NewHealthFilter(w, WithPanicThreshold(0)).WatchEndpoints("c", r.cb)
+
+	w.cb(resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1, withHealth("10.0.0.1:80", resource.EndpointHealthStatusUnhealthy)),
+	}}, nil)
+	if got := FlattenEndpoints(r.last); len(got) != 0 {
+		t.Fatalf("HealthFilter without panic mode sent endpoints %v, want none", got)
+	}
+}
+
+func TestHealthFilterError(t *testing.T) {
+	w := &fakeWatcher{}
+	r := &recordingCallback{}
+	NewHealthFilter(w).WatchEndpoints("c", r.cb)
+
+	errWatch := errors.New("watch failed")
+	w.cb(resource.EndpointsUpdate{}, errWatch)
+	if len(r.errs) != 1 || r.errs[0] != errWatch {
+		t.Fatalf("HealthFilter passed errors %v, want [%v]", r.errs, errWatch)
+	}
+	if r.updates != 0 {
+		t.Fatalf("HealthFilter sent %d updates on error, want 0", r.updates)
+	}
+}
@@ -0,0 +1,301 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"sync"
+	"time"
+)
+
+import (
+	"github.com/dubbogo/gost/log/logger"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+// OutlierDetector is an EndpointsWatcher ejecting for a while the endpoints
+// which fail consecutively, per the outlier detection of the CDS resource of
+// the watched cluster. The ejected endpoints are reported as unhealthy in the
+// endpoints updates, so that the EndpointsBridge removes their invokers until
+// they are re-admitted. It can be given to NewEndpointsBridge in place of the
+// xDS client.
+//
+// The outcome of the requests to the endpoints must be given to ReportResult.
+type OutlierDetector struct {
+	client    ClusterWatcher
+	endpoints EndpointsWatcher
+	now       func() time.Time
+
+	mu      sync.Mutex
+	watches map[string][]*outlierWatch // by cluster name
+}
+
+// NewOutlierDetector returns an OutlierDetector using client. The endpoints
+// are watched with an AggregateWatcher.
+func NewOutlierDetector(client ClusterWatcher) *OutlierDetector {
+	return &OutlierDetector{
+		client:    client,
+		endpoints: NewAggregateWatcher(client),
+		now:       time.Now,
+		watches:   make(map[string][]*outlierWatch),
+	}
+}
+
+// WatchEndpoints watches the endpoints of clusterName, and its CDS resource
+// for the outlier detection config. Without outlier detection, the endpoints
+// updates are passed through.
+func (d *OutlierDetector) WatchEndpoints(clusterName string, edsCb func(resource.EndpointsUpdate, error)) (cancel func()) {
+	w := &outlierWatch{
+		detector:    d,
+		clusterName: clusterName,
+		cb:          edsCb,
+		hosts:       make(map[string]*hostStats),
+	}
+	d.mu.Lock()
+	d.watches[clusterName] = append(d.watches[clusterName], w)
+	d.mu.Unlock()
+
+	w.mu.Lock()
+	w.cancelCDS = d.client.WatchCluster(clusterName, w.handleCluster)
+	w.cancelEDS = d.endpoints.WatchEndpoints(clusterName, w.handleEndpoints)
+	w.mu.Unlock()
+	return func() {
+		d.mu.Lock()
+		watches := d.watches[clusterName]
+		for i, other := range watches {
+			if other == w {
+				d.watches[clusterName] = append(watches[:i:i], watches[i+1:]...)
+				break
+			}
+		}
+		if len(d.watches[clusterName]) == 0 {
+			delete(d.watches, clusterName)
+		}
+		d.mu.Unlock()
+		w.cancel()
+	}
+}
+
+// ReportResult reports the outcome of a request to the endpoint addr of
+// clusterName: err is nil if it succeeded. The endpoint is ejected once it
+// failed the consecutive 5xx of the outlier detection in a row, unless the
+// max ejection percent of the cluster is reached.
+func (d *OutlierDetector) ReportResult(clusterName, addr string, err error) {
+	d.mu.Lock()
+	watches := append([]*outlierWatch(nil), d.watches[clusterName]...)
+	d.mu.Unlock()
+	for _, w := range watches {
+		w.reportResult(addr, err)
+	}
+}
+
+// hostStats are the outlier detection stats of an endpoint.
+type hostStats struct {
+	consecutiveErrors uint32
+	// ejections is the number of times the host has been ejected in a row,
+	// multiplying its ejection time. It's decremented at each sweep the host
+	// isn't ejected.
+	ejections uint32
+	ejectedAt time.Time // zero if not ejected
+}
+
+func (h *hostStats) ejected() bool {
+	return !h.ejectedAt.IsZero()
+}
+
+// outlierWatch is an endpoints watch of OutlierDetector.
+type outlierWatch struct {
+	detector    *OutlierDetector
+	clusterName string
+	cb          func(resource.EndpointsUpdate, error)
+
+	mu        sync.Mutex
+	cancelCDS func()
+	cancelEDS func()
+	canceled  bool
+	config    *resource.OutlierDetection
+	timer     *time.Timer
+	update    *resource.EndpointsUpdate // nil until received
+	hosts     map[string]*hostStats     // by address, of the endpoints of update
+}
+
+func (w *outlierWatch) cancel() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.canceled {
+		return
+	}
+	w.canceled = true
+	w.cancelCDS()
+	w.cancelEDS()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+func (w *outlierWatch) handleCluster(update resource.ClusterUpdate, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.canceled {
+		return
+	}
+	if err != nil {
+		if resource.ErrType(err) != resource.ErrorTypeResourceNotFound {
+			logger.Warnf("[XDS OutlierDetector] cluster %s: keeping the outlier detection on error: %v", w.clusterName, err)
+			return
+		}
+		update = resource.ClusterUpdate{}
+	}
+
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.config = update.OutlierDetection
+	if w.config == nil {
+		// Without outlier detection, all the hosts are re-admitted.
+		readmitted := false
+		for _, h := range w.hosts {
+			readmitted = readmitted || h.ejected()
+			*h = hostStats{}
+		}
+		if readmitted {
+			w.sendLocked()
+		}
+		return
+	}
+	w.timer = time.AfterFunc(w.config.Interval, w.sweep)
+}
+
+func (w *outlierWatch) handleEndpoints(update resource.EndpointsUpdate, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.canceled {
+		return
+	}
+	if err != nil {
+		w.cb(update, err)
+		return
+	}
+
+	hosts := make(map[string]*hostStats)
+	for _, l := range update.Localities {
+		for _, ep := range l.Endpoints {
+			if h, ok := w.hosts[ep.Address]; ok {
+				hosts[ep.Address] = h
+			} else {
+				hosts[ep.Address] = &hostStats{}
+			}
+		}
+	}
+	w.hosts = hosts
+	w.update = &update
+	w.sendLocked()
+}
+
+func (w *outlierWatch) reportResult(addr string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	h, ok := w.hosts[addr]
+	if w.canceled || w.config == nil || !ok || h.ejected() {
+		return
+	}
+	if err == nil {
+		h.consecutiveErrors = 0
+		return
+	}
+	h.consecutiveErrors++
+	if h.consecutiveErrors < w.config.Consecutive5xx {
+		return
+	}
+
+	ejected := 0
+	for _, other := range w.hosts {
+		if other.ejected() {
+			ejected++
+		}
+	}
+	// Like Envoy, a host is ejected as long as the ejected ones are under the
+	// max percent, so that one of them can always be ejected.
+	if uint32(ejected*100) >= uint32(len(w.hosts))*w.config.MaxEjectionPercent {
+		return
+	}
+	h.consecutiveErrors = 0
+	h.ejections++
+	h.ejectedAt = w.detector.now()
+	logger.Infof("[XDS OutlierDetector] cluster %s: ejecting %s after %d consecutive errors", w.clusterName, addr, w.config.Consecutive5xx)
+	w.sendLocked()
+}
+
+// sweep re-admits the hosts whose ejection time is over, and schedules the
+// next sweep.
+func (w *outlierWatch) sweep() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.canceled || w.config == nil {
+		return
+	}
+
+	now := w.detector.now()
+	readmitted := false
+	for addr, h := range w.hosts {
+		if !h.ejected() {
+			if h.ejections > 0 {
+				h.ejections--
+			}
+			continue
+		}
+		if now.Before(h.ejectedAt.Add(w.config.BaseEjectionTime * time.Duration(h.ejections))) {
+			continue
+		}
+		h.ejectedAt = time.Time{}
+		h.consecutiveErrors = 0
+		readmitted = true
+		logger.Infof("[XDS OutlierDetector] cluster %s: re-admitting %s", w.clusterName, addr)
+	}
+	if readmitted {
+		w.sendLocked()
+	}
+	if w.timer != nil {
+		w.timer.Reset(w.config.Interval)
+	}
+}
+
+// sendLocked sends the last endpoints update, with the ejected endpoints
+// reported as unhealthy.
+//
+// Caller must hold w.mu.
+func (w *outlierWatch) sendLocked() {
+	if w.update == nil {
+		return
+	}
+	update := *w.update
+	update.Localities = make([]resource.Locality, len(w.update.Localities))
+	for i, l := range w.update.Localities {
+		l.Endpoints = append([]resource.Endpoint(nil), l.Endpoints...)
+		for j, ep := range l.Endpoints {
+			if w.hosts[ep.Address].ejected() {
+				l.Endpoints[j].HealthStatus = resource.EndpointHealthStatusUnhealthy
+			}
+		}
+		update.Localities[i] = l
+	}
+	w.cb(update, nil)
+}
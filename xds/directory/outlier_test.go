@@ -0,0 +1,222 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package directory
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/google/go-cmp/cmp"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
+)
+
+var errRequest = errors.New("request failed")
+
+// outlierTest is an OutlierDetector watching cluster "c", with a fake clock.
+type outlierTest struct {
+	w   *fakeClusterWatcher
+	d   *OutlierDetector
+	r   *recordingCallback
+	now time.Time
+}
+
+func newOutlierTest(t *testing.T, od *resource.OutlierDetection, addrs ...string) *outlierTest {
+	t.Helper()
+	ot := &outlierTest{
+		w:   newFakeClusterWatcher(),
+		r:   &recordingCallback{},
+		now: time.Unix(1000, 0),
+	}
+	ot.d = NewOutlierDetector(ot.w)
+	ot.d.now = func() time.Time { return ot.now }
+	t.Cleanup(ot.d.WatchEndpoints("c", ot.r.cb))
+
+	ot.setOutlierDetection(od)
+	var endpoints []resource.Endpoint
+	for _, addr := range addrs {
+		endpoints = append(endpoints, healthy(addr, 1))
+	}
+	ot.w.sendEndpoints("c-eds", resource.EndpointsUpdate{Localities: []resource.Locality{locality(0, 1, endpoints...)}}, nil)
+	return ot
+}
+
+func (ot *outlierTest) setOutlierDetection(od *resource.OutlierDetection) {
+	ot.w.sendCluster("c", resource.ClusterUpdate{
+		ClusterName:      "c",
+		ClusterType:      resource.ClusterTypeEDS,
+		EDSServiceName:   "c-eds",
+		OutlierDetection: od,
+	}, nil)
+}
+
+func (ot *outlierTest) fail(addr string, n int) {
+	for i := 0; i < n; i++ {
+		ot.d.ReportResult("c", addr, errRequest)
+	}
+}
+
+// sweepAt runs the sweep of the watch at now + d.
+func (ot *outlierTest) sweepAt(d time.Duration) {
+	ot.now = ot.now.Add(d)
+	for _, w := range ot.d.watches["c"] {
+		w.sweep()
+	}
+}
+
+// ejected returns the addresses reported unhealthy in the last update.
+func (ot *outlierTest) ejected() []string {
+	var addrs []string
+	for _, l := range ot.r.last.Localities {
+		for _, ep := range l.Endpoints {
+			if ep.HealthStatus == resource.EndpointHealthStatusUnhealthy {
+				addrs = append(addrs, ep.Address)
+			}
+		}
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+func (ot *outlierTest) checkEjected(t *testing.T, want ...string) {
+	t.Helper()
+	if diff := cmp.Diff(want, ot.ejected()); diff != "" {
+		t.Fatalf("ejected endpoints diff (-want +got):\n%s", diff)
+	}
+}
+
+func testOutlierDetection() *resource.OutlierDetection {
+	return &resource.OutlierDetection{
+		Consecutive5xx:     3,
+		Interval:           time.Hour, // the sweeps are run by the tests
+		BaseEjectionTime:   30 * time.Second,
+		MaxEjectionPercent: 50,
+	}
+}
+
+func TestOutlierDetectorEjectsAfterConsecutiveErrors(t *testing.T) {
+	ot := newOutlierTest(t, testOutlierDetection(), "10.0.0.1:80", "10.0.0.2:80")
+	if ot.r.updates != 1 {
+		t.Fatalf("got %d updates, want 1", ot.r.updates)
+	}
+
+	ot.fail("10.0.0.1:80", 2)
+	ot.d.ReportResult("c", "10.0.0.1:80", nil)
+	ot.fail("10.0.0.1:80", 2)
+	ot.checkEjected(t)
+	if ot.r.updates != 1 {
+		t.Fatalf("got %d updates before any ejection, want 1", ot.r.updates)
+	}
+
+	ot.fail("10.0.0.1:80", 1)
+	ot.checkEjected(t, "10.0.0.1:80")
+	if ot.r.updates != 2 {
+		t.Fatalf("got %d updates after the ejection, want 2", ot.r.updates)
+	}
+
+	// The errors of an ejected host, or of an unknown one, are ignored.
+	ot.fail("10.0.0.1:80", 3)
+	ot.fail("10.9.9.9:80", 3)
+	if ot.r.updates != 2 {
+		t.Fatalf("got %d updates, want 2", ot.r.updates)
+	}
+}
+
+func TestOutlierDetectorReadmission(t *testing.T) {
+	ot := newOutlierTest(t, testOutlierDetection(), "10.0.0.1:80", "10.0.0.2:80")
+
+	ot.fail("10.0.0.1:80", 3)
+	ot.checkEjected(t, "10.0.0.1:80")
+	ot.sweepAt(29 * time.Second)
+	ot.checkEjected(t, "10.0.0.1:80")
+	ot.sweepAt(time.Second)
+	ot.checkEjected(t)
+
+	// Ejected again right away, the host is ejected twice as long.
+	ot.fail("10.0.0.1:80", 3)
+	ot.checkEjected(t, "10.0.0.1:80")
+	ot.sweepAt(59 * time.Second)
+	ot.checkEjected(t, "10.0.0.1:80")
+	ot.sweepAt(time.Second)
+	ot.checkEjected(t)
+
+	// The multiplier decreases at each sweep the host isn't ejected.
+	ot.sweepAt(time.Hour)
+	ot.fail("10.0.0.1:80", 3)
+	ot.sweepAt(59 * time.Second)
+	ot.checkEjected(t, "10.0.0.1:80")
+	ot.sweepAt(time.Second)
+	ot.checkEjected(t)
+}
+
+func TestOutlierDetectorMaxEjectionPercent(t *testing.T) {
+	ot := newOutlierTest(t, testOutlierDetection(), "10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80", "10.0.0.4:80")
+
+	ot.fail("10.0.0.1:80", 3)
+	ot.fail("10.0.0.2:80", 3)
+	ot.fail("10.0.0.3:80", 3)
+	ot.checkEjected(t, "10.0.0.1:80", "10.0.0.2:80")
+
+	// Once a host is re-admitted, another one can be ejected.
+	ot.sweepAt(30 * time.Second)
+	ot.checkEjected(t)
+	ot.fail("10.0.0.3:80", 3)
+	ot.checkEjected(t, "10.0.0.3:80")
+}
+
+func TestOutlierDetectorEndpointsUpdates(t *testing.T) {
+	ot := newOutlierTest(t, testOutlierDetection(), "10.0.0.1:80", "10.0.0.2:80")
+	ot.fail("10.0.0.1:80", 3)
+
+	// An ejected host stays ejected across the updates.
+	ot.w.sendEndpoints("c-eds", resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1, healthy("10.0.0.1:80", 1), healthy("10.0.0.3:80", 1)),
+	}}, nil)
+	ot.checkEjected(t, "10.0.0.1:80")
+
+	// A removed host loses its stats.
+	ot.w.sendEndpoints("c-eds", resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1, healthy("10.0.0.3:80", 1)),
+	}}, nil)
+	ot.w.sendEndpoints("c-eds", resource.EndpointsUpdate{Localities: []resource.Locality{
+		locality(0, 1, healthy("10.0.0.1:80", 1), healthy("10.0.0.3:80", 1)),
+	}}, nil)
+	ot.checkEjected(t)
+}
+
+func TestOutlierDetectorDisabled(t *testing.T) {
+	ot := newOutlierTest(t, nil, "10.0.0.1:80", "10.0.0.2:80")
+	ot.fail("10.0.0.1:80", 10)
+	ot.checkEjected(t)
+	if ot.r.updates != 1 {
+		t.Fatalf("got %d updates, want 1", ot.r.updates)
+	}
+
+	// Enabled then disabled, the ejected hosts are re-admitted.
+	ot.setOutlierDetection(testOutlierDetection())
+	ot.fail("10.0.0.1:80", 3)
+	ot.checkEjected(t, "10.0.0.1:80")
+	ot.setOutlierDetection(nil)
+	ot.checkEjected(t)
+}
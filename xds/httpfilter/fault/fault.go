@@ -122,6 +122,16 @@ func (builder) IsTerminal() bool {
 var _ httpfilter.ClientInterceptorBuilder = builder{}
 
 func (builder) BuildClientInterceptor(cfg, override httpfilter.FilterConfig) (iresolver.ClientInterceptor, error) {
+	icfg, err := faultConfig(cfg, override)
+	if icfg == nil || err != nil {
+		return nil, err
+	}
+	return &interceptor{config: icfg}, nil
+}
+
+// faultConfig returns the fault config of the listener config cfg, replaced
+// by override if not nil. It returns nil if no fault is configured.
+func faultConfig(cfg, override httpfilter.FilterConfig) (*fpb.HTTPFault, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("fault: nil config provided")
 	}
@@ -145,7 +155,35 @@ func (builder) BuildClientInterceptor(cfg, override httpfilter.FilterConfig) (ir
 		(icfg.GetDelay() == nil && icfg.GetAbort() == nil) {
 		return nil, nil
 	}
-	return &interceptor{config: icfg}, nil
+	return icfg, nil
+}
+
+// Injector injects the faults of a fault filter config in invocations which
+// are not gRPC streams, e.g. the dubbo invocations of the xDS directory.
+type Injector struct {
+	config *fpb.HTTPFault
+}
+
+// NewInjector returns an Injector for the fault filter config cfg of the
+// listener, completely replaced by override if not nil. The override is the
+// one of the filter in the weighted cluster, or else in the route, or else in
+// the virtual host, like for the gRPC interceptor. It returns nil if no fault
+// is configured.
+func NewInjector(cfg, override httpfilter.FilterConfig) (*Injector, error) {
+	icfg, err := faultConfig(cfg, override)
+	if icfg == nil || err != nil {
+		return nil, err
+	}
+	return &Injector{config: icfg}, nil
+}
+
+// Inject injects the configured faults in an invocation with ctx: it waits
+// for the delay, if any, then returns the status error of the abort, if any.
+// An abort with the OK status returns ErrAbortedOK, the invocation must then
+// be answered with an empty successful result. It returns nil if the
+// invocation should proceed.
+func (i *Injector) Inject(ctx context.Context) error {
+	return inject(ctx, i.config)
 }
 
 type interceptor struct {
@@ -155,25 +193,30 @@ type interceptor struct {
 var activeFaults uint32 // global active faults; accessed atomically
 
 func (i *interceptor) NewStream(ctx context.Context, ri iresolver.RPCInfo, done func(), newStream func(ctx context.Context, done func()) (iresolver.ClientStream, error)) (iresolver.ClientStream, error) {
-	if maxAF := i.config.GetMaxActiveFaults(); maxAF != nil {
+	if err := inject(ctx, i.config); err != nil {
+		if err == ErrAbortedOK {
+			return &okStream{ctx: ctx}, nil
+		}
+		return nil, err
+	}
+	return newStream(ctx, done)
+}
+
+// inject injects the delay then the abort of config, unless the max active
+// faults is reached.
+func inject(ctx context.Context, config *fpb.HTTPFault) error {
+	if maxAF := config.GetMaxActiveFaults(); maxAF != nil {
 		defer atomic.AddUint32(&activeFaults, ^uint32(0)) // decrement counter
 		if af := atomic.AddUint32(&activeFaults, 1); af > maxAF.GetValue() {
 			// Would exceed maximum active fault limit.
-			return newStream(ctx, done)
+			return nil
 		}
 	}
 
-	if err := injectDelay(ctx, i.config.GetDelay()); err != nil {
-		return nil, err
+	if err := injectDelay(ctx, config.GetDelay()); err != nil {
+		return err
 	}
-
-	if err := injectAbort(ctx, i.config.GetAbort()); err != nil {
-		if err == errOKStream {
-			return &okStream{ctx: ctx}, nil
-		}
-		return nil, err
-	}
-	return newStream(ctx, done)
+	return injectAbort(ctx, config.GetAbort())
 }
 
 // For overriding in tests
@@ -249,12 +292,14 @@ func injectAbort(ctx context.Context, abortCfg *fpb.FaultAbort) error {
 		return nil
 	}
 	if code == codes.OK {
-		return errOKStream
+		return ErrAbortedOK
 	}
 	return status.Errorf(code, "RPC terminated due to fault injection")
 }
 
-var errOKStream = errors.New("stream terminated early with OK status")
+// ErrAbortedOK is returned by Injector.Inject when the invocation is aborted
+// with the OK status.
+var ErrAbortedOK = errors.New("stream terminated early with OK status")
 
 // parseIntFromMD returns the integer in the last header or nil if parsing
 // failed.
@@ -0,0 +1,167 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fault
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+import (
+	cpb "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/common/fault/v3"
+	fpb "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
+	tpb "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+import (
+	"dubbo.apache.org/dubbo-go/v3/xds/httpfilter"
+	iresolver "dubbo.apache.org/dubbo-go/v3/xds/utils/resolver"
+)
+
+func percent(p uint32) *tpb.FractionalPercent {
+	return &tpb.FractionalPercent{Numerator: p, Denominator: tpb.FractionalPercent_HUNDRED}
+}
+
+func grpcAbort(code codes.Code, p uint32) *fpb.FaultAbort {
+	return &fpb.FaultAbort{ErrorType: &fpb.FaultAbort_GrpcStatus{GrpcStatus: uint32(code)}, Percentage: percent(p)}
+}
+
+func parse(t *testing.T, fault *fpb.HTTPFault) httpfilter.FilterConfig {
+	t.Helper()
+	any, err := anypb.New(fault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := builder{}.ParseFilterConfig(any)
+	if err != nil {
+		t.Fatalf("ParseFilterConfig() failed: %v", err)
+	}
+	return cfg
+}
+
+func newTestInjector(t *testing.T, cfg, override *fpb.HTTPFault) *Injector {
+	t.Helper()
+	var overrideCfg httpfilter.FilterConfig
+	if override != nil {
+		overrideCfg = parse(t, override)
+	}
+	i, err := NewInjector(parse(t, cfg), overrideCfg)
+	if err != nil {
+		t.Fatalf("NewInjector() failed: %v", err)
+	}
+	return i
+}
+
+func TestInjectorDelay(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	i := newTestInjector(t, &fpb.HTTPFault{Delay: &cpb.FaultDelay{
+		FaultDelaySecifier: &cpb.FaultDelay_FixedDelay{FixedDelay: durationpb.New(delay)},
+		Percentage:         percent(100),
+	}}, nil)
+
+	start := time.Now()
+	if err := i.Inject(context.Background()); err != nil {
+		t.Fatalf("Inject() = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("Inject() returned after %v, want a delay of %v", elapsed, delay)
+	}
+
+	// The delay stops with the context of the invocation.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := i.Inject(ctx); err != context.Canceled {
+		t.Errorf("Inject() with a canceled context = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestInjectorAbort(t *testing.T) {
+	tests := []struct {
+		name  string
+		abort *fpb.FaultAbort
+		want  codes.Code
+	}{
+		{
+			name:  "grpc status",
+			abort: grpcAbort(codes.Unavailable, 100),
+			want:  codes.Unavailable,
+		},
+		{
+			name:  "http status",
+			abort: &fpb.FaultAbort{ErrorType: &fpb.FaultAbort_HttpStatus{HttpStatus: 404}, Percentage: percent(100)},
+			want:  codes.Unimplemented,
+		},
+		{
+			name:  "zero percent",
+			abort: grpcAbort(codes.Unavailable, 0),
+			want:  codes.OK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			i := newTestInjector(t, &fpb.HTTPFault{Abort: test.abort}, nil)
+			if got := status.Code(i.Inject(context.Background())); got != test.want {
+				t.Errorf("Inject() code = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	i := newTestInjector(t, &fpb.HTTPFault{Abort: grpcAbort(codes.OK, 100)}, nil)
+	if err := i.Inject(context.Background()); err != ErrAbortedOK {
+		t.Errorf("Inject() with an OK abort = %v, want %v", err, ErrAbortedOK)
+	}
+}
+
+func TestInjectorOverride(t *testing.T) {
+	listener := &fpb.HTTPFault{Abort: grpcAbort(codes.Unavailable, 100)}
+
+	i := newTestInjector(t, listener, &fpb.HTTPFault{Abort: grpcAbort(codes.PermissionDenied, 100)})
+	if got := status.Code(i.Inject(context.Background())); got != codes.PermissionDenied {
+		t.Errorf("Inject() code = %v, want the one of the override %v", got, codes.PermissionDenied)
+	}
+
+	// An override without fault disables the listener faults.
+	if i := newTestInjector(t, listener, &fpb.HTTPFault{}); i != nil {
+		t.Errorf("NewInjector() with an empty override = %+v, want nil", i)
+	}
+	if _, err := NewInjector(nil, nil); err == nil {
+		t.Error("NewInjector() without config succeeded")
+	}
+}
+
+func TestInterceptorAbort(t *testing.T) {
+	i, err := builder{}.BuildClientInterceptor(parse(t, &fpb.HTTPFault{Abort: grpcAbort(codes.Unavailable, 100)}), nil)
+	if err != nil {
+		t.Fatalf("BuildClientInterceptor() failed: %v", err)
+	}
+	newStream := func(context.Context, func()) (iresolver.ClientStream, error) {
+		t.Fatal("the stream was created despite the abort")
+		return nil, nil
+	}
+	_, err = i.NewStream(context.Background(), iresolver.RPCInfo{}, func() {}, newStream)
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("NewStream() = %v, want code %v", err, codes.Unavailable)
+	}
+}
@@ -48,6 +48,7 @@ import (
 	"dubbo.apache.org/dubbo-go/v3/xds/balancer/ringhash"
 	"dubbo.apache.org/dubbo-go/v3/xds/client/resource"
 	"dubbo.apache.org/dubbo-go/v3/xds/httpfilter"
+	_ "dubbo.apache.org/dubbo-go/v3/xds/httpfilter/fault"
 	"dubbo.apache.org/dubbo-go/v3/xds/httpfilter/router"
 	"dubbo.apache.org/dubbo-go/v3/xds/utils/envconfig"
 	"dubbo.apache.org/dubbo-go/v3/xds/utils/grpcrand"
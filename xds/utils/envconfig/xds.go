@@ -48,6 +48,7 @@ const (
 	rbacSupportEnv               = "GRPC_XDS_EXPERIMENTAL_RBAC"
 	federationEnv                = "GRPC_EXPERIMENTAL_XDS_FEDERATION"
 	rlsInXDSEnv                  = "GRPC_EXPERIMENTAL_XDS_RLS_LB"
+	bootstrapNodeEnvStrictEnv    = "GRPC_XDS_BOOTSTRAP_NODE_ENV_STRICT"
 
 	c2pResolverTestOnlyTrafficDirectorURIEnv = "GRPC_TEST_ONLY_GOOGLE_C2P_RESOLVER_TRAFFIC_DIRECTOR_URI"
 )
@@ -77,11 +78,11 @@ var (
 	// the new API to get security integration on the server.
 	XDSClientSideSecurity = false // !strings.EqualFold(os.Getenv(clientSideSecuritySupportEnv), "true")
 	// XDSAggregateAndDNS indicates whether processing of aggregated cluster
-	// and DNS cluster is enabled, which can be enabled by setting the
+	// and DNS cluster is enabled, which can be disabled by setting the
 	// environment variable
 	// "GRPC_XDS_EXPERIMENTAL_ENABLE_AGGREGATE_AND_LOGICAL_DNS_CLUSTER" to
-	// "true".
-	XDSAggregateAndDNS = strings.EqualFold(os.Getenv(aggregateAndDNSSupportEnv), "true")
+	// "false".
+	XDSAggregateAndDNS = !strings.EqualFold(os.Getenv(aggregateAndDNSSupportEnv), "false")
 
 	// XDSRBAC indicates whether xDS configured RBAC HTTP Filter is enabled,
 	// which can be disabled by setting the environment variable
@@ -97,6 +98,13 @@ var (
 	// "true".
 	XDSRLS = strings.EqualFold(os.Getenv(rlsInXDSEnv), "true")
 
+	// XDSBootstrapNodeEnvStrict indicates whether a bootstrap fails to load
+	// when its node id or cluster references an unset environment variable,
+	// instead of substituting an empty string. It can be disabled by setting
+	// the environment variable "GRPC_XDS_BOOTSTRAP_NODE_ENV_STRICT" to
+	// "false".
+	XDSBootstrapNodeEnvStrict = !strings.EqualFold(os.Getenv(bootstrapNodeEnvStrictEnv), "false")
+
 	// C2PResolverTestOnlyTrafficDirectorURI is the TD URI for testing.
 	C2PResolverTestOnlyTrafficDirectorURI = os.Getenv(c2pResolverTestOnlyTrafficDirectorURIEnv)
 )
@@ -89,3 +89,18 @@ func FormatJSON(b []byte) string {
 	}
 	return out.String()
 }
+
+// Lazy returns e as a fmt.Stringer which marshals it with ToJSON only when it's
+// formatted. Debug logs take it instead of ToJSON(e), so that nothing is
+// marshaled when the debug level is disabled.
+func Lazy(e any) fmt.Stringer {
+	return lazyJSON{e: e}
+}
+
+type lazyJSON struct {
+	e any
+}
+
+func (l lazyJSON) String() string {
+	return ToJSON(l.e)
+}
@@ -24,6 +24,7 @@
 package xds_cache
 
 import (
+	"math/rand"
 	"sync"
 	"time"
 )
@@ -44,7 +45,9 @@ type cacheEntry struct {
 type TimeoutCache struct {
 	mu      sync.Mutex
 	timeout time.Duration
-	cache   map[any]*cacheEntry
+	// jitter spreads the timeouts of the items over [timeout, timeout*(1+jitter)).
+	jitter float64
+	cache  map[any]*cacheEntry
 }
 
 // NewTimeoutCache creates a TimeoutCache with the given timeout.
@@ -55,6 +58,26 @@ func NewTimeoutCache(timeout time.Duration) *TimeoutCache {
 	}
 }
 
+// NewTimeoutCacheWithJitter creates a TimeoutCache deleting each item after
+// the given timeout plus a random part of up to jitter times the timeout, so
+// that the items added at the same time are not all deleted at the same time.
+// A jitter that is not positive disables it.
+func NewTimeoutCacheWithJitter(timeout time.Duration, jitter float64) *TimeoutCache {
+	c := NewTimeoutCache(timeout)
+	if jitter > 0 {
+		c.jitter = jitter
+	}
+	return c
+}
+
+// itemTimeout returns the timeout of an item being added.
+func (c *TimeoutCache) itemTimeout() time.Duration {
+	if c.jitter == 0 {
+		return c.timeout
+	}
+	return c.timeout + time.Duration(rand.Float64()*c.jitter*float64(c.timeout))
+}
+
 // Add adds an item to the cache, with the specified callback to be called when
 // the item is removed from the cache upon timeout. If the item is removed from
 // the cache using a call to Remove before the timeout expires, the callback
@@ -74,7 +97,7 @@ func (c *TimeoutCache) Add(key, item any, callback func()) (any, bool) {
 		item:     item,
 		callback: callback,
 	}
-	entry.timer = time.AfterFunc(c.timeout, func() {
+	entry.timer = time.AfterFunc(c.itemTimeout(), func() {
 		c.mu.Lock()
 		if entry.deleted {
 			c.mu.Unlock()
@@ -126,6 +149,17 @@ func (c *TimeoutCache) removeInternal(key any) (*cacheEntry, bool) {
 	return entry, true
 }
 
+// Items returns a snapshot of the items in the cache.
+func (c *TimeoutCache) Items() []any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	items := make([]any, 0, len(c.cache))
+	for _, e := range c.cache {
+		items = append(items, e.item)
+	}
+	return items
+}
+
 // Clear removes all entries, and runs the callbacks if runCallback is true.
 func (c *TimeoutCache) Clear(runCallback bool) {
 	var entries []*cacheEntry
@@ -0,0 +1,94 @@
+/*
+ * Licensed to the Apache Software Foundation (ASF) under one or more
+ * contributor license agreements.  See the NOTICE file distributed with
+ * this work for additional information regarding copyright ownership.
+ * The ASF licenses this file to You under the Apache License, Version 2.0
+ * (the "License"); you may not use this file except in compliance with
+ * the License.  You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xds_cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestItemTimeoutJitter(t *testing.T) {
+	const timeout = time.Second
+	c := NewTimeoutCacheWithJitter(timeout, 0.5)
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		d := c.itemTimeout()
+		if d < timeout || d >= timeout*3/2 {
+			t.Fatalf("itemTimeout() = %v, want in [%v, %v)", d, timeout, timeout*3/2)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("itemTimeout() returned %v every time, want the timeouts spread", c.itemTimeout())
+	}
+
+	for _, jitter := range []float64{0, -1} {
+		c := NewTimeoutCacheWithJitter(timeout, jitter)
+		if d := c.itemTimeout(); d != timeout {
+			t.Fatalf("itemTimeout() with jitter %v = %v, want %v", jitter, d, timeout)
+		}
+	}
+}
+
+func TestJitteredTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	c := NewTimeoutCacheWithJitter(timeout, 1)
+	deleted := make(chan time.Time, 1)
+	start := time.Now()
+	c.Add("k", "v", func() { deleted <- time.Now() })
+
+	select {
+	case at := <-deleted:
+		if d := at.Sub(start); d < timeout {
+			t.Fatalf("item deleted after %v, want at least %v", d, timeout)
+		}
+	case <-time.After(10 * timeout):
+		t.Fatal("timeout waiting for the item to be deleted")
+	}
+	if items := c.Items(); len(items) != 0 {
+		t.Fatalf("Items() = %v after the timeout, want none", items)
+	}
+}
+
+// TestRemoveBeforeJitteredTimeout verifies that an item removed before its
+// jittered timeout, e.g. an idle authority revived, is never deleted.
+func TestRemoveBeforeJitteredTimeout(t *testing.T) {
+	const timeout = 20 * time.Millisecond
+	c := NewTimeoutCacheWithJitter(timeout, 1)
+	deleted := make(chan struct{}, 1)
+	c.Add("k", "v", func() { deleted <- struct{}{} })
+
+	if item, ok := c.Remove("k"); !ok || item != "v" {
+		t.Fatalf("Remove() = %v, %v, want v, true", item, ok)
+	}
+	select {
+	case <-deleted:
+		t.Fatal("the callback of a removed item is called")
+	case <-time.After(5 * timeout):
+	}
+
+	// The key can be added again, with a new timer.
+	if _, ok := c.Add("k", "v2", func() { deleted <- struct{}{} }); !ok {
+		t.Fatal("Add() after Remove() didn't add the item")
+	}
+	select {
+	case <-deleted:
+	case <-time.After(50 * timeout):
+		t.Fatal("timeout waiting for the item added again to be deleted")
+	}
+}